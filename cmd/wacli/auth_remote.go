@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mdp/qrterminal/v3"
+	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/out"
+)
+
+// remoteReinitAfter is how long a service may sit unauthenticated without a
+// QR code before pairing is started again, e.g. after the codes expired.
+const remoteReinitAfter = 15 * time.Second
+
+func newAuthRemoteCmd(flags *rootFlags) *cobra.Command {
+	var wait time.Duration
+	var interval time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "remote",
+		Short: "Pair a running wasvc service by scanning its QR code in this terminal",
+		Long:  "Pair the wasvc service at --server (default http://localhost:8080) by driving its /auth endpoints and printing each QR code in this terminal until it is linked.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			ctx, cancel := context.WithTimeout(ctx, wait)
+			defer cancel()
+
+			if flags.server == "" {
+				flags.server = "http://localhost:8080"
+			}
+			c := remoteAuthClient{newRemoteClient(flags)}
+			status, err := c.pair(ctx, interval)
+			if err != nil {
+				return err
+			}
+
+			if flags.asJSON {
+				return out.WriteJSON(os.Stdout, map[string]any{
+					"authenticated": true,
+					"state":         status.State,
+				})
+			}
+			fmt.Fprintf(os.Stdout, "Authenticated. %s is %s.\n", c.base, status.State)
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&wait, "wait", 5*time.Minute, "give up if not paired within this time")
+	cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "how often to poll the service")
+
+	return cmd
+}
+
+// remoteAuthStatus mirrors the service's GET /auth/status response.
+type remoteAuthStatus struct {
+	State         string `json:"state"`
+	Authenticated bool   `json:"authenticated"`
+	HasQR         bool   `json:"has_qr"`
+	Error         string `json:"error,omitempty"`
+}
+
+// remoteQRCode mirrors the service's GET /auth/qr response.
+type remoteQRCode struct {
+	QRCode string `json:"qr_code,omitempty"`
+	State  string `json:"state"`
+}
+
+// remoteAuthClient drives the pairing endpoints of a running service.
+type remoteAuthClient struct {
+	*remoteClient
+}
+
+// pair starts authentication on the service and prints each new QR code
+// until the service reports it is authenticated.
+func (c remoteAuthClient) pair(ctx context.Context, interval time.Duration) (remoteAuthStatus, error) {
+	var status remoteAuthStatus
+	if _, err := c.do(ctx, http.MethodGet, "/auth/status", nil, &status); err != nil {
+		return status, err
+	}
+	if status.Authenticated {
+		return status, nil
+	}
+	if err := c.init(ctx); err != nil {
+		return status, err
+	}
+	fmt.Fprintf(os.Stderr, "Waiting for a QR code from %s…\n", c.base)
+
+	lastInit := time.Now()
+	lastQR := ""
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return status, fmt.Errorf("not paired in time (state: %s)", status.State)
+			}
+			return status, ctx.Err()
+		case <-ticker.C:
+		}
+
+		if _, err := c.do(ctx, http.MethodGet, "/auth/status", nil, &status); err != nil {
+			return status, err
+		}
+		switch {
+		case status.Authenticated:
+			return status, nil
+		case status.State == "error":
+			return status, fmt.Errorf("pairing failed: %s", status.Error)
+		case status.HasQR:
+			var qr remoteQRCode
+			if _, err := c.do(ctx, http.MethodGet, "/auth/qr", nil, &qr); err != nil {
+				return status, err
+			}
+			if qr.QRCode != "" && qr.QRCode != lastQR {
+				lastQR = qr.QRCode
+				fmt.Fprintln(os.Stderr, "\nScan this QR code with WhatsApp (Linked Devices):")
+				qrterminal.GenerateHalfBlock(qr.QRCode, qrterminal.M, os.Stderr)
+				fmt.Fprintln(os.Stderr)
+			}
+		case status.State == "unauthenticated" && time.Since(lastInit) > remoteReinitAfter:
+			if err := c.init(ctx); err != nil {
+				return status, err
+			}
+			lastInit = time.Now()
+		}
+	}
+}
+
+// init calls POST /auth/init. A service that became authenticated in the
+// meantime is not an error.
+func (c remoteAuthClient) init(ctx context.Context) error {
+	_, err := c.do(ctx, http.MethodPost, "/auth/init", struct{}{}, nil)
+	var apiErr *remoteError
+	if errors.As(err, &apiErr) && apiErr.Code == "ALREADY_AUTHENTICATED" {
+		return nil
+	}
+	return err
+}
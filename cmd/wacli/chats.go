@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
+	"strconv"
 	"text/tabwriter"
 	"time"
 
@@ -31,6 +34,10 @@ func newChatsListCmd(flags *rootFlags) *cobra.Command {
 			ctx, cancel := withTimeout(context.Background(), flags)
 			defer cancel()
 
+			if flags.server != "" {
+				return listChatsRemote(ctx, flags, query, limit)
+			}
+
 			a, lk, err := newApp(ctx, flags, false, false)
 			if err != nil {
 				return err
@@ -45,16 +52,11 @@ func newChatsListCmd(flags *rootFlags) *cobra.Command {
 				return out.WriteJSON(os.Stdout, chats)
 			}
 
-			w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
-			fmt.Fprintln(w, "KIND\tNAME\tJID\tLAST")
-			for _, c := range chats {
-				name := c.Name
-				if name == "" {
-					name = c.JID
-				}
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Kind, truncate(name, 28), c.JID, c.LastMessageTS.Local().Format("2006-01-02 15:04:05"))
+			rows := make([]chatRow, len(chats))
+			for i, c := range chats {
+				rows[i] = chatRow{JID: c.JID, Kind: c.Kind, Name: c.Name, LastMessageTS: c.LastMessageTS}
 			}
-			_ = w.Flush()
+			printChats(rows)
 			return nil
 		},
 	}
@@ -75,6 +77,10 @@ func newChatsShowCmd(flags *rootFlags) *cobra.Command {
 			ctx, cancel := withTimeout(context.Background(), flags)
 			defer cancel()
 
+			if flags.server != "" {
+				return showChatRemote(ctx, flags, jid)
+			}
+
 			a, lk, err := newApp(ctx, flags, false, false)
 			if err != nil {
 				return err
@@ -95,3 +101,62 @@ func newChatsShowCmd(flags *rootFlags) *cobra.Command {
 	cmd.Flags().StringVar(&jid, "jid", "", "chat JID")
 	return cmd
 }
+
+// chatRow is a chat as the chats table prints it. It also decodes chats
+// from the service's API.
+type chatRow struct {
+	JID           string    `json:"jid"`
+	Kind          string    `json:"kind"`
+	Name          string    `json:"name"`
+	LastMessageTS time.Time `json:"last_message_ts,omitempty"`
+}
+
+func printChats(chats []chatRow) {
+	w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tJID\tLAST")
+	for _, c := range chats {
+		name := c.Name
+		if name == "" {
+			name = c.JID
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Kind, truncate(name, 28), c.JID, c.LastMessageTS.Local().Format("2006-01-02 15:04:05"))
+	}
+	_ = w.Flush()
+}
+
+func listChatsRemote(ctx context.Context, flags *rootFlags, query string, limit int) error {
+	var resp struct {
+		Chats []chatRow `json:"chats"`
+	}
+	return runRemote(ctx, flags, http.MethodGet,
+		"/chats"+remoteQuery("q", query, "limit", strconv.Itoa(limit)), nil, &resp, func() {
+			printChats(resp.Chats)
+		})
+}
+
+// showChatRemote finds the chat through the chat search; the API has no
+// single-chat lookup.
+func showChatRemote(ctx context.Context, flags *rootFlags, jid string) error {
+	var resp struct {
+		Chats []json.RawMessage `json:"chats"`
+	}
+	if _, err := newRemoteClient(flags).do(ctx, http.MethodGet,
+		"/chats"+remoteQuery("q", jid, "limit", "200"), nil, &resp); err != nil {
+		return err
+	}
+	for _, raw := range resp.Chats {
+		var c chatRow
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return err
+		}
+		if c.JID != jid {
+			continue
+		}
+		if flags.asJSON {
+			return out.WriteJSON(os.Stdout, raw)
+		}
+		fmt.Fprintf(os.Stdout, "JID: %s\nKind: %s\nName: %s\nLast: %s\n", c.JID, c.Kind, c.Name, c.LastMessageTS.Local().Format(time.RFC3339))
+		return nil
+	}
+	return fmt.Errorf("chat not found: %s", jid)
+}
@@ -16,31 +16,36 @@ import (
 
 func newDoctorCmd(flags *rootFlags) *cobra.Command {
 	var connect bool
+	var report bool
+	var reportOpts doctorReportOptions
 
 	cmd := &cobra.Command{
 		Use:   "doctor",
 		Short: "Diagnostics for store/auth/search",
+		Long: "Print a quick diagnosis of the local store, lock, authentication and search index.\n" +
+			"With --report, write a redacted zip bundle for bug reports instead: settings, schema and integrity check,\n" +
+			"lock status, connectivity to WhatsApp, and the tail of --log-file. With --server, the running service's\n" +
+			"diagnostics are included in place of the local store.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx, cancel := withTimeout(context.Background(), flags)
 			defer cancel()
 
+			if flags.server != "" && !report {
+				return errRemoteUnsupported
+			}
+
 			storeDir := flags.storeDir
 			if storeDir == "" {
 				storeDir = config.DefaultStoreDir()
 			}
 			storeDir, _ = filepath.Abs(storeDir)
 
-			var lockHeld bool
-			var lockInfo string
-			if b, err := os.ReadFile(filepath.Join(storeDir, "LOCK")); err == nil {
-				lockInfo = strings.TrimSpace(string(b))
-			}
-			if lk, err := lock.Acquire(storeDir); err == nil {
-				_ = lk.Release()
-			} else {
-				lockHeld = true
+			if report {
+				return writeDoctorReport(ctx, flags, storeDir, reportOpts)
 			}
 
+			lockHeld, lockInfo := storeLockState(storeDir)
+
 			a, lk, err := newApp(ctx, flags, connect, true)
 			if err != nil {
 				return err
@@ -99,5 +104,22 @@ func newDoctorCmd(flags *rootFlags) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&connect, "connect", false, "try connecting to WhatsApp (requires store lock)")
+	cmd.Flags().BoolVar(&report, "report", false, "write a redacted diagnostics bundle for bug reports")
+	cmd.Flags().StringVar(&reportOpts.output, "output", "", "bundle path for --report (default wacli-report-<time>.zip)")
+	cmd.Flags().StringVar(&reportOpts.logFile, "log-file", "", "log file whose tail is included in the --report bundle")
+	cmd.Flags().IntVar(&reportOpts.logLines, "log-lines", 200, "how many lines of --log-file to include")
 	return cmd
 }
+
+// storeLockState reports whether another process holds the store lock, and
+// what the lock file says about it.
+func storeLockState(storeDir string) (held bool, info string) {
+	if b, err := os.ReadFile(filepath.Join(storeDir, "LOCK")); err == nil {
+		info = strings.TrimSpace(string(b))
+	}
+	if lk, err := lock.Acquire(storeDir); err == nil {
+		_ = lk.Release()
+		return false, info
+	}
+	return true, info
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/diag"
+	"github.com/steipete/wacli/internal/out"
+	"github.com/steipete/wacli/internal/service"
+)
+
+// probeTimeout bounds each connectivity check of a report.
+const probeTimeout = 10 * time.Second
+
+// whatsappEndpoints are checked for reachability in a report.
+var whatsappEndpoints = []struct{ name, address string }{
+	{"whatsapp-web", "web.whatsapp.com:443"},
+	{"whatsapp-media", "mmg.whatsapp.net:443"},
+}
+
+type doctorReportOptions struct {
+	output   string
+	logFile  string
+	logLines int
+}
+
+// writeDoctorReport collects diagnostics into a redacted zip bundle. A
+// section that cannot be collected is noted in the report rather than
+// failing it, since a report is most needed when things are broken.
+func writeDoctorReport(ctx context.Context, flags *rootFlags, storeDir string, opts doctorReportOptions) error {
+	now := time.Now()
+	if opts.output == "" {
+		opts.output = "wacli-report-" + now.Format("20060102-150405") + ".zip"
+	}
+
+	rep := diag.Report{
+		GeneratedAt: now.UTC(),
+		Version:     version,
+		GoVersion:   runtime.Version(),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+	}
+	fail := func(section string, err error) {
+		rep.Errors = append(rep.Errors, fmt.Sprintf("%s: %v", section, err))
+	}
+
+	settings, err := service.Settings()
+	if err != nil {
+		fail("settings", err)
+		settings = map[string]string{}
+	}
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok && (strings.HasPrefix(k, "WACLI_") || k == "WA_DEBUG") {
+			settings[k] = v
+		}
+	}
+	settings["WACLI_STORE"] = storeDir
+	if flags.server != "" {
+		settings["WACLI_SERVER"] = flags.server
+	}
+	rep.Settings = diag.RedactSettings(settings)
+
+	// Whatever was redacted from the settings must not show up in the log either.
+	var secrets []string
+	for k, v := range settings {
+		if rep.Settings[k] != v {
+			secrets = append(secrets, v)
+		}
+	}
+	secrets = append(secrets, flags.apiKey)
+	redactor := diag.NewRedactor(secrets)
+
+	if flags.server != "" {
+		c := newRemoteClient(flags)
+		probe := diag.Probe{Name: "server", Address: diag.RedactValue("WACLI_SERVER", c.base)}
+		start := time.Now()
+		raw, err := c.do(ctx, http.MethodGet, "/doctor", nil, nil)
+		if err != nil {
+			probe.Error = redactor.Line(err.Error())
+		} else {
+			probe.OK = true
+			probe.LatencyMS = time.Since(start).Milliseconds()
+			rep.Server = raw
+		}
+		rep.Connectivity = append(rep.Connectivity, probe)
+	} else {
+		held, info := storeLockState(storeDir)
+		rep.Lock = &diag.Lock{Held: held, Info: info}
+		if st, err := collectStoreDiagnostics(ctx, flags, storeDir); err != nil {
+			fail("store", err)
+		} else {
+			rep.Store = st
+		}
+	}
+
+	for _, e := range whatsappEndpoints {
+		rep.Connectivity = append(rep.Connectivity, diag.ProbeTLS(ctx, e.name, e.address, probeTimeout))
+	}
+
+	var logLines []string
+	if opts.logFile != "" {
+		lines, err := diag.Tail(opts.logFile, opts.logLines)
+		if err != nil {
+			fail("log", err)
+		}
+		for _, l := range lines {
+			logLines = append(logLines, redactor.Line(l))
+		}
+		rep.LogFile = opts.logFile
+	}
+
+	f, err := os.OpenFile(opts.output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if err := diag.WriteBundle(f, rep, logLines); err != nil {
+		_ = f.Close()
+		_ = os.Remove(opts.output)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if flags.asJSON {
+		return out.WriteJSON(os.Stdout, map[string]any{
+			"output": opts.output,
+			"errors": rep.Errors,
+		})
+	}
+	fmt.Fprintf(os.Stdout, "Wrote %s\n", opts.output)
+	for _, e := range rep.Errors {
+		fmt.Fprintf(os.Stdout, "  could not collect %s\n", e)
+	}
+	fmt.Fprintln(os.Stdout, "Secrets and phone numbers are redacted; review the bundle before attaching it to a bug report.")
+	return nil
+}
+
+func collectStoreDiagnostics(ctx context.Context, flags *rootFlags, storeDir string) (*diag.Store, error) {
+	a, lk, err := newApp(ctx, flags, false, true)
+	if err != nil {
+		return nil, err
+	}
+	defer closeApp(a, lk)
+
+	db := a.DB()
+	st := &diag.Store{Dir: storeDir, FTSEnabled: db.HasFTS()}
+	if st.Schema, err = db.SchemaInfo(); err != nil {
+		return nil, err
+	}
+	problems, err := db.IntegrityCheck()
+	switch {
+	case err != nil:
+		st.Integrity = []string{"check failed: " + err.Error()}
+	case len(problems) == 0:
+		st.Integrity = []string{"ok"}
+	default:
+		st.Integrity = problems
+	}
+	st.Messages, _ = db.CountMessages()
+	st.Chats, _ = db.CountChats()
+	st.Contacts, _ = db.CountContacts()
+	st.Groups, _ = db.CountGroups()
+	if err := a.OpenWA(); err == nil {
+		st.Authenticated = a.WA().IsAuthed()
+	}
+	return st, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/export"
+	"github.com/steipete/wacli/internal/out"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+func newExportCmd(flags *rootFlags) *cobra.Command {
+	var chat string
+	var format string
+	var withMedia bool
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export a chat as a txt, JSON or HTML transcript, optionally with its media",
+		Long: "Export every stored message of a chat, oldest first, into a directory (or a .zip archive when --output ends in .zip).\n" +
+			"The transcript is chat.txt, chat.json or chat.html; with --with-media, downloaded attachments are copied to media/ and linked from it.\n" +
+			"Only media already downloaded (wacli media download, or sync --download-media) can be included.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if chat == "" {
+				return fmt.Errorf("--chat is required")
+			}
+			if !export.ValidFormat(format) {
+				return fmt.Errorf("--format must be txt, json or html")
+			}
+			jid, err := wa.ParseUserOrJID(chat)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				output = "chat-" + jid.User
+			}
+
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+
+			a, lk, err := newApp(ctx, flags, false, false)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+
+			c, err := a.DB().GetChat(jid.String())
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("chat not found: %s", jid)
+			}
+			if err != nil {
+				return err
+			}
+
+			var sink export.Sink
+			var zipFile *os.File
+			if strings.HasSuffix(strings.ToLower(output), ".zip") {
+				zipFile, err = os.Create(output)
+				if err != nil {
+					return err
+				}
+				defer zipFile.Close()
+				sink = export.NewZipSink(zipFile)
+			} else if sink, err = export.NewDirSink(output); err != nil {
+				return err
+			}
+
+			res, err := export.Write(sink, a.DB(), c, export.Options{Format: format, WithMedia: withMedia})
+			if cerr := sink.Close(); err == nil {
+				err = cerr
+			}
+			if zipFile != nil {
+				if cerr := zipFile.Close(); err == nil {
+					err = cerr
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			if flags.asJSON {
+				return out.WriteJSON(os.Stdout, map[string]any{
+					"chat_jid":      c.JID,
+					"output":        output,
+					"transcript":    res.Transcript,
+					"messages":      res.Messages,
+					"media":         res.Media,
+					"missing_media": res.MissingMedia,
+				})
+			}
+			fmt.Fprintf(os.Stdout, "Exported %d messages and %d attachments to %s\n", res.Messages, res.Media, output)
+			if res.MissingMedia > 0 {
+				fmt.Fprintf(os.Stderr, "warning: %d downloaded attachments were missing on disk and left out\n", res.MissingMedia)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&chat, "chat", "", "chat to export (phone number or JID)")
+	cmd.Flags().StringVar(&format, "format", export.FormatTXT, "transcript format: txt, json or html")
+	cmd.Flags().BoolVar(&withMedia, "with-media", false, "include downloaded attachments")
+	cmd.Flags().StringVar(&output, "output", "", "output directory, or a .zip file (default chat-<number>)")
+	return cmd
+}
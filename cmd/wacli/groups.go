@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
@@ -39,6 +41,15 @@ func newGroupsRefreshCmd(flags *rootFlags) *cobra.Command {
 			ctx, cancel := withTimeout(context.Background(), flags)
 			defer cancel()
 
+			if flags.server != "" {
+				var resp struct {
+					GroupsImported int `json:"groups_imported"`
+				}
+				return runRemote(ctx, flags, http.MethodPost, "/groups/refresh", struct{}{}, &resp, func() {
+					fmt.Fprintf(os.Stdout, "Imported %d groups.\n", resp.GroupsImported)
+				})
+			}
+
 			a, lk, err := newApp(ctx, flags, true, false)
 			if err != nil {
 				return err
@@ -84,6 +95,16 @@ func newGroupsListCmd(flags *rootFlags) *cobra.Command {
 			ctx, cancel := withTimeout(context.Background(), flags)
 			defer cancel()
 
+			if flags.server != "" {
+				var resp struct {
+					Groups []groupRow `json:"groups"`
+				}
+				return runRemote(ctx, flags, http.MethodGet,
+					"/groups"+remoteQuery("q", query, "limit", strconv.Itoa(limit)), nil, &resp, func() {
+						printGroups(resp.Groups)
+					})
+			}
+
 			a, lk, err := newApp(ctx, flags, false, false)
 			if err != nil {
 				return err
@@ -98,16 +119,11 @@ func newGroupsListCmd(flags *rootFlags) *cobra.Command {
 				return out.WriteJSON(os.Stdout, gs)
 			}
 
-			w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
-			fmt.Fprintln(w, "NAME\tJID\tCREATED")
-			for _, g := range gs {
-				name := g.Name
-				if name == "" {
-					name = g.JID
-				}
-				fmt.Fprintf(w, "%s\t%s\t%s\n", truncate(name, 40), g.JID, g.CreatedAt.Local().Format("2006-01-02"))
+			rows := make([]groupRow, len(gs))
+			for i, g := range gs {
+				rows[i] = groupRow{JID: g.JID, Name: g.Name, CreatedAt: g.CreatedAt}
 			}
-			_ = w.Flush()
+			printGroups(rows)
 			return nil
 		},
 	}
@@ -128,6 +144,18 @@ func newGroupsInfoCmd(flags *rootFlags) *cobra.Command {
 			ctx, cancel := withTimeout(context.Background(), flags)
 			defer cancel()
 
+			if flags.server != "" {
+				var info struct {
+					groupRow
+					OwnerJID         string `json:"owner_jid"`
+					ParticipantCount int    `json:"participant_count"`
+				}
+				return runRemote(ctx, flags, http.MethodGet, remotePath("groups", jidStr), nil, &info, func() {
+					fmt.Fprintf(os.Stdout, "JID: %s\nName: %s\nOwner: %s\nCreated: %s\nParticipants: %d\n",
+						info.JID, info.Name, info.OwnerJID, info.CreatedAt.Local().Format(time.RFC3339), info.ParticipantCount)
+				})
+			}
+
 			a, lk, err := newApp(ctx, flags, true, false)
 			if err != nil {
 				return err
@@ -184,6 +212,11 @@ func newGroupsRenameCmd(flags *rootFlags) *cobra.Command {
 			ctx, cancel := withTimeout(context.Background(), flags)
 			defer cancel()
 
+			if flags.server != "" {
+				return runRemote(ctx, flags, http.MethodPut, remotePath("groups", jidStr, "name"),
+					map[string]string{"name": name}, nil, func() { fmt.Fprintln(os.Stdout, "OK") })
+			}
+
 			a, lk, err := newApp(ctx, flags, true, false)
 			if err != nil {
 				return err
@@ -244,6 +277,11 @@ func newGroupsParticipantsActionCmd(flags *rootFlags, action string) *cobra.Comm
 			ctx, cancel := withTimeout(context.Background(), flags)
 			defer cancel()
 
+			if flags.server != "" {
+				return runRemote(ctx, flags, http.MethodPost, remotePath("groups", group, "participants"),
+					map[string]any{"action": action, "users": users}, nil, func() { fmt.Fprintln(os.Stdout, "OK") })
+			}
+
 			a, lk, err := newApp(ctx, flags, true, false)
 			if err != nil {
 				return err
@@ -321,6 +359,15 @@ func newGroupsInviteLinkGetCmd(flags *rootFlags) *cobra.Command {
 			ctx, cancel := withTimeout(context.Background(), flags)
 			defer cancel()
 
+			if flags.server != "" {
+				var resp struct {
+					Link string `json:"link"`
+				}
+				return runRemote(ctx, flags, http.MethodGet, remotePath("groups", jidStr, "invite"), nil, &resp, func() {
+					fmt.Fprintln(os.Stdout, resp.Link)
+				})
+			}
+
 			a, lk, err := newApp(ctx, flags, true, false)
 			if err != nil {
 				return err
@@ -364,6 +411,15 @@ func newGroupsInviteLinkRevokeCmd(flags *rootFlags) *cobra.Command {
 			ctx, cancel := withTimeout(context.Background(), flags)
 			defer cancel()
 
+			if flags.server != "" {
+				var resp struct {
+					Link string `json:"link"`
+				}
+				return runRemote(ctx, flags, http.MethodPost, remotePath("groups", jidStr, "invite", "revoke"), struct{}{}, &resp, func() {
+					fmt.Fprintln(os.Stdout, resp.Link)
+				})
+			}
+
 			a, lk, err := newApp(ctx, flags, true, false)
 			if err != nil {
 				return err
@@ -407,6 +463,14 @@ func newGroupsJoinCmd(flags *rootFlags) *cobra.Command {
 			ctx, cancel := withTimeout(context.Background(), flags)
 			defer cancel()
 
+			if flags.server != "" {
+				var resp struct {
+					JID string `json:"jid"`
+				}
+				return runRemote(ctx, flags, http.MethodPost, "/groups/join",
+					map[string]string{"code": code}, &resp, func() { fmt.Fprintf(os.Stdout, "Joined: %s\n", resp.JID) })
+			}
+
 			a, lk, err := newApp(ctx, flags, true, false)
 			if err != nil {
 				return err
@@ -449,6 +513,12 @@ func newGroupsLeaveCmd(flags *rootFlags) *cobra.Command {
 			ctx, cancel := withTimeout(context.Background(), flags)
 			defer cancel()
 
+			if flags.server != "" {
+				return runRemote(ctx, flags, http.MethodPost, remotePath("groups", jidStr, "leave"), struct{}{}, nil, func() {
+					fmt.Fprintln(os.Stdout, "OK")
+				})
+			}
+
 			a, lk, err := newApp(ctx, flags, true, false)
 			if err != nil {
 				return err
@@ -479,7 +549,28 @@ func newGroupsLeaveCmd(flags *rootFlags) *cobra.Command {
 	return cmd
 }
 
-func persistGroupInfo(db *store.DB, info *types.GroupInfo) error {
+// groupRow is a group as the groups table prints it. It also decodes groups
+// from the service's API.
+type groupRow struct {
+	JID       string    `json:"jid"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+func printGroups(groups []groupRow) {
+	w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tJID\tCREATED")
+	for _, g := range groups {
+		name := g.Name
+		if name == "" {
+			name = g.JID
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", truncate(name, 40), g.JID, g.CreatedAt.Local().Format("2006-01-02"))
+	}
+	_ = w.Flush()
+}
+
+func persistGroupInfo(db store.Store, info *types.GroupInfo) error {
 	if info == nil {
 		return nil
 	}
@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"strconv"
 	"text/tabwriter"
 	"time"
 
@@ -37,6 +39,16 @@ func newMessagesListCmd(flags *rootFlags) *cobra.Command {
 			ctx, cancel := withTimeout(context.Background(), flags)
 			defer cancel()
 
+			if flags.server != "" {
+				if err := rejectRemoteFlags(cmd, "after", "before"); err != nil {
+					return err
+				}
+				if chat == "" {
+					return fmt.Errorf("--chat is required with --server")
+				}
+				return listMessagesRemote(ctx, flags, chat, limit)
+			}
+
 			a, lk, err := newApp(ctx, flags, false, false)
 			if err != nil {
 				return err
@@ -77,30 +89,7 @@ func newMessagesListCmd(flags *rootFlags) *cobra.Command {
 				})
 			}
 
-			w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
-			fmt.Fprintln(w, "TIME\tCHAT\tFROM\tID\tTEXT")
-			for _, m := range msgs {
-				from := m.SenderJID
-				if m.FromMe {
-					from = "me"
-				}
-				chatLabel := m.ChatName
-				if chatLabel == "" {
-					chatLabel = m.ChatJID
-				}
-				text := m.Text
-				if m.MediaType != "" && text == "" {
-					text = "[" + m.MediaType + "]"
-				}
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-					m.Timestamp.Local().Format("2006-01-02 15:04:05"),
-					truncate(chatLabel, 24),
-					truncate(from, 18),
-					truncate(m.MsgID, 14),
-					truncate(text, 80),
-				)
-			}
-			_ = w.Flush()
+			printMessages(messageRows(msgs))
 			return nil
 		},
 	}
@@ -128,6 +117,13 @@ func newMessagesSearchCmd(flags *rootFlags) *cobra.Command {
 			ctx, cancel := withTimeout(context.Background(), flags)
 			defer cancel()
 
+			if flags.server != "" {
+				if err := rejectRemoteFlags(cmd, "chat", "from", "after", "before", "type"); err != nil {
+					return err
+				}
+				return searchMessagesRemote(ctx, flags, args[0], limit)
+			}
+
 			a, lk, err := newApp(ctx, flags, false, false)
 			if err != nil {
 				return err
@@ -171,30 +167,7 @@ func newMessagesSearchCmd(flags *rootFlags) *cobra.Command {
 				})
 			}
 
-			w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
-			fmt.Fprintf(w, "TIME\tCHAT\tFROM\tID\tMATCH\n")
-			for _, m := range msgs {
-				fromLabel := m.SenderJID
-				if m.FromMe {
-					fromLabel = "me"
-				}
-				chatLabel := m.ChatName
-				if chatLabel == "" {
-					chatLabel = m.ChatJID
-				}
-				match := m.Snippet
-				if match == "" {
-					match = m.Text
-				}
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-					m.Timestamp.Local().Format("2006-01-02 15:04:05"),
-					truncate(chatLabel, 24),
-					truncate(fromLabel, 18),
-					truncate(m.MsgID, 14),
-					truncate(match, 90),
-				)
-			}
-			_ = w.Flush()
+			printSearchResults(messageRows(msgs))
 			if !a.DB().HasFTS() {
 				fmt.Fprintln(os.Stderr, "Note: FTS5 not enabled; search is using LIKE (slow).")
 			}
@@ -325,3 +298,107 @@ func newMessagesContextCmd(flags *rootFlags) *cobra.Command {
 	cmd.Flags().IntVar(&after, "after", 5, "messages after")
 	return cmd
 }
+
+// messageRow is a message as the list and search tables print it. It also
+// decodes messages from the service's API.
+type messageRow struct {
+	ChatJID   string    `json:"chat_jid"`
+	ChatName  string    `json:"chat_name"`
+	MsgID     string    `json:"msg_id"`
+	SenderJID string    `json:"sender_jid,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	FromMe    bool      `json:"from_me"`
+	Text      string    `json:"text,omitempty"`
+	MediaType string    `json:"media_type,omitempty"`
+	Snippet   string    `json:"snippet,omitempty"`
+}
+
+func messageRows(msgs []store.Message) []messageRow {
+	rows := make([]messageRow, len(msgs))
+	for i, m := range msgs {
+		rows[i] = messageRow{
+			ChatJID:   m.ChatJID,
+			ChatName:  m.ChatName,
+			MsgID:     m.MsgID,
+			SenderJID: m.SenderJID,
+			Timestamp: m.Timestamp,
+			FromMe:    m.FromMe,
+			Text:      m.Text,
+			MediaType: m.MediaType,
+			Snippet:   m.Snippet,
+		}
+	}
+	return rows
+}
+
+func (m messageRow) labels() (chat, from string) {
+	chat = m.ChatName
+	if chat == "" {
+		chat = m.ChatJID
+	}
+	from = m.SenderJID
+	if m.FromMe {
+		from = "me"
+	}
+	return chat, from
+}
+
+func printMessages(msgs []messageRow) {
+	w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tCHAT\tFROM\tID\tTEXT")
+	for _, m := range msgs {
+		chatLabel, from := m.labels()
+		text := m.Text
+		if m.MediaType != "" && text == "" {
+			text = "[" + m.MediaType + "]"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			m.Timestamp.Local().Format("2006-01-02 15:04:05"),
+			truncate(chatLabel, 24),
+			truncate(from, 18),
+			truncate(m.MsgID, 14),
+			truncate(text, 80),
+		)
+	}
+	_ = w.Flush()
+}
+
+func printSearchResults(msgs []messageRow) {
+	w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "TIME\tCHAT\tFROM\tID\tMATCH\n")
+	for _, m := range msgs {
+		chatLabel, fromLabel := m.labels()
+		match := m.Snippet
+		if match == "" {
+			match = m.Text
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			m.Timestamp.Local().Format("2006-01-02 15:04:05"),
+			truncate(chatLabel, 24),
+			truncate(fromLabel, 18),
+			truncate(m.MsgID, 14),
+			truncate(match, 90),
+		)
+	}
+	_ = w.Flush()
+}
+
+func listMessagesRemote(ctx context.Context, flags *rootFlags, chat string, limit int) error {
+	var resp struct {
+		Messages []messageRow `json:"messages"`
+	}
+	return runRemote(ctx, flags, http.MethodGet,
+		remotePath("chats", chat, "messages")+remoteQuery("limit", strconv.Itoa(limit)), nil, &resp, func() {
+			printMessages(resp.Messages)
+		})
+}
+
+func searchMessagesRemote(ctx context.Context, flags *rootFlags, query string, limit int) error {
+	var resp struct {
+		Messages []messageRow `json:"messages"`
+	}
+	return runRemote(ctx, flags, http.MethodGet,
+		"/search"+remoteQuery("q", query, "limit", strconv.Itoa(limit)), nil, &resp, func() {
+			printSearchResults(resp.Messages)
+		})
+}
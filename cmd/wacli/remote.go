@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/out"
+)
+
+// errRemoteUnsupported is returned by commands that need the local store
+// when --server is set.
+var errRemoteUnsupported = errors.New("this command is not available with --server; it needs the local store")
+
+// remoteClient talks to a running wasvc service over its REST API.
+type remoteClient struct {
+	base   string
+	apiKey string
+	http   *http.Client
+}
+
+func newRemoteClient(flags *rootFlags) *remoteClient {
+	return &remoteClient{
+		base:   strings.TrimRight(flags.server, "/"),
+		apiKey: flags.apiKey,
+		// Commands bound requests with their context (--timeout).
+		http: &http.Client{},
+	}
+}
+
+// remoteError is an error response from the service.
+type remoteError struct {
+	Status  int    `json:"-"`
+	Message string `json:"error"`
+	Code    string `json:"code"`
+}
+
+func (e *remoteError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("server returned %d", e.Status)
+	}
+	if e.Code == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s (%s)", e.Message, e.Code)
+}
+
+// do sends in as a JSON body (when non-nil) and decodes the response into
+// dst (when non-nil). The raw response is returned for --json output.
+func (c *remoteClient) do(ctx context.Context, method, path string, in, dst any) (json.RawMessage, error) {
+	var body io.Reader
+	if in != nil {
+		raw, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.send(req, dst)
+}
+
+// sendFile uploads a local file to POST /messages/file as multipart form
+// data, so large files are not base64-encoded in memory.
+func (c *remoteClient) sendFile(ctx context.Context, fields map[string]string, path string, dst any) (json.RawMessage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		for k, v := range fields {
+			if v == "" {
+				continue
+			}
+			if err := mw.WriteField(k, v); err != nil {
+				_ = pw.CloseWithError(err)
+				return
+			}
+		}
+		part, err := mw.CreateFormFile("file", filepath.Base(path))
+		if err == nil {
+			_, err = io.Copy(part, f)
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.base+"/messages/file", pr)
+	if err != nil {
+		_ = pr.Close()
+		return nil, err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return c.send(req, dst)
+}
+
+func (c *remoteClient) send(req *http.Request, dst any) (json.RawMessage, error) {
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Path, err)
+	}
+	if resp.StatusCode >= 300 {
+		apiErr := &remoteError{Status: resp.StatusCode}
+		_ = json.Unmarshal(data, apiErr)
+		return nil, apiErr
+	}
+	if dst != nil {
+		if err := json.Unmarshal(data, dst); err != nil {
+			return nil, fmt.Errorf("%s %s: decode response: %w", req.Method, req.URL.Path, err)
+		}
+	}
+	return data, nil
+}
+
+// remotePath joins path segments, escaping each one.
+func remotePath(segments ...string) string {
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteByte('/')
+		b.WriteString(url.PathEscape(s))
+	}
+	return b.String()
+}
+
+// remoteQuery encodes non-empty query parameters given as key, value pairs.
+func remoteQuery(kv ...string) string {
+	q := url.Values{}
+	for i := 0; i+1 < len(kv); i += 2 {
+		if kv[i+1] != "" {
+			q.Set(kv[i], kv[i+1])
+		}
+	}
+	if len(q) == 0 {
+		return ""
+	}
+	return "?" + q.Encode()
+}
+
+// rejectRemoteFlags fails if any of the named flags, which the REST API has
+// no equivalent for, were set.
+func rejectRemoteFlags(cmd *cobra.Command, names ...string) error {
+	for _, name := range names {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s is not supported with --server", name)
+		}
+	}
+	return nil
+}
+
+// runRemote calls the service and prints the response: as is with --json,
+// otherwise through print once dst has been decoded.
+func runRemote(ctx context.Context, flags *rootFlags, method, path string, in, dst any, print func()) error {
+	raw, err := newRemoteClient(flags).do(ctx, method, path, in, dst)
+	if err != nil {
+		return err
+	}
+	if flags.asJSON {
+		return out.WriteJSON(os.Stdout, raw)
+	}
+	print()
+	return nil
+}
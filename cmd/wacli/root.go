@@ -11,8 +11,10 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/steipete/wacli/internal/app"
 	"github.com/steipete/wacli/internal/config"
+	"github.com/steipete/wacli/internal/dbcrypt"
 	"github.com/steipete/wacli/internal/lock"
 	"github.com/steipete/wacli/internal/out"
+	"github.com/steipete/wacli/internal/phone"
 )
 
 var version = "dev"
@@ -21,6 +23,12 @@ type rootFlags struct {
 	storeDir string
 	asJSON   bool
 	timeout  time.Duration
+	server   string // remote mode: base URL of a running wasvc service
+	apiKey   string
+	country  string // default country code for phone numbers in national format
+
+	dbKey     string // SQLCipher key of the store databases
+	dbKeyFile string
 }
 
 func execute(args []string) error {
@@ -37,11 +45,32 @@ func execute(args []string) error {
 	rootCmd.PersistentFlags().StringVar(&flags.storeDir, "store", "", "store directory (default: ~/.wacli)")
 	rootCmd.PersistentFlags().BoolVar(&flags.asJSON, "json", false, "output JSON instead of human-readable text")
 	rootCmd.PersistentFlags().DurationVar(&flags.timeout, "timeout", 5*time.Minute, "command timeout (non-sync commands)")
+	rootCmd.PersistentFlags().StringVar(&flags.server, "server", os.Getenv("WACLI_SERVER"), "use a running wasvc service at this URL instead of the local store (env WACLI_SERVER)")
+	rootCmd.PersistentFlags().StringVar(&flags.apiKey, "api-key", os.Getenv("WACLI_API_KEY"), "API key for --server (env WACLI_API_KEY)")
+	rootCmd.PersistentFlags().StringVar(&flags.country, "country-code", os.Getenv("WACLI_DEFAULT_COUNTRY_CODE"), "country code for phone numbers in national format, e.g. 49 (env WACLI_DEFAULT_COUNTRY_CODE)")
+	rootCmd.PersistentFlags().StringVar(&flags.dbKey, "db-key", os.Getenv("WASVC_DB_KEY"), "SQLCipher key of an encrypted store (env WASVC_DB_KEY)")
+	rootCmd.PersistentFlags().StringVar(&flags.dbKeyFile, "db-key-file", os.Getenv("WASVC_DB_KEY_FILE"), "file containing the SQLCipher key of an encrypted store (env WASVC_DB_KEY_FILE)")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := phone.SetDefaultCountryCode(flags.country); err != nil {
+			return err
+		}
+		// Key the store databases before any command opens them.
+		if flags.dbKey != "" && flags.dbKeyFile != "" {
+			return fmt.Errorf("set only one of --db-key and --db-key-file")
+		}
+		key, err := dbcrypt.LoadKey(flags.dbKey, flags.dbKeyFile)
+		if err != nil {
+			return fmt.Errorf("invalid database key: %w", err)
+		}
+		dbcrypt.SetKey(key)
+		return nil
+	}
 
 	rootCmd.AddCommand(newVersionCmd())
 	rootCmd.AddCommand(newDoctorCmd(&flags))
 	rootCmd.AddCommand(newAuthCmd(&flags))
 	rootCmd.AddCommand(newSyncCmd(&flags))
+	rootCmd.AddCommand(newWatchCmd(&flags))
 	rootCmd.AddCommand(newMessagesCmd(&flags))
 	rootCmd.AddCommand(newSendCmd(&flags))
 	rootCmd.AddCommand(newMediaCmd(&flags))
@@ -49,6 +78,7 @@ func execute(args []string) error {
 	rootCmd.AddCommand(newChatsCmd(&flags))
 	rootCmd.AddCommand(newGroupsCmd(&flags))
 	rootCmd.AddCommand(newHistoryCmd(&flags))
+	rootCmd.AddCommand(newExportCmd(&flags))
 
 	rootCmd.SetArgs(args)
 	if err := rootCmd.Execute(); err != nil {
@@ -59,6 +89,9 @@ func execute(args []string) error {
 }
 
 func newApp(ctx context.Context, flags *rootFlags, needLock bool, allowUnauthed bool) (*app.App, *lock.Lock, error) {
+	if flags.server != "" {
+		return nil, nil, errRemoteUnsupported
+	}
 	storeDir := flags.storeDir
 	if storeDir == "" {
 		storeDir = config.DefaultStoreDir()
@@ -3,13 +3,16 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/app"
 	"github.com/steipete/wacli/internal/out"
 	"github.com/steipete/wacli/internal/store"
 	"github.com/steipete/wacli/internal/wa"
+	"go.mau.fi/whatsmeow/types"
 )
 
 func newSendCmd(flags *rootFlags) *cobra.Command {
@@ -19,6 +22,7 @@ func newSendCmd(flags *rootFlags) *cobra.Command {
 	}
 	cmd.AddCommand(newSendTextCmd(flags))
 	cmd.AddCommand(newSendFileCmd(flags))
+	cmd.AddCommand(newSendBulkCmd(flags))
 	return cmd
 }
 
@@ -37,6 +41,10 @@ func newSendTextCmd(flags *rootFlags) *cobra.Command {
 			ctx, cancel := withTimeout(context.Background(), flags)
 			defer cancel()
 
+			if flags.server != "" {
+				return sendTextRemote(ctx, flags, to, message)
+			}
+
 			a, lk, err := newApp(ctx, flags, true, false)
 			if err != nil {
 				return err
@@ -55,27 +63,12 @@ func newSendTextCmd(flags *rootFlags) *cobra.Command {
 				return err
 			}
 
-			msgID, err := a.WA().SendText(ctx, toJID, message)
+			msgID, err := sendTextLocal(ctx, a, toJID, message)
 			if err != nil {
 				return err
 			}
 
-			now := time.Now().UTC()
 			chat := toJID
-			chatName := a.WA().ResolveChatName(ctx, chat, "")
-			kind := chatKindFromJID(chat)
-			_ = a.DB().UpsertChat(chat.String(), kind, chatName, now)
-			_ = a.DB().UpsertMessage(store.UpsertMessageParams{
-				ChatJID:    chat.String(),
-				ChatName:   chatName,
-				MsgID:      string(msgID),
-				SenderJID:  "",
-				SenderName: "me",
-				Timestamp:  now,
-				FromMe:     true,
-				Text:       message,
-			})
-
 			if flags.asJSON {
 				return out.WriteJSON(os.Stdout, map[string]any{
 					"sent": true,
@@ -92,3 +85,38 @@ func newSendTextCmd(flags *rootFlags) *cobra.Command {
 	cmd.Flags().StringVar(&message, "message", "", "message text")
 	return cmd
 }
+
+// sendTextLocal sends a text message and records it in the local store.
+func sendTextLocal(ctx context.Context, a *app.App, to types.JID, message string) (types.MessageID, error) {
+	msgID, err := a.WA().SendText(ctx, to, message)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	chatName := a.WA().ResolveChatName(ctx, to, "")
+	kind := chatKindFromJID(to)
+	_ = a.DB().UpsertChat(to.String(), kind, chatName, now)
+	_ = a.DB().UpsertMessage(store.UpsertMessageParams{
+		ChatJID:    to.String(),
+		ChatName:   chatName,
+		MsgID:      string(msgID),
+		SenderJID:  "",
+		SenderName: "me",
+		Timestamp:  now,
+		FromMe:     true,
+		Text:       message,
+	})
+	return msgID, nil
+}
+
+func sendTextRemote(ctx context.Context, flags *rootFlags, to, message string) error {
+	var resp struct {
+		MessageID string `json:"message_id"`
+		To        string `json:"to"`
+	}
+	return runRemote(ctx, flags, http.MethodPost, "/messages/text",
+		map[string]string{"to": to, "message": message}, &resp, func() {
+			fmt.Fprintf(os.Stdout, "Sent to %s (id %s)\n", resp.To, resp.MessageID)
+		})
+}
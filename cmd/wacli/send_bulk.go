@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/bulk"
+	"github.com/steipete/wacli/internal/out"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+func newSendBulkCmd(flags *rootFlags) *cobra.Command {
+	var csvPath string
+	var template string
+	var delay time.Duration
+	var dryRun bool
+	var reportPath string
+
+	cmd := &cobra.Command{
+		Use:   "bulk",
+		Short: "Send a templated text message to every recipient of a CSV file",
+		Long: "Send a text message to each row of a CSV file with a header row, one at a time with --delay between sends.\n" +
+			"The recipient is taken from a column named to, phone, number or jid; any column can be used in the\n" +
+			"template as {{column}}, e.g. --template \"Hi {{name}}\". Failed rows don't stop the run; Ctrl+C does,\n" +
+			"and the rows not yet sent are reported as skipped.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if csvPath == "" || template == "" {
+				return fmt.Errorf("--csv and --template are required")
+			}
+			f, err := os.Open(csvPath)
+			if err != nil {
+				return err
+			}
+			recipients, err := bulk.ReadCSV(f)
+			_ = f.Close()
+			if err != nil {
+				return fmt.Errorf("%s: %w", csvPath, err)
+			}
+			if len(recipients) == 0 {
+				return fmt.Errorf("%s: no recipients", csvPath)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			var send bulk.SendFunc
+			switch {
+			case dryRun:
+			case flags.server != "":
+				c := newRemoteClient(flags)
+				send = func(ctx context.Context, to, text string) (string, error) {
+					ctx, cancel := withTimeout(ctx, flags)
+					defer cancel()
+					var resp struct {
+						MessageID string `json:"message_id"`
+					}
+					_, err := c.do(ctx, http.MethodPost, "/messages/text", map[string]string{"to": to, "message": text}, &resp)
+					return resp.MessageID, err
+				}
+			default:
+				a, lk, err := newApp(ctx, flags, true, false)
+				if err != nil {
+					return err
+				}
+				defer closeApp(a, lk)
+				if err := a.EnsureAuthed(); err != nil {
+					return err
+				}
+				if err := a.Connect(ctx, false, nil); err != nil {
+					return err
+				}
+				send = func(ctx context.Context, to, text string) (string, error) {
+					jid, err := wa.ParseUserOrJID(to)
+					if err != nil {
+						return "", err
+					}
+					ctx, cancel := withTimeout(ctx, flags)
+					defer cancel()
+					id, err := sendTextLocal(ctx, a, jid, text)
+					return string(id), err
+				}
+			}
+
+			done := 0
+			results := bulk.Run(ctx, recipients, bulk.Options{Template: template, Delay: delay, DryRun: dryRun}, send, func(r bulk.Result) {
+				done++
+				if flags.asJSON {
+					return
+				}
+				detail := r.MessageID
+				if r.Error != "" {
+					detail = r.Error
+				} else if r.Status == bulk.StatusDryRun {
+					detail = truncate(r.Message, 60)
+				}
+				fmt.Fprintf(os.Stdout, "%d/%d\t%s\t%s\t%s\n", done, len(recipients), r.To, r.Status, detail)
+			})
+
+			if reportPath != "" {
+				if err := writeBulkReport(reportPath, results); err != nil {
+					return err
+				}
+			}
+
+			sent := bulk.Count(results, bulk.StatusSent)
+			failed := bulk.Count(results, bulk.StatusFailed)
+			skipped := bulk.Count(results, bulk.StatusSkipped)
+			if flags.asJSON {
+				if err := out.WriteJSON(os.Stdout, map[string]any{
+					"total":   len(results),
+					"sent":    sent,
+					"failed":  failed,
+					"skipped": skipped,
+					"dry_run": dryRun,
+					"results": results,
+				}); err != nil {
+					return err
+				}
+			} else if dryRun {
+				fmt.Fprintf(os.Stdout, "Dry run: %d messages rendered, %d failed\n", len(results)-failed, failed)
+			} else {
+				fmt.Fprintf(os.Stdout, "Sent %d of %d (%d failed, %d skipped)\n", sent, len(results), failed, skipped)
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d messages failed", failed, len(results))
+			}
+			return ctx.Err()
+		},
+	}
+
+	cmd.Flags().StringVar(&csvPath, "csv", "", "CSV file of recipients, with a header row")
+	cmd.Flags().StringVar(&template, "template", "", "message text; {{column}} is replaced with the row's value")
+	cmd.Flags().DurationVar(&delay, "delay", 3*time.Second, "pause between two sends")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "render every message without sending anything")
+	cmd.Flags().StringVar(&reportPath, "report", "", "write a CSV report of every row's outcome to this file")
+	return cmd
+}
+
+func writeBulkReport(path string, results []bulk.Result) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := bulk.WriteReport(f, results); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
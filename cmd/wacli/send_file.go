@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"mime"
 	"net/http"
 	"os"
@@ -19,7 +20,7 @@ import (
 
 func sendFile(ctx context.Context, a interface {
 	WA() app.WAClient
-	DB() *store.DB
+	DB() store.Store
 }, to types.JID, filePath, caption, mimeOverride string) (string, map[string]string, error) {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
@@ -110,6 +111,13 @@ func sendFile(ctx context.Context, a interface {
 		}
 	}
 
+	if err := wa.AttachThumbnail(ctx, msg, data); err != nil {
+		fmt.Fprintf(os.Stderr, "Sending without thumbnail: %v\n", err)
+	}
+	if err := wa.AttachAudioInfo(ctx, msg, data); err != nil {
+		fmt.Fprintf(os.Stderr, "Sending without duration and waveform: %v\n", err)
+	}
+
 	id, err := a.WA().SendProtoMessage(ctx, to, msg)
 	if err != nil {
 		return "", nil, err
@@ -136,6 +144,7 @@ func sendFile(ctx context.Context, a interface {
 		FileSHA256:    up.FileSHA256,
 		FileEncSHA256: up.FileEncSHA256,
 		FileLength:    up.FileLength,
+		Duration:      msg.GetAudioMessage().GetSeconds(),
 	})
 
 	return id, map[string]string{
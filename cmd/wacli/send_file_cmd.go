@@ -27,6 +27,10 @@ func newSendFileCmd(flags *rootFlags) *cobra.Command {
 			ctx, cancel := withTimeout(context.Background(), flags)
 			defer cancel()
 
+			if flags.server != "" {
+				return sendFileRemote(ctx, flags, to, filePath, caption, mimeOverride)
+			}
+
 			a, lk, err := newApp(ctx, flags, true, false)
 			if err != nil {
 				return err
@@ -69,3 +73,24 @@ func newSendFileCmd(flags *rootFlags) *cobra.Command {
 	cmd.Flags().StringVar(&mimeOverride, "mime", "", "override detected mime type")
 	return cmd
 }
+
+func sendFileRemote(ctx context.Context, flags *rootFlags, to, filePath, caption, mimeOverride string) error {
+	var resp struct {
+		MessageID string `json:"message_id"`
+		To        string `json:"to"`
+		Filename  string `json:"filename"`
+	}
+	raw, err := newRemoteClient(flags).sendFile(ctx, map[string]string{
+		"to":        to,
+		"caption":   caption,
+		"mime_type": mimeOverride,
+	}, filePath, &resp)
+	if err != nil {
+		return err
+	}
+	if flags.asJSON {
+		return out.WriteJSON(os.Stdout, raw)
+	}
+	fmt.Fprintf(os.Stdout, "Sent %s to %s (id %s)\n", resp.Filename, resp.To, resp.MessageID)
+	return nil
+}
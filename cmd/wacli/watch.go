@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	appPkg "github.com/steipete/wacli/internal/app"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// watchedMessage is one line of `wacli watch --json`.
+type watchedMessage struct {
+	ChatJID    string    `json:"chat_jid"`
+	ChatName   string    `json:"chat_name,omitempty"`
+	MsgID      string    `json:"msg_id"`
+	SenderJID  string    `json:"sender_jid,omitempty"`
+	SenderName string    `json:"sender_name,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+	FromMe     bool      `json:"from_me"`
+	Text       string    `json:"text,omitempty"`
+	MediaType  string    `json:"media_type,omitempty"`
+	Caption    string    `json:"caption,omitempty"`
+}
+
+func newWatchCmd(flags *rootFlags) *cobra.Command {
+	var chats []string
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Stream new messages to stdout as they arrive (NDJSON with --json)",
+		Long: "Stream new incoming and outgoing messages to stdout until Ctrl+C, while syncing them into the local store.\n" +
+			"Each message is one tab-separated line (time, chat, sender, text), or one JSON object per line with --json.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			want := make(map[string]bool, len(chats))
+			for _, c := range chats {
+				jid, err := wa.ParseUserOrJID(c)
+				if err != nil {
+					return err
+				}
+				want[jid.String()] = true
+			}
+
+			a, lk, err := newApp(ctx, flags, true, false)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+
+			if err := a.EnsureAuthed(); err != nil {
+				return err
+			}
+
+			var mu sync.Mutex
+			enc := json.NewEncoder(os.Stdout)
+			_, err = a.Sync(ctx, appPkg.SyncOptions{
+				Mode:    appPkg.SyncModeFollow,
+				AllowQR: false,
+				OnMessage: func(pm wa.ParsedMessage) {
+					if len(want) > 0 && !want[pm.Chat.String()] {
+						return
+					}
+					m := watchedMessage{
+						ChatJID:   pm.Chat.String(),
+						ChatName:  a.WA().ResolveChatName(ctx, pm.Chat, pm.PushName),
+						MsgID:     pm.ID,
+						SenderJID: pm.SenderJID,
+						Timestamp: pm.Timestamp,
+						FromMe:    pm.FromMe,
+						Text:      pm.Text,
+					}
+					if pm.FromMe {
+						m.SenderName = "me"
+					} else if s := strings.TrimSpace(pm.PushName); s != "-" {
+						m.SenderName = s
+					}
+					if pm.Media != nil {
+						m.MediaType = pm.Media.Type
+						m.Caption = pm.Media.Caption
+					}
+
+					mu.Lock()
+					defer mu.Unlock()
+					if flags.asJSON {
+						_ = enc.Encode(m)
+						return
+					}
+					fmt.Fprintln(os.Stdout, formatWatched(m))
+				},
+			})
+			return err
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&chats, "chat", nil, "only messages in this chat (phone number or JID; repeatable)")
+	return cmd
+}
+
+// formatWatched renders a message as one tab-separated line; tabs and
+// newlines in the text are flattened so each message stays on one line.
+func formatWatched(m watchedMessage) string {
+	chat := m.ChatName
+	if chat == "" {
+		chat = m.ChatJID
+	}
+	from := m.SenderName
+	if from == "" {
+		from = m.SenderJID
+	}
+	text := m.Text
+	if text == "" {
+		text = m.Caption
+	}
+	if m.MediaType != "" {
+		text = strings.TrimSpace("[" + m.MediaType + "] " + text)
+	}
+	text = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ").Replace(text)
+	return strings.Join([]string{m.Timestamp.Local().Format("2006-01-02 15:04:05"), chat, from, text}, "\t")
+}
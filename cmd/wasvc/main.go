@@ -6,8 +6,17 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/steipete/wacli/internal/amqpsink"
 	"github.com/steipete/wacli/internal/api"
+	"github.com/steipete/wacli/internal/dbcrypt"
+	"github.com/steipete/wacli/internal/exechook"
+	"github.com/steipete/wacli/internal/kafkasink"
+	"github.com/steipete/wacli/internal/mqttsink"
+	"github.com/steipete/wacli/internal/natssink"
+	"github.com/steipete/wacli/internal/replica"
+	"github.com/steipete/wacli/internal/sdnotify"
 	"github.com/steipete/wacli/internal/service"
 	"github.com/steipete/wacli/internal/webhook"
 )
@@ -16,12 +25,28 @@ func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 	log.Println("[Main] Starting WhatsApp API Service...")
 
-	// Load configuration from environment
-	cfg := service.LoadFromEnv()
+	// Load configuration from the config file, if any, and environment
+	cfg, err := service.LoadConfig()
+	if err != nil {
+		log.Fatalf("[Main] Invalid configuration: %v", err)
+	}
 	if err := cfg.Validate(); err != nil {
 		log.Fatalf("[Main] Invalid configuration: %v", err)
 	}
 
+	// Load webhook client certificate / CA bundle up front so bad files fail fast
+	webhookTLS, err := webhook.LoadTLSConfig(cfg.WebhookClientCert, cfg.WebhookClientKey, cfg.WebhookCAFile)
+	if err != nil {
+		log.Fatalf("[Main] Invalid webhook TLS configuration: %v", err)
+	}
+
+	// Key the SQLite databases before anything opens them
+	dbKey, err := dbcrypt.LoadKey(cfg.DBKey, cfg.DBKeyFile)
+	if err != nil {
+		log.Fatalf("[Main] Invalid database key: %v", err)
+	}
+	dbcrypt.SetKey(dbKey)
+
 	log.Printf("[Main] Data directory: %s", cfg.DataDir)
 	log.Printf("[Main] Listen address: %s", cfg.Addr())
 
@@ -31,34 +56,148 @@ func main() {
 		log.Fatalf("[Main] Failed to create manager: %v", err)
 	}
 
-	// Create webhook emitter if configured
+	// Create HTTP API server
+	server := api.NewServer(cfg, mgr)
+
+	// Create context for graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Start service manager
+	if err := mgr.Start(ctx); err != nil {
+		log.Fatalf("[Main] Failed to start manager: %v", err)
+	}
+
+	// Create webhook emitter if configured (after the store is open so queued
+	// events from a previous run are retried)
 	var webhookEmitter *webhook.Emitter
+	var endpoints []webhook.Endpoint
 	if cfg.WebhookURL != "" {
-		log.Printf("[Main] Webhook URL: %s", cfg.WebhookURL)
+		endpoints = append(endpoints, webhook.Endpoint{URL: cfg.WebhookURL, Secret: cfg.WebhookSecret, SecondarySecret: cfg.WebhookSecretSecondary, Events: cfg.WebhookEvents, Headers: cfg.WebhookHeaders,
+			ChatFilter: webhook.ChatFilter{Chats: cfg.WebhookChats, ExcludeChats: cfg.WebhookExcludeChats}})
+	}
+	for _, wh := range cfg.Webhooks {
+		endpoints = append(endpoints, webhook.Endpoint{URL: wh.URL, Secret: wh.Secret, SecondarySecret: wh.SecondarySecret, Events: wh.Events, Headers: wh.Headers,
+			ChatFilter: webhook.ChatFilter{Chats: wh.Chats, ExcludeChats: wh.ExcludeChats}})
+	}
+	if len(endpoints) > 0 {
+		for _, ep := range endpoints {
+			log.Printf("[Main] Webhook URL: %s (events: %v)", ep.URL, ep.Events)
+		}
 		webhookEmitter = webhook.NewEmitter(webhook.Config{
-			URL:        cfg.WebhookURL,
-			Secret:     cfg.WebhookSecret,
-			MaxRetries: cfg.WebhookRetries,
-			Timeout:    cfg.WebhookTimeout,
+			Endpoints:       endpoints,
+			MaxRetries:      cfg.WebhookRetries,
+			Timeout:         cfg.WebhookTimeout,
+			DB:              mgr.App().DB(),
+			TLS:             webhookTLS,
+			SignatureHeader: cfg.WebhookSignatureHeader,
+			SignTimestamp:   cfg.WebhookSignTimestamp,
 		})
 		webhookEmitter.Start()
+		mgr.SetWebhookEmitter(webhookEmitter)
+
+		subscribeWebhooks(mgr, webhookEmitter)
+	}
 
-		// Register message handler for webhooks
-		mgr.OnMessage(func(msg *service.ReceivedMessage) {
-			webhookEmitter.Emit("message.received", msg)
+	// Start exec hook if configured
+	var execRunner *exechook.Runner
+	if cfg.ExecHookCommand != "" {
+		log.Printf("[Main] Exec hook: %s (events: %v)", cfg.ExecHookCommand, cfg.ExecHookEvents)
+		execRunner = exechook.NewRunner(exechook.Config{
+			Command:     cfg.ExecHookCommand,
+			Events:      cfg.ExecHookEvents,
+			Concurrency: cfg.ExecHookConcurrency,
+			Timeout:     cfg.ExecHookTimeout,
 		})
+		execRunner.Start()
+		subscribe(mgr, execRunner)
 	}
 
-	// Create HTTP API server
-	server := api.NewServer(cfg, mgr)
+	for _, p := range cfg.Plugins {
+		log.Printf("[Main] Plugin: %s", p)
+	}
+	if cfg.ResponderURL != "" {
+		log.Printf("[Main] Auto-responder: %s (model: %s, all chats: %v)", cfg.ResponderURL, cfg.ResponderModel, cfg.ResponderAllChats)
+	}
 
-	// Create context for graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// Start NATS publisher if configured
+	var natsPublisher *natssink.Publisher
+	if cfg.NATSURL != "" {
+		natsPublisher = natssink.NewPublisher(natssink.Config{
+			URL:           cfg.NATSURL,
+			CredsFile:     cfg.NATSCredsFile,
+			SubjectPrefix: cfg.NATSSubjectPrefix,
+			Events:        cfg.NATSEvents,
+			JetStream:     cfg.NATSJetStream,
+			Stream:        cfg.NATSStream,
+		})
+		if err := natsPublisher.Start(); err != nil {
+			log.Fatalf("[Main] Failed to start NATS publisher: %v", err)
+		}
+		subscribe(mgr, natsPublisher)
+	}
 
-	// Start service manager
-	if err := mgr.Start(ctx); err != nil {
-		log.Fatalf("[Main] Failed to start manager: %v", err)
+	// Start Kafka producer if configured
+	var kafkaProducer *kafkasink.Producer
+	if len(cfg.KafkaBrokers) > 0 {
+		kafkaProducer, err = kafkasink.NewProducer(kafkasink.Config{
+			Brokers:       cfg.KafkaBrokers,
+			Topic:         cfg.KafkaTopic,
+			Format:        cfg.KafkaFormat,
+			Events:        cfg.KafkaEvents,
+			SASLMechanism: cfg.KafkaSASLMechanism,
+			Username:      cfg.KafkaUsername,
+			Password:      cfg.KafkaPassword,
+			TLS:           cfg.KafkaTLS,
+		})
+		if err != nil {
+			log.Fatalf("[Main] Failed to create Kafka producer: %v", err)
+		}
+		kafkaProducer.Start()
+		subscribe(mgr, kafkaProducer)
+	}
+
+	// Start MQTT publisher if configured
+	var mqttPublisher *mqttsink.Publisher
+	if cfg.MQTTURL != "" {
+		mqttPublisher = mqttsink.NewPublisher(mqttsink.Config{
+			BrokerURL:   cfg.MQTTURL,
+			ClientID:    cfg.MQTTClientID,
+			Username:    cfg.MQTTUsername,
+			Password:    cfg.MQTTPassword,
+			TopicPrefix: cfg.MQTTTopicPrefix,
+			Events:      cfg.MQTTEvents,
+			QoS:         byte(cfg.MQTTQoS),
+		})
+		mqttPublisher.Start()
+		subscribe(mgr, mqttPublisher)
+	}
+
+	// Start AMQP publisher if configured
+	var amqpPublisher *amqpsink.Publisher
+	if cfg.AMQPURL != "" {
+		amqpPublisher = amqpsink.NewPublisher(amqpsink.Config{
+			URL:          cfg.AMQPURL,
+			Exchange:     cfg.AMQPExchange,
+			ExchangeType: cfg.AMQPExchangeType,
+			RoutingKey:   cfg.AMQPRoutingKey,
+			Events:       cfg.AMQPEvents,
+		})
+		amqpPublisher.Start()
+		subscribe(mgr, amqpPublisher)
+	}
+
+	// Start standby replication if configured
+	var replicaSender *replica.Sender
+	if cfg.ReplicaURL != "" {
+		log.Printf("[Main] Replica URL: %s", cfg.ReplicaURL)
+		replicaSender = replica.NewSender(replica.Config{
+			URL:      cfg.ReplicaURL,
+			APIKey:   cfg.ReplicaAPIKey,
+			Secret:   cfg.ReplicaSecret,
+			Interval: cfg.ReplicaInterval,
+		}, mgr.App().DB())
+		replicaSender.Start()
 	}
 
 	// Handle shutdown signals
@@ -75,9 +214,27 @@ func main() {
 
 	log.Println("[Main] Service started successfully")
 
-	// Wait for shutdown signal
-	sig := <-sigChan
-	log.Printf("[Main] Received signal %v, shutting down...", sig)
+	// Tell systemd (Type=notify) the service is up, and feed its watchdog
+	// while the service is healthy
+	if err := sdnotify.Notify(sdnotify.Ready + "\n" + sdnotify.Status("WhatsApp "+mgr.State().State().String())); err != nil {
+		log.Printf("[Main] %v", err)
+	}
+	mgr.State().OnStateChange(func(_, state service.State) {
+		_ = sdnotify.Notify(sdnotify.Status("WhatsApp " + state.String()))
+	})
+	if interval := sdnotify.WatchdogInterval(); interval > 0 {
+		log.Printf("[Main] systemd watchdog: %s (grace: %s)", interval, cfg.WatchdogGrace)
+		go runWatchdog(ctx, mgr, interval)
+	}
+
+	// Wait for a shutdown signal, or a restart requested by POST /admin/restore
+	select {
+	case sig := <-sigChan:
+		log.Printf("[Main] Received signal %v, shutting down...", sig)
+	case <-mgr.ShutdownRequested():
+		log.Println("[Main] Shutdown requested to apply a staged restore...")
+	}
+	_ = sdnotify.Notify(sdnotify.Stopping)
 
 	// Create shutdown context with timeout
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
@@ -93,6 +250,36 @@ func main() {
 		webhookEmitter.Stop()
 	}
 
+	// Stop exec hook (kills commands still running)
+	if execRunner != nil {
+		execRunner.Stop()
+	}
+
+	// Stop NATS publisher
+	if natsPublisher != nil {
+		natsPublisher.Stop()
+	}
+
+	// Stop Kafka producer
+	if kafkaProducer != nil {
+		kafkaProducer.Stop()
+	}
+
+	// Stop MQTT publisher (marks the service offline)
+	if mqttPublisher != nil {
+		mqttPublisher.Stop()
+	}
+
+	// Stop AMQP publisher
+	if amqpPublisher != nil {
+		amqpPublisher.Stop()
+	}
+
+	// Stop replication before the store is closed
+	if replicaSender != nil {
+		replicaSender.Stop()
+	}
+
 	// Stop service manager
 	if err := mgr.Stop(); err != nil {
 		log.Printf("[Main] Manager stop error: %v", err)
@@ -100,3 +287,66 @@ func main() {
 
 	log.Println("[Main] Shutdown complete")
 }
+
+// runWatchdog sends systemd watchdog heartbeats at half the watchdog
+// interval while the service is healthy, so systemd restarts it once the
+// WhatsApp connection has been down for longer than WASVC_WATCHDOG_GRACE.
+func runWatchdog(ctx context.Context, mgr *service.Manager, interval time.Duration) {
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+
+	healthy := true
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if mgr.Healthy() {
+			if !healthy {
+				log.Println("[Main] Service healthy again, resuming watchdog heartbeats")
+			}
+			healthy = true
+			if err := sdnotify.Notify(sdnotify.Watchdog); err != nil {
+				log.Printf("[Main] %v", err)
+			}
+			continue
+		}
+		if healthy {
+			state := mgr.State()
+			log.Printf("[Main] Service unhealthy (%s since %s), withholding watchdog heartbeats",
+				state.State(), state.Since().Format(time.RFC3339))
+		}
+		healthy = false
+	}
+}
+
+// eventSink is implemented by every event delivery backend.
+type eventSink interface {
+	Emit(eventType string, data interface{})
+}
+
+// subscribe forwards received messages and all other service events to sink.
+func subscribe(mgr *service.Manager, sink eventSink) {
+	mgr.OnMessage(func(msg *service.ReceivedMessage) {
+		sink.Emit(service.EventMessageReceived, msg)
+	})
+	mgr.OnEvent(sink.Emit)
+}
+
+// subscribeWebhooks is subscribe for the webhook emitter, which also honors
+// the webhooks routing rules picked for a message and skips messages held
+// back by the no_webhook spam action.
+func subscribeWebhooks(mgr *service.Manager, emitter *webhook.Emitter) {
+	mgr.OnMessage(func(msg *service.ReceivedMessage) {
+		if msg.NoWebhook {
+			return
+		}
+		if len(msg.Webhooks) > 0 {
+			emitter.EmitTo(msg.Webhooks, service.EventMessageReceived, msg)
+			return
+		}
+		emitter.Emit(service.EventMessageReceived, msg)
+	})
+	mgr.OnEvent(emitter.Emit)
+}
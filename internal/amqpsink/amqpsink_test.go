@@ -0,0 +1,71 @@
+package amqpsink
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/webhook"
+)
+
+// refusingBroker accepts connections and closes them before the AMQP
+// handshake, so every publish fails. It counts the connection attempts.
+func refusingBroker(t *testing.T) (url string, dials *atomic.Int32) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	dials = &atomic.Int32{}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			dials.Add(1)
+			_ = conn.Close()
+		}
+	}()
+	return "amqp://guest:guest@" + ln.Addr().String() + "/", dials
+}
+
+func TestDeliverRetriesWithBackoff(t *testing.T) {
+	defer func(d time.Duration) { retryBackoff = d }(retryBackoff)
+	retryBackoff = 50 * time.Millisecond
+
+	url, dials := refusingBroker(t)
+	p := NewPublisher(Config{URL: url})
+
+	start := time.Now()
+	err := p.deliver(context.Background(), &webhook.Event{Type: "message.received"})
+	elapsed := time.Since(start)
+	if err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Fatalf("deliver error %v, want a failure after 3 attempts", err)
+	}
+	if n := dials.Load(); n != maxAttempts {
+		t.Fatalf("dialed %d times, want %d", n, maxAttempts)
+	}
+	// Backoff of 50ms, then 100ms.
+	if elapsed < 150*time.Millisecond {
+		t.Fatalf("gave up after %s, want at least 150ms of backoff", elapsed)
+	}
+}
+
+func TestDeliverStopsRetryingWhenCancelled(t *testing.T) {
+	url, dials := refusingBroker(t)
+	p := NewPublisher(Config{URL: url})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if err := p.deliver(ctx, &webhook.Event{Type: "message.received"}); err == nil {
+		t.Fatalf("deliver succeeded against a refusing broker")
+	}
+	if n := dials.Load(); n > 1 || time.Since(start) > retryBackoff {
+		t.Fatalf("kept retrying after cancel: %d dials in %s", n, time.Since(start))
+	}
+}
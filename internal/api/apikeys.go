@@ -0,0 +1,163 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/steipete/wacli/internal/service"
+	"github.com/steipete/wacli/internal/store"
+)
+
+// CreateAPIKey handles POST /admin/keys
+func (h *Handlers) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+
+	key, info, err := h.manager.IssueAPIKey(req.Label, req.ReadOnly)
+	if err != nil {
+		if errors.Is(err, service.ErrAPIKeyAuthDisabled) {
+			writeError(w, http.StatusConflict, err.Error(), "AUTH_DISABLED")
+			return
+		}
+		writeFailure(w, err, "CREATE_KEY_FAILED")
+		return
+	}
+
+	resp := apiKeyToResponse(info, time.Now())
+	resp.Key = key
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// RotateAPIKey handles POST /admin/keys/rotate
+func (h *Handlers) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req RotateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	grace := service.DefaultAPIKeyGrace
+	if req.GraceSeconds != nil {
+		if *req.GraceSeconds < 0 {
+			writeFieldError(w, "grace_seconds", "grace_seconds must not be negative", "INVALID_GRACE")
+			return
+		}
+		grace = time.Duration(*req.GraceSeconds) * time.Second
+	}
+
+	rot, err := h.manager.RotateAPIKey(req.Label, req.ReadOnly, grace)
+	if err != nil {
+		if errors.Is(err, service.ErrAPIKeyAuthDisabled) {
+			writeError(w, http.StatusConflict, err.Error(), "AUTH_DISABLED")
+			return
+		}
+		writeFailure(w, err, "ROTATE_KEY_FAILED")
+		return
+	}
+
+	resp := RotateAPIKeyResponse{
+		APIKeyResponse:   apiKeyToResponse(rot.Info, time.Now()),
+		PreviousExpireAt: rot.ExpiresAt,
+		PreviousKeys:     rot.Replaced,
+	}
+	resp.Key = rot.Key
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// ListAPIKeys handles GET /admin/keys
+func (h *Handlers) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.manager.ListAPIKeys()
+	if err != nil {
+		writeFailure(w, err, "LIST_KEYS_FAILED")
+		return
+	}
+
+	now := time.Now()
+	resp := APIKeysResponse{
+		Count: len(keys),
+		Keys:  make([]APIKeyResponse, len(keys)),
+	}
+	for i, k := range keys {
+		resp.Keys[i] = apiKeyToResponse(k, now)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// IssueAccessToken handles POST /auth/token
+func (h *Handlers) IssueAccessToken(w http.ResponseWriter, r *http.Request) {
+	var req AccessTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if req.TTLSeconds < 0 {
+		writeFieldError(w, "ttl_seconds", "ttl_seconds must not be negative", "INVALID_TTL")
+		return
+	}
+	if err := service.ValidateScopes(req.Scopes); err != nil {
+		writeFieldError(w, "scopes", err.Error(), "INVALID_SCOPE")
+		return
+	}
+	if !h.manager.Config().APIKeyAuth() {
+		writeError(w, http.StatusConflict, service.ErrAPIKeyAuthDisabled.Error(), "AUTH_DISABLED")
+		return
+	}
+	// Only an API key may be exchanged, whatever let the request through.
+	readOnly, ok := apiKeyRequest(r)
+	if !ok {
+		writeError(w, http.StatusForbidden, "an API key is required to issue access tokens", "FORBIDDEN")
+		return
+	}
+	if readOnly {
+		for _, s := range req.Scopes {
+			if s != service.ScopeRead {
+				writeError(w, http.StatusForbidden, "read-only key may only issue read tokens", "FORBIDDEN")
+				return
+			}
+		}
+	}
+
+	token, info, err := h.manager.IssueAccessToken(req.Scopes, time.Duration(req.TTLSeconds)*time.Second)
+	if err != nil {
+		if errors.Is(err, service.ErrAPIKeyAuthDisabled) {
+			writeError(w, http.StatusConflict, err.Error(), "AUTH_DISABLED")
+			return
+		}
+		writeFailure(w, err, "ISSUE_TOKEN_FAILED")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, AccessTokenResponse{
+		Token:     token,
+		TokenType: "Bearer",
+		Scopes:    info.Scopes,
+		ExpiresIn: int(time.Until(info.ExpiresAt).Round(time.Second).Seconds()),
+		ExpiresAt: info.ExpiresAt,
+	})
+}
+
+func apiKeyToResponse(k store.APIKey, now time.Time) APIKeyResponse {
+	resp := APIKeyResponse{
+		ID:        k.ID,
+		Hint:      k.Hint,
+		Label:     k.Label,
+		ReadOnly:  k.ReadOnly,
+		Active:    !k.Expired(now),
+		CreatedAt: k.CreatedAt,
+	}
+	if !k.ExpiresAt.IsZero() {
+		exp := k.ExpiresAt
+		resp.ExpiresAt = &exp
+	}
+	if !k.LastUsedAt.IsZero() {
+		used := k.LastUsedAt
+		resp.LastUsedAt = &used
+	}
+	return resp
+}
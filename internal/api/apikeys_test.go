@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/steipete/wacli/internal/service"
+)
+
+func TestIssueAccessTokenRequiresAPIKey(t *testing.T) {
+	cfg := service.DefaultConfig()
+	cfg.DataDir = t.TempDir()
+	cfg.APIKey = "secret"
+	mgr, err := service.NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	h := NewHandlers(mgr)
+
+	issue := func(r *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.IssueAccessToken(w, r)
+		return w
+	}
+	body := func() *http.Request {
+		return httptest.NewRequest("POST", "/auth/token", strings.NewReader(`{"scopes":["write"]}`))
+	}
+
+	if w := issue(body()); w.Code != http.StatusForbidden {
+		t.Fatalf("without API key: status %d, want 403; body %s", w.Code, w.Body)
+	}
+
+	r := body()
+	r = r.WithContext(context.WithValue(r.Context(), apiKeyKey{}, true))
+	if w := issue(r); w.Code != http.StatusForbidden {
+		t.Fatalf("write scope with read-only key: status %d, want 403", w.Code)
+	}
+
+	r = body()
+	r = r.WithContext(context.WithValue(r.Context(), apiKeyKey{}, false))
+	if w := issue(r); w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"wat_`) {
+		t.Fatalf("with API key: status %d, body %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/steipete/wacli/internal/service"
+	"github.com/steipete/wacli/internal/store"
+)
+
+// StartBackfillAll handles POST /history/backfill/all
+func (h *Handlers) StartBackfillAll(w http.ResponseWriter, r *http.Request) {
+	var req BackfillAllRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if req.Concurrency < 0 || req.Concurrency > 10 {
+		writeFieldError(w, "concurrency", "concurrency must be between 1 and 10", "INVALID_CONCURRENCY")
+		return
+	}
+	if req.IntervalSeconds < 0 {
+		writeFieldError(w, "interval_seconds", "interval_seconds must not be negative", "INVALID_INTERVAL")
+		return
+	}
+
+	_, err := h.manager.StartBackfillAll(service.BackfillAllParams{
+		Count:           req.Count,
+		Requests:        req.Requests,
+		WaitSeconds:     req.WaitPerRequestSeconds,
+		Concurrency:     req.Concurrency,
+		IntervalSeconds: req.IntervalSeconds,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrBackfillRunning):
+			writeError(w, http.StatusConflict, err.Error(), "BACKFILL_RUNNING")
+		default:
+			writeFailure(w, err, "BACKFILL_FAILED")
+		}
+		return
+	}
+
+	status, err := h.manager.BackfillAllStatus()
+	if err != nil {
+		writeFailure(w, err, "BACKFILL_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, backfillAllStatusToResponse(status))
+}
+
+// BackfillAllStatus handles GET /history/backfill/all
+func (h *Handlers) BackfillAllStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.manager.BackfillAllStatus()
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "no backfill run", "NOT_FOUND")
+			return
+		}
+		writeFailure(w, err, "BACKFILL_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusOK, backfillAllStatusToResponse(status))
+}
+
+// CancelBackfillAll handles DELETE /history/backfill/all
+func (h *Handlers) CancelBackfillAll(w http.ResponseWriter, r *http.Request) {
+	if err := h.manager.CancelBackfillAll(); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "no backfill run in progress", "NOT_FOUND")
+			return
+		}
+		writeFailure(w, err, "BACKFILL_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "backfill cancelled",
+	})
+}
+
+func backfillAllStatusToResponse(s service.BackfillAllStatus) BackfillAllResponse {
+	resp := BackfillAllResponse{
+		Status:                s.Run.Status,
+		Active:                s.Active,
+		Count:                 s.Run.Count,
+		Requests:              s.Run.Requests,
+		WaitPerRequestSeconds: int(s.Run.WaitPerRequest / time.Second),
+		Concurrency:           s.Run.Concurrency,
+		IntervalSeconds:       int(s.Run.Interval / time.Second),
+		StartedAt:             s.Run.StartedAt,
+		Pending:               s.Counts[store.BackfillPending] + s.Counts[store.BackfillRunning],
+		Done:                  s.Counts[store.BackfillDone],
+		Skipped:               s.Counts[store.BackfillSkipped],
+		Failed:                s.Counts[store.BackfillFailed],
+		FailedChats:           make([]BackfillChatResponse, len(s.Failed)),
+	}
+	for _, n := range s.Counts {
+		resp.Total += n
+	}
+	if !s.Run.FinishedAt.IsZero() {
+		finished := s.Run.FinishedAt
+		resp.FinishedAt = &finished
+	}
+	for i, c := range s.Failed {
+		resp.FailedChats[i] = BackfillChatResponse{ChatJID: c.ChatJID, Error: c.Error, UpdatedAt: c.UpdatedAt}
+	}
+	return resp
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// backupWriter sets the download headers on the first write, so errors that
+// happen before any data is produced can still be reported as JSON.
+type backupWriter struct {
+	w        http.ResponseWriter
+	filename string
+	started  bool
+}
+
+func (bw *backupWriter) Write(p []byte) (int, error) {
+	if !bw.started {
+		bw.started = true
+		bw.w.Header().Set("Content-Type", "application/gzip")
+		bw.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", bw.filename))
+		bw.w.WriteHeader(http.StatusOK)
+	}
+	return bw.w.Write(p)
+}
+
+// Backup handles POST /admin/backup?media=false
+func (h *Handlers) Backup(w http.ResponseWriter, r *http.Request) {
+	includeMedia := r.URL.Query().Get("media") != "false"
+
+	// Archives with media can take longer than the server's write timeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	bw := &backupWriter{w: w, filename: "wasvc-backup-" + time.Now().UTC().Format("20060102T150405Z") + ".tar.gz"}
+	manifest, err := h.manager.Backup(bw, includeMedia)
+	if err != nil {
+		if !bw.started {
+			writeFailure(w, err, "BACKUP_FAILED")
+			return
+		}
+		// Headers are gone; the client sees a truncated archive.
+		log.Printf("[API] Backup aborted mid-stream: %v", err)
+		return
+	}
+	log.Printf("[API] Backup written (%d databases, %d media files, media included: %v)",
+		len(manifest.Databases), len(manifest.Media), includeMedia)
+}
+
+// Restore handles POST /admin/restore with a backup archive as the body.
+// The archive is verified and staged, then the service shuts down so the
+// restore is applied on restart.
+func (h *Handlers) Restore(w http.ResponseWriter, r *http.Request) {
+	_ = http.NewResponseController(w).SetReadDeadline(time.Time{})
+
+	manifest, err := h.manager.StageRestore(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error(), "RESTORE_FAILED")
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, RestoreResponse{
+		Success:       true,
+		CreatedAt:     manifest.CreatedAt,
+		Databases:     len(manifest.Databases),
+		MediaFiles:    len(manifest.Media),
+		MediaIncluded: manifest.MediaIncluded,
+		Message:       "restore staged; the service is shutting down and applies it on the next start",
+	})
+	h.manager.RequestShutdown()
+}
@@ -0,0 +1,395 @@
+package api
+
+import (
+	"net/http"
+)
+
+// BrowserPage handles GET /browse. It serves a read-only chat browser that
+// talks to the regular API with the key the operator enters, so the page
+// itself needs no authentication.
+func (h *Handlers) BrowserPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(browserPageHTML))
+}
+
+const browserPageHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>WhatsApp Service - Message Browser</title>
+    <style>
+        * {
+            box-sizing: border-box;
+            margin: 0;
+            padding: 0;
+        }
+
+        body {
+            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Oxygen, Ubuntu, sans-serif;
+            background: #f0f2f5;
+            color: #1a1a1a;
+            height: 100vh;
+            display: flex;
+            flex-direction: column;
+        }
+
+        header {
+            background: #075e54;
+            color: white;
+            padding: 12px 20px;
+            display: flex;
+            align-items: center;
+            gap: 12px;
+        }
+
+        header h1 {
+            font-size: 18px;
+            font-weight: 600;
+            flex: 1;
+        }
+
+        header input {
+            padding: 8px 12px;
+            border: none;
+            border-radius: 6px;
+            font-size: 14px;
+            width: 260px;
+        }
+
+        button {
+            padding: 8px 14px;
+            border: none;
+            border-radius: 6px;
+            background: #25d366;
+            color: white;
+            font-size: 14px;
+            cursor: pointer;
+        }
+
+        button.link {
+            background: none;
+            color: #128c7e;
+            padding: 0;
+            font-size: 13px;
+        }
+
+        main {
+            flex: 1;
+            display: flex;
+            min-height: 0;
+        }
+
+        #sidebar {
+            width: 320px;
+            background: white;
+            border-right: 1px solid #e0e0e0;
+            display: flex;
+            flex-direction: column;
+        }
+
+        #chat-filter {
+            margin: 12px;
+            padding: 8px 12px;
+            border: 1px solid #d1d7db;
+            border-radius: 6px;
+            font-size: 14px;
+        }
+
+        #chats {
+            list-style: none;
+            overflow-y: auto;
+            flex: 1;
+        }
+
+        #chats li {
+            padding: 12px 16px;
+            border-bottom: 1px solid #f0f2f5;
+            cursor: pointer;
+        }
+
+        #chats li:hover, #chats li.active {
+            background: #f0f2f5;
+        }
+
+        .chat-name {
+            font-weight: 500;
+            overflow: hidden;
+            text-overflow: ellipsis;
+            white-space: nowrap;
+        }
+
+        .meta {
+            color: #667781;
+            font-size: 12px;
+            margin-top: 2px;
+        }
+
+        #pane {
+            flex: 1;
+            display: flex;
+            flex-direction: column;
+            min-width: 0;
+        }
+
+        #pane-title {
+            padding: 14px 20px;
+            background: white;
+            border-bottom: 1px solid #e0e0e0;
+            font-weight: 600;
+        }
+
+        #messages {
+            flex: 1;
+            overflow-y: auto;
+            padding: 20px;
+            display: flex;
+            flex-direction: column;
+            gap: 8px;
+        }
+
+        .message {
+            max-width: 70%;
+            background: white;
+            border-radius: 8px;
+            padding: 8px 12px;
+            box-shadow: 0 1px 1px rgba(0, 0, 0, 0.08);
+            align-self: flex-start;
+            white-space: pre-wrap;
+            word-wrap: break-word;
+        }
+
+        .message.from-me {
+            background: #d9fdd3;
+            align-self: flex-end;
+        }
+
+        .message img, .message video {
+            display: block;
+            max-width: 100%;
+            max-height: 320px;
+            margin-top: 6px;
+            border-radius: 6px;
+        }
+
+        .message audio {
+            display: block;
+            margin-top: 6px;
+        }
+
+        .empty, .error {
+            color: #667781;
+            text-align: center;
+            margin-top: 40px;
+        }
+
+        .error {
+            color: #c62828;
+        }
+    </style>
+</head>
+<body>
+    <header>
+        <h1>Message Browser</h1>
+        <input id="search-input" type="search" placeholder="Search messages">
+        <input id="key-input" type="password" placeholder="API key" autocomplete="off">
+        <button onclick="saveKey()">Connect</button>
+    </header>
+    <main>
+        <aside id="sidebar">
+            <input id="chat-filter" type="search" placeholder="Filter chats">
+            <ul id="chats"></ul>
+        </aside>
+        <section id="pane">
+            <div id="pane-title">Select a chat</div>
+            <div id="messages"><p class="empty">Enter your API key and pick a chat to browse its messages.</p></div>
+        </section>
+    </main>
+
+    <script>
+        // The key lives only in this tab; it is sent as X-API-Key like any client would.
+        let apiKey = sessionStorage.getItem('wasvc-api-key') || '';
+        let activeChat = '';
+        let objectURLs = [];
+
+        document.getElementById('key-input').value = apiKey;
+
+        async function api(path) {
+            const headers = apiKey ? { 'X-API-Key': apiKey } : {};
+            const response = await fetch(path, { headers });
+            if (!response.ok) {
+                let message = response.status + ' ' + response.statusText;
+                try {
+                    const body = await response.json();
+                    if (body.error) message = body.error;
+                } catch (e) {}
+                throw new Error(message);
+            }
+            return response;
+        }
+
+        function saveKey() {
+            apiKey = document.getElementById('key-input').value.trim();
+            sessionStorage.setItem('wasvc-api-key', apiKey);
+            loadChats();
+        }
+
+        function el(tag, className, text) {
+            const node = document.createElement(tag);
+            if (className) node.className = className;
+            if (text) node.textContent = text;
+            return node;
+        }
+
+        function formatTime(ts) {
+            if (!ts || ts.startsWith('0001-')) return '';
+            return new Date(ts).toLocaleString();
+        }
+
+        function showStatus(text, isError) {
+            const list = document.getElementById('messages');
+            list.replaceChildren(el('p', isError ? 'error' : 'empty', text));
+        }
+
+        async function loadChats() {
+            const q = document.getElementById('chat-filter').value.trim();
+            const list = document.getElementById('chats');
+            try {
+                const params = new URLSearchParams({ limit: '200' });
+                if (q) params.set('q', q);
+                const data = await (await api('/chats?' + params)).json();
+                list.replaceChildren();
+                for (const chat of data.chats) {
+                    const item = el('li');
+                    item.dataset.jid = chat.jid;
+                    if (chat.jid === activeChat) item.classList.add('active');
+                    item.append(el('div', 'chat-name', chat.name || chat.jid));
+                    item.append(el('div', 'meta', [chat.kind, formatTime(chat.last_message_ts)].filter(Boolean).join(' · ')));
+                    item.onclick = () => openChat(chat.jid, chat.name || chat.jid);
+                    list.append(item);
+                }
+                if (data.chats.length === 0) list.append(el('li', 'meta', 'No chats'));
+            } catch (error) {
+                list.replaceChildren();
+                showStatus('Failed to load chats: ' + error.message, true);
+            }
+        }
+
+        async function openChat(jid, name) {
+            activeChat = jid;
+            for (const item of document.querySelectorAll('#chats li')) {
+                item.classList.toggle('active', item.dataset.jid === jid);
+            }
+            document.getElementById('pane-title').textContent = name;
+            showStatus('Loading...');
+            try {
+                const data = await (await api('/chats/' + encodeURIComponent(jid) + '/messages?limit=200')).json();
+                // The API returns the newest first; read top to bottom instead.
+                renderMessages(data.messages.reverse(), false);
+            } catch (error) {
+                showStatus('Failed to load messages: ' + error.message, true);
+            }
+        }
+
+        async function search() {
+            const q = document.getElementById('search-input').value.trim();
+            if (!q) return;
+            activeChat = '';
+            for (const item of document.querySelectorAll('#chats li')) item.classList.remove('active');
+            document.getElementById('pane-title').textContent = 'Search: ' + q;
+            showStatus('Searching...');
+            try {
+                const data = await (await api('/search?' + new URLSearchParams({ q, limit: '200' }))).json();
+                renderMessages(data.messages, true);
+            } catch (error) {
+                showStatus('Search failed: ' + error.message, true);
+            }
+        }
+
+        function renderMessages(messages, withChat) {
+            for (const url of objectURLs) URL.revokeObjectURL(url);
+            objectURLs = [];
+            const list = document.getElementById('messages');
+            list.replaceChildren();
+            if (messages.length === 0) {
+                showStatus('No messages');
+                return;
+            }
+            for (const msg of messages) {
+                const bubble = el('div', msg.from_me ? 'message from-me' : 'message');
+                const meta = [formatTime(msg.timestamp)];
+                if (withChat) meta.unshift(msg.chat_name || msg.chat_jid);
+                if (!msg.from_me && msg.sender_jid && msg.sender_jid !== msg.chat_jid) meta.unshift(msg.sender_jid);
+                bubble.append(el('div', 'meta', meta.join(' · ')));
+                if (msg.text || msg.snippet) bubble.append(el('div', '', msg.text || msg.snippet));
+                if (msg.media_type) {
+                    const button = el('button', 'link', 'Preview ' + msg.media_type);
+                    button.onclick = () => previewMedia(bubble, button, msg);
+                    bubble.append(button);
+                }
+                if (withChat) {
+                    const open = el('button', 'link', 'Open chat');
+                    open.onclick = () => openChat(msg.chat_jid, msg.chat_name || msg.chat_jid);
+                    bubble.append(el('br'), open);
+                }
+                list.append(bubble);
+            }
+            if (!withChat) list.scrollTop = list.scrollHeight;
+        }
+
+        async function previewMedia(bubble, button, msg) {
+            button.disabled = true;
+            button.textContent = 'Loading ' + msg.media_type + '...';
+            try {
+                const path = '/media/' + encodeURIComponent(msg.chat_jid) + '/' + encodeURIComponent(msg.msg_id) + '/content?inline=true';
+                const blob = await (await api(path)).blob();
+                const url = URL.createObjectURL(blob);
+                objectURLs.push(url);
+                let node;
+                if (msg.media_type === 'image' || msg.media_type === 'sticker') {
+                    node = el('img');
+                    node.src = url;
+                } else if (msg.media_type === 'video') {
+                    node = el('video');
+                    node.src = url;
+                    node.controls = true;
+                } else if (msg.media_type === 'gif') {
+                    node = el('video');
+                    node.src = url;
+                    node.autoplay = node.loop = node.muted = true;
+                } else if (msg.media_type === 'audio') {
+                    node = el('audio');
+                    node.src = url;
+                    node.controls = true;
+                } else {
+                    node = el('a', '', 'Open ' + msg.media_type);
+                    node.href = url;
+                    node.target = '_blank';
+                    node.rel = 'noopener';
+                }
+                button.replaceWith(node);
+            } catch (error) {
+                button.disabled = false;
+                button.textContent = 'Preview failed: ' + error.message;
+            }
+        }
+
+        let filterTimer = null;
+        document.getElementById('chat-filter').addEventListener('input', () => {
+            clearTimeout(filterTimer);
+            filterTimer = setTimeout(loadChats, 300);
+        });
+        document.getElementById('search-input').addEventListener('keydown', (event) => {
+            if (event.key === 'Enter') search();
+        });
+        document.getElementById('key-input').addEventListener('keydown', (event) => {
+            if (event.key === 'Enter') saveKey();
+        });
+
+        loadChats();
+    </script>
+</body>
+</html>
+`
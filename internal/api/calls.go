@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// ListCalls handles GET /calls
+func (h *Handlers) ListCalls(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	p := store.ListCallsParams{FromJID: q.Get("from"), Limit: 50}
+	if l := q.Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 {
+			p.Limit = n
+		}
+	}
+	if p.Limit > 200 {
+		p.Limit = 200
+	}
+	if v := q.Get("before"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeFieldError(w, "before", "before must be RFC3339", "INVALID_BEFORE")
+			return
+		}
+		p.Before = &t
+	}
+
+	calls, err := h.manager.ListCalls(p)
+	if err != nil {
+		writeFailure(w, err, "LIST_CALLS_FAILED")
+		return
+	}
+
+	resp := CallsResponse{Count: len(calls), Calls: make([]CallResponse, len(calls))}
+	for i, c := range calls {
+		resp.Calls[i] = CallResponse{
+			CallID:    c.CallID,
+			FromJID:   c.FromJID,
+			GroupJID:  c.GroupJID,
+			Video:     c.Video,
+			Status:    c.Status,
+			Timestamp: c.Timestamp,
+			EndedAt:   optionalTime(c.EndedAt),
+			EndReason: c.EndReason,
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
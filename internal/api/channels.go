@@ -0,0 +1,151 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// ListChannels handles GET /channels
+func (h *Handlers) ListChannels(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	channels, err := h.manager.ListChannels(r.URL.Query().Get("q"), limit)
+	if err != nil {
+		writeFailure(w, err, "LIST_CHANNELS_FAILED")
+		return
+	}
+	resp := ChannelsResponse{
+		Count:    len(channels),
+		Channels: make([]ChannelResponse, len(channels)),
+	}
+	for i, c := range channels {
+		resp.Channels[i] = channelToResponse(c)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetChannel handles GET /channels/{jid}
+func (h *Handlers) GetChannel(w http.ResponseWriter, r *http.Request) {
+	c, err := h.manager.GetChannel(r.PathValue("jid"))
+	if err != nil {
+		writeFailure(w, err, "GET_CHANNEL_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusOK, channelToResponse(c))
+}
+
+// ListChannelPosts handles GET /channels/{jid}/messages. With ?refresh=true
+// the latest posts are fetched from WhatsApp first.
+func (h *Handlers) ListChannelPosts(w http.ResponseWriter, r *http.Request) {
+	channelJID := r.PathValue("jid")
+	q := r.URL.Query()
+	limit := 50
+	if l := q.Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	var before *time.Time
+	if v := q.Get("before"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeFieldError(w, "before", "before must be RFC3339", "INVALID_BEFORE")
+			return
+		}
+		before = &t
+	}
+	refresh := false
+	if v := q.Get("refresh"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeFieldError(w, "refresh", "refresh must be true or false", "INVALID_REQUEST")
+			return
+		}
+		refresh = b
+	}
+
+	posts, err := h.manager.ListChannelPosts(r.Context(), channelJID, limit, before, refresh)
+	if err != nil {
+		writeFailure(w, err, "LIST_MESSAGES_FAILED")
+		return
+	}
+	resp := ChannelPostsResponse{
+		ChannelJID: channelJID,
+		Count:      len(posts),
+		Posts:      make([]ChannelPostResponse, len(posts)),
+	}
+	for i, p := range posts {
+		resp.ChannelJID = p.ChannelJID
+		resp.Posts[i] = ChannelPostResponse{
+			MsgID:     p.MsgID,
+			ServerID:  p.ServerID,
+			Timestamp: p.Timestamp,
+			Text:      p.Text,
+			MediaType: p.MediaType,
+			Caption:   p.MediaCaption,
+			Views:     p.Views,
+			Reactions: p.Reactions,
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// FollowChannel handles PUT (follow) and DELETE (unfollow) on
+// /channels/{jid}/follow.
+func (h *Handlers) FollowChannel(w http.ResponseWriter, r *http.Request) {
+	channelJID := r.PathValue("jid")
+	if r.Method == http.MethodDelete {
+		if err := h.manager.UnfollowChannel(r.Context(), channelJID); err != nil {
+			writeFailure(w, err, "FOLLOW_FAILED")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"success":     true,
+			"channel_jid": channelJID,
+			"followed":    false,
+		})
+		return
+	}
+
+	c, err := h.manager.FollowChannel(r.Context(), channelJID)
+	if err != nil {
+		writeFailure(w, err, "FOLLOW_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusOK, channelToResponse(c))
+}
+
+func channelToResponse(c store.Channel) ChannelResponse {
+	resp := ChannelResponse{
+		JID:         c.JID,
+		Name:        c.Name,
+		Description: c.Description,
+		Subscribers: c.Subscribers,
+		Verified:    c.Verified,
+		Role:        c.Role,
+		Muted:       c.Muted,
+		Followed:    c.Followed,
+	}
+	if c.InviteCode != "" {
+		resp.InviteLink = "https://whatsapp.com/channel/" + c.InviteCode
+	}
+	if !c.LastPostAt.IsZero() {
+		t := c.LastPostAt
+		resp.LastPostAt = &t
+	}
+	return resp
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// SetChatState handles PUT (set) and DELETE (clear) on /chats/{jid}/archive,
+// /chats/{jid}/pin, /chats/{jid}/mute and /chats/{jid}/read. PUT /mute
+// accepts an optional {"duration_seconds": N}; without it the chat is muted
+// forever.
+func (h *Handlers) SetChatState(w http.ResponseWriter, r *http.Request) {
+	chatJID, state := r.PathValue("jid"), r.PathValue("state")
+	on := r.Method == http.MethodPut
+
+	var err error
+	resp := map[string]interface{}{"success": true, "chat_jid": chatJID}
+	switch state {
+	case "archive":
+		err = h.manager.SetChatArchived(r.Context(), chatJID, on)
+		resp["archived"] = on
+	case "pin":
+		err = h.manager.SetChatPinned(r.Context(), chatJID, on)
+		resp["pinned"] = on
+	case "read":
+		err = h.manager.MarkChatRead(r.Context(), chatJID, on)
+		resp["read"] = on
+	case "mute":
+		var req MuteChatRequest
+		if on {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+				writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+				return
+			}
+			if req.DurationSeconds < 0 {
+				writeFieldError(w, "duration_seconds", "duration_seconds must not be negative", "INVALID_DURATION")
+				return
+			}
+		}
+		duration := time.Duration(req.DurationSeconds) * time.Second
+		err = h.manager.MuteChat(r.Context(), chatJID, on, duration)
+		resp["muted"] = on
+		if on && duration > 0 {
+			resp["muted_until"] = time.Now().Add(duration).UTC()
+		}
+	default:
+		writeError(w, http.StatusNotFound, "endpoint not found", "NOT_FOUND")
+		return
+	}
+
+	if err != nil {
+		writeFailure(w, err, "CHAT_STATE_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ClearChatMessages handles DELETE /chats/{jid}/messages. It removes the
+// chat's stored messages and downloaded media; with ?sync=true the chat is
+// also cleared on WhatsApp.
+func (h *Handlers) ClearChatMessages(w http.ResponseWriter, r *http.Request) {
+	chatJID, ok := h.chatParam(w, r, "jid")
+	if !ok {
+		return
+	}
+	syncToPhone := false
+	if v := r.URL.Query().Get("sync"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeFieldError(w, "sync", "sync must be true or false", "INVALID_REQUEST")
+			return
+		}
+		syncToPhone = b
+	}
+
+	res, err := h.manager.ClearChatHistory(r.Context(), chatJID, syncToPhone)
+	if err != nil {
+		writeFailure(w, err, "CLEAR_CHAT_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusOK, ClearChatResponse{
+		Success:         true,
+		ChatJID:         res.ChatJID,
+		MessagesDeleted: res.Messages,
+		MediaFiles:      res.MediaFiles,
+		MediaBytes:      res.MediaBytes,
+		Synced:          res.Synced,
+	})
+}
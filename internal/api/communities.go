@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ListCommunities handles GET /communities
+func (h *Handlers) ListCommunities(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	communities, err := h.manager.ListCommunities(r.URL.Query().Get("q"), limit)
+	if err != nil {
+		writeFailure(w, err, "LIST_COMMUNITIES_FAILED")
+		return
+	}
+	resp := CommunitiesResponse{
+		Count:       len(communities),
+		Communities: make([]CommunityResponse, len(communities)),
+	}
+	for i, c := range communities {
+		resp.Communities[i] = CommunityResponse{
+			JID:             c.JID,
+			Name:            c.Name,
+			Description:     c.Description,
+			GroupCount:      c.GroupCount,
+			AnnouncementJID: c.AnnouncementJID,
+			CreatedAt:       c.CreatedAt,
+			UpdatedAt:       c.UpdatedAt,
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ListCommunityGroups handles GET /communities/{jid}/groups and
+// GET /communities/{jid}/announcements, which only returns the announcement
+// group. With ?refresh=true the linked groups are fetched from WhatsApp first.
+func (h *Handlers) ListCommunityGroups(w http.ResponseWriter, r *http.Request) {
+	communityJID := r.PathValue("jid")
+	announcementsOnly := strings.HasSuffix(r.URL.Path, "/announcements")
+
+	refresh := false
+	if v := r.URL.Query().Get("refresh"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeFieldError(w, "refresh", "refresh must be true or false", "INVALID_REQUEST")
+			return
+		}
+		refresh = b
+	}
+
+	groups, err := h.manager.ListCommunityGroups(r.Context(), communityJID, refresh)
+	if err != nil {
+		writeFailure(w, err, "LIST_COMMUNITY_GROUPS_FAILED")
+		return
+	}
+	resp := CommunityGroupsResponse{
+		CommunityJID: communityJID,
+		Groups:       []GroupResponse{},
+	}
+	for _, g := range groups {
+		if announcementsOnly && !g.IsAnnouncementGroup {
+			continue
+		}
+		resp.CommunityJID = g.CommunityJID
+		resp.Groups = append(resp.Groups, groupToResponse(g))
+	}
+	resp.Count = len(resp.Groups)
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// minCompressBytes is the smallest response body worth compressing.
+const minCompressBytes = 1024
+
+// encoder is a compressor that can be flushed mid-stream and reused.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+type zstdEncoder struct{ *zstd.Encoder }
+
+func (e zstdEncoder) Reset(w io.Writer) { e.Encoder.Reset(w) }
+
+var encoderPools = map[string]*sync.Pool{
+	"zstd": {New: func() interface{} {
+		e, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+		return zstdEncoder{e}
+	}},
+	"gzip": {New: func() interface{} {
+		e, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return e
+	}},
+}
+
+// CompressionMiddleware compresses JSON, text and CSV responses with zstd or
+// gzip, whichever the client prefers in Accept-Encoding, zstd on a tie.
+// Small bodies and media, which is compressed already, are sent as is.
+// Without enabled responses are never compressed.
+func CompressionMiddleware(enabled bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, status: http.StatusOK}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding picks zstd or gzip from an Accept-Encoding header, or
+// returns "" if the client accepts neither.
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if name == "*" {
+			name = "gzip"
+		}
+		if _, ok := encoderPools[name]; !ok || q <= 0 {
+			continue
+		}
+		if q > bestQ || q == bestQ && name == "zstd" {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// compressible reports whether a response of this content type gains from
+// compression.
+func compressible(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mt, "text/") || mt == "application/json" || mt == "application/x-ndjson" || strings.HasSuffix(mt, "+json")
+}
+
+// compressWriter holds back the first minCompressBytes of a response, then
+// decides whether to compress it.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	status   int
+	buf      []byte
+	started  bool
+	enc      encoder
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if !w.started {
+		w.status = code
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.started {
+		if w.enc != nil {
+			return w.enc.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= minCompressBytes {
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// start writes the header and anything held back, compressing the body if
+// compress is set and the response qualifies.
+func (w *compressWriter) start(compress bool) error {
+	w.started = true
+	h := w.ResponseWriter.Header()
+	if compress && h.Get("Content-Encoding") == "" && compressible(h.Get("Content-Type")) &&
+		w.status != http.StatusNoContent && w.status != http.StatusNotModified {
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		w.enc = encoderPools[w.encoding].Get().(encoder)
+		w.enc.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// Flush sends what has been written so far, compressed if compressing.
+func (w *compressWriter) Flush() {
+	if !w.started {
+		_ = w.start(true)
+	}
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close finishes the response and returns the encoder to its pool.
+func (w *compressWriter) Close() {
+	if !w.started {
+		_ = w.start(len(w.buf) >= minCompressBytes)
+	}
+	if w.enc != nil {
+		_ = w.enc.Close()
+		encoderPools[w.encoding].Put(w.enc)
+		w.enc = nil
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -1,10 +1,14 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+
+	"github.com/steipete/wacli/internal/service"
 )
 
 // SearchContacts handles GET /contacts
@@ -22,7 +26,7 @@ func (h *Handlers) SearchContacts(w http.ResponseWriter, r *http.Request) {
 
 	contacts, err := h.manager.SearchContacts(query, limit)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "SEARCH_CONTACTS_FAILED")
+		writeFailure(w, err, "SEARCH_CONTACTS_FAILED")
 		return
 	}
 
@@ -46,17 +50,9 @@ func (h *Handlers) SearchContacts(w http.ResponseWriter, r *http.Request) {
 
 // GetContact handles GET /contacts/{jid}
 func (h *Handlers) GetContact(w http.ResponseWriter, r *http.Request) {
-	jid := strings.TrimPrefix(r.URL.Path, "/contacts/")
-	jid = strings.TrimSuffix(jid, "/")
-
+	jid := r.PathValue("jid")
 	if strings.TrimSpace(jid) == "" {
-		writeError(w, http.StatusBadRequest, "JID is required", "MISSING_JID")
-		return
-	}
-
-	// Check if this is a sub-resource path
-	if strings.Contains(jid, "/") {
-		writeError(w, http.StatusNotFound, "endpoint not found", "NOT_FOUND")
+		writeFieldError(w, "jid", "JID is required", "MISSING_JID")
 		return
 	}
 
@@ -80,7 +76,7 @@ func (h *Handlers) GetContact(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) RefreshContacts(w http.ResponseWriter, r *http.Request) {
 	count, err := h.manager.RefreshContacts(r.Context())
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "REFRESH_CONTACTS_FAILED")
+		writeFailure(w, err, "REFRESH_CONTACTS_FAILED")
 		return
 	}
 
@@ -92,14 +88,7 @@ func (h *Handlers) RefreshContacts(w http.ResponseWriter, r *http.Request) {
 
 // SetContactAlias handles PUT /contacts/{jid}/alias
 func (h *Handlers) SetContactAlias(w http.ResponseWriter, r *http.Request) {
-	// Extract JID from path: /contacts/{jid}/alias
-	path := strings.TrimPrefix(r.URL.Path, "/contacts/")
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 || parts[1] != "alias" {
-		writeError(w, http.StatusBadRequest, "invalid path", "INVALID_PATH")
-		return
-	}
-	jid := parts[0]
+	jid := r.PathValue("jid")
 
 	var req SetAliasRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -108,12 +97,12 @@ func (h *Handlers) SetContactAlias(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if strings.TrimSpace(req.Alias) == "" {
-		writeError(w, http.StatusBadRequest, "alias is required", "MISSING_ALIAS")
+		writeFieldError(w, "alias", "alias is required", "MISSING_ALIAS")
 		return
 	}
 
 	if err := h.manager.SetContactAlias(jid, req.Alias); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "SET_ALIAS_FAILED")
+		writeFailure(w, err, "SET_ALIAS_FAILED")
 		return
 	}
 
@@ -126,17 +115,10 @@ func (h *Handlers) SetContactAlias(w http.ResponseWriter, r *http.Request) {
 
 // DeleteContactAlias handles DELETE /contacts/{jid}/alias
 func (h *Handlers) DeleteContactAlias(w http.ResponseWriter, r *http.Request) {
-	// Extract JID from path: /contacts/{jid}/alias
-	path := strings.TrimPrefix(r.URL.Path, "/contacts/")
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 || parts[1] != "alias" {
-		writeError(w, http.StatusBadRequest, "invalid path", "INVALID_PATH")
-		return
-	}
-	jid := parts[0]
+	jid := r.PathValue("jid")
 
 	if err := h.manager.RemoveContactAlias(jid); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "DELETE_ALIAS_FAILED")
+		writeFailure(w, err, "DELETE_ALIAS_FAILED")
 		return
 	}
 
@@ -148,14 +130,7 @@ func (h *Handlers) DeleteContactAlias(w http.ResponseWriter, r *http.Request) {
 
 // AddContactTag handles POST /contacts/{jid}/tags
 func (h *Handlers) AddContactTag(w http.ResponseWriter, r *http.Request) {
-	// Extract JID from path: /contacts/{jid}/tags
-	path := strings.TrimPrefix(r.URL.Path, "/contacts/")
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 || parts[1] != "tags" {
-		writeError(w, http.StatusBadRequest, "invalid path", "INVALID_PATH")
-		return
-	}
-	jid := parts[0]
+	jid := r.PathValue("jid")
 
 	var req AddTagRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -164,12 +139,12 @@ func (h *Handlers) AddContactTag(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if strings.TrimSpace(req.Tag) == "" {
-		writeError(w, http.StatusBadRequest, "tag is required", "MISSING_TAG")
+		writeFieldError(w, "tag", "tag is required", "MISSING_TAG")
 		return
 	}
 
 	if err := h.manager.AddContactTag(jid, req.Tag); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "ADD_TAG_FAILED")
+		writeFailure(w, err, "ADD_TAG_FAILED")
 		return
 	}
 
@@ -182,23 +157,15 @@ func (h *Handlers) AddContactTag(w http.ResponseWriter, r *http.Request) {
 
 // DeleteContactTag handles DELETE /contacts/{jid}/tags/{tag}
 func (h *Handlers) DeleteContactTag(w http.ResponseWriter, r *http.Request) {
-	// Extract JID and tag from path: /contacts/{jid}/tags/{tag}
-	path := strings.TrimPrefix(r.URL.Path, "/contacts/")
-	parts := strings.Split(path, "/")
-	if len(parts) < 3 || parts[1] != "tags" {
-		writeError(w, http.StatusBadRequest, "invalid path", "INVALID_PATH")
-		return
-	}
-	jid := parts[0]
-	tag := parts[2]
+	jid, tag := r.PathValue("jid"), r.PathValue("tag")
 
 	if strings.TrimSpace(tag) == "" {
-		writeError(w, http.StatusBadRequest, "tag is required", "MISSING_TAG")
+		writeFieldError(w, "tag", "tag is required", "MISSING_TAG")
 		return
 	}
 
 	if err := h.manager.RemoveContactTag(jid, tag); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "DELETE_TAG_FAILED")
+		writeFailure(w, err, "DELETE_TAG_FAILED")
 		return
 	}
 
@@ -208,3 +175,143 @@ func (h *Handlers) DeleteContactTag(w http.ResponseWriter, r *http.Request) {
 		Tag:     tag,
 	})
 }
+
+// ListBlocked handles GET /contacts/blocked. With ?refresh=true the
+// blocklist is re-read from WhatsApp first.
+func (h *Handlers) ListBlocked(w http.ResponseWriter, r *http.Request) {
+	refresh := false
+	if v := r.URL.Query().Get("refresh"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeFieldError(w, "refresh", "refresh must be true or false", "INVALID_REQUEST")
+			return
+		}
+		refresh = b
+	}
+
+	blocked, err := h.manager.ListBlocked(r.Context(), refresh)
+	if err != nil {
+		writeFailure(w, err, "LIST_BLOCKED_FAILED")
+		return
+	}
+
+	resp := BlockedContactsResponse{
+		Count:    len(blocked),
+		Contacts: make([]BlockedContactResponse, len(blocked)),
+	}
+	for i, b := range blocked {
+		resp.Contacts[i] = BlockedContactResponse{
+			JID:       b.JID,
+			Name:      b.Name,
+			BlockedAt: b.BlockedAt,
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// BlockContact handles POST /contacts/{jid}/block and
+// POST /contacts/{jid}/unblock
+func (h *Handlers) BlockContact(w http.ResponseWriter, r *http.Request) {
+	jid, block := r.PathValue("jid"), strings.HasSuffix(r.URL.Path, "/block")
+
+	if err := h.manager.SetBlocked(r.Context(), jid, block); err != nil {
+		writeFailure(w, err, "BLOCK_FAILED")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, BlockResponse{
+		Success: true,
+		JID:     jid,
+		Blocked: block,
+	})
+}
+
+// maxCheckPhones bounds a single POST /contacts/check request.
+const maxCheckPhones = 500
+
+// CheckContacts handles POST /contacts/check
+func (h *Handlers) CheckContacts(w http.ResponseWriter, r *http.Request) {
+	var req CheckContactsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if len(req.Phones) == 0 {
+		writeFieldError(w, "phones", "phones are required", "MISSING_PHONES")
+		return
+	}
+	if len(req.Phones) > maxCheckPhones {
+		writeFieldError(w, "phones", "at most 500 phones per request", "TOO_MANY_PHONES")
+		return
+	}
+
+	checks, err := h.manager.CheckNumbers(r.Context(), req.Phones)
+	if err != nil {
+		writeFailure(w, err, "CHECK_FAILED")
+		return
+	}
+
+	resp := CheckContactsResponse{
+		Count:   len(checks),
+		Results: make([]ContactCheckResponse, len(checks)),
+	}
+	for i, c := range checks {
+		if c.Registered {
+			resp.Registered++
+		}
+		resp.Results[i] = ContactCheckResponse{
+			Phone:        c.Phone,
+			Query:        c.Query,
+			Registered:   c.Registered,
+			JID:          c.JID,
+			BusinessName: c.BusinessName,
+			Error:        c.Error,
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// maxContactImportBytes bounds the CSV body of POST /contacts/import.
+const maxContactImportBytes = 10 << 20
+
+// ImportContacts handles POST /contacts/import with a CSV body.
+func (h *Handlers) ImportContacts(w http.ResponseWriter, r *http.Request) {
+	body := http.MaxBytesReader(w, r.Body, maxContactImportBytes)
+	res, err := h.manager.ImportContacts(r.Context(), body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			writeError(w, http.StatusRequestEntityTooLarge, "CSV larger than 10 MB", "CSV_TOO_LARGE")
+		case errors.Is(err, service.ErrInvalidCSV):
+			writeError(w, http.StatusBadRequest, err.Error(), "INVALID_CSV")
+		default:
+			writeFailure(w, err, "IMPORT_FAILED")
+		}
+		return
+	}
+
+	resp := ContactImportResponse{
+		Success:  true,
+		Rows:     res.Rows,
+		Imported: res.Imported,
+		Resolved: res.Resolved,
+	}
+	for _, s := range res.Skipped {
+		resp.Skipped = append(resp.Skipped, ContactImportErrorResponse{Line: s.Line, Phone: s.Phone, Error: s.Error})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ExportContacts handles GET /contacts/export
+func (h *Handlers) ExportContacts(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if _, err := h.manager.ExportContacts(&buf); err != nil {
+		writeFailure(w, err, "EXPORT_FAILED")
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="contacts.csv"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
@@ -1,7 +1,10 @@
 package api
 
 import (
+	"encoding/json"
 	"time"
+
+	"github.com/steipete/wacli/internal/rules"
 )
 
 // --- Request DTOs ---
@@ -20,6 +23,17 @@ type SendFileRequest struct {
 	Filename string `json:"filename,omitempty"`
 	Caption  string `json:"caption,omitempty"`
 	MimeType string `json:"mime_type,omitempty"`
+	UploadID string `json:"upload_id,omitempty"` // a completed upload session
+	// Preview image (base64 JPEG, PNG or GIF) instead of the generated one
+	ThumbnailData string `json:"thumbnail_data,omitempty"`
+	GIFPlayback   bool   `json:"gif_playback,omitempty"` // loop a video like a GIF
+}
+
+// CreateUploadRequest is the request body for POST /uploads.
+type CreateUploadRequest struct {
+	Filename string `json:"filename,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+	Size     int64  `json:"size"` // total bytes
 }
 
 // --- Response DTOs ---
@@ -29,15 +43,28 @@ type ErrorResponse struct {
 	Error   string `json:"error"`
 	Code    string `json:"code,omitempty"`
 	Details string `json:"details,omitempty"`
+	// Fields lists the request fields that failed validation, if any.
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError is one invalid or missing request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 // HealthResponse is returned by the health check endpoint.
 type HealthResponse struct {
-	Status    string `json:"status"`
-	State     string `json:"state"`
-	Ready     bool   `json:"ready"`
-	Version   string `json:"version"`
-	Timestamp string `json:"timestamp"`
+	Status            string     `json:"status"`
+	State             string     `json:"state"`
+	Ready             bool       `json:"ready"`
+	Version           string     `json:"version"`
+	Timestamp         string     `json:"timestamp"`
+	UptimeSeconds     int64      `json:"uptime_seconds"`
+	LastEventAt       *time.Time `json:"last_event_at,omitempty"`
+	ReconnectCount    int64      `json:"reconnect_count"`
+	WebhookQueueDepth int64      `json:"webhook_queue_depth"`
+	DBReachable       bool       `json:"db_reachable"`
 }
 
 // AuthStatusResponse is returned by the auth status endpoint.
@@ -46,6 +73,7 @@ type AuthStatusResponse struct {
 	Authenticated bool   `json:"authenticated"`
 	Ready         bool   `json:"ready"`
 	HasQR         bool   `json:"has_qr"`
+	PairCode      string `json:"pair_code,omitempty"` // while linking by phone number
 	Error         string `json:"error,omitempty"`
 }
 
@@ -57,11 +85,64 @@ type QRCodeResponse struct {
 	Error   string `json:"error,omitempty"`
 }
 
+// PairCodeRequest is the request body for POST /auth/pair-code.
+type PairCodeRequest struct {
+	Phone string `json:"phone"` // international format, e.g. +15550109999
+}
+
+// PairCodeResponse carries the code to enter on the phone.
+type PairCodeResponse struct {
+	PairCode string `json:"pair_code"`
+	State    string `json:"state"`
+}
+
 // SendMessageResponse is returned after sending a message.
 type SendMessageResponse struct {
 	Success   bool   `json:"success"`
 	MessageID string `json:"message_id"`
 	To        string `json:"to"`
+	Queued    bool   `json:"queued,omitempty"`    // held in the outbox until reconnected
+	OutboxID  int64  `json:"outbox_id,omitempty"` // see GET /outbox/{id}
+}
+
+// FailedSendResponse is a send that failed at WhatsApp and can be retried.
+type FailedSendResponse struct {
+	ID        int64     `json:"id"`
+	ChatJID   string    `json:"chat_jid"`
+	Kind      string    `json:"kind"`           // text|file
+	Text      string    `json:"text,omitempty"` // message, or caption of a file
+	Filename  string    `json:"filename,omitempty"`
+	MimeType  string    `json:"mime_type,omitempty"`
+	Size      int64     `json:"size,omitempty"`
+	Error     string    `json:"error"`
+	Attempts  int       `json:"attempts"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FailedSendsResponse is returned by GET /messages/failed.
+type FailedSendsResponse struct {
+	Count    int                  `json:"count"`
+	Messages []FailedSendResponse `json:"messages"`
+}
+
+// OutboxMessageResponse is a message in the outbox.
+type OutboxMessageResponse struct {
+	ID        int64     `json:"id"`
+	ChatJID   string    `json:"chat_jid"`
+	Text      string    `json:"text"`
+	Status    string    `json:"status"` // queued|sending|sent|failed|cancelled
+	MessageID string    `json:"message_id,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Attempts  int       `json:"attempts"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OutboxResponse is returned by GET /outbox.
+type OutboxResponse struct {
+	Count    int                     `json:"count"`
+	Messages []OutboxMessageResponse `json:"messages"`
 }
 
 // MessageResponse represents a message in API responses.
@@ -70,11 +151,73 @@ type MessageResponse struct {
 	ChatName  string    `json:"chat_name"`
 	MsgID     string    `json:"msg_id"`
 	SenderJID string    `json:"sender_jid,omitempty"`
+	SenderPN  string    `json:"sender_pn,omitempty"`  // phone-number JID of the sender, if known
+	SenderLID string    `json:"sender_lid,omitempty"` // LID of the sender, if known
 	Timestamp time.Time `json:"timestamp"`
 	FromMe    bool      `json:"from_me"`
 	Text      string    `json:"text,omitempty"`
 	MediaType string    `json:"media_type,omitempty"`
+	Duration  int       `json:"duration,omitempty"` // seconds, audio and video
+	SpamScore float64   `json:"spam_score,omitempty"`
+	Starred   bool      `json:"starred,omitempty"`
 	Snippet   string    `json:"snippet,omitempty"`
+	Rank      float64   `json:"rank,omitempty"` // search relevance, higher is better
+	// Delivery state of our own messages
+	Status      string     `json:"status,omitempty"` // sent|delivered|read|played
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	ReadAt      *time.Time `json:"read_at,omitempty"`
+	PlayedAt    *time.Time `json:"played_at,omitempty"`
+	// The message this one replies to
+	ReplyTo *ReplyToResponse `json:"reply_to,omitempty"`
+	// Latest edit by the sender; text keeps the original
+	EditedText string     `json:"edited_text,omitempty"`
+	EditedAt   *time.Time `json:"edited_at,omitempty"`
+	Revoked    bool       `json:"revoked,omitempty"` // deleted for everyone
+}
+
+// ReplyToResponse identifies the message a reply quotes.
+type ReplyToResponse struct {
+	MsgID     string `json:"msg_id"`
+	SenderJID string `json:"sender_jid,omitempty"`
+}
+
+// MessageStatusResponse is returned by GET /messages/{chat}/{id}/status.
+type MessageStatusResponse struct {
+	ChatJID     string            `json:"chat_jid"`
+	MsgID       string            `json:"msg_id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Status      string            `json:"status"` // sent|delivered|read|played
+	DeliveredAt *time.Time        `json:"delivered_at,omitempty"`
+	ReadAt      *time.Time        `json:"read_at,omitempty"`
+	PlayedAt    *time.Time        `json:"played_at,omitempty"`
+	Recipients  []ReceiptResponse `json:"recipients"`
+}
+
+// ReceiptResponse is the delivery state of a message for one recipient.
+type ReceiptResponse struct {
+	JID         string     `json:"jid"`
+	Status      string     `json:"status"` // delivered|read|played
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	ReadAt      *time.Time `json:"read_at,omitempty"`
+	PlayedAt    *time.Time `json:"played_at,omitempty"`
+}
+
+// CallResponse is an incoming call.
+type CallResponse struct {
+	CallID    string     `json:"call_id"`
+	FromJID   string     `json:"from_jid"`
+	GroupJID  string     `json:"group_jid,omitempty"`
+	Video     bool       `json:"video"`
+	Status    string     `json:"status"` // ringing|answered|rejected|missed
+	Timestamp time.Time  `json:"timestamp"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	EndReason string     `json:"end_reason,omitempty"`
+}
+
+// CallsResponse is returned by GET /calls.
+type CallsResponse struct {
+	Count int            `json:"count"`
+	Calls []CallResponse `json:"calls"`
 }
 
 // SearchResponse is returned by the search endpoint.
@@ -86,10 +229,18 @@ type SearchResponse struct {
 
 // ChatResponse represents a chat in API responses.
 type ChatResponse struct {
-	JID           string    `json:"jid"`
-	Kind          string    `json:"kind"`
-	Name          string    `json:"name"`
-	LastMessageTS time.Time `json:"last_message_ts,omitempty"`
+	JID           string     `json:"jid"`
+	Kind          string     `json:"kind"`
+	Name          string     `json:"name"`
+	LastMessageTS time.Time  `json:"last_message_ts,omitempty"`
+	Archived      bool       `json:"archived,omitempty"`
+	Pinned        bool       `json:"pinned,omitempty"`
+	Muted         bool       `json:"muted,omitempty"`
+	MutedUntil    *time.Time `json:"muted_until,omitempty"` // absent when muted forever
+	UnreadCount   int        `json:"unread_count"`
+	LastMessage   string     `json:"last_message_text,omitempty"`
+	LastSender    string     `json:"last_sender,omitempty"`
+	Labels        []string   `json:"labels,omitempty"`
 }
 
 // ChatsResponse is returned by the chats listing endpoint.
@@ -125,6 +276,52 @@ type StatsResponse struct {
 	HasFTS       bool   `json:"has_fts"`
 }
 
+// SenderCountResponse is one sender's message count within a chat.
+type SenderCountResponse struct {
+	JID          string `json:"jid"`
+	Name         string `json:"name,omitempty"`
+	MessageCount int64  `json:"message_count"`
+}
+
+// ChatStatsEntry summarizes the traffic of one chat.
+type ChatStatsEntry struct {
+	JID            string                `json:"jid"`
+	Name           string                `json:"name,omitempty"`
+	Kind           string                `json:"kind,omitempty"`
+	MessageCount   int64                 `json:"message_count"`
+	MediaCount     int64                 `json:"media_count"`
+	FirstMessageAt time.Time             `json:"first_message_at"`
+	LastMessageAt  time.Time             `json:"last_message_at"`
+	TopSenders     []SenderCountResponse `json:"top_senders"`
+}
+
+// ChatStatsResponse is returned by the chat stats endpoint.
+type ChatStatsResponse struct {
+	Since *time.Time       `json:"since,omitempty"`
+	Until *time.Time       `json:"until,omitempty"`
+	Chats []ChatStatsEntry `json:"chats"`
+	Count int              `json:"count"`
+}
+
+// TimelineBucketResponse counts the messages of one day or hour.
+type TimelineBucketResponse struct {
+	Start    time.Time `json:"start"`
+	Sent     int64     `json:"sent"`
+	Received int64     `json:"received"`
+	Total    int64     `json:"total"`
+}
+
+// TimelineResponse is returned by the stats timeline endpoint.
+type TimelineResponse struct {
+	Interval      string                   `json:"interval"`
+	Since         time.Time                `json:"since"`
+	Until         time.Time                `json:"until"`
+	ChatJID       string                   `json:"chat_jid,omitempty"`
+	TotalSent     int64                    `json:"total_sent"`
+	TotalReceived int64                    `json:"total_received"`
+	Buckets       []TimelineBucketResponse `json:"buckets"`
+}
+
 // --- Contact DTOs ---
 
 // ContactResponse represents a contact in API responses.
@@ -143,6 +340,101 @@ type ContactsResponse struct {
 	Contacts []ContactResponse `json:"contacts"`
 }
 
+// CheckContactsRequest is the request body for POST /contacts/check.
+type CheckContactsRequest struct {
+	Phones []string `json:"phones"` // international format, e.g. "+1 555 123 4567"
+}
+
+// ContactCheckResponse is the registration status of one phone number.
+type ContactCheckResponse struct {
+	Phone        string `json:"phone"`           // as given
+	Query        string `json:"query,omitempty"` // normalized number that was checked
+	Registered   bool   `json:"registered"`
+	JID          string `json:"jid,omitempty"` // canonical JID to send to
+	BusinessName string `json:"business_name,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// CheckContactsResponse is returned by POST /contacts/check, in request order.
+type CheckContactsResponse struct {
+	Count      int                    `json:"count"`
+	Registered int                    `json:"registered"`
+	Results    []ContactCheckResponse `json:"results"`
+}
+
+// ContactImportResponse is returned by POST /contacts/import.
+type ContactImportResponse struct {
+	Success  bool                         `json:"success"`
+	Rows     int                          `json:"rows"`
+	Imported int                          `json:"imported"`
+	Resolved bool                         `json:"resolved"` // numbers were checked against WhatsApp
+	Skipped  []ContactImportErrorResponse `json:"skipped,omitempty"`
+}
+
+// ContactImportErrorResponse is a CSV row that was not imported.
+type ContactImportErrorResponse struct {
+	Line  int    `json:"line"`
+	Phone string `json:"phone,omitempty"`
+	Error string `json:"error"`
+}
+
+// UploadResponse describes an upload session.
+type UploadResponse struct {
+	UploadID  string    `json:"upload_id"`
+	Filename  string    `json:"filename,omitempty"`
+	MimeType  string    `json:"mime_type,omitempty"`
+	Size      int64     `json:"size"`
+	Received  int64     `json:"received"` // next chunk starts here
+	Complete  bool      `json:"complete"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ProfileResponse is the linked account's own profile.
+type ProfileResponse struct {
+	JID       string `json:"jid"`
+	PushName  string `json:"push_name"`
+	About     string `json:"about,omitempty"`
+	PictureID string `json:"picture_id,omitempty"`
+}
+
+// ProfileNameRequest is the request body for PUT /profile/name.
+type ProfileNameRequest struct {
+	Name string `json:"name"`
+}
+
+// ProfileAboutRequest is the request body for PUT /profile/about.
+type ProfileAboutRequest struct {
+	About string `json:"about"`
+}
+
+// ProfileUpdateResponse is returned by the PUT /profile/* endpoints.
+type ProfileUpdateResponse struct {
+	Success   bool    `json:"success"`
+	PushName  string  `json:"push_name,omitempty"`
+	About     *string `json:"about,omitempty"`
+	PictureID string  `json:"picture_id,omitempty"`
+}
+
+// BlockedContactResponse represents an entry of the blocklist.
+type BlockedContactResponse struct {
+	JID       string    `json:"jid"`
+	Name      string    `json:"name,omitempty"`
+	BlockedAt time.Time `json:"blocked_at"` // when the block was first seen by the service
+}
+
+// BlockedContactsResponse is returned when listing blocked contacts.
+type BlockedContactsResponse struct {
+	Count    int                      `json:"count"`
+	Contacts []BlockedContactResponse `json:"contacts"`
+}
+
+// BlockResponse is returned after blocking or unblocking a contact.
+type BlockResponse struct {
+	Success bool   `json:"success"`
+	JID     string `json:"jid"`
+	Blocked bool   `json:"blocked"`
+}
+
 // RefreshContactsResponse is returned after refreshing contacts.
 type RefreshContactsResponse struct {
 	Success          bool `json:"success"`
@@ -177,11 +469,16 @@ type TagResponse struct {
 
 // GroupResponse represents a group in API responses.
 type GroupResponse struct {
-	JID        string    `json:"jid"`
-	Name       string    `json:"name"`
-	OwnerJID   string    `json:"owner_jid,omitempty"`
-	CreatedAt  time.Time `json:"created_at,omitempty"`
-	UpdatedAt  time.Time `json:"updated_at,omitempty"`
+	JID         string    `json:"jid"`
+	Name        string    `json:"name"`
+	OwnerJID    string    `json:"owner_jid,omitempty"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
+
+	IsCommunity         bool   `json:"is_community,omitempty"`
+	CommunityJID        string `json:"community_jid,omitempty"` // community the group is linked to
+	IsAnnouncementGroup bool   `json:"is_announcement_group,omitempty"`
 }
 
 // GroupsResponse is returned by the groups listing endpoint.
@@ -192,19 +489,33 @@ type GroupsResponse struct {
 
 // GroupParticipant represents a group participant.
 type GroupParticipant struct {
-	JID   string `json:"jid"`
-	Role  string `json:"role,omitempty"` // "admin", "superadmin", or empty for regular
-	Error string `json:"error,omitempty"`
+	JID         string `json:"jid"`
+	PhoneNumber string `json:"phone_number,omitempty"` // phone-number JID, when known
+	LID         string `json:"lid,omitempty"`          // LID, when known
+	Role        string `json:"role,omitempty"`         // "admin", "superadmin", or empty for regular
+	Error       string `json:"error,omitempty"`
+}
+
+// CreateGroupRequest is the request body for POST /groups.
+type CreateGroupRequest struct {
+	Name         string   `json:"name"`         // At most 25 characters
+	Participants []string `json:"participants"` // Phone numbers or JIDs; you are added automatically
 }
 
 // GroupInfoResponse is returned by the group info endpoint.
 type GroupInfoResponse struct {
-	JID              string             `json:"jid"`
-	Name             string             `json:"name"`
-	OwnerJID         string             `json:"owner_jid,omitempty"`
-	CreatedAt        time.Time          `json:"created_at,omitempty"`
-	ParticipantCount int                `json:"participant_count"`
-	Participants     []GroupParticipant `json:"participants,omitempty"`
+	JID                 string             `json:"jid"`
+	Name                string             `json:"name"`
+	OwnerJID            string             `json:"owner_jid,omitempty"`
+	Description         string             `json:"description,omitempty"`
+	Announce            bool               `json:"announce"` // only admins can send messages
+	Locked              bool               `json:"locked"`   // only admins can edit group info
+	IsCommunity         bool               `json:"is_community,omitempty"`
+	CommunityJID        string             `json:"community_jid,omitempty"`
+	IsAnnouncementGroup bool               `json:"is_announcement_group,omitempty"`
+	CreatedAt           time.Time          `json:"created_at,omitempty"`
+	ParticipantCount    int                `json:"participant_count"`
+	Participants        []GroupParticipant `json:"participants,omitempty"`
 }
 
 // RefreshGroupsResponse is returned after refreshing groups.
@@ -225,6 +536,26 @@ type RenameGroupResponse struct {
 	Name    string `json:"name"`
 }
 
+// GroupDescriptionRequest is the request body for setting a group's
+// description. An empty description removes it.
+type GroupDescriptionRequest struct {
+	Description string `json:"description"`
+}
+
+// GroupDescriptionResponse is returned after setting a group's description.
+type GroupDescriptionResponse struct {
+	Success     bool   `json:"success"`
+	JID         string `json:"jid"`
+	Description string `json:"description"`
+}
+
+// GroupSettingsRequest is the request body for changing group settings.
+// Omitted fields are left unchanged.
+type GroupSettingsRequest struct {
+	Announce *bool `json:"announce,omitempty"` // only admins can send messages
+	Locked   *bool `json:"locked,omitempty"`   // only admins can edit group info
+}
+
 // UpdateParticipantsRequest is the request body for managing group participants.
 type UpdateParticipantsRequest struct {
 	Action string   `json:"action"` // "add", "remove", "promote", "demote"
@@ -250,6 +581,19 @@ type JoinGroupRequest struct {
 	Code string `json:"code"` // Invite code from link
 }
 
+// GroupInvitePreviewResponse describes the group behind an invite code.
+type GroupInvitePreviewResponse struct {
+	JID                  string    `json:"jid"`
+	Name                 string    `json:"name"`
+	Description          string    `json:"description,omitempty"`
+	OwnerJID             string    `json:"owner_jid,omitempty"`
+	CreatedAt            time.Time `json:"created_at,omitempty"`
+	ParticipantCount     int       `json:"participant_count"`
+	IsCommunity          bool      `json:"is_community,omitempty"`
+	CommunityJID         string    `json:"community_jid,omitempty"`
+	JoinApprovalRequired bool      `json:"join_approval_required,omitempty"` // joining sends a request to the admins
+}
+
 // JoinGroupResponse is returned after joining a group.
 type JoinGroupResponse struct {
 	Success bool   `json:"success"`
@@ -262,6 +606,26 @@ type LeaveGroupResponse struct {
 	JID     string `json:"jid"`
 }
 
+// MemberActivityResponse describes a single member's activity in a group.
+type MemberActivityResponse struct {
+	JID           string    `json:"jid"`
+	Name          string    `json:"name,omitempty"`
+	MessageCount  int64     `json:"message_count"`
+	Hours         [24]int64 `json:"hours"` // messages per UTC hour of day
+	LastMessageAt time.Time `json:"last_message_at,omitempty"`
+}
+
+// GroupActivityResponse is returned by the group activity endpoint.
+type GroupActivityResponse struct {
+	JID           string                   `json:"jid"`
+	Since         time.Time                `json:"since"`
+	Until         time.Time                `json:"until"`
+	TotalMessages int64                    `json:"total_messages"`
+	Hours         [24]int64                `json:"hours"`
+	MemberCount   int                      `json:"member_count"`
+	Members       []MemberActivityResponse `json:"members"`
+}
+
 // --- Media DTOs ---
 
 // DownloadMediaResponse is returned after downloading media.
@@ -276,6 +640,22 @@ type DownloadMediaResponse struct {
 	DownloadedAt time.Time `json:"downloaded_at"`
 }
 
+// DownloadStatusResponse reports the progress of a media download.
+type DownloadStatusResponse struct {
+	ChatJID      string     `json:"chat_jid"`
+	MsgID        string     `json:"msg_id"`
+	State        string     `json:"state"` // not_started|downloading|partial|failed|completed
+	BytesDone    int64      `json:"bytes_done"`
+	BytesTotal   int64      `json:"bytes_total,omitempty"`
+	Percent      float64    `json:"percent,omitempty"`
+	ResumedFrom  int64      `json:"resumed_from,omitempty"`
+	PartialBytes int64      `json:"partial_bytes,omitempty"`
+	LocalPath    string     `json:"local_path,omitempty"`
+	Error        string     `json:"error,omitempty"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+}
+
 // SendFileResponse is returned after sending a file.
 type SendFileResponse struct {
 	Success   bool   `json:"success"`
@@ -284,6 +664,10 @@ type SendFileResponse struct {
 	MediaType string `json:"media_type"`
 	Filename  string `json:"filename,omitempty"`
 	MimeType  string `json:"mime_type,omitempty"`
+	// Bytes is the size sent; it differs from OriginalBytes when the file
+	// was optimized or transcoded.
+	Bytes         int64 `json:"bytes,omitempty"`
+	OriginalBytes int64 `json:"original_bytes,omitempty"`
 }
 
 // --- History Backfill DTOs ---
@@ -304,6 +688,41 @@ type BackfillResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
+// BackfillAllRequest is the request body for backfilling every known chat.
+type BackfillAllRequest struct {
+	Count                 int `json:"count,omitempty"`                    // Messages per request (default: 50)
+	Requests              int `json:"requests,omitempty"`                 // Requests per chat (default: 1)
+	WaitPerRequestSeconds int `json:"wait_per_request_seconds,omitempty"` // Wait per request (default: 60)
+	Concurrency           int `json:"concurrency,omitempty"`              // Chats backfilled at once (default: 2, max: 10)
+	IntervalSeconds       int `json:"interval_seconds,omitempty"`         // Minimum gap between starting two chats
+}
+
+// BackfillAllResponse describes the current or last backfill-all run.
+type BackfillAllResponse struct {
+	Status                string                 `json:"status"` // "running", "completed", "cancelled"
+	Active                bool                   `json:"active"` // false while a running run waits for a connection
+	Count                 int                    `json:"count"`
+	Requests              int                    `json:"requests"`
+	WaitPerRequestSeconds int                    `json:"wait_per_request_seconds"`
+	Concurrency           int                    `json:"concurrency"`
+	IntervalSeconds       int                    `json:"interval_seconds"`
+	StartedAt             time.Time              `json:"started_at"`
+	FinishedAt            *time.Time             `json:"finished_at,omitempty"`
+	Total                 int                    `json:"total"`
+	Pending               int                    `json:"pending"`
+	Done                  int                    `json:"done"`
+	Skipped               int                    `json:"skipped"`
+	Failed                int                    `json:"failed"`
+	FailedChats           []BackfillChatResponse `json:"failed_chats"`
+}
+
+// BackfillChatResponse describes a chat whose backfill failed.
+type BackfillChatResponse struct {
+	ChatJID   string    `json:"chat_jid"`
+	Error     string    `json:"error"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 // BackfillStatusResponse is returned when polling backfill job status.
 type BackfillStatusResponse struct {
 	JobID         string `json:"job_id"`
@@ -319,10 +738,31 @@ type BackfillStatusResponse struct {
 
 // SyncStatusResponse is returned by the sync status endpoint.
 type SyncStatusResponse struct {
-	Running        bool      `json:"running"`
-	State          string    `json:"state"`
-	MessagesSynced int64     `json:"messages_synced"`
-	StartedAt      time.Time `json:"started_at,omitempty"`
+	Running        bool            `json:"running"`
+	State          string          `json:"state"`
+	MessagesSynced int64           `json:"messages_synced"`
+	HistoryChunks  int64           `json:"history_chunks"`
+	StartedAt      *time.Time      `json:"started_at,omitempty"`
+	LastEventAt    *time.Time      `json:"last_event_at,omitempty"`
+	Startup        StartupResponse `json:"startup"`
+}
+
+// StartupResponse reports the startup pipeline in the sync status.
+type StartupResponse struct {
+	Ready      bool                  `json:"ready"`
+	StartedAt  *time.Time            `json:"started_at,omitempty"`
+	FinishedAt *time.Time            `json:"finished_at,omitempty"`
+	Steps      []StartupStepResponse `json:"steps"`
+}
+
+// StartupStepResponse reports one step of the startup pipeline.
+type StartupStepResponse struct {
+	Name       string     `json:"name"`
+	Status     string     `json:"status"` // pending|running|done|skipped|failed
+	Count      int        `json:"count"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
 }
 
 // StartSyncRequest is the request body for starting sync.
@@ -357,6 +797,23 @@ type DoctorResponse struct {
 	ChatCount     int64  `json:"chat_count"`
 	ContactCount  int64  `json:"contact_count"`
 	GroupCount    int64  `json:"group_count"`
+
+	MediaRateLimited       int64 `json:"media_rate_limited"`
+	MediaRetries           int64 `json:"media_retries"`
+	MediaRateLimitFailures int64 `json:"media_rate_limit_failures"`
+
+	Recovery RecoveryResponse `json:"recovery"`
+}
+
+// RecoveryResponse summarizes the startup recovery scan.
+type RecoveryResponse struct {
+	ScannedAt            time.Time `json:"scanned_at"`
+	TempFilesRemoved     int       `json:"temp_files_removed"`
+	PartialDownloads     int       `json:"partial_downloads"`
+	StalePartialsRemoved int       `json:"stale_partials_removed"`
+	PendingWebhooks      int64     `json:"pending_webhooks"`
+	OutboxQueued         int64     `json:"outbox_queued"`
+	OutboxInterrupted    int64     `json:"outbox_interrupted"`
 }
 
 // --- Message Context DTOs ---
@@ -367,3 +824,445 @@ type MessageContextResponse struct {
 	TargetMsgID string            `json:"target_msg_id"`
 	Messages    []MessageResponse `json:"messages"`
 }
+
+// --- Replication DTOs ---
+
+// ReplicaIngestResponse is returned after applying a replicated batch.
+type ReplicaIngestResponse struct {
+	Success  bool  `json:"success"`
+	Cursor   int64 `json:"cursor"`
+	Chats    int   `json:"chats"`
+	Messages int   `json:"messages"`
+	Deleted  int   `json:"deleted"`
+}
+
+// --- Webhook DTOs ---
+
+// WebhookDeadLetterResponse is a webhook delivery that exhausted its retries.
+type WebhookDeadLetterResponse struct {
+	ID          int64           `json:"id"`
+	EndpointURL string          `json:"endpoint_url"`
+	EventType   string          `json:"event_type"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	FailedAt    time.Time       `json:"failed_at"`
+	Event       json.RawMessage `json:"event"`
+}
+
+// WebhookDeadLettersResponse is returned when listing dead-lettered webhooks.
+type WebhookDeadLettersResponse struct {
+	Count       int                         `json:"count"`
+	DeadLetters []WebhookDeadLetterResponse `json:"dead_letters"`
+}
+
+// ReplayWebhookResponse is returned after re-queuing a dead-lettered webhook.
+type ReplayWebhookResponse struct {
+	Success bool  `json:"success"`
+	ID      int64 `json:"id"`
+	QueueID int64 `json:"queue_id"`
+}
+
+// WebhookResponse is a configured webhook endpoint and its chat filter.
+type WebhookResponse struct {
+	URL           string   `json:"url"`
+	Events        []string `json:"events,omitempty"`
+	Chats         []string `json:"chats,omitempty"`
+	ExcludeChats  []string `json:"exclude_chats,omitempty"`
+	RuntimeFilter bool     `json:"runtime_filter"` // chat filter set through the API
+}
+
+// WebhooksResponse lists the configured webhook endpoints.
+type WebhooksResponse struct {
+	Count    int               `json:"count"`
+	Webhooks []WebhookResponse `json:"webhooks"`
+}
+
+// WebhookChatFilterRequest is the request body for PUT /webhooks/chat-filter.
+type WebhookChatFilterRequest struct {
+	URL          string   `json:"url"`
+	Chats        []string `json:"chats"`
+	ExcludeChats []string `json:"exclude_chats"`
+}
+
+// WebhookLatencyResponse summarizes delivery request durations.
+type WebhookLatencyResponse struct {
+	Count int64 `json:"count"`
+	AvgMs int64 `json:"avg_ms"`
+	P50Ms int64 `json:"p50_ms"`
+	P95Ms int64 `json:"p95_ms"`
+	P99Ms int64 `json:"p99_ms"`
+	Slow  int64 `json:"slow_count"` // requests slower than the largest histogram bucket
+}
+
+// WebhookCountersResponse are delivery counters since the service started.
+type WebhookCountersResponse struct {
+	Enqueued     int64 `json:"enqueued"`
+	Delivered    int64 `json:"delivered"`
+	Retried      int64 `json:"retried"`
+	Failed       int64 `json:"failed"`
+	Dropped      int64 `json:"dropped"`
+	DeadLettered int64 `json:"dead_lettered"`
+}
+
+// WebhookEndpointStatsResponse are the delivery metrics of one endpoint.
+type WebhookEndpointStatsResponse struct {
+	URL string `json:"url"`
+	WebhookCountersResponse
+	Latency WebhookLatencyResponse `json:"latency"`
+}
+
+// WebhookStatsResponse is returned by GET /webhooks/stats.
+type WebhookStatsResponse struct {
+	QueueDepth int                            `json:"queue_depth"`
+	Totals     WebhookCountersResponse        `json:"totals"`
+	Endpoints  []WebhookEndpointStatsResponse `json:"endpoints"`
+}
+
+// --- Recent Event DTOs ---
+
+// RecentEventResponse is a service event from the recent events buffer.
+type RecentEventResponse struct {
+	ID        int64       `json:"id"`
+	Type      string      `json:"type"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// RecentEventsResponse is returned by GET /events/recent.
+type RecentEventsResponse struct {
+	Count   int                   `json:"count"`
+	LastID  int64                 `json:"last_id"` // pass as since_id on the next poll
+	HasMore bool                  `json:"has_more"`
+	Missed  bool                  `json:"missed"` // events after since_id are no longer buffered
+	Events  []RecentEventResponse `json:"events"`
+}
+
+// --- Label DTOs ---
+
+// CreateLabelRequest is the request body for POST /labels.
+type CreateLabelRequest struct {
+	Name  string `json:"name"`
+	Color int32  `json:"color,omitempty"` // WhatsApp label color index
+}
+
+// LabelResponse describes a chat label.
+type LabelResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Color     int32     `json:"color"`
+	Synced    bool      `json:"synced"` // mirrored with WhatsApp Business
+	ChatCount int64     `json:"chat_count"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LabelsResponse is returned when listing labels.
+type LabelsResponse struct {
+	Count  int             `json:"count"`
+	Labels []LabelResponse `json:"labels"`
+}
+
+// --- Token DTOs ---
+
+// CreateChatTokenRequest is the request body for POST /tokens.
+type CreateChatTokenRequest struct {
+	ChatJID    string `json:"chat_jid"`
+	Label      string `json:"label,omitempty"`
+	TTLSeconds int    `json:"ttl_seconds,omitempty"` // 0 = no expiry
+}
+
+// ChatTokenResponse describes a chat-scoped token. Token is only set when
+// the token is created.
+type ChatTokenResponse struct {
+	ID        int64      `json:"id"`
+	Token     string     `json:"token,omitempty"`
+	ChatJID   string     `json:"chat_jid"`
+	Label     string     `json:"label,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ChatTokensResponse is returned when listing chat-scoped tokens.
+type ChatTokensResponse struct {
+	Count  int                 `json:"count"`
+	Tokens []ChatTokenResponse `json:"tokens"`
+}
+
+// --- API key DTOs ---
+
+// CreateAPIKeyRequest is the request body for POST /admin/keys.
+type CreateAPIKeyRequest struct {
+	Label    string `json:"label,omitempty"`
+	ReadOnly bool   `json:"read_only,omitempty"`
+}
+
+// RotateAPIKeyRequest is the request body for POST /admin/keys/rotate.
+type RotateAPIKeyRequest struct {
+	Label        string `json:"label,omitempty"`
+	ReadOnly     bool   `json:"read_only,omitempty"`     // rotate the read-only keys instead
+	GraceSeconds *int   `json:"grace_seconds,omitempty"` // default 86400; 0 revokes the old keys at once
+}
+
+// APIKeyResponse describes an API key. Key is only set when the key is
+// issued.
+type APIKeyResponse struct {
+	ID         int64      `json:"id"`
+	Key        string     `json:"key,omitempty"`
+	Hint       string     `json:"hint,omitempty"`
+	Label      string     `json:"label,omitempty"`
+	ReadOnly   bool       `json:"read_only"`
+	Active     bool       `json:"active"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// RotateAPIKeyResponse is returned by POST /admin/keys/rotate.
+type RotateAPIKeyResponse struct {
+	APIKeyResponse
+	PreviousExpireAt time.Time `json:"previous_expire_at"`
+	PreviousKeys     int       `json:"previous_keys"`
+}
+
+// APIKeysResponse is returned when listing API keys.
+type APIKeysResponse struct {
+	Count int              `json:"count"`
+	Keys  []APIKeyResponse `json:"keys"`
+}
+
+// AccessTokenRequest is the request body for POST /auth/token.
+type AccessTokenRequest struct {
+	Scopes     []string `json:"scopes,omitempty"`      // read, send, write; default read
+	TTLSeconds int      `json:"ttl_seconds,omitempty"` // 0 = WASVC_ACCESS_TOKEN_TTL
+}
+
+// AccessTokenResponse is returned by POST /auth/token.
+type AccessTokenResponse struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type"`
+	Scopes    []string  `json:"scopes"`
+	ExpiresIn int       `json:"expires_in"` // seconds
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// --- Script DTOs ---
+
+// PutScriptRequest is the request body for PUT /scripts/{name}.
+type PutScriptRequest struct {
+	Source  string `json:"source"`
+	Enabled *bool  `json:"enabled,omitempty"` // defaults to true
+}
+
+// ScriptResponse describes a stored script. The run counters cover the time
+// since the scripts were last reloaded.
+type ScriptResponse struct {
+	Name      string     `json:"name"`
+	Source    string     `json:"source"`
+	Enabled   bool       `json:"enabled"`
+	Loaded    bool       `json:"loaded"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Runs      int64      `json:"runs"`
+	Errors    int64      `json:"errors"`
+	LastError string     `json:"last_error,omitempty"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+}
+
+// ScriptsResponse is returned when listing scripts.
+type ScriptsResponse struct {
+	Count   int              `json:"count"`
+	Scripts []ScriptResponse `json:"scripts"`
+}
+
+// --- Rule DTOs ---
+
+// RuleRequest is the request body for POST /rules and PUT /rules/{id}.
+type RuleRequest struct {
+	Name       string           `json:"name"`
+	Enabled    *bool            `json:"enabled,omitempty"` // defaults to true
+	Priority   int              `json:"priority,omitempty"`
+	Conditions rules.Conditions `json:"conditions"`
+	Actions    []rules.Action   `json:"actions"`
+}
+
+// RuleResponse describes a routing rule.
+type RuleResponse struct {
+	ID         int64            `json:"id"`
+	Name       string           `json:"name"`
+	Enabled    bool             `json:"enabled"`
+	Priority   int              `json:"priority"`
+	Conditions rules.Conditions `json:"conditions"`
+	Actions    []rules.Action   `json:"actions"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+}
+
+// RulesResponse is returned when listing rules.
+type RulesResponse struct {
+	Count int            `json:"count"`
+	Rules []RuleResponse `json:"rules"`
+}
+
+// --- Backup DTOs ---
+
+// RestoreResponse is returned once a backup archive has been staged.
+type RestoreResponse struct {
+	Success       bool      `json:"success"`
+	CreatedAt     time.Time `json:"created_at"`
+	Databases     int       `json:"databases"`
+	MediaFiles    int       `json:"media_files"`
+	MediaIncluded bool      `json:"media_included"`
+	Message       string    `json:"message"`
+}
+
+// --- Retention DTOs ---
+
+// RetentionResponse describes the retention policy and per-chat overrides.
+type RetentionResponse struct {
+	MaxAgeSeconds   int64                   `json:"max_age_seconds"` // 0 = keep forever
+	MaxDBBytes      int64                   `json:"max_db_bytes"`    // 0 = no limit
+	IntervalSeconds int64                   `json:"interval_seconds"`
+	Chats           []ChatRetentionResponse `json:"chats"`
+}
+
+// ChatRetentionResponse is a per-chat retention override.
+type ChatRetentionResponse struct {
+	ChatJID       string    `json:"chat_jid"`
+	MaxAgeSeconds int64     `json:"max_age_seconds"`
+	KeepForever   bool      `json:"keep_forever"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// SetChatRetentionRequest is the request body for PUT /chats/{jid}/retention.
+type SetChatRetentionRequest struct {
+	MaxAgeSeconds int64 `json:"max_age_seconds"` // 0 = keep forever
+}
+
+// --- Responder DTOs ---
+
+// ResponderResponse describes the auto-responder settings and per-chat
+// overrides.
+type ResponderResponse struct {
+	Enabled        bool                    `json:"enabled"` // a completion endpoint is configured
+	Model          string                  `json:"model"`
+	AllChats       bool                    `json:"all_chats"`
+	ContextSize    int                     `json:"context_messages"`
+	MaxPerHour     int                     `json:"max_per_hour"` // per chat; 0 = no limit
+	TimeoutSeconds int64                   `json:"timeout_seconds"`
+	Chats          []ChatResponderResponse `json:"chats"`
+}
+
+// ChatResponderResponse is a per-chat auto-responder override.
+type ChatResponderResponse struct {
+	ChatJID   string    `json:"chat_jid"`
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SetChatResponderRequest is the request body for PUT /chats/{jid}/responder.
+type SetChatResponderRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// RetentionReportResponse reports what a pruning run removed (or would remove).
+type RetentionReportResponse struct {
+	DryRun     bool                 `json:"dry_run"`
+	Messages   int                  `json:"messages"`
+	ByAge      int                  `json:"by_age"`
+	BySize     int                  `json:"by_size"`
+	MediaFiles int                  `json:"media_files"`
+	MediaBytes int64                `json:"media_bytes"`
+	DBBytes    int64                `json:"db_bytes,omitempty"`
+	Chats      []RetentionChatCount `json:"chats"`
+}
+
+// RetentionChatCount is the number of messages removed from one chat.
+type RetentionChatCount struct {
+	ChatJID  string `json:"chat_jid"`
+	Messages int    `json:"messages"`
+}
+
+// MuteChatRequest is the optional request body for PUT /chats/{jid}/mute.
+type MuteChatRequest struct {
+	DurationSeconds int64 `json:"duration_seconds,omitempty"` // 0 mutes forever
+}
+
+// ClearChatResponse is returned by DELETE /chats/{jid}/messages.
+type ClearChatResponse struct {
+	Success         bool   `json:"success"`
+	ChatJID         string `json:"chat_jid"`
+	MessagesDeleted int    `json:"messages_deleted"`
+	MediaFiles      int    `json:"media_files"`
+	MediaBytes      int64  `json:"media_bytes"`
+	Synced          bool   `json:"synced"` // also cleared on WhatsApp
+}
+
+// --- Channel DTOs ---
+
+// ChannelResponse represents a WhatsApp Channel (newsletter).
+type ChannelResponse struct {
+	JID         string     `json:"jid"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	InviteLink  string     `json:"invite_link,omitempty"`
+	Subscribers int        `json:"subscribers"`
+	Verified    bool       `json:"verified"`
+	Role        string     `json:"role,omitempty"` // owner, admin, subscriber or guest
+	Muted       bool       `json:"muted"`
+	Followed    bool       `json:"followed"`
+	LastPostAt  *time.Time `json:"last_post_at,omitempty"`
+}
+
+// ChannelsResponse is returned when listing channels.
+type ChannelsResponse struct {
+	Count    int               `json:"count"`
+	Channels []ChannelResponse `json:"channels"`
+}
+
+// ChannelPostResponse represents a channel post.
+type ChannelPostResponse struct {
+	MsgID     string         `json:"msg_id"`
+	ServerID  int64          `json:"server_id,omitempty"`
+	Timestamp time.Time      `json:"timestamp"`
+	Text      string         `json:"text,omitempty"`
+	MediaType string         `json:"media_type,omitempty"`
+	Caption   string         `json:"caption,omitempty"`
+	Views     int            `json:"views"`
+	Reactions map[string]int `json:"reactions,omitempty"`
+}
+
+// ChannelPostsResponse is returned when listing channel posts.
+type ChannelPostsResponse struct {
+	ChannelJID string                `json:"channel_jid"`
+	Count      int                   `json:"count"`
+	Posts      []ChannelPostResponse `json:"posts"`
+}
+
+// SetPresenceRequest is the request body for POST /presence.
+type SetPresenceRequest struct {
+	State string `json:"state"` // available or unavailable
+}
+
+// CommunityResponse represents a community.
+type CommunityResponse struct {
+	JID             string    `json:"jid"`
+	Name            string    `json:"name"`
+	Description     string    `json:"description,omitempty"`
+	GroupCount      int       `json:"group_count"`                // linked groups, including the announcement group
+	AnnouncementJID string    `json:"announcement_jid,omitempty"` // the community's announcement group
+	CreatedAt       time.Time `json:"created_at,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at,omitempty"`
+}
+
+// CommunitiesResponse is returned when listing communities.
+type CommunitiesResponse struct {
+	Count       int                 `json:"count"`
+	Communities []CommunityResponse `json:"communities"`
+}
+
+// CommunityGroupsResponse is returned when listing a community's groups.
+type CommunityGroupsResponse struct {
+	CommunityJID string          `json:"community_jid"`
+	Count        int             `json:"count"`
+	Groups       []GroupResponse `json:"groups"`
+}
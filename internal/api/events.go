@@ -0,0 +1,67 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/steipete/wacli/internal/service"
+)
+
+// RecentEvents handles GET /events/recent
+func (h *Handlers) RecentEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var sinceID int64
+	if v := q.Get("since_id"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			writeFieldError(w, "since_id", "since_id must be a non-negative integer", "INVALID_SINCE_ID")
+			return
+		}
+		sinceID = n
+	}
+	limit := 100
+	if l := q.Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+	var types []string
+	for _, t := range strings.Split(q.Get("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+
+	res, err := h.manager.RecentEvents(sinceID, limit, types)
+	if errors.Is(err, service.ErrRecentEventsDisabled) {
+		writeError(w, http.StatusNotFound, err.Error(), "RECENT_EVENTS_DISABLED")
+		return
+	}
+	if err != nil {
+		writeFailure(w, err, "RECENT_EVENTS_FAILED")
+		return
+	}
+
+	resp := RecentEventsResponse{
+		Count:   len(res.Events),
+		LastID:  res.LastID,
+		HasMore: res.HasMore,
+		Missed:  res.Missed,
+		Events:  make([]RecentEventResponse, len(res.Events)),
+	}
+	for i, ev := range res.Events {
+		resp.Events[i] = RecentEventResponse{
+			ID:        ev.ID,
+			Type:      ev.Type,
+			Timestamp: ev.Timestamp,
+			Data:      ev.Data,
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/steipete/wacli/internal/service"
+	"github.com/steipete/wacli/internal/store"
+)
+
+// ListFailedSends handles GET /messages/failed
+func (h *Handlers) ListFailedSends(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := 50
+	if l := q.Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	sends, err := h.manager.ListFailedSends(q.Get("chat"), limit)
+	if err != nil {
+		writeFailure(w, err, "LIST_FAILED_SENDS_FAILED")
+		return
+	}
+
+	resp := FailedSendsResponse{Count: len(sends), Messages: make([]FailedSendResponse, len(sends))}
+	for i, f := range sends {
+		resp.Messages[i] = failedSendToResponse(f)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// RetryFailedSend handles POST /messages/{id}/retry
+func (h *Handlers) RetryFailedSend(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt64(r, "id")
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid failed send id", "INVALID_ID")
+		return
+	}
+
+	res, err := h.manager.RetryFailedSend(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			writeError(w, http.StatusNotFound, err.Error(), "NOT_FOUND")
+			return
+		}
+		writeSendError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, SendMessageResponse{
+		Success:   true,
+		MessageID: res.MessageID,
+		To:        res.ChatJID,
+	})
+}
+
+// DeleteFailedSend handles DELETE /messages/failed/{id}
+func (h *Handlers) DeleteFailedSend(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt64(r, "id")
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid failed send id", "INVALID_ID")
+		return
+	}
+	if err := h.manager.DeleteFailedSend(id); err != nil {
+		writeFailure(w, err, "DELETE_FAILED_SEND_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"id":      id,
+	})
+}
+
+func failedSendToResponse(f store.FailedSend) FailedSendResponse {
+	return FailedSendResponse{
+		ID:        f.ID,
+		ChatJID:   f.ChatJID,
+		Kind:      f.Kind,
+		Text:      f.Text,
+		Filename:  f.Filename,
+		MimeType:  f.MimeType,
+		Size:      f.Size,
+		Error:     f.Error,
+		Attempts:  f.Attempts,
+		CreatedAt: f.CreatedAt,
+		UpdatedAt: f.UpdatedAt,
+	}
+}
@@ -2,9 +2,18 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/steipete/wacli/internal/app"
+	"github.com/steipete/wacli/internal/service"
+	"github.com/steipete/wacli/internal/store"
 )
 
 // ListGroups handles GET /groups
@@ -22,7 +31,7 @@ func (h *Handlers) ListGroups(w http.ResponseWriter, r *http.Request) {
 
 	groups, err := h.manager.ListGroups(query, limit)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "LIST_GROUPS_FAILED")
+		writeFailure(w, err, "LIST_GROUPS_FAILED")
 		return
 	}
 
@@ -31,40 +40,86 @@ func (h *Handlers) ListGroups(w http.ResponseWriter, r *http.Request) {
 		Groups: make([]GroupResponse, len(groups)),
 	}
 	for i, g := range groups {
-		resp.Groups[i] = GroupResponse{
-			JID:       g.JID,
-			Name:      g.Name,
-			OwnerJID:  g.OwnerJID,
-			CreatedAt: g.CreatedAt,
-			UpdatedAt: g.UpdatedAt,
-		}
+		resp.Groups[i] = groupToResponse(g)
 	}
 
 	writeJSON(w, http.StatusOK, resp)
 }
 
+func groupToResponse(g store.Group) GroupResponse {
+	return GroupResponse{
+		JID:                 g.JID,
+		Name:                g.Name,
+		OwnerJID:            g.OwnerJID,
+		Description:         g.Description,
+		IsCommunity:         g.IsCommunity,
+		CommunityJID:        g.CommunityJID,
+		IsAnnouncementGroup: g.IsAnnouncementGroup,
+		CreatedAt:           g.CreatedAt,
+		UpdatedAt:           g.UpdatedAt,
+	}
+}
+
 // GetGroupInfo handles GET /groups/{jid}
 func (h *Handlers) GetGroupInfo(w http.ResponseWriter, r *http.Request) {
-	jid := strings.TrimPrefix(r.URL.Path, "/groups/")
-	jid = strings.TrimSuffix(jid, "/")
-
+	jid := r.PathValue("jid")
 	if strings.TrimSpace(jid) == "" {
-		writeError(w, http.StatusBadRequest, "JID is required", "MISSING_JID")
+		writeFieldError(w, "jid", "JID is required", "MISSING_JID")
 		return
 	}
 
-	// Check if this is a sub-resource path
-	if strings.Contains(jid, "/") {
-		writeError(w, http.StatusNotFound, "endpoint not found", "NOT_FOUND")
+	info, err := h.manager.GetGroupInfo(r.Context(), jid)
+	if err != nil {
+		writeFailure(w, err, "GET_GROUP_INFO_FAILED")
 		return
 	}
 
-	info, err := h.manager.GetGroupInfo(r.Context(), jid)
+	writeJSON(w, http.StatusOK, groupInfoToResponse(info))
+}
+
+// CreateGroup handles POST /groups
+func (h *Handlers) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	var req CreateGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		writeFieldError(w, "name", "name is required", "MISSING_NAME")
+		return
+	}
+	if utf8.RuneCountInString(req.Name) > 25 {
+		writeFieldError(w, "name", "name must be at most 25 characters", "INVALID_NAME")
+		return
+	}
+	if len(req.Participants) == 0 {
+		writeFieldError(w, "participants", "participants are required", "MISSING_USERS")
+		return
+	}
+
+	info, err := h.manager.CreateGroup(r.Context(), req.Name, req.Participants)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "GET_GROUP_INFO_FAILED")
+		switch {
+		case errors.Is(err, service.ErrInvalidJID):
+			writeFieldError(w, "participants", err.Error(), "INVALID_JID")
+		default:
+			writeFailure(w, err, "CREATE_GROUP_FAILED")
+		}
 		return
 	}
+	writeJSON(w, http.StatusCreated, groupInfoToResponse(info))
+}
+
+// optionalJID returns "" for an empty JID, so it is omitted from JSON.
+func optionalJID(j types.JID) string {
+	if j.IsEmpty() {
+		return ""
+	}
+	return j.ToNonAD().String()
+}
 
+func groupInfoToResponse(info *types.GroupInfo) GroupInfoResponse {
 	participants := make([]GroupParticipant, len(info.Participants))
 	for i, p := range info.Participants {
 		role := ""
@@ -74,9 +129,16 @@ func (h *Handlers) GetGroupInfo(w http.ResponseWriter, r *http.Request) {
 		if p.IsSuperAdmin {
 			role = "superadmin"
 		}
+		errStr := ""
+		if p.Error != 0 {
+			errStr = strconv.Itoa(p.Error)
+		}
 		participants[i] = GroupParticipant{
-			JID:  p.JID.String(),
-			Role: role,
+			JID:         p.JID.String(),
+			PhoneNumber: optionalJID(p.PhoneNumber),
+			LID:         optionalJID(p.LID),
+			Role:        role,
+			Error:       errStr,
 		}
 	}
 
@@ -85,21 +147,27 @@ func (h *Handlers) GetGroupInfo(w http.ResponseWriter, r *http.Request) {
 		ownerJID = info.OwnerJID.String()
 	}
 
-	writeJSON(w, http.StatusOK, GroupInfoResponse{
-		JID:              info.JID.String(),
-		Name:             info.Name,
-		OwnerJID:         ownerJID,
-		CreatedAt:        info.GroupCreated,
-		ParticipantCount: len(info.Participants),
-		Participants:     participants,
-	})
+	return GroupInfoResponse{
+		JID:                 info.JID.String(),
+		Name:                info.Name,
+		OwnerJID:            ownerJID,
+		Description:         info.Topic,
+		Announce:            info.IsAnnounce,
+		Locked:              info.IsLocked,
+		IsCommunity:         info.IsParent,
+		CommunityJID:        app.CommunityJID(info),
+		IsAnnouncementGroup: info.IsDefaultSubGroup,
+		CreatedAt:           info.GroupCreated,
+		ParticipantCount:    len(info.Participants),
+		Participants:        participants,
+	}
 }
 
 // RefreshGroups handles POST /groups/refresh
 func (h *Handlers) RefreshGroups(w http.ResponseWriter, r *http.Request) {
 	count, err := h.manager.RefreshGroups(r.Context())
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "REFRESH_GROUPS_FAILED")
+		writeFailure(w, err, "REFRESH_GROUPS_FAILED")
 		return
 	}
 
@@ -111,14 +179,7 @@ func (h *Handlers) RefreshGroups(w http.ResponseWriter, r *http.Request) {
 
 // RenameGroup handles PUT /groups/{jid}/name
 func (h *Handlers) RenameGroup(w http.ResponseWriter, r *http.Request) {
-	// Extract JID from path: /groups/{jid}/name
-	path := strings.TrimPrefix(r.URL.Path, "/groups/")
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 || parts[1] != "name" {
-		writeError(w, http.StatusBadRequest, "invalid path", "INVALID_PATH")
-		return
-	}
-	jid := parts[0]
+	jid := r.PathValue("jid")
 
 	var req RenameGroupRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -127,12 +188,12 @@ func (h *Handlers) RenameGroup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if strings.TrimSpace(req.Name) == "" {
-		writeError(w, http.StatusBadRequest, "name is required", "MISSING_NAME")
+		writeFieldError(w, "name", "name is required", "MISSING_NAME")
 		return
 	}
 
 	if err := h.manager.RenameGroup(r.Context(), jid, req.Name); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "RENAME_GROUP_FAILED")
+		writeFailure(w, err, "RENAME_GROUP_FAILED")
 		return
 	}
 
@@ -143,16 +204,59 @@ func (h *Handlers) RenameGroup(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// UpdateGroupParticipants handles POST /groups/{jid}/participants
-func (h *Handlers) UpdateGroupParticipants(w http.ResponseWriter, r *http.Request) {
-	// Extract JID from path: /groups/{jid}/participants
-	path := strings.TrimPrefix(r.URL.Path, "/groups/")
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 || parts[1] != "participants" {
-		writeError(w, http.StatusBadRequest, "invalid path", "INVALID_PATH")
+// SetGroupDescription handles PUT /groups/{jid}/description
+func (h *Handlers) SetGroupDescription(w http.ResponseWriter, r *http.Request) {
+	jid := r.PathValue("jid")
+
+	var req GroupDescriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
 		return
 	}
-	jid := parts[0]
+
+	if utf8.RuneCountInString(req.Description) > 2048 {
+		writeFieldError(w, "description", "description must be at most 2048 characters", "INVALID_DESCRIPTION")
+		return
+	}
+
+	if err := h.manager.SetGroupDescription(r.Context(), jid, req.Description); err != nil {
+		writeFailure(w, err, "SET_DESCRIPTION_FAILED")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, GroupDescriptionResponse{
+		Success:     true,
+		JID:         jid,
+		Description: req.Description,
+	})
+}
+
+// UpdateGroupSettings handles PUT /groups/{jid}/settings
+func (h *Handlers) UpdateGroupSettings(w http.ResponseWriter, r *http.Request) {
+	jid := r.PathValue("jid")
+
+	var req GroupSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if req.Announce == nil && req.Locked == nil {
+		writeFieldError(w, "announce", "announce or locked is required", "MISSING_SETTINGS")
+		return
+	}
+
+	info, err := h.manager.SetGroupSettings(r.Context(), jid, req.Announce, req.Locked)
+	if err != nil {
+		writeFailure(w, err, "GROUP_SETTINGS_FAILED")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, groupInfoToResponse(info))
+}
+
+// UpdateGroupParticipants handles POST /groups/{jid}/participants
+func (h *Handlers) UpdateGroupParticipants(w http.ResponseWriter, r *http.Request) {
+	jid := r.PathValue("jid")
 
 	var req UpdateParticipantsRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -161,17 +265,17 @@ func (h *Handlers) UpdateGroupParticipants(w http.ResponseWriter, r *http.Reques
 	}
 
 	if req.Action == "" {
-		writeError(w, http.StatusBadRequest, "action is required", "MISSING_ACTION")
+		writeFieldError(w, "action", "action is required", "MISSING_ACTION")
 		return
 	}
 	if len(req.Users) == 0 {
-		writeError(w, http.StatusBadRequest, "users are required", "MISSING_USERS")
+		writeFieldError(w, "users", "users are required", "MISSING_USERS")
 		return
 	}
 
 	result, err := h.manager.UpdateGroupParticipants(r.Context(), jid, req.Users, req.Action)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "UPDATE_PARTICIPANTS_FAILED")
+		writeFailure(w, err, "UPDATE_PARTICIPANTS_FAILED")
 		return
 	}
 
@@ -182,8 +286,10 @@ func (h *Handlers) UpdateGroupParticipants(w http.ResponseWriter, r *http.Reques
 			errStr = strconv.Itoa(int(p.Error))
 		}
 		participants[i] = GroupParticipant{
-			JID:   p.JID.String(),
-			Error: errStr,
+			JID:         p.JID.String(),
+			PhoneNumber: optionalJID(p.PhoneNumber),
+			LID:         optionalJID(p.LID),
+			Error:       errStr,
 		}
 	}
 
@@ -197,18 +303,11 @@ func (h *Handlers) UpdateGroupParticipants(w http.ResponseWriter, r *http.Reques
 
 // GetGroupInviteLink handles GET /groups/{jid}/invite
 func (h *Handlers) GetGroupInviteLink(w http.ResponseWriter, r *http.Request) {
-	// Extract JID from path: /groups/{jid}/invite
-	path := strings.TrimPrefix(r.URL.Path, "/groups/")
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 || parts[1] != "invite" {
-		writeError(w, http.StatusBadRequest, "invalid path", "INVALID_PATH")
-		return
-	}
-	jid := parts[0]
+	jid := r.PathValue("jid")
 
 	link, err := h.manager.GetGroupInviteLink(r.Context(), jid)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "GET_INVITE_LINK_FAILED")
+		writeFailure(w, err, "GET_INVITE_LINK_FAILED")
 		return
 	}
 
@@ -220,18 +319,11 @@ func (h *Handlers) GetGroupInviteLink(w http.ResponseWriter, r *http.Request) {
 
 // RevokeGroupInviteLink handles POST /groups/{jid}/invite/revoke
 func (h *Handlers) RevokeGroupInviteLink(w http.ResponseWriter, r *http.Request) {
-	// Extract JID from path: /groups/{jid}/invite/revoke
-	path := strings.TrimPrefix(r.URL.Path, "/groups/")
-	parts := strings.Split(path, "/")
-	if len(parts) < 3 || parts[1] != "invite" || parts[2] != "revoke" {
-		writeError(w, http.StatusBadRequest, "invalid path", "INVALID_PATH")
-		return
-	}
-	jid := parts[0]
+	jid := r.PathValue("jid")
 
 	link, err := h.manager.RevokeGroupInviteLink(r.Context(), jid)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "REVOKE_INVITE_LINK_FAILED")
+		writeFailure(w, err, "REVOKE_INVITE_LINK_FAILED")
 		return
 	}
 
@@ -241,6 +333,48 @@ func (h *Handlers) RevokeGroupInviteLink(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// PreviewGroupInvite handles GET /groups/invite/{code}
+func (h *Handlers) PreviewGroupInvite(w http.ResponseWriter, r *http.Request) {
+	code := r.PathValue("code")
+	if strings.TrimSpace(code) == "" {
+		writeFieldError(w, "code", "code is required", "MISSING_CODE")
+		return
+	}
+
+	info, err := h.manager.PreviewGroupInvite(r.Context(), code)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInvite):
+			writeFieldError(w, "code", err.Error(), "INVALID_CODE")
+		case errors.Is(err, service.ErrInviteRevoked):
+			writeError(w, http.StatusGone, err.Error(), "INVITE_REVOKED")
+		default:
+			writeFailure(w, err, "INVITE_PREVIEW_FAILED")
+		}
+		return
+	}
+
+	count := info.ParticipantCount
+	if count == 0 {
+		count = len(info.Participants)
+	}
+	ownerJID := ""
+	if info.OwnerJID.User != "" {
+		ownerJID = info.OwnerJID.String()
+	}
+	writeJSON(w, http.StatusOK, GroupInvitePreviewResponse{
+		JID:                  info.JID.String(),
+		Name:                 info.Name,
+		Description:          info.Topic,
+		OwnerJID:             ownerJID,
+		CreatedAt:            info.GroupCreated,
+		ParticipantCount:     count,
+		IsCommunity:          info.IsParent,
+		CommunityJID:         app.CommunityJID(info),
+		JoinApprovalRequired: info.IsJoinApprovalRequired,
+	})
+}
+
 // JoinGroup handles POST /groups/join
 func (h *Handlers) JoinGroup(w http.ResponseWriter, r *http.Request) {
 	var req JoinGroupRequest
@@ -250,13 +384,13 @@ func (h *Handlers) JoinGroup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if strings.TrimSpace(req.Code) == "" {
-		writeError(w, http.StatusBadRequest, "code is required", "MISSING_CODE")
+		writeFieldError(w, "code", "code is required", "MISSING_CODE")
 		return
 	}
 
 	jid, err := h.manager.JoinGroup(r.Context(), req.Code)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "JOIN_GROUP_FAILED")
+		writeFailure(w, err, "JOIN_GROUP_FAILED")
 		return
 	}
 
@@ -268,17 +402,10 @@ func (h *Handlers) JoinGroup(w http.ResponseWriter, r *http.Request) {
 
 // LeaveGroup handles POST /groups/{jid}/leave
 func (h *Handlers) LeaveGroup(w http.ResponseWriter, r *http.Request) {
-	// Extract JID from path: /groups/{jid}/leave
-	path := strings.TrimPrefix(r.URL.Path, "/groups/")
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 || parts[1] != "leave" {
-		writeError(w, http.StatusBadRequest, "invalid path", "INVALID_PATH")
-		return
-	}
-	jid := parts[0]
+	jid := r.PathValue("jid")
 
 	if err := h.manager.LeaveGroup(r.Context(), jid); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "LEAVE_GROUP_FAILED")
+		writeFailure(w, err, "LEAVE_GROUP_FAILED")
 		return
 	}
 
@@ -287,3 +414,68 @@ func (h *Handlers) LeaveGroup(w http.ResponseWriter, r *http.Request) {
 		JID:     jid,
 	})
 }
+
+// GetGroupActivity handles GET /groups/{jid}/activity
+func (h *Handlers) GetGroupActivity(w http.ResponseWriter, r *http.Request) {
+	jid := r.PathValue("jid")
+
+	// Window: ?days=N (default 30, max 365) or explicit ?since=/&until= (RFC3339)
+	until := time.Now().UTC()
+	if v := r.URL.Query().Get("until"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeFieldError(w, "until", "until must be RFC3339", "INVALID_UNTIL")
+			return
+		}
+		until = t.UTC()
+	}
+	days := 30
+	if d := r.URL.Query().Get("days"); d != "" {
+		if n, err := strconv.Atoi(d); err == nil && n > 0 {
+			days = n
+		}
+	}
+	if days > 365 {
+		days = 365
+	}
+	since := until.AddDate(0, 0, -days)
+	if v := r.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeFieldError(w, "since", "since must be RFC3339", "INVALID_SINCE")
+			return
+		}
+		since = t.UTC()
+	}
+	if !since.Before(until) {
+		writeFieldError(w, "since", "since must be before until", "INVALID_WINDOW")
+		return
+	}
+
+	activity, err := h.manager.GetGroupActivity(jid, since, until)
+	if err != nil {
+		writeFailure(w, err, "GET_GROUP_ACTIVITY_FAILED")
+		return
+	}
+
+	resp := GroupActivityResponse{
+		JID:           activity.ChatJID,
+		Since:         since,
+		Until:         until,
+		TotalMessages: activity.TotalMessages,
+		Hours:         activity.Hours,
+		MemberCount:   len(activity.Members),
+		Members:       make([]MemberActivityResponse, len(activity.Members)),
+	}
+	for i, m := range activity.Members {
+		resp.Members[i] = MemberActivityResponse{
+			JID:           m.SenderJID,
+			Name:          m.SenderName,
+			MessageCount:  m.MessageCount,
+			Hours:         m.Hours,
+			LastMessageAt: m.LastMessage,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"mime"
 	"net/http"
 	"path"
 	"strconv"
@@ -14,6 +17,7 @@ import (
 
 	"github.com/steipete/wacli/internal/service"
 	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
 )
 
 const version = "wasvc/1.0"
@@ -40,20 +44,124 @@ func writeError(w http.ResponseWriter, status int, err, code string) {
 	writeJSON(w, status, ErrorResponse{Error: err, Code: code})
 }
 
+// writeMediaRateLimited reports CDN throttling as 503 with a Retry-After hint.
+func writeMediaRateLimited(w http.ResponseWriter, err error) {
+	retryAfter := 30 * time.Second
+	var rl *wa.MediaRateLimitError
+	if errors.As(err, &rl) && rl.RetryAfter > 0 {
+		retryAfter = rl.RetryAfter
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Round(time.Second)/time.Second)))
+	writeError(w, http.StatusServiceUnavailable, err.Error(), "MEDIA_RATE_LIMITED")
+}
+
+// writeSendThrottled responds 429 to a send refused by the send throttle.
+func writeSendThrottled(w http.ResponseWriter, err error) {
+	retryAfter := time.Second
+	var te *service.SendThrottledError
+	if errors.As(err, &te) && te.RetryAfter > retryAfter {
+		retryAfter = te.RetryAfter
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+	writeError(w, http.StatusTooManyRequests, err.Error(), "SEND_THROTTLED")
+}
+
+// writeSendError responds to a failed send, telling rejected files apart
+// from delivery failures.
+func writeSendError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, wa.ErrMediaRateLimited):
+		writeMediaRateLimited(w, err)
+	case errors.Is(err, service.ErrSendThrottled):
+		writeSendThrottled(w, err)
+	case errors.Is(err, service.ErrFileTooLarge):
+		writeError(w, http.StatusRequestEntityTooLarge, err.Error(), "FILE_TOO_LARGE")
+	case errors.Is(err, service.ErrMediaTypeNotAllowed):
+		writeError(w, http.StatusUnsupportedMediaType, err.Error(), "UNSUPPORTED_MEDIA_TYPE")
+	case errors.Is(err, service.ErrMimeMismatch):
+		writeError(w, http.StatusUnsupportedMediaType, err.Error(), "MIME_MISMATCH")
+	case errors.Is(err, service.ErrInvalidThumbnail):
+		writeError(w, http.StatusBadRequest, err.Error(), "INVALID_THUMBNAIL")
+	case errors.Is(err, service.ErrGIFNotVideo):
+		writeError(w, http.StatusBadRequest, err.Error(), "GIF_NOT_VIDEO")
+	default:
+		writeFailure(w, err, "SEND_FAILED")
+	}
+}
+
+// chatParam resolves the path parameter name, a JID or phone number, to the
+// JID its chat is stored under. It writes a 400 and returns false if the
+// value is neither.
+func (h *Handlers) chatParam(w http.ResponseWriter, r *http.Request, name string) (string, bool) {
+	jid, err := h.manager.ResolveChatJID(r.PathValue(name))
+	if err != nil {
+		writeFieldError(w, name, err.Error(), "INVALID_JID")
+		return "", false
+	}
+	return jid, true
+}
+
+// writeFieldError rejects a request because of one invalid or missing
+// field, naming it so clients can point at the right input.
+func writeFieldError(w http.ResponseWriter, field, msg, code string) {
+	writeJSON(w, http.StatusBadRequest, ErrorResponse{
+		Error:  msg,
+		Code:   code,
+		Fields: []FieldError{{Field: field, Message: msg}},
+	})
+}
+
+// writeFailure responds to an operation that failed after the request was
+// accepted. Failures ClassifyError recognizes, such as the service not being
+// ready, a bad JID, a missing record or a WhatsApp-level refusal, get their
+// own status and code; anything else is a 500 with the given code.
+func writeFailure(w http.ResponseWriter, err error, code string) {
+	status := http.StatusInternalServerError
+	switch service.ClassifyError(err) {
+	case service.ErrNotReady, service.ErrNotConnected, service.ErrNotAuthenticated:
+		status, code = http.StatusServiceUnavailable, "NOT_CONNECTED"
+	case service.ErrInvalidJID:
+		status, code = http.StatusBadRequest, "INVALID_JID"
+	case service.ErrNotFound:
+		status, code = http.StatusNotFound, "NOT_FOUND"
+	case service.ErrNotOnWhatsApp:
+		status, code = http.StatusUnprocessableEntity, "NOT_ON_WHATSAPP"
+	case service.ErrRateLimited:
+		w.Header().Set("Retry-After", "60")
+		status, code = http.StatusTooManyRequests, "WA_RATE_LIMITED"
+	case service.ErrTimedOut:
+		status, code = http.StatusGatewayTimeout, "WA_TIMEOUT"
+	case service.ErrNotGroupAdmin:
+		status, code = http.StatusForbidden, "NOT_GROUP_ADMIN"
+	case service.ErrNotInGroup:
+		status, code = http.StatusForbidden, "NOT_IN_GROUP"
+	case service.ErrGroupNotFound:
+		status, code = http.StatusNotFound, "GROUP_NOT_FOUND"
+	case service.ErrForbidden:
+		status, code = http.StatusForbidden, "WA_FORBIDDEN"
+	}
+	writeError(w, status, err.Error(), code)
+}
+
 // Health handles GET /health
 func (h *Handlers) Health(w http.ResponseWriter, r *http.Request) {
-	state := h.manager.State()
+	health := h.manager.Health(r.Context())
 	status := "ok"
-	if !state.State().IsReady() {
+	if !health.Ready || !health.DBReachable {
 		status = "degraded"
 	}
 
 	writeJSON(w, http.StatusOK, HealthResponse{
-		Status:    status,
-		State:     state.State().String(),
-		Ready:     state.State().IsReady(),
-		Version:   version,
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Status:            status,
+		State:             health.State.String(),
+		Ready:             health.Ready,
+		Version:           version,
+		Timestamp:         time.Now().UTC().Format(time.RFC3339),
+		UptimeSeconds:     int64(health.Uptime / time.Second),
+		ReconnectCount:    health.Reconnects,
+		WebhookQueueDepth: health.WebhookQueue,
+		LastEventAt:       optionalTime(health.LastEventAt),
+		DBReachable:       health.DBReachable,
 	})
 }
 
@@ -67,6 +175,7 @@ func (h *Handlers) AuthStatus(w http.ResponseWriter, r *http.Request) {
 		Authenticated: info.State == service.StateConnected,
 		Ready:         info.Ready,
 		HasQR:         info.HasQR,
+		PairCode:      info.PairCode,
 		Error:         info.Error,
 	})
 }
@@ -135,10 +244,49 @@ func (h *Handlers) AuthInit(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// AuthPairCode handles POST /auth/pair-code. It links by phone number as an
+// alternative to QR scanning and returns the code to enter on the phone;
+// pairing then completes in the background like POST /auth/init.
+func (h *Handlers) AuthPairCode(w http.ResponseWriter, r *http.Request) {
+	var req PairCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if strings.TrimSpace(req.Phone) == "" {
+		writeFieldError(w, "phone", "phone is required", "MISSING_PHONE")
+		return
+	}
+	if h.manager.State().State() == service.StateConnected {
+		writeError(w, http.StatusBadRequest, "already authenticated", "ALREADY_AUTHENTICATED")
+		return
+	}
+
+	code, err := h.manager.RequestPairCode(req.Phone)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPhone):
+			writeFieldError(w, "phone", err.Error(), "INVALID_PHONE")
+		case errors.Is(err, service.ErrAlreadyAuthenticated):
+			writeError(w, http.StatusBadRequest, err.Error(), "ALREADY_AUTHENTICATED")
+		case errors.Is(err, service.ErrAuthInProgress):
+			writeError(w, http.StatusConflict, err.Error(), "AUTH_IN_PROGRESS")
+		default:
+			writeFailure(w, err, "PAIR_CODE_FAILED")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, PairCodeResponse{
+		PairCode: code,
+		State:    h.manager.State().State().String(),
+	})
+}
+
 // AuthLogout handles POST /auth/logout
 func (h *Handlers) AuthLogout(w http.ResponseWriter, r *http.Request) {
 	if err := h.manager.Logout(r.Context()); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "LOGOUT_FAILED")
+		writeFailure(w, err, "LOGOUT_FAILED")
 		return
 	}
 
@@ -156,23 +304,36 @@ func (h *Handlers) SendText(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if strings.TrimSpace(req.To) == "" {
-		writeError(w, http.StatusBadRequest, "recipient 'to' is required", "MISSING_TO")
+		writeFieldError(w, "to", "recipient 'to' is required", "MISSING_TO")
+		return
+	}
+	if !chatAllowed(r, req.To) {
+		writeError(w, http.StatusForbidden, "token is not allowed to send to this chat", "FORBIDDEN")
 		return
 	}
 	if strings.TrimSpace(req.Message) == "" {
-		writeError(w, http.StatusBadRequest, "message is required", "MISSING_MESSAGE")
+		writeFieldError(w, "message", "message is required", "MISSING_MESSAGE")
 		return
 	}
 
-	msgID, err := h.manager.SendText(r.Context(), req.To, req.Message)
+	res, err := h.manager.SendTextOrQueue(r.Context(), req.To, req.Message)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "SEND_FAILED")
+		writeSendError(w, err)
+		return
+	}
+	if res.Queued {
+		writeJSON(w, http.StatusAccepted, SendMessageResponse{
+			Success:  true,
+			To:       req.To,
+			Queued:   true,
+			OutboxID: res.OutboxID,
+		})
 		return
 	}
 
 	writeJSON(w, http.StatusOK, SendMessageResponse{
 		Success:   true,
-		MessageID: msgID,
+		MessageID: res.MessageID,
 		To:        req.To,
 	})
 }
@@ -180,25 +341,88 @@ func (h *Handlers) SendText(w http.ResponseWriter, r *http.Request) {
 // SendFile handles POST /messages/file
 func (h *Handlers) SendFile(w http.ResponseWriter, r *http.Request) {
 	var req SendFileRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	var data []byte
+	var opts service.SendFileOptions
+	var err error
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "multipart/form-data" {
+		limit := int64(maxUploadBytes)
+		if n := h.manager.Config().SendMaxBytes; n > 0 && n < limit {
+			limit = n
+		}
+		req, data, opts.Thumbnail, err = readFileUpload(w, r, limit)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("file larger than %d MB", limit>>20), "FILE_TOO_LARGE")
+				return
+			}
+			writeError(w, http.StatusBadRequest, "invalid multipart body: "+err.Error(), "INVALID_REQUEST")
+			return
+		}
+		if len(data) == 0 {
+			writeFieldError(w, "file", "multipart body needs a non-empty 'file' part", "MISSING_FILE")
+			return
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
 		return
 	}
 
 	if strings.TrimSpace(req.To) == "" {
-		writeError(w, http.StatusBadRequest, "recipient 'to' is required", "MISSING_TO")
+		writeFieldError(w, "to", "recipient 'to' is required", "MISSING_TO")
 		return
 	}
+	if !chatAllowed(r, req.To) {
+		writeError(w, http.StatusForbidden, "token is not allowed to send to this chat", "FORBIDDEN")
+		return
+	}
+	// A chat-scoped token must not make the server fetch URLs or send
+	// upload sessions it did not create.
+	if chatScoped(r) && (req.FileURL != "" || req.UploadID != "") {
+		writeError(w, http.StatusForbidden, "token may only send file_data or a multipart file", "FORBIDDEN")
+		return
+	}
+	opts.GIFPlayback = req.GIFPlayback
+	if req.ThumbnailData != "" {
+		if opts.Thumbnail, err = decodeBase64(req.ThumbnailData); err != nil {
+			writeFieldError(w, "thumbnail_data", "invalid base64 thumbnail_data", "INVALID_THUMBNAIL")
+			return
+		}
+	}
 
-	var data []byte
-	var err error
-	var filename string
+	if req.UploadID != "" {
+		result, err := h.manager.SendUpload(r.Context(), req.To, req.UploadID, req.Filename, req.Caption, req.MimeType, opts)
+		if err != nil {
+			if errors.Is(err, service.ErrNotFound) || errors.Is(err, service.ErrUploadBusy) || errors.Is(err, service.ErrUploadIncomplete) {
+				writeUploadError(w, err)
+				return
+			}
+			writeSendError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, SendFileResponse{
+			Success:       true,
+			MessageID:     result.MessageID,
+			To:            req.To,
+			MediaType:     result.MediaType,
+			Filename:      result.Filename,
+			MimeType:      result.MimeType,
+			Bytes:         result.Bytes,
+			OriginalBytes: result.OriginalBytes,
+		})
+		return
+	}
 
-	if req.FileData != "" {
+	filename := req.Filename
+	if data != nil {
+		if filename == "" {
+			filename = "file"
+		}
+	} else if req.FileData != "" {
 		// Decode base64 data
 		data, err = decodeBase64(req.FileData)
 		if err != nil {
-			writeError(w, http.StatusBadRequest, "invalid base64 file_data", "INVALID_FILE_DATA")
+			writeFieldError(w, "file_data", "invalid base64 file_data", "INVALID_FILE_DATA")
 			return
 		}
 		filename = req.Filename
@@ -216,23 +440,25 @@ func (h *Handlers) SendFile(w http.ResponseWriter, r *http.Request) {
 			filename = req.Filename
 		}
 	} else {
-		writeError(w, http.StatusBadRequest, "either file_data or file_url is required", "MISSING_FILE")
+		writeFieldError(w, "file_data", "one of file_data, file_url or upload_id is required", "MISSING_FILE")
 		return
 	}
 
-	result, err := h.manager.SendFile(r.Context(), req.To, data, filename, req.Caption, req.MimeType)
+	result, err := h.manager.SendFile(r.Context(), req.To, data, filename, req.Caption, req.MimeType, opts)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "SEND_FAILED")
+		writeSendError(w, err)
 		return
 	}
 
 	writeJSON(w, http.StatusOK, SendFileResponse{
-		Success:   true,
-		MessageID: result.MessageID,
-		To:        req.To,
-		MediaType: result.MediaType,
-		Filename:  result.Filename,
-		MimeType:  result.MimeType,
+		Success:       true,
+		MessageID:     result.MessageID,
+		To:            req.To,
+		MediaType:     result.MediaType,
+		Filename:      result.Filename,
+		MimeType:      result.MimeType,
+		Bytes:         result.Bytes,
+		OriginalBytes: result.OriginalBytes,
 	})
 }
 
@@ -240,7 +466,7 @@ func (h *Handlers) SendFile(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) Search(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
 	if strings.TrimSpace(query) == "" {
-		writeError(w, http.StatusBadRequest, "query parameter 'q' is required", "MISSING_QUERY")
+		writeFieldError(w, "q", "query parameter 'q' is required", "MISSING_QUERY")
 		return
 	}
 
@@ -254,9 +480,33 @@ func (h *Handlers) Search(w http.ResponseWriter, r *http.Request) {
 		limit = 200
 	}
 
-	messages, err := h.manager.SearchMessages(query, limit)
+	p := store.SearchMessagesParams{
+		Query:     query,
+		Limit:     limit,
+		Sort:      r.URL.Query().Get("sort"),
+		Highlight: r.URL.Query().Get("highlight"),
+	}
+	switch p.Sort {
+	case "", store.SearchSortRank, store.SearchSortTime:
+	default:
+		writeFieldError(w, "sort", "sort must be rank or time", "INVALID_SORT")
+		return
+	}
+	switch p.Highlight {
+	case "", store.HighlightBrackets, store.HighlightMark:
+	default:
+		writeFieldError(w, "highlight", "highlight must be brackets or mark", "INVALID_HIGHLIGHT")
+		return
+	}
+	if v := r.URL.Query().Get("snippet_tokens"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			p.SnippetTokens = n
+		}
+	}
+
+	messages, err := h.manager.SearchMessages(p)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "SEARCH_FAILED")
+		writeFailure(w, err, "SEARCH_FAILED")
 		return
 	}
 
@@ -285,9 +535,44 @@ func (h *Handlers) ListChats(w http.ResponseWriter, r *http.Request) {
 		limit = 200
 	}
 
-	chats, err := h.manager.ListChats(query, limit)
+	p := store.ListChatsParams{
+		Query:   query,
+		LabelID: r.URL.Query().Get("label"),
+		Kind:    r.URL.Query().Get("kind"),
+		Sort:    r.URL.Query().Get("sort"),
+		Limit:   limit,
+	}
+	switch p.Kind {
+	case "", "dm", "group", "broadcast", "channel":
+	default:
+		writeFieldError(w, "kind", "kind must be dm, group, broadcast or channel", "INVALID_KIND")
+		return
+	}
+	switch p.Sort {
+	case "", store.ChatSortLastMessage, store.ChatSortName:
+	default:
+		writeFieldError(w, "sort", "sort must be last_message or name", "INVALID_SORT")
+		return
+	}
+	for _, f := range []struct {
+		name string
+		dst  **bool
+	}{{"has_unread", &p.HasUnread}, {"archived", &p.Archived}} {
+		v := r.URL.Query().Get(f.name)
+		if v == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeFieldError(w, f.name, f.name+" must be true or false", "INVALID_REQUEST")
+			return
+		}
+		*f.dst = &b
+	}
+
+	chats, err := h.manager.ListChats(p)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "LIST_CHATS_FAILED")
+		writeFailure(w, err, "LIST_CHATS_FAILED")
 		return
 	}
 
@@ -301,6 +586,17 @@ func (h *Handlers) ListChats(w http.ResponseWriter, r *http.Request) {
 			Kind:          c.Kind,
 			Name:          c.Name,
 			LastMessageTS: c.LastMessageTS,
+			Archived:      c.Archived,
+			Pinned:        c.Pinned,
+			Muted:         c.Muted,
+			UnreadCount:   c.UnreadCount,
+			LastMessage:   c.LastMessage,
+			LastSender:    c.LastSender,
+			Labels:        c.Labels,
+		}
+		if !c.MutedUntil.IsZero() {
+			t := c.MutedUntil
+			resp.Chats[i].MutedUntil = &t
 		}
 	}
 
@@ -309,17 +605,8 @@ func (h *Handlers) ListChats(w http.ResponseWriter, r *http.Request) {
 
 // ListMessages handles GET /chats/{jid}/messages
 func (h *Handlers) ListMessages(w http.ResponseWriter, r *http.Request) {
-	// Extract chat JID from path
-	path := strings.TrimPrefix(r.URL.Path, "/chats/")
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 || parts[1] != "messages" {
-		writeError(w, http.StatusBadRequest, "invalid path", "INVALID_PATH")
-		return
-	}
-	chatJID := parts[0]
-
-	if strings.TrimSpace(chatJID) == "" {
-		writeError(w, http.StatusBadRequest, "chat JID is required", "MISSING_JID")
+	chatJID, ok := h.chatParam(w, r, "jid")
+	if !ok {
 		return
 	}
 
@@ -335,7 +622,7 @@ func (h *Handlers) ListMessages(w http.ResponseWriter, r *http.Request) {
 
 	messages, err := h.manager.ListMessages(chatJID, limit)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "LIST_MESSAGES_FAILED")
+		writeFailure(w, err, "LIST_MESSAGES_FAILED")
 		return
 	}
 
@@ -353,15 +640,11 @@ func (h *Handlers) ListMessages(w http.ResponseWriter, r *http.Request) {
 
 // GetMedia handles GET /media/{chat_jid}/{msg_id}
 func (h *Handlers) GetMedia(w http.ResponseWriter, r *http.Request) {
-	// Extract chat JID and msg ID from path
-	path := strings.TrimPrefix(r.URL.Path, "/media/")
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 {
-		writeError(w, http.StatusBadRequest, "chat_jid and msg_id are required", "INVALID_PATH")
+	chatJID, ok := h.chatParam(w, r, "chat")
+	if !ok {
 		return
 	}
-	chatJID := parts[0]
-	msgID := parts[1]
+	msgID := r.PathValue("id")
 
 	info, err := h.manager.GetMediaDownloadInfo(chatJID, msgID)
 	if err != nil {
@@ -369,7 +652,7 @@ func (h *Handlers) GetMedia(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusNotFound, "media not found", "NOT_FOUND")
 			return
 		}
-		writeError(w, http.StatusInternalServerError, err.Error(), "GET_MEDIA_FAILED")
+		writeFailure(w, err, "GET_MEDIA_FAILED")
 		return
 	}
 
@@ -411,6 +694,196 @@ func (h *Handlers) Stats(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ChatStats handles GET /stats/chats
+func (h *Handlers) ChatStats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var p store.ChatStatsParams
+
+	// Window: ?days=N or ?since=/&until= (RFC3339); all messages by default
+	if v := q.Get("until"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeFieldError(w, "until", "until must be RFC3339", "INVALID_UNTIL")
+			return
+		}
+		p.Until = t.UTC()
+	}
+	if v := q.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeFieldError(w, "since", "since must be RFC3339", "INVALID_SINCE")
+			return
+		}
+		p.Since = t.UTC()
+	} else if d := q.Get("days"); d != "" {
+		n, err := strconv.Atoi(d)
+		if err != nil || n <= 0 {
+			writeFieldError(w, "days", "days must be a positive integer", "INVALID_DAYS")
+			return
+		}
+		until := p.Until
+		if until.IsZero() {
+			until = time.Now().UTC()
+		}
+		p.Since = until.AddDate(0, 0, -n)
+	}
+	if !p.Since.IsZero() && !p.Until.IsZero() && !p.Since.Before(p.Until) {
+		writeFieldError(w, "since", "since must be before until", "INVALID_WINDOW")
+		return
+	}
+
+	p.Limit = 50
+	if v := q.Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			p.Limit = n
+		}
+	}
+	if p.Limit > 500 {
+		p.Limit = 500
+	}
+	p.TopSenders = 3
+	if v := q.Get("top_senders"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			p.TopSenders = n
+		}
+	}
+	if p.TopSenders > 20 {
+		p.TopSenders = 20
+	}
+
+	stats, err := h.manager.ChatStats(p)
+	if err != nil {
+		writeFailure(w, err, "CHAT_STATS_FAILED")
+		return
+	}
+
+	resp := ChatStatsResponse{
+		Chats: make([]ChatStatsEntry, len(stats)),
+		Count: len(stats),
+	}
+	if !p.Since.IsZero() {
+		resp.Since = &p.Since
+	}
+	if !p.Until.IsZero() {
+		resp.Until = &p.Until
+	}
+	for i, s := range stats {
+		entry := ChatStatsEntry{
+			JID:            s.ChatJID,
+			Name:           s.ChatName,
+			Kind:           s.Kind,
+			MessageCount:   s.MessageCount,
+			MediaCount:     s.MediaCount,
+			FirstMessageAt: s.FirstMessage,
+			LastMessageAt:  s.LastMessage,
+			TopSenders:     make([]SenderCountResponse, len(s.TopSenders)),
+		}
+		for j, sc := range s.TopSenders {
+			entry.TopSenders[j] = SenderCountResponse{
+				JID:          sc.SenderJID,
+				Name:         sc.SenderName,
+				MessageCount: sc.MessageCount,
+			}
+		}
+		resp.Chats[i] = entry
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// maxTimelineBuckets caps the series /stats/timeline returns, about a year
+// of days or a month of hours.
+const maxTimelineBuckets = 1000
+
+// StatsTimeline handles GET /stats/timeline
+func (h *Handlers) StatsTimeline(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	// Granularity: ?interval=day (default, last 30 days) or hour (last 24 hours)
+	interval := q.Get("interval")
+	var p store.TimelineParams
+	defaultWindow := 30 * 24 * time.Hour
+	switch interval {
+	case "", "day":
+		interval, p.Bucket = "day", 24*time.Hour
+	case "hour":
+		p.Bucket, defaultWindow = time.Hour, 24*time.Hour
+	default:
+		writeFieldError(w, "interval", "interval must be day or hour", "INVALID_INTERVAL")
+		return
+	}
+
+	// Window: ?days=N or ?since=/&until= (RFC3339)
+	p.Until = time.Now().UTC()
+	if v := q.Get("until"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeFieldError(w, "until", "until must be RFC3339", "INVALID_UNTIL")
+			return
+		}
+		p.Until = t.UTC()
+	}
+	p.Since = p.Until.Add(-defaultWindow)
+	if v := q.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeFieldError(w, "since", "since must be RFC3339", "INVALID_SINCE")
+			return
+		}
+		p.Since = t.UTC()
+	} else if d := q.Get("days"); d != "" {
+		n, err := strconv.Atoi(d)
+		if err != nil || n <= 0 {
+			writeFieldError(w, "days", "days must be a positive integer", "INVALID_DAYS")
+			return
+		}
+		p.Since = p.Until.AddDate(0, 0, -n)
+	}
+	p.Since = p.Since.Truncate(p.Bucket)
+	if !p.Since.Before(p.Until) {
+		writeFieldError(w, "since", "since must be before until", "INVALID_WINDOW")
+		return
+	}
+	if p.Until.Sub(p.Since) > maxTimelineBuckets*p.Bucket {
+		writeFieldError(w, "since", fmt.Sprintf("window spans more than %d %ss", maxTimelineBuckets, interval), "INVALID_WINDOW")
+		return
+	}
+
+	if v := q.Get("chat_jid"); v != "" {
+		jid, err := h.manager.ResolveChatJID(v)
+		if err != nil {
+			writeFieldError(w, "chat_jid", err.Error(), "INVALID_JID")
+			return
+		}
+		p.ChatJID = jid
+	}
+
+	buckets, err := h.manager.MessageTimeline(p)
+	if err != nil {
+		writeFailure(w, err, "TIMELINE_FAILED")
+		return
+	}
+
+	resp := TimelineResponse{
+		Interval: interval,
+		Since:    p.Since,
+		Until:    p.Until,
+		ChatJID:  p.ChatJID,
+		Buckets:  make([]TimelineBucketResponse, len(buckets)),
+	}
+	for i, b := range buckets {
+		resp.Buckets[i] = TimelineBucketResponse{
+			Start:    b.Start,
+			Sent:     b.Sent,
+			Received: b.Received,
+			Total:    b.Sent + b.Received,
+		}
+		resp.TotalSent += b.Sent
+		resp.TotalReceived += b.Received
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
 // NotFound handles 404 responses.
 func (h *Handlers) NotFound(w http.ResponseWriter, r *http.Request) {
 	writeError(w, http.StatusNotFound, "endpoint not found", "NOT_FOUND")
@@ -423,17 +896,42 @@ func (h *Handlers) MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
 
 // messageToResponse converts a store.Message to MessageResponse.
 func messageToResponse(m store.Message) MessageResponse {
-	return MessageResponse{
-		ChatJID:   m.ChatJID,
-		ChatName:  m.ChatName,
-		MsgID:     m.MsgID,
-		SenderJID: m.SenderJID,
-		Timestamp: m.Timestamp,
-		FromMe:    m.FromMe,
-		Text:      m.Text,
-		MediaType: m.MediaType,
-		Snippet:   m.Snippet,
+	resp := MessageResponse{
+		ChatJID:     m.ChatJID,
+		ChatName:    m.ChatName,
+		MsgID:       m.MsgID,
+		SenderJID:   m.SenderJID,
+		SenderPN:    m.SenderPN,
+		SenderLID:   m.SenderLID,
+		Timestamp:   m.Timestamp,
+		FromMe:      m.FromMe,
+		Text:        m.Text,
+		MediaType:   m.MediaType,
+		Duration:    m.Duration,
+		SpamScore:   m.SpamScore,
+		Starred:     m.Starred,
+		Snippet:     m.Snippet,
+		Rank:        m.Rank,
+		Status:      m.Status,
+		DeliveredAt: optionalTime(m.DeliveredAt),
+		ReadAt:      optionalTime(m.ReadAt),
+		PlayedAt:    optionalTime(m.PlayedAt),
+		EditedText:  m.EditedText,
+		EditedAt:    optionalTime(m.EditedAt),
+		Revoked:     m.Revoked,
 	}
+	if m.ReplyToID != "" {
+		resp.ReplyTo = &ReplyToResponse{MsgID: m.ReplyToID, SenderJID: m.ReplyToSender}
+	}
+	return resp
+}
+
+// optionalTime returns nil for the zero time, so it is omitted from JSON.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
 }
 
 // drainBody discards and closes the request body.
@@ -447,9 +945,11 @@ func (h *Handlers) Doctor(w http.ResponseWriter, r *http.Request) {
 	storeDir, lockHeld, authenticated, connected := h.manager.GetDiagnostics()
 	messageCount, chatCount, contactCount, groupCount, ftsEnabled, err := h.manager.GetDBStats()
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "DIAGNOSTICS_FAILED")
+		writeFailure(w, err, "DIAGNOSTICS_FAILED")
 		return
 	}
+	media := h.manager.MediaStats()
+	recovery := h.manager.Recovery()
 
 	writeJSON(w, http.StatusOK, DoctorResponse{
 		StoreDir:      storeDir,
@@ -461,40 +961,81 @@ func (h *Handlers) Doctor(w http.ResponseWriter, r *http.Request) {
 		ChatCount:     chatCount,
 		ContactCount:  contactCount,
 		GroupCount:    groupCount,
+
+		MediaRateLimited:       media.RateLimited,
+		MediaRetries:           media.Retries,
+		MediaRateLimitFailures: media.Failures,
+
+		Recovery: RecoveryResponse{
+			ScannedAt:            recovery.ScannedAt,
+			TempFilesRemoved:     recovery.TempFilesRemoved,
+			PartialDownloads:     recovery.PartialDownloads,
+			StalePartialsRemoved: recovery.StalePartialsRemoved,
+			PendingWebhooks:      recovery.PendingWebhooks,
+			OutboxQueued:         recovery.OutboxQueued,
+			OutboxInterrupted:    recovery.OutboxInterrupted,
+		},
 	})
 }
 
 // SyncStatus handles GET /sync/status
 func (h *Handlers) SyncStatus(w http.ResponseWriter, r *http.Request) {
-	running, state, startedAt := h.manager.SyncStatus()
+	p := h.manager.SyncStatus()
 
-	writeJSON(w, http.StatusOK, SyncStatusResponse{
-		Running:        running,
-		State:          state,
-		MessagesSynced: 0, // TODO: track message count
-		StartedAt:      startedAt,
-	})
+	resp := SyncStatusResponse{
+		Running:        p.Running,
+		State:          p.State,
+		MessagesSynced: p.MessagesSynced,
+		HistoryChunks:  p.HistoryChunks,
+	}
+	if !p.StartedAt.IsZero() {
+		resp.StartedAt = &p.StartedAt
+	}
+	if !p.LastEventAt.IsZero() {
+		resp.LastEventAt = &p.LastEventAt
+	}
+	resp.Startup = StartupResponse{
+		Ready: p.Startup.Ready,
+		Steps: make([]StartupStepResponse, len(p.Startup.Steps)),
+	}
+	if !p.Startup.StartedAt.IsZero() {
+		resp.Startup.StartedAt = &p.Startup.StartedAt
+	}
+	if !p.Startup.FinishedAt.IsZero() {
+		resp.Startup.FinishedAt = &p.Startup.FinishedAt
+	}
+	for i, s := range p.Startup.Steps {
+		resp.Startup.Steps[i] = StartupStepResponse{
+			Name:       s.Name,
+			Status:     s.Status,
+			Count:      s.Count,
+			Error:      s.Error,
+			StartedAt:  s.StartedAt,
+			FinishedAt: s.FinishedAt,
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
 }
 
 // DownloadMedia handles POST /media/{chat_jid}/{msg_id}/download
 func (h *Handlers) DownloadMedia(w http.ResponseWriter, r *http.Request) {
-	// Extract chat JID and msg ID from path: /media/{chat_jid}/{msg_id}/download
-	path := strings.TrimPrefix(r.URL.Path, "/media/")
-	parts := strings.Split(path, "/")
-	if len(parts) < 3 || parts[2] != "download" {
-		writeError(w, http.StatusBadRequest, "invalid path", "INVALID_PATH")
+	chatJID, ok := h.chatParam(w, r, "chat")
+	if !ok {
 		return
 	}
-	chatJID := parts[0]
-	msgID := parts[1]
+	msgID := r.PathValue("id")
 
 	result, err := h.manager.DownloadMedia(r.Context(), chatJID, msgID)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			writeError(w, http.StatusNotFound, err.Error(), "NOT_FOUND")
+		if errors.Is(err, wa.ErrMediaRateLimited) {
+			writeMediaRateLimited(w, err)
+			return
+		}
+		if errors.Is(err, service.ErrDownloadInProgress) {
+			writeError(w, http.StatusConflict, err.Error(), "DOWNLOAD_IN_PROGRESS")
 			return
 		}
-		writeError(w, http.StatusInternalServerError, err.Error(), "DOWNLOAD_FAILED")
+		writeFailure(w, err, "DOWNLOAD_FAILED")
 		return
 	}
 
@@ -510,6 +1051,45 @@ func (h *Handlers) DownloadMedia(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// DownloadStatus handles GET /media/{chat_jid}/{msg_id}/download-status
+func (h *Handlers) DownloadStatus(w http.ResponseWriter, r *http.Request) {
+	chatJID, ok := h.chatParam(w, r, "chat")
+	if !ok {
+		return
+	}
+	st, err := h.manager.GetDownloadStatus(chatJID, r.PathValue("id"))
+	if err != nil {
+		if store.IsNotFound(err) {
+			writeError(w, http.StatusNotFound, "media not found", "NOT_FOUND")
+			return
+		}
+		writeFailure(w, err, "DOWNLOAD_STATUS_FAILED")
+		return
+	}
+
+	resp := DownloadStatusResponse{
+		ChatJID:      st.ChatJID,
+		MsgID:        st.MsgID,
+		State:        st.State,
+		BytesDone:    st.BytesDone,
+		BytesTotal:   st.BytesTotal,
+		ResumedFrom:  st.ResumedFrom,
+		PartialBytes: st.PartialBytes,
+		LocalPath:    st.LocalPath,
+		Error:        st.Error,
+	}
+	if st.BytesTotal > 0 {
+		resp.Percent = math.Min(100, math.Round(float64(st.BytesDone)*1000/float64(st.BytesTotal))/10)
+	}
+	if !st.StartedAt.IsZero() {
+		resp.StartedAt = &st.StartedAt
+	}
+	if !st.UpdatedAt.IsZero() {
+		resp.UpdatedAt = &st.UpdatedAt
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
 // decodeBase64 decodes a base64 string, handling data URL prefixes.
 func decodeBase64(s string) ([]byte, error) {
 	// Strip data URL prefix if present (e.g., "data:image/png;base64,...")
@@ -519,6 +1099,72 @@ func decodeBase64(s string) ([]byte, error) {
 	return base64.StdEncoding.DecodeString(s)
 }
 
+// maxUploadBytes bounds a multipart file upload to POST /messages/file.
+const maxUploadBytes = 100 << 20
+
+// maxThumbnailBytes bounds a custom thumbnail image.
+const maxThumbnailBytes = 5 << 20
+
+// readFileUpload reads a multipart/form-data send request part by part: the
+// raw bytes of the "file" and "thumbnail" parts and the to, caption,
+// filename and mime_type fields. The file's own name and Content-Type are
+// used unless the fields override them. data is nil if there is no file
+// part, thumbnail if there is no thumbnail part.
+func readFileUpload(w http.ResponseWriter, r *http.Request, limit int64) (req SendFileRequest, data, thumbnail []byte, err error) {
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return req, nil, nil, err
+	}
+	var partName, partType string
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return req, nil, nil, err
+		}
+		switch part.FormName() {
+		case "file":
+			if data, err = io.ReadAll(part); err != nil {
+				return req, nil, nil, err
+			}
+			partName, partType = part.FileName(), part.Header.Get("Content-Type")
+		case "thumbnail":
+			if thumbnail, err = io.ReadAll(io.LimitReader(part, maxThumbnailBytes)); err != nil {
+				return req, nil, nil, err
+			}
+		case "to", "caption", "filename", "mime_type", "gif_playback":
+			v, err := io.ReadAll(io.LimitReader(part, 64<<10))
+			if err != nil {
+				return req, nil, nil, err
+			}
+			switch part.FormName() {
+			case "to":
+				req.To = string(v)
+			case "caption":
+				req.Caption = string(v)
+			case "filename":
+				req.Filename = string(v)
+			case "mime_type":
+				req.MimeType = string(v)
+			case "gif_playback":
+				if req.GIFPlayback, err = strconv.ParseBool(string(v)); err != nil {
+					return req, nil, nil, fmt.Errorf("gif_playback must be true or false")
+				}
+			}
+		}
+		part.Close()
+	}
+	if req.Filename == "" {
+		req.Filename = partName
+	}
+	if req.MimeType == "" && partType != "application/octet-stream" {
+		req.MimeType = partType
+	}
+	return req, data, thumbnail, nil
+}
+
 // downloadFile downloads a file from a URL and returns its content and filename.
 func downloadFile(url string) ([]byte, string, error) {
 	resp, err := http.Get(url)
@@ -554,7 +1200,7 @@ func (h *Handlers) Backfill(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if strings.TrimSpace(req.ChatJID) == "" {
-		writeError(w, http.StatusBadRequest, "chat_jid is required", "MISSING_CHAT_JID")
+		writeFieldError(w, "chat_jid", "chat_jid is required", "MISSING_CHAT_JID")
 		return
 	}
 
@@ -574,7 +1220,7 @@ func (h *Handlers) Backfill(w http.ResponseWriter, r *http.Request) {
 
 	result, err := h.manager.BackfillHistory(r.Context(), req.ChatJID, count, requests, waitSeconds)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error(), "BACKFILL_FAILED")
+		writeFailure(w, err, "BACKFILL_FAILED")
 		return
 	}
 
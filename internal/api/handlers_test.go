@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.mau.fi/whatsmeow"
+
+	"github.com/steipete/wacli/internal/service"
+)
+
+func TestWriteFailureStatus(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"not initialized", fmt.Errorf("app not initialized: %w", service.ErrNotReady), http.StatusServiceUnavailable, "NOT_CONNECTED"},
+		{"not authenticated", service.ErrNotAuthenticated, http.StatusServiceUnavailable, "NOT_CONNECTED"},
+		{"disconnected", fmt.Errorf("send: %w", whatsmeow.ErrNotConnected), http.StatusServiceUnavailable, "NOT_CONNECTED"},
+		{"invalid JID", fmt.Errorf("%w: %w", service.ErrInvalidJID, errors.New("unexpected number of dots")), http.StatusBadRequest, "INVALID_JID"},
+		{"not found", fmt.Errorf("rule 7 %w", service.ErrNotFound), http.StatusNotFound, "NOT_FOUND"},
+		{"rate limited", whatsmeow.ErrIQRateOverLimit, http.StatusTooManyRequests, "WA_RATE_LIMITED"},
+		{"group admin", fmt.Errorf("%w: %w", service.ErrNotGroupAdmin, whatsmeow.ErrIQForbidden), http.StatusForbidden, "NOT_GROUP_ADMIN"},
+		// Text alone no longer decides the status.
+		{"not found in text", errors.New("chat not found"), http.StatusInternalServerError, "TEST_FAILED"},
+		{"other", errors.New("disk full"), http.StatusInternalServerError, "TEST_FAILED"},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		writeFailure(w, c.err, "TEST_FAILED")
+		var resp ErrorResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: decode: %v", c.name, err)
+		}
+		if w.Code != c.status || resp.Code != c.code {
+			t.Fatalf("%s: got %d %s, want %d %s", c.name, w.Code, resp.Code, c.status, c.code)
+		}
+		if resp.Error != c.err.Error() {
+			t.Fatalf("%s: message %q, want %q", c.name, resp.Error, c.err.Error())
+		}
+	}
+}
+
+func TestSendFileChatScopedTokenSources(t *testing.T) {
+	h := &Handlers{}
+	for _, body := range []string{
+		`{"to":"111@s.whatsapp.net","file_url":"http://169.254.169.254/latest/meta-data/"}`,
+		`{"to":"111@s.whatsapp.net","upload_id":"someone-elses-upload"}`,
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/messages/file", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		r = r.WithContext(context.WithValue(r.Context(), chatScopeKey{}, "111@s.whatsapp.net"))
+		w := httptest.NewRecorder()
+		h.SendFile(w, r)
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("%s: status %d, want 403; body %s", body, w.Code, w.Body)
+		}
+	}
+}
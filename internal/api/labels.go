@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/steipete/wacli/internal/service"
+	"github.com/steipete/wacli/internal/store"
+)
+
+// ListLabels handles GET /labels
+func (h *Handlers) ListLabels(w http.ResponseWriter, r *http.Request) {
+	labels, err := h.manager.ListLabels()
+	if err != nil {
+		writeFailure(w, err, "LIST_LABELS_FAILED")
+		return
+	}
+
+	resp := LabelsResponse{
+		Count:  len(labels),
+		Labels: make([]LabelResponse, len(labels)),
+	}
+	for i, l := range labels {
+		resp.Labels[i] = labelToResponse(l)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// CreateLabel handles POST /labels
+func (h *Handlers) CreateLabel(w http.ResponseWriter, r *http.Request) {
+	var req CreateLabelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		writeFieldError(w, "name", "name is required", "MISSING_NAME")
+		return
+	}
+
+	label, err := h.manager.CreateLabel(r.Context(), req.Name, req.Color)
+	if err != nil {
+		if errors.Is(err, service.ErrLabelExists) {
+			writeError(w, http.StatusConflict, err.Error(), "LABEL_EXISTS")
+			return
+		}
+		writeFailure(w, err, "LABEL_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusCreated, labelToResponse(label))
+}
+
+// DeleteLabel handles DELETE /labels/{id}
+func (h *Handlers) DeleteLabel(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if err := h.manager.DeleteLabel(r.Context(), id); err != nil {
+		writeFailure(w, err, "LABEL_FAILED")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"id":      id,
+	})
+}
+
+// LabelChat handles PUT (add) and DELETE (remove) /chats/{jid}/labels/{id}
+func (h *Handlers) LabelChat(w http.ResponseWriter, r *http.Request) {
+	chatJID, labelID := r.PathValue("jid"), r.PathValue("id")
+	labeled := r.Method == http.MethodPut
+
+	label, err := h.manager.LabelChat(r.Context(), chatJID, labelID, labeled)
+	if err != nil {
+		writeFailure(w, err, "LABEL_FAILED")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":  true,
+		"chat_jid": chatJID,
+		"label_id": label.ID,
+		"labeled":  labeled,
+	})
+}
+
+func labelToResponse(l store.Label) LabelResponse {
+	return LabelResponse{
+		ID:        l.ID,
+		Name:      l.Name,
+		Color:     l.Color,
+		Synced:    l.Synced,
+		ChatCount: l.ChatCount,
+		UpdatedAt: l.UpdatedAt,
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/steipete/wacli/internal/service"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// GetMediaContent handles GET /media/{chat_jid}/{msg_id}/content. It streams
+// the decrypted media, from the media dir if downloaded and straight from
+// WhatsApp otherwise. Range requests are supported; ?inline=true asks
+// browsers to display the media instead of saving it.
+func (h *Handlers) GetMediaContent(w http.ResponseWriter, r *http.Request) {
+	chatJID, ok := h.chatParam(w, r, "chat")
+	if !ok {
+		return
+	}
+	disposition := "attachment"
+	if v := r.URL.Query().Get("inline"); v != "" {
+		inline, err := strconv.ParseBool(v)
+		if err != nil {
+			writeFieldError(w, "inline", "inline must be true or false", "INVALID_REQUEST")
+			return
+		}
+		if inline {
+			disposition = "inline"
+		}
+	}
+
+	// Fetching and streaming large media can outlast the write timeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	content, err := h.manager.OpenMedia(r.Context(), chatJID, r.PathValue("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			writeError(w, http.StatusNotFound, "media not found", "NOT_FOUND")
+		case errors.Is(err, wa.ErrMediaRateLimited):
+			writeMediaRateLimited(w, err)
+		case errors.Is(err, service.ErrNoMedia):
+			writeError(w, http.StatusNotFound, err.Error(), "NOT_FOUND")
+		default:
+			writeFailure(w, err, "DOWNLOAD_FAILED")
+		}
+		return
+	}
+	defer content.Close()
+
+	if content.MimeType != "" {
+		w.Header().Set("Content-Type", content.MimeType)
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": content.Filename}))
+	http.ServeContent(w, r, content.Filename, content.ModTime, content)
+}
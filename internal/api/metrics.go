@@ -0,0 +1,71 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/steipete/wacli/internal/webhook"
+)
+
+// Metrics handles GET /metrics in the Prometheus text exposition format.
+func (h *Handlers) Metrics(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	st, _ := h.manager.WebhookStats()
+	writeWebhookMetrics(&buf, st)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// writeWebhookMetrics writes the webhook delivery counters, labelled by
+// endpoint URL, and the delivery latency histogram.
+func writeWebhookMetrics(buf *bytes.Buffer, st webhook.Stats) {
+	fmt.Fprintf(buf, "# HELP wasvc_webhook_queue_depth Webhook deliveries waiting in memory for a worker.\n")
+	fmt.Fprintf(buf, "# TYPE wasvc_webhook_queue_depth gauge\n")
+	fmt.Fprintf(buf, "wasvc_webhook_queue_depth %d\n", st.QueueDepth)
+
+	counters := []struct {
+		name, help string
+		value      func(webhook.EndpointStats) int64
+	}{
+		{"wasvc_webhook_events_enqueued_total", "Webhook events accepted for delivery.", func(s webhook.EndpointStats) int64 { return s.Enqueued }},
+		{"wasvc_webhook_events_delivered_total", "Webhook events acknowledged by the endpoint.", func(s webhook.EndpointStats) int64 { return s.Delivered }},
+		{"wasvc_webhook_delivery_retries_total", "Webhook delivery attempts after the first.", func(s webhook.EndpointStats) int64 { return s.Retried }},
+		{"wasvc_webhook_delivery_failures_total", "Webhook delivery attempts that failed.", func(s webhook.EndpointStats) int64 { return s.Failed }},
+		{"wasvc_webhook_events_dropped_total", "Webhook events lost to a full queue or exhausted retries.", func(s webhook.EndpointStats) int64 { return s.Dropped }},
+		{"wasvc_webhook_events_dead_lettered_total", "Webhook events moved to the dead-letter table.", func(s webhook.EndpointStats) int64 { return s.DeadLettered }},
+	}
+	for _, c := range counters {
+		fmt.Fprintf(buf, "# HELP %s %s\n", c.name, c.help)
+		fmt.Fprintf(buf, "# TYPE %s counter\n", c.name)
+		for _, ep := range st.Endpoints {
+			fmt.Fprintf(buf, "%s{endpoint=%s} %d\n", c.name, promLabel(ep.URL), c.value(ep))
+		}
+	}
+
+	const hist = "wasvc_webhook_delivery_duration_seconds"
+	fmt.Fprintf(buf, "# HELP %s Duration of webhook delivery requests.\n", hist)
+	fmt.Fprintf(buf, "# TYPE %s histogram\n", hist)
+	for _, ep := range st.Endpoints {
+		label := promLabel(ep.URL)
+		var cumulative int64
+		for i, bound := range webhook.LatencyBuckets {
+			cumulative += ep.Latency.Counts[i]
+			le := strconv.FormatFloat(bound.Seconds(), 'g', -1, 64)
+			fmt.Fprintf(buf, "%s_bucket{endpoint=%s,le=\"%s\"} %d\n", hist, label, le, cumulative)
+		}
+		fmt.Fprintf(buf, "%s_bucket{endpoint=%s,le=\"+Inf\"} %d\n", hist, label, ep.Latency.Count)
+		fmt.Fprintf(buf, "%s_sum{endpoint=%s} %s\n", hist, label, strconv.FormatFloat(ep.Latency.Sum.Seconds(), 'g', -1, 64))
+		fmt.Fprintf(buf, "%s_count{endpoint=%s} %d\n", hist, label, ep.Latency.Count)
+	}
+}
+
+// promLabel quotes a Prometheus label value.
+func promLabel(v string) string {
+	v = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+	return `"` + v + `"`
+}
@@ -1,10 +1,16 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/steipete/wacli/internal/service"
 )
 
 // responseWriter wraps http.ResponseWriter to capture status code.
@@ -18,6 +24,11 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // LoggingMiddleware logs all HTTP requests.
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -37,43 +48,63 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 		defer func() {
 			if err := recover(); err != nil {
 				log.Printf("[API] Panic recovered: %v", err)
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				writeError(w, http.StatusInternalServerError, "internal server error", "INTERNAL_ERROR")
 			}
 		}()
 		next.ServeHTTP(w, r)
 	})
 }
 
-// CORSMiddleware adds CORS headers for browser clients.
+// CORSMiddleware adds CORS headers for browser clients. Preflight OPTIONS
+// requests are answered by the router, for known paths only.
 func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
-
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
 		next.ServeHTTP(w, r)
 	})
 }
 
-// APIKeyMiddleware validates the API key if configured.
-func APIKeyMiddleware(apiKey string, next http.Handler) http.Handler {
+// APIKeyVerifier checks an API key and reports whether it is read-only.
+type APIKeyVerifier func(key string) (readOnly bool, err error)
+
+// AccessTokenVerifier returns the scopes an access token grants.
+type AccessTokenVerifier func(token string) ([]string, error)
+
+// ChatTokenResolver returns the chat JID a chat-scoped token grants access to.
+type ChatTokenResolver func(token string) (string, error)
+
+// MediaURLVerifier reports whether expires and sig sign the media content
+// URL of a message.
+type MediaURLVerifier func(chatJID, msgID, expires, sig string) bool
+
+type chatScopeKey struct{}
+
+// apiKeyKey marks requests made with an API key; the value is whether the
+// key is read-only.
+type apiKeyKey struct{}
+
+// APIKeyMiddleware validates the API key if verifyKey is set; without it
+// every request is allowed. Read-only keys may only read. Requests carrying
+// an access token instead are
+// limited to its scopes, and those carrying a chat-scoped token to sending
+// to and reading from that one chat. Media content URLs with a valid
+// signature need no key, and neither do routes registered as public.
+//
+// Decisions are made on the route the request was routed to (r.Pattern),
+// never on the raw path; requests that were not routed are treated as
+// private.
+func APIKeyMiddleware(verifyKey APIKeyVerifier, verifyAccess AccessTokenVerifier, resolveChat ChatTokenResolver, verifyMedia MediaURLVerifier, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Skip auth for health check, web UI, and auth endpoints
-		if r.URL.Path == "/" ||
-			r.URL.Path == "/health" ||
-			r.URL.Path == "/healthz" ||
-			strings.HasPrefix(r.URL.Path, "/auth/") {
+		// Health check, web UI and login endpoints
+		if publicRoute(r) {
 			next.ServeHTTP(w, r)
 			return
 		}
 
 		// If no API key configured, allow all requests
-		if apiKey == "" {
+		if verifyKey == nil {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -88,17 +119,263 @@ func APIKeyMiddleware(apiKey string, next http.Handler) http.Handler {
 			}
 		}
 
-		if key != apiKey {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusUnauthorized)
-			_, _ = w.Write([]byte(`{"error":"unauthorized","code":"UNAUTHORIZED"}`))
+		if signedMediaURL(r, verifyMedia) {
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		if key != "" {
+			if readOnly, err := verifyKey(key); err == nil {
+				if readOnly && !readOnlyRoute(r) {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusForbidden)
+					_, _ = w.Write([]byte(`{"error":"read-only key is not allowed to make this request","code":"FORBIDDEN"}`))
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyKey{}, readOnly)))
+				return
+			}
+		}
+
+		if key != "" && verifyAccess != nil {
+			if scopes, err := verifyAccess(key); err == nil {
+				if !accessTokenRoute(r, scopes) {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusForbidden)
+					_, _ = w.Write([]byte(`{"error":"token scope does not allow this request","code":"FORBIDDEN"}`))
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		if key != "" && resolveChat != nil {
+			if chatJID, err := resolveChat(key); err == nil {
+				if !chatScopedRoute(r, chatJID) {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusForbidden)
+					_, _ = w.Write([]byte(`{"error":"token is not allowed to access this endpoint","code":"FORBIDDEN"}`))
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), chatScopeKey{}, chatJID)))
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"unauthorized","code":"UNAUTHORIZED"}`))
 	})
 }
 
+// signedMediaURL reports whether r fetches media content with a valid
+// signature, as handed out in message.received webhooks.
+func signedMediaURL(r *http.Request, verify MediaURLVerifier) bool {
+	if verify == nil || !readMethod(r) || routePattern(r) != "/media/{chat}/{id}/content" {
+		return false
+	}
+	q := r.URL.Query()
+	return q.Get("sig") != "" && verify(r.PathValue("chat"), r.PathValue("id"), q.Get("expires"), q.Get("sig"))
+}
+
+// readMethod reports whether the request only reads.
+func readMethod(r *http.Request) bool {
+	return r.Method == http.MethodGet || r.Method == http.MethodHead
+}
+
+// credentialRoute reports whether the route pattern hands out or manages
+// credentials.
+func credentialRoute(pattern string) bool {
+	return pattern == "/auth/token" || pattern == "/tokens" || strings.HasPrefix(pattern, "/tokens/") || strings.HasPrefix(pattern, "/admin/keys")
+}
+
+// readOnlyReads are the route patterns a read-only API key may read: chats,
+// messages, contacts, search, statistics and media. Configuration such as
+// webhooks, scripts and rules stays hidden from it.
+var readOnlyReads = map[string]bool{
+	"/chats":                             true,
+	"/chats/{jid}/messages":              true,
+	"/messages/starred":                  true,
+	"/messages/failed":                   true,
+	"/messages/{chat}/{id}/status":       true,
+	"/contacts":                          true,
+	"/contacts/blocked":                  true,
+	"/contacts/export":                   true,
+	"/contacts/{jid}":                    true,
+	"/contacts/{jid}/photo":              true,
+	"/search":                            true,
+	"/stats":                             true,
+	"/stats/chats":                       true,
+	"/stats/timeline":                    true,
+	"/media/{chat}/{id}":                 true,
+	"/media/{chat}/{id}/content":         true,
+	"/media/{chat}/{id}/download-status": true,
+}
+
+// readOnlyRoute reports whether a read-only API key may call this endpoint:
+// the reads in readOnlyReads, and downloading media. Of the credential
+// endpoints it may only exchange itself for an access token, which the
+// handler limits to reads.
+func readOnlyRoute(r *http.Request) bool {
+	pattern := routePattern(r)
+	switch {
+	case pattern == "/auth/token":
+		return true
+	case readMethod(r):
+		return readOnlyReads[pattern]
+	}
+	return r.Method == http.MethodPost && pattern == "/media/{chat}/{id}/download"
+}
+
+// apiKeyRequest reports whether the request was made with an API key, and
+// whether that key is read-only.
+func apiKeyRequest(r *http.Request) (readOnly, ok bool) {
+	readOnly, ok = r.Context().Value(apiKeyKey{}).(bool)
+	return readOnly, ok
+}
+
+// accessTokenRoute reports whether an access token with scopes may call
+// this endpoint. Endpoints that hand out credentials need the API key.
+func accessTokenRoute(r *http.Request, scopes []string) bool {
+	pattern := routePattern(r)
+	if pattern == "" || credentialRoute(pattern) {
+		return false
+	}
+	has := func(scope string) bool {
+		for _, s := range scopes {
+			if s == scope {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case readMethod(r):
+		return has(service.ScopeRead)
+	case r.Method == http.MethodPost && (pattern == "/messages/text" || pattern == "/messages/file"):
+		return has(service.ScopeSend) || has(service.ScopeWrite)
+	}
+	return has(service.ScopeWrite)
+}
+
+// chatScopedRoute reports whether a chat-scoped token may call this endpoint.
+// Sends are allowed here and checked against the recipient by the handler.
+// The chat is taken from the path parameters the handler will use.
+func chatScopedRoute(r *http.Request, chatJID string) bool {
+	switch pattern := routePattern(r); pattern {
+	case "/messages/text", "/messages/file":
+		return r.Method == http.MethodPost
+	case "/messages/{chat}/{id}/status":
+		return readMethod(r) && sameChat(r.PathValue("chat"), chatJID)
+	case "/chats/{jid}/messages":
+		return readMethod(r) && sameChat(r.PathValue("jid"), chatJID)
+	default:
+		return strings.HasPrefix(pattern, "/media/{chat}/") && sameChat(r.PathValue("chat"), chatJID)
+	}
+}
+
+// chatScoped reports whether the request was made with a chat-scoped token.
+func chatScoped(r *http.Request) bool {
+	_, ok := r.Context().Value(chatScopeKey{}).(string)
+	return ok
+}
+
+// chatAllowed reports whether the request may act on chat. Requests made with
+// the API key (or without auth configured) are not restricted.
+func chatAllowed(r *http.Request, chat string) bool {
+	scope, ok := r.Context().Value(chatScopeKey{}).(string)
+	if !ok {
+		return true
+	}
+	return sameChat(chat, scope)
+}
+
+func sameChat(chat, scope string) bool {
+	jid, err := service.NormalizeChatJID(chat)
+	return err == nil && jid == scope
+}
+
+// BodyLimitMiddleware caps request bodies at limit bytes, or fileLimit for
+// endpoints that take files. Restores and upload chunks are streamed to disk
+// and bounded by their own checks. A zero limit means no limit. Oversized
+// bodies get 413 with code BODY_TOO_LARGE, whether announced by
+// Content-Length or found while the handler reads them.
+func BodyLimitMiddleware(limit, fileLimit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := limit
+			switch routePattern(r) {
+			case "/admin/restore", "/uploads/{id}":
+				n = 0
+			case "/messages/file", "/contacts/import", "/replica/ingest":
+				n = fileLimit
+			}
+			if n <= 0 || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.ContentLength > n {
+				writeBodyTooLarge(w, n)
+				return
+			}
+
+			body := &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, n)}
+			r.Body = body
+			next.ServeHTTP(&limitedBodyWriter{ResponseWriter: w, body: body, limit: n}, r)
+		})
+	}
+}
+
+func writeBodyTooLarge(w http.ResponseWriter, limit int64) {
+	writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body larger than %d MB", limit>>20), "BODY_TOO_LARGE")
+}
+
+// limitedBody notes when a request body hits its limit.
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// limitedBodyWriter turns the 400 a handler answers a cut-off body with
+// into a 413.
+type limitedBodyWriter struct {
+	http.ResponseWriter
+	body     *limitedBody
+	limit    int64
+	replaced bool
+}
+
+func (w *limitedBodyWriter) WriteHeader(code int) {
+	if code == http.StatusBadRequest && w.body.exceeded {
+		w.replaced = true
+		writeBodyTooLarge(w.ResponseWriter, w.limit)
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *limitedBodyWriter) Write(p []byte) (int, error) {
+	if w.replaced {
+		return len(p), nil
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *limitedBodyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // ContentTypeMiddleware sets default content type for API responses.
 func ContentTypeMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/steipete/wacli/internal/service"
+)
+
+// testAuthHandler routes a few endpoints behind APIKeyMiddleware the way
+// NewServer does. Keys: "full" and "ro" (read-only) API keys, "wat_read" a
+// read access token, "wct_chat" a token scoped to 111@s.whatsapp.net.
+func testAuthHandler() http.Handler {
+	verifyKey := func(key string) (bool, error) {
+		switch key {
+		case "full":
+			return false, nil
+		case "ro":
+			return true, nil
+		}
+		return false, errors.New("unknown key")
+	}
+	verifyAccess := func(token string) ([]string, error) {
+		if token == "wat_read" {
+			return []string{service.ScopeRead}, nil
+		}
+		return nil, errors.New("invalid token")
+	}
+	resolveChat := func(token string) (string, error) {
+		if token == "wct_chat" {
+			return "111@s.whatsapp.net", nil
+		}
+		return "", errors.New("invalid token")
+	}
+	verifyMedia := func(chatJID, msgID, expires, sig string) bool {
+		return chatJID == "111@s.whatsapp.net" && msgID == "ABC" && sig == "good"
+	}
+	rt := newRouter(func(next http.Handler) http.Handler {
+		return APIKeyMiddleware(verifyKey, verifyAccess, resolveChat, verifyMedia, next)
+	})
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	rt.handlePublic(http.MethodGet, "/health", ok)
+	rt.handlePublic(http.MethodGet, "/auth/status", ok)
+	rt.handle(http.MethodPost, "/auth/token", ok)
+	rt.handle(http.MethodPost, "/auth/logout", ok)
+	rt.handle(http.MethodPost, "/messages/text", ok)
+	rt.handle(http.MethodGet, "/chats/{jid}/messages", ok)
+	rt.handle(http.MethodGet, "/media/{chat}/{id}/content", ok)
+	rt.handle(http.MethodGet, "/webhooks", ok)
+	rt.handle(http.MethodGet, "/scripts/{name}", ok)
+	rt.handle(http.MethodGet, "/admin/keys", ok)
+	return rt
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	h := testAuthHandler()
+	cases := []struct {
+		method, target, key string
+		want                int
+	}{
+		// Public routes only as registered; a trailing slash is no route.
+		{"GET", "/health", "", http.StatusOK},
+		{"GET", "/auth/status", "", http.StatusOK},
+		{"GET", "/health/", "", http.StatusNotFound},
+		{"POST", "/auth/token", "", http.StatusUnauthorized},
+		{"POST", "/auth/token/", "", http.StatusNotFound},
+		{"POST", "/auth/logout", "", http.StatusUnauthorized},
+		{"POST", "/auth/logout/", "", http.StatusNotFound},
+		{"POST", "/auth/%74oken", "", http.StatusUnauthorized},
+		{"POST", "/auth/logout", "full", http.StatusOK},
+
+		// Read-only keys and access tokens.
+		{"POST", "/messages/text", "ro", http.StatusForbidden},
+		{"GET", "/chats/1/messages", "ro", http.StatusOK},
+		{"HEAD", "/chats/1/messages", "ro", http.StatusOK},
+		{"POST", "/auth/token", "ro", http.StatusOK},
+		{"GET", "/media/1/ABC/content", "ro", http.StatusOK},
+		{"GET", "/webhooks", "ro", http.StatusForbidden},
+		{"GET", "/scripts/greet", "ro", http.StatusForbidden},
+		{"HEAD", "/scripts/greet", "ro", http.StatusForbidden},
+		{"GET", "/admin/keys", "ro", http.StatusForbidden},
+		{"GET", "/scripts/greet", "full", http.StatusOK},
+		{"POST", "/auth/token", "wat_read", http.StatusForbidden},
+		{"GET", "/chats/1/messages", "wat_read", http.StatusOK},
+		{"POST", "/messages/text", "wat_read", http.StatusForbidden},
+
+		// Chat-scoped tokens see the chat the handler will use.
+		{"GET", "/chats/111%40s.whatsapp.net/messages", "wct_chat", http.StatusOK},
+		{"GET", "/chats/222@s.whatsapp.net/messages", "wct_chat", http.StatusForbidden},
+		{"GET", "/chats/111@s.whatsapp.net%2F..%2F222@s.whatsapp.net/messages", "wct_chat", http.StatusForbidden},
+		{"GET", "/chats/111@s.whatsapp.net/messages/", "wct_chat", http.StatusNotFound},
+		{"POST", "/auth/token", "wct_chat", http.StatusForbidden},
+
+		// Signed media URLs need no key.
+		{"GET", "/media/111@s.whatsapp.net/ABC/content?sig=good", "", http.StatusOK},
+		{"GET", "/media/111@s.whatsapp.net/ABC/content?sig=bad", "", http.StatusUnauthorized},
+		{"GET", "/media/111@s.whatsapp.net%2FABC/ABC/content?sig=good", "", http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(c.method, c.target, nil)
+		if c.key != "" {
+			r.Header.Set("Authorization", "Bearer "+c.key)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != c.want {
+			t.Fatalf("%s %s (key %q): status %d, want %d; body %s", c.method, c.target, c.key, w.Code, c.want, w.Body)
+		}
+	}
+}
+
+func TestAPIKeyMiddlewareUnroutedIsPrivate(t *testing.T) {
+	reached := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { reached = true })
+	h := APIKeyMiddleware(func(string) (bool, error) { return true, nil }, nil, nil, nil, next)
+	r := httptest.NewRequest("GET", "/health", nil)
+	r.Header.Set("X-API-Key", "ro")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if reached || w.Code != http.StatusForbidden {
+		t.Fatalf("unrouted request: status %d, reached %v", w.Code, reached)
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/steipete/wacli/internal/service"
+	"github.com/steipete/wacli/internal/store"
+)
+
+// ListOutbox handles GET /outbox
+func (h *Handlers) ListOutbox(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	status := q.Get("status")
+	switch status {
+	case "", store.OutboxQueued, store.OutboxSending, store.OutboxSent, store.OutboxFailed, store.OutboxCancelled:
+	default:
+		writeFieldError(w, "status", "status must be queued, sending, sent, failed or cancelled", "INVALID_STATUS")
+		return
+	}
+	limit := 50
+	if l := q.Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	msgs, err := h.manager.ListOutbox(status, limit)
+	if err != nil {
+		writeFailure(w, err, "OUTBOX_FAILED")
+		return
+	}
+	resp := OutboxResponse{Count: len(msgs), Messages: make([]OutboxMessageResponse, len(msgs))}
+	for i, m := range msgs {
+		resp.Messages[i] = outboxToResponse(m)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetOutboxMessage handles GET /outbox/{id}
+func (h *Handlers) GetOutboxMessage(w http.ResponseWriter, r *http.Request) {
+	id, ok := outboxID(w, r)
+	if !ok {
+		return
+	}
+	msg, err := h.manager.GetOutbox(id)
+	if err != nil {
+		writeOutboxError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, outboxToResponse(msg))
+}
+
+// CancelOutboxMessage handles DELETE /outbox/{id}
+func (h *Handlers) CancelOutboxMessage(w http.ResponseWriter, r *http.Request) {
+	id, ok := outboxID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.manager.CancelOutbox(id); err != nil {
+		writeOutboxError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"id":      id,
+		"status":  store.OutboxCancelled,
+	})
+}
+
+func outboxID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := pathInt64(r, "id")
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid outbox id", "INVALID_ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func writeOutboxError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotQueued):
+		writeError(w, http.StatusConflict, err.Error(), "NOT_QUEUED")
+	default:
+		writeFailure(w, err, "OUTBOX_FAILED")
+	}
+}
+
+func outboxToResponse(m store.OutboxMessage) OutboxMessageResponse {
+	return OutboxMessageResponse{
+		ID:        m.ID,
+		ChatJID:   m.ChatJID,
+		Text:      m.Text,
+		Status:    m.Status,
+		MessageID: m.MsgID,
+		Error:     m.Error,
+		Attempts:  m.Attempts,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// SetPresence handles POST /presence
+func (h *Handlers) SetPresence(w http.ResponseWriter, r *http.Request) {
+	var req SetPresenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if req.State != "available" && req.State != "unavailable" {
+		writeFieldError(w, "state", "state must be available or unavailable", "INVALID_STATE")
+		return
+	}
+
+	if err := h.manager.SetPresence(r.Context(), req.State); err != nil {
+		writeFailure(w, err, "PRESENCE_FAILED")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"state":   req.State,
+	})
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/steipete/wacli/internal/service"
+)
+
+const (
+	maxPushNameLen     = 25
+	maxAboutLen        = 139
+	maxProfilePhotoLen = 5 << 20
+)
+
+// GetProfile handles GET /profile
+func (h *Handlers) GetProfile(w http.ResponseWriter, r *http.Request) {
+	p, err := h.manager.GetProfile(r.Context())
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ProfileResponse{
+		JID:       p.JID,
+		PushName:  p.PushName,
+		About:     p.About,
+		PictureID: p.PictureID,
+	})
+}
+
+// SetProfileName handles PUT /profile/name
+func (h *Handlers) SetProfileName(w http.ResponseWriter, r *http.Request) {
+	var req ProfileNameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		writeFieldError(w, "name", "name is required", "MISSING_NAME")
+		return
+	}
+	if utf8.RuneCountInString(name) > maxPushNameLen {
+		writeFieldError(w, "name", "name must be at most 25 characters", "INVALID_NAME")
+		return
+	}
+	if err := h.manager.SetPushName(r.Context(), name); err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ProfileUpdateResponse{Success: true, PushName: name})
+}
+
+// SetProfileAbout handles PUT /profile/about
+func (h *Handlers) SetProfileAbout(w http.ResponseWriter, r *http.Request) {
+	var req ProfileAboutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	about := strings.TrimSpace(req.About)
+	if utf8.RuneCountInString(about) > maxAboutLen {
+		writeFieldError(w, "about", "about must be at most 139 characters", "INVALID_ABOUT")
+		return
+	}
+	if err := h.manager.SetAbout(r.Context(), about); err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ProfileUpdateResponse{Success: true, About: &about})
+}
+
+// GetOwnProfilePhoto handles GET /profile/photo; see GetProfilePhoto.
+func (h *Handlers) GetOwnProfilePhoto(w http.ResponseWriter, r *http.Request) {
+	preview, ok := parsePreview(w, r)
+	if !ok {
+		return
+	}
+	photo, err := h.manager.GetOwnProfilePhoto(r.Context(), preview)
+	servePhoto(w, r, photo, err)
+}
+
+// SetProfilePhoto handles PUT /profile/photo with a JPEG image as the body.
+func (h *Handlers) SetProfilePhoto(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxProfilePhotoLen))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "photo larger than 5 MB", "PHOTO_TOO_LARGE")
+			return
+		}
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if len(data) == 0 {
+		writeFieldError(w, "photo", "photo is required", "MISSING_PHOTO")
+		return
+	}
+	id, err := h.manager.SetProfilePhoto(r.Context(), data)
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ProfileUpdateResponse{Success: true, PictureID: id})
+}
+
+func writeProfileError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidImage):
+		writeFieldError(w, "photo", err.Error(), "INVALID_IMAGE")
+	default:
+		writeFailure(w, err, "PROFILE_FAILED")
+	}
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/steipete/wacli/internal/service"
+)
+
+// GetProfilePhoto handles GET /contacts/{jid}/photo and
+// GET /groups/{jid}/photo. The picture is served as image/jpeg; ?preview=true
+// returns the small thumbnail instead of the full-size picture.
+func (h *Handlers) GetProfilePhoto(w http.ResponseWriter, r *http.Request) {
+	preview, ok := parsePreview(w, r)
+	if !ok {
+		return
+	}
+	photo, err := h.manager.GetProfilePhoto(r.Context(), r.PathValue("jid"), preview)
+	servePhoto(w, r, photo, err)
+}
+
+// parsePreview reads the ?preview flag, writing a 400 if it is malformed.
+func parsePreview(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	v := r.URL.Query().Get("preview")
+	if v == "" {
+		return false, true
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		writeFieldError(w, "preview", "preview must be true or false", "INVALID_REQUEST")
+		return false, false
+	}
+	return b, true
+}
+
+// servePhoto writes a cached profile picture or the error fetching it.
+func servePhoto(w http.ResponseWriter, r *http.Request, photo *service.ProfilePhoto, err error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPhotoHidden):
+			writeError(w, http.StatusForbidden, err.Error(), "PHOTO_HIDDEN")
+		default:
+			writeFailure(w, err, "PHOTO_FAILED")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("ETag", strconv.Quote(photo.ID))
+	http.ServeFile(w, r, photo.Path)
+}
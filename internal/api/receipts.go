@@ -0,0 +1,49 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/steipete/wacli/internal/service"
+)
+
+// MessageStatus handles GET /messages/{chat}/{id}/status
+func (h *Handlers) MessageStatus(w http.ResponseWriter, r *http.Request) {
+	chatJID, ok := h.chatParam(w, r, "chat")
+	if !ok {
+		return
+	}
+	msgID := r.PathValue("id")
+
+	msg, receipts, err := h.manager.MessageStatus(chatJID, msgID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotOwnMessage):
+			writeError(w, http.StatusBadRequest, err.Error(), "NOT_OWN_MESSAGE")
+		default:
+			writeFailure(w, err, "STATUS_FAILED")
+		}
+		return
+	}
+
+	resp := MessageStatusResponse{
+		ChatJID:     msg.ChatJID,
+		MsgID:       msg.MsgID,
+		Timestamp:   msg.Timestamp,
+		Status:      msg.Status,
+		DeliveredAt: optionalTime(msg.DeliveredAt),
+		ReadAt:      optionalTime(msg.ReadAt),
+		PlayedAt:    optionalTime(msg.PlayedAt),
+		Recipients:  make([]ReceiptResponse, len(receipts)),
+	}
+	for i, rc := range receipts {
+		resp.Recipients[i] = ReceiptResponse{
+			JID:         rc.RecipientJID,
+			Status:      rc.Status,
+			DeliveredAt: optionalTime(rc.DeliveredAt),
+			ReadAt:      optionalTime(rc.ReadAt),
+			PlayedAt:    optionalTime(rc.PlayedAt),
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
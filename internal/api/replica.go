@@ -0,0 +1,45 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/steipete/wacli/internal/replica"
+)
+
+// ReplicaIngest handles POST /replica/ingest
+func (h *Handlers) ReplicaIngest(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read request body", "INVALID_REQUEST")
+		return
+	}
+
+	if secret := h.manager.Config().ReplicaSecret; secret != "" {
+		if !replica.Verify(body, secret, r.Header.Get("X-Replica-Signature")) {
+			writeError(w, http.StatusUnauthorized, "invalid replica signature", "INVALID_SIGNATURE")
+			return
+		}
+	}
+
+	var batch replica.Batch
+	if err := json.Unmarshal(body, &batch); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+
+	result, err := h.manager.ApplyReplicaBatch(batch)
+	if err != nil {
+		writeFailure(w, err, "REPLICA_APPLY_FAILED")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ReplicaIngestResponse{
+		Success:  true,
+		Cursor:   batch.Cursor,
+		Chats:    result.Chats,
+		Messages: result.Messages,
+		Deleted:  result.Deleted,
+	})
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// GetResponder handles GET /admin/responder
+func (h *Handlers) GetResponder(w http.ResponseWriter, r *http.Request) {
+	overrides, err := h.manager.ListChatResponders()
+	if err != nil {
+		writeFailure(w, err, "RESPONDER_FAILED")
+		return
+	}
+
+	cfg := h.manager.Config()
+	resp := ResponderResponse{
+		Enabled:        cfg.ResponderURL != "",
+		Model:          cfg.ResponderModel,
+		AllChats:       cfg.ResponderAllChats,
+		ContextSize:    cfg.ResponderContext,
+		MaxPerHour:     cfg.ResponderMaxPerHour,
+		TimeoutSeconds: int64(cfg.ResponderTimeout / time.Second),
+		Chats:          make([]ChatResponderResponse, len(overrides)),
+	}
+	for i, o := range overrides {
+		resp.Chats[i] = ChatResponderResponse{
+			ChatJID:   o.ChatJID,
+			Enabled:   o.Enabled,
+			UpdatedAt: o.UpdatedAt,
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// SetChatResponder handles PUT /chats/{jid}/responder
+func (h *Handlers) SetChatResponder(w http.ResponseWriter, r *http.Request) {
+	chatJID := r.PathValue("jid")
+	var req SetChatResponderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if req.Enabled == nil {
+		writeFieldError(w, "enabled", "enabled is required", "MISSING_ENABLED")
+		return
+	}
+
+	if err := h.manager.SetChatResponder(chatJID, *req.Enabled); err != nil {
+		writeFailure(w, err, "RESPONDER_FAILED")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":  true,
+		"chat_jid": chatJID,
+		"enabled":  *req.Enabled,
+	})
+}
+
+// ClearChatResponder handles DELETE /chats/{jid}/responder
+func (h *Handlers) ClearChatResponder(w http.ResponseWriter, r *http.Request) {
+	chatJID := r.PathValue("jid")
+	if err := h.manager.ClearChatResponder(chatJID); err != nil {
+		writeFailure(w, err, "RESPONDER_FAILED")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":  true,
+		"chat_jid": chatJID,
+	})
+}
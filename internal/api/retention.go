@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/steipete/wacli/internal/service"
+)
+
+// GetRetention handles GET /admin/retention
+func (h *Handlers) GetRetention(w http.ResponseWriter, r *http.Request) {
+	overrides, err := h.manager.ListChatRetention()
+	if err != nil {
+		writeFailure(w, err, "RETENTION_FAILED")
+		return
+	}
+
+	cfg := h.manager.Config()
+	resp := RetentionResponse{
+		MaxAgeSeconds:   int64(cfg.RetentionMaxAge / time.Second),
+		MaxDBBytes:      cfg.RetentionMaxDBBytes,
+		IntervalSeconds: int64(cfg.RetentionInterval / time.Second),
+		Chats:           make([]ChatRetentionResponse, len(overrides)),
+	}
+	for i, o := range overrides {
+		resp.Chats[i] = ChatRetentionResponse{
+			ChatJID:       o.ChatJID,
+			MaxAgeSeconds: int64(o.MaxAge / time.Second),
+			KeepForever:   o.MaxAge == 0,
+			UpdatedAt:     o.UpdatedAt,
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// RetentionDryRun handles POST /admin/retention/dry-run
+func (h *Handlers) RetentionDryRun(w http.ResponseWriter, r *http.Request) {
+	report, err := h.manager.PruneMessages(true)
+	if err != nil {
+		writeFailure(w, err, "RETENTION_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusOK, retentionReportToResponse(report))
+}
+
+// SetChatRetention handles PUT /chats/{jid}/retention
+func (h *Handlers) SetChatRetention(w http.ResponseWriter, r *http.Request) {
+	chatJID := r.PathValue("jid")
+	var req SetChatRetentionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if req.MaxAgeSeconds < 0 {
+		writeFieldError(w, "max_age_seconds", "max_age_seconds must not be negative", "INVALID_MAX_AGE")
+		return
+	}
+
+	if err := h.manager.SetChatRetention(chatJID, time.Duration(req.MaxAgeSeconds)*time.Second); err != nil {
+		writeFailure(w, err, "RETENTION_FAILED")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":         true,
+		"chat_jid":        chatJID,
+		"max_age_seconds": req.MaxAgeSeconds,
+		"keep_forever":    req.MaxAgeSeconds == 0,
+	})
+}
+
+// ClearChatRetention handles DELETE /chats/{jid}/retention
+func (h *Handlers) ClearChatRetention(w http.ResponseWriter, r *http.Request) {
+	chatJID := r.PathValue("jid")
+	if err := h.manager.ClearChatRetention(chatJID); err != nil {
+		writeFailure(w, err, "RETENTION_FAILED")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":  true,
+		"chat_jid": chatJID,
+	})
+}
+
+func retentionReportToResponse(r service.RetentionReport) RetentionReportResponse {
+	resp := RetentionReportResponse{
+		DryRun:     r.DryRun,
+		Messages:   r.Messages,
+		ByAge:      r.ByAge,
+		BySize:     r.BySize,
+		MediaFiles: r.MediaFiles,
+		MediaBytes: r.MediaBytes,
+		DBBytes:    r.DBBytes,
+		Chats:      make([]RetentionChatCount, 0, len(r.Chats)),
+	}
+	for jid, n := range r.Chats {
+		resp.Chats = append(resp.Chats, RetentionChatCount{ChatJID: jid, Messages: n})
+	}
+	sort.Slice(resp.Chats, func(i, j int) bool {
+		if resp.Chats[i].Messages != resp.Chats[j].Messages {
+			return resp.Chats[i].Messages > resp.Chats[j].Messages
+		}
+		return resp.Chats[i].ChatJID < resp.Chats[j].ChatJID
+	})
+	return resp
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// router is an http.ServeMux that answers in JSON: unknown paths get 404
+// NOT_FOUND and known paths called with the wrong method 405
+// METHOD_NOT_ALLOWED. OPTIONS is answered for known paths, without
+// credentials, so browsers can send CORS preflights.
+//
+// The middleware given to newRouter wraps every registered handler, so it
+// runs after routing and decides on r.Pattern and r.PathValue rather than
+// the raw path.
+type router struct {
+	mux        *http.ServeMux
+	middleware []func(http.Handler) http.Handler
+}
+
+type publicKey struct{}
+
+func newRouter(middleware ...func(http.Handler) http.Handler) *router {
+	return &router{mux: http.NewServeMux(), middleware: middleware}
+}
+
+// handle registers handler for method and pattern. Like http.ServeMux it
+// panics on a malformed pattern or a conflicting registration.
+func (rt *router) handle(method, pattern string, handler http.HandlerFunc) {
+	rt.mux.Handle(method+" "+pattern, ChainMiddleware(handler, rt.middleware...))
+}
+
+// handlePublic is handle for endpoints that need no credentials, such as
+// the health check and the login flow.
+func (rt *router) handlePublic(method, pattern string, handler http.HandlerFunc) {
+	h := ChainMiddleware(handler, rt.middleware...)
+	rt.mux.Handle(method+" "+pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), publicKey{}, true)))
+	}))
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := rt.mux.Handler(r); pattern == "" {
+		w = &jsonErrorWriter{ResponseWriter: w, options: r.Method == http.MethodOptions}
+	}
+	rt.mux.ServeHTTP(w, r)
+}
+
+// jsonErrorWriter replaces the plain-text bodies of the 404 and 405
+// responses ServeMux writes for requests it cannot route. No route takes
+// OPTIONS, so for those ServeMux's 405 means the path is known and becomes
+// 200 with its Allow header.
+type jsonErrorWriter struct {
+	http.ResponseWriter
+	options  bool // request method is OPTIONS
+	replaced bool
+}
+
+func (w *jsonErrorWriter) WriteHeader(status int) {
+	if status == http.StatusMethodNotAllowed {
+		w.Header().Set("Allow", w.Header().Get("Allow")+", "+http.MethodOptions)
+	}
+	switch {
+	case status == http.StatusMethodNotAllowed && w.options:
+		w.Header().Del("Content-Type")
+		w.Header().Del("X-Content-Type-Options")
+		w.ResponseWriter.WriteHeader(http.StatusOK)
+	case status == http.StatusNotFound:
+		writeError(w.ResponseWriter, status, "endpoint not found", "NOT_FOUND")
+	case status == http.StatusMethodNotAllowed:
+		writeError(w.ResponseWriter, status, "method not allowed", "METHOD_NOT_ALLOWED")
+	default:
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.replaced = true
+}
+
+func (w *jsonErrorWriter) Write(b []byte) (int, error) {
+	if w.replaced {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// publicRoute reports whether the request was routed to an endpoint
+// registered with handlePublic.
+func publicRoute(r *http.Request) bool {
+	public, _ := r.Context().Value(publicKey{}).(bool)
+	return public
+}
+
+// routePattern returns the path of the pattern the request was routed to,
+// without the method, or "" if it has not been routed.
+func routePattern(r *http.Request) string {
+	_, path, _ := strings.Cut(r.Pattern, " ")
+	return path
+}
+
+// pathInt64 reads a numeric path parameter.
+func pathInt64(r *http.Request, name string) (int64, error) {
+	return strconv.ParseInt(r.PathValue(name), 10, 64)
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/steipete/wacli/internal/service"
+)
+
+// testRouter registers handlers that answer with the matched pattern and
+// its parameters.
+func testRouter() *router {
+	rt := newRouter()
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		out := routePattern(r)
+		for _, name := range []string{"jid", "id", "chat", "path"} {
+			if v := r.PathValue(name); v != "" {
+				out += " " + name + "=" + v
+			}
+		}
+		_, _ = w.Write([]byte(out))
+	}
+	rt.handle(http.MethodGet, "/groups", echo)
+	rt.handle(http.MethodPost, "/groups/join", echo)
+	rt.handle(http.MethodGet, "/groups/{jid}", echo)
+	rt.handle(http.MethodPut, "/groups/{jid}/name", echo)
+	rt.handle(http.MethodGet, "/chats/{jid}/messages", echo)
+	rt.handle(http.MethodDelete, "/chats/{jid}/messages", echo)
+	rt.handle(http.MethodGet, "/media/{chat}/{id}", echo)
+	rt.handle(http.MethodGet, "/files/{path...}", echo)
+	return rt
+}
+
+func serve(h http.Handler, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestRouterMatches(t *testing.T) {
+	rt := testRouter()
+	cases := []struct {
+		method, target, want string
+	}{
+		{"GET", "/groups", "/groups"},
+		{"POST", "/groups/join", "/groups/join"},
+		{"GET", "/groups/123@g.us", "/groups/{jid} jid=123@g.us"},
+		{"PUT", "/groups/123@g.us/name", "/groups/{jid}/name jid=123@g.us"},
+		{"GET", "/chats/123%40s.whatsapp.net/messages", "/chats/{jid}/messages jid=123@s.whatsapp.net"},
+		{"GET", "/chats/a%2Fb/messages", "/chats/{jid}/messages jid=a/b"},
+		{"GET", "/media/123@s.whatsapp.net/ABC", "/media/{chat}/{id} id=ABC chat=123@s.whatsapp.net"},
+		{"GET", "/files/a/b%20c", "/files/{path...} path=a/b c"},
+		{"HEAD", "/groups", ""},
+	}
+	for _, c := range cases {
+		w := serve(rt, c.method, c.target)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s %s: status %d, body %s", c.method, c.target, w.Code, w.Body)
+		}
+		if c.method != "HEAD" && w.Body.String() != c.want {
+			t.Fatalf("%s %s = %q, want %q", c.method, c.target, w.Body, c.want)
+		}
+	}
+}
+
+func TestRouterLiteralBeatsParameter(t *testing.T) {
+	rt := newRouter()
+	rt.handle(http.MethodGet, "/groups/{jid}", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("param")) })
+	rt.handle(http.MethodGet, "/groups/join", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("literal")) })
+	if got := serve(rt, "GET", "/groups/join").Body.String(); got != "literal" {
+		t.Fatalf("GET /groups/join = %q, want literal", got)
+	}
+	if got := serve(rt, "GET", "/groups/other").Body.String(); got != "param" {
+		t.Fatalf("GET /groups/other = %q, want param", got)
+	}
+}
+
+func TestRouterRejectsUnknownPaths(t *testing.T) {
+	rt := testRouter()
+	for _, target := range []string{
+		"/nope",
+		"/groups/",
+		"/groups/join/",
+		"/groups/123@g.us/name/",
+		"/chats/123/messages/",
+		"/groups%2Fjoin",
+		"/",
+	} {
+		w := serve(rt, "GET", target)
+		if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), `"NOT_FOUND"`) {
+			t.Fatalf("GET %s: status %d, body %s; want 404 NOT_FOUND", target, w.Code, w.Body)
+		}
+	}
+	if w := serve(rt, "OPTIONS", "/nope"); w.Code != http.StatusNotFound {
+		t.Fatalf("OPTIONS /nope: status %d, want 404", w.Code)
+	}
+}
+
+func TestRouterMethodNotAllowed(t *testing.T) {
+	rt := testRouter()
+	w := serve(rt, "POST", "/chats/123/messages")
+	if w.Code != http.StatusMethodNotAllowed || !strings.Contains(w.Body.String(), `"METHOD_NOT_ALLOWED"`) {
+		t.Fatalf("POST: status %d, body %s", w.Code, w.Body)
+	}
+	if got := w.Header().Get("Allow"); got != "DELETE, GET, HEAD, OPTIONS" {
+		t.Fatalf("Allow = %q", got)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Fatalf("Content-Type = %q", got)
+	}
+	if w := serve(rt, "HEAD", "/groups/123@g.us/name"); w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("HEAD without GET: status %d, want 405", w.Code)
+	}
+
+	w = serve(rt, "OPTIONS", "/groups/123@g.us/name")
+	if w.Code != http.StatusOK || w.Header().Get("Allow") != "PUT, OPTIONS" {
+		t.Fatalf("OPTIONS: status %d, Allow %q", w.Code, w.Header().Get("Allow"))
+	}
+}
+
+func TestRouterMiddlewareSeesRoute(t *testing.T) {
+	var seen string
+	mw := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = routePattern(r) + " " + r.PathValue("jid")
+			next.ServeHTTP(w, r)
+		})
+	}
+	rt := newRouter(mw)
+	rt.handle(http.MethodGet, "/chats/{jid}/messages", func(w http.ResponseWriter, r *http.Request) {})
+	if w := serve(rt, "GET", "/chats/1%40s.whatsapp.net/messages"); w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+	if seen != "/chats/{jid}/messages 1@s.whatsapp.net" {
+		t.Fatalf("middleware saw %q", seen)
+	}
+
+	seen = ""
+	if w := serve(rt, "GET", "/chats/1/messages/"); w.Code != http.StatusNotFound || seen != "" {
+		t.Fatalf("unknown path reached middleware: status %d, seen %q", w.Code, seen)
+	}
+	if w := serve(rt, "OPTIONS", "/chats/1/messages"); w.Code != http.StatusOK || seen != "" {
+		t.Fatalf("OPTIONS reached middleware: status %d, seen %q", w.Code, seen)
+	}
+}
+
+func TestNewServerRoutes(t *testing.T) {
+	// Registering a pattern that conflicts with another panics.
+	h := NewServer(service.Config{}, nil).server.Handler
+	if w := serve(h, "GET", "/nope"); w.Code != http.StatusNotFound {
+		t.Fatalf("GET /nope: status %d", w.Code)
+	}
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/steipete/wacli/internal/rules"
+	"github.com/steipete/wacli/internal/service"
+	"github.com/steipete/wacli/internal/store"
+)
+
+// ListRules handles GET /rules
+func (h *Handlers) ListRules(w http.ResponseWriter, r *http.Request) {
+	list, err := h.manager.ListRules()
+	if err != nil {
+		writeFailure(w, err, "LIST_RULES_FAILED")
+		return
+	}
+
+	resp := RulesResponse{
+		Count: len(list),
+		Rules: make([]RuleResponse, len(list)),
+	}
+	for i, rule := range list {
+		resp.Rules[i] = ruleToResponse(rule)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// CreateRule handles POST /rules
+func (h *Handlers) CreateRule(w http.ResponseWriter, r *http.Request) {
+	params, ok := decodeRuleRequest(w, r)
+	if !ok {
+		return
+	}
+
+	rule, err := h.manager.CreateRule(params)
+	if err != nil {
+		writeRuleError(w, err, "CREATE_RULE_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusCreated, ruleToResponse(rule))
+}
+
+// GetRule handles GET /rules/{id}
+func (h *Handlers) GetRule(w http.ResponseWriter, r *http.Request) {
+	id, ok := ruleID(w, r)
+	if !ok {
+		return
+	}
+
+	rule, err := h.manager.GetRule(id)
+	if err != nil {
+		writeRuleError(w, err, "GET_RULE_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusOK, ruleToResponse(rule))
+}
+
+// UpdateRule handles PUT /rules/{id}
+func (h *Handlers) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	id, ok := ruleID(w, r)
+	if !ok {
+		return
+	}
+	params, ok := decodeRuleRequest(w, r)
+	if !ok {
+		return
+	}
+
+	rule, err := h.manager.UpdateRule(id, params)
+	if err != nil {
+		writeRuleError(w, err, "UPDATE_RULE_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusOK, ruleToResponse(rule))
+}
+
+// DeleteRule handles DELETE /rules/{id}
+func (h *Handlers) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	id, ok := ruleID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.manager.DeleteRule(id); err != nil {
+		writeRuleError(w, err, "DELETE_RULE_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"id":      id,
+	})
+}
+
+func ruleID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := pathInt64(r, "id")
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid rule id", "INVALID_ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func decodeRuleRequest(w http.ResponseWriter, r *http.Request) (service.RuleParams, bool) {
+	var req RuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return service.RuleParams{}, false
+	}
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	return service.RuleParams{
+		Name:       req.Name,
+		Enabled:    enabled,
+		Priority:   req.Priority,
+		Conditions: req.Conditions,
+		Actions:    req.Actions,
+	}, true
+}
+
+func writeRuleError(w http.ResponseWriter, err error, code string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRule):
+		writeError(w, http.StatusBadRequest, err.Error(), "INVALID_RULE")
+	default:
+		writeFailure(w, err, code)
+	}
+}
+
+func ruleToResponse(r store.Rule) RuleResponse {
+	resp := RuleResponse{
+		ID:        r.ID,
+		Name:      r.Name,
+		Enabled:   r.Enabled,
+		Priority:  r.Priority,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+	// Stored rules were validated when saved.
+	_ = json.Unmarshal([]byte(r.Conditions), &resp.Conditions)
+	_ = json.Unmarshal([]byte(r.Actions), &resp.Actions)
+	if resp.Actions == nil {
+		resp.Actions = []rules.Action{}
+	}
+	return resp
+}
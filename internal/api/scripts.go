@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/steipete/wacli/internal/service"
+)
+
+// maxScriptBytes bounds the body of PUT /scripts/{name}.
+const maxScriptBytes = 256 << 10
+
+// ListScripts handles GET /scripts
+func (h *Handlers) ListScripts(w http.ResponseWriter, r *http.Request) {
+	scripts, err := h.manager.ListScripts()
+	if err != nil {
+		writeFailure(w, err, "LIST_SCRIPTS_FAILED")
+		return
+	}
+
+	resp := ScriptsResponse{
+		Count:   len(scripts),
+		Scripts: make([]ScriptResponse, len(scripts)),
+	}
+	for i, s := range scripts {
+		resp.Scripts[i] = scriptToResponse(s)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetScript handles GET /scripts/{name}
+func (h *Handlers) GetScript(w http.ResponseWriter, r *http.Request) {
+	s, err := h.manager.GetScript(r.PathValue("name"))
+	if err != nil {
+		writeFailure(w, err, "GET_SCRIPT_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusOK, scriptToResponse(s))
+}
+
+// PutScript handles PUT /scripts/{name}
+func (h *Handlers) PutScript(w http.ResponseWriter, r *http.Request) {
+	var req PutScriptRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxScriptBytes)).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "script larger than 256 KB", "SCRIPT_TOO_LARGE")
+			return
+		}
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if strings.TrimSpace(req.Source) == "" {
+		writeFieldError(w, "source", "source is required", "MISSING_SOURCE")
+		return
+	}
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	s, err := h.manager.PutScript(r.PathValue("name"), req.Source, enabled)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidScriptName):
+			writeError(w, http.StatusBadRequest, err.Error(), "INVALID_SCRIPT_NAME")
+		case errors.Is(err, service.ErrInvalidScript):
+			writeFieldError(w, "source", err.Error(), "INVALID_SCRIPT")
+		default:
+			writeFailure(w, err, "SAVE_SCRIPT_FAILED")
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, scriptToResponse(s))
+}
+
+// DeleteScript handles DELETE /scripts/{name}
+func (h *Handlers) DeleteScript(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := h.manager.DeleteScript(name); err != nil {
+		writeFailure(w, err, "DELETE_SCRIPT_FAILED")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"name":    name,
+	})
+}
+
+func scriptToResponse(s service.ScriptInfo) ScriptResponse {
+	resp := ScriptResponse{
+		Name:      s.Name,
+		Source:    s.Source,
+		Enabled:   s.Enabled,
+		Loaded:    s.Loaded,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+		Runs:      s.Stats.Runs,
+		Errors:    s.Stats.Errors,
+		LastError: s.Stats.LastError,
+	}
+	if !s.Stats.LastRunAt.IsZero() {
+		t := s.Stats.LastRunAt
+		resp.LastRunAt = &t
+	}
+	return resp
+}
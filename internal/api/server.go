@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/steipete/wacli/internal/service"
@@ -23,66 +22,244 @@ type Server struct {
 func NewServer(cfg service.Config, mgr *service.Manager) *Server {
 	handlers := NewHandlers(mgr)
 
-	mux := http.NewServeMux()
+	var verifyKey APIKeyVerifier
+	if cfg.APIKeyAuth() {
+		verifyKey = func(key string) (bool, error) {
+			k, err := mgr.VerifyAPIKey(key)
+			return k.ReadOnly, err
+		}
+	}
+	// Route middleware runs after routing, so it sees the matched pattern.
+	mux := newRouter(
+		CompressionMiddleware(cfg.Compression),
+		ContentTypeMiddleware,
+		BodyLimitMiddleware(cfg.MaxBodyBytes, cfg.MaxFileBodyBytes),
+		func(next http.Handler) http.Handler {
+			return APIKeyMiddleware(verifyKey, func(token string) ([]string, error) {
+				t, err := mgr.VerifyAccessToken(token)
+				return t.Scopes, err
+			}, func(token string) (string, error) {
+				t, err := mgr.ResolveChatToken(token)
+				return t.ChatJID, err
+			}, mgr.VerifyMediaURL, next)
+		},
+	)
+	get := func(pattern string, h http.HandlerFunc) { mux.handle(http.MethodGet, pattern, h) }
+	post := func(pattern string, h http.HandlerFunc) { mux.handle(http.MethodPost, pattern, h) }
+	put := func(pattern string, h http.HandlerFunc) { mux.handle(http.MethodPut, pattern, h) }
+	del := func(pattern string, h http.HandlerFunc) { mux.handle(http.MethodDelete, pattern, h) }
+	// Endpoints reachable without credentials
+	publicGet := func(pattern string, h http.HandlerFunc) { mux.handlePublic(http.MethodGet, pattern, h) }
+	publicPost := func(pattern string, h http.HandlerFunc) { mux.handlePublic(http.MethodPost, pattern, h) }
 
 	// Web UI for authentication (no auth required)
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		// Only serve auth page for exact root path
-		if r.URL.Path != "/" {
-			handlers.NotFound(w, r)
-			return
-		}
-		handlers.AuthPage(w, r)
-	})
+	publicGet("/{$}", handlers.AuthPage)
+
+	// Read-only message browser; it calls the API with the operator's key
+	publicGet("/browse", handlers.BrowserPage)
 
 	// Health endpoints (no auth required)
-	mux.HandleFunc("/health", handlers.Health)
-	mux.HandleFunc("/healthz", handlers.Health)
+	publicGet("/health", handlers.Health)
+	publicGet("/healthz", handlers.Health)
+
+	// Auth endpoints; logging out and issuing tokens need the API key
+	publicGet("/auth/status", handlers.AuthStatus)
+	publicGet("/auth/qr", handlers.AuthQR)
+	publicPost("/auth/init", handlers.AuthInit)
+	publicPost("/auth/pair-code", handlers.AuthPairCode)
+	post("/auth/logout", handlers.AuthLogout)
+	post("/auth/token", handlers.IssueAccessToken)
+
+	// Presence
+	post("/presence", handlers.SetPresence)
 
-	// Auth endpoints
-	mux.HandleFunc("/auth/status", handlers.AuthStatus)
-	mux.HandleFunc("/auth/qr", handlers.AuthQR)
-	mux.HandleFunc("/auth/init", methodHandler(http.MethodPost, handlers.AuthInit))
-	mux.HandleFunc("/auth/logout", methodHandler(http.MethodPost, handlers.AuthLogout))
+	// Calls
+	get("/calls", handlers.ListCalls)
 
 	// Message endpoints
-	mux.HandleFunc("/messages/text", methodHandler(http.MethodPost, handlers.SendText))
-	mux.HandleFunc("/messages/file", methodHandler(http.MethodPost, handlers.SendFile))
+	post("/messages/text", handlers.SendText)
+	post("/messages/file", handlers.SendFile)
+	get("/messages/starred", handlers.ListStarredMessages)
+	get("/messages/failed", handlers.ListFailedSends)
+	del("/messages/failed/{id}", handlers.DeleteFailedSend)
+	post("/messages/{id}/retry", handlers.RetryFailedSend)
+	get("/messages/{chat}/{id}/status", handlers.MessageStatus)
+	get("/outbox", handlers.ListOutbox)
+	get("/outbox/{id}", handlers.GetOutboxMessage)
+	del("/outbox/{id}", handlers.CancelOutboxMessage)
 
 	// Search endpoint
-	mux.HandleFunc("/search", methodHandler(http.MethodGet, handlers.Search))
+	get("/search", handlers.Search)
 
 	// Chats endpoints
-	mux.HandleFunc("/chats", methodHandler(http.MethodGet, handlers.ListChats))
-	mux.HandleFunc("/chats/", chatMessagesHandler(handlers))
-
-	// Media endpoint
-	mux.HandleFunc("/media/", mediaHandler(handlers))
+	get("/chats", handlers.ListChats)
+	get("/chats/{jid}/messages", handlers.ListMessages)
+	del("/chats/{jid}/messages", handlers.ClearChatMessages)
+	put("/chats/{jid}/messages/{id}/star", handlers.StarMessage)
+	del("/chats/{jid}/messages/{id}/star", handlers.StarMessage)
+	put("/chats/{jid}/retention", handlers.SetChatRetention)
+	del("/chats/{jid}/retention", handlers.ClearChatRetention)
+	put("/chats/{jid}/responder", handlers.SetChatResponder)
+	del("/chats/{jid}/responder", handlers.ClearChatResponder)
+	put("/chats/{jid}/labels/{id}", handlers.LabelChat)
+	del("/chats/{jid}/labels/{id}", handlers.LabelChat)
+	// archive, pin, mute and read
+	put("/chats/{jid}/{state}", handlers.SetChatState)
+	del("/chats/{jid}/{state}", handlers.SetChatState)
+
+	// Label endpoints
+	get("/labels", handlers.ListLabels)
+	post("/labels", handlers.CreateLabel)
+	del("/labels/{id}", handlers.DeleteLabel)
+
+	// Media endpoints
+	get("/media/{chat}/{id}", handlers.GetMedia)
+	get("/media/{chat}/{id}/content", handlers.GetMediaContent)
+	post("/media/{chat}/{id}/download", handlers.DownloadMedia)
+	get("/media/{chat}/{id}/download-status", handlers.DownloadStatus)
 
 	// Stats endpoint
-	mux.HandleFunc("/stats", methodHandler(http.MethodGet, handlers.Stats))
+	get("/stats", handlers.Stats)
+	get("/stats/chats", handlers.ChatStats)
+	get("/stats/timeline", handlers.StatsTimeline)
+
+	// Upload session endpoints
+	post("/uploads", handlers.CreateUpload)
+	get("/uploads/{id}", handlers.GetUpload)
+	put("/uploads/{id}", handlers.PutUploadChunk)
+	del("/uploads/{id}", handlers.DeleteUpload)
 
 	// Contacts endpoints
-	mux.HandleFunc("/contacts", methodHandler(http.MethodGet, handlers.SearchContacts))
-	mux.HandleFunc("/contacts/refresh", methodHandler(http.MethodPost, handlers.RefreshContacts))
-	mux.HandleFunc("/contacts/", contactsHandler(handlers))
+	get("/contacts", handlers.SearchContacts)
+	post("/contacts/refresh", handlers.RefreshContacts)
+	get("/contacts/blocked", handlers.ListBlocked)
+	post("/contacts/check", handlers.CheckContacts)
+	post("/contacts/import", handlers.ImportContacts)
+	get("/contacts/export", handlers.ExportContacts)
+	get("/contacts/{jid}", handlers.GetContact)
+	put("/contacts/{jid}/alias", handlers.SetContactAlias)
+	del("/contacts/{jid}/alias", handlers.DeleteContactAlias)
+	get("/contacts/{jid}/photo", handlers.GetProfilePhoto)
+	post("/contacts/{jid}/block", handlers.BlockContact)
+	post("/contacts/{jid}/unblock", handlers.BlockContact)
+	post("/contacts/{jid}/tags", handlers.AddContactTag)
+	del("/contacts/{jid}/tags/{tag}", handlers.DeleteContactTag)
+
+	// Profile endpoints
+	get("/profile", handlers.GetProfile)
+	put("/profile/name", handlers.SetProfileName)
+	put("/profile/about", handlers.SetProfileAbout)
+	get("/profile/photo", handlers.GetOwnProfilePhoto)
+	put("/profile/photo", handlers.SetProfilePhoto)
 
 	// Groups endpoints
-	mux.HandleFunc("/groups", methodHandler(http.MethodGet, handlers.ListGroups))
-	mux.HandleFunc("/groups/refresh", methodHandler(http.MethodPost, handlers.RefreshGroups))
-	mux.HandleFunc("/groups/join", methodHandler(http.MethodPost, handlers.JoinGroup))
-	mux.HandleFunc("/groups/", groupsHandler(handlers))
+	get("/groups", handlers.ListGroups)
+	post("/groups", handlers.CreateGroup)
+	post("/groups/refresh", handlers.RefreshGroups)
+	post("/groups/join", handlers.JoinGroup)
+	get("/groups/{jid}", handlers.GetGroupInfo)
+	put("/groups/{jid}/name", handlers.RenameGroup)
+	put("/groups/{jid}/settings", handlers.UpdateGroupSettings)
+	put("/groups/{jid}/description", handlers.SetGroupDescription)
+	post("/groups/{jid}/participants", handlers.UpdateGroupParticipants)
+	post("/groups/{jid}/invite/revoke", handlers.RevokeGroupInviteLink)
+	post("/groups/{jid}/leave", handlers.LeaveGroup)
+	// ServeMux cannot rank /groups/invite/{code} against the reads of
+	// /groups/{jid}/..., so they share one pattern; a group JID is never
+	// "invite".
+	groupReads := map[string]http.HandlerFunc{
+		"photo":    handlers.GetProfilePhoto,
+		"invite":   handlers.GetGroupInviteLink,
+		"activity": handlers.GetGroupActivity,
+	}
+	get("/groups/{jid}/{view}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("jid") == "invite" {
+			r.SetPathValue("code", r.PathValue("view"))
+			handlers.PreviewGroupInvite(w, r)
+			return
+		}
+		if h, ok := groupReads[r.PathValue("view")]; ok {
+			h(w, r)
+			return
+		}
+		writeError(w, http.StatusNotFound, "endpoint not found", "NOT_FOUND")
+	})
+
+	// Channel endpoints
+	get("/channels", handlers.ListChannels)
+	get("/channels/{jid}", handlers.GetChannel)
+	get("/channels/{jid}/messages", handlers.ListChannelPosts)
+	put("/channels/{jid}/follow", handlers.FollowChannel)
+	del("/channels/{jid}/follow", handlers.FollowChannel)
+	get("/communities", handlers.ListCommunities)
+	get("/communities/{jid}/groups", handlers.ListCommunityGroups)
+	get("/communities/{jid}/announcements", handlers.ListCommunityGroups)
 
 	// Sync control endpoints
-	mux.HandleFunc("/sync/status", methodHandler(http.MethodGet, handlers.SyncStatus))
-	mux.HandleFunc("/sync/start", methodHandler(http.MethodPost, handlers.StartSync))
-	mux.HandleFunc("/sync/stop", methodHandler(http.MethodPost, handlers.StopSync))
+	get("/sync/status", handlers.SyncStatus)
+	post("/sync/start", handlers.StartSync)
+	post("/sync/stop", handlers.StopSync)
 
 	// History backfill endpoint
-	mux.HandleFunc("/history/backfill", methodHandler(http.MethodPost, handlers.Backfill))
+	post("/history/backfill", handlers.Backfill)
+	post("/history/backfill/all", handlers.StartBackfillAll)
+	get("/history/backfill/all", handlers.BackfillAllStatus)
+	del("/history/backfill/all", handlers.CancelBackfillAll)
+
+	// Webhook endpoints
+	get("/webhooks", handlers.ListWebhooks)
+	get("/webhooks/stats", handlers.WebhookStats)
+	put("/webhooks/chat-filter", handlers.SetWebhookChatFilter)
+	del("/webhooks/chat-filter", handlers.ResetWebhookChatFilter)
+	// Webhook dead-letter endpoints
+	get("/webhooks/deadletter", handlers.ListWebhookDeadLetters)
+	post("/webhooks/deadletter/{id}/replay", handlers.ReplayWebhookDeadLetter)
+
+	// Chat-scoped token endpoints
+	get("/tokens", handlers.ListChatTokens)
+	post("/tokens", handlers.CreateChatToken)
+	del("/tokens/{id}", handlers.RevokeChatToken)
+
+	// Routing rule endpoints
+	get("/rules", handlers.ListRules)
+	post("/rules", handlers.CreateRule)
+	get("/rules/{id}", handlers.GetRule)
+	put("/rules/{id}", handlers.UpdateRule)
+	del("/rules/{id}", handlers.DeleteRule)
+
+	// Auto-responder endpoint
+	get("/admin/responder", handlers.GetResponder)
+
+	// Script endpoints
+	get("/scripts", handlers.ListScripts)
+	get("/scripts/{name}", handlers.GetScript)
+	put("/scripts/{name}", handlers.PutScript)
+	del("/scripts/{name}", handlers.DeleteScript)
+
+	// Replication endpoint (standby side)
+	post("/replica/ingest", handlers.ReplicaIngest)
+
+	// Backup and restore endpoints
+	post("/admin/backup", handlers.Backup)
+	post("/admin/restore", handlers.Restore)
+
+	// API key endpoints
+	get("/admin/keys", handlers.ListAPIKeys)
+	post("/admin/keys", handlers.CreateAPIKey)
+	post("/admin/keys/rotate", handlers.RotateAPIKey)
+
+	// Retention endpoints
+	get("/admin/retention", handlers.GetRetention)
+	post("/admin/retention/dry-run", handlers.RetentionDryRun)
 
 	// Doctor/diagnostics endpoint
-	mux.HandleFunc("/doctor", methodHandler(http.MethodGet, handlers.Doctor))
+	get("/doctor", handlers.Doctor)
+
+	// Prometheus metrics
+	get("/metrics", handlers.Metrics)
+
+	// Recent events, for clients that poll instead of taking webhooks
+	get("/events/recent", handlers.RecentEvents)
 
 	// Apply middleware
 	handler := ChainMiddleware(
@@ -90,10 +267,6 @@ func NewServer(cfg service.Config, mgr *service.Manager) *Server {
 		LoggingMiddleware,
 		RecoveryMiddleware,
 		CORSMiddleware,
-		ContentTypeMiddleware,
-		func(next http.Handler) http.Handler {
-			return APIKeyMiddleware(cfg.APIKey, next)
-		},
 	)
 
 	server := &http.Server{
@@ -131,193 +304,3 @@ func (s *Server) Shutdown(ctx context.Context) error {
 func (s *Server) Addr() string {
 	return s.config.Addr()
 }
-
-// methodHandler restricts an endpoint to a specific HTTP method.
-func methodHandler(method string, handler http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-		if r.Method != method {
-			writeError(w, http.StatusMethodNotAllowed, "method not allowed", "METHOD_NOT_ALLOWED")
-			return
-		}
-		handler(w, r)
-	}
-}
-
-// chatMessagesHandler handles /chats/{jid}/messages routes.
-func chatMessagesHandler(h *Handlers) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		path := strings.TrimPrefix(r.URL.Path, "/chats/")
-		if strings.Contains(path, "/messages") {
-			if r.Method != http.MethodGet {
-				writeError(w, http.StatusMethodNotAllowed, "method not allowed", "METHOD_NOT_ALLOWED")
-				return
-			}
-			h.ListMessages(w, r)
-			return
-		}
-		writeError(w, http.StatusNotFound, "endpoint not found", "NOT_FOUND")
-	}
-}
-
-// contactsHandler handles /contacts/{jid}/* routes.
-func contactsHandler(h *Handlers) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		path := strings.TrimPrefix(r.URL.Path, "/contacts/")
-		parts := strings.Split(path, "/")
-
-		// /contacts/{jid}
-		if len(parts) == 1 && parts[0] != "" {
-			if r.Method != http.MethodGet {
-				writeError(w, http.StatusMethodNotAllowed, "method not allowed", "METHOD_NOT_ALLOWED")
-				return
-			}
-			h.GetContact(w, r)
-			return
-		}
-
-		// /contacts/{jid}/alias
-		if len(parts) >= 2 && parts[1] == "alias" {
-			switch r.Method {
-			case http.MethodPut:
-				h.SetContactAlias(w, r)
-			case http.MethodDelete:
-				h.DeleteContactAlias(w, r)
-			default:
-				writeError(w, http.StatusMethodNotAllowed, "method not allowed", "METHOD_NOT_ALLOWED")
-			}
-			return
-		}
-
-		// /contacts/{jid}/tags or /contacts/{jid}/tags/{tag}
-		if len(parts) >= 2 && parts[1] == "tags" {
-			switch r.Method {
-			case http.MethodPost:
-				h.AddContactTag(w, r)
-			case http.MethodDelete:
-				h.DeleteContactTag(w, r)
-			default:
-				writeError(w, http.StatusMethodNotAllowed, "method not allowed", "METHOD_NOT_ALLOWED")
-			}
-			return
-		}
-
-		writeError(w, http.StatusNotFound, "endpoint not found", "NOT_FOUND")
-	}
-}
-
-// groupsHandler handles /groups/{jid}/* routes.
-func groupsHandler(h *Handlers) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		path := strings.TrimPrefix(r.URL.Path, "/groups/")
-		parts := strings.Split(path, "/")
-
-		// /groups/{jid}
-		if len(parts) == 1 && parts[0] != "" {
-			if r.Method != http.MethodGet {
-				writeError(w, http.StatusMethodNotAllowed, "method not allowed", "METHOD_NOT_ALLOWED")
-				return
-			}
-			h.GetGroupInfo(w, r)
-			return
-		}
-
-		// /groups/{jid}/name
-		if len(parts) >= 2 && parts[1] == "name" {
-			if r.Method != http.MethodPut {
-				writeError(w, http.StatusMethodNotAllowed, "method not allowed", "METHOD_NOT_ALLOWED")
-				return
-			}
-			h.RenameGroup(w, r)
-			return
-		}
-
-		// /groups/{jid}/participants
-		if len(parts) >= 2 && parts[1] == "participants" {
-			if r.Method != http.MethodPost {
-				writeError(w, http.StatusMethodNotAllowed, "method not allowed", "METHOD_NOT_ALLOWED")
-				return
-			}
-			h.UpdateGroupParticipants(w, r)
-			return
-		}
-
-		// /groups/{jid}/invite or /groups/{jid}/invite/revoke
-		if len(parts) >= 2 && parts[1] == "invite" {
-			if len(parts) >= 3 && parts[2] == "revoke" {
-				if r.Method != http.MethodPost {
-					writeError(w, http.StatusMethodNotAllowed, "method not allowed", "METHOD_NOT_ALLOWED")
-					return
-				}
-				h.RevokeGroupInviteLink(w, r)
-				return
-			}
-			if r.Method != http.MethodGet {
-				writeError(w, http.StatusMethodNotAllowed, "method not allowed", "METHOD_NOT_ALLOWED")
-				return
-			}
-			h.GetGroupInviteLink(w, r)
-			return
-		}
-
-		// /groups/{jid}/leave
-		if len(parts) >= 2 && parts[1] == "leave" {
-			if r.Method != http.MethodPost {
-				writeError(w, http.StatusMethodNotAllowed, "method not allowed", "METHOD_NOT_ALLOWED")
-				return
-			}
-			h.LeaveGroup(w, r)
-			return
-		}
-
-		writeError(w, http.StatusNotFound, "endpoint not found", "NOT_FOUND")
-	}
-}
-
-// mediaHandler handles /media/{chat_jid}/{msg_id}/* routes.
-func mediaHandler(h *Handlers) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		path := strings.TrimPrefix(r.URL.Path, "/media/")
-		parts := strings.Split(path, "/")
-
-		// /media/{chat_jid}/{msg_id}/download
-		if len(parts) >= 3 && parts[2] == "download" {
-			if r.Method != http.MethodPost {
-				writeError(w, http.StatusMethodNotAllowed, "method not allowed", "METHOD_NOT_ALLOWED")
-				return
-			}
-			h.DownloadMedia(w, r)
-			return
-		}
-
-		// /media/{chat_jid}/{msg_id} - GET media info or serve file
-		if len(parts) >= 2 {
-			if r.Method != http.MethodGet {
-				writeError(w, http.StatusMethodNotAllowed, "method not allowed", "METHOD_NOT_ALLOWED")
-				return
-			}
-			h.GetMedia(w, r)
-			return
-		}
-
-		writeError(w, http.StatusNotFound, "endpoint not found", "NOT_FOUND")
-	}
-}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// StarMessage handles PUT (star) and DELETE (unstar) /chats/{jid}/messages/{id}/star
+func (h *Handlers) StarMessage(w http.ResponseWriter, r *http.Request) {
+	chatJID, ok := h.chatParam(w, r, "jid")
+	if !ok {
+		return
+	}
+	msgID := r.PathValue("id")
+	starred := r.Method == http.MethodPut
+
+	if err := h.manager.StarMessage(r.Context(), chatJID, msgID, starred); err != nil {
+		writeFailure(w, err, "STAR_FAILED")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":  true,
+		"chat_jid": chatJID,
+		"msg_id":   msgID,
+		"starred":  starred,
+	})
+}
+
+// ListStarredMessages handles GET /messages/starred
+func (h *Handlers) ListStarredMessages(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	p := store.ListStarredParams{ChatJID: q.Get("chat"), Limit: 50}
+	if l := q.Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 {
+			p.Limit = n
+		}
+	}
+	if p.Limit > 200 {
+		p.Limit = 200
+	}
+	if v := q.Get("before"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeFieldError(w, "before", "before must be RFC3339", "INVALID_BEFORE")
+			return
+		}
+		p.Before = &t
+	}
+
+	messages, err := h.manager.ListStarredMessages(p)
+	if err != nil {
+		writeFailure(w, err, "LIST_MESSAGES_FAILED")
+		return
+	}
+
+	resp := MessagesResponse{
+		ChatJID:  p.ChatJID,
+		Count:    len(messages),
+		Messages: make([]MessageResponse, len(messages)),
+	}
+	for i, m := range messages {
+		resp.Messages[i] = messageToResponse(m)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
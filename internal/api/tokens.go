@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/service"
+	"github.com/steipete/wacli/internal/store"
+)
+
+// CreateChatToken handles POST /tokens
+func (h *Handlers) CreateChatToken(w http.ResponseWriter, r *http.Request) {
+	var req CreateChatTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if strings.TrimSpace(req.ChatJID) == "" {
+		writeFieldError(w, "chat_jid", "chat_jid is required", "MISSING_CHAT_JID")
+		return
+	}
+	if req.TTLSeconds < 0 {
+		writeFieldError(w, "ttl_seconds", "ttl_seconds must not be negative", "INVALID_TTL")
+		return
+	}
+
+	token, info, err := h.manager.MintChatToken(req.ChatJID, req.Label, time.Duration(req.TTLSeconds)*time.Second)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidJID) {
+			writeFieldError(w, "chat_jid", err.Error(), "INVALID_JID")
+			return
+		}
+		writeFailure(w, err, "CREATE_TOKEN_FAILED")
+		return
+	}
+
+	resp := chatTokenToResponse(info)
+	resp.Token = token
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// ListChatTokens handles GET /tokens
+func (h *Handlers) ListChatTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.manager.ListChatTokens(r.URL.Query().Get("chat_jid"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidJID) {
+			writeFieldError(w, "chat_jid", err.Error(), "INVALID_JID")
+			return
+		}
+		writeFailure(w, err, "LIST_TOKENS_FAILED")
+		return
+	}
+
+	resp := ChatTokensResponse{
+		Count:  len(tokens),
+		Tokens: make([]ChatTokenResponse, len(tokens)),
+	}
+	for i, t := range tokens {
+		resp.Tokens[i] = chatTokenToResponse(t)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// RevokeChatToken handles DELETE /tokens/{id}
+func (h *Handlers) RevokeChatToken(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt64(r, "id")
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid token id", "INVALID_ID")
+		return
+	}
+
+	if err := h.manager.RevokeChatToken(id); err != nil {
+		writeFailure(w, err, "REVOKE_TOKEN_FAILED")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"id":      id,
+	})
+}
+
+func chatTokenToResponse(t store.ChatToken) ChatTokenResponse {
+	resp := ChatTokenResponse{
+		ID:        t.ID,
+		ChatJID:   t.ChatJID,
+		Label:     t.Label,
+		CreatedAt: t.CreatedAt,
+	}
+	if !t.ExpiresAt.IsZero() {
+		exp := t.ExpiresAt
+		resp.ExpiresAt = &exp
+	}
+	return resp
+}
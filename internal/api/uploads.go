@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/steipete/wacli/internal/service"
+)
+
+// CreateUpload handles POST /uploads
+func (h *Handlers) CreateUpload(w http.ResponseWriter, r *http.Request) {
+	var req CreateUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	u, err := h.manager.CreateUpload(req.Filename, req.MimeType, req.Size)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidUploadSize):
+			writeFieldError(w, "size", err.Error(), "INVALID_SIZE")
+			return
+		case errors.Is(err, service.ErrFileTooLarge), errors.Is(err, service.ErrMediaTypeNotAllowed):
+			writeSendError(w, err)
+			return
+		}
+		writeFailure(w, err, "UPLOAD_FAILED")
+		return
+	}
+	writeJSON(w, http.StatusCreated, uploadToResponse(u))
+}
+
+// GetUpload handles GET /uploads/{id}
+func (h *Handlers) GetUpload(w http.ResponseWriter, r *http.Request) {
+	u, err := h.manager.GetUpload(r.PathValue("id"))
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, uploadToResponse(u))
+}
+
+// PutUploadChunk handles PUT /uploads/{id} with a Content-Range header such
+// as "bytes 0-1048575/5242880".
+func (h *Handlers) PutUploadChunk(w http.ResponseWriter, r *http.Request) {
+	start, end, total, err := parseContentRange(r.Header.Get("Content-Range"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error(), "INVALID_RANGE")
+		return
+	}
+	length := end - start + 1
+	if r.ContentLength >= 0 && r.ContentLength != length {
+		writeError(w, http.StatusBadRequest, "Content-Length does not match Content-Range", "INVALID_RANGE")
+		return
+	}
+
+	// Large chunks on slow links can outlast the server timeouts.
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	u, err := h.manager.WriteUploadChunk(r.PathValue("id"), start, length, total, r.Body)
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, uploadToResponse(u))
+}
+
+// DeleteUpload handles DELETE /uploads/{id}
+func (h *Handlers) DeleteUpload(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.manager.DeleteUpload(id); err != nil {
+		writeUploadError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":   true,
+		"upload_id": id,
+	})
+}
+
+// parseContentRange parses "bytes <start>-<end>/<total>".
+func parseContentRange(s string) (start, end, total int64, err error) {
+	if s == "" {
+		return 0, 0, 0, fmt.Errorf("Content-Range header is required")
+	}
+	if _, err := fmt.Sscanf(s, "bytes %d-%d/%d", &start, &end, &total); err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid Content-Range %q: want bytes <start>-<end>/<total>", s)
+	}
+	if start < 0 || end < start || total <= end {
+		return 0, 0, 0, fmt.Errorf("invalid Content-Range %q", s)
+	}
+	return start, end, total, nil
+}
+
+func uploadToResponse(u *service.UploadSession) UploadResponse {
+	return UploadResponse{
+		UploadID:  u.ID,
+		Filename:  u.Filename,
+		MimeType:  u.MimeType,
+		Size:      u.Size,
+		Received:  u.Received,
+		Complete:  u.Complete(),
+		ExpiresAt: u.ExpiresAt,
+	}
+}
+
+func writeUploadError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrOffsetMismatch):
+		writeError(w, http.StatusConflict, err.Error(), "UPLOAD_OFFSET_MISMATCH")
+	case errors.Is(err, service.ErrUploadBusy):
+		writeError(w, http.StatusConflict, err.Error(), "UPLOAD_BUSY")
+	case errors.Is(err, service.ErrInvalidRange):
+		writeError(w, http.StatusRequestedRangeNotSatisfiable, err.Error(), "INVALID_RANGE")
+	case errors.Is(err, service.ErrUploadIncomplete):
+		writeError(w, http.StatusBadRequest, err.Error(), "UPLOAD_INCOMPLETE")
+	default:
+		writeFailure(w, err, "UPLOAD_FAILED")
+	}
+}
@@ -198,6 +198,59 @@ const authPageHTML = `<!DOCTYPE html>
             transform: none;
         }
 
+        .btn-secondary {
+            background: white;
+            color: #075e54;
+            border: 1px solid #cbd5e1;
+        }
+
+        .btn-secondary:hover {
+            background: #f8fafc;
+        }
+
+        #phone-link {
+            margin-top: 16px;
+        }
+
+        .phone-toggle {
+            background: none;
+            border: none;
+            color: #128c7e;
+            font-size: 14px;
+            cursor: pointer;
+            text-decoration: underline;
+        }
+
+        #phone-form {
+            display: none;
+            margin-top: 12px;
+        }
+
+        .phone-input {
+            width: 100%;
+            padding: 12px 14px;
+            font-size: 16px;
+            border: 1px solid #cbd5e1;
+            border-radius: 8px;
+            margin-bottom: 12px;
+        }
+
+        #pair-container {
+            background: #f8fafc;
+            border-radius: 12px;
+            padding: 24px;
+            margin-bottom: 24px;
+            display: none;
+        }
+
+        .pair-code {
+            font-family: ui-monospace, SFMono-Regular, Menlo, monospace;
+            font-size: 32px;
+            font-weight: 600;
+            letter-spacing: 4px;
+            color: #1a1a1a;
+        }
+
         .success-container {
             display: none;
         }
@@ -295,6 +348,13 @@ const authPageHTML = `<!DOCTYPE html>
                 </p>
             </div>
 
+            <div id="pair-container">
+                <div id="pair-code" class="pair-code"></div>
+                <p class="qr-instructions">
+                    Open WhatsApp on your phone → Settings → Linked Devices → Link a Device → Link with phone number instead → Enter this code
+                </p>
+            </div>
+
             <div id="steps" class="steps">
                 <div class="step">
                     <span class="step-number">1</span>
@@ -313,6 +373,14 @@ const authPageHTML = `<!DOCTYPE html>
             <button id="link-btn" class="btn" onclick="startAuth()">
                 Generate QR Code
             </button>
+
+            <div id="phone-link">
+                <button class="phone-toggle" onclick="togglePhoneForm()">Link with phone number instead</button>
+                <div id="phone-form">
+                    <input id="phone-input" class="phone-input" type="tel" placeholder="+1 555 010 9999" autocomplete="tel">
+                    <button id="pair-btn" class="btn btn-secondary" onclick="startPairCode()">Get Pairing Code</button>
+                </div>
+            </div>
         </div>
 
         <div id="success-content" class="success-container">
@@ -355,6 +423,8 @@ const authPageHTML = `<!DOCTYPE html>
             const mainContent = document.getElementById('main-content');
             const successContent = document.getElementById('success-content');
             const steps = document.getElementById('steps');
+            const pairContainer = document.getElementById('pair-container');
+            const phoneLink = document.getElementById('phone-link');
 
             // Remove all status classes
             badge.className = 'status-badge';
@@ -366,11 +436,21 @@ const authPageHTML = `<!DOCTYPE html>
                 mainContent.style.display = 'none';
                 successContent.style.display = 'block';
                 stopPolling();
+            } else if (status.pair_code) {
+                badge.classList.add('pairing');
+                statusText.textContent = 'Waiting for code entry...';
+                btn.style.display = 'none';
+                steps.style.display = 'none';
+                phoneLink.style.display = 'none';
+                qrContainer.style.display = 'none';
+                pairContainer.style.display = 'block';
+                document.getElementById('pair-code').textContent = formatPairCode(status.pair_code);
             } else if (status.state === 'pairing' || status.has_qr) {
                 badge.classList.add('pairing');
                 statusText.textContent = 'Waiting for scan...';
                 btn.style.display = 'none';
                 steps.style.display = 'none';
+                phoneLink.style.display = 'none';
                 qrContainer.style.display = 'block';
 
                 // Keep fetching QR code (it refreshes every ~20 seconds)
@@ -382,8 +462,11 @@ const authPageHTML = `<!DOCTYPE html>
                 statusText.textContent = 'Connecting...';
                 btn.disabled = true;
                 btn.textContent = 'Connecting...';
-                qrContainer.style.display = 'block';
-                showQRLoading();
+                phoneLink.style.display = 'none';
+                if (pairContainer.style.display !== 'block') {
+                    qrContainer.style.display = 'block';
+                    showQRLoading();
+                }
             } else {
                 badge.classList.add('disconnected');
                 statusText.textContent = 'Not Connected';
@@ -391,7 +474,9 @@ const authPageHTML = `<!DOCTYPE html>
                 btn.textContent = 'Generate QR Code';
                 btn.style.display = 'block';
                 steps.style.display = 'block';
+                phoneLink.style.display = 'block';
                 qrContainer.style.display = 'none';
+                pairContainer.style.display = 'none';
                 qrDisplayed = false;
                 qrFetchAttempts = 0;
             }
@@ -471,6 +556,7 @@ const authPageHTML = `<!DOCTYPE html>
             // Show QR container with loading state immediately
             qrContainer.style.display = 'block';
             steps.style.display = 'none';
+            document.getElementById('phone-link').style.display = 'none';
             showQRLoading();
 
             try {
@@ -492,10 +578,59 @@ const authPageHTML = `<!DOCTYPE html>
                 btn.textContent = 'Generate QR Code';
                 btn.style.display = 'block';
                 steps.style.display = 'block';
+                document.getElementById('phone-link').style.display = 'block';
                 qrContainer.style.display = 'none';
             }
         }
 
+        function togglePhoneForm() {
+            const form = document.getElementById('phone-form');
+            form.style.display = form.style.display === 'block' ? 'none' : 'block';
+            if (form.style.display === 'block') {
+                document.getElementById('phone-input').focus();
+            }
+        }
+
+        function formatPairCode(code) {
+            // WhatsApp shows codes as XXXX-XXXX
+            return code.length === 8 ? code.slice(0, 4) + '-' + code.slice(4) : code;
+        }
+
+        async function startPairCode() {
+            const pairBtn = document.getElementById('pair-btn');
+            const phone = document.getElementById('phone-input').value.trim();
+            if (!phone) {
+                showError('Enter your phone number including the country code.');
+                return;
+            }
+
+            pairBtn.disabled = true;
+            pairBtn.textContent = 'Requesting code...';
+            hideError();
+
+            try {
+                const response = await fetch('/auth/pair-code', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ phone: phone })
+                });
+                const data = await response.json();
+
+                if (!response.ok) {
+                    throw new Error(data.error || 'Failed to request pairing code');
+                }
+
+                updateUI({ state: data.state, pair_code: data.pair_code });
+                startPolling();
+            } catch (error) {
+                console.error('Pair code request failed:', error);
+                showError(error.message);
+            } finally {
+                pairBtn.disabled = false;
+                pairBtn.textContent = 'Get Pairing Code';
+            }
+        }
+
         function startPolling() {
             if (pollInterval) return;
 
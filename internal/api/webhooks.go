@@ -0,0 +1,172 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/steipete/wacli/internal/service"
+	"github.com/steipete/wacli/internal/webhook"
+)
+
+// ListWebhookDeadLetters handles GET /webhooks/deadletter
+func (h *Handlers) ListWebhookDeadLetters(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	dls, err := h.manager.ListWebhookDeadLetters(limit)
+	if err != nil {
+		writeFailure(w, err, "LIST_DEADLETTER_FAILED")
+		return
+	}
+
+	resp := WebhookDeadLettersResponse{
+		Count:       len(dls),
+		DeadLetters: make([]WebhookDeadLetterResponse, len(dls)),
+	}
+	for i, dl := range dls {
+		resp.DeadLetters[i] = WebhookDeadLetterResponse{
+			ID:          dl.ID,
+			EndpointURL: dl.EndpointURL,
+			EventType:   dl.EventType,
+			Attempts:    dl.Attempts,
+			LastError:   dl.LastError,
+			CreatedAt:   dl.CreatedAt,
+			FailedAt:    dl.FailedAt,
+			Event:       json.RawMessage(dl.Payload),
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ReplayWebhookDeadLetter handles POST /webhooks/deadletter/{id}/replay
+func (h *Handlers) ReplayWebhookDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt64(r, "id")
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid dead letter id", "INVALID_ID")
+		return
+	}
+
+	queueID, err := h.manager.ReplayWebhookDeadLetter(id)
+	if err != nil {
+		writeFailure(w, err, "REPLAY_FAILED")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ReplayWebhookResponse{
+		Success: true,
+		ID:      id,
+		QueueID: queueID,
+	})
+}
+
+// ListWebhooks handles GET /webhooks
+func (h *Handlers) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks := h.manager.ListWebhooks()
+	resp := WebhooksResponse{Count: len(hooks), Webhooks: make([]WebhookResponse, len(hooks))}
+	for i, wh := range hooks {
+		resp.Webhooks[i] = webhookToResponse(wh)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// WebhookStats handles GET /webhooks/stats
+func (h *Handlers) WebhookStats(w http.ResponseWriter, r *http.Request) {
+	// Without webhooks there is nothing to report, which is not an error.
+	st, _ := h.manager.WebhookStats()
+
+	resp := WebhookStatsResponse{
+		QueueDepth: st.QueueDepth,
+		Endpoints:  make([]WebhookEndpointStatsResponse, len(st.Endpoints)),
+	}
+	for i, ep := range st.Endpoints {
+		counters := WebhookCountersResponse{
+			Enqueued:     ep.Enqueued,
+			Delivered:    ep.Delivered,
+			Retried:      ep.Retried,
+			Failed:       ep.Failed,
+			Dropped:      ep.Dropped,
+			DeadLettered: ep.DeadLettered,
+		}
+		resp.Endpoints[i] = WebhookEndpointStatsResponse{
+			URL:                     ep.URL,
+			WebhookCountersResponse: counters,
+			Latency: WebhookLatencyResponse{
+				Count: ep.Latency.Count,
+				AvgMs: ep.Latency.Mean().Milliseconds(),
+				P50Ms: ep.Latency.Quantile(0.5).Milliseconds(),
+				P95Ms: ep.Latency.Quantile(0.95).Milliseconds(),
+				P99Ms: ep.Latency.Quantile(0.99).Milliseconds(),
+				Slow:  ep.Latency.Counts[len(ep.Latency.Counts)-1],
+			},
+		}
+		resp.Totals.Enqueued += counters.Enqueued
+		resp.Totals.Delivered += counters.Delivered
+		resp.Totals.Retried += counters.Retried
+		resp.Totals.Failed += counters.Failed
+		resp.Totals.Dropped += counters.Dropped
+		resp.Totals.DeadLettered += counters.DeadLettered
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// SetWebhookChatFilter handles PUT /webhooks/chat-filter
+func (h *Handlers) SetWebhookChatFilter(w http.ResponseWriter, r *http.Request) {
+	var req WebhookChatFilterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	h.updateWebhookChatFilter(w, req.URL, &webhook.ChatFilter{Chats: req.Chats, ExcludeChats: req.ExcludeChats})
+}
+
+// ResetWebhookChatFilter handles DELETE /webhooks/chat-filter?url=...
+func (h *Handlers) ResetWebhookChatFilter(w http.ResponseWriter, r *http.Request) {
+	h.updateWebhookChatFilter(w, r.URL.Query().Get("url"), nil)
+}
+
+func (h *Handlers) updateWebhookChatFilter(w http.ResponseWriter, url string, f *webhook.ChatFilter) {
+	url = strings.TrimSpace(url)
+	if url == "" {
+		writeFieldError(w, "url", "url is required", "MISSING_URL")
+		return
+	}
+	if err := h.manager.SetWebhookChatFilter(url, f); err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotFound), errors.Is(err, webhook.ErrUnknownEndpoint):
+			writeError(w, http.StatusNotFound, err.Error(), "NOT_FOUND")
+		case errors.Is(err, webhook.ErrInvalidChatFilter):
+			writeFieldError(w, "chats", err.Error(), "INVALID_CHAT_FILTER")
+		default:
+			writeFailure(w, err, "SET_CHAT_FILTER_FAILED")
+		}
+		return
+	}
+	for _, wh := range h.manager.ListWebhooks() {
+		if wh.URL == url {
+			writeJSON(w, http.StatusOK, webhookToResponse(wh))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "webhook endpoint not found", "NOT_FOUND")
+}
+
+func webhookToResponse(wh service.WebhookInfo) WebhookResponse {
+	return WebhookResponse{
+		URL:           wh.URL,
+		Events:        wh.Events,
+		Chats:         wh.Chats,
+		ExcludeChats:  wh.ExcludeChats,
+		RuntimeFilter: wh.RuntimeFilter,
+	}
+}
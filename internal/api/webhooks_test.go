@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/steipete/wacli/internal/service"
+)
+
+func TestReplayWebhookDeadLetter(t *testing.T) {
+	cfg := service.DefaultConfig()
+	cfg.DataDir = t.TempDir()
+	mgr, err := service.NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	// Without a session the connection attempt ends in unauthenticated; wait
+	// for it so Stop does not close the app underneath it.
+	settled := make(chan struct{})
+	var once sync.Once
+	mgr.State().OnStateChange(func(old, state service.State) {
+		if old == service.StateConnecting {
+			once.Do(func() { close(settled) })
+		}
+	})
+	if err := mgr.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() {
+		<-settled
+		_ = mgr.Stop()
+	})
+	db := mgr.App().DB()
+
+	queueID, err := db.EnqueueWebhookEvent("http://hook.example", "message.received", []byte(`{}`))
+	if err != nil {
+		t.Fatalf("EnqueueWebhookEvent: %v", err)
+	}
+	if err := db.DeadLetterWebhookEvent(queueID, 4, "status 500"); err != nil {
+		t.Fatalf("DeadLetterWebhookEvent: %v", err)
+	}
+	dead, err := db.ListWebhookDeadLetters(10)
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("dead letters = %v (%v), want one", dead, err)
+	}
+
+	h := NewHandlers(mgr)
+	replay := func(id string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/webhooks/deadletter/"+id+"/replay", nil)
+		r.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		h.ReplayWebhookDeadLetter(w, r)
+		return w
+	}
+
+	w := replay(strconv.FormatInt(dead[0].ID, 10))
+	if w.Code != http.StatusOK {
+		t.Fatalf("replay: status %d, want 200; body %s", w.Code, w.Body)
+	}
+	var resp ReplayWebhookResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if dead, _ := db.ListWebhookDeadLetters(10); len(dead) != 0 {
+		t.Fatalf("dead letter still listed after replay: %+v", dead)
+	}
+	pending, err := db.PendingWebhookEvents(10)
+	if err != nil || len(pending) != 1 || pending[0].ID != resp.QueueID || pending[0].EndpointURL != "http://hook.example" {
+		t.Fatalf("pending = %+v (%v), want the replayed event as %d", pending, err, resp.QueueID)
+	}
+
+	if w := replay(strconv.FormatInt(dead[0].ID, 10)); w.Code != http.StatusNotFound {
+		t.Fatalf("second replay: status %d, want 404", w.Code)
+	}
+	if w := replay("x"); w.Code != http.StatusBadRequest {
+		t.Fatalf("bad id: status %d, want 400", w.Code)
+	}
+}
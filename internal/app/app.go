@@ -10,6 +10,7 @@ import (
 	"github.com/steipete/wacli/internal/store"
 	"github.com/steipete/wacli/internal/wa"
 	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/appstate"
 	waProto "go.mau.fi/whatsmeow/binary/proto"
 	"go.mau.fi/whatsmeow/types"
 )
@@ -27,19 +28,47 @@ type WAClient interface {
 	ResolveChatName(ctx context.Context, chat types.JID, pushName string) string
 	GetContact(ctx context.Context, jid types.JID) (types.ContactInfo, error)
 	GetAllContacts(ctx context.Context) (map[types.JID]types.ContactInfo, error)
+	LIDMappings(ctx context.Context) (map[types.JID]types.JID, error)
+	IsOnWhatsApp(ctx context.Context, phones []string) ([]types.IsOnWhatsAppResponse, error)
+	GetProfilePictureInfo(ctx context.Context, jid types.JID, params *whatsmeow.GetProfilePictureParams) (*types.ProfilePictureInfo, error)
+	Self() (types.JID, string)
+	GetUserInfo(ctx context.Context, jids []types.JID) (map[types.JID]types.UserInfo, error)
+	SetAbout(ctx context.Context, about string) error
+	SetProfilePhoto(ctx context.Context, jpeg []byte) (string, error)
 
 	GetJoinedGroups(ctx context.Context) ([]*types.GroupInfo, error)
 	GetGroupInfo(ctx context.Context, jid types.JID) (*types.GroupInfo, error)
 	SetGroupName(ctx context.Context, jid types.JID, name string) error
+	SetGroupTopic(ctx context.Context, jid types.JID, topic string) error
+	GetSubGroups(ctx context.Context, community types.JID) ([]*types.GroupLinkTarget, error)
+	SetGroupAnnounce(ctx context.Context, jid types.JID, announce bool) error
+	SetGroupLocked(ctx context.Context, jid types.JID, locked bool) error
+	CreateGroup(ctx context.Context, name string, participants []types.JID) (*types.GroupInfo, error)
 	UpdateGroupParticipants(ctx context.Context, group types.JID, users []types.JID, action wa.GroupParticipantAction) ([]types.GroupParticipant, error)
 	GetGroupInviteLink(ctx context.Context, group types.JID, reset bool) (string, error)
+	GetBlocklist(ctx context.Context) ([]types.JID, error)
+	RejectCall(ctx context.Context, from types.JID, callID string) error
+	UpdateBlocklist(ctx context.Context, jid types.JID, block bool) ([]types.JID, error)
+	GetGroupInfoFromLink(ctx context.Context, code string) (*types.GroupInfo, error)
 	JoinGroupWithLink(ctx context.Context, code string) (types.JID, error)
 	LeaveGroup(ctx context.Context, group types.JID) error
 
+	GetSubscribedNewsletters(ctx context.Context) ([]*types.NewsletterMetadata, error)
+	GetNewsletterInfo(ctx context.Context, jid types.JID) (*types.NewsletterMetadata, error)
+	FollowNewsletter(ctx context.Context, jid types.JID) error
+	UnfollowNewsletter(ctx context.Context, jid types.JID) error
+	GetNewsletterMessages(ctx context.Context, jid types.JID, count int, before types.MessageServerID) ([]*types.NewsletterMessage, error)
+
 	SendText(ctx context.Context, to types.JID, text string) (types.MessageID, error)
 	SendProtoMessage(ctx context.Context, to types.JID, msg *waProto.Message) (types.MessageID, error)
 	Upload(ctx context.Context, data []byte, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error)
+	UploadFile(ctx context.Context, path string, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error)
 	DownloadMediaToFile(ctx context.Context, directPath string, encFileHash, fileHash, mediaKey []byte, fileLength uint64, mediaType, mmsType string, targetPath string) (int64, error)
+	DownloadMediaToTemp(ctx context.Context, directPath string, encFileHash, fileHash, mediaKey []byte, fileLength uint64, mediaType, mmsType string) (*wa.TempMedia, error)
+
+	SendAppState(ctx context.Context, patch appstate.PatchInfo) error
+	SendPresence(ctx context.Context, state types.Presence) error
+	SendChatPresence(ctx context.Context, jid types.JID, state types.ChatPresence, media types.ChatPresenceMedia) error
 
 	RequestHistorySyncOnDemand(ctx context.Context, lastKnown types.MessageInfo, count int) (types.MessageID, error)
 	Logout(ctx context.Context) error
@@ -47,6 +76,8 @@ type WAClient interface {
 
 type Options struct {
 	StoreDir      string
+	DBURL         string // Postgres URL for the message index; empty uses wacli.db in StoreDir
+	SQLite        store.SQLiteOptions
 	Version       string
 	JSON          bool
 	AllowUnauthed bool
@@ -55,7 +86,7 @@ type Options struct {
 type App struct {
 	opts Options
 	wa   WAClient
-	db   *store.DB
+	db   store.Store
 }
 
 func New(opts Options) (*App, error) {
@@ -66,9 +97,13 @@ func New(opts Options) (*App, error) {
 		return nil, fmt.Errorf("create store dir: %w", err)
 	}
 
-	indexPath := filepath.Join(opts.StoreDir, "wacli.db")
-
-	db, err := store.Open(indexPath)
+	var db store.Store
+	var err error
+	if opts.DBURL != "" {
+		db, err = store.OpenPostgres(opts.DBURL)
+	} else {
+		db, err = store.OpenWithOptions(filepath.Join(opts.StoreDir, "wacli.db"), opts.SQLite)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -112,7 +147,7 @@ func (a *App) EnsureAuthed() error {
 }
 
 func (a *App) WA() WAClient        { return a.wa }
-func (a *App) DB() *store.DB       { return a.db }
+func (a *App) DB() store.Store     { return a.db }
 func (a *App) StoreDir() string    { return a.opts.StoreDir }
 func (a *App) Version() string     { return a.opts.Version }
 func (a *App) AllowUnauthed() bool { return a.opts.AllowUnauthed }
@@ -126,3 +161,17 @@ func (a *App) Connect(ctx context.Context, allowQR bool, qrWriter func(string))
 		OnQRCode: qrWriter,
 	})
 }
+
+// ConnectPairPhone links a new device by phone number: the pairing code for
+// phone is passed to onCode, to be entered on the phone, and the call returns
+// once pairing has completed or failed.
+func (a *App) ConnectPairPhone(ctx context.Context, phone string, onCode func(string)) error {
+	if err := a.OpenWA(); err != nil {
+		return err
+	}
+	return a.wa.Connect(ctx, wa.ConnectOptions{
+		AllowQR:    true,
+		PairPhone:  phone,
+		OnPairCode: onCode,
+	})
+}
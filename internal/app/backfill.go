@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -14,6 +15,10 @@ import (
 	"go.mau.fi/whatsmeow/types/events"
 )
 
+// ErrNoLocalMessages is returned when a chat has no stored message to
+// backfill from.
+var ErrNoLocalMessages = errors.New("no messages in local DB")
+
 type BackfillOptions struct {
 	ChatJID        string
 	Count          int
@@ -116,7 +121,7 @@ func (a *App) BackfillHistory(ctx context.Context, opts BackfillOptions) (Backfi
 				oldest, err := a.db.GetOldestMessageInfo(chatStr)
 				if err != nil {
 					if err == sql.ErrNoRows {
-						return fmt.Errorf("no messages for %s in local DB; run `wacli sync` first", chatStr)
+						return fmt.Errorf("%w for %s; run `wacli sync` first", ErrNoLocalMessages, chatStr)
 					}
 					return err
 				}
@@ -40,6 +40,9 @@ func (a *App) refreshGroups(ctx context.Context) error {
 			continue
 		}
 		_ = a.db.UpsertGroup(g.JID.String(), g.GroupName.Name, g.OwnerJID.String(), g.GroupCreated)
+		_ = a.db.SetGroupDescription(g.JID.String(), g.Topic)
+		_ = a.db.SetGroupCommunity(g.JID.String(), g.IsParent)
+		_ = a.db.LinkGroup(g.JID.String(), CommunityJID(g), g.IsDefaultSubGroup)
 		_ = a.db.UpsertChat(g.JID.String(), "group", g.GroupName.Name, now)
 	}
 	return nil
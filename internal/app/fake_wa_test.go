@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/steipete/wacli/internal/wa"
 	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/appstate"
 	waProto "go.mau.fi/whatsmeow/binary/proto"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
@@ -29,7 +31,14 @@ type fakeWA struct {
 	contacts map[types.JID]types.ContactInfo
 	groups   map[types.JID]*types.GroupInfo
 
+	groupInfoCalls int
+
 	onDemandHistory func(lastKnown types.MessageInfo, count int) *events.HistorySync
+
+	appStatePatches []appstate.PatchInfo
+	presence        types.Presence
+	newsletters     map[types.JID]*types.NewsletterMetadata // followed channels
+	lids            map[types.JID]types.JID                 // LID -> phone number
 }
 
 func newFakeWA() *fakeWA {
@@ -125,6 +134,36 @@ func (f *fakeWA) GetContact(ctx context.Context, jid types.JID) (types.ContactIn
 	return types.ContactInfo{Found: false}, nil
 }
 
+func (f *fakeWA) IsOnWhatsApp(ctx context.Context, phones []string) ([]types.IsOnWhatsAppResponse, error) {
+	out := make([]types.IsOnWhatsAppResponse, len(phones))
+	for i, p := range phones {
+		out[i] = types.IsOnWhatsAppResponse{Query: p, JID: types.NewJID(strings.TrimPrefix(p, "+"), types.DefaultUserServer), IsIn: true}
+	}
+	return out, nil
+}
+
+func (f *fakeWA) GetProfilePictureInfo(ctx context.Context, jid types.JID, params *whatsmeow.GetProfilePictureParams) (*types.ProfilePictureInfo, error) {
+	return nil, whatsmeow.ErrProfilePictureNotSet
+}
+
+func (f *fakeWA) Self() (types.JID, string) {
+	return types.NewJID("15550000000", types.DefaultUserServer), "Me"
+}
+
+func (f *fakeWA) GetUserInfo(ctx context.Context, jids []types.JID) (map[types.JID]types.UserInfo, error) {
+	out := make(map[types.JID]types.UserInfo, len(jids))
+	for _, jid := range jids {
+		out[jid] = types.UserInfo{}
+	}
+	return out, nil
+}
+
+func (f *fakeWA) SetAbout(ctx context.Context, about string) error { return nil }
+
+func (f *fakeWA) SetProfilePhoto(ctx context.Context, jpeg []byte) (string, error) {
+	return "1", nil
+}
+
 func (f *fakeWA) GetAllContacts(ctx context.Context) (map[types.JID]types.ContactInfo, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -148,6 +187,7 @@ func (f *fakeWA) GetJoinedGroups(ctx context.Context) ([]*types.GroupInfo, error
 func (f *fakeWA) GetGroupInfo(ctx context.Context, jid types.JID) (*types.GroupInfo, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
+	f.groupInfoCalls++
 	return f.groups[jid], nil
 }
 
@@ -163,6 +203,64 @@ func (f *fakeWA) SetGroupName(ctx context.Context, jid types.JID, name string) e
 	return nil
 }
 
+func (f *fakeWA) GetSubGroups(ctx context.Context, community types.JID) ([]*types.GroupLinkTarget, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []*types.GroupLinkTarget
+	for _, g := range f.groups {
+		if g.LinkedParentJID == community {
+			out = append(out, &types.GroupLinkTarget{JID: g.JID, GroupName: g.GroupName, GroupIsDefaultSub: g.GroupIsDefaultSub})
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeWA) SetGroupAnnounce(ctx context.Context, jid types.JID, announce bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	g := f.groups[jid]
+	if g == nil {
+		return fmt.Errorf("group not found")
+	}
+	g.IsAnnounce = announce
+	return nil
+}
+
+func (f *fakeWA) SetGroupLocked(ctx context.Context, jid types.JID, locked bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	g := f.groups[jid]
+	if g == nil {
+		return fmt.Errorf("group not found")
+	}
+	g.IsLocked = locked
+	return nil
+}
+
+func (f *fakeWA) SetGroupTopic(ctx context.Context, jid types.JID, topic string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	g := f.groups[jid]
+	if g == nil {
+		g = &types.GroupInfo{JID: jid}
+		f.groups[jid] = g
+	}
+	g.Topic = topic
+	return nil
+}
+
+func (f *fakeWA) CreateGroup(ctx context.Context, name string, participants []types.JID) (*types.GroupInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	jid := types.NewJID(fmt.Sprintf("1203630000%d", len(f.groups)), types.GroupServer)
+	g := &types.GroupInfo{JID: jid, GroupName: types.GroupName{Name: name}, GroupCreated: time.Now()}
+	for _, p := range participants {
+		g.Participants = append(g.Participants, types.GroupParticipant{JID: p})
+	}
+	f.groups[jid] = g
+	return g, nil
+}
+
 func (f *fakeWA) UpdateGroupParticipants(ctx context.Context, group types.JID, users []types.JID, action wa.GroupParticipantAction) ([]types.GroupParticipant, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -198,6 +296,34 @@ func (f *fakeWA) GetGroupInviteLink(ctx context.Context, group types.JID, reset
 	return "https://chat.whatsapp.com/invite/test", nil
 }
 
+func (f *fakeWA) LIDMappings(ctx context.Context) (map[types.JID]types.JID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make(map[types.JID]types.JID, len(f.lids))
+	for lid, pn := range f.lids {
+		out[lid] = pn
+	}
+	return out, nil
+}
+
+func (f *fakeWA) GetBlocklist(ctx context.Context) ([]types.JID, error) {
+	return nil, nil
+}
+
+func (f *fakeWA) UpdateBlocklist(ctx context.Context, jid types.JID, block bool) ([]types.JID, error) {
+	if !block {
+		return nil, nil
+	}
+	return []types.JID{jid}, nil
+}
+
+func (f *fakeWA) RejectCall(ctx context.Context, from types.JID, callID string) error { return nil }
+
+func (f *fakeWA) GetGroupInfoFromLink(ctx context.Context, code string) (*types.GroupInfo, error) {
+	jid, _ := types.ParseJID("12345@g.us")
+	return &types.GroupInfo{JID: jid, GroupName: types.GroupName{Name: "Invited"}}, nil
+}
+
 func (f *fakeWA) JoinGroupWithLink(ctx context.Context, code string) (types.JID, error) {
 	return types.ParseJID("12345@g.us")
 }
@@ -216,6 +342,10 @@ func (f *fakeWA) Upload(ctx context.Context, data []byte, mediaType whatsmeow.Me
 	return whatsmeow.UploadResponse{}, nil
 }
 
+func (f *fakeWA) UploadFile(ctx context.Context, path string, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
+	return whatsmeow.UploadResponse{}, nil
+}
+
 func (f *fakeWA) DownloadMediaToFile(ctx context.Context, directPath string, encFileHash, fileHash, mediaKey []byte, fileLength uint64, mediaType, mmsType string, targetPath string) (int64, error) {
 	if err := os.MkdirAll(filepath.Dir(targetPath), 0o700); err != nil {
 		return 0, err
@@ -230,6 +360,78 @@ func (f *fakeWA) DownloadMediaToFile(ctx context.Context, directPath string, enc
 	return st.Size(), nil
 }
 
+func (f *fakeWA) DownloadMediaToTemp(ctx context.Context, directPath string, encFileHash, fileHash, mediaKey []byte, fileLength uint64, mediaType, mmsType string) (*wa.TempMedia, error) {
+	tmp, err := os.CreateTemp("", "fake-media-*")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmp.WriteString("test"); err != nil {
+		return nil, err
+	}
+	if _, err := tmp.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	return &wa.TempMedia{File: tmp}, nil
+}
+
+func (f *fakeWA) SendAppState(ctx context.Context, patch appstate.PatchInfo) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.appStatePatches = append(f.appStatePatches, patch)
+	return nil
+}
+
+func (f *fakeWA) SendPresence(ctx context.Context, state types.Presence) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.presence = state
+	return nil
+}
+
+func (f *fakeWA) SendChatPresence(ctx context.Context, jid types.JID, state types.ChatPresence, media types.ChatPresenceMedia) error {
+	return nil
+}
+
+func (f *fakeWA) GetSubscribedNewsletters(ctx context.Context) ([]*types.NewsletterMetadata, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []*types.NewsletterMetadata
+	for _, n := range f.newsletters {
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+func (f *fakeWA) GetNewsletterInfo(ctx context.Context, jid types.JID) (*types.NewsletterMetadata, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if n := f.newsletters[jid]; n != nil {
+		return n, nil
+	}
+	return &types.NewsletterMetadata{ID: jid}, nil
+}
+
+func (f *fakeWA) FollowNewsletter(ctx context.Context, jid types.JID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.newsletters == nil {
+		f.newsletters = make(map[types.JID]*types.NewsletterMetadata)
+	}
+	f.newsletters[jid] = &types.NewsletterMetadata{ID: jid}
+	return nil
+}
+
+func (f *fakeWA) UnfollowNewsletter(ctx context.Context, jid types.JID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.newsletters, jid)
+	return nil
+}
+
+func (f *fakeWA) GetNewsletterMessages(ctx context.Context, jid types.JID, count int, before types.MessageServerID) ([]*types.NewsletterMessage, error) {
+	return nil, nil
+}
+
 func (f *fakeWA) RequestHistorySyncOnDemand(ctx context.Context, lastKnown types.MessageInfo, count int) (types.MessageID, error) {
 	f.mu.Lock()
 	cb := f.onDemandHistory
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// SyncLIDMappings copies every LID mapping whatsmeow knows into db and
+// returns how many there were.
+func SyncLIDMappings(ctx context.Context, w WAClient, db store.Store) (int, error) {
+	m, err := w.LIDMappings(ctx)
+	if err != nil {
+		return 0, err
+	}
+	ms := make([]store.LIDMapping, 0, len(m))
+	for lid, pn := range m {
+		ms = append(ms, store.LIDMapping{LID: lid.String(), PN: pn.String()})
+	}
+	return len(ms), db.PutLIDMappings(ms)
+}
+
+// SenderLIDMapping returns the mapping between the sender's two identities
+// when the message carries both.
+func SenderLIDMapping(pm wa.ParsedMessage) []store.LIDMapping {
+	if pm.SenderAlt == "" || pm.SenderJID == "" {
+		return nil
+	}
+	lm := store.LIDMapping{LID: pm.SenderJID, PN: pm.SenderAlt}
+	if !store.IsLID(lm.LID) {
+		lm.LID, lm.PN = lm.PN, lm.LID
+	}
+	if !store.IsLID(lm.LID) {
+		return nil
+	}
+	return []store.LIDMapping{lm}
+}
+
+// HistoryLIDMappings returns the LID mappings a history sync carries, both
+// the explicit list and those of its conversations.
+func HistoryLIDMappings(data *waHistorySync.HistorySync) []store.LIDMapping {
+	var ms []store.LIDMapping
+	add := func(lid, pn string) {
+		l, err1 := types.ParseJID(lid)
+		p, err2 := types.ParseJID(pn)
+		if err1 != nil || err2 != nil || l.Server != types.HiddenUserServer || p.Server != types.DefaultUserServer {
+			return
+		}
+		ms = append(ms, store.LIDMapping{LID: l.ToNonAD().String(), PN: p.ToNonAD().String()})
+	}
+	for _, m := range data.GetPhoneNumberToLidMappings() {
+		add(m.GetLidJID(), m.GetPnJID())
+	}
+	for _, conv := range data.GetConversations() {
+		if conv.GetLidJID() != "" && conv.GetPnJID() != "" {
+			add(conv.GetLidJID(), conv.GetPnJID())
+		}
+	}
+	return ms
+}
+
+// GroupLIDMappings returns the mappings of group participants listed with
+// both identities.
+func GroupLIDMappings(g *types.GroupInfo) []store.LIDMapping {
+	if g == nil {
+		return nil
+	}
+	var ms []store.LIDMapping
+	for _, p := range g.Participants {
+		if !p.LID.IsEmpty() && !p.PhoneNumber.IsEmpty() {
+			ms = append(ms, store.LIDMapping{LID: p.LID.ToNonAD().String(), PN: p.PhoneNumber.ToNonAD().String()})
+		}
+	}
+	return ms
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/steipete/wacli/internal/wa"
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSyncLIDMappings(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	f.lids = map[types.JID]types.JID{
+		types.NewJID("987", types.HiddenUserServer): types.NewJID("111", types.DefaultUserServer),
+	}
+	a.wa = f
+
+	n, err := SyncLIDMappings(context.Background(), a.wa, a.db)
+	if err != nil || n != 1 {
+		t.Fatalf("SyncLIDMappings = %d, %v", n, err)
+	}
+	lm, err := a.db.LookupLID("987@lid")
+	if err != nil || lm.PN != "111@s.whatsapp.net" {
+		t.Fatalf("LookupLID = %+v, %v", lm, err)
+	}
+}
+
+func TestSenderAndHistoryLIDMappings(t *testing.T) {
+	pm := wa.ParsedMessage{SenderJID: "111@s.whatsapp.net", SenderAlt: "987@lid"}
+	ms := SenderLIDMapping(pm)
+	if len(ms) != 1 || ms[0].LID != "987@lid" || ms[0].PN != "111@s.whatsapp.net" {
+		t.Fatalf("SenderLIDMapping = %+v", ms)
+	}
+	if ms := SenderLIDMapping(wa.ParsedMessage{SenderJID: "111@s.whatsapp.net"}); ms != nil {
+		t.Fatalf("expected no mapping without alt, got %+v", ms)
+	}
+
+	data := &waHistorySync.HistorySync{
+		PhoneNumberToLidMappings: []*waHistorySync.PhoneNumberToLIDMapping{
+			{PnJID: proto.String("111@s.whatsapp.net"), LidJID: proto.String("987@lid")},
+			{PnJID: proto.String("bogus"), LidJID: proto.String("986@lid")},
+		},
+		Conversations: []*waHistorySync.Conversation{
+			{ID: proto.String("987@lid"), LidJID: proto.String("987@lid"), PnJID: proto.String("111@s.whatsapp.net")},
+			{ID: proto.String("123@g.us")},
+		},
+	}
+	if ms := HistoryLIDMappings(data); len(ms) != 2 || ms[1].LID != "987@lid" {
+		t.Fatalf("HistoryLIDMappings = %+v", ms)
+	}
+}
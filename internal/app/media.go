@@ -21,7 +21,7 @@ type mediaJob struct {
 }
 
 func (a *App) ResolveMediaOutputPath(info store.MediaDownloadInfo, requested string) (string, error) {
-	filename := mediaFilename(info)
+	filename := MediaFilename(info)
 
 	if strings.TrimSpace(requested) != "" {
 		out := requested
@@ -49,7 +49,9 @@ func (a *App) ResolveMediaOutputPath(info store.MediaDownloadInfo, requested str
 	return filepath.Join(baseDir, filename), nil
 }
 
-func mediaFilename(info store.MediaDownloadInfo) string {
+// MediaFilename names a media file after its original name or message ID,
+// with an extension matching its MIME type.
+func MediaFilename(info store.MediaDownloadInfo) string {
 	name := strings.TrimSpace(info.Filename)
 	ext := ""
 	if strings.TrimSpace(info.MimeType) != "" {
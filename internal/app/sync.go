@@ -27,6 +27,7 @@ type SyncOptions struct {
 	AllowQR         bool
 	OnQRCode        func(string)
 	AfterConnect    func(context.Context) error
+	OnMessage       func(wa.ParsedMessage) // called for each live message once stored; not for history
 	DownloadMedia   bool
 	RefreshContacts bool
 	RefreshGroups   bool
@@ -91,19 +92,23 @@ func (a *App) Sync(ctx context.Context, opts SyncOptions) (SyncResult, error) {
 			if opts.DownloadMedia && pm.Media != nil && pm.ID != "" {
 				enqueueMedia(pm.Chat.String(), pm.ID)
 			}
+			if opts.OnMessage != nil {
+				opts.OnMessage(pm)
+			}
 			if messagesStored.Load()%25 == 0 {
 				fmt.Fprintf(os.Stderr, "\rSynced %d messages...", messagesStored.Load())
 			}
 		case *events.HistorySync:
 			fmt.Fprintf(os.Stderr, "\nProcessing history sync (%d conversations)...\n", len(v.Data.Conversations))
+			_ = a.db.PutLIDMappings(HistoryLIDMappings(v.Data))
 			for _, conv := range v.Data.Conversations {
 				lastEvent.Store(time.Now().UTC().UnixNano())
 				chatID := strings.TrimSpace(conv.GetID())
 				if chatID == "" {
 					continue
 				}
+				var pms []wa.ParsedMessage
 				for _, m := range conv.Messages {
-					lastEvent.Store(time.Now().UTC().UnixNano())
 					if m.Message == nil {
 						continue
 					}
@@ -111,11 +116,19 @@ func (a *App) Sync(ctx context.Context, opts SyncOptions) (SyncResult, error) {
 					if pm.ID == "" || pm.Chat.IsEmpty() {
 						continue
 					}
-					if err := a.storeParsedMessage(ctx, pm); err == nil {
-						messagesStored.Add(1)
-					}
-					if opts.DownloadMedia && pm.Media != nil && pm.ID != "" {
-						enqueueMedia(pm.Chat.String(), pm.ID)
+					pms = append(pms, pm)
+				}
+				n, err := a.storeHistoryMessages(ctx, pms)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "\nstoring history of %s: %v\n", chatID, err)
+				}
+				messagesStored.Add(int64(n))
+				lastEvent.Store(time.Now().UTC().UnixNano())
+				if opts.DownloadMedia {
+					for _, pm := range pms {
+						if pm.Media != nil {
+							enqueueMedia(pm.Chat.String(), pm.ID)
+						}
 					}
 				}
 			}
@@ -152,6 +165,7 @@ func (a *App) Sync(ctx context.Context, opts SyncOptions) (SyncResult, error) {
 	if opts.RefreshGroups {
 		_ = a.refreshGroups(ctx)
 	}
+	_, _ = SyncLIDMappings(ctx, a.wa, a.db)
 	if opts.AfterConnect != nil {
 		if err := opts.AfterConnect(ctx); err != nil {
 			return SyncResult{MessagesStored: messagesStored.Load()}, err
@@ -207,25 +221,151 @@ func chatKind(chat types.JID) string {
 	if chat.IsBroadcastList() {
 		return "broadcast"
 	}
+	if chat.Server == types.NewsletterServer {
+		return "channel"
+	}
 	if chat.Server == types.DefaultUserServer {
 		return "dm"
 	}
 	return "unknown"
 }
 
+// CommunityJID returns the community a group is linked to, or "".
+func CommunityJID(g *types.GroupInfo) string {
+	if g.LinkedParentJID.IsEmpty() {
+		return ""
+	}
+	return g.LinkedParentJID.String()
+}
+
+// NewChannelPost converts a parsed channel message for the channel_posts
+// table; serverID is 0 when unknown.
+func NewChannelPost(pm wa.ParsedMessage, serverID int64) store.ChannelPost {
+	post := store.ChannelPost{
+		ChannelJID: pm.Chat.String(),
+		MsgID:      pm.ID,
+		ServerID:   serverID,
+		Timestamp:  pm.Timestamp,
+		Text:       pm.Text,
+	}
+	if pm.Media != nil {
+		post.MediaType = pm.Media.Type
+		post.MediaCaption = pm.Media.Caption
+	}
+	return post
+}
+
 func (a *App) storeParsedMessage(ctx context.Context, pm wa.ParsedMessage) error {
+	// Channel posts are kept apart from chat messages.
+	if pm.Chat.Server == types.NewsletterServer {
+		return a.db.UpsertChannelPost(NewChannelPost(pm, 0))
+	}
+
+	if pm.IsChange() {
+		_, err := ApplyMessageChange(a.db, pm)
+		return err
+	}
+
+	_ = a.db.PutLIDMappings(SenderLIDMapping(pm))
 	chatJID := pm.Chat.String()
 	chatName := a.wa.ResolveChatName(ctx, pm.Chat, pm.PushName)
 	if err := a.db.UpsertChat(chatJID, chatKind(pm.Chat), chatName, pm.Timestamp); err != nil {
 		return err
 	}
+	a.storeChatDetails(ctx, pm.Chat)
+	return a.db.UpsertMessage(messageParams(pm, chatName, a.senderName(ctx, pm, nil)))
+}
+
+// historyNames caches name lookups while storing a history sync chunk, so
+// each chat and sender is looked up once rather than once per message.
+type historyNames struct {
+	chats   map[string]string // chat JID + push name -> chat name
+	senders map[string]string // sender JID -> contact name
+	details map[string]bool   // chats whose details were stored
+}
+
+// storeHistoryMessages stores the messages of a history sync conversation in
+// one transaction and returns how many were stored.
+func (a *App) storeHistoryMessages(ctx context.Context, pms []wa.ParsedMessage) (int, error) {
+	names := historyNames{chats: map[string]string{}, senders: map[string]string{}, details: map[string]bool{}}
+	chats := make(map[string]store.UpsertChatParams)
+	var msgs []store.UpsertMessageParams
+	var changes []wa.ParsedMessage
+	posts := 0
+	for _, pm := range pms {
+		if pm.Chat.Server == types.NewsletterServer {
+			if err := a.db.UpsertChannelPost(NewChannelPost(pm, 0)); err == nil {
+				posts++
+			}
+			continue
+		}
+		if pm.IsChange() {
+			changes = append(changes, pm)
+			continue
+		}
+
+		chatJID := pm.Chat.String()
+		key := chatJID + "\x00" + pm.PushName
+		chatName, ok := names.chats[key]
+		if !ok {
+			chatName = a.wa.ResolveChatName(ctx, pm.Chat, pm.PushName)
+			names.chats[key] = chatName
+		}
+		if !names.details[chatJID] {
+			names.details[chatJID] = true
+			a.storeChatDetails(ctx, pm.Chat)
+		}
+
+		c := chats[chatJID]
+		c.JID, c.Kind = chatJID, chatKind(pm.Chat)
+		if chatName != "" {
+			c.Name = chatName
+		}
+		if pm.Timestamp.After(c.LastMessageTS) {
+			c.LastMessageTS = pm.Timestamp
+		}
+		chats[chatJID] = c
+		msgs = append(msgs, messageParams(pm, chatName, a.senderName(ctx, pm, names.senders)))
+	}
+	n := 0
+	if len(msgs) > 0 {
+		rows := make([]store.UpsertChatParams, 0, len(chats))
+		for _, c := range chats {
+			rows = append(rows, c)
+		}
+		var err error
+		if n, err = a.db.UpsertMessages(rows, msgs); err != nil {
+			return posts + n, err
+		}
+	}
+	// Edits and revokes apply once the messages they target are stored.
+	for _, pm := range changes {
+		_, _ = ApplyMessageChange(a.db, pm)
+	}
+	return posts + n, nil
+}
 
+// ApplyMessageChange applies an edit or revoke to the stored message it
+// targets. It reports false if that message is not stored.
+func ApplyMessageChange(db store.Store, pm wa.ParsedMessage) (bool, error) {
+	switch {
+	case pm.RevokeOf != "":
+		return db.RevokeMessage(pm.Chat.String(), pm.RevokeOf)
+	case pm.EditOf != "":
+		return db.EditMessage(pm.Chat.String(), pm.EditOf, pm.Text, pm.Timestamp)
+	}
+	return false, nil
+}
+
+// storeChatDetails stores best-effort contact details for a direct chat, or
+// group metadata and participants for a group.
+func (a *App) storeChatDetails(ctx context.Context, chat types.JID) {
 	// Best-effort: store contact info for DMs.
-	if pm.Chat.Server == types.DefaultUserServer {
-		if info, err := a.wa.GetContact(ctx, pm.Chat.ToNonAD()); err == nil {
+	if chat.Server == types.DefaultUserServer {
+		if info, err := a.wa.GetContact(ctx, chat.ToNonAD()); err == nil {
 			_ = a.db.UpsertContact(
-				pm.Chat.String(),
-				pm.Chat.User,
+				chat.String(),
+				chat.User,
 				info.PushName,
 				info.FullName,
 				info.FirstName,
@@ -234,18 +374,51 @@ func (a *App) storeParsedMessage(ctx context.Context, pm wa.ParsedMessage) error
 		}
 	}
 
+	// Best-effort: store group metadata (and participants) when available.
+	if chat.Server == types.GroupServer {
+		if gi, err := a.wa.GetGroupInfo(ctx, chat); err == nil && gi != nil {
+			_ = a.db.UpsertGroup(gi.JID.String(), gi.GroupName.Name, gi.OwnerJID.String(), gi.GroupCreated)
+			_ = a.db.SetGroupDescription(gi.JID.String(), gi.Topic)
+			_ = a.db.SetGroupCommunity(gi.JID.String(), gi.IsParent)
+			_ = a.db.LinkGroup(gi.JID.String(), CommunityJID(gi), gi.IsDefaultSubGroup)
+			var ps []store.GroupParticipant
+			for _, p := range gi.Participants {
+				role := "member"
+				if p.IsSuperAdmin {
+					role = "superadmin"
+				} else if p.IsAdmin {
+					role = "admin"
+				}
+				ps = append(ps, store.GroupParticipant{
+					GroupJID: chat.String(),
+					UserJID:  p.JID.String(),
+					Role:     role,
+				})
+			}
+			_ = a.db.ReplaceGroupParticipants(chat.String(), ps)
+			_ = a.db.PutLIDMappings(GroupLIDMappings(gi))
+		}
+	}
+}
+
+// senderName returns the name to store for a message's sender, storing the
+// sender's contact details along the way. cache, if set, remembers contact
+// names across calls.
+func (a *App) senderName(ctx context.Context, pm wa.ParsedMessage, cache map[string]string) string {
 	senderName := ""
 	if pm.FromMe {
 		senderName = "me"
 	} else if s := strings.TrimSpace(pm.PushName); s != "" && s != "-" {
 		senderName = s
 	}
-	if pm.SenderJID != "" {
+	if pm.SenderJID == "" {
+		return senderName
+	}
+	name, ok := cache[pm.SenderJID]
+	if !ok {
 		if jid, err := types.ParseJID(pm.SenderJID); err == nil {
 			if info, err := a.wa.GetContact(ctx, jid.ToNonAD()); err == nil {
-				if name := wa.BestContactName(info); name != "" {
-					senderName = name
-				}
+				name = wa.BestContactName(info)
 				_ = a.db.UpsertContact(
 					jid.String(),
 					jid.User,
@@ -256,62 +429,41 @@ func (a *App) storeParsedMessage(ctx context.Context, pm wa.ParsedMessage) error
 				)
 			}
 		}
-	}
-
-	// Best-effort: store group metadata (and participants) when available.
-	if pm.Chat.Server == types.GroupServer {
-		if gi, err := a.wa.GetGroupInfo(ctx, pm.Chat); err == nil && gi != nil {
-			_ = a.db.UpsertGroup(gi.JID.String(), gi.GroupName.Name, gi.OwnerJID.String(), gi.GroupCreated)
-			var ps []store.GroupParticipant
-			for _, p := range gi.Participants {
-				role := "member"
-				if p.IsSuperAdmin {
-					role = "superadmin"
-				} else if p.IsAdmin {
-					role = "admin"
-				}
-				ps = append(ps, store.GroupParticipant{
-					GroupJID: pm.Chat.String(),
-					UserJID:  p.JID.String(),
-					Role:     role,
-				})
-			}
-			_ = a.db.ReplaceGroupParticipants(pm.Chat.String(), ps)
+		if cache != nil {
+			cache[pm.SenderJID] = name
 		}
 	}
+	if name != "" {
+		return name
+	}
+	return senderName
+}
 
-	var mediaType, caption, filename, mimeType, directPath string
-	var mediaKey, fileSha, fileEncSha []byte
-	var fileLen uint64
+// messageParams maps a parsed message to its store row.
+func messageParams(pm wa.ParsedMessage, chatName, senderName string) store.UpsertMessageParams {
+	p := store.UpsertMessageParams{
+		ChatJID:    pm.Chat.String(),
+		ChatName:   chatName,
+		MsgID:      pm.ID,
+		SenderJID:  pm.SenderJID,
+		SenderName: senderName,
+		Timestamp:  pm.Timestamp,
+		FromMe:     pm.FromMe,
+		Text:       pm.Text,
+	}
+	p.ReplyToID = pm.ReplyToID
+	p.ReplyToSender = pm.ReplyToSender
 	if pm.Media != nil {
-		mediaType = pm.Media.Type
-		caption = pm.Media.Caption
-		filename = pm.Media.Filename
-		mimeType = pm.Media.MimeType
-		directPath = pm.Media.DirectPath
-		mediaKey = pm.Media.MediaKey
-		fileSha = pm.Media.FileSHA256
-		fileEncSha = pm.Media.FileEncSHA256
-		fileLen = pm.Media.FileLength
-	}
-
-	return a.db.UpsertMessage(store.UpsertMessageParams{
-		ChatJID:       chatJID,
-		ChatName:      chatName,
-		MsgID:         pm.ID,
-		SenderJID:     pm.SenderJID,
-		SenderName:    senderName,
-		Timestamp:     pm.Timestamp,
-		FromMe:        pm.FromMe,
-		Text:          pm.Text,
-		MediaType:     mediaType,
-		MediaCaption:  caption,
-		Filename:      filename,
-		MimeType:      mimeType,
-		DirectPath:    directPath,
-		MediaKey:      mediaKey,
-		FileSHA256:    fileSha,
-		FileEncSHA256: fileEncSha,
-		FileLength:    fileLen,
-	})
+		p.MediaType = pm.Media.Type
+		p.MediaCaption = pm.Media.Caption
+		p.Filename = pm.Media.Filename
+		p.MimeType = pm.Media.MimeType
+		p.DirectPath = pm.Media.DirectPath
+		p.MediaKey = pm.Media.MediaKey
+		p.FileSHA256 = pm.Media.FileSHA256
+		p.FileEncSHA256 = pm.Media.FileEncSHA256
+		p.FileLength = pm.Media.FileLength
+		p.Duration = pm.Media.Seconds
+	}
+	return p
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/steipete/wacli/internal/wa"
 	waProto "go.mau.fi/whatsmeow/binary/proto"
 	"go.mau.fi/whatsmeow/proto/waCommon"
 	"go.mau.fi/whatsmeow/proto/waHistorySync"
@@ -70,13 +71,18 @@ func TestSyncStoresLiveAndHistoryMessages(t *testing.T) {
 		time.Sleep(100 * time.Millisecond)
 		cancel()
 	}()
+	var seen []string
 	res, err := a.Sync(ctx, SyncOptions{
-		Mode:    SyncModeFollow,
-		AllowQR: false,
+		Mode:      SyncModeFollow,
+		AllowQR:   false,
+		OnMessage: func(pm wa.ParsedMessage) { seen = append(seen, pm.ID) },
 	})
 	if err != nil {
 		t.Fatalf("Sync: %v", err)
 	}
+	if len(seen) != 1 || seen[0] != "m-live" {
+		t.Fatalf("expected OnMessage for the live message only, got %v", seen)
+	}
 	if res.MessagesStored != 2 {
 		t.Fatalf("expected 2 MessagesStored, got %d", res.MessagesStored)
 	}
@@ -106,3 +112,70 @@ func TestSyncOnceIdleExit(t *testing.T) {
 		t.Fatalf("expected to exit quickly on idle, took %s", time.Since(start))
 	}
 }
+
+func TestStoreHistoryMessagesLooksUpEachChatOnce(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+
+	group := types.JID{User: "12345", Server: types.GroupServer}
+	f.groups[group] = &types.GroupInfo{JID: group, GroupName: types.GroupName{Name: "Team"}}
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var pms []wa.ParsedMessage
+	for i, id := range []string{"h1", "h2", "h3"} {
+		pms = append(pms, wa.ParsedMessage{
+			Chat:      group,
+			ID:        id,
+			SenderJID: "111@s.whatsapp.net",
+			Timestamp: base.Add(time.Duration(i) * time.Minute),
+			Text:      "msg " + id,
+		})
+	}
+
+	n, err := a.storeHistoryMessages(context.Background(), pms)
+	if err != nil || n != 3 {
+		t.Fatalf("storeHistoryMessages = %d, %v", n, err)
+	}
+	// One lookup to name the chat, one to store its details; not one per message.
+	if f.groupInfoCalls != 2 {
+		t.Fatalf("GetGroupInfo called %d times, want 2", f.groupInfoCalls)
+	}
+	c, err := a.db.GetChat(group.String())
+	if err != nil || c.Name != "Team" || !c.LastMessageTS.Equal(base.Add(2*time.Minute)) {
+		t.Fatalf("GetChat = %+v, %v", c, err)
+	}
+	if got, _ := a.db.CountMessages(); got != 3 {
+		t.Fatalf("CountMessages = %d", got)
+	}
+}
+
+func TestStoreHistoryMessagesAppliesEditsAndRevokes(t *testing.T) {
+	a := newTestApp(t)
+	a.wa = newFakeWA()
+
+	chat := types.JID{User: "111", Server: types.DefaultUserServer}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	pms := []wa.ParsedMessage{
+		{Chat: chat, ID: "e1", Timestamp: base.Add(2 * time.Minute), EditOf: "m1", Text: "edited"},
+		{Chat: chat, ID: "m1", Timestamp: base, Text: "original"},
+		{Chat: chat, ID: "m2", Timestamp: base.Add(time.Minute), Text: "oops"},
+		{Chat: chat, ID: "r1", Timestamp: base.Add(3 * time.Minute), RevokeOf: "m2"},
+	}
+	n, err := a.storeHistoryMessages(context.Background(), pms)
+	if err != nil || n != 2 {
+		t.Fatalf("storeHistoryMessages = %d, %v", n, err)
+	}
+
+	m1, err := a.db.GetMessage(chat.String(), "m1")
+	if err != nil || m1.Text != "original" || m1.EditedText != "edited" {
+		t.Fatalf("m1 = %+v, %v", m1, err)
+	}
+	m2, err := a.db.GetMessage(chat.String(), "m2")
+	if err != nil || !m2.Revoked {
+		t.Fatalf("m2 = %+v, %v", m2, err)
+	}
+	if _, err := a.db.GetMessage(chat.String(), "e1"); err == nil {
+		t.Fatalf("edit stored as a message of its own")
+	}
+}
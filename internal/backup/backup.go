@@ -0,0 +1,388 @@
+// Package backup writes and restores snapshots of a wasvc data directory as
+// gzipped tarballs.
+//
+// An archive holds SQLite snapshots of wacli.db and session.db (taken with
+// VACUUM INTO, so they are consistent while the service keeps running), the
+// downloaded media files, and a trailing manifest.json with the size and
+// SHA-256 of every file. Restores are staged next to the live data and only
+// swapped in by ApplyPending while the data directory is locked and no
+// database is open.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+const (
+	manifestName    = "manifest.json"
+	manifestVersion = 1
+	mediaDir        = "media"
+
+	// PendingDir holds a verified restore until ApplyPending swaps it in.
+	PendingDir = "restore-pending"
+)
+
+// databases are snapshotted into every archive, if present.
+var databases = []string{"wacli.db", "session.db"}
+
+// FileEntry describes one file of a backup.
+type FileEntry struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest describes the contents of a backup archive.
+type Manifest struct {
+	Version       int         `json:"version"`
+	CreatedAt     time.Time   `json:"created_at"`
+	MediaIncluded bool        `json:"media_included"`
+	Databases     []FileEntry `json:"databases"`
+	Media         []FileEntry `json:"media"` // listed even when media files are not included
+}
+
+// MediaBytes returns the total size of the listed media.
+func (m Manifest) MediaBytes() int64 {
+	var n int64
+	for _, f := range m.Media {
+		n += f.Size
+	}
+	return n
+}
+
+// Write snapshots dataDir and streams it to w as a .tar.gz. The database
+// snapshots are taken before anything is written, so an error returned
+// without output can still be reported to the caller.
+func Write(w io.Writer, dataDir string, includeMedia bool) (Manifest, error) {
+	m := Manifest{
+		Version:       manifestVersion,
+		CreatedAt:     time.Now().UTC(),
+		MediaIncluded: includeMedia,
+	}
+
+	tmp, err := os.MkdirTemp(dataDir, ".backup-")
+	if err != nil {
+		return m, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	var snapshots []string
+	for _, name := range databases {
+		src := filepath.Join(dataDir, name)
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			continue
+		}
+		dst := filepath.Join(tmp, name)
+		if err := store.SnapshotSQLite(src, dst); err != nil {
+			return m, fmt.Errorf("snapshot %s: %w", name, err)
+		}
+		snapshots = append(snapshots, name)
+	}
+	if len(snapshots) == 0 {
+		return m, fmt.Errorf("no databases found in %s", dataDir)
+	}
+
+	media, err := listMedia(dataDir)
+	if err != nil {
+		return m, fmt.Errorf("list media: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	for _, name := range snapshots {
+		entry, err := addFile(tw, filepath.Join(tmp, name), name)
+		if err != nil {
+			return m, err
+		}
+		m.Databases = append(m.Databases, entry)
+	}
+	for _, rel := range media {
+		full := filepath.Join(dataDir, filepath.FromSlash(rel))
+		var entry FileEntry
+		if includeMedia {
+			entry, err = addFile(tw, full, rel)
+		} else {
+			entry, err = hashFile(full, rel)
+		}
+		if err != nil {
+			return m, err
+		}
+		m.Media = append(m.Media, entry)
+	}
+
+	raw, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return m, err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: manifestName, Mode: 0600, Size: int64(len(raw)), ModTime: m.CreatedAt}); err != nil {
+		return m, err
+	}
+	if _, err := tw.Write(raw); err != nil {
+		return m, err
+	}
+	if err := tw.Close(); err != nil {
+		return m, err
+	}
+	return m, gz.Close()
+}
+
+// listMedia returns the slash-separated paths of all completed media files.
+func listMedia(dataDir string) ([]string, error) {
+	root := filepath.Join(dataDir, mediaDir)
+	var out []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == root {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if !d.Type().IsRegular() || wa.IsTempDownload(d.Name()) || wa.IsPartialDownload(d.Name()) {
+			return nil
+		}
+		rel, err := filepath.Rel(dataDir, p)
+		if err != nil {
+			return err
+		}
+		out = append(out, filepath.ToSlash(rel))
+		return nil
+	})
+	return out, err
+}
+
+// addFile copies a file into the archive, hashing it on the way.
+func addFile(tw *tar.Writer, full, name string) (FileEntry, error) {
+	f, err := os.Open(full)
+	if err != nil {
+		return FileEntry{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return FileEntry{}, err
+	}
+
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+		return FileEntry{}, err
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tw, h), f)
+	if err != nil {
+		return FileEntry{}, fmt.Errorf("archive %s: %w", name, err)
+	}
+	return FileEntry{Path: name, Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+func hashFile(full, name string) (FileEntry, error) {
+	f, err := os.Open(full)
+	if err != nil {
+		return FileEntry{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return FileEntry{}, err
+	}
+	return FileEntry{Path: name, Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// Stage extracts an archive into dataDir/restore-pending after checking every
+// file against the manifest. Live data is not touched; ApplyPending swaps the
+// staged files in on the next start.
+func Stage(r io.Reader, dataDir string) (Manifest, error) {
+	var m Manifest
+
+	tmp, err := os.MkdirTemp(dataDir, ".restore-")
+	if err != nil {
+		return m, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return m, fmt.Errorf("not a gzip archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	got := make(map[string]FileEntry)
+	var rawManifest []byte
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return m, fmt.Errorf("read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return m, fmt.Errorf("unexpected entry %q in archive", hdr.Name)
+		}
+		if hdr.Name == manifestName {
+			if rawManifest, err = io.ReadAll(io.LimitReader(tr, 64<<20)); err != nil {
+				return m, err
+			}
+			continue
+		}
+		if !allowedPath(hdr.Name) {
+			return m, fmt.Errorf("unexpected entry %q in archive", hdr.Name)
+		}
+
+		dst := filepath.Join(tmp, filepath.FromSlash(hdr.Name))
+		if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+			return m, err
+		}
+		f, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err != nil {
+			return m, err
+		}
+		h := sha256.New()
+		n, err := io.Copy(io.MultiWriter(f, h), tr)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return m, fmt.Errorf("extract %s: %w", hdr.Name, err)
+		}
+		got[hdr.Name] = FileEntry{Path: hdr.Name, Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}
+	}
+
+	if rawManifest == nil {
+		return m, fmt.Errorf("archive has no %s", manifestName)
+	}
+	if err := json.Unmarshal(rawManifest, &m); err != nil {
+		return m, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if m.Version != manifestVersion {
+		return m, fmt.Errorf("unsupported backup version %d", m.Version)
+	}
+	if err := verify(m, got); err != nil {
+		return m, err
+	}
+
+	pending := filepath.Join(dataDir, PendingDir)
+	if err := os.RemoveAll(pending); err != nil {
+		return m, err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, manifestName), rawManifest, 0600); err != nil {
+		return m, err
+	}
+	if err := os.Rename(tmp, pending); err != nil {
+		return m, fmt.Errorf("stage restore: %w", err)
+	}
+	return m, nil
+}
+
+// allowedPath accepts only the databases and files below media/.
+func allowedPath(name string) bool {
+	if name != path.Clean(name) || path.IsAbs(name) || strings.HasPrefix(name, "../") {
+		return false
+	}
+	for _, db := range databases {
+		if name == db {
+			return true
+		}
+	}
+	return strings.HasPrefix(name, mediaDir+"/")
+}
+
+// verify checks the extracted files against the manifest, both ways.
+func verify(m Manifest, got map[string]FileEntry) error {
+	expected := append([]FileEntry{}, m.Databases...)
+	if m.MediaIncluded {
+		expected = append(expected, m.Media...)
+	}
+
+	hasIndex := false
+	for _, want := range expected {
+		have, ok := got[want.Path]
+		if !ok {
+			return fmt.Errorf("archive is missing %s", want.Path)
+		}
+		if have != want {
+			return fmt.Errorf("%s does not match the manifest (corrupt archive?)", want.Path)
+		}
+		if want.Path == "wacli.db" {
+			hasIndex = true
+		}
+		delete(got, want.Path)
+	}
+	for name := range got {
+		return fmt.Errorf("%s is not listed in the manifest", name)
+	}
+	if !hasIndex {
+		return fmt.Errorf("archive has no wacli.db")
+	}
+	return nil
+}
+
+// ApplyPending swaps a staged restore into dataDir. The caller must hold the
+// data directory lock with no database open. The replaced files are moved to
+// dataDir/pre-restore-<timestamp>, whose path is returned; it is empty if no
+// restore was pending.
+func ApplyPending(dataDir string) (string, Manifest, error) {
+	var m Manifest
+	pending := filepath.Join(dataDir, PendingDir)
+	raw, err := os.ReadFile(filepath.Join(pending, manifestName))
+	if os.IsNotExist(err) {
+		return "", m, nil
+	}
+	if err != nil {
+		return "", m, err
+	}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return "", m, fmt.Errorf("invalid staged manifest: %w", err)
+	}
+
+	old := filepath.Join(dataDir, "pre-restore-"+time.Now().UTC().Format("20060102T150405Z"))
+	if err := os.MkdirAll(old, 0700); err != nil {
+		return "", m, err
+	}
+
+	// Move the live files out of the way, including SQLite sidecar files that
+	// would otherwise be replayed into the restored databases.
+	var move []string
+	for _, db := range databases {
+		move = append(move, db, db+"-wal", db+"-shm")
+	}
+	if m.MediaIncluded {
+		move = append(move, mediaDir)
+	}
+	for _, name := range move {
+		if err := os.Rename(filepath.Join(dataDir, name), filepath.Join(old, name)); err != nil && !os.IsNotExist(err) {
+			return old, m, fmt.Errorf("move aside %s: %w", name, err)
+		}
+	}
+
+	for _, entry := range m.Databases {
+		if err := os.Rename(filepath.Join(pending, entry.Path), filepath.Join(dataDir, entry.Path)); err != nil {
+			return old, m, fmt.Errorf("restore %s: %w", entry.Path, err)
+		}
+	}
+	if m.MediaIncluded {
+		src := filepath.Join(pending, mediaDir)
+		if _, err := os.Stat(src); err == nil {
+			if err := os.Rename(src, filepath.Join(dataDir, mediaDir)); err != nil {
+				return old, m, fmt.Errorf("restore media: %w", err)
+			}
+		}
+	}
+
+	return old, m, os.RemoveAll(pending)
+}
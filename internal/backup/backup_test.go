@@ -0,0 +1,135 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+func newDataDir(t *testing.T, chatName string) string {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := store.Open(filepath.Join(dir, "wacli.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := db.UpsertChat("123@s.whatsapp.net", "dm", chatName, time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	_ = db.Close()
+
+	media := filepath.Join(dir, "media", "123@s.whatsapp.net")
+	if err := os.MkdirAll(media, 0700); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(media, "photo.jpg"), []byte("jpeg:"+chatName), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(media, "video.mp4.part"), []byte("partial"), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return dir
+}
+
+func chatName(t *testing.T, dataDir string) string {
+	t.Helper()
+	db, err := store.Open(filepath.Join(dataDir, "wacli.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	chats, err := db.ListChats("", 10)
+	if err != nil || len(chats) != 1 {
+		t.Fatalf("ListChats = %+v, %v", chats, err)
+	}
+	return chats[0].Name
+}
+
+func TestBackupRestoreRoundTrip(t *testing.T) {
+	src := newDataDir(t, "Alice")
+	var buf bytes.Buffer
+	m, err := Write(&buf, src, true)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if len(m.Databases) != 1 || len(m.Media) != 1 || m.Media[0].Path != "media/123@s.whatsapp.net/photo.jpg" {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+
+	dst := newDataDir(t, "Bob")
+	if _, err := Stage(bytes.NewReader(buf.Bytes()), dst); err != nil {
+		t.Fatalf("Stage: %v", err)
+	}
+	if chatName(t, dst) != "Bob" {
+		t.Fatalf("staging must not touch live data")
+	}
+
+	old, _, err := ApplyPending(dst)
+	if err != nil {
+		t.Fatalf("ApplyPending: %v", err)
+	}
+	if got := chatName(t, dst); got != "Alice" {
+		t.Fatalf("expected restored chat Alice, got %s", got)
+	}
+	photo, _ := os.ReadFile(filepath.Join(dst, "media", "123@s.whatsapp.net", "photo.jpg"))
+	if string(photo) != "jpeg:Alice" {
+		t.Fatalf("expected restored media, got %q", photo)
+	}
+	if _, err := os.Stat(filepath.Join(old, "wacli.db")); err != nil {
+		t.Fatalf("expected previous database kept in %s: %v", old, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, PendingDir)); !os.IsNotExist(err) {
+		t.Fatalf("expected pending restore to be consumed")
+	}
+
+	if old, _, err := ApplyPending(dst); err != nil || old != "" {
+		t.Fatalf("expected no pending restore, got %q, %v", old, err)
+	}
+}
+
+func TestStageRejectsTamperedArchive(t *testing.T) {
+	src := newDataDir(t, "Alice")
+	var buf bytes.Buffer
+	if _, err := Write(&buf, src, true); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	// Re-pack the archive with the photo modified.
+	gz, _ := gzip.NewReader(&buf)
+	tr := tar.NewReader(gz)
+	var out bytes.Buffer
+	gzw := gzip.NewWriter(&out)
+	tw := tar.NewWriter(gzw)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		var body bytes.Buffer
+		_, _ = body.ReadFrom(tr)
+		data := body.Bytes()
+		if strings.HasSuffix(hdr.Name, "photo.jpg") {
+			data = []byte("tampered")
+			hdr.Size = int64(len(data))
+		}
+		_ = tw.WriteHeader(hdr)
+		_, _ = tw.Write(data)
+	}
+	_ = tw.Close()
+	_ = gzw.Close()
+
+	dst := t.TempDir()
+	if _, err := Stage(&out, dst); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("expected manifest mismatch, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, PendingDir)); !os.IsNotExist(err) {
+		t.Fatalf("tampered archive must not be staged")
+	}
+}
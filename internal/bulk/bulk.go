@@ -0,0 +1,236 @@
+// Package bulk sends one templated message to each recipient of a list,
+// pacing the sends so a campaign does not burst into WhatsApp's spam
+// detection.
+//
+// Recipients come from a CSV file with a header row: one column holds the
+// phone number or JID, and every column can be used in the message template
+// as {{column}}.
+package bulk
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// recipientColumns are the header names accepted for the recipient column,
+// in order of preference.
+var recipientColumns = []string{"to", "phone", "number", "jid"}
+
+// placeholder matches {{name}} in a template, allowing spaces inside.
+var placeholder = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+
+// Result statuses.
+const (
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+	StatusDryRun  = "dry_run"
+	StatusSkipped = "skipped" // not attempted because the run was stopped
+)
+
+// Recipient is one row of the recipient list.
+type Recipient struct {
+	Row    int // line in the CSV file, for reporting
+	To     string
+	Fields map[string]string
+}
+
+// ReadCSV reads recipients from CSV data with a header row. Header names are
+// matched case-insensitively; rows without a recipient are an error.
+func ReadCSV(r io.Reader) ([]Recipient, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("csv is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, h := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+	}
+	toCol := -1
+	for _, name := range recipientColumns {
+		for i, h := range header {
+			if h == name {
+				toCol = i
+				break
+			}
+		}
+		if toCol >= 0 {
+			break
+		}
+	}
+	if toCol < 0 {
+		return nil, fmt.Errorf("csv needs a recipient column named one of: %s", strings.Join(recipientColumns, ", "))
+	}
+
+	var out []Recipient
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		row, _ := cr.FieldPos(0)
+		if len(rec) == 1 && strings.TrimSpace(rec[0]) == "" {
+			continue
+		}
+		fields := make(map[string]string, len(header))
+		for i, h := range header {
+			if i < len(rec) && h != "" {
+				fields[h] = strings.TrimSpace(rec[i])
+			}
+		}
+		to := normalizeRecipient(fields[header[toCol]])
+		if to == "" {
+			return nil, fmt.Errorf("csv line %d: missing recipient", row)
+		}
+		out = append(out, Recipient{Row: row, To: to, Fields: fields})
+	}
+}
+
+// normalizeRecipient strips the formatting spreadsheets tend to add to
+// phone numbers ("+1 (555) 010-0100"); JIDs are kept as they are.
+func normalizeRecipient(s string) string {
+	if strings.Contains(s, "@") {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')', '.', '+':
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// Render replaces each {{name}} in tmpl with the recipient's field of that
+// name. A placeholder without a matching column is an error, so a typo
+// never reaches a recipient.
+func Render(tmpl string, fields map[string]string) (string, error) {
+	var missing []string
+	text := placeholder.ReplaceAllStringFunc(tmpl, func(m string) string {
+		name := strings.ToLower(placeholder.FindStringSubmatch(m)[1])
+		v, ok := fields[name]
+		if !ok {
+			missing = append(missing, name)
+		}
+		return v
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("template uses unknown column(s): %s", strings.Join(missing, ", "))
+	}
+	return text, nil
+}
+
+// SendFunc sends text to a recipient and returns the message ID.
+type SendFunc func(ctx context.Context, to, text string) (string, error)
+
+// Options controls a run.
+type Options struct {
+	Template string
+	Delay    time.Duration // pause between two sends
+	DryRun   bool          // render and report, but send nothing
+}
+
+// Result is the outcome for one recipient.
+type Result struct {
+	Row       int    `json:"row"`
+	To        string `json:"to"`
+	Status    string `json:"status"`
+	MessageID string `json:"message_id,omitempty"`
+	Message   string `json:"message"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Run sends the rendered template to each recipient in order, waiting
+// opts.Delay between sends. A failed send does not stop the run; a
+// cancelled ctx does, and the remaining recipients are reported as skipped.
+// report, if set, is called with each result as it happens.
+func Run(ctx context.Context, recipients []Recipient, opts Options, send SendFunc, report func(Result)) []Result {
+	results := make([]Result, 0, len(recipients))
+	add := func(r Result) {
+		results = append(results, r)
+		if report != nil {
+			report(r)
+		}
+	}
+
+	sent := 0
+	for _, rcp := range recipients {
+		res := Result{Row: rcp.Row, To: rcp.To}
+		text, err := Render(opts.Template, rcp.Fields)
+		if err != nil {
+			res.Status = StatusFailed
+			res.Error = err.Error()
+			add(res)
+			continue
+		}
+		res.Message = text
+
+		if opts.DryRun {
+			res.Status = StatusDryRun
+			add(res)
+			continue
+		}
+		if sent > 0 && opts.Delay > 0 {
+			t := time.NewTimer(opts.Delay)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+			case <-t.C:
+			}
+		}
+		if ctx.Err() != nil {
+			res.Status = StatusSkipped
+			add(res)
+			continue
+		}
+
+		sent++
+		id, err := send(ctx, rcp.To, text)
+		if err != nil {
+			res.Status = StatusFailed
+			res.Error = err.Error()
+		} else {
+			res.Status = StatusSent
+			res.MessageID = id
+		}
+		add(res)
+	}
+	return results
+}
+
+// Count returns how many results have the given status.
+func Count(results []Result, status string) int {
+	n := 0
+	for _, r := range results {
+		if r.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteReport writes results as CSV with a header row.
+func WriteReport(w io.Writer, results []Result) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"row", "to", "status", "message_id", "error", "message"})
+	for _, r := range results {
+		_ = cw.Write([]string{strconv.Itoa(r.Row), r.To, r.Status, r.MessageID, r.Error, r.Message})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,122 @@
+package bulk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadCSV(t *testing.T) {
+	in := "\ufeffName, Phone ,City\nAlice,+1 (555) 010-0100,Berlin\n\nBob,4915112345678@s.whatsapp.net\n"
+	got, err := ReadCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ReadCSV: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d recipients, want 2: %+v", len(got), got)
+	}
+	if got[0].To != "15550100100" || got[0].Fields["name"] != "Alice" || got[0].Fields["city"] != "Berlin" || got[0].Row != 2 {
+		t.Fatalf("recipient 0 = %+v", got[0])
+	}
+	if got[1].To != "4915112345678@s.whatsapp.net" || got[1].Fields["city"] != "" || got[1].Row != 4 {
+		t.Fatalf("recipient 1 = %+v", got[1])
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	for name, in := range map[string]string{
+		"empty":             "",
+		"no column":         "name,city\nAlice,Berlin\n",
+		"missing recipient": "to,name\n,Alice\n",
+	} {
+		if _, err := ReadCSV(strings.NewReader(in)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	got, err := Render("Hi {{name}}, see you in {{ City }}!", map[string]string{"name": "Alice", "city": "Berlin"})
+	if err != nil || got != "Hi Alice, see you in Berlin!" {
+		t.Fatalf("Render = %q, %v", got, err)
+	}
+	if _, err := Render("Hi {{nmae}}", map[string]string{"name": "Alice"}); err == nil || !strings.Contains(err.Error(), "nmae") {
+		t.Fatalf("expected unknown column error, got %v", err)
+	}
+}
+
+func TestRunSendsPacedAndContinuesAfterFailure(t *testing.T) {
+	recipients := []Recipient{
+		{Row: 2, To: "1", Fields: map[string]string{"name": "A"}},
+		{Row: 3, To: "2", Fields: map[string]string{"name": "B"}},
+		{Row: 4, To: "3", Fields: map[string]string{}},
+		{Row: 5, To: "4", Fields: map[string]string{"name": "D"}},
+	}
+	var sentAt []time.Time
+	send := func(ctx context.Context, to, text string) (string, error) {
+		sentAt = append(sentAt, time.Now())
+		if to == "2" {
+			return "", errors.New("boom")
+		}
+		return "id-" + to, nil
+	}
+	var reported int
+	results := Run(context.Background(), recipients, Options{Template: "Hi {{name}}", Delay: 20 * time.Millisecond}, send, func(Result) { reported++ })
+
+	if reported != 4 || len(results) != 4 {
+		t.Fatalf("reported %d, results %d", reported, len(results))
+	}
+	if results[0].Status != StatusSent || results[0].MessageID != "id-1" || results[0].Message != "Hi A" {
+		t.Fatalf("results[0] = %+v", results[0])
+	}
+	if results[1].Status != StatusFailed || results[1].Error != "boom" {
+		t.Fatalf("results[1] = %+v", results[1])
+	}
+	if results[2].Status != StatusFailed || results[3].Status != StatusSent {
+		t.Fatalf("results = %+v", results)
+	}
+	if len(sentAt) != 3 {
+		t.Fatalf("sent %d, want 3 (row with template error is not sent)", len(sentAt))
+	}
+	for i := 1; i < len(sentAt); i++ {
+		if gap := sentAt[i].Sub(sentAt[i-1]); gap < 20*time.Millisecond {
+			t.Fatalf("send %d came %v after the previous one", i, gap)
+		}
+	}
+}
+
+func TestRunDryRunAndCancel(t *testing.T) {
+	recipients := []Recipient{{To: "1"}, {To: "2"}}
+	send := func(ctx context.Context, to, text string) (string, error) {
+		t.Fatalf("dry run sent to %s", to)
+		return "", nil
+	}
+	results := Run(context.Background(), recipients, Options{Template: "Hi", DryRun: true}, send, nil)
+	if Count(results, StatusDryRun) != 2 {
+		t.Fatalf("results = %+v", results)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results = Run(ctx, recipients, Options{Template: "Hi", Delay: time.Hour}, func(ctx context.Context, to, text string) (string, error) {
+		cancel()
+		return "x", nil
+	}, nil)
+	if results[0].Status != StatusSent || results[1].Status != StatusSkipped {
+		t.Fatalf("results = %+v", results)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteReport(&buf, []Result{{Row: 2, To: "1", Status: StatusSent, MessageID: "m", Message: "Hi, A"}})
+	if err != nil {
+		t.Fatalf("WriteReport: %v", err)
+	}
+	want := "row,to,status,message_id,error,message\n2,1,sent,m,,\"Hi, A\"\n"
+	if buf.String() != want {
+		t.Fatalf("report = %q, want %q", buf.String(), want)
+	}
+}
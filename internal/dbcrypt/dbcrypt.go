@@ -0,0 +1,227 @@
+// Package dbcrypt opens SQLite databases encrypted at rest with SQLCipher.
+//
+// Every SQLite database of the service is opened through Driver. Without a
+// key it behaves exactly like the plain go-sqlite3 driver. With a key, each
+// new connection is keyed with PRAGMA key before use, which requires a binary
+// linked against SQLCipher instead of the SQLite amalgamation bundled with
+// go-sqlite3 (build with -tags libsqlite3 and CGO flags pointing at
+// libsqlcipher; see the Dockerfile). Connections fail with ErrUnsupported on
+// builds without SQLCipher rather than silently writing plaintext.
+package dbcrypt
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// Driver is the database/sql driver name to open SQLite databases with.
+const Driver = "sqlite3_dbcrypt"
+
+// ErrUnsupported is returned when a key is set but SQLite lacks SQLCipher.
+var ErrUnsupported = errors.New("database encryption requires a build linked against SQLCipher")
+
+var (
+	mu  sync.RWMutex
+	key string
+)
+
+func init() {
+	sql.Register(Driver, &sqlite3.SQLiteDriver{ConnectHook: connect})
+}
+
+// SetKey sets the key for connections opened afterwards; empty disables
+// encryption. Set it before opening any database.
+func SetKey(k string) {
+	mu.Lock()
+	key = k
+	mu.Unlock()
+}
+
+// Enabled reports whether a key is set.
+func Enabled() bool {
+	return currentKey() != ""
+}
+
+func currentKey() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return key
+}
+
+// LoadKey returns the key given directly, or else the contents of keyFile
+// with surrounding whitespace trimmed. Both empty means no encryption.
+func LoadKey(k, keyFile string) (string, error) {
+	if k != "" || keyFile == "" {
+		return k, nil
+	}
+	raw, err := os.ReadFile(keyFile)
+	if err != nil {
+		return "", fmt.Errorf("read key file: %w", err)
+	}
+	k = strings.TrimSpace(string(raw))
+	if k == "" {
+		return "", fmt.Errorf("key file %s is empty", keyFile)
+	}
+	return k, nil
+}
+
+func connect(c *sqlite3.SQLiteConn) error {
+	k := currentKey()
+	if k == "" {
+		return nil
+	}
+	if _, err := c.Exec("PRAGMA key = "+quote(k), nil); err != nil {
+		return fmt.Errorf("set database key: %w", err)
+	}
+	if ok, err := hasCipher(c); err != nil {
+		return err
+	} else if !ok {
+		return ErrUnsupported
+	}
+	// The key is only checked when the first page is read.
+	if _, err := c.Exec("SELECT count(*) FROM sqlite_master", nil); err != nil {
+		return fmt.Errorf("wrong database key or unencrypted database: %w", err)
+	}
+	return nil
+}
+
+// OpenDB opens dsn like sql.Open(Driver, dsn), and additionally runs each
+// statement in pragmas on every new connection once it is keyed. Settings
+// such as PRAGMA synchronous are per connection, so running them once after
+// opening would only reach one connection of the pool; and with SQLCipher,
+// pragmas that read the database must wait for the key, which rules out
+// passing them in the DSN.
+func OpenDB(dsn string, pragmas ...string) *sql.DB {
+	return sql.OpenDB(connector{dsn: dsn, pragmas: pragmas})
+}
+
+type connector struct {
+	dsn     string
+	pragmas []string
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	d := &sqlite3.SQLiteDriver{ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+		if err := connect(conn); err != nil {
+			return err
+		}
+		for _, p := range c.pragmas {
+			if _, err := conn.Exec(p, nil); err != nil {
+				return fmt.Errorf("%s: %w", p, err)
+			}
+		}
+		return nil
+	}}
+	return d.Open(c.dsn)
+}
+
+func (c connector) Driver() driver.Driver {
+	return &sqlite3.SQLiteDriver{ConnectHook: connect}
+}
+
+// hasCipher reports whether the linked SQLite library is SQLCipher, which
+// answers PRAGMA cipher_version with a row.
+func hasCipher(c *sqlite3.SQLiteConn) (bool, error) {
+	rows, err := c.Query("PRAGMA cipher_version", nil)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	dest := make([]driver.Value, len(rows.Columns()))
+	if err := rows.Next(dest); err == io.EOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// quote renders s as an SQL string literal. PRAGMA arguments cannot be bound.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// IsPlaintext reports whether path is an unencrypted SQLite database.
+// SQLCipher files start with random salt instead of the SQLite header.
+func IsPlaintext(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(f, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return false, nil // empty files are created keyed on first use
+		}
+		return false, err
+	}
+	return bytes.Equal(header, []byte("SQLite format 3\x00")), nil
+}
+
+// Encrypt rewrites the plaintext database at path as a SQLCipher database
+// keyed with the current key, using sqlcipher_export. The plaintext file and
+// its -wal/-shm sidecars are removed once the encrypted copy is in place.
+// The database must not be open elsewhere.
+func Encrypt(path string) error {
+	k := currentKey()
+	if k == "" {
+		return fmt.Errorf("no database key set")
+	}
+
+	tmp := path + ".encrypting"
+	_ = os.Remove(tmp)
+
+	// Open without the key so the plaintext file can be read.
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=5000", path))
+	if err != nil {
+		return fmt.Errorf("open sqlite: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	err = func() error {
+		var version string
+		if err := db.QueryRow("PRAGMA cipher_version").Scan(&version); err == sql.ErrNoRows {
+			return ErrUnsupported
+		} else if err != nil {
+			return err
+		}
+		if _, err := db.Exec("ATTACH DATABASE ? AS encrypted KEY ?", tmp, k); err != nil {
+			return fmt.Errorf("attach encrypted copy: %w", err)
+		}
+		if _, err := db.Exec("SELECT sqlcipher_export('encrypted')"); err != nil {
+			return fmt.Errorf("export: %w", err)
+		}
+		if _, err := db.Exec("DETACH DATABASE encrypted"); err != nil {
+			return fmt.Errorf("detach: %w", err)
+		}
+		return nil
+	}()
+	if cerr := db.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(path + suffix); err != nil && !os.IsNotExist(err) {
+			_ = os.Remove(tmp)
+			return err
+		}
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replace plaintext database: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package dbcrypt
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadKey(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(file, []byte("  s3cret\n"), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if k, err := LoadKey("direct", file); err != nil || k != "direct" {
+		t.Fatalf("expected direct key, got %q, %v", k, err)
+	}
+	if k, err := LoadKey("", file); err != nil || k != "s3cret" {
+		t.Fatalf("expected trimmed file key, got %q, %v", k, err)
+	}
+	if k, err := LoadKey("", ""); err != nil || k != "" {
+		t.Fatalf("expected no key, got %q, %v", k, err)
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty")
+	_ = os.WriteFile(empty, []byte("\n"), 0600)
+	if _, err := LoadKey("", empty); err == nil {
+		t.Fatalf("expected error for empty key file")
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if got := quote("it's"); got != "'it''s'" {
+		t.Fatalf("quote = %s", got)
+	}
+}
+
+func TestIsPlaintext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plain.db")
+	db, err := sql.Open(Driver, "file:"+path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := db.Exec("CREATE TABLE t (x INTEGER)"); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	_ = db.Close()
+
+	if plain, err := IsPlaintext(path); err != nil || !plain {
+		t.Fatalf("expected plaintext database, got %v, %v", plain, err)
+	}
+
+	other := filepath.Join(t.TempDir(), "random.db")
+	_ = os.WriteFile(other, []byte("0123456789abcdef0123456789abcdef"), 0600)
+	if plain, err := IsPlaintext(other); err != nil || plain {
+		t.Fatalf("expected non-plaintext file, got %v, %v", plain, err)
+	}
+}
+
+func TestKeyWithoutSQLCipher(t *testing.T) {
+	SetKey("s3cret")
+	defer SetKey("")
+
+	db, err := sql.Open(Driver, "file:"+filepath.Join(t.TempDir(), "enc.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	err = db.Ping()
+	if err == nil {
+		t.Skip("built against SQLCipher")
+	}
+	if !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,254 @@
+// Package diag assembles the bundle written by `wacli doctor --report`: a
+// snapshot of configuration, store health, lock state, connectivity and
+// recent log lines to attach to a bug report.
+//
+// Everything that goes into a bundle is redacted first. Settings that hold
+// secrets are replaced outright, URLs keep only their scheme and host, and
+// phone numbers in JIDs are masked down to their last digits.
+package diag
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// Redacted replaces a secret value.
+const Redacted = "[redacted]"
+
+// secretWords mark a setting as secret when they appear in its name.
+var secretWords = []string{"KEY", "SECRET", "TOKEN", "PASSWORD", "PASSPHRASE", "CREDS", "HEADERS"}
+
+var (
+	// jidUser matches the phone number part of a user JID.
+	jidUser = regexp.MustCompile(`\b(\d+)(\d{4})(:\d+)?@(s\.whatsapp\.net|lid|c\.us)\b`)
+	// bearer matches bearer tokens in logged headers.
+	bearer = regexp.MustCompile(`(?i)\b(bearer\s+)\S+`)
+	// urlUserinfo matches credentials embedded in URLs.
+	urlUserinfo = regexp.MustCompile(`(\w+://)[^\s/@]+@`)
+)
+
+// Report is the report.json of a bundle. Sections that could not be
+// collected are left empty and explained in Errors.
+type Report struct {
+	GeneratedAt  time.Time         `json:"generated_at"`
+	Version      string            `json:"version"`
+	GoVersion    string            `json:"go_version"`
+	OS           string            `json:"os"`
+	Arch         string            `json:"arch"`
+	Settings     map[string]string `json:"settings"`
+	Store        *Store            `json:"store,omitempty"`
+	Lock         *Lock             `json:"lock,omitempty"`
+	Server       json.RawMessage   `json:"server,omitempty"` // the service's GET /doctor, in remote mode
+	Connectivity []Probe           `json:"connectivity"`
+	LogFile      string            `json:"log_file,omitempty"`
+	Errors       []string          `json:"errors,omitempty"`
+}
+
+// Store describes the local store.
+type Store struct {
+	Dir           string           `json:"dir"`
+	Schema        store.SchemaInfo `json:"schema"`
+	Integrity     []string         `json:"integrity"` // ["ok"], or the problems found
+	FTSEnabled    bool             `json:"fts_enabled"`
+	Messages      int64            `json:"messages"`
+	Chats         int64            `json:"chats"`
+	Contacts      int64            `json:"contacts"`
+	Groups        int64            `json:"groups"`
+	Authenticated bool             `json:"authenticated"`
+}
+
+// Lock describes the store lock.
+type Lock struct {
+	Held bool   `json:"held"`
+	Info string `json:"info,omitempty"` // pid and acquisition time of the holder
+}
+
+// Probe is the outcome of one connectivity check.
+type Probe struct {
+	Name      string `json:"name"`
+	Address   string `json:"address"`
+	OK        bool   `json:"ok"`
+	LatencyMS int64  `json:"latency_ms,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// RedactSettings returns settings with every value passed through
+// RedactValue.
+func RedactSettings(settings map[string]string) map[string]string {
+	out := make(map[string]string, len(settings))
+	for k, v := range settings {
+		out[k] = RedactValue(k, v)
+	}
+	return out
+}
+
+// RedactValue redacts a setting's value based on its name and content.
+func RedactValue(key, value string) string {
+	if value == "" {
+		return ""
+	}
+	upper := strings.ToUpper(key)
+	for _, w := range secretWords {
+		if strings.Contains(upper, w) {
+			return Redacted
+		}
+	}
+	if strings.HasPrefix(strings.TrimSpace(value), "{") || strings.HasPrefix(strings.TrimSpace(value), "[") {
+		return Redacted // JSON settings may carry secrets anywhere
+	}
+	if strings.Contains(value, "://") {
+		parts := strings.Split(value, ",")
+		for i, p := range parts {
+			parts[i] = redactURL(strings.TrimSpace(p))
+		}
+		return strings.Join(parts, ",")
+	}
+	return value
+}
+
+// redactURL keeps a URL's scheme and host; paths and queries often carry
+// tokens (webhook URLs in particular).
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return Redacted
+	}
+	out := u.Scheme + "://" + u.Host
+	if u.User != nil {
+		out = u.Scheme + "://" + Redacted + "@" + u.Host
+	}
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+		out += "/" + Redacted
+	}
+	return out
+}
+
+// Redactor masks secrets and phone numbers in free text such as log lines.
+type Redactor struct {
+	secrets []string
+}
+
+// NewRedactor returns a Redactor that also replaces each of the given
+// secret values wherever they appear. Values shorter than 4 characters are
+// ignored, as masking them would garble unrelated text.
+func NewRedactor(secrets []string) *Redactor {
+	r := &Redactor{}
+	for _, s := range secrets {
+		if len(s) >= 4 {
+			r.secrets = append(r.secrets, s)
+		}
+	}
+	// Longest first, so a secret containing another is replaced whole.
+	sort.Slice(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+	return r
+}
+
+// Line redacts one line of text.
+func (r *Redactor) Line(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	s = bearer.ReplaceAllString(s, "${1}"+Redacted)
+	s = urlUserinfo.ReplaceAllString(s, "${1}"+Redacted+"@")
+	return jidUser.ReplaceAllStringFunc(s, func(m string) string {
+		sub := jidUser.FindStringSubmatch(m)
+		return strings.Repeat("x", len(sub[1])) + sub[2] + sub[3] + "@" + sub[4]
+	})
+}
+
+// Tail returns the last n lines of a file, reading at most the last 4 MiB.
+func Tail(path string, n int) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	const maxRead = 4 << 20
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offset := st.Size() - maxRead
+	if offset < 0 {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		// Drop the partial first line.
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return nil, nil
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
+
+// ProbeTLS checks that a TLS connection to address can be established.
+func ProbeTLS(ctx context.Context, name, address string, timeout time.Duration) Probe {
+	p := Probe{Name: name, Address: address}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	d := tls.Dialer{NetDialer: &net.Dialer{}}
+	conn, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		p.Error = err.Error()
+		return p
+	}
+	_ = conn.Close()
+	p.OK = true
+	p.LatencyMS = time.Since(start).Milliseconds()
+	return p
+}
+
+// WriteBundle writes a zip archive with report.json and, if there are any
+// log lines, log.txt. Both must already be redacted.
+func WriteBundle(w io.Writer, rep Report, logLines []string) error {
+	zw := zip.NewWriter(w)
+	f, err := zw.Create("report.json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(rep); err != nil {
+		return err
+	}
+	if len(logLines) > 0 {
+		f, err := zw.Create("log.txt")
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, strings.Join(logLines, "\n")+"\n"); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
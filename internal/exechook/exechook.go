@@ -0,0 +1,96 @@
+// Package exechook delivers service events to a local command, one process
+// per event with the event JSON on stdin.
+package exechook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/webhook"
+)
+
+// maxLoggedOutput caps how much command output is logged on failure.
+const maxLoggedOutput = 512
+
+// Config holds exec hook configuration.
+type Config struct {
+	Command     string   // Run with "sh -c"
+	Events      []string // Event filter; empty means all, "group.*" matches a prefix
+	Concurrency int      // Max commands running at once
+	Timeout     time.Duration
+}
+
+// Runner runs the configured command for each event.
+type Runner struct {
+	config Config
+	sink   *webhook.Sink
+}
+
+// NewRunner creates a new exec hook runner.
+func NewRunner(cfg Config) *Runner {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 2
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	r := &Runner{config: cfg}
+	r.sink = webhook.NewSink(webhook.SinkConfig{Name: "ExecHook", Events: cfg.Events, Workers: cfg.Concurrency, Publish: r.run})
+	return r
+}
+
+// Start launches the workers.
+func (r *Runner) Start() {
+	r.sink.Start()
+	log.Printf("[ExecHook] Started %d workers", r.config.Concurrency)
+}
+
+// Stop runs the command for the queued events, kills the commands still
+// running once the sink gives up draining, and waits for the workers to exit.
+func (r *Runner) Stop() {
+	r.sink.Stop()
+	log.Println("[ExecHook] Stopped")
+}
+
+// Emit queues an event if it matches the configured filter.
+func (r *Runner) Emit(eventType string, data interface{}) {
+	r.sink.Emit(eventType, data)
+}
+
+// run executes the command once with the event on stdin.
+func (r *Runner) run(ctx context.Context, event *webhook.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.config.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", r.config.Command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(), "WASVC_EVENT_TYPE="+event.Type)
+	cmd.WaitDelay = time.Second
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return context.DeadlineExceeded
+		}
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			if len(msg) > maxLoggedOutput {
+				msg = msg[:maxLoggedOutput] + "..."
+			}
+			log.Printf("[ExecHook] Output: %s", msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package exechook
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/webhook"
+)
+
+func TestRunnerPipesEventToCommand(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "event.json")
+	r := NewRunner(Config{Command: "cat > " + out, Timeout: 5 * time.Second})
+
+	event := &webhook.Event{Type: "message.sent", Timestamp: time.Now().UTC(), Data: map[string]string{"msg_id": "abc"}}
+	if err := r.run(context.Background(), event); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	raw, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var got struct {
+		Type string            `json:"type"`
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got.Type != "message.sent" || got.Data["msg_id"] != "abc" {
+		t.Fatalf("unexpected stdin payload: %s", raw)
+	}
+}
+
+func TestRunnerEnforcesTimeout(t *testing.T) {
+	r := NewRunner(Config{Command: "sleep 5", Timeout: 100 * time.Millisecond})
+
+	start := time.Now()
+	err := r.run(context.Background(), &webhook.Event{Type: "call.incoming"})
+	if err == nil || !strings.Contains(err.Error(), "deadline") {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Fatalf("timeout not enforced, took %s", time.Since(start))
+	}
+}
@@ -0,0 +1,232 @@
+// Package export writes a conversation from the local store as a
+// self-contained archive, like WhatsApp's own chat export but without its
+// message limit: the transcript as plain text, JSON or HTML, plus the
+// downloaded attachments under media/.
+package export
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// Transcript formats.
+const (
+	FormatTXT  = "txt"
+	FormatJSON = "json"
+	FormatHTML = "html"
+)
+
+// mediaDir is where attachments go inside an export.
+const mediaDir = "media"
+
+// Options controls an export.
+type Options struct {
+	Format    string
+	WithMedia bool           // copy downloaded attachments into the export
+	Location  *time.Location // for txt and html timestamps; nil means local time
+	Now       func() time.Time
+}
+
+// Result summarizes an export.
+type Result struct {
+	Transcript   string // file name of the transcript within the export
+	Messages     int
+	Media        int // attachments copied
+	MissingMedia int // downloaded attachments whose file is gone
+}
+
+// Source provides the messages of a chat, oldest first.
+type Source interface {
+	ExportChat(chatJID string, fn func(store.ExportedMessage) error) error
+}
+
+// Sink receives the files of an export one at a time: a writer returned by
+// Create is only valid until the next call to Create or Close.
+type Sink interface {
+	Create(name string) (io.Writer, error)
+	Close() error
+}
+
+// ValidFormat reports whether format is a supported transcript format.
+func ValidFormat(format string) bool {
+	switch format {
+	case FormatTXT, FormatJSON, FormatHTML:
+		return true
+	}
+	return false
+}
+
+// Write exports chat from src into sink. The transcript is written first,
+// streaming the messages, and the attachments after it.
+func Write(sink Sink, src Source, chat store.Chat, opts Options) (Result, error) {
+	if !ValidFormat(opts.Format) {
+		return Result{}, fmt.Errorf("unsupported format %q (use txt, json or html)", opts.Format)
+	}
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	res := Result{Transcript: "chat." + opts.Format}
+	w, err := sink.Create(res.Transcript)
+	if err != nil {
+		return res, err
+	}
+	r := newRenderer(opts.Format, w, opts.Location)
+	if err := r.header(chat, opts.Now().UTC()); err != nil {
+		return res, err
+	}
+
+	type copyJob struct{ from, to string }
+	var copies []copyJob
+	err = src.ExportChat(chat.JID, func(m store.ExportedMessage) error {
+		res.Messages++
+		attachment := ""
+		if opts.WithMedia && m.MediaType != "" && m.LocalPath != "" {
+			if _, err := os.Stat(m.LocalPath); err == nil {
+				attachment = path.Join(mediaDir, attachmentName(m))
+				copies = append(copies, copyJob{from: m.LocalPath, to: attachment})
+			} else {
+				res.MissingMedia++
+			}
+		}
+		return r.message(m, attachment)
+	})
+	if err != nil {
+		return res, err
+	}
+	if err := r.footer(); err != nil {
+		return res, err
+	}
+
+	for _, c := range copies {
+		if err := copyFile(sink, c.from, c.to); err != nil {
+			return res, fmt.Errorf("copy %s: %w", c.from, err)
+		}
+		res.Media++
+	}
+	return res, nil
+}
+
+func copyFile(sink Sink, from, to string) error {
+	f, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w, err := sink.Create(to)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// attachmentName names an attachment after its message, which is unique
+// within the chat, keeping the extension of the stored file.
+func attachmentName(m store.ExportedMessage) string {
+	ext := filepath.Ext(m.LocalPath)
+	if ext == "" {
+		ext = filepath.Ext(m.Filename)
+	}
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, m.MsgID)
+	return name + strings.ToLower(ext)
+}
+
+// senderLabel is how a message's author is shown in txt and html exports.
+func senderLabel(m store.ExportedMessage) string {
+	switch {
+	case m.FromMe:
+		return "me"
+	case m.SenderName != "":
+		return m.SenderName
+	case m.SenderJID != "":
+		if user, _, ok := strings.Cut(m.SenderJID, "@"); ok {
+			return user
+		}
+		return m.SenderJID
+	}
+	return "unknown"
+}
+
+// dirSink writes an export into a directory.
+type dirSink struct {
+	dir string
+	cur *os.File
+}
+
+// NewDirSink returns a sink that writes into dir, creating it if needed.
+func NewDirSink(dir string) (Sink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &dirSink{dir: dir}, nil
+}
+
+func (s *dirSink) Create(name string) (io.Writer, error) {
+	if err := s.closeCurrent(); err != nil {
+		return nil, err
+	}
+	p := filepath.Join(s.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		return nil, err
+	}
+	s.cur = f
+	return f, nil
+}
+
+func (s *dirSink) Close() error {
+	return s.closeCurrent()
+}
+
+func (s *dirSink) closeCurrent() error {
+	if s.cur == nil {
+		return nil
+	}
+	err := s.cur.Close()
+	s.cur = nil
+	return err
+}
+
+// zipSink writes an export as a zip archive.
+type zipSink struct {
+	zw *zip.Writer
+}
+
+// NewZipSink returns a sink that writes a zip archive to w. Close finishes
+// the archive but does not close w.
+func NewZipSink(w io.Writer) Sink {
+	return &zipSink{zw: zip.NewWriter(w)}
+}
+
+func (s *zipSink) Create(name string) (io.Writer, error) {
+	return s.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+}
+
+func (s *zipSink) Close() error {
+	return s.zw.Close()
+}
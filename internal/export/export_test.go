@@ -0,0 +1,172 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+type fakeSource []store.ExportedMessage
+
+func (s fakeSource) ExportChat(chatJID string, fn func(store.ExportedMessage) error) error {
+	for _, m := range s {
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func testMessages(t *testing.T) fakeSource {
+	t.Helper()
+	photo := filepath.Join(t.TempDir(), "photo.JPG")
+	if err := os.WriteFile(photo, []byte("jpeg"), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	base := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	return fakeSource{
+		{MsgID: "m1", SenderJID: "123@s.whatsapp.net", SenderName: "Alice", Timestamp: base, Text: "hello\nthere"},
+		{MsgID: "m2", Timestamp: base.Add(time.Minute), FromMe: true, MediaType: "image", Caption: "look", LocalPath: photo},
+		{MsgID: "m/3", SenderJID: "123@s.whatsapp.net", Timestamp: base.Add(24 * time.Hour), MediaType: "audio"},
+		{MsgID: "m4", SenderJID: "123@s.whatsapp.net", Timestamp: base.Add(25 * time.Hour), MediaType: "video", LocalPath: "/nonexistent/v.mp4"},
+	}
+}
+
+var testChat = store.Chat{JID: "123@s.whatsapp.net", Kind: "dm", Name: "Alice <3"}
+
+func testOptions(format string, withMedia bool) Options {
+	return Options{
+		Format:    format,
+		WithMedia: withMedia,
+		Location:  time.UTC,
+		Now:       func() time.Time { return time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC) },
+	}
+}
+
+func TestWriteTXTDir(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewDirSink(dir)
+	if err != nil {
+		t.Fatalf("NewDirSink: %v", err)
+	}
+	res, err := Write(sink, testMessages(t), testChat, testOptions(FormatTXT, true))
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if res.Messages != 4 || res.Media != 1 || res.MissingMedia != 1 || res.Transcript != "chat.txt" {
+		t.Fatalf("Result = %+v", res)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "chat.txt"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	want := "[2024-06-01 09:00:00] Alice: hello\nthere\n" +
+		"[2024-06-01 09:01:00] me: <attached: media/m2.jpg> look\n" +
+		"[2024-06-02 09:00:00] 123: <audio omitted>\n" +
+		"[2024-06-02 10:00:00] 123: <video omitted>\n"
+	if string(got) != want {
+		t.Fatalf("chat.txt =\n%s\nwant\n%s", got, want)
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "media", "m2.jpg")); err != nil || string(b) != "jpeg" {
+		t.Fatalf("media/m2.jpg = %q, %v", b, err)
+	}
+}
+
+func TestWriteJSONZip(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewZipSink(&buf)
+	if _, err := Write(sink, testMessages(t), testChat, testOptions(FormatJSON, false)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader: %v", err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != "chat.json" {
+		t.Fatalf("zip entries = %+v", zr.File)
+	}
+	f, err := zr.File[0].Open()
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	raw, _ := io.ReadAll(f)
+	_ = f.Close()
+
+	var doc struct {
+		Chat       jsonChat      `json:"chat"`
+		ExportedAt time.Time     `json:"exported_at"`
+		Messages   []jsonMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("Unmarshal: %v\n%s", err, raw)
+	}
+	if doc.Chat.JID != testChat.JID || doc.Chat.Name != testChat.Name || len(doc.Messages) != 4 {
+		t.Fatalf("doc = %+v", doc)
+	}
+	if m := doc.Messages[1]; m.MsgID != "m2" || !m.FromMe || m.MediaType != "image" || m.Attachment != "" {
+		t.Fatalf("messages[1] = %+v", m)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewDirSink(dir)
+	if err != nil {
+		t.Fatalf("NewDirSink: %v", err)
+	}
+	if _, err := Write(sink, testMessages(t), testChat, testOptions(FormatHTML, true)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	_ = sink.Close()
+
+	raw, err := os.ReadFile(filepath.Join(dir, "chat.html"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	page := string(raw)
+	for _, want := range []string{
+		"<title>Alice &lt;3</title>",
+		`<img src="media/m2.jpg"`,
+		"&lt;audio omitted&gt;",
+		"Saturday, 1 June 2024",
+		"Sunday, 2 June 2024",
+		"</html>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("chat.html missing %q", want)
+		}
+	}
+	if n := strings.Count(page, `class="day"`); n != 2 {
+		t.Errorf("day dividers = %d, want 2", n)
+	}
+}
+
+func TestWriteRejectsUnknownFormat(t *testing.T) {
+	sink := NewZipSink(io.Discard)
+	if _, err := Write(sink, fakeSource{}, testChat, Options{Format: "pdf"}); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
+
+func TestAttachmentName(t *testing.T) {
+	got := attachmentName(store.ExportedMessage{MsgID: "3EB0/..x", Filename: "Report.PDF"})
+	if got != "3EB0___x.pdf" {
+		t.Fatalf("attachmentName = %q", got)
+	}
+}
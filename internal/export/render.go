@@ -0,0 +1,272 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// renderer writes a transcript. attachment is the attachment's path within
+// the export, or empty when it is not included.
+type renderer interface {
+	header(chat store.Chat, exportedAt time.Time) error
+	message(m store.ExportedMessage, attachment string) error
+	footer() error
+}
+
+func newRenderer(format string, w io.Writer, loc *time.Location) renderer {
+	switch format {
+	case FormatJSON:
+		return &jsonRenderer{w: w}
+	case FormatHTML:
+		return &htmlRenderer{w: w, loc: loc}
+	default:
+		return &txtRenderer{w: w, loc: loc}
+	}
+}
+
+// omitted is the placeholder for media that is not part of the export,
+// e.g. "<image omitted>".
+func omitted(mediaType string) string {
+	return "<" + mediaType + " omitted>"
+}
+
+// txtRenderer writes one "[time] sender: text" entry per message, like
+// WhatsApp's own text export; multi-line messages continue on the
+// following lines.
+type txtRenderer struct {
+	w   io.Writer
+	loc *time.Location
+}
+
+func (r *txtRenderer) header(store.Chat, time.Time) error { return nil }
+
+func (r *txtRenderer) message(m store.ExportedMessage, attachment string) error {
+	parts := make([]string, 0, 3)
+	if m.MediaType != "" {
+		if attachment != "" {
+			parts = append(parts, "<attached: "+attachment+">")
+		} else {
+			parts = append(parts, omitted(m.MediaType))
+		}
+		if m.Caption != "" && m.Caption != m.Text {
+			parts = append(parts, m.Caption)
+		}
+	}
+	if m.Text != "" {
+		parts = append(parts, m.Text)
+	}
+	_, err := fmt.Fprintf(r.w, "[%s] %s: %s\n", m.Timestamp.In(r.loc).Format("2006-01-02 15:04:05"), senderLabel(m), strings.Join(parts, " "))
+	return err
+}
+
+func (r *txtRenderer) footer() error { return nil }
+
+// jsonChat and jsonMessage are the shapes of a JSON export.
+type jsonChat struct {
+	JID  string `json:"jid"`
+	Name string `json:"name,omitempty"`
+	Kind string `json:"kind,omitempty"`
+}
+
+type jsonMessage struct {
+	MsgID      string    `json:"msg_id"`
+	Timestamp  time.Time `json:"timestamp"`
+	FromMe     bool      `json:"from_me"`
+	SenderJID  string    `json:"sender_jid,omitempty"`
+	SenderName string    `json:"sender_name,omitempty"`
+	Text       string    `json:"text,omitempty"`
+	MediaType  string    `json:"media_type,omitempty"`
+	Caption    string    `json:"caption,omitempty"`
+	Filename   string    `json:"filename,omitempty"`
+	MimeType   string    `json:"mime_type,omitempty"`
+	Attachment string    `json:"attachment,omitempty"`
+}
+
+// jsonRenderer writes {"chat":…,"exported_at":…,"messages":[…]} with one
+// message per line, without holding the messages in memory.
+type jsonRenderer struct {
+	w     io.Writer
+	count int
+}
+
+func (r *jsonRenderer) header(chat store.Chat, exportedAt time.Time) error {
+	c, err := json.Marshal(jsonChat{JID: chat.JID, Name: chat.Name, Kind: chat.Kind})
+	if err != nil {
+		return err
+	}
+	at, err := json.Marshal(exportedAt)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(r.w, `{"chat":%s,"exported_at":%s,"messages":[`, c, at)
+	return err
+}
+
+func (r *jsonRenderer) message(m store.ExportedMessage, attachment string) error {
+	raw, err := json.Marshal(jsonMessage{
+		MsgID:      m.MsgID,
+		Timestamp:  m.Timestamp.UTC(),
+		FromMe:     m.FromMe,
+		SenderJID:  m.SenderJID,
+		SenderName: m.SenderName,
+		Text:       m.Text,
+		MediaType:  m.MediaType,
+		Caption:    m.Caption,
+		Filename:   m.Filename,
+		MimeType:   m.MimeType,
+		Attachment: attachment,
+	})
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if r.count == 0 {
+		sep = "\n"
+	}
+	r.count++
+	_, err = fmt.Fprintf(r.w, "%s%s", sep, raw)
+	return err
+}
+
+func (r *jsonRenderer) footer() error {
+	_, err := io.WriteString(r.w, "\n]}\n")
+	return err
+}
+
+// htmlRenderer writes a single page with inline styles that opens offline;
+// attachments are referenced relative to it.
+type htmlRenderer struct {
+	w       io.Writer
+	loc     *time.Location
+	lastDay string
+}
+
+// htmlMessage is the data of one message in htmlMessageTemplate.
+type htmlMessage struct {
+	Day        string // set on the first message of a day
+	Time       string
+	Sender     string
+	FromMe     bool
+	Text       string
+	MediaType  string
+	Caption    string
+	Attachment string
+	LinkText   string
+	Omitted    string
+}
+
+func (r *htmlRenderer) header(chat store.Chat, exportedAt time.Time) error {
+	title := chat.Name
+	if title == "" {
+		title = chat.JID
+	}
+	return htmlHeaderTemplate.Execute(r.w, map[string]string{
+		"Title":      title,
+		"JID":        chat.JID,
+		"ExportedAt": exportedAt.In(r.loc).Format("2006-01-02 15:04"),
+	})
+}
+
+func (r *htmlRenderer) message(m store.ExportedMessage, attachment string) error {
+	ts := m.Timestamp.In(r.loc)
+	hm := htmlMessage{
+		Time:      ts.Format("15:04"),
+		Sender:    senderLabel(m),
+		FromMe:    m.FromMe,
+		Text:      m.Text,
+		MediaType: m.MediaType,
+	}
+	if day := ts.Format("Monday, 2 January 2006"); day != r.lastDay {
+		hm.Day = day
+		r.lastDay = day
+	}
+	if m.Caption != m.Text {
+		hm.Caption = m.Caption
+	}
+	if m.MediaType != "" {
+		if attachment != "" {
+			hm.Attachment = attachment
+			hm.LinkText = m.Filename
+			if hm.LinkText == "" {
+				hm.LinkText = attachment
+			}
+		} else {
+			hm.Omitted = omitted(m.MediaType)
+		}
+	}
+	return htmlMessageTemplate.Execute(r.w, hm)
+}
+
+func (r *htmlRenderer) footer() error {
+	_, err := io.WriteString(r.w, htmlFooter)
+	return err
+}
+
+var htmlHeaderTemplate = template.Must(template.New("header").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>{{.Title}}</title>
+<style>
+    body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; background: #efeae2; color: #1a1a1a; margin: 0; }
+    header { background: #075e54; color: white; padding: 14px 20px; }
+    header h1 { font-size: 18px; margin: 0; }
+    header p { font-size: 12px; margin: 4px 0 0; opacity: 0.8; }
+    main { max-width: 860px; margin: 0 auto; padding: 20px; display: flex; flex-direction: column; gap: 6px; }
+    .day { align-self: center; background: #e1f2fb; color: #54656f; font-size: 12px; border-radius: 6px; padding: 4px 10px; margin: 10px 0; }
+    .message { max-width: 70%; background: white; border-radius: 8px; padding: 6px 10px; box-shadow: 0 1px 1px rgba(0, 0, 0, 0.08); align-self: flex-start; white-space: pre-wrap; word-wrap: break-word; }
+    .message.from-me { background: #d9fdd3; align-self: flex-end; }
+    .sender { color: #128c7e; font-size: 13px; font-weight: 600; }
+    .time { color: #667781; font-size: 11px; text-align: right; }
+    .omitted { color: #667781; font-style: italic; }
+    .message img, .message video { display: block; max-width: 100%; max-height: 360px; border-radius: 6px; margin: 4px 0; }
+    .message audio { display: block; margin: 4px 0; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<p>{{.JID}} · exported {{.ExportedAt}}</p>
+</header>
+<main>
+`))
+
+var htmlMessageTemplate = template.Must(template.New("message").Parse(`{{if .Day}}<div class="day">{{.Day}}</div>
+{{end}}<div class="message{{if .FromMe}} from-me{{end}}">
+<div class="sender">{{.Sender}}</div>
+{{- if .Attachment}}
+{{- if or (eq .MediaType "image") (eq .MediaType "sticker")}}
+<a href="{{.Attachment}}"><img src="{{.Attachment}}" alt="{{.MediaType}}" loading="lazy"></a>
+{{- else if eq .MediaType "video"}}
+<video src="{{.Attachment}}" controls preload="metadata"></video>
+{{- else if eq .MediaType "gif"}}
+<video src="{{.Attachment}}" autoplay loop muted playsinline></video>
+{{- else if eq .MediaType "audio"}}
+<audio src="{{.Attachment}}" controls preload="none"></audio>
+{{- else}}
+<a href="{{.Attachment}}">{{.LinkText}}</a>
+{{- end}}
+{{- else if .Omitted}}
+<div class="omitted">{{.Omitted}}</div>
+{{- end}}
+{{- if .Caption}}
+<div>{{.Caption}}</div>
+{{- end}}
+{{- if .Text}}
+<div>{{.Text}}</div>
+{{- end}}
+<div class="time">{{.Time}}</div>
+</div>
+`))
+
+const htmlFooter = `</main>
+</body>
+</html>
+`
@@ -0,0 +1,199 @@
+// Package kafkasink produces service events to a Kafka topic, keyed by chat
+// JID so all events of one chat land on the same partition.
+package kafkasink
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/steipete/wacli/internal/webhook"
+)
+
+// Serialization formats.
+const (
+	FormatJSON     = "json"
+	FormatProtobuf = "protobuf" // google.protobuf.Struct with type, timestamp and data fields
+)
+
+// maxBatch caps how many queued events are written in one produce call.
+const maxBatch = 100
+
+// Config holds Kafka sink configuration.
+type Config struct {
+	Brokers       []string
+	Topic         string
+	Format        string   // json (default) or protobuf
+	Events        []string // event filter; empty means all
+	SASLMechanism string   // plain, scram-sha-256 or scram-sha-512; empty disables SASL
+	Username      string
+	Password      string
+	TLS           bool
+}
+
+// Producer produces events to Kafka.
+type Producer struct {
+	config Config
+	sink   *webhook.Sink
+	writer *kafka.Writer
+}
+
+// NewProducer creates a new Kafka producer. It fails on an unknown format or
+// SASL mechanism; brokers are only contacted once events are written.
+func NewProducer(cfg Config) (*Producer, error) {
+	if cfg.Topic == "" {
+		cfg.Topic = "wasvc.events"
+	}
+	if cfg.Format == "" {
+		cfg.Format = FormatJSON
+	}
+	if cfg.Format != FormatJSON && cfg.Format != FormatProtobuf {
+		return nil, fmt.Errorf("unknown format %q", cfg.Format)
+	}
+
+	transport := &kafka.Transport{DialTimeout: 10 * time.Second}
+	if cfg.TLS {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	mechanism, err := saslMechanism(cfg.SASLMechanism, cfg.Username, cfg.Password)
+	if err != nil {
+		return nil, err
+	}
+	transport.SASL = mechanism
+
+	p := &Producer{
+		config: cfg,
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Brokers...),
+			Topic:        cfg.Topic,
+			Balancer:     &kafka.Hash{},
+			BatchTimeout: 50 * time.Millisecond,
+			// Wait for all in-sync replicas; the zero value does not wait.
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+			Transport:              transport,
+		},
+	}
+	p.sink = webhook.NewSink(webhook.SinkConfig{Name: "Kafka", Events: cfg.Events, PublishBatch: p.write, MaxBatch: maxBatch})
+	return p, nil
+}
+
+func saslMechanism(name, username, password string) (sasl.Mechanism, error) {
+	switch strings.ToLower(name) {
+	case "":
+		return nil, nil
+	case "plain":
+		return plain.Mechanism{Username: username, Password: password}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, username, password)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, username, password)
+	}
+	return nil, fmt.Errorf("unknown SASL mechanism %q", name)
+}
+
+// Start launches the producer worker.
+func (p *Producer) Start() {
+	p.sink.Start()
+	log.Printf("[Kafka] Producing %s events to %s on %s", p.config.Format, p.config.Topic, strings.Join(p.config.Brokers, ","))
+}
+
+// Stop produces the queued events and closes the writer.
+func (p *Producer) Stop() {
+	p.sink.Stop()
+	if err := p.writer.Close(); err != nil {
+		log.Printf("[Kafka] Close error: %v", err)
+	}
+	log.Println("[Kafka] Stopped")
+}
+
+// Emit queues an event if it matches the configured filter.
+func (p *Producer) Emit(eventType string, data interface{}) {
+	p.sink.Emit(eventType, data)
+}
+
+// write produces a batch of events; events that fail to encode are logged
+// and skipped.
+func (p *Producer) write(ctx context.Context, events []*webhook.Event) error {
+	msgs := make([]kafka.Message, 0, len(events))
+	for _, event := range events {
+		msg, err := encode(event, p.config.Format)
+		if err != nil {
+			log.Printf("[Kafka] Failed to encode %s: %v", event.Type, err)
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return p.writer.WriteMessages(ctx, msgs...)
+}
+
+// encode serializes an event into a Kafka message keyed by its chat JID.
+func encode(event *webhook.Event, format string) (kafka.Message, error) {
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return kafka.Message{}, err
+	}
+
+	msg := kafka.Message{
+		Key:   partitionKey(fields["data"]),
+		Value: raw,
+		Headers: []kafka.Header{
+			{Key: "event_type", Value: []byte(event.Type)},
+			{Key: "content_type", Value: []byte(contentType(format))},
+		},
+		Time: event.Timestamp,
+	}
+	if format == FormatProtobuf {
+		s, err := structpb.NewStruct(fields)
+		if err != nil {
+			return kafka.Message{}, err
+		}
+		if msg.Value, err = proto.Marshal(s); err != nil {
+			return kafka.Message{}, err
+		}
+	}
+	return msg, nil
+}
+
+// partitionKey returns the chat (or group) JID of an event payload, or nil
+// for events not tied to a chat, which are spread round-robin.
+func partitionKey(data interface{}) []byte {
+	fields, ok := data.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	for _, name := range []string{"chat_jid", "group_jid"} {
+		if jid, ok := fields[name].(string); ok && jid != "" {
+			return []byte(jid)
+		}
+	}
+	return nil
+}
+
+func contentType(format string) string {
+	if format == FormatProtobuf {
+		return "application/x-protobuf; messageType=google.protobuf.Struct"
+	}
+	return "application/json"
+}
@@ -0,0 +1,67 @@
+package kafkasink
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/steipete/wacli/internal/webhook"
+)
+
+func TestEncodeKeysByChatJID(t *testing.T) {
+	event := &webhook.Event{
+		Type:      "message.received",
+		Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Data:      map[string]string{"chat_jid": "123@s.whatsapp.net", "text": "hi"},
+	}
+
+	msg, err := encode(event, FormatJSON)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if string(msg.Key) != "123@s.whatsapp.net" {
+		t.Fatalf("expected chat JID key, got %q", msg.Key)
+	}
+	var got webhook.Event
+	if err := json.Unmarshal(msg.Value, &got); err != nil || got.Type != "message.received" {
+		t.Fatalf("unexpected JSON value %s (%v)", msg.Value, err)
+	}
+
+	msg, err = encode(&webhook.Event{Type: "state.connected", Data: map[string]string{"to": "connected"}}, FormatJSON)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if msg.Key != nil {
+		t.Fatalf("expected no key for chat-less event, got %q", msg.Key)
+	}
+}
+
+func TestEncodeProtobuf(t *testing.T) {
+	event := &webhook.Event{
+		Type: "group.participant_changed",
+		Data: map[string]interface{}{"group_jid": "42@g.us", "participants": []string{"1@s.whatsapp.net"}},
+	}
+
+	msg, err := encode(event, FormatProtobuf)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if string(msg.Key) != "42@g.us" {
+		t.Fatalf("expected group JID key, got %q", msg.Key)
+	}
+
+	var s structpb.Struct
+	if err := proto.Unmarshal(msg.Value, &s); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if s.Fields["type"].GetStringValue() != "group.participant_changed" {
+		t.Fatalf("unexpected type field: %v", s.Fields["type"])
+	}
+	data := s.Fields["data"].GetStructValue()
+	if data.GetFields()["group_jid"].GetStringValue() != "42@g.us" {
+		t.Fatalf("unexpected data: %v", data)
+	}
+}
@@ -0,0 +1,164 @@
+// Package mqttsink publishes service events to an MQTT broker, for
+// home-automation setups such as Home Assistant or Node-RED.
+package mqttsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/steipete/wacli/internal/webhook"
+)
+
+const (
+	publishTimeout = 5 * time.Second
+	statePrefix    = "state."
+	offlineStatus  = "offline"
+)
+
+// Config holds MQTT sink configuration.
+type Config struct {
+	BrokerURL   string // tcp://host:1883, ssl://host:8883 or ws://host/mqtt
+	ClientID    string
+	Username    string
+	Password    string
+	TopicPrefix string   // events go to <prefix>/<event type with / separators>
+	Events      []string // event filter; empty means all
+	QoS         byte
+}
+
+// Publisher publishes events to MQTT. Besides one topic per event type it
+// keeps the retained topic <prefix>/status set to the current service state,
+// or "offline" (via the last will) when the service goes away.
+type Publisher struct {
+	config Config
+	sink   *webhook.Sink
+	client mqtt.Client
+
+	statusMu sync.Mutex
+	status   string // last state seen; republished on reconnect
+}
+
+// NewPublisher creates a new MQTT publisher.
+func NewPublisher(cfg Config) *Publisher {
+	if cfg.ClientID == "" {
+		cfg.ClientID = "wasvc"
+	}
+	if cfg.TopicPrefix == "" {
+		cfg.TopicPrefix = "wasvc"
+	}
+	cfg.TopicPrefix = strings.TrimSuffix(cfg.TopicPrefix, "/")
+	if cfg.QoS > 2 {
+		cfg.QoS = 1
+	}
+
+	p := &Publisher{config: cfg}
+	p.sink = webhook.NewSink(webhook.SinkConfig{
+		Name: "MQTT",
+		// State events always update the status topic.
+		Filter: func(eventType string) bool {
+			return strings.HasPrefix(eventType, statePrefix) || webhook.MatchEvent(cfg.Events, eventType)
+		},
+		Publish: p.publish,
+	})
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(cfg.BrokerURL).
+		SetClientID(cfg.ClientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetWill(p.statusTopic(), offlineStatus, cfg.QoS, true).
+		SetOnConnectHandler(p.onConnect).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			log.Printf("[MQTT] Connection lost: %v", err)
+		})
+	p.client = mqtt.NewClient(opts)
+	return p
+}
+
+// Start connects to the broker (retrying in the background) and begins
+// publishing.
+func (p *Publisher) Start() {
+	p.client.Connect()
+	p.sink.Start()
+	log.Printf("[MQTT] Publishing to %s/# on %s", p.config.TopicPrefix, p.config.BrokerURL)
+}
+
+// Stop publishes the queued events, marks the service offline and
+// disconnects.
+func (p *Publisher) Stop() {
+	p.sink.Stop()
+	if p.client.IsConnected() {
+		p.client.Publish(p.statusTopic(), p.config.QoS, true, offlineStatus).WaitTimeout(publishTimeout)
+	}
+	p.client.Disconnect(250)
+	log.Println("[MQTT] Stopped")
+}
+
+// Emit queues an event if it matches the configured filter.
+func (p *Publisher) Emit(eventType string, data interface{}) {
+	if strings.HasPrefix(eventType, statePrefix) {
+		p.statusMu.Lock()
+		p.status = strings.TrimPrefix(eventType, statePrefix)
+		p.statusMu.Unlock()
+	}
+	p.sink.Emit(eventType, data)
+}
+
+// onConnect republishes the retained status after every (re)connect.
+func (p *Publisher) onConnect(c mqtt.Client) {
+	log.Printf("[MQTT] Connected to %s", p.config.BrokerURL)
+	p.statusMu.Lock()
+	status := p.status
+	p.statusMu.Unlock()
+	if status == "" {
+		status = "online"
+	}
+	c.Publish(p.statusTopic(), p.config.QoS, true, status)
+}
+
+// publish sends the event to its topic; state events also update the
+// retained status topic.
+func (p *Publisher) publish(_ context.Context, event *webhook.Event) error {
+	if strings.HasPrefix(event.Type, statePrefix) {
+		status := strings.TrimPrefix(event.Type, statePrefix)
+		if err := p.send(p.statusTopic(), true, []byte(status)); err != nil {
+			return err
+		}
+		if !webhook.MatchEvent(p.config.Events, event.Type) {
+			return nil
+		}
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	return p.send(Topic(p.config.TopicPrefix, event.Type), false, payload)
+}
+
+func (p *Publisher) send(topic string, retained bool, payload []byte) error {
+	token := p.client.Publish(topic, p.config.QoS, retained, payload)
+	if !token.WaitTimeout(publishTimeout) {
+		return fmt.Errorf("publish to %s timed out", topic)
+	}
+	return token.Error()
+}
+
+func (p *Publisher) statusTopic() string {
+	return p.config.TopicPrefix + "/status"
+}
+
+// Topic maps an event type to its MQTT topic, e.g. "message.received" under
+// prefix "wasvc" becomes "wasvc/message/received".
+func Topic(prefix, eventType string) string {
+	return prefix + "/" + strings.ReplaceAll(eventType, ".", "/")
+}
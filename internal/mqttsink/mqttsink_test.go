@@ -0,0 +1,155 @@
+package mqttsink
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/webhook"
+)
+
+func TestTopic(t *testing.T) {
+	cases := []struct{ prefix, eventType, want string }{
+		{"wasvc", "message.received", "wasvc/message/received"},
+		{"home/wa", "group.participant_changed", "home/wa/group/participant_changed"},
+		{"wasvc", "call.incoming", "wasvc/call/incoming"},
+	}
+	for _, c := range cases {
+		if got := Topic(c.prefix, c.eventType); got != c.want {
+			t.Fatalf("Topic(%q, %q) = %q, want %q", c.prefix, c.eventType, got, c.want)
+		}
+	}
+}
+
+type published struct {
+	topic    string
+	payload  []byte
+	retained bool
+}
+
+// fakeBroker speaks just enough MQTT 3.1.1 to accept a QoS 0 client and
+// record what it publishes.
+type fakeBroker struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	msgs []published
+	done chan struct{} // closed when the client hangs up
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	b := &fakeBroker{ln: ln, done: make(chan struct{})}
+	t.Cleanup(func() { _ = ln.Close() })
+	go b.serve()
+	return b
+}
+
+func (b *fakeBroker) serve() {
+	conn, err := b.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer close(b.done)
+	defer conn.Close()
+
+	rd := bufio.NewReader(conn)
+	for {
+		header, err := rd.ReadByte()
+		if err != nil {
+			return
+		}
+		// Remaining length is a base-128 varint.
+		var n, shift int
+		for {
+			c, err := rd.ReadByte()
+			if err != nil {
+				return
+			}
+			n |= int(c&0x7f) << shift
+			shift += 7
+			if c&0x80 == 0 {
+				break
+			}
+		}
+		body := make([]byte, n)
+		if _, err := io.ReadFull(rd, body); err != nil {
+			return
+		}
+
+		switch header >> 4 {
+		case 1: // CONNECT
+			_, _ = conn.Write([]byte{0x20, 0x02, 0x00, 0x00})
+		case 3: // PUBLISH, QoS 0: topic length, topic, payload
+			topicLen := int(body[0])<<8 | int(body[1])
+			b.mu.Lock()
+			b.msgs = append(b.msgs, published{
+				topic:    string(body[2 : 2+topicLen]),
+				payload:  body[2+topicLen:],
+				retained: header&0x01 != 0,
+			})
+			b.mu.Unlock()
+		case 12: // PINGREQ
+			_, _ = conn.Write([]byte{0xd0, 0x00})
+		case 14: // DISCONNECT
+			return
+		}
+	}
+}
+
+func (b *fakeBroker) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.msgs)
+}
+
+func TestPublisherTopicsAndFilter(t *testing.T) {
+	broker := newFakeBroker(t)
+	p := NewPublisher(Config{BrokerURL: "tcp://" + broker.ln.Addr().String(), TopicPrefix: "home/wa/", Events: []string{"message.*"}})
+	p.Start()
+	// Connected once the initial status arrives.
+	deadline := time.Now().Add(5 * time.Second)
+	for broker.count() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("client did not connect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	p.Emit("message.received", map[string]string{"chat_jid": "123@s.whatsapp.net"})
+	p.Emit("group.joined", map[string]string{"group_jid": "42@g.us"})
+	p.Emit("state.connected", nil)
+	p.Stop()
+	<-broker.done
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	var events, statuses []string
+	for _, m := range broker.msgs {
+		if m.topic == "home/wa/status" {
+			if !m.retained {
+				t.Fatalf("status %q not retained", m.payload)
+			}
+			statuses = append(statuses, string(m.payload))
+			continue
+		}
+		events = append(events, m.topic)
+		var ev webhook.Event
+		if err := json.Unmarshal(m.payload, &ev); err != nil || Topic("home/wa", ev.Type) != m.topic {
+			t.Fatalf("payload on %s: %s (%v)", m.topic, m.payload, err)
+		}
+	}
+	// Filtered events are not published; state events only set the status.
+	if len(events) != 1 || events[0] != "home/wa/message/received" {
+		t.Fatalf("event topics %v, want [home/wa/message/received]", events)
+	}
+	if len(statuses) != 3 || statuses[0] != "online" || statuses[1] != "connected" || statuses[2] != "offline" {
+		t.Fatalf("statuses %v, want online, connected, offline", statuses)
+	}
+}
@@ -0,0 +1,150 @@
+// Package natssink publishes service events to NATS, optionally persisted in
+// a JetStream stream.
+package natssink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/steipete/wacli/internal/webhook"
+)
+
+// publishTimeout bounds how long a JetStream publish waits for its ack.
+const publishTimeout = 5 * time.Second
+
+// Config holds NATS sink configuration.
+type Config struct {
+	URL           string
+	CredsFile     string   // optional .creds file for NATS auth
+	SubjectPrefix string   // events go to <prefix>.<event type>, e.g. wasvc.message.received
+	Events        []string // event filter; empty means all
+	JetStream     bool     // publish through JetStream so events are persisted
+	Stream        string   // JetStream stream, created or updated to cover <prefix>.>
+}
+
+// Publisher publishes events to NATS.
+type Publisher struct {
+	config Config
+	sink   *webhook.Sink
+	nc     *nats.Conn
+	js     jetstream.JetStream
+}
+
+// NewPublisher creates a new NATS publisher.
+func NewPublisher(cfg Config) *Publisher {
+	if cfg.SubjectPrefix == "" {
+		cfg.SubjectPrefix = "wasvc"
+	}
+	cfg.SubjectPrefix = strings.TrimSuffix(cfg.SubjectPrefix, ".")
+	if cfg.Stream == "" {
+		cfg.Stream = "WASVC"
+	}
+
+	p := &Publisher{config: cfg}
+	p.sink = webhook.NewSink(webhook.SinkConfig{Name: "NATS", Events: cfg.Events, Publish: p.publish})
+	return p
+}
+
+// Start connects to NATS and begins publishing. The connection keeps
+// retrying in the background if the server is not reachable yet.
+func (p *Publisher) Start() error {
+	opts := []nats.Option{
+		nats.Name("wasvc"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				log.Printf("[NATS] Disconnected: %v", err)
+			}
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			log.Printf("[NATS] Reconnected to %s", nc.ConnectedUrl())
+		}),
+	}
+	if p.config.CredsFile != "" {
+		opts = append(opts, nats.UserCredentials(p.config.CredsFile))
+	}
+
+	nc, err := nats.Connect(p.config.URL, opts...)
+	if err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	p.nc = nc
+
+	if p.config.JetStream {
+		js, err := jetstream.New(nc)
+		if err != nil {
+			nc.Close()
+			return fmt.Errorf("jetstream: %w", err)
+		}
+		p.js = js
+		if nc.IsConnected() {
+			p.ensureStream(context.Background())
+		}
+	}
+
+	p.sink.Start()
+	log.Printf("[NATS] Publishing to %s.> on %s (jetstream: %v)", p.config.SubjectPrefix, p.config.URL, p.config.JetStream)
+	return nil
+}
+
+// Stop publishes the queued events, flushes them to the server and closes
+// the connection.
+func (p *Publisher) Stop() {
+	p.sink.Stop()
+	if p.nc != nil {
+		if err := p.nc.Drain(); err != nil {
+			p.nc.Close()
+		}
+	}
+	log.Println("[NATS] Stopped")
+}
+
+// Emit queues an event if it matches the configured filter.
+func (p *Publisher) Emit(eventType string, data interface{}) {
+	p.sink.Emit(eventType, data)
+}
+
+// ensureStream creates the JetStream stream or widens it to our subjects.
+func (p *Publisher) ensureStream(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+	_, err := p.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     p.config.Stream,
+		Subjects: []string{p.config.SubjectPrefix + ".>"},
+	})
+	if err != nil {
+		log.Printf("[NATS] Failed to ensure stream %s: %v", p.config.Stream, err)
+	}
+}
+
+// publish sends one event to <prefix>.<event type>.
+func (p *Publisher) publish(ctx context.Context, event *webhook.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	subject := p.config.SubjectPrefix + "." + event.Type
+
+	if p.js == nil {
+		return p.nc.Publish(subject, payload)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+	_, err = p.js.Publish(ctx, subject, payload)
+	if errors.Is(err, jetstream.ErrNoStreamResponse) {
+		// Stream missing (first connect failed or it was deleted); create and retry once.
+		p.ensureStream(ctx)
+		_, err = p.js.Publish(ctx, subject, payload)
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+package natssink
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/steipete/wacli/internal/webhook"
+)
+
+// fakeServer speaks just enough of the NATS client protocol to accept a
+// connection and record the messages published on it.
+type fakeServer struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	msgs map[string][]byte // subject -> payload
+	done chan struct{}     // closed when the client hangs up
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	s := &fakeServer{ln: ln, msgs: map[string][]byte{}, done: make(chan struct{})}
+	t.Cleanup(func() { _ = ln.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeServer) url() string { return "nats://" + s.ln.Addr().String() }
+
+func (s *fakeServer) serve() {
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer close(s.done)
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"fake\",\"version\":\"2.10.0\",\"proto\":1,\"max_payload\":1048576}\r\n")
+	rd := bufio.NewReader(conn)
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "PING":
+			fmt.Fprintf(conn, "PONG\r\n")
+		case "PUB":
+			n, _ := strconv.Atoi(fields[len(fields)-1])
+			payload := make([]byte, n+2) // payload and CRLF
+			if _, err := io.ReadFull(rd, payload); err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.msgs[fields[1]] = payload[:n]
+			s.mu.Unlock()
+		}
+	}
+}
+
+func TestPublisherPublishesMatchingEventsBySubject(t *testing.T) {
+	srv := newFakeServer(t)
+	p := NewPublisher(Config{URL: srv.url(), SubjectPrefix: "wa.", Events: []string{"message.*"}})
+	if err := p.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	p.Emit("message.received", map[string]string{"chat_jid": "123@s.whatsapp.net"})
+	p.Emit("group.joined", map[string]string{"group_jid": "42@g.us"})
+	p.Emit("message.sent", map[string]string{"msg_id": "ABC"})
+	// Stop publishes what is still queued before closing the connection.
+	p.Stop()
+	<-srv.done
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.msgs) != 2 {
+		t.Fatalf("published subjects %v, want wa.message.received and wa.message.sent", srv.msgs)
+	}
+	var ev webhook.Event
+	if err := json.Unmarshal(srv.msgs["wa.message.received"], &ev); err != nil || ev.Type != "message.received" {
+		t.Fatalf("wa.message.received payload %s (%v)", srv.msgs["wa.message.received"], err)
+	}
+	if _, ok := srv.msgs["wa.message.sent"]; !ok {
+		t.Fatalf("wa.message.sent not published: %v", srv.msgs)
+	}
+}
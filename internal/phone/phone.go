@@ -0,0 +1,91 @@
+// Package phone normalizes phone numbers to E.164 so callers can accept
+// numbers in local formats.
+package phone
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// E.164 bounds on the number of digits including the country code.
+const (
+	minDigits = 7
+	maxDigits = 15
+)
+
+var defaultCC atomic.Value // string
+
+// SetDefaultCountryCode sets the country code used by Normalize for numbers
+// written in national format. An empty code disables national numbers.
+func SetDefaultCountryCode(cc string) error {
+	cc, err := ParseCountryCode(cc)
+	if err != nil {
+		return err
+	}
+	defaultCC.Store(cc)
+	return nil
+}
+
+// DefaultCountryCode returns the code set by SetDefaultCountryCode.
+func DefaultCountryCode() string {
+	cc, _ := defaultCC.Load().(string)
+	return cc
+}
+
+// ParseCountryCode validates a calling code such as "49" or "+49" and
+// returns its digits.
+func ParseCountryCode(s string) (string, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "+")
+	if s == "" {
+		return "", nil
+	}
+	if len(s) > 3 || s[0] == '0' || strings.Trim(s, "0123456789") != "" {
+		return "", fmt.Errorf("invalid country code: %s", s)
+	}
+	return s, nil
+}
+
+// Normalize is NormalizeWith using the default country code.
+func Normalize(s string) (string, error) {
+	return NormalizeWith(s, DefaultCountryCode())
+}
+
+// NormalizeWith turns a phone number into its E.164 digits without the
+// leading +. Spaces, dashes, dots, slashes and parentheses are ignored.
+// Numbers starting with + or 00 are international; otherwise, with a
+// country code cc set, a leading trunk 0 is replaced by cc, and in the
+// North American plan (cc 1) a 10-digit number gets the 1 prepended. Other
+// numbers are taken to already include their country code.
+func NormalizeWith(s, cc string) (string, error) {
+	raw := strings.TrimSpace(s)
+	var b strings.Builder
+	for i, r := range raw {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0, r == ' ', r == '-', r == '.', r == '/', r == '(', r == ')':
+		default:
+			return "", fmt.Errorf("invalid phone number: %s", s)
+		}
+	}
+	digits := b.String()
+
+	switch {
+	case strings.HasPrefix(raw, "+"):
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	case strings.HasPrefix(digits, "0"):
+		if cc == "" {
+			return "", fmt.Errorf("phone number %s is in national format; use +<country code> or set a default country code", s)
+		}
+		digits = cc + digits[1:]
+	case cc == "1" && len(digits) == 10:
+		digits = cc + digits
+	}
+
+	if len(digits) < minDigits || len(digits) > maxDigits || digits[0] == '0' {
+		return "", fmt.Errorf("invalid phone number: %s", s)
+	}
+	return digits, nil
+}
@@ -0,0 +1,55 @@
+package phone
+
+import "testing"
+
+func TestNormalizeWith(t *testing.T) {
+	cases := []struct {
+		in, cc, want string
+	}{
+		{"+49 151 1234 5678", "", "4915112345678"},
+		{"0049 151 1234 5678", "", "4915112345678"},
+		{"4915112345678", "", "4915112345678"},
+		{"0151 1234-5678", "49", "4915112345678"},
+		{"(0151) 1234/5678", "49", "4915112345678"},
+		{"+1 (555) 123-4567", "49", "15551234567"},
+		{"(555) 123-4567", "1", "15551234567"},
+		{"1 555.123.4567", "1", "15551234567"},
+		{"447911123456", "49", "447911123456"},
+	}
+	for _, c := range cases {
+		got, err := NormalizeWith(c.in, c.cc)
+		if err != nil {
+			t.Fatalf("NormalizeWith(%q, %q): %v", c.in, c.cc, err)
+		}
+		if got != c.want {
+			t.Fatalf("NormalizeWith(%q, %q) = %q, want %q", c.in, c.cc, got, c.want)
+		}
+	}
+}
+
+func TestNormalizeWithInvalid(t *testing.T) {
+	for _, in := range []string{"", "123", "0151 12345678", "+49 151 abc", "49+151", "+0123456789", "1234567890123456"} {
+		if got, err := NormalizeWith(in, ""); err == nil {
+			t.Fatalf("NormalizeWith(%q) = %q, expected error", in, got)
+		}
+	}
+}
+
+func TestDefaultCountryCode(t *testing.T) {
+	defer SetDefaultCountryCode("")
+	if err := SetDefaultCountryCode("+44"); err != nil {
+		t.Fatalf("SetDefaultCountryCode: %v", err)
+	}
+	got, err := Normalize("07911 123456")
+	if err != nil || got != "447911123456" {
+		t.Fatalf("Normalize = %q, %v", got, err)
+	}
+	for _, cc := range []string{"0", "1234", "4a"} {
+		if err := SetDefaultCountryCode(cc); err == nil {
+			t.Fatalf("expected error for country code %q", cc)
+		}
+	}
+	if DefaultCountryCode() != "44" {
+		t.Fatalf("invalid code replaced default: %q", DefaultCountryCode())
+	}
+}
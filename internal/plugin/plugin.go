@@ -0,0 +1,185 @@
+// Package plugin runs external programs or HTTP services on inbound messages
+// and collects the actions they ask for, so custom logic can live outside the
+// service.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/webhook"
+)
+
+// maxResponseBytes caps how much of a plugin's answer is read.
+const maxResponseBytes = 1 << 20
+
+// Action types a plugin can return.
+const (
+	ActionReply       = "reply"        // send Text back to the chat
+	ActionTag         = "tag"          // add Tag to the sender's contact
+	ActionDropWebhook = "drop_webhook" // do not deliver the message to webhooks and sinks
+)
+
+// Plugin is one configured plugin: a command run with "sh -c", or an HTTP
+// endpoint.
+type Plugin struct {
+	Name    string `json:"name"`
+	Command string `json:"command,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Secret  string `json:"secret,omitempty"` // signs HTTP requests like webhooks
+}
+
+// String returns the plugin's name, or what it runs if it has none.
+func (p Plugin) String() string {
+	switch {
+	case p.Name != "":
+		return p.Name
+	case p.URL != "":
+		return p.URL
+	default:
+		return p.Command
+	}
+}
+
+// Action is something a plugin asks the service to do.
+type Action struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+	Tag  string `json:"tag,omitempty"`
+}
+
+// Response is what a plugin writes to stdout or returns as the HTTP body.
+// An empty answer means no actions.
+type Response struct {
+	Actions []Action `json:"actions"`
+}
+
+// Config holds plugin configuration.
+type Config struct {
+	Plugins []Plugin
+	Timeout time.Duration // per plugin call
+}
+
+// Runner calls the configured plugins.
+type Runner struct {
+	config Config
+	client *http.Client
+}
+
+// NewRunner creates a new plugin runner.
+func NewRunner(cfg Config) *Runner {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	return &Runner{
+		config: cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Run passes the event to every plugin in order and returns their actions in
+// the same order. A plugin that fails or times out is logged and skipped.
+func (r *Runner) Run(ctx context.Context, event *webhook.Event) []Action {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("[Plugin] Encode %s event: %v", event.Type, err)
+		return nil
+	}
+	var actions []Action
+	for _, p := range r.config.Plugins {
+		resp, err := r.call(ctx, p, event.Type, payload)
+		if err != nil {
+			log.Printf("[Plugin] %s failed: %v", p, err)
+			continue
+		}
+		actions = append(actions, resp.Actions...)
+	}
+	return actions
+}
+
+func (r *Runner) call(ctx context.Context, p Plugin, eventType string, payload []byte) (*Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.Timeout)
+	defer cancel()
+
+	var out []byte
+	var err error
+	if p.URL != "" {
+		out, err = r.post(ctx, p, payload)
+	} else {
+		out, err = r.exec(ctx, p, eventType, payload)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var resp Response
+	if len(bytes.TrimSpace(out)) == 0 {
+		return &resp, nil
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	return &resp, nil
+}
+
+// exec runs a command plugin with the event on stdin and reads the response
+// from stdout; stderr is logged.
+func (r *Runner) exec(ctx context.Context, p Plugin, eventType string, payload []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", p.Command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(), "WASVC_EVENT_TYPE="+eventType)
+	cmd.WaitDelay = time.Second
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		if len(msg) > 512 {
+			msg = msg[:512] + "..."
+		}
+		log.Printf("[Plugin] %s: %s", p, msg)
+	}
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, context.DeadlineExceeded
+		}
+		return nil, err
+	}
+	if stdout.Len() > maxResponseBytes {
+		return nil, fmt.Errorf("response larger than %d bytes", maxResponseBytes)
+	}
+	return stdout.Bytes(), nil
+}
+
+// post sends the event to an HTTP plugin. Any 2xx status is accepted; 204
+// means no actions.
+func (r *Runner) post(ctx context.Context, p Plugin, payload []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "wasvc-plugin/1.0")
+	if p.Secret != "" {
+		req.Header.Set("X-Webhook-Signature", webhook.Sign(payload, p.Secret))
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+}
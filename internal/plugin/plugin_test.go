@@ -0,0 +1,85 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/webhook"
+)
+
+func testEvent() *webhook.Event {
+	return &webhook.Event{Type: "message.received", Timestamp: time.Now().UTC(), Data: map[string]string{"text": "hi"}}
+}
+
+func TestRunCommandPlugin(t *testing.T) {
+	in := filepath.Join(t.TempDir(), "event.json")
+	r := NewRunner(Config{Plugins: []Plugin{{
+		Name:    "echo",
+		Command: `cat > ` + in + `; echo '{"actions":[{"type":"reply","text":"hello"},{"type":"tag","tag":"lead"}]}'`,
+	}}})
+
+	actions := r.Run(context.Background(), testEvent())
+	if len(actions) != 2 || actions[0].Type != ActionReply || actions[0].Text != "hello" || actions[1].Tag != "lead" {
+		t.Fatalf("unexpected actions: %+v", actions)
+	}
+	raw, err := os.ReadFile(in)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var got webhook.Event
+	if err := json.Unmarshal(raw, &got); err != nil || got.Type != "message.received" {
+		t.Fatalf("unexpected stdin payload: %s", raw)
+	}
+}
+
+func TestRunHTTPPluginSigns(t *testing.T) {
+	var sig, want string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sig, want = r.Header.Get("X-Webhook-Signature"), webhook.Sign(body, "s3cret")
+		_, _ = w.Write([]byte(`{"actions":[{"type":"drop_webhook"}]}`))
+	}))
+	defer srv.Close()
+
+	r := NewRunner(Config{Plugins: []Plugin{{URL: srv.URL, Secret: "s3cret"}}})
+	actions := r.Run(context.Background(), testEvent())
+	if len(actions) != 1 || actions[0].Type != ActionDropWebhook {
+		t.Fatalf("unexpected actions: %+v", actions)
+	}
+	if sig == "" || sig != want {
+		t.Fatalf("signature = %q, want %q", sig, want)
+	}
+}
+
+func TestRunSkipsFailingPlugins(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	r := NewRunner(Config{
+		Plugins: []Plugin{
+			{Command: "exit 1"},
+			{Command: "echo not json"},
+			{Command: "sleep 5"},
+			{URL: srv.URL},
+			{Command: `echo '{"actions":[{"type":"tag","tag":"ok"}]}'`},
+		},
+		Timeout: 200 * time.Millisecond,
+	})
+	start := time.Now()
+	actions := r.Run(context.Background(), testEvent())
+	if len(actions) != 1 || actions[0].Tag != "ok" {
+		t.Fatalf("unexpected actions: %+v", actions)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Fatalf("timeout not enforced, took %s", time.Since(start))
+	}
+}
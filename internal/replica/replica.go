@@ -0,0 +1,217 @@
+package replica
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// Batch is the payload streamed from a primary to a standby instance.
+// Messages are sent whole each time they, their star or their receipts
+// change; Deleted lists messages removed since the previous batch.
+type Batch struct {
+	Source   string       `json:"source,omitempty"`
+	Cursor   int64        `json:"cursor"`
+	Chats    []Chat       `json:"chats"`
+	Messages []Message    `json:"messages"`
+	Deleted  []MessageRef `json:"deleted,omitempty"`
+}
+
+// MessageRef identifies a message.
+type MessageRef struct {
+	ChatJID string `json:"chat_jid"`
+	MsgID   string `json:"msg_id"`
+}
+
+// Chat is a replicated chat row.
+type Chat struct {
+	JID           string    `json:"jid"`
+	Kind          string    `json:"kind"`
+	Name          string    `json:"name,omitempty"`
+	LastMessageTS time.Time `json:"last_message_ts,omitempty"`
+}
+
+// Message is a replicated message row, including media metadata so the
+// standby can download media itself after taking over.
+type Message struct {
+	ChatJID       string    `json:"chat_jid"`
+	ChatName      string    `json:"chat_name,omitempty"`
+	MsgID         string    `json:"msg_id"`
+	SenderJID     string    `json:"sender_jid,omitempty"`
+	SenderName    string    `json:"sender_name,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+	FromMe        bool      `json:"from_me"`
+	Text          string    `json:"text,omitempty"`
+	MediaType     string    `json:"media_type,omitempty"`
+	MediaCaption  string    `json:"media_caption,omitempty"`
+	Filename      string    `json:"filename,omitempty"`
+	MimeType      string    `json:"mime_type,omitempty"`
+	DirectPath    string    `json:"direct_path,omitempty"`
+	MediaKey      []byte    `json:"media_key,omitempty"`
+	FileSHA256    []byte    `json:"file_sha256,omitempty"`
+	FileEncSHA256 []byte    `json:"file_enc_sha256,omitempty"`
+	FileLength    uint64    `json:"file_length,omitempty"`
+	Duration      uint32    `json:"duration,omitempty"`
+	ReplyToID     string    `json:"reply_to_id,omitempty"`
+	ReplyToSender string    `json:"reply_to_sender,omitempty"`
+	EditedText    string    `json:"edited_text,omitempty"`
+	EditedAt      time.Time `json:"edited_at,omitempty"`
+	Revoked       bool      `json:"revoked,omitempty"`
+	StarredAt     time.Time `json:"starred_at,omitempty"` // zero when not starred
+	Receipts      []Receipt `json:"receipts,omitempty"`
+}
+
+// Receipt is the delivery state of a replicated message for one recipient.
+type Receipt struct {
+	RecipientJID string    `json:"recipient_jid"`
+	DeliveredAt  time.Time `json:"delivered_at,omitempty"`
+	ReadAt       time.Time `json:"read_at,omitempty"`
+	PlayedAt     time.Time `json:"played_at,omitempty"`
+}
+
+// ApplyResult summarizes an applied batch.
+type ApplyResult struct {
+	Chats    int `json:"chats"`
+	Messages int `json:"messages"`
+	Deleted  int `json:"deleted"`
+}
+
+// Apply writes a replicated batch into the local store. Rows are upserted and
+// deletions of missing messages ignored, so re-delivering a batch is harmless.
+func Apply(db store.Store, b Batch) (ApplyResult, error) {
+	var res ApplyResult
+	if db == nil {
+		return res, fmt.Errorf("store not available")
+	}
+	chats := map[string]bool{}
+	for _, c := range b.Chats {
+		if strings.TrimSpace(c.JID) == "" {
+			continue
+		}
+		if err := db.UpsertChat(c.JID, c.Kind, c.Name, c.LastMessageTS); err != nil {
+			return res, fmt.Errorf("upsert chat %s: %w", c.JID, err)
+		}
+		chats[c.JID] = true
+		res.Chats++
+	}
+	for _, m := range b.Messages {
+		if strings.TrimSpace(m.ChatJID) == "" || strings.TrimSpace(m.MsgID) == "" {
+			continue
+		}
+		// Messages reference chats; make sure the row exists even if the
+		// sender omitted it, without clobbering the kind of a known chat.
+		if !chats[m.ChatJID] {
+			if _, err := db.GetChat(m.ChatJID); store.IsNotFound(err) {
+				if err := db.UpsertChat(m.ChatJID, "unknown", m.ChatName, m.Timestamp); err != nil {
+					return res, fmt.Errorf("upsert chat %s: %w", m.ChatJID, err)
+				}
+			}
+			chats[m.ChatJID] = true
+		}
+		if err := db.UpsertMessage(store.UpsertMessageParams{
+			ChatJID:       m.ChatJID,
+			ChatName:      m.ChatName,
+			MsgID:         m.MsgID,
+			SenderJID:     m.SenderJID,
+			SenderName:    m.SenderName,
+			Timestamp:     m.Timestamp,
+			FromMe:        m.FromMe,
+			Text:          m.Text,
+			MediaType:     m.MediaType,
+			MediaCaption:  m.MediaCaption,
+			Filename:      m.Filename,
+			MimeType:      m.MimeType,
+			DirectPath:    m.DirectPath,
+			MediaKey:      m.MediaKey,
+			FileSHA256:    m.FileSHA256,
+			FileEncSHA256: m.FileEncSHA256,
+			FileLength:    m.FileLength,
+			Duration:      m.Duration,
+			ReplyToID:     m.ReplyToID,
+			ReplyToSender: m.ReplyToSender,
+		}); err != nil {
+			return res, fmt.Errorf("upsert message %s/%s: %w", m.ChatJID, m.MsgID, err)
+		}
+		if err := applyState(db, m); err != nil {
+			return res, fmt.Errorf("update message %s/%s: %w", m.ChatJID, m.MsgID, err)
+		}
+		res.Messages++
+	}
+	for _, ref := range b.Deleted {
+		if _, err := db.DeleteMessage(ref.ChatJID, ref.MsgID); err != nil {
+			return res, fmt.Errorf("delete message %s/%s: %w", ref.ChatJID, ref.MsgID, err)
+		}
+		res.Deleted++
+	}
+	return res, nil
+}
+
+// applyState copies the edit, revocation, star and receipts of a message,
+// which UpsertMessage leaves alone.
+func applyState(db store.Store, m Message) error {
+	if !m.EditedAt.IsZero() {
+		if _, err := db.EditMessage(m.ChatJID, m.MsgID, m.EditedText, m.EditedAt); err != nil {
+			return err
+		}
+	}
+	if m.Revoked {
+		if _, err := db.RevokeMessage(m.ChatJID, m.MsgID); err != nil {
+			return err
+		}
+	}
+	if err := db.SetMessageStarred(m.ChatJID, m.MsgID, !m.StarredAt.IsZero(), m.StarredAt); err != nil {
+		return err
+	}
+	for _, r := range m.Receipts {
+		// Receipts keep the first time seen per status, so replaying
+		// them in order of progress reproduces the primary's row.
+		for _, st := range []struct {
+			status string
+			at     time.Time
+		}{{store.ReceiptDelivered, r.DeliveredAt}, {store.ReceiptRead, r.ReadAt}, {store.ReceiptPlayed, r.PlayedAt}} {
+			if st.at.IsZero() {
+				continue
+			}
+			if err := db.AddMessageReceipts(m.ChatJID, r.RecipientJID, []string{m.MsgID}, st.status, st.at); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func messageFromStore(m store.ReplicaMessage) Message {
+	var receipts []Receipt
+	for _, r := range m.Receipts {
+		receipts = append(receipts, Receipt{RecipientJID: r.RecipientJID, DeliveredAt: r.DeliveredAt, ReadAt: r.ReadAt, PlayedAt: r.PlayedAt})
+	}
+	return Message{
+		ChatJID:       m.ChatJID,
+		ChatName:      m.ChatName,
+		MsgID:         m.MsgID,
+		SenderJID:     m.SenderJID,
+		SenderName:    m.SenderName,
+		Timestamp:     m.Timestamp,
+		FromMe:        m.FromMe,
+		Text:          m.Text,
+		MediaType:     m.MediaType,
+		MediaCaption:  m.MediaCaption,
+		Filename:      m.Filename,
+		MimeType:      m.MimeType,
+		DirectPath:    m.DirectPath,
+		MediaKey:      m.MediaKey,
+		FileSHA256:    m.FileSHA256,
+		FileEncSHA256: m.FileEncSHA256,
+		FileLength:    m.FileLength,
+		Duration:      m.Duration,
+		ReplyToID:     m.ReplyToID,
+		ReplyToSender: m.ReplyToSender,
+		EditedText:    m.EditedText,
+		EditedAt:      m.EditedAt,
+		Revoked:       m.Revoked,
+		StarredAt:     m.StarredAt,
+		Receipts:      receipts,
+	}
+}
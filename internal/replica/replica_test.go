@@ -0,0 +1,106 @@
+package replica
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+func openStore(t *testing.T, name string) *store.DB {
+	t.Helper()
+	db, err := store.Open(filepath.Join(t.TempDir(), name))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestSenderReplicatesEditsAndDeletes(t *testing.T) {
+	primary := openStore(t, "primary.db")
+	standby := openStore(t, "standby.db")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var b Batch
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, err := Apply(standby, b); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+	for _, db := range []*store.DB{primary, standby} {
+		if err := db.EnableChangeLog(); err != nil {
+			t.Fatalf("EnableChangeLog: %v", err)
+		}
+	}
+	s := NewSender(Config{URL: srv.URL}, primary)
+	defer s.cancel()
+
+	chat := "123@s.whatsapp.net"
+	base := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	if err := primary.UpsertChat(chat, "dm", "Alice", base); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	for _, id := range []string{"m1", "m2"} {
+		if err := primary.UpsertMessage(store.UpsertMessageParams{ChatJID: chat, MsgID: id, Timestamp: base, Text: id, FromMe: true}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+	if err := s.flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if n, _ := standby.CountMessages(); n != 2 {
+		t.Fatalf("standby has %d messages, want 2", n)
+	}
+
+	if _, err := primary.EditMessage(chat, "m1", "m1 edited", base.Add(time.Minute)); err != nil {
+		t.Fatalf("EditMessage: %v", err)
+	}
+	if err := primary.SetMessageStarred(chat, "m1", true, base.Add(time.Minute)); err != nil {
+		t.Fatalf("SetMessageStarred: %v", err)
+	}
+	if err := primary.AddMessageReceipts(chat, chat, []string{"m1"}, store.ReceiptRead, base.Add(2*time.Minute)); err != nil {
+		t.Fatalf("AddMessageReceipts: %v", err)
+	}
+	if _, err := primary.DeleteMessage(chat, "m2"); err != nil {
+		t.Fatalf("DeleteMessage: %v", err)
+	}
+	if err := s.flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	if pending, err := primary.MessageChangesAfter(0, 10); err != nil || len(pending) != 0 {
+		t.Fatalf("acknowledged changes left in the primary log: %+v (err=%v)", pending, err)
+	}
+
+	changes, err := standby.MessageChangesAfter(0, 10)
+	if err != nil {
+		t.Fatalf("MessageChangesAfter: %v", err)
+	}
+	var m1 *store.ReplicaMessage
+	for i, c := range changes {
+		if c.MsgID == "m2" && !c.Deleted {
+			t.Fatalf("m2 still stored on the standby")
+		}
+		if c.MsgID == "m1" {
+			m1 = &changes[i]
+		}
+	}
+	if m1 == nil || m1.EditedText != "m1 edited" || m1.StarredAt.IsZero() {
+		t.Fatalf("edit or star of m1 not replicated: %+v", m1)
+	}
+	if len(m1.Receipts) != 1 || m1.Receipts[0].Status != store.ReceiptRead {
+		t.Fatalf("receipt of m1 not replicated: %+v", m1.Receipts)
+	}
+	if n, _ := standby.CountMessages(); n != 1 {
+		t.Fatalf("standby has %d messages, want 1", n)
+	}
+}
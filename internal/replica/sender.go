@@ -0,0 +1,191 @@
+package replica
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// cursorName is the replication_cursors key used by the sender.
+const cursorName = "standby"
+
+// Config holds replication sender configuration.
+type Config struct {
+	URL       string // Full ingest URL, e.g. http://standby:8080/replica/ingest
+	APIKey    string // Sent as X-API-Key to the receiver
+	Secret    string // Optional HMAC secret for X-Replica-Signature
+	Interval  time.Duration
+	BatchSize int
+	Timeout   time.Duration
+}
+
+// Sender streams stored chats and messages, and later changes to them, to a
+// standby receiver.
+type Sender struct {
+	config Config
+	db     store.Store
+	client *http.Client
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewSender creates a new replication sender reading from db.
+func NewSender(cfg Config, db store.Store) *Sender {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Sender{
+		config: cfg,
+		db:     db,
+		client: &http.Client{Timeout: cfg.Timeout},
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start begins streaming in the background.
+func (s *Sender) Start() {
+	s.wg.Add(1)
+	go s.loop()
+	log.Printf("[Replica] Streaming to %s every %s", s.config.URL, s.config.Interval)
+}
+
+// Stop stops streaming and waits for the in-flight batch to finish.
+func (s *Sender) Stop() {
+	s.cancel()
+	s.wg.Wait()
+	log.Println("[Replica] Stopped")
+}
+
+func (s *Sender) loop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.flush(); err != nil {
+			log.Printf("[Replica] Sync failed: %v", err)
+		}
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// flush sends batches until the receiver has caught up or an error occurs.
+func (s *Sender) flush() error {
+	cursor, err := s.db.GetReplicationCursor(cursorName)
+	if err != nil {
+		return fmt.Errorf("read cursor: %w", err)
+	}
+
+	for s.ctx.Err() == nil {
+		rows, err := s.db.MessageChangesAfter(cursor, s.config.BatchSize)
+		if err != nil {
+			return fmt.Errorf("read messages: %w", err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		batch := Batch{Messages: make([]Message, 0, len(rows))}
+		seen := map[string]bool{}
+		for _, r := range rows {
+			if r.Deleted {
+				batch.Deleted = append(batch.Deleted, MessageRef{ChatJID: r.ChatJID, MsgID: r.MsgID})
+				continue
+			}
+			batch.Messages = append(batch.Messages, messageFromStore(r))
+			if seen[r.ChatJID] {
+				continue
+			}
+			seen[r.ChatJID] = true
+			if c, err := s.db.GetChat(r.ChatJID); err == nil {
+				batch.Chats = append(batch.Chats, Chat{
+					JID:           c.JID,
+					Kind:          c.Kind,
+					Name:          c.Name,
+					LastMessageTS: c.LastMessageTS,
+				})
+			}
+		}
+		batch.Cursor = rows[len(rows)-1].Seq
+
+		if err := s.send(batch); err != nil {
+			return err
+		}
+		if err := s.db.SetReplicationCursor(cursorName, batch.Cursor); err != nil {
+			return fmt.Errorf("save cursor: %w", err)
+		}
+		if err := s.db.PruneMessageChanges(batch.Cursor); err != nil {
+			return fmt.Errorf("prune change log: %w", err)
+		}
+		cursor = batch.Cursor
+	}
+	return nil
+}
+
+func (s *Sender) send(b Batch) error {
+	payload, err := json.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("marshal batch: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.config.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "wasvc-replica/1.0")
+	if s.config.APIKey != "" {
+		req.Header.Set("X-API-Key", s.config.APIKey)
+	}
+	if s.config.Secret != "" {
+		req.Header.Set("X-Replica-Signature", Sign(payload, s.config.Secret))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send batch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the HMAC-SHA256 signature of payload in "sha256=<hex>" form.
+func Sign(payload []byte, secret string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(payload)
+	return "sha256=" + hex.EncodeToString(h.Sum(nil))
+}
+
+// Verify reports whether signature matches payload for secret.
+func Verify(payload []byte, secret, signature string) bool {
+	return hmac.Equal([]byte(Sign(payload, secret)), []byte(signature))
+}
@@ -0,0 +1,161 @@
+// Package responder asks an OpenAI-compatible chat completions endpoint for
+// replies to inbound messages, and rate limits those replies per chat.
+package responder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxResponseBytes caps how much of a completion response is read.
+const maxResponseBytes = 1 << 20
+
+// Roles of a conversation turn.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Turn is one message of the conversation sent to the model.
+type Turn struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Config holds the completion endpoint settings.
+type Config struct {
+	URL          string // base URL, e.g. https://api.openai.com/v1
+	APIKey       string // sent as a bearer token if set
+	Model        string
+	SystemPrompt string // prepended to every conversation if set
+	MaxTokens    int    // zero leaves the limit to the server
+	Timeout      time.Duration
+}
+
+// Client requests completions.
+type Client struct {
+	config Config
+	client *http.Client
+}
+
+// NewClient creates a completion client.
+func NewClient(cfg Config) *Client {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return &Client{config: cfg, client: &http.Client{Timeout: cfg.Timeout}}
+}
+
+type completionRequest struct {
+	Model     string `json:"model"`
+	Messages  []Turn `json:"messages"`
+	MaxTokens int    `json:"max_tokens,omitempty"`
+}
+
+type completionResponse struct {
+	Choices []struct {
+		Message Turn `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Complete returns the model's reply to a conversation, oldest turn first.
+func (c *Client) Complete(ctx context.Context, turns []Turn) (string, error) {
+	messages := turns
+	if c.config.SystemPrompt != "" {
+		messages = append([]Turn{{Role: RoleSystem, Content: c.config.SystemPrompt}}, turns...)
+	}
+	body, err := json.Marshal(completionRequest{
+		Model:     c.config.Model,
+		Messages:  messages,
+		MaxTokens: c.config.MaxTokens,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	url := strings.TrimSuffix(c.config.URL, "/") + "/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+
+	var out completionResponse
+	jsonErr := json.Unmarshal(data, &out)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if jsonErr == nil && out.Error != nil && out.Error.Message != "" {
+			return "", fmt.Errorf("unexpected status: %d: %s", resp.StatusCode, out.Error.Message)
+		}
+		return "", fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+	if jsonErr != nil {
+		return "", fmt.Errorf("decode response: %w", jsonErr)
+	}
+	if len(out.Choices) == 0 {
+		return "", fmt.Errorf("no completion returned")
+	}
+	return strings.TrimSpace(out.Choices[0].Message.Content), nil
+}
+
+// Limiter allows at most a fixed number of events per key within a sliding
+// window.
+type Limiter struct {
+	limit  int
+	window time.Duration
+
+	mu     sync.Mutex
+	events map[string][]time.Time
+}
+
+// NewLimiter creates a limiter. A limit of zero or less allows everything.
+func NewLimiter(limit int, window time.Duration) *Limiter {
+	return &Limiter{limit: limit, window: window, events: make(map[string][]time.Time)}
+}
+
+// Allow records an event for key at now and reports whether it is within
+// the limit. Refused events are not recorded.
+func (l *Limiter) Allow(key string, now time.Time) bool {
+	if l.limit <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := now.Add(-l.window)
+	recent := l.events[key][:0]
+	for _, t := range l.events[key] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= l.limit {
+		l.events[key] = recent
+		return false
+	}
+	l.events[key] = append(recent, now)
+	return true
+}
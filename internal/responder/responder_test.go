@@ -0,0 +1,93 @@
+package responder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComplete(t *testing.T) {
+	var got completionRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"  We open at 9.\n"}}]}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(Config{URL: srv.URL + "/v1/", APIKey: "sk-test", Model: "small", SystemPrompt: "Be brief.", MaxTokens: 100})
+	reply, err := c.Complete(context.Background(), []Turn{
+		{Role: RoleUser, Content: "hi"},
+		{Role: RoleAssistant, Content: "hello"},
+		{Role: RoleUser, Content: "when do you open?"},
+	})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if reply != "We open at 9." {
+		t.Fatalf("reply = %q", reply)
+	}
+	if got.Model != "small" || got.MaxTokens != 100 || len(got.Messages) != 4 ||
+		got.Messages[0] != (Turn{Role: RoleSystem, Content: "Be brief."}) || got.Messages[3].Content != "when do you open?" {
+		t.Fatalf("unexpected request: %+v", got)
+	}
+}
+
+func TestCompleteErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch strings.TrimSuffix(r.URL.Path, "/chat/completions") {
+		case "/status":
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":{"message":"rate limit reached"}}`))
+		case "/empty":
+			_, _ = w.Write([]byte(`{"choices":[]}`))
+		default:
+			_, _ = w.Write([]byte(`not json`))
+		}
+	}))
+	defer srv.Close()
+
+	for path, want := range map[string]string{
+		"/status": "429: rate limit reached",
+		"/empty":  "no completion returned",
+		"/junk":   "decode response",
+	} {
+		_, err := NewClient(Config{URL: srv.URL + path}).Complete(context.Background(), []Turn{{Role: RoleUser, Content: "hi"}})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", path, err, want)
+		}
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(2, time.Hour)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if !l.Allow("a", now) || !l.Allow("a", now.Add(time.Minute)) {
+		t.Fatal("first two events should be allowed")
+	}
+	if l.Allow("a", now.Add(2*time.Minute)) {
+		t.Fatal("third event within the window should be refused")
+	}
+	if !l.Allow("b", now) {
+		t.Fatal("keys should be limited separately")
+	}
+	if !l.Allow("a", now.Add(time.Hour+time.Second)) {
+		t.Fatal("event after the window should be allowed")
+	}
+
+	if unlimited := NewLimiter(0, time.Hour); !unlimited.Allow("a", now) || !unlimited.Allow("a", now) {
+		t.Fatal("zero limit should allow everything")
+	}
+}
@@ -0,0 +1,233 @@
+// Package rules routes inbound messages with declarative rules: conditions
+// on the chat, sender, text and media type, and actions such as delivering
+// the message to a particular webhook, replying, tagging or ignoring it.
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Action types.
+const (
+	ActionWebhook = "webhook" // deliver the message only to URL (and other routed webhooks)
+	ActionReply   = "reply"   // send Text, a template, back to the chat
+	ActionTag     = "tag"     // add Tag to the sender's contact
+	ActionIgnore  = "ignore"  // drop the message; later rules are not evaluated
+)
+
+// mediaTypes are the values Conditions.MediaTypes accepts. "text" matches
+// messages without media; "gif" matches videos sent as GIFs.
+var mediaTypes = map[string]bool{"text": true, "image": true, "video": true, "gif": true, "audio": true, "document": true}
+
+// Conditions select the messages a rule applies to. All set fields must
+// match; a rule without conditions matches every message.
+type Conditions struct {
+	Chats      []string `json:"chats,omitempty"`       // chat JIDs
+	Senders    []string `json:"senders,omitempty"`     // sender JIDs
+	Text       string   `json:"text,omitempty"`        // regular expression, matched against text or caption
+	MediaTypes []string `json:"media_types,omitempty"` // text, image, video, gif, audio, document
+}
+
+// Action is what a matching rule does.
+type Action struct {
+	Type string `json:"type"`
+	URL  string `json:"url,omitempty"`
+	Text string `json:"text,omitempty"`
+	Tag  string `json:"tag,omitempty"`
+}
+
+// Rule is a parsed rule.
+type Rule struct {
+	ID         int64
+	Name       string
+	Conditions Conditions
+	Actions    []Action
+
+	text *regexp.Regexp
+}
+
+// Message is what rules are evaluated against.
+type Message struct {
+	ChatJID    string
+	ChatName   string
+	SenderJID  string
+	SenderName string
+	Text       string
+	Caption    string
+	MediaType  string // empty for text messages
+}
+
+// Result collects the actions of all matching rules, in rule order.
+type Result struct {
+	Matched  []string // names of the matching rules
+	Ignore   bool
+	Webhooks []string // webhook URLs to deliver to instead of all of them
+	Replies  []string // expanded reply texts
+	Tags     []string
+}
+
+// Parse decodes and validates a rule's JSON conditions and actions.
+func Parse(id int64, name, conditions, actions string) (Rule, error) {
+	r := Rule{ID: id, Name: name}
+	if err := json.Unmarshal([]byte(conditions), &r.Conditions); err != nil {
+		return Rule{}, fmt.Errorf("conditions: %w", err)
+	}
+	if err := json.Unmarshal([]byte(actions), &r.Actions); err != nil {
+		return Rule{}, fmt.Errorf("actions: %w", err)
+	}
+	if err := r.compile(); err != nil {
+		return Rule{}, err
+	}
+	return r, nil
+}
+
+// compile validates the rule and prepares its regular expression.
+func (r *Rule) compile() error {
+	if r.Conditions.Text != "" {
+		re, err := regexp.Compile(r.Conditions.Text)
+		if err != nil {
+			return fmt.Errorf("text: %w", err)
+		}
+		r.text = re
+	}
+	for _, t := range r.Conditions.MediaTypes {
+		if !mediaTypes[t] {
+			return fmt.Errorf("unknown media type %q", t)
+		}
+	}
+	if len(r.Actions) == 0 {
+		return errors.New("at least one action is required")
+	}
+	for i, a := range r.Actions {
+		var missing string
+		switch a.Type {
+		case ActionWebhook:
+			if strings.TrimSpace(a.URL) == "" {
+				missing = "url"
+			}
+		case ActionReply:
+			if strings.TrimSpace(a.Text) == "" {
+				missing = "text"
+			}
+		case ActionTag:
+			if strings.TrimSpace(a.Tag) == "" {
+				missing = "tag"
+			}
+		case ActionIgnore:
+		default:
+			return fmt.Errorf("action %d: unknown type %q", i+1, a.Type)
+		}
+		if missing != "" {
+			return fmt.Errorf("action %d: %s requires %s", i+1, a.Type, missing)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the rule applies to msg.
+func (r Rule) Matches(msg Message) bool {
+	c := r.Conditions
+	if len(c.Chats) > 0 && !contains(c.Chats, msg.ChatJID) {
+		return false
+	}
+	if len(c.Senders) > 0 && !contains(c.Senders, msg.SenderJID) {
+		return false
+	}
+	if len(c.MediaTypes) > 0 {
+		mediaType := msg.MediaType
+		if mediaType == "" {
+			mediaType = "text"
+		}
+		if !contains(c.MediaTypes, mediaType) {
+			return false
+		}
+	}
+	if r.text != nil && !r.text.MatchString(msg.Text) && !(msg.Caption != "" && r.text.MatchString(msg.Caption)) {
+		return false
+	}
+	return true
+}
+
+// Expand fills in the placeholders of a reply template: {chat_jid},
+// {chat_name}, {sender_jid}, {sender_name} and {text}.
+func Expand(template string, msg Message) string {
+	text := msg.Text
+	if text == "" {
+		text = msg.Caption
+	}
+	return strings.NewReplacer(
+		"{chat_jid}", msg.ChatJID,
+		"{chat_name}", msg.ChatName,
+		"{sender_jid}", msg.SenderJID,
+		"{sender_name}", msg.SenderName,
+		"{text}", text,
+	).Replace(template)
+}
+
+// Engine holds the active rules.
+type Engine struct {
+	mu    sync.RWMutex
+	rules []Rule // in evaluation order
+}
+
+// Load replaces the active rules. They are evaluated in the order given.
+func (e *Engine) Load(rules []Rule) {
+	e.mu.Lock()
+	e.rules = rules
+	e.mu.Unlock()
+}
+
+// Len returns the number of active rules.
+func (e *Engine) Len() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return len(e.rules)
+}
+
+// Evaluate applies the active rules to msg. Every matching rule contributes
+// its actions until one ignores the message.
+func (e *Engine) Evaluate(msg Message) Result {
+	e.mu.RLock()
+	rules := e.rules
+	e.mu.RUnlock()
+
+	var res Result
+	for _, r := range rules {
+		if !r.Matches(msg) {
+			continue
+		}
+		res.Matched = append(res.Matched, r.Name)
+		for _, a := range r.Actions {
+			switch a.Type {
+			case ActionWebhook:
+				if !contains(res.Webhooks, a.URL) {
+					res.Webhooks = append(res.Webhooks, a.URL)
+				}
+			case ActionReply:
+				res.Replies = append(res.Replies, Expand(a.Text, msg))
+			case ActionTag:
+				res.Tags = append(res.Tags, strings.TrimSpace(a.Tag))
+			case ActionIgnore:
+				res.Ignore = true
+			}
+		}
+		if res.Ignore {
+			break
+		}
+	}
+	return res
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
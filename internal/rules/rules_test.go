@@ -0,0 +1,89 @@
+package rules
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustParse(t *testing.T, id int64, name, conditions, actions string) Rule {
+	t.Helper()
+	r, err := Parse(id, name, conditions, actions)
+	if err != nil {
+		t.Fatalf("Parse(%s): %v", name, err)
+	}
+	return r
+}
+
+func TestParseRejectsInvalidRules(t *testing.T) {
+	cases := map[string][2]string{
+		"bad json":       {`{`, `[{"type":"ignore"}]`},
+		"bad regex":      {`{"text":"("}`, `[{"type":"ignore"}]`},
+		"bad media type": {`{"media_types":["sticker"]}`, `[{"type":"ignore"}]`},
+		"no actions":     {`{}`, `[]`},
+		"unknown action": {`{}`, `[{"type":"forward"}]`},
+		"webhook no url": {`{}`, `[{"type":"webhook"}]`},
+		"reply no text":  {`{}`, `[{"type":"reply","text":" "}]`},
+		"tag no tag":     {`{}`, `[{"type":"tag"}]`},
+	}
+	for name, c := range cases {
+		if _, err := Parse(1, name, c[0], c[1]); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	r := mustParse(t, 1, "invoices",
+		`{"chats":["1@s.whatsapp.net"],"text":"(?i)invoice","media_types":["text","document"]}`,
+		`[{"type":"tag","tag":"billing"}]`)
+
+	cases := []struct {
+		msg  Message
+		want bool
+	}{
+		{Message{ChatJID: "1@s.whatsapp.net", Text: "Where is my INVOICE?"}, true},
+		{Message{ChatJID: "1@s.whatsapp.net", MediaType: "document", Caption: "invoice 42"}, true},
+		{Message{ChatJID: "1@s.whatsapp.net", MediaType: "image", Caption: "invoice 42"}, false},
+		{Message{ChatJID: "2@s.whatsapp.net", Text: "invoice"}, false},
+		{Message{ChatJID: "1@s.whatsapp.net", Text: "hello"}, false},
+	}
+	for _, c := range cases {
+		if got := r.Matches(c.msg); got != c.want {
+			t.Errorf("Matches(%+v) = %v, want %v", c.msg, got, c.want)
+		}
+	}
+
+	all := mustParse(t, 2, "any", `{}`, `[{"type":"ignore"}]`)
+	if !all.Matches(Message{ChatJID: "x"}) {
+		t.Error("rule without conditions should match everything")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	var e Engine
+	e.Load([]Rule{
+		mustParse(t, 1, "support", `{"text":"(?i)help"}`,
+			`[{"type":"webhook","url":"http://support"},{"type":"reply","text":"Hi {sender_name}, we got: {text}"}]`),
+		mustParse(t, 2, "vip", `{"senders":["9@s.whatsapp.net"]}`,
+			`[{"type":"tag","tag":"vip"},{"type":"webhook","url":"http://support"},{"type":"webhook","url":"http://vip"}]`),
+		mustParse(t, 3, "mute", `{"chats":["muted@g.us"]}`, `[{"type":"tag","tag":"muted"},{"type":"ignore"}]`),
+		mustParse(t, 4, "after-mute", `{}`, `[{"type":"tag","tag":"seen"}]`),
+	})
+
+	res := e.Evaluate(Message{ChatJID: "9@s.whatsapp.net", SenderJID: "9@s.whatsapp.net", SenderName: "Ann", Text: "help please"})
+	want := Result{
+		Matched:  []string{"support", "vip", "after-mute"},
+		Webhooks: []string{"http://support", "http://vip"},
+		Replies:  []string{"Hi Ann, we got: help please"},
+		Tags:     []string{"vip", "seen"},
+	}
+	if !reflect.DeepEqual(res, want) {
+		t.Fatalf("Evaluate = %+v, want %+v", res, want)
+	}
+
+	res = e.Evaluate(Message{ChatJID: "muted@g.us", SenderJID: "1@s.whatsapp.net", Text: "help"})
+	if !res.Ignore || strings.Join(res.Matched, ",") != "support,mute" || strings.Join(res.Tags, ",") != "muted" {
+		t.Fatalf("Evaluate(muted) = %+v", res)
+	}
+}
@@ -0,0 +1,250 @@
+// Package script runs user-supplied JavaScript on inbound messages in an
+// embedded, sandboxed runtime. Scripts define an onMessage(msg) function and
+// can only reach the outside world through the small API the engine binds:
+// sendText, addTag and log. There is no file, network or timer access.
+package script
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// maxCallStackSize stops runaway recursion long before it exhausts memory.
+const maxCallStackSize = 1024
+
+// API is what scripts may do. The service implements it.
+type API interface {
+	SendText(ctx context.Context, to, text string) (string, error)
+	AddContactTag(jid, tag string) error
+}
+
+// Source is a script to load.
+type Source struct {
+	Name string
+	Code string
+}
+
+// Stats describes how a loaded script has fared since it was loaded.
+type Stats struct {
+	Runs      int64
+	Errors    int64
+	LastError string
+	LastRunAt time.Time
+}
+
+// Config holds engine configuration.
+type Config struct {
+	API     API
+	Timeout time.Duration // per onMessage call, including API calls
+}
+
+// Engine holds the loaded scripts and dispatches messages to them.
+type Engine struct {
+	config Config
+
+	mu      sync.RWMutex
+	scripts []*instance // in load order
+}
+
+// instance is one loaded script with its own runtime. A goja runtime is not
+// safe for concurrent use, so calls are serialized.
+type instance struct {
+	name    string
+	mu      sync.Mutex
+	vm      *goja.Runtime
+	handler goja.Callable
+	ctx     context.Context // of the running call, for API calls
+	stats   Stats
+}
+
+// NewEngine creates an engine with no scripts loaded.
+func NewEngine(cfg Config) *Engine {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	return &Engine{config: cfg}
+}
+
+// Check compiles a script and runs its top level against an API that does
+// nothing, reporting syntax errors, exceptions and a missing onMessage.
+func Check(name, code string, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	_, err := newInstance(name, code, nopAPI{}, timeout)
+	return err
+}
+
+// Load replaces the loaded scripts. Scripts that fail to load are skipped
+// and reported in the returned error; the others are loaded regardless.
+func (e *Engine) Load(sources []Source) error {
+	var loaded []*instance
+	var errs []error
+	for _, src := range sources {
+		inst, err := newInstance(src.Name, src.Code, e.config.API, e.config.Timeout)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		loaded = append(loaded, inst)
+	}
+
+	e.mu.Lock()
+	e.scripts = loaded
+	e.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// Len returns the number of loaded scripts.
+func (e *Engine) Len() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return len(e.scripts)
+}
+
+// Stats returns the stats of a loaded script.
+func (e *Engine) Stats(name string) (Stats, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for _, inst := range e.scripts {
+		if inst.name == name {
+			inst.mu.Lock()
+			defer inst.mu.Unlock()
+			return inst.stats, true
+		}
+	}
+	return Stats{}, false
+}
+
+// Dispatch calls onMessage of every loaded script, in order, with msg as a
+// plain object shaped like its JSON encoding. Script errors are logged.
+func (e *Engine) Dispatch(ctx context.Context, msg interface{}) {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("[Scripts] Encode message: %v", err)
+		return
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		log.Printf("[Scripts] Encode message: %v", err)
+		return
+	}
+
+	e.mu.RLock()
+	scripts := e.scripts
+	e.mu.RUnlock()
+	for _, inst := range scripts {
+		if err := inst.call(ctx, obj, e.config.Timeout); err != nil {
+			log.Printf("[Scripts] %s: %v", inst.name, err)
+		}
+	}
+}
+
+func (inst *instance) call(ctx context.Context, obj map[string]interface{}, timeout time.Duration) error {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	inst.ctx = ctx
+	stop := interruptOnDone(ctx, inst.vm)
+	defer stop()
+
+	_, err := inst.handler(goja.Undefined(), inst.vm.ToValue(obj))
+	inst.stats.Runs++
+	inst.stats.LastRunAt = time.Now().UTC()
+	if err != nil {
+		err = describe(err)
+		inst.stats.Errors++
+		inst.stats.LastError = err.Error()
+	}
+	return err
+}
+
+// newInstance sets up a runtime with the API bound, runs the script's top
+// level and looks up onMessage.
+func newInstance(name, code string, api API, timeout time.Duration) (*instance, error) {
+	prg, err := goja.Compile(name, code, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, describe(err))
+	}
+
+	inst := &instance{name: name, vm: goja.New(), ctx: context.Background()}
+	vm := inst.vm
+	vm.SetMaxCallStackSize(maxCallStackSize)
+	// A non-nil error is thrown as an exception in the script.
+	_ = vm.Set("sendText", func(to, text string) (string, error) {
+		return api.SendText(inst.ctx, to, text)
+	})
+	_ = vm.Set("addTag", func(jid, tag string) error {
+		return api.AddContactTag(jid, tag)
+	})
+	_ = vm.Set("log", func(call goja.FunctionCall) goja.Value {
+		args := make([]string, len(call.Arguments))
+		for i, a := range call.Arguments {
+			args[i] = a.String()
+		}
+		log.Printf("[Scripts] %s: %s", name, strings.Join(args, " "))
+		return goja.Undefined()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	stop := interruptOnDone(ctx, vm)
+	defer stop()
+	if _, err := vm.RunProgram(prg); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, describe(err))
+	}
+	handler, ok := goja.AssertFunction(vm.Get("onMessage"))
+	if !ok {
+		return nil, fmt.Errorf("%s: onMessage(msg) is not defined", name)
+	}
+	inst.handler = handler
+	return inst, nil
+}
+
+// interruptOnDone stops the runtime when ctx ends. The returned func must be
+// called once the runtime is idle again.
+func interruptOnDone(ctx context.Context, vm *goja.Runtime) func() {
+	done, exited := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-ctx.Done():
+			vm.Interrupt("timeout")
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited // so a late Interrupt cannot hit the next call
+		vm.ClearInterrupt()
+	}
+}
+
+// describe turns goja errors into one-line messages.
+func describe(err error) error {
+	var interrupted *goja.InterruptedError
+	var exception *goja.Exception
+	switch {
+	case errors.As(err, &interrupted):
+		return fmt.Errorf("timed out")
+	case errors.As(err, &exception):
+		return errors.New(exception.Error())
+	}
+	return err
+}
+
+// nopAPI backs Check, so validating a script has no side effects.
+type nopAPI struct{}
+
+func (nopAPI) SendText(context.Context, string, string) (string, error) { return "", nil }
+func (nopAPI) AddContactTag(string, string) error                       { return nil }
@@ -0,0 +1,121 @@
+package script
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeAPI struct {
+	sent []string
+	tags []string
+	err  error
+}
+
+func (f *fakeAPI) SendText(_ context.Context, to, text string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	f.sent = append(f.sent, to+": "+text)
+	return "MSGID", nil
+}
+
+func (f *fakeAPI) AddContactTag(jid, tag string) error {
+	f.tags = append(f.tags, jid+": "+tag)
+	return nil
+}
+
+const autoReply = `
+function onMessage(msg) {
+	if (msg.text.toLowerCase().indexOf("price") >= 0) {
+		var id = sendText(msg.chat_jid, "Our prices: https://example.com/prices");
+		addTag(msg.sender_jid, "lead");
+		log("replied", id);
+	}
+}
+`
+
+func TestDispatchCallsAPI(t *testing.T) {
+	api := &fakeAPI{}
+	e := NewEngine(Config{API: api, Timeout: time.Second})
+	if err := e.Load([]Source{{Name: "prices", Code: autoReply}}); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	e.Dispatch(context.Background(), map[string]string{"chat_jid": "1@s.whatsapp.net", "sender_jid": "2@s.whatsapp.net", "text": "What is the PRICE?"})
+	e.Dispatch(context.Background(), map[string]string{"chat_jid": "1@s.whatsapp.net", "text": "hello"})
+
+	if len(api.sent) != 1 || api.sent[0] != "1@s.whatsapp.net: Our prices: https://example.com/prices" {
+		t.Fatalf("sent = %v", api.sent)
+	}
+	if len(api.tags) != 1 || api.tags[0] != "2@s.whatsapp.net: lead" {
+		t.Fatalf("tags = %v", api.tags)
+	}
+	st, ok := e.Stats("prices")
+	if !ok || st.Runs != 2 || st.Errors != 0 {
+		t.Fatalf("stats = %+v, %v", st, ok)
+	}
+}
+
+func TestDispatchRecordsErrors(t *testing.T) {
+	api := &fakeAPI{err: errors.New("not connected")}
+	e := NewEngine(Config{API: api, Timeout: 200 * time.Millisecond})
+	err := e.Load([]Source{
+		{Name: "send", Code: `function onMessage(m) { sendText(m.chat_jid, "hi") }`},
+		{Name: "loop", Code: `function onMessage(m) { for (;;) {} }`},
+	})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	start := time.Now()
+	e.Dispatch(context.Background(), map[string]string{"chat_jid": "1@s.whatsapp.net"})
+	if time.Since(start) > 3*time.Second {
+		t.Fatalf("timeout not enforced, took %s", time.Since(start))
+	}
+	if st, _ := e.Stats("send"); st.Errors != 1 || !strings.Contains(st.LastError, "not connected") {
+		t.Fatalf("send stats = %+v", st)
+	}
+	if st, _ := e.Stats("loop"); st.Errors != 1 || st.LastError != "timed out" {
+		t.Fatalf("loop stats = %+v", st)
+	}
+
+	// The runtime is usable again after a timeout.
+	e.Dispatch(context.Background(), map[string]string{"chat_jid": "1@s.whatsapp.net"})
+	if st, _ := e.Stats("loop"); st.Runs != 2 {
+		t.Fatalf("loop runs = %d, want 2", st.Runs)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if err := Check("ok", autoReply, time.Second); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	for name, code := range map[string]string{
+		"syntax":   `function onMessage(m) {`,
+		"missing":  `var x = 1;`,
+		"throws":   `throw new Error("boom"); function onMessage(m) {}`,
+		"toplevel": `for (;;) {} function onMessage(m) {}`,
+		"not-a-fn": `var onMessage = 42;`,
+	} {
+		if err := Check(name, code, 200*time.Millisecond); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoadSkipsBrokenScripts(t *testing.T) {
+	e := NewEngine(Config{API: &fakeAPI{}})
+	err := e.Load([]Source{
+		{Name: "good", Code: `function onMessage(m) {}`},
+		{Name: "bad", Code: `function onMessage(m) {`},
+	})
+	if err == nil || !strings.Contains(err.Error(), "bad") {
+		t.Fatalf("Load error = %v, want one naming bad", err)
+	}
+	if e.Len() != 1 {
+		t.Fatalf("Len = %d, want 1", e.Len())
+	}
+}
@@ -0,0 +1,64 @@
+package sdnotify
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if err := Notify(Ready); err != nil {
+		t.Fatalf("Notify without socket: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram sockets unavailable: %v", err)
+	}
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	if err := Notify(Ready + "\n" + Status("connected")); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 256)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if got := string(buf[:n]); got != "READY=1\nSTATUS=connected" {
+		t.Fatalf("got %q", got)
+	}
+
+	t.Setenv("NOTIFY_SOCKET", filepath.Join(t.TempDir(), "missing.sock"))
+	if err := Notify(Ready); err == nil {
+		t.Fatal("expected an error for a missing socket")
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "")
+	t.Setenv("WATCHDOG_PID", "")
+	if d := WatchdogInterval(); d != 0 {
+		t.Fatalf("unset: %s", d)
+	}
+
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	if d := WatchdogInterval(); d != 30*time.Second {
+		t.Fatalf("WatchdogInterval = %s, want 30s", d)
+	}
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	if d := WatchdogInterval(); d != 30*time.Second {
+		t.Fatalf("own pid: %s, want 30s", d)
+	}
+	t.Setenv("WATCHDOG_PID", "1")
+	if d := WatchdogInterval(); d != 0 {
+		t.Fatalf("other pid: %s, want 0", d)
+	}
+}
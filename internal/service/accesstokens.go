@@ -0,0 +1,121 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// accessTokenPrefix marks access tokens so they are recognizable in logs
+// and are never looked up as API keys.
+const accessTokenPrefix = "wat_"
+
+// Access token scopes.
+const (
+	ScopeRead  = "read"  // GET requests
+	ScopeSend  = "send"  // sending messages
+	ScopeWrite = "write" // every other request, including sends
+)
+
+// ErrInvalidAccessToken is returned by VerifyAccessToken for malformed,
+// forged or expired tokens.
+var ErrInvalidAccessToken = errors.New("invalid access token")
+
+// AccessToken is a short-lived token signed by the service. It is not
+// stored, so it cannot be revoked before it expires.
+type AccessToken struct {
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+// accessTokenClaims is the signed part of an access token.
+type accessTokenClaims struct {
+	Scopes []string `json:"scp"`
+	Exp    int64    `json:"exp"`
+}
+
+// HasScope reports whether the token grants scope.
+func (t AccessToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateScopes checks that every entry is a known scope.
+func ValidateScopes(scopes []string) error {
+	for _, s := range scopes {
+		switch s {
+		case ScopeRead, ScopeSend, ScopeWrite:
+		default:
+			return fmt.Errorf("unknown scope %q: want read, send or write", s)
+		}
+	}
+	return nil
+}
+
+// IssueAccessToken returns a token granting scopes until ttl has passed.
+// A zero ttl, or one over WASVC_ACCESS_TOKEN_TTL, is capped to it. Without
+// scopes the token is read-only.
+func (m *Manager) IssueAccessToken(scopes []string, ttl time.Duration) (string, AccessToken, error) {
+	if !m.config.APIKeyAuth() {
+		return "", AccessToken{}, ErrAPIKeyAuthDisabled
+	}
+	if len(scopes) == 0 {
+		scopes = []string{ScopeRead}
+	}
+	if err := ValidateScopes(scopes); err != nil {
+		return "", AccessToken{}, err
+	}
+	if ttl <= 0 || ttl > m.config.AccessTokenTTL {
+		ttl = m.config.AccessTokenTTL
+	}
+
+	expiresAt := time.Now().UTC().Add(ttl).Truncate(time.Second)
+	payload, err := json.Marshal(accessTokenClaims{Scopes: scopes, Exp: expiresAt.Unix()})
+	if err != nil {
+		return "", AccessToken{}, err
+	}
+	body := base64.RawURLEncoding.EncodeToString(payload)
+	token := accessTokenPrefix + body + "." + m.accessTokenSignature(body)
+	return token, AccessToken{Scopes: scopes, ExpiresAt: expiresAt}, nil
+}
+
+// VerifyAccessToken checks the signature and expiry of an access token and
+// returns what it grants.
+func (m *Manager) VerifyAccessToken(token string) (AccessToken, error) {
+	body, sig, ok := strings.Cut(strings.TrimPrefix(token, accessTokenPrefix), ".")
+	if !ok || !strings.HasPrefix(token, accessTokenPrefix) {
+		return AccessToken{}, ErrInvalidAccessToken
+	}
+	if !hmac.Equal([]byte(sig), []byte(m.accessTokenSignature(body))) {
+		return AccessToken{}, ErrInvalidAccessToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return AccessToken{}, ErrInvalidAccessToken
+	}
+	var c accessTokenClaims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return AccessToken{}, ErrInvalidAccessToken
+	}
+	t := AccessToken{Scopes: c.Scopes, ExpiresAt: time.Unix(c.Exp, 0).UTC()}
+	if !time.Now().Before(t.ExpiresAt) {
+		return AccessToken{}, ErrInvalidAccessToken
+	}
+	return t, nil
+}
+
+func (m *Manager) accessTokenSignature(body string) string {
+	h := hmac.New(sha256.New, m.accessTokenKey)
+	h.Write([]byte(body))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// apiKeyPrefix marks keys issued by RotateAPIKey so they are recognizable
+// in logs and config files.
+const apiKeyPrefix = "wak_"
+
+// apiKeyHintLen is how much of an issued key is kept to tell keys apart.
+const apiKeyHintLen = len(apiKeyPrefix) + 4
+
+// apiKeyTouchInterval limits how often a key's last use is written back.
+const apiKeyTouchInterval = time.Minute
+
+// DefaultAPIKeyGrace is how long replaced keys stay valid after a rotation
+// unless another grace period is given.
+const DefaultAPIKeyGrace = 24 * time.Hour
+
+// ErrInvalidAPIKey is returned by VerifyAPIKey for unknown or expired keys.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// ErrAPIKeyAuthDisabled is returned when issuing keys or tokens while no API
+// key is configured, so they would not be enforced.
+var ErrAPIKeyAuthDisabled = errors.New("API key authentication is not configured")
+
+// APIKeyRotation is the result of RotateAPIKey.
+type APIKeyRotation struct {
+	Key       string // plaintext, only returned here
+	Info      store.APIKey
+	ExpiresAt time.Time // when the replaced keys stop working
+	Replaced  int       // number of keys given the grace period
+}
+
+// seedAPIKey stores the hash of the configured API key so it can be
+// verified and rotated like issued keys. A key that was rotated out keeps
+// its expiry even if it is still configured.
+func (m *Manager) seedAPIKey(db store.Store) {
+	hash := m.config.APIKeyHash
+	if m.config.APIKey != "" {
+		hash = hashToken(m.config.APIKey)
+	}
+	if hash == "" {
+		return
+	}
+	if err := db.EnsureAPIKey(hash, "", "config"); err != nil {
+		log.Printf("[Auth] Failed to store configured API key: %v", err)
+	}
+}
+
+// VerifyAPIKey returns the record of a valid, unexpired API key and
+// records its use.
+func (m *Manager) VerifyAPIKey(key string) (store.APIKey, error) {
+	a := m.App()
+	if a == nil {
+		return store.APIKey{}, errNotInitialized
+	}
+	if key == "" {
+		return store.APIKey{}, ErrInvalidAPIKey
+	}
+	info, err := a.DB().APIKeyByHash(hashToken(key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.APIKey{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		log.Printf("[Auth] API key lookup failed: %v", err)
+		return store.APIKey{}, err
+	}
+	now := time.Now().UTC()
+	if info.Expired(now) {
+		return store.APIKey{}, ErrInvalidAPIKey
+	}
+	if now.Sub(info.LastUsedAt) >= apiKeyTouchInterval {
+		if err := a.DB().TouchAPIKey(info.ID, now); err != nil {
+			log.Printf("[Auth] Failed to record use of API key %d: %v", info.ID, err)
+		}
+	}
+	return info, nil
+}
+
+// IssueAPIKey creates an API key in addition to the existing ones. A
+// read-only key may only read chats, messages, contacts and media. The
+// plaintext key is returned once; only its hash is stored.
+func (m *Manager) IssueAPIKey(label string, readOnly bool) (string, store.APIKey, error) {
+	if !m.config.APIKeyAuth() {
+		return "", store.APIKey{}, ErrAPIKeyAuthDisabled
+	}
+	a := m.App()
+	if a == nil {
+		return "", store.APIKey{}, errNotInitialized
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", store.APIKey{}, err
+	}
+	key := apiKeyPrefix + hex.EncodeToString(buf)
+
+	info, err := a.DB().CreateAPIKey(hashToken(key), key[:apiKeyHintLen], label, readOnly)
+	if err != nil {
+		return "", store.APIKey{}, err
+	}
+	log.Printf("[Auth] Issued API key %d (read-only: %v)", info.ID, readOnly)
+	return key, info, nil
+}
+
+// RotateAPIKey issues a new API key and lets every other key of the same
+// kind, full access or read-only, expire after grace.
+func (m *Manager) RotateAPIKey(label string, readOnly bool, grace time.Duration) (APIKeyRotation, error) {
+	key, info, err := m.IssueAPIKey(label, readOnly)
+	if err != nil {
+		return APIKeyRotation{}, err
+	}
+	a := m.App()
+	if a == nil {
+		return APIKeyRotation{}, errNotInitialized
+	}
+	expiresAt := time.Now().UTC().Add(grace)
+	n, err := a.DB().ExpireAPIKeys(info.ID, readOnly, expiresAt)
+	if err != nil {
+		return APIKeyRotation{}, err
+	}
+	log.Printf("[Auth] Rotated to API key %d; %d previous key(s) expire at %s", info.ID, n, expiresAt.Format(time.RFC3339))
+	return APIKeyRotation{Key: key, Info: info, ExpiresAt: expiresAt, Replaced: n}, nil
+}
+
+// ListAPIKeys returns all API keys, including expired ones.
+func (m *Manager) ListAPIKeys() ([]store.APIKey, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	return a.DB().ListAPIKeys()
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// autoDownloadQueueSize bounds the media waiting for the auto-downloader;
+// messages arriving while it is full are left for on-demand download.
+const autoDownloadQueueSize = 500
+
+// autoDownloadTimeout bounds a single automatic download.
+const autoDownloadTimeout = 10 * time.Minute
+
+type autoDownload struct {
+	chatJID string
+	msgID   string
+}
+
+// queueAutoDownload schedules the media of an incoming message for download
+// if the config asks for it.
+func (m *Manager) queueAutoDownload(chatJID, msgID, mediaType string, size uint64) {
+	if m.autoDownloads == nil || !m.config.AutoDownloads(mediaType, size) {
+		return
+	}
+	select {
+	case m.autoDownloads <- autoDownload{chatJID: chatJID, msgID: msgID}:
+	default:
+		log.Printf("[Media] Download queue full, skipping %s/%s", chatJID, msgID)
+	}
+}
+
+// runAutoDownloader fetches queued media until ctx is done and emits
+// media.downloaded with the local path of every file stored.
+func (m *Manager) runAutoDownloader(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-m.autoDownloads:
+			dctx, cancel := context.WithTimeout(ctx, autoDownloadTimeout)
+			res, err := m.DownloadMedia(dctx, job.chatJID, job.msgID)
+			cancel()
+			if err != nil {
+				// A manual download of the same message may have won the race.
+				if !errors.Is(err, ErrDownloadInProgress) {
+					log.Printf("[Media] Auto-download of %s/%s failed: %v", job.chatJID, job.msgID, err)
+				}
+				continue
+			}
+			m.emitEvent(EventMediaDownloaded, &MediaDownloadedEvent{
+				ChatJID:   res.ChatJID,
+				MsgID:     res.MsgID,
+				MediaType: res.MediaType,
+				MimeType:  res.MimeType,
+				LocalPath: res.LocalPath,
+				Bytes:     res.Bytes,
+				Timestamp: res.DownloadedAt,
+			})
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/steipete/wacli/internal/app"
+	"github.com/steipete/wacli/internal/store"
+)
+
+// backfillAllChatLimit bounds the chats covered by one backfill-all run.
+const backfillAllChatLimit = 100000
+
+// BackfillAllParams configures a backfill-all run. Zero values take the
+// defaults of a single-chat backfill, two workers and no pause.
+type BackfillAllParams struct {
+	Count           int
+	Requests        int
+	WaitSeconds     int
+	Concurrency     int
+	IntervalSeconds int
+}
+
+// BackfillAllStatus describes the current or last backfill-all run.
+type BackfillAllStatus struct {
+	Active bool // a run is being processed right now
+	Run    store.BackfillRun
+	Counts map[string]int       // chats per status
+	Failed []store.BackfillChat // chats whose backfill failed
+}
+
+// StartBackfillAll starts backfilling every known chat in the background,
+// replacing the checkpoints of any previous run.
+func (m *Manager) StartBackfillAll(p BackfillAllParams) (store.BackfillRun, error) {
+	a := m.App()
+	if a == nil {
+		return store.BackfillRun{}, errNotInitialized
+	}
+	if !m.state.State().IsReady() {
+		return store.BackfillRun{}, fmt.Errorf("%w (state: %s)", ErrNotReady, m.state.State())
+	}
+	if p.Count <= 0 {
+		p.Count = 50
+	}
+	if p.Requests <= 0 {
+		p.Requests = 1
+	}
+	if p.WaitSeconds <= 0 {
+		p.WaitSeconds = 60
+	}
+	if p.Concurrency <= 0 {
+		p.Concurrency = 2
+	}
+	if p.IntervalSeconds < 0 {
+		p.IntervalSeconds = 0
+	}
+
+	m.backfillMu.Lock()
+	defer m.backfillMu.Unlock()
+	if m.backfillCancel != nil {
+		return store.BackfillRun{}, ErrBackfillRunning
+	}
+
+	chats, err := a.DB().ListChats("", backfillAllChatLimit)
+	if err != nil {
+		return store.BackfillRun{}, fmt.Errorf("list chats: %w", err)
+	}
+	jids := make([]string, 0, len(chats))
+	for _, c := range chats {
+		jids = append(jids, c.JID)
+	}
+
+	run := store.BackfillRun{
+		Count:          p.Count,
+		Requests:       p.Requests,
+		WaitPerRequest: time.Duration(p.WaitSeconds) * time.Second,
+		Concurrency:    p.Concurrency,
+		Interval:       time.Duration(p.IntervalSeconds) * time.Second,
+		Status:         store.BackfillRunning,
+		StartedAt:      time.Now().UTC(),
+	}
+	if err := a.DB().StartBackfillRun(run, jids); err != nil {
+		return store.BackfillRun{}, fmt.Errorf("save backfill run: %w", err)
+	}
+	m.launchBackfillAll(a.DB(), run)
+	return run, nil
+}
+
+// CancelBackfillAll stops the active run. Chats not yet backfilled stay
+// pending but the run is not resumed.
+func (m *Manager) CancelBackfillAll() error {
+	a := m.App()
+	if a == nil {
+		return errNotInitialized
+	}
+
+	m.backfillMu.Lock()
+	defer m.backfillMu.Unlock()
+	run, err := a.DB().GetBackfillRun()
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && run.Status != store.BackfillRunning) {
+		return fmt.Errorf("backfill run %w", ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	if m.backfillCancel != nil {
+		m.backfillCancel()
+		m.backfillCancel = nil
+	}
+	return a.DB().FinishBackfillRun(store.BackfillCancelled, time.Now().UTC())
+}
+
+// BackfillAllStatus returns the progress of the current or last run.
+func (m *Manager) BackfillAllStatus() (BackfillAllStatus, error) {
+	a := m.App()
+	if a == nil {
+		return BackfillAllStatus{}, errNotInitialized
+	}
+	run, err := a.DB().GetBackfillRun()
+	if errors.Is(err, sql.ErrNoRows) {
+		return BackfillAllStatus{}, fmt.Errorf("backfill run %w", ErrNotFound)
+	}
+	if err != nil {
+		return BackfillAllStatus{}, err
+	}
+	counts, err := a.DB().CountBackfillChats()
+	if err != nil {
+		return BackfillAllStatus{}, err
+	}
+	failed, err := a.DB().ListBackfillChats(store.BackfillFailed)
+	if err != nil {
+		return BackfillAllStatus{}, err
+	}
+
+	m.backfillMu.Lock()
+	active := m.backfillCancel != nil
+	m.backfillMu.Unlock()
+	return BackfillAllStatus{Active: active, Run: run, Counts: counts, Failed: failed}, nil
+}
+
+// resumeBackfillAll continues a run interrupted by a restart or a lost
+// connection. It is called on every connect and does nothing if no run is
+// unfinished or one is already being processed.
+func (m *Manager) resumeBackfillAll() {
+	a := m.App()
+	if a == nil {
+		return
+	}
+
+	m.backfillMu.Lock()
+	defer m.backfillMu.Unlock()
+	if m.backfillCancel != nil {
+		return
+	}
+	run, err := a.DB().GetBackfillRun()
+	if err != nil || run.Status != store.BackfillRunning {
+		return
+	}
+	if err := a.DB().ResetRunningBackfillChats(); err != nil {
+		log.Printf("[Backfill] Failed to reset interrupted chats: %v", err)
+		return
+	}
+	log.Printf("[Backfill] Resuming backfill of all chats started %s", run.StartedAt.Format(time.RFC3339))
+	m.launchBackfillAll(a.DB(), run)
+}
+
+// launchBackfillAll starts processing the pending chats of run. The caller
+// must hold backfillMu.
+func (m *Manager) launchBackfillAll(db store.Store, run store.BackfillRun) {
+	m.mu.RLock()
+	parent := m.ctx
+	m.mu.RUnlock()
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	m.backfillCancel = cancel
+	m.backfillGen++
+	go m.runBackfillAll(ctx, cancel, m.backfillGen, db, run)
+}
+
+// runBackfillAll backfills the pending chats with run.Concurrency workers,
+// starting at most one chat per run.Interval. Every chat is checkpointed, so
+// chats interrupted by shutdown or a lost connection are put back to pending
+// and picked up by resumeBackfillAll.
+func (m *Manager) runBackfillAll(ctx context.Context, cancel context.CancelFunc, gen int, db store.Store, run store.BackfillRun) {
+	defer func() {
+		m.backfillMu.Lock()
+		if m.backfillGen == gen {
+			m.backfillCancel = nil
+		}
+		m.backfillMu.Unlock()
+		cancel()
+	}()
+
+	pending, err := db.ListBackfillChats(store.BackfillPending)
+	if err != nil {
+		log.Printf("[Backfill] Failed to list pending chats: %v", err)
+		return
+	}
+	log.Printf("[Backfill] Backfilling %d chats with %d workers", len(pending), run.Concurrency)
+
+	jobs := make(chan string)
+	var interrupted bool
+	var interruptOnce sync.Once
+	var wg sync.WaitGroup
+	for i := 0; i < run.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chat := range jobs {
+				if !m.backfillChat(ctx, db, run, chat) {
+					interruptOnce.Do(func() {
+						interrupted = true
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for i, c := range pending {
+		if i > 0 && run.Interval > 0 {
+			select {
+			case <-ctx.Done():
+				break feed
+			case <-time.After(run.Interval):
+			}
+		}
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- c.ChatJID:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if ctx.Err() != nil || interrupted {
+		log.Printf("[Backfill] Backfill of all chats paused; it resumes on the next connect")
+		return
+	}
+	if err := db.FinishBackfillRun(store.BackfillCompleted, time.Now().UTC()); err != nil {
+		log.Printf("[Backfill] Failed to finish run: %v", err)
+		return
+	}
+	log.Printf("[Backfill] Backfill of all chats completed")
+}
+
+// backfillChat backfills one chat and records the outcome. It returns false
+// if the chat was interrupted and should be retried later.
+func (m *Manager) backfillChat(ctx context.Context, db store.Store, run store.BackfillRun, chat string) bool {
+	if err := db.UpdateBackfillChat(store.BackfillChat{ChatJID: chat, Status: store.BackfillRunning}); err != nil {
+		log.Printf("[Backfill] Failed to checkpoint %s: %v", chat, err)
+	}
+
+	res, err := m.BackfillHistory(ctx, chat, run.Count, run.Requests, int(run.WaitPerRequest/time.Second))
+	checkpoint := store.BackfillChat{ChatJID: chat}
+	ok := true
+	switch {
+	case err == nil:
+		checkpoint.Status = store.BackfillDone
+		checkpoint.RequestsSent = res.RequestsSent
+		checkpoint.MessagesAdded = res.MessagesAdded
+	case errors.Is(err, app.ErrNoLocalMessages):
+		checkpoint.Status = store.BackfillSkipped
+	case ctx.Err() != nil || !m.state.State().IsReady():
+		checkpoint.Status = store.BackfillPending
+		ok = false
+	default:
+		checkpoint.Status = store.BackfillFailed
+		checkpoint.Error = err.Error()
+		log.Printf("[Backfill] Backfill of %s failed: %v", chat, err)
+	}
+	if err := db.UpdateBackfillChat(checkpoint); err != nil {
+		log.Printf("[Backfill] Failed to checkpoint %s: %v", chat, err)
+	}
+	return ok
+}
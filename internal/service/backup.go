@@ -0,0 +1,68 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/steipete/wacli/internal/backup"
+)
+
+// Backup streams a consistent snapshot of the data directory to w as a
+// .tar.gz. Media files are only included when includeMedia is set; they are
+// listed in the manifest either way.
+func (m *Manager) Backup(w io.Writer, includeMedia bool) (backup.Manifest, error) {
+	a := m.App()
+	if a == nil {
+		return backup.Manifest{}, errNotInitialized
+	}
+	if a.DB().Backend() != "sqlite" {
+		return backup.Manifest{}, fmt.Errorf("backups are only supported for the SQLite store; back up %s with its own tools", a.DB().Backend())
+	}
+	return backup.Write(w, m.config.DataDir, includeMedia)
+}
+
+// StageRestore verifies a backup archive and stages it for the next start.
+// The running service is not affected; callers are expected to follow up
+// with RequestShutdown so the restore is applied on restart.
+func (m *Manager) StageRestore(r io.Reader) (backup.Manifest, error) {
+	a := m.App()
+	if a == nil {
+		return backup.Manifest{}, errNotInitialized
+	}
+	if a.DB().Backend() != "sqlite" {
+		return backup.Manifest{}, fmt.Errorf("restores are only supported for the SQLite store")
+	}
+	return backup.Stage(r, m.config.DataDir)
+}
+
+// RequestShutdown asks the process to shut down, e.g. so a staged restore is
+// applied by the supervisor's restart.
+func (m *Manager) RequestShutdown() {
+	m.shutdownOnce.Do(func() { close(m.shutdown) })
+}
+
+// ShutdownRequested is closed once RequestShutdown has been called.
+func (m *Manager) ShutdownRequested() <-chan struct{} {
+	return m.shutdown
+}
+
+// applyPendingRestore swaps in a staged restore. It runs in Start with the
+// lock held and before any database is opened. Failures are logged and the
+// service continues with whatever data is in place.
+func (m *Manager) applyPendingRestore() {
+	old, manifest, err := backup.ApplyPending(m.config.DataDir)
+	if err != nil {
+		if old != "" {
+			log.Printf("[Restore] Failed to apply staged restore: %v (previous data moved to %s)", err, old)
+		} else {
+			log.Printf("[Restore] Failed to apply staged restore: %v", err)
+		}
+		return
+	}
+	if old == "" {
+		return
+	}
+	log.Printf("[Restore] Restored backup from %s (%d databases, media included: %v); previous data moved to %s",
+		manifest.CreatedAt.Format("2006-01-02 15:04:05"), len(manifest.Databases), manifest.MediaIncluded, old)
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// ListBlocked returns the local mirror of the blocklist. With refresh set,
+// it is re-read from WhatsApp first.
+func (m *Manager) ListBlocked(ctx context.Context, refresh bool) ([]store.BlockedContact, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	if refresh {
+		if !m.state.State().IsReady() {
+			return nil, fmt.Errorf("%w (state: %s)", ErrNotReady, m.state.State())
+		}
+		if err := m.refreshBlocklist(ctx); err != nil {
+			return nil, fmt.Errorf("fetch blocklist: %w", err)
+		}
+	}
+	return a.DB().ListBlocked()
+}
+
+// SetBlocked blocks or unblocks a contact on WhatsApp and in the local mirror.
+func (m *Manager) SetBlocked(ctx context.Context, jidStr string, block bool) error {
+	a := m.App()
+	if a == nil || a.WA() == nil {
+		return errNotInitialized
+	}
+	if !m.state.State().IsReady() {
+		return fmt.Errorf("%w (state: %s)", ErrNotReady, m.state.State())
+	}
+	jid, err := wa.ParseUserOrJID(jidStr)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+	if jid.Server != types.DefaultUserServer && jid.Server != types.HiddenUserServer {
+		return fmt.Errorf("%w: %s is not a user", ErrInvalidJID, jid)
+	}
+
+	// The returned list may use other addressing than jid, so only the
+	// changed entry is mirrored; full lists are taken from syncBlocklist.
+	if _, err := a.WA().UpdateBlocklist(ctx, jid.ToNonAD(), block); err != nil {
+		return err
+	}
+	return a.DB().SetBlocked(jid.ToNonAD().String(), block)
+}
+
+// syncBlocklist refreshes the blocklist mirror after connecting.
+func (m *Manager) syncBlocklist() {
+	if err := m.refreshBlocklist(m.ctx); err != nil {
+		log.Printf("[Contacts] Failed to sync blocklist: %v", err)
+	}
+}
+
+func (m *Manager) refreshBlocklist(ctx context.Context) error {
+	a := m.App()
+	if a == nil || a.WA() == nil {
+		return errNotInitialized
+	}
+	jids, err := a.WA().GetBlocklist(ctx)
+	if err != nil {
+		return err
+	}
+	list := make([]string, len(jids))
+	for i, jid := range jids {
+		list[i] = jid.ToNonAD().String()
+	}
+	return a.DB().ReplaceBlocklist(list)
+}
+
+// handleBlocklist mirrors blocks and unblocks made on other devices.
+func (m *Manager) handleBlocklist(evt *events.Blocklist) {
+	if evt.Action == events.BlocklistActionModify {
+		go m.syncBlocklist()
+		return
+	}
+	a := m.App()
+	if a == nil {
+		return
+	}
+	for _, change := range evt.Changes {
+		block := change.Action == events.BlocklistChangeActionBlock
+		if err := a.DB().SetBlocked(change.JID.ToNonAD().String(), block); err != nil {
+			log.Printf("[Contacts] Failed to update blocklist for %s: %v", change.JID, err)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// ListCalls returns recorded incoming calls, newest first.
+func (m *Manager) ListCalls(p store.ListCallsParams) ([]store.Call, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	if p.FromJID != "" {
+		jid, err := NormalizeChatJID(p.FromJID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidJID, err)
+		}
+		p.FromJID = jid
+	}
+	return a.DB().ListCalls(p)
+}
+
+// handleCallOffer handles a 1:1 call.
+func (m *Manager) handleCallOffer(evt *events.CallOffer) {
+	video := false
+	if evt.Data != nil {
+		_, video = evt.Data.GetOptionalChildByTag("video")
+	}
+	m.handleIncomingCall(evt.BasicCallMeta, video)
+}
+
+// handleCallOfferNotice handles a group call.
+func (m *Manager) handleCallOfferNotice(evt *events.CallOfferNotice) {
+	m.handleIncomingCall(evt.BasicCallMeta, evt.Media == "video")
+}
+
+// handleIncomingCall records a new call, emits call.incoming and, with
+// WASVC_CALL_REJECT, rejects it.
+func (m *Manager) handleIncomingCall(meta types.BasicCallMeta, video bool) {
+	caller := meta.CallCreator
+	if caller.IsEmpty() {
+		caller = meta.From
+	}
+	caller = caller.ToNonAD()
+
+	if a := m.App(); a != nil {
+		isNew, err := a.DB().InsertCall(store.Call{
+			CallID:    meta.CallID,
+			FromJID:   caller.String(),
+			GroupJID:  groupJIDString(meta.GroupJID),
+			Video:     video,
+			Timestamp: meta.Timestamp,
+		})
+		if err != nil {
+			log.Printf("[Calls] Failed to store call %s: %v", meta.CallID, err)
+		} else if !isNew {
+			return
+		}
+	}
+
+	m.emitEvent(EventCallIncoming, &CallEvent{
+		CallID:       meta.CallID,
+		FromJID:      caller.String(),
+		GroupJID:     groupJIDString(meta.GroupJID),
+		Video:        video,
+		AutoRejected: m.config.CallReject,
+		Timestamp:    meta.Timestamp,
+	})
+	if m.config.CallReject {
+		// Event handlers must not block on network round trips.
+		go m.rejectCall(meta.From, caller, meta.CallID)
+	}
+}
+
+// rejectCall rejects a call and sends the caller WASVC_CALL_REJECT_MESSAGE.
+func (m *Manager) rejectCall(from, caller types.JID, callID string) {
+	a := m.App()
+	if a == nil || a.WA() == nil {
+		return
+	}
+	if err := a.WA().RejectCall(m.ctx, from, callID); err != nil {
+		log.Printf("[Calls] Failed to reject call %s from %s: %v", callID, caller, err)
+		return
+	}
+	if err := a.DB().SetCallStatus(callID, store.CallRejected); err != nil {
+		log.Printf("[Calls] Failed to store rejection of call %s: %v", callID, err)
+	}
+	if m.config.CallRejectMessage == "" {
+		return
+	}
+	if _, err := m.SendText(m.ctx, caller.String(), m.config.CallRejectMessage); err != nil {
+		log.Printf("[Calls] Failed to send call reply to %s: %v", caller, err)
+	}
+}
+
+// handleCallAccept records a call answered on another device.
+func (m *Manager) handleCallAccept(evt *events.CallAccept) {
+	m.setCallStatus(evt.CallID, store.CallAnswered)
+}
+
+// handleCallReject records a call rejected on another device.
+func (m *Manager) handleCallReject(evt *events.CallReject) {
+	m.setCallStatus(evt.CallID, store.CallRejected)
+}
+
+func (m *Manager) setCallStatus(callID, status string) {
+	a := m.App()
+	if a == nil {
+		return
+	}
+	if err := a.DB().SetCallStatus(callID, status); err != nil {
+		log.Printf("[Calls] Failed to mark call %s %s: %v", callID, status, err)
+	}
+}
+
+// handleCallTerminate records the end of a call.
+func (m *Manager) handleCallTerminate(evt *events.CallTerminate) {
+	a := m.App()
+	if a == nil {
+		return
+	}
+	at := evt.Timestamp
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+	if err := a.DB().EndCall(evt.CallID, at, evt.Reason); err != nil {
+		log.Printf("[Calls] Failed to store end of call %s: %v", evt.CallID, err)
+	}
+}
+
+func groupJIDString(jid types.JID) string {
+	if jid.IsEmpty() {
+		return ""
+	}
+	return jid.String()
+}
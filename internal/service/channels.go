@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"github.com/steipete/wacli/internal/app"
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// parseChannelJID accepts a channel JID with or without the @newsletter
+// suffix.
+func parseChannelJID(s string) (types.JID, error) {
+	s = strings.TrimSpace(s)
+	if s != "" && !strings.Contains(s, "@") {
+		s += "@" + types.NewsletterServer
+	}
+	jid, err := types.ParseJID(s)
+	if err != nil {
+		return types.JID{}, fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+	if jid.Server != types.NewsletterServer {
+		return types.JID{}, fmt.Errorf("%w: %s is not a channel", ErrInvalidJID, s)
+	}
+	return jid, nil
+}
+
+// ListChannels returns the known channels, followed ones first.
+func (m *Manager) ListChannels(query string, limit int) ([]store.Channel, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	return a.DB().ListChannels(query, limit)
+}
+
+// GetChannel returns a stored channel.
+func (m *Manager) GetChannel(channelJID string) (store.Channel, error) {
+	a := m.App()
+	if a == nil {
+		return store.Channel{}, errNotInitialized
+	}
+	jid, err := parseChannelJID(channelJID)
+	if err != nil {
+		return store.Channel{}, err
+	}
+	c, err := a.DB().GetChannel(jid.String())
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.Channel{}, fmt.Errorf("channel %w", ErrNotFound)
+	}
+	return c, err
+}
+
+// ListChannelPosts returns stored posts of a channel, newest first. With
+// refresh set, the latest limit posts are fetched from WhatsApp first, which
+// also updates their view and reaction counts.
+func (m *Manager) ListChannelPosts(ctx context.Context, channelJID string, limit int, before *time.Time, refresh bool) ([]store.ChannelPost, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	jid, err := parseChannelJID(channelJID)
+	if err != nil {
+		return nil, err
+	}
+	if refresh {
+		if err := m.fetchChannelPosts(ctx, a, jid, limit); err != nil {
+			return nil, fmt.Errorf("fetch posts: %w", err)
+		}
+	}
+	return a.DB().ListChannelPosts(store.ListChannelPostsParams{ChannelJID: jid.String(), Limit: limit, Before: before})
+}
+
+func (m *Manager) fetchChannelPosts(ctx context.Context, a *app.App, jid types.JID, count int) error {
+	if !m.state.State().IsReady() {
+		return fmt.Errorf("%w (state: %s)", ErrNotReady, m.state.State())
+	}
+	if count <= 0 {
+		count = 50
+	}
+	posts, err := a.WA().GetNewsletterMessages(ctx, jid, count, 0)
+	if err != nil {
+		return err
+	}
+	for _, nm := range posts {
+		if nm.MessageID == "" {
+			continue
+		}
+		post := app.NewChannelPost(wa.ParseNewsletterMessage(jid, nm), int64(nm.MessageServerID))
+		post.Views = nm.ViewsCount
+		post.Reactions = nm.ReactionCounts
+		if err := a.DB().UpsertChannelPost(post); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FollowChannel follows a channel on WhatsApp and stores its metadata.
+func (m *Manager) FollowChannel(ctx context.Context, channelJID string) (store.Channel, error) {
+	a := m.App()
+	if a == nil {
+		return store.Channel{}, errNotInitialized
+	}
+	if !m.state.State().IsReady() {
+		return store.Channel{}, fmt.Errorf("%w (state: %s)", ErrNotReady, m.state.State())
+	}
+	jid, err := parseChannelJID(channelJID)
+	if err != nil {
+		return store.Channel{}, err
+	}
+	if err := a.WA().FollowNewsletter(ctx, jid); err != nil {
+		return store.Channel{}, err
+	}
+	c := store.Channel{JID: jid.String(), Followed: true}
+	if info, err := a.WA().GetNewsletterInfo(ctx, jid); err == nil && info != nil {
+		c = channelFromMetadata(info)
+		c.Followed = true
+	} else if err != nil {
+		log.Printf("[Channels] Followed %s but could not fetch its info: %v", jid, err)
+	}
+	if err := a.DB().UpsertChannel(c); err != nil {
+		return store.Channel{}, err
+	}
+	return a.DB().GetChannel(jid.String())
+}
+
+// UnfollowChannel unfollows a channel on WhatsApp. Its stored posts are kept.
+func (m *Manager) UnfollowChannel(ctx context.Context, channelJID string) error {
+	a := m.App()
+	if a == nil {
+		return errNotInitialized
+	}
+	if !m.state.State().IsReady() {
+		return fmt.Errorf("%w (state: %s)", ErrNotReady, m.state.State())
+	}
+	jid, err := parseChannelJID(channelJID)
+	if err != nil {
+		return err
+	}
+	if err := a.WA().UnfollowNewsletter(ctx, jid); err != nil {
+		return err
+	}
+	return a.DB().SetChannelFollowed(jid.String(), false)
+}
+
+// syncChannels refreshes the followed channels after connecting.
+func (m *Manager) syncChannels() {
+	a := m.App()
+	if a == nil || a.WA() == nil {
+		return
+	}
+	list, err := a.WA().GetSubscribedNewsletters(m.ctx)
+	if err != nil {
+		log.Printf("[Channels] Failed to list followed channels: %v", err)
+		return
+	}
+	keep := make([]string, 0, len(list))
+	for _, info := range list {
+		c := channelFromMetadata(info)
+		c.Followed = true
+		if err := a.DB().UpsertChannel(c); err != nil {
+			log.Printf("[Channels] Failed to store %s: %v", c.JID, err)
+			continue
+		}
+		keep = append(keep, c.JID)
+	}
+	if err := a.DB().UnfollowChannelsExcept(keep); err != nil {
+		log.Printf("[Channels] Failed to reconcile followed channels: %v", err)
+	}
+}
+
+func channelFromMetadata(info *types.NewsletterMetadata) store.Channel {
+	meta := info.ThreadMeta
+	c := store.Channel{
+		JID:         info.ID.String(),
+		Name:        meta.Name.Text,
+		Description: meta.Description.Text,
+		InviteCode:  meta.InviteCode,
+		Subscribers: meta.SubscriberCount,
+		Verified:    meta.VerificationState == types.NewsletterVerificationStateVerified,
+	}
+	if v := info.ViewerMeta; v != nil {
+		c.Role = string(v.Role)
+		c.Muted = v.Mute == types.NewsletterMuteOn
+	}
+	return c
+}
+
+// handleChannelPost stores a live channel post and emits channel.post.
+func (m *Manager) handleChannelPost(a *app.App, evt *events.Message, pm wa.ParsedMessage) {
+	post := app.NewChannelPost(pm, int64(evt.Info.ServerID))
+	if err := a.DB().UpsertChannelPost(post); err != nil {
+		log.Printf("[Channels] Failed to store post %s/%s: %v", post.ChannelJID, post.MsgID, err)
+		return
+	}
+	m.syncProgress.messages.Add(1)
+	m.emitEvent(EventChannelPost, &ChannelPostEvent{
+		ChannelJID: post.ChannelJID,
+		MsgID:      post.MsgID,
+		ServerID:   post.ServerID,
+		Text:       post.Text,
+		MediaType:  post.MediaType,
+		Caption:    post.MediaCaption,
+		Timestamp:  post.Timestamp,
+	})
+}
+
+// handleNewsletterJoin records a channel followed on another device.
+func (m *Manager) handleNewsletterJoin(evt *events.NewsletterJoin) {
+	a := m.App()
+	if a == nil {
+		return
+	}
+	c := channelFromMetadata(&evt.NewsletterMetadata)
+	c.Followed = true
+	if err := a.DB().UpsertChannel(c); err != nil {
+		log.Printf("[Channels] Failed to store %s: %v", c.JID, err)
+	}
+}
+
+// handleNewsletterLeave records a channel unfollowed on another device.
+func (m *Manager) handleNewsletterLeave(evt *events.NewsletterLeave) {
+	a := m.App()
+	if a == nil {
+		return
+	}
+	if err := a.DB().SetChannelFollowed(evt.ID.String(), false); err != nil {
+		log.Printf("[Channels] Failed to unfollow %s: %v", evt.ID, err)
+	}
+}
+
+// handleNewsletterMute records a channel muted or unmuted on another device.
+func (m *Manager) handleNewsletterMute(evt *events.NewsletterMuteChange) {
+	a := m.App()
+	if a == nil {
+		return
+	}
+	if err := a.DB().SetChannelMuted(evt.ID.String(), evt.Mute == types.NewsletterMuteOn); err != nil {
+		log.Printf("[Channels] Failed to store mute of %s: %v", evt.ID, err)
+	}
+}
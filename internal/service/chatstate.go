@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waSyncAction"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// SetChatArchived archives or unarchives a chat on WhatsApp and locally.
+// Archiving also unpins the chat.
+func (m *Manager) SetChatArchived(ctx context.Context, chatJID string, archived bool) error {
+	return m.pushChatState(ctx, chatJID, func(target types.JID) appstate.PatchInfo {
+		return appstate.BuildArchive(target, archived, time.Time{}, nil)
+	}, func(db store.Store, chat string) error {
+		return db.SetChatArchived(chat, archived)
+	})
+}
+
+// SetChatPinned pins or unpins a chat on WhatsApp and locally.
+func (m *Manager) SetChatPinned(ctx context.Context, chatJID string, pinned bool) error {
+	return m.pushChatState(ctx, chatJID, func(target types.JID) appstate.PatchInfo {
+		return appstate.BuildPin(target, pinned)
+	}, func(db store.Store, chat string) error {
+		return db.SetChatPinned(chat, pinned)
+	})
+}
+
+// MuteChat mutes a chat for the given duration, or forever if it is zero;
+// muted=false unmutes it.
+func (m *Manager) MuteChat(ctx context.Context, chatJID string, muted bool, duration time.Duration) error {
+	var until time.Time
+	if muted && duration > 0 {
+		until = time.Now().Add(duration)
+	}
+	return m.pushChatState(ctx, chatJID, func(target types.JID) appstate.PatchInfo {
+		if until.IsZero() {
+			return appstate.BuildMuteAbs(target, muted, nil)
+		}
+		end := until.UnixMilli()
+		return appstate.BuildMuteAbs(target, muted, &end)
+	}, func(db store.Store, chat string) error {
+		return db.SetChatMuted(chat, muted, until)
+	})
+}
+
+// MarkChatRead marks a chat read or unread on WhatsApp and locally. Marking
+// a chat read clears its unread count.
+func (m *Manager) MarkChatRead(ctx context.Context, chatJID string, read bool) error {
+	a := m.App()
+	if a == nil {
+		return errNotInitialized
+	}
+	chat, err := NormalizeChatJID(chatJID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+	newest, err := a.DB().GetNewestMessageInfo(chat)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	return m.pushChatState(ctx, chat, func(target types.JID) appstate.PatchInfo {
+		return appstate.BuildMarkChatAsRead(target, read, newest.Timestamp, messageKey(target, newest))
+	}, func(db store.Store, chat string) error {
+		return db.SetChatRead(chat, read)
+	})
+}
+
+// ClearChatResult describes what ClearChatHistory removed.
+type ClearChatResult struct {
+	ChatJID    string
+	Messages   int
+	MediaFiles int
+	MediaBytes int64
+	Synced     bool // the chat was also cleared on WhatsApp
+}
+
+// ClearChatHistory deletes the stored messages and downloaded media of a
+// chat, keeping the chat itself. With syncToPhone set the chat is cleared on
+// WhatsApp first, so nothing is deleted locally if the phone rejects it.
+func (m *Manager) ClearChatHistory(ctx context.Context, chatJID string, syncToPhone bool) (ClearChatResult, error) {
+	a := m.App()
+	if a == nil {
+		return ClearChatResult{}, errNotInitialized
+	}
+	chat, err := NormalizeChatJID(chatJID)
+	if err != nil {
+		return ClearChatResult{}, fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+
+	if syncToPhone {
+		newest, err := a.DB().GetNewestMessageInfo(chat)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return ClearChatResult{}, err
+		}
+		if err := m.pushChatState(ctx, chat, func(target types.JID) appstate.PatchInfo {
+			return buildClearChat(target, newest)
+		}, func(store.Store, string) error { return nil }); err != nil {
+			return ClearChatResult{}, err
+		}
+	}
+
+	res, err := a.DB().DeleteChatMessages(chat)
+	if err != nil {
+		return ClearChatResult{}, err
+	}
+	out := ClearChatResult{ChatJID: chat, Messages: res.Messages, Synced: syncToPhone}
+	out.MediaFiles, out.MediaBytes = m.removeMedia(res.MediaPaths, false)
+	return out, nil
+}
+
+// buildClearChat builds the clearChat patch whatsmeow has no builder for.
+// The index flags ask to delete starred messages but keep media on the phone.
+func buildClearChat(target types.JID, newest store.MessageInfo) appstate.PatchInfo {
+	ts := newest.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	messageRange := &waSyncAction.SyncActionMessageRange{
+		LastMessageTimestamp: proto.Int64(ts.Unix()),
+	}
+	if key := messageKey(target, newest); key != nil {
+		messageRange.Messages = []*waSyncAction.SyncActionMessage{{Key: key, Timestamp: proto.Int64(ts.Unix())}}
+	}
+	return appstate.PatchInfo{
+		Type: appstate.WAPatchRegularHigh,
+		Mutations: []appstate.MutationInfo{{
+			Index:   []string{appstate.IndexClearChat, target.String(), "1", "0"},
+			Version: 6,
+			Value: &waSyncAction.SyncActionValue{
+				ClearChatAction: &waSyncAction.ClearChatAction{MessageRange: messageRange},
+			},
+		}},
+	}
+}
+
+// messageKey returns the key of a stored message, or nil if there is none.
+func messageKey(target types.JID, info store.MessageInfo) *waCommon.MessageKey {
+	if info.MsgID == "" {
+		return nil
+	}
+	key := &waCommon.MessageKey{
+		RemoteJID: proto.String(target.String()),
+		FromMe:    proto.Bool(info.FromMe),
+		ID:        proto.String(info.MsgID),
+	}
+	if target.Server == types.GroupServer && !info.FromMe && info.SenderJID != "" {
+		key.Participant = proto.String(info.SenderJID)
+	}
+	return key
+}
+
+// pushChatState sends a chat app-state patch to the phone and, once it is
+// accepted, records the change locally.
+func (m *Manager) pushChatState(ctx context.Context, chatJID string, build func(types.JID) appstate.PatchInfo, save func(db store.Store, chat string) error) error {
+	a := m.App()
+	if a == nil {
+		return errNotInitialized
+	}
+	chat, err := NormalizeChatJID(chatJID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+	target, err := types.ParseJID(chat)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+	if err := a.WA().SendAppState(ctx, build(target)); err != nil {
+		return fmt.Errorf("sync chat state: %w", err)
+	}
+	return save(a.DB(), chat)
+}
+
+// handleArchive records a chat archived or unarchived on another device.
+func (m *Manager) handleArchive(evt *events.Archive) {
+	a := m.App()
+	if a == nil || evt.Action == nil {
+		return
+	}
+	if err := a.DB().SetChatArchived(evt.JID.String(), evt.Action.GetArchived()); err != nil {
+		log.Printf("[Manager] Failed to store archive state for %s: %v", evt.JID, err)
+	}
+}
+
+// handlePin records a chat pinned or unpinned on another device.
+func (m *Manager) handlePin(evt *events.Pin) {
+	a := m.App()
+	if a == nil || evt.Action == nil {
+		return
+	}
+	if err := a.DB().SetChatPinned(evt.JID.String(), evt.Action.GetPinned()); err != nil {
+		log.Printf("[Manager] Failed to store pin state for %s: %v", evt.JID, err)
+	}
+}
+
+// handleMute records a chat muted or unmuted on another device. The mute end
+// is in milliseconds, with -1 meaning forever.
+func (m *Manager) handleMute(evt *events.Mute) {
+	a := m.App()
+	if a == nil || evt.Action == nil {
+		return
+	}
+	var until time.Time
+	if end := evt.Action.GetMuteEndTimestamp(); end > 0 {
+		until = time.UnixMilli(end)
+	}
+	if err := a.DB().SetChatMuted(evt.JID.String(), evt.Action.GetMuted(), until); err != nil {
+		log.Printf("[Manager] Failed to store mute state for %s: %v", evt.JID, err)
+	}
+}
+
+// handleMarkChatAsRead records a chat marked read or unread on another
+// device.
+func (m *Manager) handleMarkChatAsRead(evt *events.MarkChatAsRead) {
+	a := m.App()
+	if a == nil || evt.Action == nil {
+		return
+	}
+	if err := a.DB().SetChatRead(evt.JID.String(), evt.Action.GetRead()); err != nil {
+		log.Printf("[Manager] Failed to store read state for %s: %v", evt.JID, err)
+	}
+}
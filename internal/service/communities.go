@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"github.com/steipete/wacli/internal/app"
+	"github.com/steipete/wacli/internal/store"
+)
+
+// parseCommunityJID accepts a community JID with or without the @g.us
+// suffix. Communities share the group server.
+func parseCommunityJID(s string) (types.JID, error) {
+	s = strings.TrimSpace(s)
+	if s != "" && !strings.Contains(s, "@") {
+		s += "@" + types.GroupServer
+	}
+	jid, err := types.ParseJID(s)
+	if err != nil {
+		return types.JID{}, fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+	if jid.Server != types.GroupServer {
+		return types.JID{}, fmt.Errorf("%w: %s is not a community", ErrInvalidJID, s)
+	}
+	return jid, nil
+}
+
+// ListCommunities returns the known communities.
+func (m *Manager) ListCommunities(query string, limit int) ([]store.Community, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	return a.DB().ListCommunities(query, limit)
+}
+
+// ListCommunityGroups returns the groups linked to a community. With refresh
+// set, the list is fetched from WhatsApp first, which also picks up linked
+// groups you are not a member of.
+func (m *Manager) ListCommunityGroups(ctx context.Context, communityJID string, refresh bool) ([]store.Group, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	jid, err := parseCommunityJID(communityJID)
+	if err != nil {
+		return nil, err
+	}
+	if refresh {
+		if err := m.fetchCommunityGroups(ctx, a, jid); err != nil {
+			return nil, fmt.Errorf("fetch linked groups: %w", err)
+		}
+	}
+	g, err := a.DB().GetGroup(jid.String())
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !g.IsCommunity) {
+		return nil, fmt.Errorf("community %w", ErrNotFound)
+	} else if err != nil {
+		return nil, err
+	}
+	return a.DB().ListCommunityGroups(jid.String())
+}
+
+func (m *Manager) fetchCommunityGroups(ctx context.Context, a *app.App, jid types.JID) error {
+	if !m.state.State().IsReady() {
+		return fmt.Errorf("%w (state: %s)", ErrNotReady, m.state.State())
+	}
+	targets, err := a.WA().GetSubGroups(ctx, jid)
+	if err != nil {
+		return err
+	}
+	db := a.DB()
+	if err := db.UpsertGroup(jid.String(), "", "", time.Time{}); err != nil {
+		return err
+	}
+	if err := db.SetGroupCommunity(jid.String(), true); err != nil {
+		return err
+	}
+	keep := make([]string, 0, len(targets))
+	for _, t := range targets {
+		if err := db.UpsertGroup(t.JID.String(), t.Name, "", time.Time{}); err != nil {
+			return err
+		}
+		if err := db.LinkGroup(t.JID.String(), jid.String(), t.IsDefaultSubGroup); err != nil {
+			return err
+		}
+		keep = append(keep, t.JID.String())
+	}
+	return db.UnlinkGroupsExcept(jid.String(), keep)
+}
+
+// handleGroupLink records groups linked to or unlinked from a community.
+// Sub-group changes are announced in the community, parent changes in the
+// linked group itself.
+func (m *Manager) handleGroupLink(evt *events.GroupInfo) {
+	a := m.App()
+	if a == nil {
+		return
+	}
+	db := a.DB()
+	if l := evt.Link; l != nil {
+		community, group := evt.JID, l.Group.JID
+		if l.Type == types.GroupLinkChangeTypeParent {
+			community, group = l.Group.JID, evt.JID
+		}
+		if l.Type == types.GroupLinkChangeTypeSub {
+			if err := db.UpsertGroup(group.String(), l.Group.Name, "", time.Time{}); err != nil {
+				log.Printf("[Groups] Failed to store linked group %s: %v", group, err)
+			}
+		}
+		if err := db.SetGroupCommunity(community.String(), true); err != nil {
+			log.Printf("[Groups] Failed to mark %s as community: %v", community, err)
+		}
+		if err := db.LinkGroup(group.String(), community.String(), l.Group.IsDefaultSubGroup); err != nil {
+			log.Printf("[Groups] Failed to link %s to %s: %v", group, community, err)
+		}
+	}
+	if l := evt.Unlink; l != nil {
+		group := l.Group.JID
+		if l.Type == types.GroupLinkChangeTypeParent {
+			group = evt.JID
+		}
+		if err := db.LinkGroup(group.String(), "", false); err != nil {
+			log.Printf("[Groups] Failed to unlink %s: %v", group, err)
+		}
+	}
+}
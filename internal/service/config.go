@@ -1,11 +1,21 @@
 package service
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/steipete/wacli/internal/phone"
+	"github.com/steipete/wacli/internal/plugin"
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/webhook"
 )
 
 // Config holds all configuration for the WhatsApp API service.
@@ -17,99 +27,871 @@ type Config struct {
 	// Data directory (where session.db, wacli.db, media/ live)
 	DataDir string
 
-	// API authentication
-	APIKey string
+	// Postgres URL for the message store; empty uses SQLite (wacli.db in DataDir)
+	DBURL string
+
+	// SQLCipher key for the SQLite databases, given directly or in a file
+	DBKey     string
+	DBKeyFile string
+
+	// Pragmas for the SQLite connections of wacli.db
+	SQLite store.SQLiteOptions
+
+	// API authentication; the key may be given as its SHA-256 hex hash instead
+	APIKey     string
+	APIKeyHash string
+
+	// Short-lived access tokens issued by POST /auth/token
+	AccessTokenSecret string        // HMAC key; random per run if empty
+	AccessTokenTTL    time.Duration // default and maximum lifetime
+
+	// Request body size limits; zero means no limit
+	MaxBodyBytes     int64 // any request
+	MaxFileBodyBytes int64 // file sends, contact imports and replica batches
+
+	// Compress responses with zstd or gzip when the client accepts it
+	Compression bool
 
 	// Webhook settings
-	WebhookURL     string
-	WebhookSecret  string
-	WebhookRetries int
-	WebhookTimeout time.Duration
+	WebhookURL             string
+	WebhookSecret          string
+	WebhookSecretSecondary string            // also signs WebhookURL deliveries while the secret is rotated
+	WebhookEvents          []string          // event filter for WebhookURL; empty means all
+	WebhookHeaders         map[string]string // static headers for WebhookURL, e.g. Authorization
+	Webhooks               []WebhookEndpoint
+	WebhookRetries         int
+	WebhookTimeout         time.Duration
+	// Signature header name, and whether the signature covers the
+	// X-Webhook-Timestamp value as well as the payload
+	WebhookSignatureHeader string
+	WebhookSignTimestamp   bool
+	// Mutual TLS / custom CA for webhook delivery (PEM files)
+	WebhookClientCert string
+	WebhookClientKey  string
+	WebhookCAFile     string
+	// Chat filter for WebhookURL: JIDs or chat kinds (dm, group, broadcast, channel)
+	WebhookChats        []string
+	WebhookExcludeChats []string
+	// Media of incoming messages in message.received: "" (none), "base64"
+	// (inline up to WebhookMediaMaxBytes) or "url" (signed download URL)
+	WebhookMedia         string
+	WebhookMediaMaxBytes int64
+	PublicURL            string        // base URL the service is reached at, for signed media URLs
+	MediaURLSecret       string        // HMAC key for signed media URLs; random per run if empty
+	MediaURLTTL          time.Duration // how long signed media URLs stay valid
+
+	// Exec hook settings (local command run per event)
+	ExecHookCommand     string
+	ExecHookEvents      []string // event filter; empty means all
+	ExecHookConcurrency int
+	ExecHookTimeout     time.Duration
+
+	// NATS settings
+	NATSURL           string
+	NATSCredsFile     string
+	NATSSubjectPrefix string
+	NATSEvents        []string // event filter; empty means all
+	NATSJetStream     bool
+	NATSStream        string
+
+	// Kafka settings
+	KafkaBrokers       []string
+	KafkaTopic         string
+	KafkaFormat        string   // json or protobuf
+	KafkaEvents        []string // event filter; empty means all
+	KafkaSASLMechanism string   // plain, scram-sha-256, scram-sha-512
+	KafkaUsername      string
+	KafkaPassword      string
+	KafkaTLS           bool
+
+	// MQTT settings
+	MQTTURL         string
+	MQTTClientID    string
+	MQTTUsername    string
+	MQTTPassword    string
+	MQTTTopicPrefix string
+	MQTTEvents      []string // event filter; empty means all
+	MQTTQoS         int
+
+	// AMQP settings
+	AMQPURL          string
+	AMQPExchange     string
+	AMQPExchangeType string
+	AMQPRoutingKey   string   // "{type}" is replaced with the event type
+	AMQPEvents       []string // event filter; empty means all
+
+	// Replication settings (warm standby)
+	ReplicaURL      string
+	ReplicaAPIKey   string
+	ReplicaSecret   string
+	ReplicaInterval time.Duration
+
+	// Spam scoring settings
+	SpamEnabled   bool
+	SpamThreshold float64
+	SpamActions   []string // any of: tag, archive, no_webhook
+
+	// Call settings
+	CallReject        bool   // reject incoming calls, which the API cannot answer
+	CallRejectMessage string // text sent to the caller after rejecting; empty sends nothing
+
+	// Plugin settings
+	Plugins       []plugin.Plugin // run on every inbound message, in order
+	PluginTimeout time.Duration   // per plugin call
+
+	// Script settings
+	ScriptTimeout time.Duration // per onMessage call of a script
+
+	// Auto-responder settings
+	ResponderURL          string // OpenAI-compatible base URL; empty disables the responder
+	ResponderAPIKey       string
+	ResponderModel        string
+	ResponderSystemPrompt string
+	ResponderContext      int  // earlier messages of the chat sent along
+	ResponderMaxTokens    int  // zero leaves the limit to the server
+	ResponderAllChats     bool // reply in chats without an override
+	ResponderMaxPerHour   int  // replies per chat; zero means no limit
+	ResponderTimeout      time.Duration
+
+	// Retention settings
+	RetentionMaxAge     time.Duration // zero keeps messages forever
+	RetentionMaxDBBytes int64         // zero means no size limit
+	RetentionInterval   time.Duration // zero disables the janitor
 
 	// Sync settings
-	DownloadMedia   bool
-	RefreshContacts bool
-	RefreshGroups   bool
+	DownloadMedia         bool     // fetch media of incoming messages in the background
+	DownloadMediaTypes    []string // image, video, audio, document; empty means all
+	DownloadMediaMaxBytes int64    // larger files are left for on-demand download; zero means no limit
+	DownloadMediaWorkers  int
+	RefreshContacts       bool
+	RefreshGroups         bool
+
+	// Phone settings
+	DefaultCountryCode string // calling code for numbers in national format, e.g. 49; empty requires international format
+
+	// Outbox settings
+	Outbox       bool          // queue text sends while disconnected and send them on reconnect
+	OutboxMaxAge time.Duration // queued messages older than this fail instead of being sent late; zero means no limit
+
+	// Send throttle settings, applied to every outbound message
+	SendMaxPerMinute      int           // zero means no limit
+	SendBurst             int           // sends allowed back to back; zero means SendMaxPerMinute
+	SendRecipientCooldown time.Duration // minimum gap between sends to one chat; zero means none
+
+	// Humanize settings
+	Humanize            bool          // show "typing..." and pause before each send, like a person would
+	HumanizeCharsPerSec float64       // typing speed the pause is derived from
+	HumanizeMaxDelay    time.Duration // cap on the typing pause
+	HumanizeJitter      time.Duration // random extra pause of up to this much
+
+	// Outbound media settings
+	SendMaxBytes       int64    // zero means no limit beyond WhatsApp's own
+	SendAllowedTypes   []string // MIME types or wildcards like image/*; empty allows all
+	OptimizeImages     bool     // downscale and recompress large images before sending
+	ImageMaxSide       int      // longer side, in pixels; zero means no limit
+	ImageMaxBytes      int64    // zero means no limit
+	TranscodeVideo     bool     // re-encode sent videos to H.264/AAC MP4 with ffmpeg
+	TranscodeMaxSide   int      // cap on the shorter side, in pixels; zero keeps the source size
+	TranscodeVideoKbps int      // zero lets the encoder pick
+	TranscodeAudioKbps int
 
 	// Graceful shutdown timeout
 	ShutdownTimeout time.Duration
+
+	// How long the connection may be down before the systemd watchdog is
+	// no longer fed
+	WatchdogGrace time.Duration
+
+	// Events kept in memory for GET /events/recent; zero disables it
+	RecentEvents int
+
+	// envErrs are the variables load could not parse; Validate reports them
+	// so a malformed value stops startup instead of being dropped.
+	envErrs []error
+}
+
+// WebhookEndpoint configures an additional webhook target.
+type WebhookEndpoint struct {
+	URL             string            `json:"url"`
+	Secret          string            `json:"secret,omitempty"`
+	SecondarySecret string            `json:"secondary_secret,omitempty"` // also signs deliveries during a rotation
+	Events          []string          `json:"events,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	Chats           []string          `json:"chats,omitempty"`
+	ExcludeChats    []string          `json:"exclude_chats,omitempty"`
 }
 
 // DefaultConfig returns a Config with sensible defaults.
 func DefaultConfig() Config {
 	return Config{
-		Host:            "0.0.0.0",
-		Port:            8080,
-		DataDir:         "/data",
-		WebhookRetries:  3,
-		WebhookTimeout:  10 * time.Second,
-		DownloadMedia:   true,
-		RefreshContacts: true,
-		RefreshGroups:   true,
-		ShutdownTimeout: 30 * time.Second,
+		Host:                 "0.0.0.0",
+		Port:                 8080,
+		DataDir:              "/data",
+		SQLite:               store.DefaultSQLiteOptions(),
+		WebhookRetries:       3,
+		WebhookTimeout:       10 * time.Second,
+		WebhookMediaMaxBytes: 5 << 20,
+		MediaURLTTL:          24 * time.Hour,
+		AccessTokenTTL:       15 * time.Minute,
+		MaxBodyBytes:         10 << 20,
+		MaxFileBodyBytes:     150 << 20,
+		Compression:          true,
+		ExecHookConcurrency:  2,
+		ExecHookTimeout:      10 * time.Second,
+		NATSSubjectPrefix:    "wasvc",
+		NATSJetStream:        true,
+		NATSStream:           "WASVC",
+		KafkaTopic:           "wasvc.events",
+		KafkaFormat:          "json",
+		MQTTClientID:         "wasvc",
+		MQTTTopicPrefix:      "wasvc",
+		MQTTQoS:              1,
+		AMQPExchange:         "wasvc.events",
+		AMQPExchangeType:     "topic",
+		AMQPRoutingKey:       "{type}",
+		ReplicaInterval:      5 * time.Second,
+		SpamThreshold:        0.7,
+		SpamActions:          []string{"tag"},
+		PluginTimeout:        5 * time.Second,
+		ScriptTimeout:        5 * time.Second,
+		ResponderModel:       "gpt-4o-mini",
+		ResponderContext:     20,
+		ResponderMaxPerHour:  10,
+		ResponderTimeout:     30 * time.Second,
+		RetentionInterval:    time.Hour,
+		OutboxMaxAge:         24 * time.Hour,
+		HumanizeCharsPerSec:  15,
+		HumanizeMaxDelay:     8 * time.Second,
+		HumanizeJitter:       2 * time.Second,
+		DownloadMedia:        true,
+		DownloadMediaWorkers: 2,
+		RefreshContacts:      true,
+		RefreshGroups:        true,
+		ImageMaxSide:         2048,
+		ImageMaxBytes:        2 << 20,
+		TranscodeMaxSide:     720,
+		TranscodeVideoKbps:   1500,
+		TranscodeAudioKbps:   128,
+		ShutdownTimeout:      30 * time.Second,
+		WatchdogGrace:        5 * time.Minute,
+		RecentEvents:         500,
 	}
 }
 
 // LoadFromEnv loads configuration from environment variables.
 func LoadFromEnv() Config {
+	return load(os.LookupEnv)
+}
+
+// load builds a configuration from the WASVC_* variables lookup returns,
+// starting from the defaults.
+func load(lookup func(string) (string, bool)) Config {
 	cfg := DefaultConfig()
+	getenv := func(key string) string {
+		v, _ := lookup(key)
+		return v
+	}
 
-	if v := os.Getenv("WASVC_HOST"); v != "" {
+	if v := getenv("WASVC_HOST"); v != "" {
 		cfg.Host = v
 	}
-	if v := os.Getenv("WASVC_PORT"); v != "" {
+	if v := getenv("WASVC_PORT"); v != "" {
 		if port, err := strconv.Atoi(v); err == nil && port > 0 {
 			cfg.Port = port
 		}
 	}
-	if v := os.Getenv("WASVC_DATA_DIR"); v != "" {
+	if v := getenv("WASVC_DATA_DIR"); v != "" {
 		cfg.DataDir = v
 	}
-	if v := os.Getenv("WASVC_API_KEY"); v != "" {
+	if v := getenv("WASVC_DB_URL"); v != "" {
+		cfg.DBURL = v
+	}
+	if v := getenv("WASVC_DB_KEY"); v != "" {
+		cfg.DBKey = v
+	}
+	if v := getenv("WASVC_DB_KEY_FILE"); v != "" {
+		cfg.DBKeyFile = v
+	}
+	if v := getenv("WASVC_SQLITE_JOURNAL_MODE"); v != "" {
+		cfg.SQLite.JournalMode = strings.ToUpper(v)
+	}
+	if v := getenv("WASVC_SQLITE_SYNCHRONOUS"); v != "" {
+		cfg.SQLite.Synchronous = strings.ToUpper(v)
+	}
+	if v := getenv("WASVC_SQLITE_BUSY_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.SQLite.BusyTimeout = d
+		}
+	}
+	if v := getenv("WASVC_SQLITE_CACHE_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n != 0 {
+			cfg.SQLite.CacheSize = n
+		}
+	}
+	if v := getenv("WASVC_SQLITE_MMAP_SIZE"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			if n <= 0 {
+				n = -1 // 0 disables memory mapping, as in SQLite
+			}
+			cfg.SQLite.MmapSize = n
+		}
+	}
+	if v := getenv("WASVC_API_KEY"); v != "" {
 		cfg.APIKey = v
 	}
-	if v := os.Getenv("WASVC_WEBHOOK_URL"); v != "" {
+	if v := getenv("WASVC_API_KEY_HASH"); v != "" {
+		cfg.APIKeyHash = strings.ToLower(strings.TrimSpace(v))
+	}
+	if v := getenv("WASVC_MAX_BODY_MB"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+			cfg.MaxBodyBytes = n << 20
+		}
+	}
+	if v := getenv("WASVC_MAX_FILE_BODY_MB"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+			cfg.MaxFileBodyBytes = n << 20
+		}
+	}
+	if v := getenv("WASVC_COMPRESSION"); v != "" {
+		cfg.Compression = parseBool(v, true)
+	}
+	if v := getenv("WASVC_ACCESS_TOKEN_SECRET"); v != "" {
+		cfg.AccessTokenSecret = v
+	}
+	if v := getenv("WASVC_ACCESS_TOKEN_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.AccessTokenTTL = d
+		}
+	}
+	if v := getenv("WASVC_WEBHOOK_URL"); v != "" {
 		cfg.WebhookURL = v
 	}
-	if v := os.Getenv("WASVC_WEBHOOK_SECRET"); v != "" {
+	if v := getenv("WASVC_WEBHOOK_SECRET"); v != "" {
 		cfg.WebhookSecret = v
 	}
-	if v := os.Getenv("WASVC_WEBHOOK_RETRIES"); v != "" {
+	if v := getenv("WASVC_WEBHOOK_SECRET_SECONDARY"); v != "" {
+		cfg.WebhookSecretSecondary = v
+	}
+	if v := getenv("WASVC_WEBHOOK_EVENTS"); v != "" {
+		cfg.WebhookEvents = splitList(v)
+	}
+	if v := getenv("WASVC_WEBHOOK_CHATS"); v != "" {
+		cfg.WebhookChats = splitList(v)
+	}
+	if v := getenv("WASVC_WEBHOOK_EXCLUDE_CHATS"); v != "" {
+		cfg.WebhookExcludeChats = splitList(v)
+	}
+	if v := getenv("WASVC_WEBHOOK_HEADERS"); v != "" {
+		var headers map[string]string
+		if err := json.Unmarshal([]byte(v), &headers); err == nil {
+			cfg.WebhookHeaders = headers
+		} else {
+			cfg.envErrs = append(cfg.envErrs, fmt.Errorf("invalid WASVC_WEBHOOK_HEADERS: %w", err))
+		}
+	}
+	if v := getenv("WASVC_WEBHOOKS"); v != "" {
+		var endpoints []WebhookEndpoint
+		if err := json.Unmarshal([]byte(v), &endpoints); err == nil {
+			cfg.Webhooks = endpoints
+		} else {
+			cfg.envErrs = append(cfg.envErrs, fmt.Errorf("invalid WASVC_WEBHOOKS: %w", err))
+		}
+	}
+	if v := getenv("WASVC_WEBHOOK_RETRIES"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
 			cfg.WebhookRetries = n
 		}
 	}
-	if v := os.Getenv("WASVC_WEBHOOK_TIMEOUT"); v != "" {
+	if v := getenv("WASVC_WEBHOOK_TIMEOUT"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
 			cfg.WebhookTimeout = d
 		}
 	}
-	if v := os.Getenv("WASVC_DOWNLOAD_MEDIA"); v != "" {
+	if v := getenv("WASVC_WEBHOOK_SIGNATURE_HEADER"); v != "" {
+		cfg.WebhookSignatureHeader = strings.TrimSpace(v)
+	}
+	if v := getenv("WASVC_WEBHOOK_SIGN_TIMESTAMP"); v != "" {
+		cfg.WebhookSignTimestamp = parseBool(v, false)
+	}
+	if v := getenv("WASVC_WEBHOOK_CLIENT_CERT"); v != "" {
+		cfg.WebhookClientCert = v
+	}
+	if v := getenv("WASVC_WEBHOOK_CLIENT_KEY"); v != "" {
+		cfg.WebhookClientKey = v
+	}
+	if v := getenv("WASVC_WEBHOOK_CA_FILE"); v != "" {
+		cfg.WebhookCAFile = v
+	}
+	if v := getenv("WASVC_WEBHOOK_MEDIA"); v != "" {
+		cfg.WebhookMedia = strings.ToLower(strings.TrimSpace(v))
+	}
+	if v := getenv("WASVC_WEBHOOK_MEDIA_MAX_MB"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+			cfg.WebhookMediaMaxBytes = n << 20
+		}
+	}
+	if v := getenv("WASVC_PUBLIC_URL"); v != "" {
+		cfg.PublicURL = strings.TrimRight(strings.TrimSpace(v), "/")
+	}
+	if v := getenv("WASVC_MEDIA_URL_SECRET"); v != "" {
+		cfg.MediaURLSecret = v
+	}
+	if v := getenv("WASVC_MEDIA_URL_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.MediaURLTTL = d
+		}
+	}
+	if v := getenv("WASVC_EXEC_HOOK_COMMAND"); v != "" {
+		cfg.ExecHookCommand = v
+	}
+	if v := getenv("WASVC_EXEC_HOOK_EVENTS"); v != "" {
+		cfg.ExecHookEvents = splitList(v)
+	}
+	if v := getenv("WASVC_EXEC_HOOK_CONCURRENCY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.ExecHookConcurrency = n
+		}
+	}
+	if v := getenv("WASVC_EXEC_HOOK_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.ExecHookTimeout = d
+		}
+	}
+	if v := getenv("WASVC_NATS_URL"); v != "" {
+		cfg.NATSURL = v
+	}
+	if v := getenv("WASVC_NATS_CREDS"); v != "" {
+		cfg.NATSCredsFile = v
+	}
+	if v := getenv("WASVC_NATS_SUBJECT_PREFIX"); v != "" {
+		cfg.NATSSubjectPrefix = v
+	}
+	if v := getenv("WASVC_NATS_EVENTS"); v != "" {
+		cfg.NATSEvents = splitList(v)
+	}
+	if v := getenv("WASVC_NATS_JETSTREAM"); v != "" {
+		cfg.NATSJetStream = parseBool(v, true)
+	}
+	if v := getenv("WASVC_NATS_STREAM"); v != "" {
+		cfg.NATSStream = v
+	}
+	if v := getenv("WASVC_KAFKA_BROKERS"); v != "" {
+		cfg.KafkaBrokers = splitList(v)
+	}
+	if v := getenv("WASVC_KAFKA_TOPIC"); v != "" {
+		cfg.KafkaTopic = v
+	}
+	if v := getenv("WASVC_KAFKA_FORMAT"); v != "" {
+		cfg.KafkaFormat = strings.ToLower(v)
+	}
+	if v := getenv("WASVC_KAFKA_EVENTS"); v != "" {
+		cfg.KafkaEvents = splitList(v)
+	}
+	if v := getenv("WASVC_KAFKA_SASL_MECHANISM"); v != "" {
+		cfg.KafkaSASLMechanism = strings.ToLower(v)
+	}
+	if v := getenv("WASVC_KAFKA_USERNAME"); v != "" {
+		cfg.KafkaUsername = v
+	}
+	if v := getenv("WASVC_KAFKA_PASSWORD"); v != "" {
+		cfg.KafkaPassword = v
+	}
+	if v := getenv("WASVC_KAFKA_TLS"); v != "" {
+		cfg.KafkaTLS = parseBool(v, false)
+	}
+	if v := getenv("WASVC_MQTT_URL"); v != "" {
+		cfg.MQTTURL = v
+	}
+	if v := getenv("WASVC_MQTT_CLIENT_ID"); v != "" {
+		cfg.MQTTClientID = v
+	}
+	if v := getenv("WASVC_MQTT_USERNAME"); v != "" {
+		cfg.MQTTUsername = v
+	}
+	if v := getenv("WASVC_MQTT_PASSWORD"); v != "" {
+		cfg.MQTTPassword = v
+	}
+	if v := getenv("WASVC_MQTT_TOPIC_PREFIX"); v != "" {
+		cfg.MQTTTopicPrefix = v
+	}
+	if v := getenv("WASVC_MQTT_EVENTS"); v != "" {
+		cfg.MQTTEvents = splitList(v)
+	}
+	if v := getenv("WASVC_MQTT_QOS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MQTTQoS = n
+		}
+	}
+	if v := getenv("WASVC_AMQP_URL"); v != "" {
+		cfg.AMQPURL = v
+	}
+	if v, ok := lookup("WASVC_AMQP_EXCHANGE"); ok {
+		cfg.AMQPExchange = v // may be empty to use the default exchange
+	}
+	if v := getenv("WASVC_AMQP_EXCHANGE_TYPE"); v != "" {
+		cfg.AMQPExchangeType = v
+	}
+	if v := getenv("WASVC_AMQP_ROUTING_KEY"); v != "" {
+		cfg.AMQPRoutingKey = v
+	}
+	if v := getenv("WASVC_AMQP_EVENTS"); v != "" {
+		cfg.AMQPEvents = splitList(v)
+	}
+	if v := getenv("WASVC_REPLICA_URL"); v != "" {
+		cfg.ReplicaURL = v
+	}
+	if v := getenv("WASVC_REPLICA_API_KEY"); v != "" {
+		cfg.ReplicaAPIKey = v
+	}
+	if v := getenv("WASVC_REPLICA_SECRET"); v != "" {
+		cfg.ReplicaSecret = v
+	}
+	if v := getenv("WASVC_REPLICA_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.ReplicaInterval = d
+		}
+	}
+	if v := getenv("WASVC_CALL_REJECT"); v != "" {
+		cfg.CallReject = parseBool(v, false)
+	}
+	if v := getenv("WASVC_CALL_REJECT_MESSAGE"); v != "" {
+		cfg.CallRejectMessage = v
+	}
+	if v := getenv("WASVC_SPAM_ENABLED"); v != "" {
+		cfg.SpamEnabled = parseBool(v, false)
+	}
+	if v := getenv("WASVC_SPAM_THRESHOLD"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f <= 1 {
+			cfg.SpamThreshold = f
+		}
+	}
+	if v, ok := lookup("WASVC_SPAM_ACTIONS"); ok {
+		cfg.SpamActions = splitList(v)
+	}
+	if v := getenv("WASVC_PLUGINS"); v != "" {
+		var plugins []plugin.Plugin
+		if err := json.Unmarshal([]byte(v), &plugins); err == nil {
+			cfg.Plugins = plugins
+		} else {
+			cfg.envErrs = append(cfg.envErrs, fmt.Errorf("invalid WASVC_PLUGINS: %w", err))
+		}
+	}
+	if v := getenv("WASVC_PLUGIN_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.PluginTimeout = d
+		}
+	}
+	if v := getenv("WASVC_SCRIPT_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.ScriptTimeout = d
+		}
+	}
+	if v := getenv("WASVC_RESPONDER_URL"); v != "" {
+		cfg.ResponderURL = v
+	}
+	if v := getenv("WASVC_RESPONDER_API_KEY"); v != "" {
+		cfg.ResponderAPIKey = v
+	}
+	if v := getenv("WASVC_RESPONDER_MODEL"); v != "" {
+		cfg.ResponderModel = v
+	}
+	if v := getenv("WASVC_RESPONDER_SYSTEM_PROMPT"); v != "" {
+		cfg.ResponderSystemPrompt = v
+	}
+	if v := getenv("WASVC_RESPONDER_CONTEXT_MESSAGES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.ResponderContext = n
+		}
+	}
+	if v := getenv("WASVC_RESPONDER_MAX_TOKENS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.ResponderMaxTokens = n
+		}
+	}
+	if v := getenv("WASVC_RESPONDER_ALL_CHATS"); v != "" {
+		cfg.ResponderAllChats = parseBool(v, false)
+	}
+	if v := getenv("WASVC_RESPONDER_MAX_PER_HOUR"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.ResponderMaxPerHour = n
+		}
+	}
+	if v := getenv("WASVC_RESPONDER_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.ResponderTimeout = d
+		}
+	}
+	if v := getenv("WASVC_RETENTION_MAX_AGE"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.RetentionMaxAge = d
+		}
+	}
+	if v := getenv("WASVC_RETENTION_MAX_DB_MB"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+			cfg.RetentionMaxDBBytes = n << 20
+		}
+	}
+	if v := getenv("WASVC_RETENTION_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.RetentionInterval = d
+		}
+	}
+	if v := getenv("WASVC_DOWNLOAD_MEDIA"); v != "" {
 		cfg.DownloadMedia = parseBool(v, true)
 	}
-	if v := os.Getenv("WASVC_REFRESH_CONTACTS"); v != "" {
+	if v := getenv("WASVC_DOWNLOAD_MEDIA_TYPES"); v != "" {
+		cfg.DownloadMediaTypes = splitList(strings.ToLower(v))
+	}
+	if v := getenv("WASVC_DOWNLOAD_MEDIA_MAX_MB"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+			cfg.DownloadMediaMaxBytes = n << 20
+		}
+	}
+	if v := getenv("WASVC_DOWNLOAD_MEDIA_WORKERS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.DownloadMediaWorkers = n
+		}
+	}
+	if v := getenv("WASVC_REFRESH_CONTACTS"); v != "" {
 		cfg.RefreshContacts = parseBool(v, true)
 	}
-	if v := os.Getenv("WASVC_REFRESH_GROUPS"); v != "" {
+	if v := getenv("WASVC_REFRESH_GROUPS"); v != "" {
 		cfg.RefreshGroups = parseBool(v, true)
 	}
-	if v := os.Getenv("WASVC_SHUTDOWN_TIMEOUT"); v != "" {
+	if v := getenv("WASVC_DEFAULT_COUNTRY_CODE"); v != "" {
+		cfg.DefaultCountryCode = strings.TrimSpace(v)
+	}
+	if v := getenv("WASVC_OUTBOX"); v != "" {
+		cfg.Outbox = parseBool(v, false)
+	}
+	if v := getenv("WASVC_OUTBOX_MAX_AGE"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.OutboxMaxAge = d
+		}
+	}
+	if v := getenv("WASVC_SEND_MAX_PER_MINUTE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.SendMaxPerMinute = n
+		}
+	}
+	if v := getenv("WASVC_SEND_BURST"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.SendBurst = n
+		}
+	}
+	if v := getenv("WASVC_SEND_RECIPIENT_COOLDOWN"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.SendRecipientCooldown = d
+		}
+	}
+	if v := getenv("WASVC_HUMANIZE"); v != "" {
+		cfg.Humanize = parseBool(v, false)
+	}
+	if v := getenv("WASVC_HUMANIZE_CHARS_PER_SEC"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 {
+			cfg.HumanizeCharsPerSec = f
+		}
+	}
+	if v := getenv("WASVC_HUMANIZE_MAX_DELAY"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.HumanizeMaxDelay = d
+		}
+	}
+	if v := getenv("WASVC_HUMANIZE_JITTER"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.HumanizeJitter = d
+		}
+	}
+	if v := getenv("WASVC_SEND_MAX_MB"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+			cfg.SendMaxBytes = n << 20
+		}
+	}
+	if v := getenv("WASVC_SEND_ALLOWED_TYPES"); v != "" {
+		cfg.SendAllowedTypes = splitList(strings.ToLower(v))
+	}
+	if v := getenv("WASVC_OPTIMIZE_IMAGES"); v != "" {
+		cfg.OptimizeImages = parseBool(v, false)
+	}
+	if v := getenv("WASVC_IMAGE_MAX_SIDE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.ImageMaxSide = n
+		}
+	}
+	if v := getenv("WASVC_IMAGE_MAX_MB"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 {
+			cfg.ImageMaxBytes = int64(f * (1 << 20))
+		}
+	}
+	if v := getenv("WASVC_TRANSCODE_VIDEO"); v != "" {
+		cfg.TranscodeVideo = parseBool(v, false)
+	}
+	if v := getenv("WASVC_TRANSCODE_MAX_SIDE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.TranscodeMaxSide = n
+		}
+	}
+	if v := getenv("WASVC_TRANSCODE_VIDEO_KBPS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.TranscodeVideoKbps = n
+		}
+	}
+	if v := getenv("WASVC_TRANSCODE_AUDIO_KBPS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.TranscodeAudioKbps = n
+		}
+	}
+	if v := getenv("WASVC_SHUTDOWN_TIMEOUT"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
 			cfg.ShutdownTimeout = d
 		}
 	}
+	if v := getenv("WASVC_WATCHDOG_GRACE"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.WatchdogGrace = d
+		}
+	}
+	if v := getenv("WASVC_RECENT_EVENTS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.RecentEvents = n
+		}
+	}
 
 	return cfg
 }
 
 // Validate checks that the configuration is valid.
 func (c Config) Validate() error {
+	if len(c.envErrs) > 0 {
+		return errors.Join(c.envErrs...)
+	}
 	if c.Port <= 0 || c.Port > 65535 {
 		return fmt.Errorf("invalid port: %d", c.Port)
 	}
 	if strings.TrimSpace(c.DataDir) == "" {
 		return fmt.Errorf("data directory is required")
 	}
+	if c.DBURL != "" && !strings.HasPrefix(c.DBURL, "postgres://") && !strings.HasPrefix(c.DBURL, "postgresql://") {
+		return fmt.Errorf("WASVC_DB_URL must be a postgres:// URL")
+	}
+	if c.DBKey != "" && c.DBKeyFile != "" {
+		return fmt.Errorf("set only one of WASVC_DB_KEY and WASVC_DB_KEY_FILE")
+	}
+	if err := c.SQLite.Validate(); err != nil {
+		return fmt.Errorf("WASVC_SQLITE_*: %w", err)
+	}
+	if c.APIKeyHash != "" {
+		if b, err := hex.DecodeString(c.APIKeyHash); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("WASVC_API_KEY_HASH must be a hex-encoded SHA-256 hash")
+		}
+	}
+	for _, action := range c.SpamActions {
+		switch action {
+		case "tag", "archive", "no_webhook":
+		default:
+			return fmt.Errorf("invalid spam action: %s", action)
+		}
+	}
+	for _, t := range c.DownloadMediaTypes {
+		switch t {
+		case "image", "video", "audio", "document":
+		default:
+			return fmt.Errorf("invalid media type in WASVC_DOWNLOAD_MEDIA_TYPES: %s", t)
+		}
+	}
+	if c.DownloadMedia && c.DownloadMediaWorkers <= 0 {
+		return fmt.Errorf("WASVC_DOWNLOAD_MEDIA_WORKERS must be positive")
+	}
+	if _, err := phone.ParseCountryCode(c.DefaultCountryCode); err != nil {
+		return fmt.Errorf("WASVC_DEFAULT_COUNTRY_CODE: %w", err)
+	}
+	for _, t := range c.SendAllowedTypes {
+		if kind, sub, ok := strings.Cut(t, "/"); !ok || kind == "" || kind == "*" || sub == "" {
+			return fmt.Errorf("invalid WASVC_SEND_ALLOWED_TYPES entry: %s", t)
+		}
+	}
+	// Deliveries, queued events and stats are keyed by URL, so each
+	// endpoint may appear once across WASVC_WEBHOOK_URL and the list.
+	webhookURLs := map[string]bool{c.WebhookURL: c.WebhookURL != ""}
+	for i, wh := range c.Webhooks {
+		if strings.TrimSpace(wh.URL) == "" {
+			return fmt.Errorf("webhook %d: url is required", i)
+		}
+		if webhookURLs[wh.URL] {
+			return fmt.Errorf("webhook %d: duplicate url %s", i, wh.URL)
+		}
+		webhookURLs[wh.URL] = true
+		if wh.SecondarySecret != "" && wh.Secret == "" {
+			return fmt.Errorf("webhook %d: secondary_secret requires secret", i)
+		}
+		if err := validateHeaders(wh.Headers); err != nil {
+			return fmt.Errorf("webhook %d: %w", i, err)
+		}
+		if err := (webhook.ChatFilter{Chats: wh.Chats, ExcludeChats: wh.ExcludeChats}).Validate(); err != nil {
+			return fmt.Errorf("webhook %d: %w", i, err)
+		}
+	}
+	if err := (webhook.ChatFilter{Chats: c.WebhookChats, ExcludeChats: c.WebhookExcludeChats}).Validate(); err != nil {
+		return fmt.Errorf("WASVC_WEBHOOK_CHATS: %w", err)
+	}
+	for i, p := range c.Plugins {
+		if (strings.TrimSpace(p.Command) == "") == (strings.TrimSpace(p.URL) == "") {
+			return fmt.Errorf("plugin %d: exactly one of command and url is required", i)
+		}
+	}
+	if c.ResponderURL != "" {
+		if u, err := url.Parse(c.ResponderURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid responder URL: %s", c.ResponderURL)
+		}
+		if strings.TrimSpace(c.ResponderModel) == "" {
+			return fmt.Errorf("responder model is required")
+		}
+	}
+	if err := validateHeaders(c.WebhookHeaders); err != nil {
+		return fmt.Errorf("WASVC_WEBHOOK_HEADERS: %w", err)
+	}
+	if c.WebhookSecretSecondary != "" && c.WebhookSecret == "" {
+		return fmt.Errorf("WASVC_WEBHOOK_SECRET_SECONDARY requires WASVC_WEBHOOK_SECRET")
+	}
+	if c.WebhookSignatureHeader != "" {
+		if err := validateHeaders(map[string]string{c.WebhookSignatureHeader: ""}); err != nil {
+			return fmt.Errorf("WASVC_WEBHOOK_SIGNATURE_HEADER: %w", err)
+		}
+	}
+	switch c.KafkaFormat {
+	case "json", "protobuf":
+	default:
+		return fmt.Errorf("invalid kafka format: %s", c.KafkaFormat)
+	}
+	switch c.KafkaSASLMechanism {
+	case "", "plain", "scram-sha-256", "scram-sha-512":
+	default:
+		return fmt.Errorf("invalid kafka SASL mechanism: %s", c.KafkaSASLMechanism)
+	}
+	switch c.AMQPExchangeType {
+	case "topic", "direct", "fanout", "headers":
+	default:
+		return fmt.Errorf("invalid AMQP exchange type: %s", c.AMQPExchangeType)
+	}
+	if c.MQTTQoS < 0 || c.MQTTQoS > 2 {
+		return fmt.Errorf("invalid MQTT QoS: %d", c.MQTTQoS)
+	}
+	if (c.WebhookClientCert == "") != (c.WebhookClientKey == "") {
+		return fmt.Errorf("WASVC_WEBHOOK_CLIENT_CERT and WASVC_WEBHOOK_CLIENT_KEY must be set together")
+	}
+	switch c.WebhookMedia {
+	case "", "base64", "url":
+	default:
+		return fmt.Errorf("invalid WASVC_WEBHOOK_MEDIA: %s (want base64 or url)", c.WebhookMedia)
+	}
+	if c.PublicURL != "" {
+		if u, err := url.Parse(c.PublicURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid WASVC_PUBLIC_URL: %s", c.PublicURL)
+		}
+	}
+	if c.WebhookMedia == "url" && c.PublicURL == "" {
+		return fmt.Errorf("WASVC_WEBHOOK_MEDIA=url requires WASVC_PUBLIC_URL")
+	}
 	return nil
 }
 
@@ -118,6 +900,78 @@ func (c Config) Addr() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
 }
 
+// APIKeyAuth reports whether requests must carry an API key.
+func (c Config) APIKeyAuth() bool {
+	return c.APIKey != "" || c.APIKeyHash != ""
+}
+
+// AutoDownloads reports whether media of the given type and size is fetched
+// automatically when a message arrives.
+func (c Config) AutoDownloads(mediaType string, size uint64) bool {
+	if !c.DownloadMedia || mediaType == "" {
+		return false
+	}
+	if c.DownloadMediaMaxBytes > 0 && size > uint64(c.DownloadMediaMaxBytes) {
+		return false
+	}
+	if len(c.DownloadMediaTypes) == 0 {
+		return true
+	}
+	for _, t := range c.DownloadMediaTypes {
+		if t == mediaType || t == "video" && mediaType == "gif" {
+			return true
+		}
+	}
+	return false
+}
+
+// HasSpamAction reports whether the given action is enabled for spam above threshold.
+func (c Config) HasSpamAction(action string) bool {
+	for _, a := range c.SpamActions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// hasWebhook reports whether u is one of the configured webhook endpoints.
+func (c Config) hasWebhook(u string) bool {
+	if u == c.WebhookURL {
+		return true
+	}
+	for _, wh := range c.Webhooks {
+		if wh.URL == u {
+			return true
+		}
+	}
+	return false
+}
+
+// validateHeaders rejects header names that net/http would refuse to send.
+func validateHeaders(headers map[string]string) error {
+	for name, value := range headers {
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("invalid value for header %s", name)
+		}
+	}
+	return nil
+}
+
+// splitList splits a comma-separated value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
 func parseBool(s string, defaultVal bool) bool {
 	s = strings.ToLower(strings.TrimSpace(s))
 	switch s {
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateRejectsDuplicateWebhookURLs(t *testing.T) {
+	cases := []struct {
+		name     string
+		url      string
+		webhooks []string
+		wantErr  string
+	}{
+		{"distinct", "https://a.example/hook", []string{"https://b.example/hook", "https://c.example/hook"}, ""},
+		{"list only", "", []string{"https://a.example/hook"}, ""},
+		{"within list", "", []string{"https://a.example/hook", "https://a.example/hook"}, "webhook 1: duplicate url"},
+		{"url and list", "https://a.example/hook", []string{"https://b.example/hook", "https://a.example/hook"}, "webhook 1: duplicate url"},
+	}
+	for _, c := range cases {
+		cfg := DefaultConfig()
+		cfg.WebhookURL = c.url
+		for _, u := range c.webhooks {
+			cfg.Webhooks = append(cfg.Webhooks, WebhookEndpoint{URL: u})
+		}
+		err := cfg.Validate()
+		switch {
+		case c.wantErr == "" && err != nil:
+			t.Fatalf("%s: unexpected error: %v", c.name, err)
+		case c.wantErr != "" && (err == nil || !strings.Contains(err.Error(), c.wantErr)):
+			t.Fatalf("%s: error %v, want %q", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestValidateReportsMalformedEnv(t *testing.T) {
+	cases := []struct {
+		name, key, value, wantErr string
+	}{
+		{"webhooks", "WASVC_WEBHOOKS", `[{"url":`, "invalid WASVC_WEBHOOKS"},
+		{"webhooks object", "WASVC_WEBHOOKS", `{"url":"https://a.example/hook"}`, "invalid WASVC_WEBHOOKS"},
+		{"webhooks valid", "WASVC_WEBHOOKS", `[{"url":"https://a.example/hook"}]`, ""},
+		{"headers", "WASVC_WEBHOOK_HEADERS", `Authorization: Bearer abc`, "invalid WASVC_WEBHOOK_HEADERS"},
+		{"headers list", "WASVC_WEBHOOK_HEADERS", `["Authorization"]`, "invalid WASVC_WEBHOOK_HEADERS"},
+		{"headers valid", "WASVC_WEBHOOK_HEADERS", `{"Authorization":"Bearer abc"}`, ""},
+		{"plugins", "WASVC_PLUGINS", `[{"name":"crm","url":}]`, "invalid WASVC_PLUGINS"},
+		{"plugins valid", "WASVC_PLUGINS", `[{"name":"crm","url":"http://crm:9000/hook"}]`, ""},
+	}
+	for _, c := range cases {
+		cfg := load(func(key string) (string, bool) {
+			if key == c.key {
+				return c.value, true
+			}
+			return "", false
+		})
+		err := cfg.Validate()
+		switch {
+		case c.wantErr == "" && err != nil:
+			t.Fatalf("%s: unexpected error: %v", c.name, err)
+		case c.wantErr != "" && (err == nil || !strings.Contains(err.Error(), c.wantErr)):
+			t.Fatalf("%s: error %v, want %q", c.name, err, c.wantErr)
+		}
+	}
+}
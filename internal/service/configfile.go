@@ -0,0 +1,162 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// jsonKeys are settings whose environment form is a JSON document. Their
+// file values are passed through as JSON instead of being flattened.
+var jsonKeys = map[string]bool{
+	"WASVC_WEBHOOK_HEADERS": true,
+	"WASVC_WEBHOOKS":        true,
+	"WASVC_PLUGINS":         true,
+}
+
+// LoadConfig loads configuration from the file named by WASVC_CONFIG, if
+// set, with environment variables taking precedence over it. Without
+// WASVC_CONFIG it is the same as LoadFromEnv.
+func LoadConfig() (Config, error) {
+	path := os.Getenv("WASVC_CONFIG")
+	if path == "" {
+		return LoadFromEnv(), nil
+	}
+	file, err := readConfigFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	used := make(map[string]bool)
+	cfg := load(func(key string) (string, bool) {
+		used[key] = true
+		if v, ok := os.LookupEnv(key); ok {
+			return v, true
+		}
+		v, ok := file[key]
+		return v, ok
+	})
+
+	var unknown []string
+	for key := range file {
+		if !used[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		log.Printf("[Config] Ignoring unknown setting %s in %s", strings.ToLower(strings.TrimPrefix(key, "WASVC_")), path)
+	}
+	return cfg, nil
+}
+
+// Settings returns the WASVC_* settings in effect: those from the file
+// named by WASVC_CONFIG, overridden by the environment. Values are raw and
+// may contain secrets.
+func Settings() (map[string]string, error) {
+	out := make(map[string]string)
+	if path := os.Getenv("WASVC_CONFIG"); path != "" {
+		file, err := readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		out = file
+	}
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(k, "WASVC_") {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+// readConfigFile parses a YAML, TOML or JSON config file into the WASVC_*
+// variables it stands for. Nested keys are joined with underscores, so
+//
+//	webhook:
+//	  url: https://example.com/hook
+//
+// sets WASVC_WEBHOOK_URL. Lists of plain values become comma-separated.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	var doc map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	case ".toml":
+		err = toml.Unmarshal(data, &doc)
+	case ".json":
+		// Keep numbers as written; float64 would print 1000000 as 1e+06.
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		err = dec.Decode(&doc)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format %q (use .yaml, .toml or .json)", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	out := make(map[string]string)
+	for k, v := range doc {
+		if err := flattenSetting(out, "WASVC_"+settingKey(k), v); err != nil {
+			return nil, fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+	return out, nil
+}
+
+// flattenSetting stores v under key, descending into tables.
+func flattenSetting(out map[string]string, key string, v interface{}) error {
+	if jsonKeys[key] {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		out[key] = string(raw)
+		return nil
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		for k, sub := range v {
+			if err := flattenSetting(out, key+"_"+settingKey(k), sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+				return fmt.Errorf("%s: expected a list of plain values", strings.ToLower(strings.TrimPrefix(key, "WASVC_")))
+			}
+			items = append(items, fmt.Sprint(item))
+		}
+		out[key] = strings.Join(items, ",")
+		return nil
+	default:
+		out[key] = fmt.Sprint(v)
+		return nil
+	}
+}
+
+// settingKey turns a file key like "max-upload" into its variable form.
+func settingKey(k string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(k))
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// contactCSVHeader is the column order written by ExportContacts.
+var contactCSVHeader = []string{"phone", "name", "alias", "tags", "jid"}
+
+// importBatchSize is how many contacts are written per transaction.
+const importBatchSize = 500
+
+// ContactImportResult summarizes a CSV import.
+type ContactImportResult struct {
+	Rows     int
+	Imported int
+	Skipped  []ContactImportError
+	Resolved bool // JIDs were checked against WhatsApp
+}
+
+// ContactImportError explains why a CSV row was not imported.
+type ContactImportError struct {
+	Line  int
+	Phone string
+	Error string
+}
+
+type contactRow struct {
+	line   int
+	phone  string
+	jid    string
+	name   string
+	alias  string
+	tags   []string
+	errMsg string
+}
+
+// ImportContacts reads a CSV with a header row naming phone, name, alias,
+// tags and optionally jid columns, in any order. Tags are separated by
+// semicolons. Rows with a jid are stored under it; otherwise the phone
+// number is resolved to its canonical JID while connected, skipping numbers
+// not on WhatsApp, and taken as is while offline.
+func (m *Manager) ImportContacts(ctx context.Context, r io.Reader) (*ContactImportResult, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+
+	rows, err := readContactCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	res := &ContactImportResult{Rows: len(rows), Resolved: m.state.State().IsReady() && a.WA() != nil}
+
+	var phones []string
+	for _, row := range rows {
+		if row.errMsg == "" && row.jid == "" {
+			phones = append(phones, row.phone)
+		}
+	}
+	var checks []NumberCheck
+	if res.Resolved && len(phones) > 0 {
+		if checks, err = m.CheckNumbers(ctx, phones); err != nil {
+			return nil, err
+		}
+	}
+
+	var batch []store.ContactImport
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := a.DB().ImportContacts(batch); err != nil {
+			return err
+		}
+		res.Imported += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+	for _, row := range rows {
+		if row.errMsg == "" && row.jid == "" {
+			row.jid, row.errMsg = resolveContactRow(row.phone, res.Resolved, &checks)
+		}
+		if row.errMsg != "" {
+			res.Skipped = append(res.Skipped, ContactImportError{Line: row.line, Phone: row.phone, Error: row.errMsg})
+			continue
+		}
+		jid, _ := types.ParseJID(row.jid)
+		phone := ""
+		if jid.Server == types.DefaultUserServer {
+			phone = jid.User
+		}
+		batch = append(batch, store.ContactImport{JID: row.jid, Phone: phone, Name: row.name, Alias: row.alias, Tags: row.tags})
+		if len(batch) >= importBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// resolveContactRow maps a phone number to a JID, consuming the next result
+// of checks when resolved.
+func resolveContactRow(phone string, resolved bool, checks *[]NumberCheck) (jid, errMsg string) {
+	if !resolved {
+		q := normalizePhone(phone)
+		if q == "" {
+			return "", "invalid phone number"
+		}
+		return types.NewJID(strings.TrimPrefix(q, "+"), types.DefaultUserServer).String(), ""
+	}
+	c := (*checks)[0]
+	*checks = (*checks)[1:]
+	switch {
+	case c.Error != "":
+		return "", c.Error
+	case !c.Registered:
+		return "", "not on WhatsApp"
+	}
+	return c.JID, ""
+}
+
+func readContactCSV(r io.Reader) ([]contactRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: empty file", ErrInvalidCSV)
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCSV, err)
+	}
+	cols := map[string]int{}
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if _, dup := cols[h]; !dup {
+			cols[h] = i
+		}
+	}
+	_, hasPhone := cols["phone"]
+	_, hasJID := cols["jid"]
+	if !hasPhone && !hasJID {
+		return nil, fmt.Errorf("%w: header needs a phone or jid column", ErrInvalidCSV)
+	}
+
+	var rows []contactRow
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidCSV, err)
+		}
+		field := func(name string) string {
+			if i, ok := cols[name]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		line, _ := cr.FieldPos(0)
+		row := contactRow{line: line, phone: field("phone"), name: field("name"), alias: field("alias")}
+		for _, t := range strings.Split(field("tags"), ";") {
+			if t = strings.TrimSpace(t); t != "" {
+				row.tags = append(row.tags, t)
+			}
+		}
+		if s := field("jid"); s != "" {
+			jid, err := wa.ParseUserOrJID(s)
+			if err != nil || (jid.Server != types.DefaultUserServer && jid.Server != types.HiddenUserServer) {
+				row.errMsg = "invalid JID"
+			} else {
+				row.jid = jid.ToNonAD().String()
+			}
+		} else if row.phone == "" {
+			continue // blank line
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ExportContacts writes every contact as CSV in the format ImportContacts
+// reads, returning the number of contacts written.
+func (m *Manager) ExportContacts(w io.Writer) (int, error) {
+	a := m.App()
+	if a == nil {
+		return 0, errNotInitialized
+	}
+	contacts, err := a.DB().ListAllContacts()
+	if err != nil {
+		return 0, err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(contactCSVHeader); err != nil {
+		return 0, err
+	}
+	for _, c := range contacts {
+		phone := ""
+		if jid, err := types.ParseJID(c.JID); err == nil && jid.Server == types.DefaultUserServer {
+			phone = "+" + jid.User
+		}
+		if err := cw.Write([]string{phone, c.Name, c.Alias, strings.Join(c.Tags, ";"), c.JID}); err != nil {
+			return 0, err
+		}
+	}
+	cw.Flush()
+	return len(contacts), cw.Error()
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// Download states reported by GetDownloadStatus.
+const (
+	DownloadNotStarted = "not_started"
+	DownloadInProgress = "downloading"
+	DownloadPartial    = "partial" // interrupted, will resume from PartialBytes
+	DownloadFailed     = "failed"
+	DownloadCompleted  = "completed"
+)
+
+// downloadJobTTL is how long a finished download stays in memory for status queries.
+const downloadJobTTL = 10 * time.Minute
+
+// DownloadStatus describes the progress of a media download.
+type DownloadStatus struct {
+	ChatJID      string
+	MsgID        string
+	State        string
+	BytesDone    int64
+	BytesTotal   int64
+	ResumedFrom  int64
+	PartialBytes int64
+	LocalPath    string
+	Error        string
+	StartedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// downloadJob is the in-memory record of a download started by this process.
+type downloadJob struct {
+	progress   *wa.DownloadProgress
+	targetPath string
+	startedAt  time.Time
+	finishedAt time.Time
+	err        error
+	done       bool
+}
+
+func downloadKey(chatJID, msgID string) string {
+	return chatJID + "/" + msgID
+}
+
+// beginDownload registers a download, failing if one is already running for
+// the same message.
+func (m *Manager) beginDownload(chatJID, msgID, targetPath string) (*downloadJob, error) {
+	m.downloadsMu.Lock()
+	defer m.downloadsMu.Unlock()
+
+	key := downloadKey(chatJID, msgID)
+	if job, ok := m.downloads[key]; ok && !job.done {
+		return nil, ErrDownloadInProgress
+	}
+	if m.downloads == nil {
+		m.downloads = make(map[string]*downloadJob)
+	}
+	// Drop finished jobs that nobody asked about for a while.
+	for k, job := range m.downloads {
+		if job.done && time.Since(job.finishedAt) > downloadJobTTL {
+			delete(m.downloads, k)
+		}
+	}
+
+	job := &downloadJob{
+		progress:   &wa.DownloadProgress{},
+		targetPath: targetPath,
+		startedAt:  time.Now().UTC(),
+	}
+	m.downloads[key] = job
+	return job, nil
+}
+
+// finishDownload records the outcome of a download.
+func (m *Manager) finishDownload(job *downloadJob, err error) {
+	m.downloadsMu.Lock()
+	defer m.downloadsMu.Unlock()
+	job.done = true
+	job.err = err
+	job.finishedAt = time.Now().UTC()
+}
+
+// GetDownloadStatus reports the progress of a media download, combining the
+// running job (if any) with what is stored on disk.
+func (m *Manager) GetDownloadStatus(chatJID, msgID string) (*DownloadStatus, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+
+	info, err := a.DB().GetMediaDownloadInfo(chatJID, msgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media info: %w", err)
+	}
+
+	st := &DownloadStatus{
+		ChatJID:    info.ChatJID,
+		MsgID:      info.MsgID,
+		State:      DownloadNotStarted,
+		BytesTotal: int64(info.FileLength),
+	}
+	if info.LocalPath != "" {
+		st.State = DownloadCompleted
+		st.LocalPath = info.LocalPath
+		st.BytesDone = int64(info.FileLength)
+		st.UpdatedAt = info.DownloadedAt
+		return st, nil
+	}
+
+	m.downloadsMu.Lock()
+	job := m.downloads[downloadKey(info.ChatJID, info.MsgID)]
+	var jobErr error
+	var done bool
+	if job != nil {
+		jobErr, done = job.err, job.done
+	}
+	m.downloadsMu.Unlock()
+
+	targetPath := ""
+	if job != nil {
+		targetPath = job.targetPath
+	} else if p, err := a.ResolveMediaOutputPath(info, ""); err == nil {
+		targetPath = p
+	}
+	if targetPath != "" {
+		if fi, err := os.Stat(wa.PartialPath(targetPath)); err == nil {
+			st.PartialBytes = fi.Size()
+			st.BytesDone = fi.Size()
+			st.State = DownloadPartial
+		}
+	}
+
+	if job == nil {
+		return st, nil
+	}
+
+	snap := job.progress.Snapshot()
+	st.StartedAt = job.startedAt
+	st.ResumedFrom = snap.ResumedFrom
+	st.UpdatedAt = snap.UpdatedAt
+	if snap.Total > 0 {
+		st.BytesTotal = snap.Total
+	}
+	switch {
+	case !done:
+		st.State = DownloadInProgress
+		st.BytesDone = snap.Received
+	case jobErr != nil:
+		if st.State != DownloadPartial {
+			st.State = DownloadFailed
+		}
+		st.Error = jobErr.Error()
+	}
+	return st, nil
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"log"
+
+	"github.com/steipete/wacli/internal/app"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// handleMessageChange applies a live edit or revoke to the stored message
+// and emits message.edited or message.revoked.
+func (m *Manager) handleMessageChange(a *app.App, pm wa.ParsedMessage) {
+	stored, err := app.ApplyMessageChange(a.DB(), pm)
+	if err != nil {
+		log.Printf("[Manager] Failed to apply change %s in %s: %v", pm.ID, pm.Chat.String(), err)
+	}
+
+	if pm.RevokeOf != "" {
+		m.emitEvent(EventMessageRevoked, &MessageRevokedEvent{
+			ChatJID:   pm.Chat.String(),
+			MsgID:     pm.RevokeOf,
+			SenderJID: pm.SenderJID,
+			FromMe:    pm.FromMe,
+			Stored:    stored,
+			Timestamp: pm.Timestamp,
+		})
+		return
+	}
+	m.emitEvent(EventMessageEdited, &MessageEditedEvent{
+		ChatJID:   pm.Chat.String(),
+		MsgID:     pm.EditOf,
+		SenderJID: pm.SenderJID,
+		FromMe:    pm.FromMe,
+		Text:      pm.Text,
+		Stored:    stored,
+		Timestamp: pm.Timestamp,
+	})
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/steipete/wacli/internal/dbcrypt"
+)
+
+// encryptedDatabases are the SQLite files kept in the data directory.
+var encryptedDatabases = []string{"wacli.db", "session.db"}
+
+// encryptDataDir rewrites plaintext databases with the configured key, so
+// enabling encryption on an existing data directory needs no manual step. It
+// runs in Start with the lock held and before any database is opened.
+func (m *Manager) encryptDataDir() error {
+	if !dbcrypt.Enabled() {
+		return nil
+	}
+	for _, name := range encryptedDatabases {
+		path := filepath.Join(m.config.DataDir, name)
+		plain, err := dbcrypt.IsPlaintext(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !plain {
+			continue
+		}
+		log.Printf("[Encryption] Encrypting %s", name)
+		if err := dbcrypt.Encrypt(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
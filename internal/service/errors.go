@@ -0,0 +1,101 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mau.fi/whatsmeow"
+
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// Failures the API reports with their own status and code. ClassifyError
+// maps errors from the service, and the WhatsApp errors behind them, to one
+// of these.
+var (
+	ErrNotReady      = errors.New("service not ready")
+	ErrNotConnected  = errors.New("not connected to WhatsApp")
+	ErrNotOnWhatsApp = errors.New("not on WhatsApp")
+	ErrRateLimited   = errors.New("rate limited by WhatsApp")
+	ErrTimedOut      = errors.New("WhatsApp did not answer in time")
+	ErrNotGroupAdmin = errors.New("not an admin of the group")
+	ErrNotInGroup    = errors.New("not a member of the group")
+	ErrGroupNotFound = errors.New("group does not exist")
+	ErrForbidden     = errors.New("not allowed by WhatsApp")
+
+	ErrNotAuthenticated = errors.New("not authenticated")
+	ErrInvalidJID       = errors.New("invalid JID")
+	ErrNotFound         = errors.New("not found")
+)
+
+// Failures of a single kind of request, which its handler reports with a
+// more specific status or code.
+var (
+	ErrInvalidPhone         = errors.New("invalid phone number")
+	ErrAuthInProgress       = errors.New("authentication already in progress")
+	ErrAlreadyAuthenticated = errors.New("already authenticated")
+	ErrNoMedia              = errors.New("message has no downloadable media metadata")
+	ErrDownloadInProgress   = errors.New("download already in progress")
+	ErrBackfillRunning      = errors.New("backfill already running")
+	ErrInvalidInvite        = errors.New("invalid invite code")
+	ErrInviteRevoked        = errors.New("invite code revoked")
+	ErrInvalidRule          = errors.New("invalid rule")
+	ErrInvalidCSV           = errors.New("invalid CSV")
+	ErrInvalidImage         = errors.New("invalid image")
+	ErrLabelExists          = errors.New("label already exists")
+	ErrNotQueued            = errors.New("not queued")
+	ErrNotOwnMessage        = errors.New("not sent by us")
+	ErrPhotoHidden          = errors.New("profile picture hidden")
+
+	ErrInvalidUploadSize = errors.New("invalid size")
+	ErrUploadBusy        = errors.New("upload busy")
+	ErrOffsetMismatch    = errors.New("offset mismatch")
+	ErrInvalidRange      = errors.New("invalid range")
+	ErrUploadIncomplete  = errors.New("upload incomplete")
+)
+
+// errNotInitialized is returned while the app is not set up, before Start
+// or during a restore.
+var errNotInitialized = fmt.Errorf("app not initialized: %w", ErrNotReady)
+
+// ClassifyError returns the failure above that err stems from, or nil if
+// it is none of them.
+func ClassifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, kind := range []error{ErrNotReady, ErrNotConnected, ErrNotOnWhatsApp, ErrRateLimited, ErrTimedOut, ErrNotGroupAdmin, ErrNotInGroup, ErrGroupNotFound, ErrForbidden,
+		ErrNotAuthenticated, ErrInvalidJID, ErrNotFound} {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+	switch {
+	case errors.Is(err, wa.ErrNotConnected), errors.Is(err, whatsmeow.ErrNotConnected), errors.Is(err, whatsmeow.ErrIQDisconnected):
+		return ErrNotConnected
+	case errors.Is(err, whatsmeow.ErrIQRateOverLimit), errors.Is(err, whatsmeow.ErrIQResourceLimit):
+		return ErrRateLimited
+	case errors.Is(err, whatsmeow.ErrIQTimedOut), errors.Is(err, whatsmeow.ErrMessageTimedOut):
+		return ErrTimedOut
+	case errors.Is(err, whatsmeow.ErrNotInGroup):
+		return ErrNotInGroup
+	case errors.Is(err, whatsmeow.ErrGroupNotFound):
+		return ErrGroupNotFound
+	case errors.Is(err, whatsmeow.ErrIQForbidden), errors.Is(err, whatsmeow.ErrIQNotAuthorized):
+		return ErrForbidden
+	}
+	return nil
+}
+
+// groupAdminError marks err as ErrNotGroupAdmin if WhatsApp refused a
+// group change for lack of permission.
+func groupAdminError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, whatsmeow.ErrIQForbidden) || errors.Is(err, whatsmeow.ErrIQNotAuthorized) ||
+		errors.Is(err, whatsmeow.ErrGroupInviteLinkUnauthorized) {
+		return fmt.Errorf("%w: %w", ErrNotGroupAdmin, err)
+	}
+	return err
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/steipete/wacli/internal/webhook"
+)
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"not initialized", errNotInitialized, ErrNotReady},
+		{"wrapped not initialized", fmt.Errorf("list chats: %w", errNotInitialized), ErrNotReady},
+		{"invalid JID", fmt.Errorf("%w: %s is not a group", ErrInvalidJID, "123@s.whatsapp.net"), ErrInvalidJID},
+		{"not found", fmt.Errorf("label %d %w", 3, ErrNotFound), ErrNotFound},
+		{"no webhooks", fmt.Errorf("%w: no webhooks configured", ErrNotFound), ErrNotFound},
+		{"domain", fmt.Errorf("%w: chunk exceeds upload size %d", ErrInvalidRange, 10), nil},
+		{"chat filter", fmt.Errorf("%w: entry %q", webhook.ErrInvalidChatFilter, "x"), nil},
+		{"plain", errors.New("not found"), nil},
+	}
+	for _, c := range cases {
+		if got := ClassifyError(c.err); got != c.want {
+			t.Fatalf("%s: ClassifyError = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// Event types delivered to EventHandlers (and forwarded to webhooks).
+const (
+	EventMessageReceived         = "message.received"
+	EventMessageSent             = "message.sent"
+	EventMessageFailed           = "message.failed"
+	EventMessageEdited           = "message.edited"
+	EventMessageRevoked          = "message.revoked"
+	EventReceiptDelivered        = "receipt.delivered"
+	EventReceiptRead             = "receipt.read"
+	EventReceiptPlayed           = "receipt.played"
+	EventConnectionUp            = "connection.up"
+	EventConnectionDown          = "connection.down"
+	EventAuthLoggedOut           = "auth.logged_out"
+	EventGroupParticipantChanged = "group.participant_changed"
+	EventCallIncoming            = "call.incoming"
+	EventMediaDownloaded         = "media.downloaded"
+	EventChannelPost             = "channel.post"
+	EventSyncReady               = "sync.ready"
+
+	// EventStatePrefix is followed by the new State, e.g. "state.connected".
+	EventStatePrefix = "state."
+)
+
+// EventHandler is called for every service event except message.received,
+// which is delivered to MessageHandlers.
+type EventHandler func(eventType string, data interface{})
+
+// SentMessage is the payload of message.sent.
+type SentMessage struct {
+	ChatJID   string    `json:"chat_jid"`
+	MsgID     string    `json:"msg_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Text      string    `json:"text,omitempty"`
+	MediaType string    `json:"media_type,omitempty"`
+	Filename  string    `json:"filename,omitempty"`
+}
+
+// FailedSendEvent is the payload of message.failed, emitted when a send
+// fails at WhatsApp and is kept for retrying.
+type FailedSendEvent struct {
+	ID        int64     `json:"id"` // see POST /messages/{id}/retry
+	ChatJID   string    `json:"chat_jid"`
+	Kind      string    `json:"kind"` // text|file
+	Error     string    `json:"error"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// MessageEditedEvent is the payload of message.edited, emitted when the
+// sender edits an earlier message.
+type MessageEditedEvent struct {
+	ChatJID   string    `json:"chat_jid"`
+	MsgID     string    `json:"msg_id"` // the edited message
+	SenderJID string    `json:"sender_jid,omitempty"`
+	FromMe    bool      `json:"from_me"`
+	Text      string    `json:"text"`   // new text
+	Stored    bool      `json:"stored"` // false if the edited message is not in the store
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// MessageRevokedEvent is the payload of message.revoked, emitted when the
+// sender deletes an earlier message for everyone.
+type MessageRevokedEvent struct {
+	ChatJID   string    `json:"chat_jid"`
+	MsgID     string    `json:"msg_id"` // the deleted message
+	SenderJID string    `json:"sender_jid,omitempty"`
+	FromMe    bool      `json:"from_me"`
+	Stored    bool      `json:"stored"` // false if the deleted message is not in the store
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ReceiptEvent is the payload of receipt.delivered, receipt.read and
+// receipt.played.
+type ReceiptEvent struct {
+	ChatJID   string    `json:"chat_jid"`
+	SenderJID string    `json:"sender_jid"` // who delivered/read the messages
+	MsgIDs    []string  `json:"msg_ids"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ConnectionEvent is the payload of connection.up and connection.down.
+type ConnectionEvent struct {
+	State     string    `json:"state"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// LoggedOutEvent is the payload of auth.logged_out.
+type LoggedOutEvent struct {
+	Reason    string    `json:"reason,omitempty"`
+	OnConnect bool      `json:"on_connect"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// GroupParticipantEvent is the payload of group.participant_changed.
+type GroupParticipantEvent struct {
+	GroupJID     string    `json:"group_jid"`
+	Action       string    `json:"action"` // add|remove|promote|demote
+	Participants []string  `json:"participants"`
+	ActorJID     string    `json:"actor_jid,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// CallEvent is the payload of call.incoming.
+type CallEvent struct {
+	CallID       string    `json:"call_id"`
+	FromJID      string    `json:"from_jid"`
+	GroupJID     string    `json:"group_jid,omitempty"`
+	Video        bool      `json:"video"`
+	AutoRejected bool      `json:"auto_rejected"` // WASVC_CALL_REJECT is on
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// MediaDownloadedEvent is the payload of media.downloaded, emitted when the
+// media of an incoming message has been fetched automatically.
+type MediaDownloadedEvent struct {
+	ChatJID   string    `json:"chat_jid"`
+	MsgID     string    `json:"msg_id"`
+	MediaType string    `json:"media_type"`
+	MimeType  string    `json:"mime_type,omitempty"`
+	LocalPath string    `json:"local_path"`
+	Bytes     int64     `json:"bytes"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ChannelPostEvent is the payload of channel.post, emitted for new posts of
+// followed channels.
+type ChannelPostEvent struct {
+	ChannelJID string    `json:"channel_jid"`
+	MsgID      string    `json:"msg_id"`
+	ServerID   int64     `json:"server_id,omitempty"`
+	Text       string    `json:"text,omitempty"`
+	MediaType  string    `json:"media_type,omitempty"`
+	Caption    string    `json:"caption,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// StateChangeEvent is the payload of state.* events.
+type StateChangeEvent struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// OnEvent registers a handler for service events.
+func (m *Manager) OnEvent(handler EventHandler) {
+	m.handlersMu.Lock()
+	defer m.handlersMu.Unlock()
+	m.eventHandlers = append(m.eventHandlers, handler)
+}
+
+// emitEvent records an event for GET /events/recent and calls all
+// registered event handlers.
+func (m *Manager) emitEvent(eventType string, data interface{}) {
+	m.recordEvent(eventType, data)
+
+	m.handlersMu.RLock()
+	handlers := m.eventHandlers
+	m.handlersMu.RUnlock()
+
+	for _, h := range handlers {
+		go h(eventType, data)
+	}
+}
+
+// handleStateChange emits a state.* event for every state transition.
+func (m *Manager) handleStateChange(old, new State) {
+	data := &StateChangeEvent{
+		From:      old.String(),
+		To:        new.String(),
+		Timestamp: time.Now().UTC(),
+	}
+	if err := m.state.LastError(); err != nil && new == StateError {
+		data.Error = err.Error()
+	}
+	m.emitEvent(EventStatePrefix+new.String(), data)
+}
+
+// handleReceipt stores and emits delivery, read and played receipts for our
+// own messages.
+func (m *Manager) handleReceipt(evt *events.Receipt) {
+	// Reading a chat on another of our devices clears its unread count.
+	if evt.IsFromMe && (evt.Type == types.ReceiptTypeRead || evt.Type == types.ReceiptTypeReadSelf) {
+		if a := m.App(); a != nil {
+			if err := a.DB().SetChatRead(evt.Chat.String(), true); err != nil {
+				log.Printf("[Receipts] Failed to mark %s read: %v", evt.Chat, err)
+			}
+		}
+	}
+
+	var eventType, status string
+	switch evt.Type {
+	case types.ReceiptTypeDelivered:
+		eventType, status = EventReceiptDelivered, store.ReceiptDelivered
+	case types.ReceiptTypeRead:
+		eventType, status = EventReceiptRead, store.ReceiptRead
+	case types.ReceiptTypePlayed:
+		eventType, status = EventReceiptPlayed, store.ReceiptPlayed
+	default:
+		return
+	}
+
+	ids := make([]string, len(evt.MessageIDs))
+	for i, id := range evt.MessageIDs {
+		ids[i] = string(id)
+	}
+	// Receipts from our own devices are for messages we received.
+	if a := m.App(); a != nil && !evt.IsFromMe {
+		if err := a.DB().AddMessageReceipts(evt.Chat.String(), evt.Sender.ToNonAD().String(), ids, status, evt.Timestamp); err != nil {
+			log.Printf("[Receipts] Failed to store %s receipt in %s: %v", status, evt.Chat, err)
+		}
+	}
+	m.emitEvent(eventType, &ReceiptEvent{
+		ChatJID:   evt.Chat.String(),
+		SenderJID: evt.Sender.String(),
+		MsgIDs:    ids,
+		Timestamp: evt.Timestamp,
+	})
+}
+
+// handleGroupInfo emits one group.participant_changed event per kind of
+// membership change contained in the notification, and keeps the stored
+// description and community links current.
+func (m *Manager) handleGroupInfo(evt *events.GroupInfo) {
+	if evt.Link != nil || evt.Unlink != nil {
+		m.handleGroupLink(evt)
+	}
+	if evt.Topic != nil {
+		if a := m.App(); a != nil {
+			if err := a.DB().SetGroupDescription(evt.JID.String(), evt.Topic.Topic); err != nil {
+				log.Printf("[Groups] Failed to store description of %s: %v", evt.JID, err)
+			}
+		}
+	}
+	actor := ""
+	if evt.Sender != nil {
+		actor = evt.Sender.String()
+	}
+	for _, change := range []struct {
+		action string
+		jids   []types.JID
+	}{
+		{"add", evt.Join},
+		{"remove", evt.Leave},
+		{"promote", evt.Promote},
+		{"demote", evt.Demote},
+	} {
+		if len(change.jids) == 0 {
+			continue
+		}
+		participants := make([]string, len(change.jids))
+		for i, j := range change.jids {
+			participants[i] = j.String()
+		}
+		m.emitEvent(EventGroupParticipantChanged, &GroupParticipantEvent{
+			GroupJID:     evt.JID.String(),
+			Action:       change.action,
+			Participants: participants,
+			ActorJID:     actor,
+			Timestamp:    evt.Timestamp,
+		})
+	}
+}
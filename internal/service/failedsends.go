@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// sendError is a send that got as far as WhatsApp and failed there, as
+// opposed to one rejected up front (bad recipient, file too large, not
+// connected). Only these are kept as failed sends: sending them again as is
+// may succeed.
+type sendError struct {
+	chatJID string
+	err     error
+}
+
+func (e *sendError) Error() string { return e.err.Error() }
+func (e *sendError) Unwrap() error { return e.err }
+
+// RetryResult is the outcome of retrying a failed send.
+type RetryResult struct {
+	ChatJID   string
+	MessageID string
+	Kind      string
+}
+
+func (m *Manager) failedSendsDir() (string, error) {
+	a := m.App()
+	if a == nil {
+		return "", errNotInitialized
+	}
+	return filepath.Join(a.StoreDir(), "failed"), nil
+}
+
+// recordFailedText keeps a text message WhatsApp did not accept.
+func (m *Manager) recordFailedText(text string, err error) {
+	var se *sendError
+	if !errors.As(err, &se) {
+		return
+	}
+	a := m.App()
+	if a == nil {
+		return
+	}
+	id, dbErr := a.DB().AddFailedSend(store.FailedSend{ChatJID: se.chatJID, Kind: store.FailedSendText, Text: text, Error: err.Error()})
+	if dbErr != nil {
+		log.Printf("[Send] Failed to record failed send to %s: %v", se.chatJID, dbErr)
+		return
+	}
+	m.emitEvent(EventMessageFailed, &FailedSendEvent{ID: id, ChatJID: se.chatJID, Kind: store.FailedSendText, Error: err.Error(), Timestamp: time.Now().UTC()})
+}
+
+// recordFailedFile keeps a copy of a file that failed to upload or send, as
+// it was given to us (before any transcoding), so a retry goes through the
+// same steps.
+func (m *Manager) recordFailedFile(file mediaFile, filename, caption, mimeType string, err error) {
+	var se *sendError
+	if !errors.As(err, &se) {
+		return
+	}
+	a := m.App()
+	if a == nil {
+		return
+	}
+	dir, dirErr := m.failedSendsDir()
+	if dirErr != nil {
+		return
+	}
+	id, dbErr := a.DB().AddFailedSend(store.FailedSend{
+		ChatJID:  se.chatJID,
+		Kind:     store.FailedSendFile,
+		Text:     caption,
+		Filename: filename,
+		MimeType: mimeType,
+		Size:     file.size(),
+		Error:    err.Error(),
+	})
+	if dbErr != nil {
+		log.Printf("[Send] Failed to record failed send to %s: %v", se.chatJID, dbErr)
+		return
+	}
+	if err := saveFailedFile(dir, id, file); err != nil {
+		log.Printf("[Send] Failed to keep %s for retrying: %v", filename, err)
+		_, _ = a.DB().DeleteFailedSend(id)
+		return
+	}
+	m.emitEvent(EventMessageFailed, &FailedSendEvent{ID: id, ChatJID: se.chatJID, Kind: store.FailedSendFile, Error: err.Error(), Timestamp: time.Now().UTC()})
+}
+
+// saveFailedFile stores the content of file as dir/<id>.
+func saveFailedFile(dir string, id int64, file mediaFile) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	dst := filepath.Join(dir, strconv.FormatInt(id, 10))
+	if file.data != nil {
+		return os.WriteFile(dst, file.data, 0o600)
+	}
+	// Uploads live on the same disk; fall back to copying if linking fails.
+	if err := os.Link(file.path, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(file.path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+// ListFailedSends returns failed sends to a chat (any if empty), most recent
+// first.
+func (m *Manager) ListFailedSends(chatJID string, limit int) ([]store.FailedSend, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	if chatJID != "" {
+		jid, err := NormalizeChatJID(chatJID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidJID, err)
+		}
+		chatJID = jid
+	}
+	return a.DB().ListFailedSends(chatJID, limit)
+}
+
+// GetFailedSend returns one failed send.
+func (m *Manager) GetFailedSend(id int64) (store.FailedSend, error) {
+	a := m.App()
+	if a == nil {
+		return store.FailedSend{}, errNotInitialized
+	}
+	f, err := a.DB().GetFailedSend(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.FailedSend{}, fmt.Errorf("failed send %d %w", id, ErrNotFound)
+	}
+	return f, err
+}
+
+// RetryFailedSend sends a failed message again. On success it is removed
+// from the failed sends; if WhatsApp fails it again, the attempt and error
+// are recorded and it stays for another retry.
+func (m *Manager) RetryFailedSend(ctx context.Context, id int64) (*RetryResult, error) {
+	f, err := m.GetFailedSend(id)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := m.failedSendsDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, strconv.FormatInt(id, 10))
+
+	res := &RetryResult{ChatJID: f.ChatJID, Kind: f.Kind}
+	switch f.Kind {
+	case store.FailedSendText:
+		res.MessageID, err = m.sendText(ctx, f.ChatJID, f.Text)
+	case store.FailedSendFile:
+		if _, statErr := os.Stat(path); statErr != nil {
+			return nil, fmt.Errorf("file of failed send %d is gone: %w", id, statErr)
+		}
+		var sent *SendFileResult
+		sent, err = m.sendMedia(ctx, f.ChatJID, mediaFile{path: path}, f.Filename, f.Text, f.MimeType, SendFileOptions{})
+		if sent != nil {
+			res.MessageID = sent.MessageID
+		}
+	default:
+		return nil, fmt.Errorf("failed send %d has unknown kind %q", id, f.Kind)
+	}
+
+	a := m.App()
+	if err != nil {
+		var se *sendError
+		if errors.As(err, &se) {
+			if dbErr := a.DB().FailedSendRetryFailed(id, err.Error()); dbErr != nil {
+				log.Printf("[Send] Failed to record retry of failed send %d: %v", id, dbErr)
+			}
+		}
+		return nil, err
+	}
+	if _, err := a.DB().DeleteFailedSend(id); err != nil {
+		log.Printf("[Send] Failed to remove retried send %d: %v", id, err)
+	}
+	_ = os.Remove(path)
+	return res, nil
+}
+
+// DeleteFailedSend gives up on a failed send.
+func (m *Manager) DeleteFailedSend(id int64) error {
+	a := m.App()
+	if a == nil {
+		return errNotInitialized
+	}
+	ok, err := a.DB().DeleteFailedSend(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("failed send %d %w", id, ErrNotFound)
+	}
+	if dir, err := m.failedSendsDir(); err == nil {
+		_ = os.Remove(filepath.Join(dir, strconv.FormatInt(id, 10)))
+	}
+	return nil
+}
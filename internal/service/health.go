@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// dbPingTimeout bounds the database check of a health probe.
+const dbPingTimeout = 2 * time.Second
+
+// Health is what monitoring needs to tell a service that is connected but
+// no longer receiving anything apart from a healthy one.
+type Health struct {
+	State        State
+	Ready        bool
+	Uptime       time.Duration
+	LastEventAt  time.Time // last WhatsApp event seen; zero if none yet
+	Reconnects   int64     // times the connection was re-established since start
+	WebhookQueue int64     // webhook deliveries waiting to be sent
+	DBReachable  bool
+}
+
+// Health returns the current health snapshot. The webhook queue is only
+// counted if the database answers.
+func (m *Manager) Health(ctx context.Context) Health {
+	state := m.state.State()
+	h := Health{
+		State:       state,
+		Ready:       state.IsReady(),
+		Uptime:      time.Since(m.startedAt),
+		LastEventAt: unixNanoTime(m.syncProgress.lastEventAt.Load()),
+	}
+	if n := m.connects.Load(); n > 1 {
+		h.Reconnects = n - 1
+	}
+
+	a := m.App()
+	if a == nil {
+		return h
+	}
+	ctx, cancel := context.WithTimeout(ctx, dbPingTimeout)
+	defer cancel()
+	if err := a.DB().Ping(ctx); err != nil {
+		return h
+	}
+	h.DBReachable = true
+	if n, err := a.DB().CountPendingWebhookEvents(); err == nil {
+		h.WebhookQueue = n
+	}
+	return h
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math/rand/v2"
+	"time"
+	"unicode/utf8"
+
+	"github.com/steipete/wacli/internal/app"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// humanizeLock is held by the send to a chat that is typing right now;
+// later sends to the chat wait for it.
+type humanizeLock struct {
+	ch    chan struct{}
+	users int
+}
+
+// humanize acts out typing before a send with WASVC_HUMANIZE: it shows
+// "typing..." (or "recording audio..." for voice notes) in the chat and
+// waits in proportion to the length of text, plus a random jitter. Sends to
+// the same chat take turns, so a burst reads like consecutive messages.
+// The returned function must be called once the message is sent; it clears
+// the typing state and lets the next send to the chat start typing.
+func (m *Manager) humanize(ctx context.Context, a *app.App, to types.JID, text string, audio bool) (func(), error) {
+	if !m.config.Humanize {
+		return func() {}, nil
+	}
+	release, err := m.lockHumanize(ctx, to.String())
+	if err != nil {
+		return nil, err
+	}
+
+	media := types.ChatPresenceMediaText
+	if audio {
+		media = types.ChatPresenceMediaAudio
+	}
+	if err := a.WA().SendChatPresence(ctx, to, types.ChatPresenceComposing, media); err != nil {
+		log.Printf("[Send] Failed to show typing in %s: %v", to, err)
+	}
+	done := func() {
+		if err := a.WA().SendChatPresence(m.ctx, to, types.ChatPresencePaused, media); err != nil {
+			log.Printf("[Send] Failed to clear typing in %s: %v", to, err)
+		}
+		release()
+	}
+
+	t := time.NewTimer(m.typingDelay(text))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		done()
+		return nil, ctx.Err()
+	case <-t.C:
+		return done, nil
+	}
+}
+
+// typingDelay is how long a person would take to type text, capped by
+// WASVC_HUMANIZE_MAX_DELAY, plus up to WASVC_HUMANIZE_JITTER at random.
+func (m *Manager) typingDelay(text string) time.Duration {
+	d := time.Duration(float64(utf8.RuneCountInString(text)) / m.config.HumanizeCharsPerSec * float64(time.Second))
+	if d > m.config.HumanizeMaxDelay {
+		d = m.config.HumanizeMaxDelay
+	}
+	if j := m.config.HumanizeJitter; j > 0 {
+		d += rand.N(j)
+	}
+	return d
+}
+
+// lockHumanize waits until no other send is typing in chatJID, or ctx ends.
+func (m *Manager) lockHumanize(ctx context.Context, chatJID string) (func(), error) {
+	m.humanizeMu.Lock()
+	l := m.humanizeLocks[chatJID]
+	if l == nil {
+		l = &humanizeLock{ch: make(chan struct{}, 1)}
+		m.humanizeLocks[chatJID] = l
+	}
+	l.users++
+	m.humanizeMu.Unlock()
+
+	unref := func() {
+		m.humanizeMu.Lock()
+		if l.users--; l.users == 0 {
+			delete(m.humanizeLocks, chatJID)
+		}
+		m.humanizeMu.Unlock()
+	}
+	select {
+	case l.ch <- struct{}{}:
+		return func() {
+			<-l.ch
+			unref()
+		}, nil
+	case <-ctx.Done():
+		unref()
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+const localLabelPrefix = "local-"
+
+// ListLabels returns all labels.
+func (m *Manager) ListLabels() ([]store.Label, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	return a.DB().ListLabels()
+}
+
+// CreateLabel adds a label. Accounts that have WhatsApp Business labels (seen
+// through app-state sync) get a synced label pushed to the phone; otherwise
+// the label is kept locally.
+func (m *Manager) CreateLabel(ctx context.Context, name string, color int32) (store.Label, error) {
+	a := m.App()
+	if a == nil {
+		return store.Label{}, errNotInitialized
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return store.Label{}, fmt.Errorf("label name is required")
+	}
+	labels, err := a.DB().ListLabels()
+	if err != nil {
+		return store.Label{}, err
+	}
+
+	synced := false
+	maxSynced, maxLocal := 0, 0
+	for _, l := range labels {
+		if strings.EqualFold(l.Name, name) {
+			return store.Label{}, fmt.Errorf("label %q: %w", l.Name, ErrLabelExists)
+		}
+		if l.Synced {
+			synced = true
+			if n, err := strconv.Atoi(l.ID); err == nil && n > maxSynced {
+				maxSynced = n
+			}
+		} else if n, err := strconv.Atoi(strings.TrimPrefix(l.ID, localLabelPrefix)); err == nil && n > maxLocal {
+			maxLocal = n
+		}
+	}
+
+	l := store.Label{Name: name, Color: color, Synced: synced, UpdatedAt: time.Now().UTC()}
+	if synced {
+		l.ID = strconv.Itoa(maxSynced + 1)
+		if err := a.WA().SendAppState(ctx, appstate.BuildLabelEdit(l.ID, l.Name, l.Color, false)); err != nil {
+			return store.Label{}, fmt.Errorf("sync label: %w", err)
+		}
+	} else {
+		l.ID = localLabelPrefix + strconv.Itoa(maxLocal+1)
+	}
+	if err := a.DB().UpsertLabel(l); err != nil {
+		return store.Label{}, err
+	}
+	return l, nil
+}
+
+// DeleteLabel removes a label, from WhatsApp too if it is synced.
+func (m *Manager) DeleteLabel(ctx context.Context, idOrName string) error {
+	a := m.App()
+	if a == nil {
+		return errNotInitialized
+	}
+	l, err := m.findLabel(idOrName)
+	if err != nil {
+		return err
+	}
+	if l.Synced {
+		if err := a.WA().SendAppState(ctx, appstate.BuildLabelEdit(l.ID, l.Name, l.Color, true)); err != nil {
+			return fmt.Errorf("sync label: %w", err)
+		}
+	}
+	return a.DB().DeleteLabel(l.ID)
+}
+
+// LabelChat adds or removes a label from a chat, on WhatsApp too if the label
+// is synced.
+func (m *Manager) LabelChat(ctx context.Context, chatJID, idOrName string, labeled bool) (store.Label, error) {
+	a := m.App()
+	if a == nil {
+		return store.Label{}, errNotInitialized
+	}
+	chat, err := NormalizeChatJID(chatJID)
+	if err != nil {
+		return store.Label{}, fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+	l, err := m.findLabel(idOrName)
+	if err != nil {
+		return store.Label{}, err
+	}
+	if l.Synced {
+		target, err := types.ParseJID(chat)
+		if err != nil {
+			return store.Label{}, fmt.Errorf("%w: %w", ErrInvalidJID, err)
+		}
+		if err := a.WA().SendAppState(ctx, appstate.BuildLabelChat(target, l.ID, labeled)); err != nil {
+			return store.Label{}, fmt.Errorf("sync label: %w", err)
+		}
+	}
+	return l, a.DB().SetChatLabel(chat, l.ID, labeled)
+}
+
+func (m *Manager) findLabel(idOrName string) (store.Label, error) {
+	l, err := m.App().DB().FindLabel(strings.TrimSpace(idOrName))
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.Label{}, fmt.Errorf("label %q %w", idOrName, ErrNotFound)
+	}
+	return l, err
+}
+
+// handleLabelEdit mirrors a WhatsApp Business label created, edited or
+// deleted on any device.
+func (m *Manager) handleLabelEdit(evt *events.LabelEdit) {
+	a := m.App()
+	if a == nil || evt.Action == nil {
+		return
+	}
+	var err error
+	if evt.Action.GetDeleted() {
+		if err = a.DB().DeleteLabel(evt.LabelID); errors.Is(err, sql.ErrNoRows) {
+			err = nil
+		}
+	} else {
+		err = a.DB().UpsertLabel(store.Label{
+			ID:        evt.LabelID,
+			Name:      evt.Action.GetName(),
+			Color:     evt.Action.GetColor(),
+			Synced:    true,
+			UpdatedAt: evt.Timestamp,
+		})
+	}
+	if err != nil {
+		log.Printf("[Manager] Failed to store label %s: %v", evt.LabelID, err)
+	}
+}
+
+// handleLabelAssociationChat mirrors a chat being (un)labeled on any device.
+func (m *Manager) handleLabelAssociationChat(evt *events.LabelAssociationChat) {
+	a := m.App()
+	if a == nil || evt.Action == nil {
+		return
+	}
+	if err := a.DB().SetChatLabel(evt.JID.String(), evt.LabelID, evt.Action.GetLabeled()); err != nil {
+		log.Printf("[Manager] Failed to store label %s for %s: %v", evt.LabelID, evt.JID, err)
+	}
+}
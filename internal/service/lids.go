@@ -0,0 +1,77 @@
+package service
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/steipete/wacli/internal/app"
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// syncLIDMappings copies whatsmeow's LID mappings into the message store
+// after connecting, so senders seen only by LID resolve to phone numbers.
+func (m *Manager) syncLIDMappings() {
+	a := m.App()
+	if a == nil || a.WA() == nil {
+		return
+	}
+	n, err := app.SyncLIDMappings(m.ctx, a.WA(), a.DB())
+	if err != nil {
+		log.Printf("[Contacts] Failed to sync LID mappings: %v", err)
+		return
+	}
+	log.Printf("[Contacts] Synced %d LID mappings", n)
+}
+
+// senderIdentities returns the phone-number JID and LID of a message's
+// sender, either of which may be unknown.
+func (m *Manager) senderIdentities(a *app.App, pm wa.ParsedMessage) (pn, lid string) {
+	if pm.SenderJID == "" {
+		return "", ""
+	}
+	alt := pm.SenderAlt
+	if alt == "" {
+		if lm, err := a.DB().LookupLID(pm.SenderJID); err == nil {
+			if store.IsLID(pm.SenderJID) {
+				alt = lm.PN
+			} else {
+				alt = lm.LID
+			}
+		}
+	}
+	if store.IsLID(pm.SenderJID) {
+		return alt, pm.SenderJID
+	}
+	return pm.SenderJID, alt
+}
+
+// ResolveChatJID turns a chat identifier from a client, a JID or a phone
+// number in any format NormalizeChatJID accepts, into the JID the chat is
+// stored under. A direct chat known only by its LID, or only by its phone
+// number, is found under the other one via the LID mappings.
+func (m *Manager) ResolveChatJID(s string) (string, error) {
+	jid, err := NormalizeChatJID(s)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+	a := m.App()
+	if a == nil {
+		return jid, nil
+	}
+	if _, err := a.DB().GetChat(jid); err == nil {
+		return jid, nil
+	}
+	lm, err := a.DB().LookupLID(jid)
+	if err != nil {
+		return jid, nil
+	}
+	alt := lm.LID
+	if store.IsLID(jid) {
+		alt = lm.PN
+	}
+	if _, err := a.DB().GetChat(alt); err == nil {
+		return alt, nil
+	}
+	return jid, nil
+}
@@ -2,19 +2,31 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"mime"
 	"net/http"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/steipete/wacli/internal/app"
 	"github.com/steipete/wacli/internal/lock"
+	"github.com/steipete/wacli/internal/phone"
+	"github.com/steipete/wacli/internal/plugin"
+	"github.com/steipete/wacli/internal/replica"
+	"github.com/steipete/wacli/internal/responder"
+	"github.com/steipete/wacli/internal/rules"
+	"github.com/steipete/wacli/internal/script"
 	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/throttle"
 	"github.com/steipete/wacli/internal/wa"
+	"github.com/steipete/wacli/internal/webhook"
 	"go.mau.fi/whatsmeow"
 	waProto "go.mau.fi/whatsmeow/binary/proto"
 	"go.mau.fi/whatsmeow/types"
@@ -31,12 +43,31 @@ type ReceivedMessage struct {
 	ChatName   string    `json:"chat_name"`
 	MsgID      string    `json:"msg_id"`
 	SenderJID  string    `json:"sender_jid,omitempty"`
+	SenderPN   string    `json:"sender_pn,omitempty"`  // phone-number JID of the sender, if known
+	SenderLID  string    `json:"sender_lid,omitempty"` // LID of the sender, if known
 	SenderName string    `json:"sender_name,omitempty"`
 	Timestamp  time.Time `json:"timestamp"`
 	FromMe     bool      `json:"from_me"`
 	Text       string    `json:"text,omitempty"`
 	MediaType  string    `json:"media_type,omitempty"`
 	Caption    string    `json:"caption,omitempty"`
+	SpamScore  float64   `json:"spam_score,omitempty"`
+	ReplyTo    *ReplyTo  `json:"reply_to,omitempty"`
+	// Media, when WASVC_WEBHOOK_MEDIA is set and the message has media.
+	Media *MessageMedia `json:"media,omitempty"`
+
+	// Webhooks, when set by routing rules, are the only webhook URLs the
+	// message is delivered to.
+	Webhooks []string `json:"-"`
+	// NoWebhook keeps the message from the HTTP webhooks, as the no_webhook
+	// spam action does. Rules, scripts, plugins and other sinks still see it.
+	NoWebhook bool `json:"-"`
+}
+
+// ReplyTo identifies the message a reply quotes.
+type ReplyTo struct {
+	MsgID     string `json:"msg_id"`
+	SenderJID string `json:"sender_jid,omitempty"`
 }
 
 // Manager is the central service that manages the WhatsApp connection lifecycle.
@@ -52,10 +83,53 @@ type Manager struct {
 	syncRunning    bool
 	syncCtx        context.Context
 	syncCancel     context.CancelFunc
+	syncProgress   syncCounters
+	startup        startupState
+	presence       types.Presence // set via SetPresence; restored on reconnect
 	eventHandlerID uint32
+	recovery       RecoveryReport
 
 	messageHandlers []MessageHandler
+	eventHandlers   []EventHandler
 	handlersMu      sync.RWMutex
+	webhooks        *webhook.Emitter // nil unless webhooks are configured
+	mediaURLKey     []byte           // signs media URLs
+	accessTokenKey  []byte           // signs access tokens
+
+	downloads     map[string]*downloadJob
+	downloadsMu   sync.Mutex
+	autoDownloads chan autoDownload // nil unless DownloadMedia is set
+
+	outboxWake   chan struct{}      // nil unless Outbox is set
+	sendThrottle *throttle.Throttle // limits every outbound message
+
+	humanizeMu    sync.Mutex
+	humanizeLocks map[string]*humanizeLock // chats a send is typing in
+
+	uploadsMu   sync.Mutex
+	uploadsBusy map[string]bool // upload sessions being written or sent
+
+	plugins *plugin.Runner // nil unless plugins are configured
+	scripts *script.Engine // reloaded whenever a script is changed
+	rules   rules.Engine   // enabled routing rules, reloaded on change
+
+	responder      *responder.Client // nil unless WASVC_RESPONDER_URL is set
+	responderLimit *responder.Limiter
+	responderMu    sync.Mutex
+	responderBusy  map[string]bool // chats a reply is being generated for
+
+	shutdownOnce sync.Once
+	shutdown     chan struct{}
+
+	startedAt time.Time
+	connects  atomic.Int64 // Connected events seen, for the reconnect count
+	recent    *eventRing   // nil unless RecentEvents is set
+
+	retentionMu sync.Mutex // serializes pruning runs
+
+	backfillMu     sync.Mutex
+	backfillCancel context.CancelFunc // set while a backfill-all run is processed
+	backfillGen    int                // bumped per launch so stale runners do not clear backfillCancel
 }
 
 // NewManager creates a new service manager.
@@ -63,11 +137,45 @@ func NewManager(cfg Config) (*Manager, error) {
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
+	if err := phone.SetDefaultCountryCode(cfg.DefaultCountryCode); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
 
-	return &Manager{
-		config: cfg,
-		state:  NewStateMachine(),
-	}, nil
+	m := &Manager{
+		config:         cfg,
+		state:          NewStateMachine(),
+		shutdown:       make(chan struct{}),
+		startedAt:      time.Now(),
+		mediaURLKey:    newSigningKey(cfg.MediaURLSecret),
+		accessTokenKey: newSigningKey(cfg.AccessTokenSecret),
+		sendThrottle:   throttle.New(cfg.SendMaxPerMinute, cfg.SendBurst, cfg.SendRecipientCooldown),
+		humanizeLocks:  make(map[string]*humanizeLock),
+		recent:         newEventRing(cfg.RecentEvents),
+	}
+	if len(cfg.Plugins) > 0 {
+		m.plugins = plugin.NewRunner(plugin.Config{
+			Plugins: cfg.Plugins,
+			Timeout: cfg.PluginTimeout,
+		})
+	}
+	if cfg.ResponderURL != "" {
+		m.responder = responder.NewClient(responder.Config{
+			URL:          cfg.ResponderURL,
+			APIKey:       cfg.ResponderAPIKey,
+			Model:        cfg.ResponderModel,
+			SystemPrompt: cfg.ResponderSystemPrompt,
+			MaxTokens:    cfg.ResponderMaxTokens,
+			Timeout:      cfg.ResponderTimeout,
+		})
+		m.responderLimit = responder.NewLimiter(cfg.ResponderMaxPerHour, time.Hour)
+		m.responderBusy = make(map[string]bool)
+	}
+	m.scripts = script.NewEngine(script.Config{
+		API:     m,
+		Timeout: cfg.ScriptTimeout,
+	})
+	m.state.OnStateChange(m.handleStateChange)
+	return m, nil
 }
 
 // Start initializes the service, acquires the lock, and starts the WhatsApp connection.
@@ -87,9 +195,21 @@ func (m *Manager) Start(ctx context.Context) error {
 	}
 	m.lock = lk
 
+	// Swap in a restore staged by the previous run before opening any database
+	m.applyPendingRestore()
+
+	// Encrypt databases left in plaintext now that a key is configured
+	if err := m.encryptDataDir(); err != nil {
+		_ = lk.Release()
+		m.state.SetError(err)
+		return fmt.Errorf("encrypt data directory: %w", err)
+	}
+
 	// Initialize app
 	a, err := app.New(app.Options{
 		StoreDir: m.config.DataDir,
+		DBURL:    m.config.DBURL,
+		SQLite:   m.config.SQLite,
 		Version:  "wasvc/1.0",
 	})
 	if err != nil {
@@ -99,12 +219,46 @@ func (m *Manager) Start(ctx context.Context) error {
 	}
 	m.app = a
 
+	// Clean up or keep work interrupted by the previous run
+	m.recovery = m.recoverInterrupted(a)
+
+	// Log message changes for the standby only while one is configured
+	m.configureChangeLog(a.DB())
+
+	// Make the configured API key known to the key store
+	m.seedAPIKey(a.DB())
+
+	// Load the stored rules and scripts
+	m.reloadRules(a.DB())
+	if err := m.loadScripts(a.DB()); err != nil {
+		log.Printf("[Scripts] %v", err)
+	}
+
 	// Create cancellable context for background tasks
 	m.ctx, m.cancel = context.WithCancel(ctx)
 
+	// Fetch media of incoming messages in the background
+	if m.config.DownloadMedia {
+		m.autoDownloads = make(chan autoDownload, autoDownloadQueueSize)
+		for i := 0; i < m.config.DownloadMediaWorkers; i++ {
+			go m.runAutoDownloader(m.ctx)
+		}
+	}
+
+	// Send messages queued while disconnected, starting with any left over
+	if m.config.Outbox {
+		m.outboxWake = make(chan struct{}, 1)
+		go m.runOutbox(m.ctx)
+	}
+
 	// Try to connect
 	go m.connectAndSync()
 
+	// Prune messages outside the retention policy in the background
+	if m.config.RetentionInterval > 0 {
+		go m.runRetentionJanitor(m.ctx)
+	}
+
 	return nil
 }
 
@@ -139,6 +293,17 @@ func (m *Manager) State() *StateMachine {
 	return m.state
 }
 
+// Healthy reports whether the service is connected or waiting for a login.
+// Other states, such as reconnecting, count as healthy until they have
+// lasted WatchdogGrace.
+func (m *Manager) Healthy() bool {
+	switch m.state.State() {
+	case StateConnected, StateUnauthenticated, StatePairing:
+		return true
+	}
+	return time.Since(m.state.Since()) < m.config.WatchdogGrace
+}
+
 // Config returns the current configuration.
 func (m *Manager) Config() Config {
 	return m.config
@@ -160,6 +325,8 @@ func (m *Manager) OnMessage(handler MessageHandler) {
 
 // notifyMessageHandlers calls all registered message handlers.
 func (m *Manager) notifyMessageHandlers(msg *ReceivedMessage) {
+	m.recordEvent(EventMessageReceived, msg)
+
 	m.handlersMu.RLock()
 	handlers := m.messageHandlers
 	m.handlersMu.RUnlock()
@@ -197,6 +364,64 @@ func (m *Manager) connectAndSync() {
 
 // InitiateAuth starts the QR code authentication flow.
 func (m *Manager) InitiateAuth(ctx context.Context) error {
+	if err := m.prepareAuth(); err != nil {
+		return err
+	}
+	return m.runAuth(ctx, func() error {
+		return m.app.Connect(ctx, true, func(qr string) {
+			log.Printf("[Manager] QR code generated (length: %d)", len(qr))
+			m.state.SetQRCode(qr)
+		})
+	})
+}
+
+// RequestPairCode starts linking by phone number instead of QR code and
+// returns the pairing code to enter on the phone. Pairing then completes in
+// the background, like InitiateAuth.
+func (m *Manager) RequestPairCode(phone string) (string, error) {
+	phone, err := wa.NormalizePairPhone(phone)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidPhone, err)
+	}
+	switch m.state.State() {
+	case StateConnecting, StatePairing:
+		return "", ErrAuthInProgress
+	}
+	if err := m.prepareAuth(); err != nil {
+		return "", err
+	}
+
+	codeCh := make(chan string, 1)
+	errCh := make(chan error, 1)
+	go func() {
+		// Not tied to the request; WhatsApp closes the login socket after
+		// about 160 seconds anyway.
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+		defer cancel()
+		errCh <- m.runAuth(ctx, func() error {
+			return m.app.ConnectPairPhone(ctx, phone, func(code string) {
+				log.Printf("[Manager] Pairing code issued for %s", phone)
+				m.state.SetPairCode(code)
+				codeCh <- code
+			})
+		})
+	}()
+
+	select {
+	case code := <-codeCh:
+		return code, nil
+	case err := <-errCh:
+		if err == nil {
+			err = fmt.Errorf("pairing finished without a code")
+		}
+		return "", err
+	case <-time.After(30 * time.Second):
+		return "", fmt.Errorf("timed out waiting for pairing code")
+	}
+}
+
+// prepareAuth opens the WhatsApp client for a new login.
+func (m *Manager) prepareAuth() error {
 	m.mu.Lock()
 	if m.app == nil {
 		m.mu.Unlock()
@@ -213,9 +438,14 @@ func (m *Manager) InitiateAuth(ctx context.Context) error {
 
 	if m.app.WA() != nil && m.app.WA().IsAuthed() {
 		log.Println("[Manager] Already authenticated")
-		return fmt.Errorf("already authenticated")
+		return ErrAlreadyAuthenticated
 	}
+	return nil
+}
 
+// runAuth runs a login started by connect until the device is paired and
+// connected, then starts the sync worker.
+func (m *Manager) runAuth(ctx context.Context, connect func() error) error {
 	log.Println("[Manager] Starting authentication flow...")
 	m.state.SetState(StateConnecting)
 
@@ -247,11 +477,8 @@ func (m *Manager) InitiateAuth(ctx context.Context) error {
 		}
 	})
 
-	// Connect with QR code generation - this blocks until QR flow completes
-	err := m.app.Connect(ctx, true, func(qr string) {
-		log.Printf("[Manager] QR code generated (length: %d)", len(qr))
-		m.state.SetQRCode(qr)
-	})
+	// Connect with QR code or pairing code - this blocks until pairing completes
+	err := connect()
 
 	if err != nil {
 		m.app.WA().RemoveEventHandler(handlerID)
@@ -314,6 +541,8 @@ func (m *Manager) startSyncWorker() {
 	}
 	m.syncRunning = true
 	m.syncCtx, m.syncCancel = context.WithCancel(m.ctx)
+	m.syncProgress.reset(time.Now())
+	m.startup.reset()
 	m.mu.Unlock()
 
 	go m.runSyncWorker()
@@ -334,25 +563,82 @@ func (m *Manager) runSyncWorker() {
 
 	// Register event handler for messages
 	m.eventHandlerID = m.app.WA().AddEventHandler(func(evt interface{}) {
+		m.syncProgress.touch()
 		switch v := evt.(type) {
 		case *events.Message:
 			m.handleIncomingMessage(v)
 		case *events.Connected:
 			log.Println("[Manager] WhatsApp connected")
+			m.connects.Add(1)
 			m.state.SetState(StateConnected)
+			m.emitEvent(EventConnectionUp, &ConnectionEvent{State: string(StateConnected), Timestamp: time.Now().UTC()})
+			go m.restorePresence()
+			go m.resumeBackfillAll()
+			go m.syncChannels()
+			go m.syncBlocklist()
+			go m.syncLIDMappings()
+			go m.runStartup(m.syncCtx)
+			m.wakeOutbox()
 		case *events.Disconnected:
 			log.Println("[Manager] WhatsApp disconnected")
 			m.state.SetState(StateDisconnected)
+			m.emitEvent(EventConnectionDown, &ConnectionEvent{State: string(StateDisconnected), Timestamp: time.Now().UTC()})
 			// Signal reconnection needed
 			select {
 			case reconnectCh <- struct{}{}:
 			default:
 			}
+		case *events.LoggedOut:
+			log.Printf("[Manager] WhatsApp session logged out (reason: %s)", v.Reason)
+			m.state.SetState(StateUnauthenticated)
+			m.emitEvent(EventAuthLoggedOut, &LoggedOutEvent{Reason: v.Reason.String(), OnConnect: v.OnConnect, Timestamp: time.Now().UTC()})
+		case *events.Receipt:
+			m.handleReceipt(v)
+		case *events.NewsletterJoin:
+			m.handleNewsletterJoin(v)
+		case *events.NewsletterLeave:
+			m.handleNewsletterLeave(v)
+		case *events.NewsletterMuteChange:
+			m.handleNewsletterMute(v)
+		case *events.GroupInfo:
+			m.handleGroupInfo(v)
+		case *events.Blocklist:
+			m.handleBlocklist(v)
+		case *events.CallOffer:
+			m.handleCallOffer(v)
+		case *events.CallOfferNotice:
+			m.handleCallOfferNotice(v)
+		case *events.CallAccept:
+			m.handleCallAccept(v)
+		case *events.CallReject:
+			m.handleCallReject(v)
+		case *events.CallTerminate:
+			m.handleCallTerminate(v)
 		case *events.HistorySync:
 			m.handleHistorySync(v)
+		case *events.Star:
+			m.handleStar(v)
+		case *events.LabelEdit:
+			m.handleLabelEdit(v)
+		case *events.LabelAssociationChat:
+			m.handleLabelAssociationChat(v)
+		case *events.Archive:
+			m.handleArchive(v)
+		case *events.Pin:
+			m.handlePin(v)
+		case *events.Mute:
+			m.handleMute(v)
+		case *events.MarkChatAsRead:
+			m.handleMarkChatAsRead(v)
 		}
 	})
 
+	// Connected may have fired before the handler was registered, as after
+	// pairing.
+	if m.app.WA().IsConnected() {
+		go m.runStartup(m.syncCtx)
+	}
+
 	// Auto-reconnect loop
 	go func() {
 		backoff := time.Second
@@ -430,49 +716,34 @@ func (m *Manager) handleIncomingMessage(evt *events.Message) {
 	if a == nil {
 		return
 	}
+	if pm.Chat.Server == types.NewsletterServer {
+		m.handleChannelPost(a, evt, pm)
+		return
+	}
+	if pm.IsChange() {
+		m.handleMessageChange(a, pm)
+		return
+	}
 
 	chatName := ""
 	if a.WA() != nil {
 		chatName = a.WA().ResolveChatName(m.ctx, pm.Chat, pm.PushName)
 	}
 
+	_ = a.DB().PutLIDMappings(app.SenderLIDMapping(pm))
 	_ = a.DB().UpsertChat(pm.Chat.String(), chatKind(pm.Chat), chatName, pm.Timestamp)
 
-	var mediaType, caption string
-	var mediaKey, fileSHA256, fileEncSHA256 []byte
-	var directPath, mimeType, filename string
-	var fileLength uint64
-	if pm.Media != nil {
-		mediaType = pm.Media.Type
-		caption = pm.Media.Caption
-		filename = pm.Media.Filename
-		mimeType = pm.Media.MimeType
-		directPath = pm.Media.DirectPath
-		mediaKey = pm.Media.MediaKey
-		fileSHA256 = pm.Media.FileSHA256
-		fileEncSHA256 = pm.Media.FileEncSHA256
-		fileLength = pm.Media.FileLength
+	p := messageParams(pm, chatName)
+	mediaType, caption, fileLength := p.MediaType, p.MediaCaption, p.FileLength
+	err := a.DB().UpsertMessage(p)
+	if err == nil {
+		m.syncProgress.messages.Add(1)
+		if !pm.FromMe {
+			_ = a.DB().IncrementChatUnread(pm.Chat.String())
+		}
 	}
 
-	_ = a.DB().UpsertMessage(store.UpsertMessageParams{
-		ChatJID:       pm.Chat.String(),
-		ChatName:      chatName,
-		MsgID:         pm.ID,
-		SenderJID:     pm.SenderJID,
-		SenderName:    pm.PushName,
-		Timestamp:     pm.Timestamp,
-		FromMe:        pm.FromMe,
-		Text:          pm.Text,
-		MediaType:     mediaType,
-		MediaCaption:  caption,
-		Filename:      filename,
-		MimeType:      mimeType,
-		DirectPath:    directPath,
-		MediaKey:      mediaKey,
-		FileSHA256:    fileSHA256,
-		FileEncSHA256: fileEncSHA256,
-		FileLength:    fileLength,
-	})
+	spamScore, isSpam := m.scoreSpam(a, pm)
 
 	// Notify handlers
 	msg := &ReceivedMessage{
@@ -486,11 +757,50 @@ func (m *Manager) handleIncomingMessage(evt *events.Message) {
 		Text:       pm.Text,
 		MediaType:  mediaType,
 		Caption:    caption,
+		SpamScore:  spamScore,
+	}
+	msg.SenderPN, msg.SenderLID = m.senderIdentities(a, pm)
+	if pm.ReplyToID != "" {
+		msg.ReplyTo = &ReplyTo{MsgID: pm.ReplyToID, SenderJID: pm.ReplyToSender}
+	}
+	if err == nil && pm.Media != nil && !isSpam {
+		m.queueAutoDownload(pm.Chat.String(), pm.ID, mediaType, fileLength)
+	}
+	msg.NoWebhook = isSpam && m.config.HasSpamAction("no_webhook")
+	if m.rules.Len() > 0 && !pm.FromMe && m.applyRules(msg) {
+		return
+	}
+	if m.scripts.Len() > 0 && !pm.FromMe {
+		go m.scripts.Dispatch(m.ctx, msg)
+	}
+	if m.responder != nil && !pm.FromMe {
+		go m.respond(msg)
+	}
+	if m.config.WebhookMedia != "" && pm.Media != nil && !isSpam {
+		// Fetching media may be slow; keep it off the event loop.
+		go func() {
+			m.attachWebhookMedia(msg, int64(fileLength))
+			m.dispatchMessage(msg)
+		}()
+		return
+	}
+	m.dispatchMessage(msg)
+}
+
+// dispatchMessage runs plugins, if any, and hands msg to the message
+// handlers.
+func (m *Manager) dispatchMessage(msg *ReceivedMessage) {
+	if m.plugins != nil && !msg.FromMe {
+		// Plugins may be slow; keep them off the event loop.
+		go m.runPlugins(msg)
+		return
 	}
 	m.notifyMessageHandlers(msg)
 }
 
-// handleHistorySync processes history sync events.
+// handleHistorySync processes history sync events. Each conversation is
+// stored in one transaction; chat names are resolved once per sender rather
+// than once per message, since for groups that is a network round trip.
 func (m *Manager) handleHistorySync(evt *events.HistorySync) {
 	log.Printf("[Manager] Processing history sync (%d conversations)", len(evt.Data.Conversations))
 
@@ -498,12 +808,20 @@ func (m *Manager) handleHistorySync(evt *events.HistorySync) {
 	if a == nil {
 		return
 	}
+	if err := a.DB().PutLIDMappings(app.HistoryLIDMappings(evt.Data)); err != nil {
+		log.Printf("[Manager] Failed to store LID mappings: %v", err)
+	}
 
 	for _, conv := range evt.Data.Conversations {
 		chatID := conv.GetID()
 		if chatID == "" {
 			continue
 		}
+
+		chats := make(map[string]store.UpsertChatParams)
+		names := make(map[string]string) // chat JID + push name -> chat name
+		var msgs []store.UpsertMessageParams
+		var changes []wa.ParsedMessage
 		for _, msg := range conv.Messages {
 			if msg.Message == nil {
 				continue
@@ -512,56 +830,99 @@ func (m *Manager) handleHistorySync(evt *events.HistorySync) {
 			if pm.ID == "" {
 				continue
 			}
+			if pm.Chat.Server == types.NewsletterServer {
+				if err := a.DB().UpsertChannelPost(app.NewChannelPost(pm, 0)); err == nil {
+					m.syncProgress.messages.Add(1)
+				}
+				continue
+			}
+			if pm.IsChange() {
+				changes = append(changes, pm)
+				continue
+			}
 
-			chatName := ""
-			if a.WA() != nil {
+			chatJID := pm.Chat.String()
+			key := chatJID + "\x00" + pm.PushName
+			chatName, ok := names[key]
+			if !ok && a.WA() != nil {
 				chatName = a.WA().ResolveChatName(m.ctx, pm.Chat, pm.PushName)
+				names[key] = chatName
 			}
 
-			var mediaType, caption string
-			var mediaKey, fileSHA256, fileEncSHA256 []byte
-			var directPath, mimeType, filename string
-			var fileLength uint64
-			if pm.Media != nil {
-				mediaType = pm.Media.Type
-				caption = pm.Media.Caption
-				filename = pm.Media.Filename
-				mimeType = pm.Media.MimeType
-				directPath = pm.Media.DirectPath
-				mediaKey = pm.Media.MediaKey
-				fileSHA256 = pm.Media.FileSHA256
-				fileEncSHA256 = pm.Media.FileEncSHA256
-				fileLength = pm.Media.FileLength
+			c := chats[chatJID]
+			c.JID, c.Kind = chatJID, chatKind(pm.Chat)
+			if chatName != "" {
+				c.Name = chatName
+			}
+			if pm.Timestamp.After(c.LastMessageTS) {
+				c.LastMessageTS = pm.Timestamp
 			}
+			chats[chatJID] = c
+			msgs = append(msgs, messageParams(pm, chatName))
+		}
 
-			_ = a.DB().UpsertChat(pm.Chat.String(), chatKind(pm.Chat), chatName, pm.Timestamp)
-			_ = a.DB().UpsertMessage(store.UpsertMessageParams{
-				ChatJID:       pm.Chat.String(),
-				ChatName:      chatName,
-				MsgID:         pm.ID,
-				SenderJID:     pm.SenderJID,
-				SenderName:    pm.PushName,
-				Timestamp:     pm.Timestamp,
-				FromMe:        pm.FromMe,
-				Text:          pm.Text,
-				MediaType:     mediaType,
-				MediaCaption:  caption,
-				Filename:      filename,
-				MimeType:      mimeType,
-				DirectPath:    directPath,
-				MediaKey:      mediaKey,
-				FileSHA256:    fileSHA256,
-				FileEncSHA256: fileEncSHA256,
-				FileLength:    fileLength,
-			})
+		if len(msgs) > 0 {
+			rows := make([]store.UpsertChatParams, 0, len(chats))
+			for _, c := range chats {
+				rows = append(rows, c)
+			}
+			n, err := a.DB().UpsertMessages(rows, msgs)
+			if err != nil {
+				log.Printf("[Manager] History sync: stored %d of %d messages of %s: %v", n, len(msgs), chatID, err)
+			}
+			m.syncProgress.messages.Add(int64(n))
+		}
+		// Edits and revokes apply once the messages they target are stored.
+		for _, pm := range changes {
+			_, _ = app.ApplyMessageChange(a.DB(), pm)
+		}
+		if conv.UnreadCount != nil {
+			_ = a.DB().SetChatUnreadCount(chatID, int(conv.GetUnreadCount()))
 		}
 	}
+	m.syncProgress.historyChunks.Add(1)
 }
 
-// SendText sends a text message to the specified recipient.
+// messageParams maps a parsed message to its store row.
+func messageParams(pm wa.ParsedMessage, chatName string) store.UpsertMessageParams {
+	p := store.UpsertMessageParams{
+		ChatJID:    pm.Chat.String(),
+		ChatName:   chatName,
+		MsgID:      pm.ID,
+		SenderJID:  pm.SenderJID,
+		SenderName: pm.PushName,
+		Timestamp:  pm.Timestamp,
+		FromMe:     pm.FromMe,
+		Text:       pm.Text,
+	}
+	p.ReplyToID = pm.ReplyToID
+	p.ReplyToSender = pm.ReplyToSender
+	if pm.Media != nil {
+		p.MediaType = pm.Media.Type
+		p.MediaCaption = pm.Media.Caption
+		p.Filename = pm.Media.Filename
+		p.MimeType = pm.Media.MimeType
+		p.DirectPath = pm.Media.DirectPath
+		p.MediaKey = pm.Media.MediaKey
+		p.FileSHA256 = pm.Media.FileSHA256
+		p.FileEncSHA256 = pm.Media.FileEncSHA256
+		p.FileLength = pm.Media.FileLength
+		p.Duration = pm.Media.Seconds
+	}
+	return p
+}
+
+// SendText sends a text message to the specified recipient. A message
+// WhatsApp does not accept is kept as a failed send for retrying.
 func (m *Manager) SendText(ctx context.Context, to, text string) (string, error) {
+	msgID, err := m.sendText(ctx, to, text)
+	m.recordFailedText(text, err)
+	return msgID, err
+}
+
+func (m *Manager) sendText(ctx context.Context, to, text string) (string, error) {
 	if !m.state.State().IsReady() {
-		return "", fmt.Errorf("service not ready (state: %s)", m.state.State())
+		return "", fmt.Errorf("%w (state: %s)", ErrNotReady, m.state.State())
 	}
 
 	a := m.App()
@@ -573,10 +934,21 @@ func (m *Manager) SendText(ctx context.Context, to, text string) (string, error)
 	if err != nil {
 		return "", fmt.Errorf("invalid recipient: %w", err)
 	}
+	if err := m.checkRecipient(ctx, a, to, toJID); err != nil {
+		return "", err
+	}
+	if err := m.throttleSend(toJID.String()); err != nil {
+		return "", err
+	}
+	typed, err := m.humanize(ctx, a, toJID, text, false)
+	if err != nil {
+		return "", err
+	}
 
 	msgID, err := a.WA().SendText(ctx, toJID, text)
+	typed()
 	if err != nil {
-		return "", err
+		return "", &sendError{chatJID: toJID.String(), err: err}
 	}
 
 	// Store sent message
@@ -592,22 +964,85 @@ func (m *Manager) SendText(ctx context.Context, to, text string) (string, error)
 		FromMe:     true,
 		Text:       text,
 	})
+	m.emitEvent(EventMessageSent, &SentMessage{
+		ChatJID:   toJID.String(),
+		MsgID:     string(msgID),
+		Timestamp: now,
+		Text:      text,
+	})
 
 	return string(msgID), nil
 }
 
 // SendFileResult contains the result of sending a file.
 type SendFileResult struct {
-	MessageID string
-	MediaType string
-	Filename  string
-	MimeType  string
+	MessageID     string
+	MediaType     string
+	Filename      string
+	MimeType      string
+	Bytes         int64 // as sent, after any transcoding or optimization
+	OriginalBytes int64 // as received
+}
+
+// SendFileOptions are optional settings of a file send.
+type SendFileOptions struct {
+	Thumbnail   []byte // preview image (JPEG, PNG or GIF) instead of the generated one
+	GIFPlayback bool   // send a video to autoplay and loop silently like a GIF
+}
+
+// SendFile sends a file/media to the specified recipient. A file that fails
+// to upload or send is kept as a failed send for retrying.
+func (m *Manager) SendFile(ctx context.Context, to string, data []byte, filename, caption, mimeType string, opts SendFileOptions) (*SendFileResult, error) {
+	file := mediaFile{data: data}
+	res, err := m.sendMedia(ctx, to, file, filename, caption, mimeType, opts)
+	m.recordFailedFile(file, filename, caption, mimeType, err)
+	return res, err
+}
+
+// mediaFile is the content of a file to send, in memory or on disk.
+type mediaFile struct {
+	data []byte
+	path string // used when data is nil; streamed to WhatsApp
+}
+
+// size returns the length of the file in bytes.
+func (f mediaFile) size() int64 {
+	if f.data != nil {
+		return int64(len(f.data))
+	}
+	fi, err := os.Stat(f.path)
+	if err != nil {
+		return 0
+	}
+	return fi.Size()
+}
+
+// head returns the first bytes of the file for content sniffing.
+func (f mediaFile) head() []byte {
+	if f.data != nil {
+		return f.data
+	}
+	fh, err := os.Open(f.path)
+	if err != nil {
+		return nil
+	}
+	defer fh.Close()
+	buf := make([]byte, 512)
+	n, _ := io.ReadFull(fh, buf)
+	return buf[:n]
+}
+
+// replaceExt swaps the extension of a filename for a re-encoded file.
+func replaceExt(name, ext string) string {
+	if name == "" {
+		return ""
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ext
 }
 
-// SendFile sends a file/media to the specified recipient.
-func (m *Manager) SendFile(ctx context.Context, to string, data []byte, filename, caption, mimeType string) (*SendFileResult, error) {
+func (m *Manager) sendMedia(ctx context.Context, to string, file mediaFile, filename, caption, mimeType string, opts SendFileOptions) (*SendFileResult, error) {
 	if !m.state.State().IsReady() {
-		return nil, fmt.Errorf("service not ready (state: %s)", m.state.State())
+		return nil, fmt.Errorf("%w (state: %s)", ErrNotReady, m.state.State())
 	}
 
 	a := m.App()
@@ -619,10 +1054,26 @@ func (m *Manager) SendFile(ctx context.Context, to string, data []byte, filename
 	if err != nil {
 		return nil, fmt.Errorf("invalid recipient: %w", err)
 	}
+	if err := m.checkRecipient(ctx, a, to, toJID); err != nil {
+		return nil, err
+	}
 
-	// Detect mime type if not provided
+	originalBytes := file.size()
+	if err := m.config.checkSendSize(originalBytes); err != nil {
+		return nil, err
+	}
+	if err := m.throttleSend(toJID.String()); err != nil {
+		return nil, err
+	}
+
+	// Detect mime type if not provided, otherwise make sure it fits the content
 	if mimeType == "" {
-		mimeType = detectMimeType(filename, data)
+		mimeType = detectMimeType(filename, file.head())
+	} else if err := checkDeclaredMime(mimeType, file.head()); err != nil {
+		return nil, err
+	}
+	if err := m.config.checkSendType(mimeType); err != nil {
+		return nil, err
 	}
 
 	// Determine media type and upload type
@@ -640,19 +1091,83 @@ func (m *Manager) SendFile(ctx context.Context, to string, data []byte, filename
 		uploadType, _ = wa.MediaTypeFromString("audio")
 	}
 
+	if opts.GIFPlayback && mediaType != "video" {
+		return nil, fmt.Errorf("%w, got %s", ErrGIFNotVideo, mimeType)
+	}
+	var thumb *wa.Thumbnail
+	if opts.Thumbnail != nil {
+		if thumb, err = wa.CustomThumbnail(opts.Thumbnail, mediaType); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidThumbnail, err)
+		}
+	}
+
+	if mediaType == "video" && m.config.TranscodeVideo {
+		out, cleanup, err := m.transcodeVideo(ctx, file)
+		if err != nil {
+			log.Printf("[Media] Sending %s as is, transcoding failed: %v", filename, err)
+		} else {
+			defer cleanup()
+			file = out
+			filename = replaceExt(filename, ".mp4")
+			mimeType = "video/mp4"
+		}
+	}
+	if mediaType == "image" && m.config.OptimizeImages {
+		out, err := m.optimizeImage(file)
+		if err != nil {
+			log.Printf("[Media] Sending %s as is, optimizing failed: %v", filename, err)
+		} else if out != nil {
+			file = mediaFile{data: out}
+			filename = replaceExt(filename, ".jpg")
+			mimeType = "image/jpeg"
+		}
+	}
+
 	// Upload the file
-	up, err := a.WA().Upload(ctx, data, uploadType)
+	var up whatsmeow.UploadResponse
+	if file.data != nil {
+		up, err = a.WA().Upload(ctx, file.data, uploadType)
+	} else {
+		up, err = a.WA().UploadFile(ctx, file.path, uploadType)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("upload failed: %w", err)
+		return nil, &sendError{chatJID: toJID.String(), err: fmt.Errorf("upload failed: %w", err)}
 	}
 
 	// Build the message
 	msg := buildMediaMessage(mediaType, mimeType, filename, caption, up)
+	if opts.GIFPlayback {
+		msg.VideoMessage.GifPlayback = proto.Bool(true)
+		mediaType = "gif"
+	}
+	if file.data != nil {
+		err = wa.AttachThumbnail(ctx, msg, file.data)
+	} else {
+		err = wa.AttachFileThumbnail(ctx, msg, file.path)
+	}
+	if thumb != nil {
+		wa.SetThumbnail(msg, thumb)
+	} else if err != nil {
+		log.Printf("[Media] Sending %s without thumbnail: %v", filename, err)
+	}
+	if file.data != nil {
+		err = wa.AttachAudioInfo(ctx, msg, file.data)
+	} else {
+		err = wa.AttachFileAudioInfo(ctx, msg, file.path)
+	}
+	if err != nil {
+		log.Printf("[Media] Sending %s without duration and waveform: %v", filename, err)
+	}
 
 	// Send the message
+	typed, err := m.humanize(ctx, a, toJID, caption, mediaType == "audio")
+	if err != nil {
+		return nil, err
+	}
 	msgID, err := a.WA().SendProtoMessage(ctx, toJID, msg)
+	typed()
 	if err != nil {
-		return nil, fmt.Errorf("send failed: %w", err)
+		return nil, &sendError{chatJID: toJID.String(), err: fmt.Errorf("send failed: %w", err)}
 	}
 
 	// Store sent message
@@ -676,44 +1191,60 @@ func (m *Manager) SendFile(ctx context.Context, to string, data []byte, filename
 		FileSHA256:    up.FileSHA256,
 		FileEncSHA256: up.FileEncSHA256,
 		FileLength:    up.FileLength,
+		Duration:      msg.GetAudioMessage().GetSeconds(),
 	})
-
-	return &SendFileResult{
-		MessageID: msgID,
+	m.emitEvent(EventMessageSent, &SentMessage{
+		ChatJID:   toJID.String(),
+		MsgID:     msgID,
+		Timestamp: now,
+		Text:      caption,
 		MediaType: mediaType,
 		Filename:  filename,
-		MimeType:  mimeType,
+	})
+
+	return &SendFileResult{
+		MessageID:     msgID,
+		MediaType:     mediaType,
+		Filename:      filename,
+		MimeType:      mimeType,
+		Bytes:         int64(up.FileLength),
+		OriginalBytes: originalBytes,
 	}, nil
 }
 
 // SearchMessages searches messages in the database.
-func (m *Manager) SearchMessages(query string, limit int) ([]store.Message, error) {
+func (m *Manager) SearchMessages(p store.SearchMessagesParams) ([]store.Message, error) {
 	a := m.App()
 	if a == nil {
-		return nil, fmt.Errorf("app not initialized")
+		return nil, errNotInitialized
 	}
 
-	return a.DB().SearchMessages(store.SearchMessagesParams{
-		Query: query,
-		Limit: limit,
-	})
+	return a.DB().SearchMessages(p)
 }
 
-// ListChats returns recent chats.
-func (m *Manager) ListChats(query string, limit int) ([]store.Chat, error) {
+// ListChats returns the chats matching p, most recent first unless p asks
+// otherwise. p.LabelID may also be a label name.
+func (m *Manager) ListChats(p store.ListChatsParams) ([]store.Chat, error) {
 	a := m.App()
 	if a == nil {
-		return nil, fmt.Errorf("app not initialized")
+		return nil, errNotInitialized
+	}
+	if p.LabelID != "" {
+		l, err := m.findLabel(p.LabelID)
+		if err != nil {
+			return nil, err
+		}
+		p.LabelID = l.ID
 	}
 
-	return a.DB().ListChats(query, limit)
+	return a.DB().FilterChats(p)
 }
 
 // ListMessages returns messages from a chat.
 func (m *Manager) ListMessages(chatJID string, limit int) ([]store.Message, error) {
 	a := m.App()
 	if a == nil {
-		return nil, fmt.Errorf("app not initialized")
+		return nil, errNotInitialized
 	}
 
 	return a.DB().ListMessages(store.ListMessagesParams{
@@ -726,7 +1257,7 @@ func (m *Manager) ListMessages(chatJID string, limit int) ([]store.Message, erro
 func (m *Manager) GetMediaDownloadInfo(chatJID, msgID string) (store.MediaDownloadInfo, error) {
 	a := m.App()
 	if a == nil {
-		return store.MediaDownloadInfo{}, fmt.Errorf("app not initialized")
+		return store.MediaDownloadInfo{}, errNotInitialized
 	}
 
 	return a.DB().GetMediaDownloadInfo(chatJID, msgID)
@@ -746,12 +1277,12 @@ type DownloadMediaResult struct {
 // DownloadMedia downloads media for a message and saves it to the store.
 func (m *Manager) DownloadMedia(ctx context.Context, chatJID, msgID string) (*DownloadMediaResult, error) {
 	if !m.state.State().IsReady() {
-		return nil, fmt.Errorf("service not ready (state: %s)", m.state.State())
+		return nil, fmt.Errorf("%w (state: %s)", ErrNotReady, m.state.State())
 	}
 
 	a := m.App()
 	if a == nil || a.WA() == nil {
-		return nil, fmt.Errorf("app not initialized")
+		return nil, errNotInitialized
 	}
 
 	// Get media info from database
@@ -761,7 +1292,7 @@ func (m *Manager) DownloadMedia(ctx context.Context, chatJID, msgID string) (*Do
 	}
 
 	if info.MediaType == "" || info.DirectPath == "" || len(info.MediaKey) == 0 {
-		return nil, fmt.Errorf("message has no downloadable media metadata")
+		return nil, ErrNoMedia
 	}
 
 	// If already downloaded, return existing info
@@ -783,8 +1314,14 @@ func (m *Manager) DownloadMedia(ctx context.Context, chatJID, msgID string) (*Do
 		return nil, fmt.Errorf("failed to resolve output path: %w", err)
 	}
 
-	// Download the media
-	bytes, err := a.WA().DownloadMediaToFile(ctx, info.DirectPath, info.FileEncSHA256, info.FileSHA256, info.MediaKey, info.FileLength, info.MediaType, "", targetPath)
+	job, err := m.beginDownload(info.ChatJID, info.MsgID, targetPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Download the media (large files resume from a previous partial attempt)
+	bytes, err := a.WA().DownloadMediaToFile(wa.WithDownloadProgress(ctx, job.progress), info.DirectPath, info.FileEncSHA256, info.FileSHA256, info.MediaKey, info.FileLength, info.MediaType, "", targetPath)
+	m.finishDownload(job, err)
 	if err != nil {
 		return nil, fmt.Errorf("download failed: %w", err)
 	}
@@ -826,6 +1363,9 @@ func chatKind(chat types.JID) string {
 	if chat.IsBroadcastList() {
 		return "broadcast"
 	}
+	if chat.Server == types.NewsletterServer {
+		return "channel"
+	}
 	if chat.Server == types.DefaultUserServer {
 		return "dm"
 	}
@@ -838,7 +1378,7 @@ func chatKind(chat types.JID) string {
 func (m *Manager) SearchContacts(query string, limit int) ([]store.Contact, error) {
 	a := m.App()
 	if a == nil {
-		return nil, fmt.Errorf("app not initialized")
+		return nil, errNotInitialized
 	}
 	return a.DB().SearchContacts(query, limit)
 }
@@ -847,7 +1387,7 @@ func (m *Manager) SearchContacts(query string, limit int) ([]store.Contact, erro
 func (m *Manager) GetContact(jid string) (store.Contact, error) {
 	a := m.App()
 	if a == nil {
-		return store.Contact{}, fmt.Errorf("app not initialized")
+		return store.Contact{}, errNotInitialized
 	}
 	return a.DB().GetContact(jid)
 }
@@ -856,7 +1396,7 @@ func (m *Manager) GetContact(jid string) (store.Contact, error) {
 func (m *Manager) RefreshContacts(ctx context.Context) (int, error) {
 	a := m.App()
 	if a == nil || a.WA() == nil {
-		return 0, fmt.Errorf("app not initialized")
+		return 0, errNotInitialized
 	}
 
 	contacts, err := a.WA().GetAllContacts(ctx)
@@ -881,7 +1421,7 @@ func (m *Manager) RefreshContacts(ctx context.Context) (int, error) {
 func (m *Manager) SetContactAlias(jid, alias string) error {
 	a := m.App()
 	if a == nil {
-		return fmt.Errorf("app not initialized")
+		return errNotInitialized
 	}
 	return a.DB().SetAlias(jid, alias)
 }
@@ -890,7 +1430,7 @@ func (m *Manager) SetContactAlias(jid, alias string) error {
 func (m *Manager) RemoveContactAlias(jid string) error {
 	a := m.App()
 	if a == nil {
-		return fmt.Errorf("app not initialized")
+		return errNotInitialized
 	}
 	return a.DB().RemoveAlias(jid)
 }
@@ -899,7 +1439,7 @@ func (m *Manager) RemoveContactAlias(jid string) error {
 func (m *Manager) AddContactTag(jid, tag string) error {
 	a := m.App()
 	if a == nil {
-		return fmt.Errorf("app not initialized")
+		return errNotInitialized
 	}
 	return a.DB().AddTag(jid, tag)
 }
@@ -908,7 +1448,7 @@ func (m *Manager) AddContactTag(jid, tag string) error {
 func (m *Manager) RemoveContactTag(jid, tag string) error {
 	a := m.App()
 	if a == nil {
-		return fmt.Errorf("app not initialized")
+		return errNotInitialized
 	}
 	return a.DB().RemoveTag(jid, tag)
 }
@@ -919,7 +1459,7 @@ func (m *Manager) RemoveContactTag(jid, tag string) error {
 func (m *Manager) ListGroups(query string, limit int) ([]store.Group, error) {
 	a := m.App()
 	if a == nil {
-		return nil, fmt.Errorf("app not initialized")
+		return nil, errNotInitialized
 	}
 	return a.DB().ListGroups(query, limit)
 }
@@ -928,12 +1468,12 @@ func (m *Manager) ListGroups(query string, limit int) ([]store.Group, error) {
 func (m *Manager) GetGroupInfo(ctx context.Context, jidStr string) (*types.GroupInfo, error) {
 	a := m.App()
 	if a == nil || a.WA() == nil {
-		return nil, fmt.Errorf("app not initialized")
+		return nil, errNotInitialized
 	}
 
 	jid, err := types.ParseJID(jidStr)
 	if err != nil {
-		return nil, fmt.Errorf("invalid JID: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJID, err)
 	}
 
 	return a.WA().GetGroupInfo(ctx, jid)
@@ -943,7 +1483,7 @@ func (m *Manager) GetGroupInfo(ctx context.Context, jidStr string) (*types.Group
 func (m *Manager) RefreshGroups(ctx context.Context) (int, error) {
 	a := m.App()
 	if a == nil || a.WA() == nil {
-		return 0, fmt.Errorf("app not initialized")
+		return 0, errNotInitialized
 	}
 
 	groups, err := a.WA().GetJoinedGroups(ctx)
@@ -953,12 +1493,7 @@ func (m *Manager) RefreshGroups(ctx context.Context) (int, error) {
 
 	count := 0
 	for _, g := range groups {
-		ownerJID := ""
-		if g.OwnerJID.User != "" {
-			ownerJID = g.OwnerJID.String()
-		}
-		err := a.DB().UpsertGroup(g.JID.String(), g.Name, ownerJID, g.GroupCreated)
-		if err != nil {
+		if err := persistGroupInfo(a.DB(), g); err != nil {
 			log.Printf("[Manager] Failed to upsert group %s: %v", g.JID.String(), err)
 			continue
 		}
@@ -968,26 +1503,139 @@ func (m *Manager) RefreshGroups(ctx context.Context) (int, error) {
 	return count, nil
 }
 
+// CreateGroup creates a group with the given participants (phone numbers or
+// JIDs) and stores it locally.
+func (m *Manager) CreateGroup(ctx context.Context, name string, participants []string) (*types.GroupInfo, error) {
+	a := m.App()
+	if a == nil || a.WA() == nil {
+		return nil, errNotInitialized
+	}
+	if !m.state.State().IsReady() {
+		return nil, fmt.Errorf("%w (state: %s)", ErrNotReady, m.state.State())
+	}
+
+	var userJIDs []types.JID
+	for _, user := range participants {
+		jid, err := wa.ParseUserOrJID(user)
+		if err != nil {
+			return nil, fmt.Errorf("%w: user %s: %w", ErrInvalidJID, user, err)
+		}
+		userJIDs = append(userJIDs, jid)
+	}
+
+	info, err := a.WA().CreateGroup(ctx, name, userJIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := persistGroupInfo(a.DB(), info); err != nil {
+		log.Printf("[Manager] Failed to store new group %s: %v", info.JID.String(), err)
+	}
+	return info, nil
+}
+
+// persistGroupInfo stores a group and its participants.
+func persistGroupInfo(db store.Store, g *types.GroupInfo) error {
+	ownerJID := ""
+	if g.OwnerJID.User != "" {
+		ownerJID = g.OwnerJID.String()
+	}
+	if err := db.UpsertGroup(g.JID.String(), g.Name, ownerJID, g.GroupCreated); err != nil {
+		return err
+	}
+	if err := db.SetGroupDescription(g.JID.String(), g.Topic); err != nil {
+		return err
+	}
+	if err := db.SetGroupCommunity(g.JID.String(), g.IsParent); err != nil {
+		return err
+	}
+	if err := db.LinkGroup(g.JID.String(), app.CommunityJID(g), g.IsDefaultSubGroup); err != nil {
+		return err
+	}
+	if err := db.PutLIDMappings(app.GroupLIDMappings(g)); err != nil {
+		return err
+	}
+	if len(g.Participants) > 0 {
+		ps := make([]store.GroupParticipant, len(g.Participants))
+		for i, p := range g.Participants {
+			role := "member"
+			if p.IsSuperAdmin {
+				role = "superadmin"
+			} else if p.IsAdmin {
+				role = "admin"
+			}
+			ps[i] = store.GroupParticipant{GroupJID: g.JID.String(), UserJID: p.JID.String(), Role: role}
+		}
+		return db.ReplaceGroupParticipants(g.JID.String(), ps)
+	}
+	return nil
+}
+
 // RenameGroup changes the name of a group.
 func (m *Manager) RenameGroup(ctx context.Context, jidStr, name string) error {
 	a := m.App()
 	if a == nil || a.WA() == nil {
-		return fmt.Errorf("app not initialized")
+		return errNotInitialized
+	}
+
+	jid, err := types.ParseJID(jidStr)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+
+	return groupAdminError(a.WA().SetGroupName(ctx, jid, name))
+}
+
+// SetGroupDescription changes the description (topic) of a group and
+// records it locally. An empty description removes it.
+func (m *Manager) SetGroupDescription(ctx context.Context, jidStr, description string) error {
+	a := m.App()
+	if a == nil || a.WA() == nil {
+		return errNotInitialized
+	}
+
+	jid, err := types.ParseJID(jidStr)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+
+	if err := a.WA().SetGroupTopic(ctx, jid, description); err != nil {
+		return groupAdminError(err)
+	}
+	return a.DB().SetGroupDescription(jid.String(), description)
+}
+
+// SetGroupSettings changes the announce ("only admins can send") and locked
+// ("only admins can edit info") flags of a group; nil leaves a flag as is.
+// It returns the group info as it stands afterwards.
+func (m *Manager) SetGroupSettings(ctx context.Context, jidStr string, announce, locked *bool) (*types.GroupInfo, error) {
+	a := m.App()
+	if a == nil || a.WA() == nil {
+		return nil, errNotInitialized
 	}
 
 	jid, err := types.ParseJID(jidStr)
 	if err != nil {
-		return fmt.Errorf("invalid JID: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJID, err)
 	}
 
-	return a.WA().SetGroupName(ctx, jid, name)
+	if announce != nil {
+		if err := a.WA().SetGroupAnnounce(ctx, jid, *announce); err != nil {
+			return nil, fmt.Errorf("set announce: %w", groupAdminError(err))
+		}
+	}
+	if locked != nil {
+		if err := a.WA().SetGroupLocked(ctx, jid, *locked); err != nil {
+			return nil, fmt.Errorf("set locked: %w", groupAdminError(err))
+		}
+	}
+	return a.WA().GetGroupInfo(ctx, jid)
 }
 
 // UpdateGroupParticipants modifies group participants.
 func (m *Manager) UpdateGroupParticipants(ctx context.Context, groupJIDStr string, users []string, action string) ([]types.GroupParticipant, error) {
 	a := m.App()
 	if a == nil || a.WA() == nil {
-		return nil, fmt.Errorf("app not initialized")
+		return nil, errNotInitialized
 	}
 
 	groupJID, err := types.ParseJID(groupJIDStr)
@@ -999,7 +1647,7 @@ func (m *Manager) UpdateGroupParticipants(ctx context.Context, groupJIDStr strin
 	for _, user := range users {
 		jid, err := wa.ParseUserOrJID(user)
 		if err != nil {
-			return nil, fmt.Errorf("invalid user %s: %w", user, err)
+			return nil, fmt.Errorf("%w: user %s: %w", ErrInvalidJID, user, err)
 		}
 		userJIDs = append(userJIDs, jid)
 	}
@@ -1018,44 +1666,70 @@ func (m *Manager) UpdateGroupParticipants(ctx context.Context, groupJIDStr strin
 		return nil, fmt.Errorf("invalid action: %s", action)
 	}
 
-	return a.WA().UpdateGroupParticipants(ctx, groupJID, userJIDs, waAction)
+	participants, err := a.WA().UpdateGroupParticipants(ctx, groupJID, userJIDs, waAction)
+	return participants, groupAdminError(err)
 }
 
 // GetGroupInviteLink returns the invite link for a group.
 func (m *Manager) GetGroupInviteLink(ctx context.Context, jidStr string) (string, error) {
 	a := m.App()
 	if a == nil || a.WA() == nil {
-		return "", fmt.Errorf("app not initialized")
+		return "", errNotInitialized
 	}
 
 	jid, err := types.ParseJID(jidStr)
 	if err != nil {
-		return "", fmt.Errorf("invalid JID: %w", err)
+		return "", fmt.Errorf("%w: %w", ErrInvalidJID, err)
 	}
 
-	return a.WA().GetGroupInviteLink(ctx, jid, false)
+	link, err := a.WA().GetGroupInviteLink(ctx, jid, false)
+	return link, groupAdminError(err)
 }
 
 // RevokeGroupInviteLink revokes and returns a new invite link.
 func (m *Manager) RevokeGroupInviteLink(ctx context.Context, jidStr string) (string, error) {
 	a := m.App()
 	if a == nil || a.WA() == nil {
-		return "", fmt.Errorf("app not initialized")
+		return "", errNotInitialized
 	}
 
 	jid, err := types.ParseJID(jidStr)
 	if err != nil {
-		return "", fmt.Errorf("invalid JID: %w", err)
+		return "", fmt.Errorf("%w: %w", ErrInvalidJID, err)
 	}
 
-	return a.WA().GetGroupInviteLink(ctx, jid, true)
+	link, err := a.WA().GetGroupInviteLink(ctx, jid, true)
+	return link, groupAdminError(err)
+}
+
+// PreviewGroupInvite resolves an invite code or chat.whatsapp.com link to the
+// group it points to, without joining.
+func (m *Manager) PreviewGroupInvite(ctx context.Context, code string) (*types.GroupInfo, error) {
+	a := m.App()
+	if a == nil || a.WA() == nil {
+		return nil, errNotInitialized
+	}
+	if !m.state.State().IsReady() {
+		return nil, fmt.Errorf("%w (state: %s)", ErrNotReady, m.state.State())
+	}
+
+	info, err := a.WA().GetGroupInfoFromLink(ctx, code)
+	switch {
+	case errors.Is(err, whatsmeow.ErrInviteLinkInvalid):
+		return nil, ErrInvalidInvite
+	case errors.Is(err, whatsmeow.ErrInviteLinkRevoked):
+		return nil, ErrInviteRevoked
+	case err != nil:
+		return nil, err
+	}
+	return info, nil
 }
 
 // JoinGroup joins a group using an invite code.
 func (m *Manager) JoinGroup(ctx context.Context, code string) (string, error) {
 	a := m.App()
 	if a == nil || a.WA() == nil {
-		return "", fmt.Errorf("app not initialized")
+		return "", errNotInitialized
 	}
 
 	jid, err := a.WA().JoinGroupWithLink(ctx, code)
@@ -1070,27 +1744,69 @@ func (m *Manager) JoinGroup(ctx context.Context, code string) (string, error) {
 func (m *Manager) LeaveGroup(ctx context.Context, jidStr string) error {
 	a := m.App()
 	if a == nil || a.WA() == nil {
-		return fmt.Errorf("app not initialized")
+		return errNotInitialized
 	}
 
 	jid, err := types.ParseJID(jidStr)
 	if err != nil {
-		return fmt.Errorf("invalid JID: %w", err)
+		return fmt.Errorf("%w: %w", ErrInvalidJID, err)
 	}
 
 	return a.WA().LeaveGroup(ctx, jid)
 }
 
+// GetGroupActivity returns per-member message counts and hour-of-day
+// distribution for a group, computed from stored messages.
+func (m *Manager) GetGroupActivity(jidStr string, since, until time.Time) (store.ChatActivity, error) {
+	a := m.App()
+	if a == nil {
+		return store.ChatActivity{}, errNotInitialized
+	}
+
+	jid, err := types.ParseJID(jidStr)
+	if err != nil {
+		return store.ChatActivity{}, fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+
+	return a.DB().ChatActivity(jid.String(), since, until)
+}
+
+// ChatStats returns per-chat message counts, busiest chats first, computed
+// from stored messages.
+func (m *Manager) ChatStats(p store.ChatStatsParams) ([]store.ChatStats, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	return a.DB().ChatStats(p)
+}
+
+// MessageTimeline returns sent and received message counts per bucket.
+func (m *Manager) MessageTimeline(p store.TimelineParams) ([]store.TimelineBucket, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	return a.DB().MessageTimeline(p)
+}
+
 // --- Sync Control Methods ---
 
-// SyncStatus returns the current sync worker status.
-func (m *Manager) SyncStatus() (running bool, state string, startedAt time.Time) {
+// SyncStatus returns the sync worker status and its progress since it last
+// started.
+func (m *Manager) SyncStatus() SyncProgress {
 	m.mu.RLock()
-	running = m.syncRunning
+	running := m.syncRunning
 	m.mu.RUnlock()
-	state = string(m.state.State())
-	// Note: startedAt tracking could be added if needed
-	return
+	return SyncProgress{
+		Running:        running,
+		State:          string(m.state.State()),
+		StartedAt:      unixNanoTime(m.syncProgress.startedAt.Load()),
+		MessagesSynced: m.syncProgress.messages.Load(),
+		HistoryChunks:  m.syncProgress.historyChunks.Load(),
+		LastEventAt:    unixNanoTime(m.syncProgress.lastEventAt.Load()),
+		Startup:        m.startup.snapshot(),
+	}
 }
 
 // IsSyncRunning returns whether the sync worker is running.
@@ -1120,7 +1836,7 @@ func (m *Manager) GetDiagnostics() (storeDir string, lockHeld bool, authenticate
 func (m *Manager) GetDBStats() (messageCount, chatCount, contactCount, groupCount int64, ftsEnabled bool, err error) {
 	a := m.App()
 	if a == nil {
-		err = fmt.Errorf("app not initialized")
+		err = errNotInitialized
 		return
 	}
 
@@ -1148,6 +1864,11 @@ func (m *Manager) GetDBStats() (messageCount, chatCount, contactCount, groupCoun
 	return
 }
 
+// MediaStats returns counters for media CDN throttling.
+func (m *Manager) MediaStats() wa.MediaStats {
+	return wa.MediaRateLimitStats()
+}
+
 // detectMimeType detects the MIME type from filename extension or content.
 func detectMimeType(filename string, data []byte) string {
 	// Try extension first
@@ -1233,7 +1954,7 @@ type BackfillResult struct {
 func (m *Manager) BackfillHistory(ctx context.Context, chatJID string, count, requests, waitSeconds int) (*BackfillResult, error) {
 	a := m.App()
 	if a == nil {
-		return nil, fmt.Errorf("app not initialized")
+		return nil, errNotInitialized
 	}
 
 	waitDuration := time.Duration(waitSeconds) * time.Second
@@ -1289,3 +2010,29 @@ func (m *Manager) StopSync() error {
 	}
 	return nil
 }
+
+// --- Replication ---
+
+// configureChangeLog installs the message change log the replication sender
+// reads when a standby is configured, and drops it otherwise so message
+// writes do not pay for its triggers.
+func (m *Manager) configureChangeLog(db store.Store) {
+	if m.config.ReplicaURL != "" {
+		if err := db.EnableChangeLog(); err != nil {
+			log.Printf("[Replica] Failed to enable the change log: %v", err)
+		}
+		return
+	}
+	if err := db.DisableChangeLog(); err != nil {
+		log.Printf("[Replica] Failed to drop the change log: %v", err)
+	}
+}
+
+// ApplyReplicaBatch writes a batch streamed from a primary instance into the local store.
+func (m *Manager) ApplyReplicaBatch(b replica.Batch) (replica.ApplyResult, error) {
+	a := m.App()
+	if a == nil {
+		return replica.ApplyResult{}, errNotInitialized
+	}
+	return replica.Apply(a.DB(), b)
+}
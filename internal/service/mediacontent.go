@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/steipete/wacli/internal/app"
+)
+
+// MediaContent is readable media of a message. Close releases it; streamed
+// media that was not downloaded before is deleted then.
+type MediaContent struct {
+	io.ReadSeekCloser
+	Filename string
+	MimeType string
+	ModTime  time.Time
+	Local    bool // served from the media dir rather than fetched from WhatsApp
+}
+
+// OpenMedia returns the media of a message for streaming. Media already in
+// the media dir is read from there; otherwise it is fetched and decrypted
+// into a scratch file without being stored.
+func (m *Manager) OpenMedia(ctx context.Context, chatJID, msgID string) (*MediaContent, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	info, err := a.DB().GetMediaDownloadInfo(chatJID, msgID)
+	if err != nil {
+		return nil, err
+	}
+	c := &MediaContent{Filename: app.MediaFilename(info), MimeType: info.MimeType}
+
+	if info.LocalPath != "" {
+		if f, err := os.Open(info.LocalPath); err == nil {
+			if st, err := f.Stat(); err == nil {
+				c.ModTime = st.ModTime()
+			}
+			c.ReadSeekCloser, c.Local = f, true
+			return c, nil
+		}
+	}
+
+	if info.MediaType == "" || info.DirectPath == "" || len(info.MediaKey) == 0 {
+		return nil, ErrNoMedia
+	}
+	if !m.state.State().IsReady() || a.WA() == nil {
+		return nil, fmt.Errorf("%w (state: %s)", ErrNotReady, m.state.State())
+	}
+	tmp, err := a.WA().DownloadMediaToTemp(ctx, info.DirectPath, info.FileEncSHA256, info.FileSHA256, info.MediaKey, info.FileLength, info.MediaType, "")
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	c.ReadSeekCloser = tmp
+	return c, nil
+}
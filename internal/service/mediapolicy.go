@@ -0,0 +1,88 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrFileTooLarge is returned when a file to send exceeds
+	// WASVC_SEND_MAX_MB.
+	ErrFileTooLarge = errors.New("file too large")
+	// ErrMediaTypeNotAllowed is returned when a file's MIME type is not in
+	// WASVC_SEND_ALLOWED_TYPES.
+	ErrMediaTypeNotAllowed = errors.New("media type not allowed")
+	// ErrMimeMismatch is returned when the declared MIME type of an image,
+	// video or audio file does not match its content.
+	ErrMimeMismatch = errors.New("mime type does not match content")
+	// ErrInvalidThumbnail is returned when a custom thumbnail is not a
+	// JPEG, PNG or GIF image.
+	ErrInvalidThumbnail = errors.New("invalid thumbnail")
+	// ErrGIFNotVideo is returned when GIF playback is asked for a file that
+	// is not a video.
+	ErrGIFNotVideo = errors.New("gif playback needs a video")
+)
+
+// checkSendSize enforces the configured limit on outbound files.
+func (c Config) checkSendSize(size int64) error {
+	if c.SendMaxBytes > 0 && size > c.SendMaxBytes {
+		return fmt.Errorf("%w: %d bytes, limit is %d MB", ErrFileTooLarge, size, c.SendMaxBytes>>20)
+	}
+	return nil
+}
+
+// checkSendType enforces the configured allow-list of outbound MIME types.
+// Entries are exact types or wildcards like "image/*".
+func (c Config) checkSendType(mimeType string) error {
+	if len(c.SendAllowedTypes) == 0 {
+		return nil
+	}
+	mt := baseMimeType(mimeType)
+	for _, allowed := range c.SendAllowedTypes {
+		if allowed == mt || (strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mt, strings.TrimSuffix(allowed, "*"))) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrMediaTypeNotAllowed, mt)
+}
+
+// checkDeclaredMime verifies a caller-supplied MIME type against the file's
+// leading bytes. Only images, videos and audio are checked, since those are
+// rendered by the recipient's phone according to the declared type;
+// documents are passed through. Content the sniffer does not recognize is
+// accepted.
+func checkDeclaredMime(declared string, head []byte) error {
+	want := baseMimeType(declared)
+	kind, _, _ := strings.Cut(want, "/")
+	if kind != "image" && kind != "video" && kind != "audio" {
+		return nil
+	}
+	got := baseMimeType(http.DetectContentType(head))
+	if got == "application/octet-stream" || strings.HasPrefix(got, "text/") {
+		return nil
+	}
+	gotKind, _, _ := strings.Cut(got, "/")
+	switch kind {
+	case "image":
+		if got == want || (want == "image/jpg" && got == "image/jpeg") {
+			return nil
+		}
+	default:
+		// Containers are shared: MP4 and Ogg hold audio as well as video.
+		if gotKind == "video" || gotKind == "audio" || got == "application/ogg" {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: declared %s, content is %s", ErrMimeMismatch, want, got)
+}
+
+// baseMimeType strips parameters from a MIME type and lowercases it.
+func baseMimeType(s string) string {
+	if mt, _, err := mime.ParseMediaType(s); err == nil {
+		return mt
+	}
+	return strings.ToLower(strings.TrimSpace(s))
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/steipete/wacli/internal/app"
+	"github.com/steipete/wacli/internal/phone"
+)
+
+// checkBatchSize is how many numbers are sent to WhatsApp per query.
+const checkBatchSize = 50
+
+// NumberCheck is the registration status of one phone number.
+type NumberCheck struct {
+	Phone        string // as given
+	Query        string // normalized +<digits>; empty if the number is invalid
+	Registered   bool
+	JID          string // canonical JID, set when registered
+	BusinessName string // verified business name, if any
+	Error        string
+}
+
+// CheckNumbers reports which phone numbers are registered on WhatsApp, in
+// the order given. Numbers may contain spaces, dashes, parentheses and a +
+// or 00 prefix, or be in national format when a default country code is
+// set; invalid ones are reported per entry instead of failing the whole
+// check.
+func (m *Manager) CheckNumbers(ctx context.Context, phones []string) ([]NumberCheck, error) {
+	a := m.App()
+	if a == nil || a.WA() == nil {
+		return nil, errNotInitialized
+	}
+	if !m.state.State().IsReady() {
+		return nil, fmt.Errorf("%w (state: %s)", ErrNotReady, m.state.State())
+	}
+
+	out := make([]NumberCheck, len(phones))
+	seen := make(map[string]bool)
+	var queries []string
+	for i, p := range phones {
+		out[i].Phone = p
+		q := normalizePhone(p)
+		if q == "" {
+			out[i].Error = "invalid phone number"
+			continue
+		}
+		out[i].Query = q
+		if !seen[q] {
+			seen[q] = true
+			queries = append(queries, q)
+		}
+	}
+
+	results := make(map[string]NumberCheck, len(queries))
+	for start := 0; start < len(queries); start += checkBatchSize {
+		end := start + checkBatchSize
+		if end > len(queries) {
+			end = len(queries)
+		}
+		resp, err := a.WA().IsOnWhatsApp(ctx, queries[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("check numbers: %w", err)
+		}
+		for _, r := range resp {
+			q := r.Query
+			if !strings.HasPrefix(q, "+") {
+				q = "+" + q
+			}
+			res := NumberCheck{Registered: r.IsIn}
+			if r.IsIn {
+				res.JID = r.JID.ToNonAD().String()
+			}
+			if r.VerifiedName != nil && r.VerifiedName.Details != nil {
+				res.BusinessName = r.VerifiedName.Details.GetVerifiedName()
+			}
+			results[q] = res
+		}
+	}
+
+	for i := range out {
+		if res, ok := results[out[i].Query]; ok {
+			out[i].Registered = res.Registered
+			out[i].JID = res.JID
+			out[i].BusinessName = res.BusinessName
+		}
+	}
+	return out, nil
+}
+
+// normalizePhone turns a phone number into the +<digits> form WhatsApp
+// expects, or returns "" if it is not a plausible E.164 number. National
+// numbers use the configured default country code.
+func normalizePhone(s string) string {
+	digits, err := phone.Normalize(strings.TrimSuffix(strings.TrimSpace(s), "@s.whatsapp.net"))
+	if err != nil {
+		return ""
+	}
+	return "+" + digits
+}
+
+// checkRecipient makes sure a recipient given as a bare phone number is on
+// WhatsApp before anything is sent to it, so a typo fails with
+// ErrNotOnWhatsApp instead of a message that never arrives. Known chats and
+// explicit JIDs are not checked, and a lookup that fails lets the send go
+// ahead.
+func (m *Manager) checkRecipient(ctx context.Context, a *app.App, to string, jid types.JID) error {
+	if strings.Contains(to, "@") || jid.Server != types.DefaultUserServer {
+		return nil
+	}
+	if _, err := a.DB().GetChat(jid.String()); err == nil {
+		return nil
+	}
+	resp, err := a.WA().IsOnWhatsApp(ctx, []string{"+" + jid.User})
+	if err != nil || len(resp) == 0 {
+		return nil
+	}
+	if !resp[0].IsIn {
+		return fmt.Errorf("%w: %s", ErrNotOnWhatsApp, to)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// maxOptimizeBytes bounds the images read into memory for optimization;
+// anything larger is sent as is.
+const maxOptimizeBytes = 64 << 20
+
+// optimizeImage downscales and recompresses an outbound image that exceeds
+// the configured limits. It returns nil if the image should be sent as is.
+func (m *Manager) optimizeImage(file mediaFile) ([]byte, error) {
+	data := file.data
+	if data == nil {
+		if file.size() > maxOptimizeBytes {
+			return nil, fmt.Errorf("image larger than %d MB", maxOptimizeBytes>>20)
+		}
+		var err error
+		if data, err = os.ReadFile(file.path); err != nil {
+			return nil, err
+		}
+	}
+	return wa.OptimizeImage(data, wa.ImageOptions{
+		MaxSide:  m.config.ImageMaxSide,
+		MaxBytes: m.config.ImageMaxBytes,
+	})
+}
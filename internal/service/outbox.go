@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// SendTextResult is the outcome of SendTextOrQueue: either the id of the
+// sent message, or the outbox id of the queued one.
+type SendTextResult struct {
+	MessageID string
+	OutboxID  int64
+	Queued    bool
+}
+
+// SendTextOrQueue sends a text message like SendText. With WASVC_OUTBOX,
+// a message that cannot be sent because the service is disconnected is
+// queued instead and sent once the connection is back, as is a message the
+// send throttle refuses. While messages are queued, new ones queue behind
+// them so the recipient gets them in order.
+func (m *Manager) SendTextOrQueue(ctx context.Context, to, text string) (SendTextResult, error) {
+	if !m.config.Outbox {
+		msgID, err := m.SendText(ctx, to, text)
+		return SendTextResult{MessageID: msgID}, err
+	}
+
+	a := m.App()
+	if a == nil {
+		return SendTextResult{}, errNotInitialized
+	}
+	toJID, err := wa.ParseUserOrJID(to)
+	if err != nil {
+		return SendTextResult{}, fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	queued, err := a.DB().CountOutbox(store.OutboxQueued)
+	if err != nil {
+		return SendTextResult{}, err
+	}
+	if queued == 0 && m.state.State().IsReady() {
+		msgID, err := m.sendText(ctx, toJID.String(), text)
+		if err == nil || m.connected() && !errors.Is(err, ErrSendThrottled) {
+			m.recordFailedText(text, err)
+			return SendTextResult{MessageID: msgID}, err
+		}
+		// The connection dropped under the send; queue it instead.
+	}
+
+	id, err := a.DB().EnqueueOutbox(toJID.String(), text)
+	if err != nil {
+		return SendTextResult{}, fmt.Errorf("queue message: %w", err)
+	}
+	m.wakeOutbox()
+	return SendTextResult{OutboxID: id, Queued: true}, nil
+}
+
+// ListOutbox returns outbox messages with the given status (any if empty),
+// oldest first.
+func (m *Manager) ListOutbox(status string, limit int) ([]store.OutboxMessage, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	return a.DB().ListOutbox(status, limit)
+}
+
+// GetOutbox returns one outbox message.
+func (m *Manager) GetOutbox(id int64) (store.OutboxMessage, error) {
+	a := m.App()
+	if a == nil {
+		return store.OutboxMessage{}, errNotInitialized
+	}
+	msg, err := a.DB().GetOutbox(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.OutboxMessage{}, fmt.Errorf("outbox message %d %w", id, ErrNotFound)
+	}
+	return msg, err
+}
+
+// CancelOutbox cancels a queued message.
+func (m *Manager) CancelOutbox(id int64) error {
+	msg, err := m.GetOutbox(id)
+	if err != nil {
+		return err
+	}
+	ok, err := m.App().DB().CancelOutbox(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("outbox message %d is %s, %w", id, msg.Status, ErrNotQueued)
+	}
+	return nil
+}
+
+// wakeOutbox asks the outbox sender to look for queued messages.
+func (m *Manager) wakeOutbox() {
+	if m.outboxWake == nil {
+		return
+	}
+	select {
+	case m.outboxWake <- struct{}{}:
+	default:
+	}
+}
+
+// runOutbox sends queued messages whenever it is woken: on connect and
+// whenever a message is queued.
+func (m *Manager) runOutbox(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.outboxWake:
+			m.flushOutbox(ctx)
+		}
+	}
+}
+
+// flushOutbox sends queued messages in order until the queue is empty or
+// the connection drops. A message WhatsApp refuses fails on its own and does
+// not hold up the rest.
+func (m *Manager) flushOutbox(ctx context.Context) {
+	a := m.App()
+	if a == nil {
+		return
+	}
+	for ctx.Err() == nil && m.state.State().IsReady() {
+		msg, err := a.DB().NextOutbox()
+		if errors.Is(err, sql.ErrNoRows) {
+			return
+		}
+		if err != nil {
+			log.Printf("[Outbox] Failed to read queue: %v", err)
+			return
+		}
+		if m.config.OutboxMaxAge > 0 && time.Since(msg.CreatedAt) > m.config.OutboxMaxAge {
+			m.updateOutbox(msg.ID, store.OutboxFailed, "", fmt.Sprintf("expired after %s in the outbox", m.config.OutboxMaxAge))
+			continue
+		}
+
+		m.updateOutbox(msg.ID, store.OutboxSending, "", "")
+		msgID, err := m.sendText(ctx, msg.ChatJID, msg.Text) // failures stay in the outbox
+		switch {
+		case err == nil:
+			m.updateOutbox(msg.ID, store.OutboxSent, msgID, "")
+		case errors.Is(err, ErrSendThrottled):
+			// Keep the place in the queue and wait for the throttle.
+			m.updateOutbox(msg.ID, store.OutboxQueued, "", "")
+			if !waitThrottled(ctx, err) {
+				return
+			}
+		case !m.connected():
+			m.updateOutbox(msg.ID, store.OutboxQueued, "", err.Error())
+			return
+		default:
+			log.Printf("[Outbox] Message %d to %s failed: %v", msg.ID, msg.ChatJID, err)
+			m.updateOutbox(msg.ID, store.OutboxFailed, "", err.Error())
+		}
+	}
+}
+
+func (m *Manager) updateOutbox(id int64, status, msgID, errText string) {
+	a := m.App()
+	if a == nil {
+		return
+	}
+	if err := a.DB().UpdateOutbox(id, status, msgID, errText); err != nil {
+		log.Printf("[Outbox] Failed to mark message %d %s: %v", id, status, err)
+	}
+}
+
+// connected reports whether the WhatsApp client is connected right now.
+func (m *Manager) connected() bool {
+	a := m.App()
+	return a != nil && a.WA() != nil && a.WA().IsConnected()
+}
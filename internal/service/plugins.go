@@ -0,0 +1,49 @@
+package service
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/plugin"
+	"github.com/steipete/wacli/internal/webhook"
+)
+
+// runPlugins passes an inbound message to the configured plugins, applies
+// the actions they return, and then hands the message to the message
+// handlers unless a plugin dropped it.
+func (m *Manager) runPlugins(msg *ReceivedMessage) {
+	actions := m.plugins.Run(m.ctx, &webhook.Event{
+		Type:      EventMessageReceived,
+		Timestamp: time.Now().UTC(),
+		Data:      msg,
+	})
+
+	drop := false
+	for _, act := range actions {
+		switch act.Type {
+		case plugin.ActionReply:
+			if strings.TrimSpace(act.Text) == "" {
+				continue
+			}
+			if _, err := m.SendText(m.ctx, msg.ChatJID, act.Text); err != nil {
+				log.Printf("[Plugin] Reply to %s failed: %v", msg.ChatJID, err)
+			}
+		case plugin.ActionTag:
+			tag := strings.TrimSpace(act.Tag)
+			if tag == "" {
+				continue
+			}
+			if err := m.tagSender(msg, tag); err != nil {
+				log.Printf("[Plugin] Tag %q failed: %v", tag, err)
+			}
+		case plugin.ActionDropWebhook:
+			drop = true
+		default:
+			log.Printf("[Plugin] Ignoring unknown action %q", act.Type)
+		}
+	}
+	if !drop {
+		m.notifyMessageHandlers(msg)
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// SetPresence marks the linked device available (online) or unavailable
+// (offline). The choice is re-sent after every reconnect.
+func (m *Manager) SetPresence(ctx context.Context, state string) error {
+	presence := types.Presence(state)
+	if presence != types.PresenceAvailable && presence != types.PresenceUnavailable {
+		return fmt.Errorf("invalid presence %q: must be available or unavailable", state)
+	}
+	if !m.state.State().IsReady() {
+		return fmt.Errorf("%w (state: %s)", ErrNotReady, m.state.State())
+	}
+	a := m.App()
+	if a == nil || a.WA() == nil {
+		return fmt.Errorf("WhatsApp client not available")
+	}
+	if err := a.WA().SendPresence(ctx, presence); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.presence = presence
+	m.mu.Unlock()
+	return nil
+}
+
+// Presence returns the presence last set through SetPresence, or "" if none.
+func (m *Manager) Presence() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return string(m.presence)
+}
+
+// restorePresence re-sends the chosen presence after a reconnect, since
+// WhatsApp forgets it with the connection.
+func (m *Manager) restorePresence() {
+	m.mu.RLock()
+	presence := m.presence
+	m.mu.RUnlock()
+	a := m.App()
+	if presence == "" || a == nil || a.WA() == nil {
+		return
+	}
+	if err := a.WA().SendPresence(m.ctx, presence); err != nil {
+		log.Printf("[Manager] Failed to restore presence %s: %v", presence, err)
+	}
+}
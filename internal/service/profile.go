@@ -0,0 +1,120 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"path/filepath"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/steipete/wacli/internal/pathutil"
+)
+
+// Profile is the linked account's own profile.
+type Profile struct {
+	JID       string
+	PushName  string
+	About     string // empty while disconnected
+	PictureID string // empty if there is no picture or while disconnected
+}
+
+// selfJID returns the linked account's JID.
+func (m *Manager) selfJID() (types.JID, error) {
+	a := m.App()
+	if a == nil || a.WA() == nil {
+		return types.EmptyJID, errNotInitialized
+	}
+	jid, _ := a.WA().Self()
+	if jid.IsEmpty() {
+		return types.EmptyJID, ErrNotAuthenticated
+	}
+	return jid, nil
+}
+
+// GetProfile returns the linked account's profile. The about text and
+// picture ID are only fetched while connected.
+func (m *Manager) GetProfile(ctx context.Context) (*Profile, error) {
+	jid, err := m.selfJID()
+	if err != nil {
+		return nil, err
+	}
+	a := m.App()
+	_, pushName := a.WA().Self()
+	p := &Profile{JID: jid.String(), PushName: pushName}
+	if !m.state.State().IsReady() {
+		return p, nil
+	}
+	infos, err := a.WA().GetUserInfo(ctx, []types.JID{jid})
+	if err != nil {
+		return nil, fmt.Errorf("get profile: %w", err)
+	}
+	if info, ok := infos[jid]; ok {
+		p.About = info.Status
+		p.PictureID = info.PictureID
+	}
+	return p, nil
+}
+
+// SetPushName changes the name other users see for the linked account.
+func (m *Manager) SetPushName(ctx context.Context, name string) error {
+	if _, err := m.selfJID(); err != nil {
+		return err
+	}
+	if !m.state.State().IsReady() {
+		return fmt.Errorf("%w (state: %s)", ErrNotReady, m.state.State())
+	}
+	return m.App().WA().SendAppState(ctx, appstate.BuildSettingPushName(name))
+}
+
+// SetAbout changes the linked account's about text.
+func (m *Manager) SetAbout(ctx context.Context, about string) error {
+	if _, err := m.selfJID(); err != nil {
+		return err
+	}
+	if !m.state.State().IsReady() {
+		return fmt.Errorf("%w (state: %s)", ErrNotReady, m.state.State())
+	}
+	return m.App().WA().SetAbout(ctx, about)
+}
+
+// SetProfilePhoto changes the linked account's profile picture to a JPEG
+// image and returns the new picture ID. Cached copies of the old picture are
+// dropped.
+func (m *Manager) SetProfilePhoto(ctx context.Context, data []byte) (string, error) {
+	jid, err := m.selfJID()
+	if err != nil {
+		return "", err
+	}
+	if _, err := jpeg.DecodeConfig(bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("%w: must be a JPEG", ErrInvalidImage)
+	}
+	if !m.state.State().IsReady() {
+		return "", fmt.Errorf("%w (state: %s)", ErrNotReady, m.state.State())
+	}
+	a := m.App()
+	id, err := a.WA().SetProfilePhoto(ctx, data)
+	if errors.Is(err, whatsmeow.ErrInvalidImageFormat) {
+		return "", fmt.Errorf("%w: %w", ErrInvalidImage, err)
+	} else if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(a.StoreDir(), "media", "profile", pathutil.SanitizeSegment(jid.String()))
+	removeProfilePhotos(dir, "image", "")
+	removeProfilePhotos(dir, "preview", "")
+	return id, nil
+}
+
+// GetOwnProfilePhoto returns the linked account's profile picture; see
+// GetProfilePhoto.
+func (m *Manager) GetOwnProfilePhoto(ctx context.Context, preview bool) (*ProfilePhoto, error) {
+	jid, err := m.selfJID()
+	if err != nil {
+		return nil, err
+	}
+	return m.GetProfilePhoto(ctx, jid.String(), preview)
+}
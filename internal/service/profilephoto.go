@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/steipete/wacli/internal/pathutil"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// ProfilePhoto is a profile picture cached in the media directory.
+type ProfilePhoto struct {
+	JID  string
+	ID   string // changes whenever the picture does
+	Path string
+}
+
+// GetProfilePhoto returns the profile picture of a user, group or community,
+// downloading it into media/profile/<jid>/ unless the cached copy is still
+// current. Preview selects the small thumbnail instead of the full-size
+// picture. While disconnected, a cached copy is served as is.
+func (m *Manager) GetProfilePhoto(ctx context.Context, jidStr string, preview bool) (*ProfilePhoto, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	jid, err := wa.ParseUserOrJID(jidStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+	jid = jid.ToNonAD()
+	switch jid.Server {
+	case types.DefaultUserServer, types.HiddenUserServer, types.GroupServer:
+	default:
+		return nil, fmt.Errorf("%w: %s has no profile picture", ErrInvalidJID, jid)
+	}
+
+	kind := "image"
+	if preview {
+		kind = "preview"
+	}
+	dir := filepath.Join(a.StoreDir(), "media", "profile", pathutil.SanitizeSegment(jid.String()))
+	cached := cachedProfilePhoto(dir, kind)
+	if cached != nil {
+		cached.JID = jid.String()
+	}
+
+	if !m.state.State().IsReady() || a.WA() == nil {
+		if cached != nil {
+			return cached, nil
+		}
+		return nil, fmt.Errorf("%w (state: %s)", ErrNotReady, m.state.State())
+	}
+
+	params := &whatsmeow.GetProfilePictureParams{Preview: preview}
+	if cached != nil {
+		params.ExistingID = cached.ID
+	}
+	if jid.Server == types.GroupServer {
+		if g, err := a.DB().GetGroup(jid.String()); err == nil {
+			params.IsCommunity = g.IsCommunity
+		}
+	}
+	info, err := a.WA().GetProfilePictureInfo(ctx, jid, params)
+	switch {
+	case errors.Is(err, whatsmeow.ErrProfilePictureNotSet):
+		removeProfilePhotos(dir, kind, "")
+		return nil, fmt.Errorf("profile picture %w", ErrNotFound)
+	case errors.Is(err, whatsmeow.ErrProfilePictureUnauthorized):
+		return nil, fmt.Errorf("%w: %w", ErrPhotoHidden, err)
+	case err != nil:
+		if cached != nil {
+			log.Printf("[Media] Serving cached profile picture of %s: %v", jid, err)
+			return cached, nil
+		}
+		return nil, err
+	case info == nil:
+		return cached, nil // unchanged
+	}
+
+	name := kind + "-" + pathutil.SanitizeSegment(info.ID) + ".jpg"
+	path := filepath.Join(dir, name)
+	if _, err := wa.DownloadProfilePicture(ctx, info.URL, path); err != nil {
+		return nil, err
+	}
+	removeProfilePhotos(dir, kind, name)
+	return &ProfilePhoto{JID: jid.String(), ID: info.ID, Path: path}, nil
+}
+
+// cachedProfilePhoto finds the cached picture of a kind, named
+// <kind>-<id>.jpg.
+func cachedProfilePhoto(dir, kind string) *ProfilePhoto {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !e.Type().IsRegular() || !strings.HasPrefix(name, kind+"-") || !strings.HasSuffix(name, ".jpg") {
+			continue
+		}
+		return &ProfilePhoto{
+			ID:   strings.TrimSuffix(strings.TrimPrefix(name, kind+"-"), ".jpg"),
+			Path: filepath.Join(dir, name),
+		}
+	}
+	return nil
+}
+
+// removeProfilePhotos deletes cached pictures of a kind other than keep.
+func removeProfilePhotos(dir, kind, keep string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if name != keep && strings.HasPrefix(name, kind+"-") && strings.HasSuffix(name, ".jpg") {
+			_ = os.Remove(filepath.Join(dir, name))
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// MessageStatus returns one of our sent messages with its receipts per
+// recipient.
+func (m *Manager) MessageStatus(chatJID, msgID string) (store.Message, []store.MessageReceipt, error) {
+	a := m.App()
+	if a == nil {
+		return store.Message{}, nil, errNotInitialized
+	}
+	chat, err := NormalizeChatJID(chatJID)
+	if err != nil {
+		return store.Message{}, nil, fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+	msg, err := a.DB().GetMessage(chat, msgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.Message{}, nil, fmt.Errorf("message %s %w in %s", msgID, ErrNotFound, chat)
+	}
+	if err != nil {
+		return store.Message{}, nil, err
+	}
+	if !msg.FromMe {
+		return store.Message{}, nil, fmt.Errorf("message %s was %w; receipts are only tracked for own messages", msgID, ErrNotOwnMessage)
+	}
+	receipts, err := a.DB().MessageReceipts(chat, msgID)
+	if err != nil {
+		return store.Message{}, nil, err
+	}
+	return msg, receipts, nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/steipete/wacli/internal/webhook"
+)
+
+// ErrRecentEventsDisabled is returned when WASVC_RECENT_EVENTS is 0.
+var ErrRecentEventsDisabled = errors.New("recent events are disabled")
+
+// RecentEvent is a service event kept in memory for polling clients.
+type RecentEvent struct {
+	ID        int64 // increases by one per event; restarts at 1 with the service
+	Type      string
+	Timestamp time.Time
+	Data      interface{}
+}
+
+// RecentEvents is a page of the recent events buffer.
+type RecentEvents struct {
+	Events  []RecentEvent
+	LastID  int64 // id to poll from next: the newest event returned, or the newest seen
+	HasMore bool  // newer events than the returned ones are buffered
+	// Missed is set when events after the requested id are gone, because
+	// they were pushed out of the buffer or the service restarted.
+	Missed bool
+}
+
+// eventRing keeps the last events emitted, oldest overwritten first.
+type eventRing struct {
+	mu     sync.Mutex
+	events []RecentEvent // circular once full
+	next   int           // slot the next event is written to
+	lastID int64
+}
+
+func newEventRing(size int) *eventRing {
+	if size <= 0 {
+		return nil
+	}
+	return &eventRing{events: make([]RecentEvent, 0, size)}
+}
+
+func (r *eventRing) add(eventType string, data interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastID++
+	ev := RecentEvent{ID: r.lastID, Type: eventType, Timestamp: time.Now().UTC(), Data: data}
+	if len(r.events) < cap(r.events) {
+		r.events = append(r.events, ev)
+		return
+	}
+	r.events[r.next] = ev
+	r.next = (r.next + 1) % len(r.events)
+}
+
+// since returns up to limit events newer than sinceID whose type matches
+// types, oldest first. Without sinceID it returns the newest ones.
+func (r *eventRing) since(sinceID int64, limit int, types []string) RecentEvents {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := RecentEvents{LastID: r.lastID}
+	if len(r.events) == 0 {
+		res.Missed = sinceID > 0
+		return res
+	}
+	oldest := r.events[r.next].ID // r.next is 0 until the buffer wraps
+	if sinceID > r.lastID {
+		// Ids from before a restart.
+		sinceID, res.Missed = 0, true
+	} else if sinceID > 0 && sinceID < oldest-1 {
+		res.Missed = true
+	}
+
+	var matched []RecentEvent
+	for i := range r.events {
+		ev := r.events[(r.next+i)%len(r.events)]
+		if ev.ID > sinceID && webhook.MatchEvent(types, ev.Type) {
+			matched = append(matched, ev)
+		}
+	}
+	switch {
+	case len(matched) <= limit:
+	case sinceID == 0:
+		matched = matched[len(matched)-limit:]
+	default:
+		matched, res.HasMore = matched[:limit], true
+		res.LastID = matched[limit-1].ID
+	}
+	res.Events = matched
+	return res
+}
+
+// RecentEvents returns up to limit buffered events newer than sinceID,
+// optionally only those matching the type patterns ("message.*"), oldest
+// first. Without sinceID the newest events are returned.
+func (m *Manager) RecentEvents(sinceID int64, limit int, types []string) (RecentEvents, error) {
+	if m.recent == nil {
+		return RecentEvents{}, ErrRecentEventsDisabled
+	}
+	return m.recent.since(sinceID, limit, types), nil
+}
+
+// recordEvent adds an event to the recent events buffer, if enabled.
+func (m *Manager) recordEvent(eventType string, data interface{}) {
+	if m.recent != nil {
+		m.recent.add(eventType, data)
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/steipete/wacli/internal/app"
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// partialDownloadMaxAge bounds how long an interrupted resumable download is
+// kept around waiting for a retry.
+const partialDownloadMaxAge = 7 * 24 * time.Hour
+
+// RecoveryReport summarizes the startup scan for work left behind by a
+// previous run.
+type RecoveryReport struct {
+	ScannedAt            time.Time
+	TempFilesRemoved     int   // scratch files of downloads that were cut off mid-decrypt
+	PartialDownloads     int   // resumable downloads kept for the next request
+	StalePartialsRemoved int   // resumable downloads abandoned for too long
+	PendingWebhooks      int64 // spooled webhook events the emitter will retry
+	OutboxQueued         int64 // outbox messages to send once connected
+	OutboxInterrupted    int64 // outbox messages cut off mid-send, marked failed
+}
+
+// recoverInterrupted scans for state left by an interrupted previous run and
+// either keeps it for resumption or discards it, logging what it did.
+func (m *Manager) recoverInterrupted(a *app.App) RecoveryReport {
+	r := RecoveryReport{ScannedAt: time.Now().UTC()}
+
+	mediaDir := filepath.Join(a.StoreDir(), "media")
+	err := filepath.WalkDir(mediaDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		switch name := d.Name(); {
+		case wa.IsTempDownload(name):
+			if err := os.Remove(path); err == nil {
+				r.TempFilesRemoved++
+			}
+		case wa.IsPartialDownload(name):
+			info, err := d.Info()
+			if err == nil && time.Since(info.ModTime()) > partialDownloadMaxAge {
+				if err := os.Remove(path); err == nil {
+					r.StalePartialsRemoved++
+				}
+				return nil
+			}
+			r.PartialDownloads++
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("[Recovery] Media scan failed: %v", err)
+	}
+
+	if n, err := a.DB().CountPendingWebhookEvents(); err != nil {
+		log.Printf("[Recovery] Failed to count spooled webhooks: %v", err)
+	} else {
+		r.PendingWebhooks = n
+	}
+
+	if n, err := a.DB().FailInterruptedOutbox(); err != nil {
+		log.Printf("[Recovery] Failed to check the outbox: %v", err)
+	} else {
+		r.OutboxInterrupted = n
+	}
+	if n, err := a.DB().CountOutbox(store.OutboxQueued); err != nil {
+		log.Printf("[Recovery] Failed to count queued outbox messages: %v", err)
+	} else {
+		r.OutboxQueued = n
+	}
+
+	log.Printf("[Recovery] Removed %d interrupted download temp files, kept %d resumable downloads (%d stale removed), %d spooled webhooks pending, %d outbox messages queued (%d interrupted)",
+		r.TempFilesRemoved, r.PartialDownloads, r.StalePartialsRemoved, r.PendingWebhooks, r.OutboxQueued, r.OutboxInterrupted)
+	return r
+}
+
+// Recovery returns the result of the startup recovery scan.
+func (m *Manager) Recovery() RecoveryReport {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.recovery
+}
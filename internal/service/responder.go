@@ -0,0 +1,156 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/steipete/wacli/internal/app"
+	"github.com/steipete/wacli/internal/responder"
+	"github.com/steipete/wacli/internal/store"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// ListChatResponders returns the per-chat auto-responder overrides.
+func (m *Manager) ListChatResponders() ([]store.ChatResponder, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	return a.DB().ListChatResponders()
+}
+
+// SetChatResponder turns the auto-responder on or off for a chat,
+// overriding WASVC_RESPONDER_ALL_CHATS.
+func (m *Manager) SetChatResponder(chatJID string, enabled bool) error {
+	a := m.App()
+	if a == nil {
+		return errNotInitialized
+	}
+	jid, err := NormalizeChatJID(chatJID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+	return a.DB().SetChatResponder(jid, enabled)
+}
+
+// ClearChatResponder removes a chat's override so the default applies.
+func (m *Manager) ClearChatResponder(chatJID string) error {
+	a := m.App()
+	if a == nil {
+		return errNotInitialized
+	}
+	jid, err := NormalizeChatJID(chatJID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+	if err := a.DB().DeleteChatResponder(jid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("responder override for %s %w", jid, ErrNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+// respond asks the completion endpoint for a reply to an inbound text
+// message and sends it, if the responder is on for the chat and the chat is
+// within its rate limit. Messages arriving while a reply for the same chat
+// is being generated are not answered separately.
+func (m *Manager) respond(msg *ReceivedMessage) {
+	a := m.App()
+	if a == nil || msg.Text == "" {
+		return
+	}
+	on, err := m.responderEnabled(a, msg.ChatJID)
+	if err != nil {
+		log.Printf("[Responder] Failed to read setting for %s: %v", msg.ChatJID, err)
+		return
+	}
+	if !on {
+		return
+	}
+
+	m.responderMu.Lock()
+	if m.responderBusy[msg.ChatJID] {
+		m.responderMu.Unlock()
+		return
+	}
+	m.responderBusy[msg.ChatJID] = true
+	m.responderMu.Unlock()
+	defer func() {
+		m.responderMu.Lock()
+		delete(m.responderBusy, msg.ChatJID)
+		m.responderMu.Unlock()
+	}()
+
+	if !m.responderLimit.Allow(msg.ChatJID, time.Now()) {
+		log.Printf("[Responder] Hourly limit reached for %s, not replying", msg.ChatJID)
+		return
+	}
+
+	reply, err := m.responder.Complete(m.ctx, m.responderContext(a, msg))
+	if err != nil {
+		log.Printf("[Responder] Completion for %s failed: %v", msg.ChatJID, err)
+		return
+	}
+	if reply == "" {
+		return
+	}
+	if _, err := m.SendText(m.ctx, msg.ChatJID, reply); err != nil {
+		log.Printf("[Responder] Reply to %s failed: %v", msg.ChatJID, err)
+	}
+}
+
+// responderEnabled reports whether the responder replies in a chat: its
+// override if it has one, otherwise WASVC_RESPONDER_ALL_CHATS for direct
+// chats. Groups need an override.
+func (m *Manager) responderEnabled(a *app.App, chatJID string) (bool, error) {
+	o, err := a.DB().GetChatResponder(chatJID)
+	if errors.Is(err, sql.ErrNoRows) {
+		jid, err := types.ParseJID(chatJID)
+		direct := err == nil && (jid.Server == types.DefaultUserServer || jid.Server == types.HiddenUserServer)
+		return m.config.ResponderAllChats && direct, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return o.Enabled, nil
+}
+
+// responderContext turns the latest messages of the chat, ending with msg,
+// into a conversation: the linked account is the assistant.
+func (m *Manager) responderContext(a *app.App, msg *ReceivedMessage) []responder.Turn {
+	history, err := a.DB().ListMessages(store.ListMessagesParams{
+		ChatJID: msg.ChatJID,
+		Limit:   m.config.ResponderContext + 1,
+	})
+	if err != nil {
+		log.Printf("[Responder] Failed to load context for %s: %v", msg.ChatJID, err)
+	}
+
+	var turns []responder.Turn
+	found := false
+	for i := len(history) - 1; i >= 0; i-- { // oldest first
+		h := history[i]
+		content := h.Text
+		if content == "" && h.MediaType != "" {
+			content = "[" + h.MediaType + "]"
+		}
+		if content == "" {
+			continue
+		}
+		role := responder.RoleUser
+		if h.FromMe {
+			role = responder.RoleAssistant
+		}
+		turns = append(turns, responder.Turn{Role: role, Content: content})
+		found = found || h.MsgID == msg.MsgID
+	}
+	if !found {
+		turns = append(turns, responder.Turn{Role: responder.RoleUser, Content: msg.Text})
+	}
+	return turns
+}
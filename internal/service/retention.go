@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// RetentionReport describes the outcome of a pruning run.
+type RetentionReport struct {
+	DryRun     bool
+	Messages   int
+	ByAge      int
+	BySize     int
+	Chats      map[string]int
+	MediaFiles int
+	MediaBytes int64
+	DBBytes    int64
+}
+
+// PruneMessages applies the retention policy once. With dryRun set it only
+// reports what would be removed.
+func (m *Manager) PruneMessages(dryRun bool) (RetentionReport, error) {
+	a := m.App()
+	if a == nil {
+		return RetentionReport{}, errNotInitialized
+	}
+
+	m.retentionMu.Lock()
+	defer m.retentionMu.Unlock()
+
+	res, err := a.DB().PruneMessages(store.RetentionPolicy{
+		MaxAge:     m.config.RetentionMaxAge,
+		MaxDBBytes: m.config.RetentionMaxDBBytes,
+		Now:        time.Now(),
+	}, dryRun)
+	if err != nil {
+		return RetentionReport{}, err
+	}
+
+	report := RetentionReport{
+		DryRun:   dryRun,
+		Messages: res.Messages,
+		ByAge:    res.ByAge,
+		BySize:   res.BySize,
+		Chats:    res.Chats,
+		DBBytes:  res.DBBytes,
+	}
+
+	report.MediaFiles, report.MediaBytes = m.removeMedia(res.MediaPaths, dryRun)
+	return report, nil
+}
+
+// removeMedia deletes downloaded media files and returns how many there were
+// and their total size. With dryRun set the files are only measured.
+func (m *Manager) removeMedia(paths []string, dryRun bool) (int, int64) {
+	var files int
+	var size int64
+	// Only touch files inside the media directory, whatever the database says.
+	mediaDir := filepath.Join(m.config.DataDir, "media") + string(filepath.Separator)
+	for _, path := range paths {
+		if !strings.HasPrefix(filepath.Clean(path), mediaDir) {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !dryRun {
+			if err := os.Remove(path); err != nil {
+				log.Printf("[Media] Failed to remove %s: %v", path, err)
+				continue
+			}
+		}
+		files++
+		size += info.Size()
+	}
+	return files, size
+}
+
+// ListChatRetention returns the per-chat retention overrides.
+func (m *Manager) ListChatRetention() ([]store.ChatRetention, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	return a.DB().ListChatRetention()
+}
+
+// SetChatRetention overrides the retention of a chat; a zero maxAge keeps
+// its messages forever, also exempting them from the size limit.
+func (m *Manager) SetChatRetention(chatJID string, maxAge time.Duration) error {
+	a := m.App()
+	if a == nil {
+		return errNotInitialized
+	}
+	if maxAge < 0 {
+		return fmt.Errorf("max age must not be negative")
+	}
+	jid, err := NormalizeChatJID(chatJID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+	return a.DB().SetChatRetention(jid, maxAge)
+}
+
+// ClearChatRetention removes a chat's override so the global policy applies.
+func (m *Manager) ClearChatRetention(chatJID string) error {
+	a := m.App()
+	if a == nil {
+		return errNotInitialized
+	}
+	jid, err := NormalizeChatJID(chatJID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+	if err := a.DB().DeleteChatRetention(jid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("retention override for %s %w", jid, ErrNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+// runRetentionJanitor prunes messages every RetentionInterval until ctx is
+// cancelled. Per-chat overrides apply even without a global policy.
+func (m *Manager) runRetentionJanitor(ctx context.Context) {
+	ticker := time.NewTicker(m.config.RetentionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := m.PruneMessages(false)
+			if err != nil {
+				log.Printf("[Retention] Pruning failed: %v", err)
+				continue
+			}
+			if report.Messages > 0 {
+				log.Printf("[Retention] Removed %d messages (%d by age, %d by size) in %d chats and %d media files",
+					report.Messages, report.ByAge, report.BySize, len(report.Chats), report.MediaFiles)
+			}
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/steipete/wacli/internal/rules"
+	"github.com/steipete/wacli/internal/store"
+)
+
+// RuleParams describes a rule to create or replace.
+type RuleParams struct {
+	Name       string
+	Enabled    bool
+	Priority   int
+	Conditions rules.Conditions
+	Actions    []rules.Action
+}
+
+// ListRules returns all rules in evaluation order, enabled or not.
+func (m *Manager) ListRules() ([]store.Rule, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	return a.DB().ListRules()
+}
+
+// GetRule returns a rule by id.
+func (m *Manager) GetRule(id int64) (store.Rule, error) {
+	a := m.App()
+	if a == nil {
+		return store.Rule{}, errNotInitialized
+	}
+	r, err := a.DB().GetRule(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.Rule{}, fmt.Errorf("rule %d %w", id, ErrNotFound)
+	}
+	return r, err
+}
+
+// CreateRule validates and stores a new rule. It applies from the next
+// inbound message on.
+func (m *Manager) CreateRule(p RuleParams) (store.Rule, error) {
+	a := m.App()
+	if a == nil {
+		return store.Rule{}, errNotInitialized
+	}
+	r, err := m.ruleFromParams(p)
+	if err != nil {
+		return store.Rule{}, err
+	}
+	r, err = a.DB().CreateRule(r)
+	if err != nil {
+		return store.Rule{}, err
+	}
+	m.reloadRules(a.DB())
+	return r, nil
+}
+
+// UpdateRule validates and replaces a rule.
+func (m *Manager) UpdateRule(id int64, p RuleParams) (store.Rule, error) {
+	a := m.App()
+	if a == nil {
+		return store.Rule{}, errNotInitialized
+	}
+	r, err := m.ruleFromParams(p)
+	if err != nil {
+		return store.Rule{}, err
+	}
+	r.ID = id
+	r, err = a.DB().UpdateRule(r)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.Rule{}, fmt.Errorf("rule %d %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return store.Rule{}, err
+	}
+	m.reloadRules(a.DB())
+	return r, nil
+}
+
+// DeleteRule removes a rule.
+func (m *Manager) DeleteRule(id int64) error {
+	a := m.App()
+	if a == nil {
+		return errNotInitialized
+	}
+	err := a.DB().DeleteRule(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("rule %d %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	m.reloadRules(a.DB())
+	return nil
+}
+
+// ruleFromParams normalizes and validates p. Errors wrap ErrInvalidRule.
+func (m *Manager) ruleFromParams(p RuleParams) (store.Rule, error) {
+	name := strings.TrimSpace(p.Name)
+	if name == "" {
+		return store.Rule{}, fmt.Errorf("%w: name is required", ErrInvalidRule)
+	}
+	cond := p.Conditions
+	for _, list := range []*[]string{&cond.Chats, &cond.Senders} {
+		for i, s := range *list {
+			jid, err := NormalizeChatJID(s)
+			if err != nil {
+				return store.Rule{}, fmt.Errorf("%w: JID %q: %v", ErrInvalidRule, s, err)
+			}
+			(*list)[i] = jid
+		}
+	}
+	for _, act := range p.Actions {
+		if act.Type == rules.ActionWebhook && act.URL != "" && !m.config.hasWebhook(act.URL) {
+			return store.Rule{}, fmt.Errorf("%w: webhook %s is not configured", ErrInvalidRule, act.URL)
+		}
+	}
+
+	conditions, err := json.Marshal(cond)
+	if err != nil {
+		return store.Rule{}, err
+	}
+	actions, err := json.Marshal(p.Actions)
+	if err != nil {
+		return store.Rule{}, err
+	}
+	if _, err := rules.Parse(0, name, string(conditions), string(actions)); err != nil {
+		return store.Rule{}, fmt.Errorf("%w: %w", ErrInvalidRule, err)
+	}
+	return store.Rule{
+		Name:       name,
+		Enabled:    p.Enabled,
+		Priority:   p.Priority,
+		Conditions: string(conditions),
+		Actions:    string(actions),
+	}, nil
+}
+
+// reloadRules replaces the active rules with the enabled stored ones.
+// Rules that no longer parse are logged and skipped.
+func (m *Manager) reloadRules(db store.Store) {
+	stored, err := db.ListRules()
+	if err != nil {
+		log.Printf("[Rules] Failed to load rules: %v", err)
+		return
+	}
+	var active []rules.Rule
+	for _, s := range stored {
+		if !s.Enabled {
+			continue
+		}
+		r, err := rules.Parse(s.ID, s.Name, s.Conditions, s.Actions)
+		if err != nil {
+			log.Printf("[Rules] Skipping rule %d (%s): %v", s.ID, s.Name, err)
+			continue
+		}
+		active = append(active, r)
+	}
+	m.rules.Load(active)
+}
+
+// applyRules evaluates the rules against an inbound message, routes it to
+// the webhooks they name, and replies and tags in the background. It
+// reports whether the message is to be ignored.
+func (m *Manager) applyRules(msg *ReceivedMessage) bool {
+	res := m.rules.Evaluate(rules.Message{
+		ChatJID:    msg.ChatJID,
+		ChatName:   msg.ChatName,
+		SenderJID:  msg.SenderJID,
+		SenderName: msg.SenderName,
+		Text:       msg.Text,
+		Caption:    msg.Caption,
+		MediaType:  msg.MediaType,
+	})
+	if len(res.Matched) == 0 {
+		return false
+	}
+	if len(res.Replies) > 0 || len(res.Tags) > 0 {
+		go func() {
+			for _, text := range res.Replies {
+				if _, err := m.SendText(m.ctx, msg.ChatJID, text); err != nil {
+					log.Printf("[Rules] Reply to %s failed: %v", msg.ChatJID, err)
+				}
+			}
+			for _, tag := range res.Tags {
+				if err := m.tagSender(msg, tag); err != nil {
+					log.Printf("[Rules] Tag %q failed: %v", tag, err)
+				}
+			}
+		}()
+	}
+	msg.Webhooks = res.Webhooks
+	return res.Ignore
+}
+
+// tagSender adds tag to the contact that sent msg, or to the chat if the
+// sender is unknown.
+func (m *Manager) tagSender(msg *ReceivedMessage, tag string) error {
+	a := m.App()
+	if a == nil {
+		return errNotInitialized
+	}
+	sender := msg.SenderJID
+	if sender == "" {
+		sender = msg.ChatJID
+	}
+	return a.DB().AddTag(sender, tag)
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+
+	"github.com/steipete/wacli/internal/script"
+	"github.com/steipete/wacli/internal/store"
+)
+
+// ErrInvalidScriptName is returned for names outside [A-Za-z0-9_-]{1,64}.
+var ErrInvalidScriptName = errors.New("invalid script name")
+
+// ErrInvalidScript is returned for a script that does not compile.
+var ErrInvalidScript = errors.New("invalid script")
+
+var scriptNameRE = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ScriptInfo is a stored script and, if it is loaded, how it has fared.
+type ScriptInfo struct {
+	store.Script
+	Loaded bool
+	Stats  script.Stats
+}
+
+// ListScripts returns all stored scripts, enabled or not.
+func (m *Manager) ListScripts() ([]ScriptInfo, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	scripts, err := a.DB().ListScripts()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ScriptInfo, len(scripts))
+	for i, s := range scripts {
+		out[i] = m.scriptInfo(s)
+	}
+	return out, nil
+}
+
+// GetScript returns a stored script.
+func (m *Manager) GetScript(name string) (ScriptInfo, error) {
+	a := m.App()
+	if a == nil {
+		return ScriptInfo{}, errNotInitialized
+	}
+	s, err := a.DB().GetScript(name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ScriptInfo{}, fmt.Errorf("script %q %w", name, ErrNotFound)
+	}
+	if err != nil {
+		return ScriptInfo{}, err
+	}
+	return m.scriptInfo(s), nil
+}
+
+// PutScript creates or replaces a script and reloads the engine, so the
+// change applies to the next inbound message. The script is checked first;
+// one that does not compile or lacks onMessage is rejected.
+func (m *Manager) PutScript(name, source string, enabled bool) (ScriptInfo, error) {
+	a := m.App()
+	if a == nil {
+		return ScriptInfo{}, errNotInitialized
+	}
+	if !scriptNameRE.MatchString(name) {
+		return ScriptInfo{}, ErrInvalidScriptName
+	}
+	if err := script.Check(name, source, m.config.ScriptTimeout); err != nil {
+		return ScriptInfo{}, fmt.Errorf("%w: %w", ErrInvalidScript, err)
+	}
+	if err := a.DB().PutScript(name, source, enabled); err != nil {
+		return ScriptInfo{}, err
+	}
+	if err := m.loadScripts(a.DB()); err != nil {
+		log.Printf("[Scripts] %v", err)
+	}
+	s, err := a.DB().GetScript(name)
+	if err != nil {
+		return ScriptInfo{}, err
+	}
+	return m.scriptInfo(s), nil
+}
+
+// DeleteScript removes a script and unloads it.
+func (m *Manager) DeleteScript(name string) error {
+	a := m.App()
+	if a == nil {
+		return errNotInitialized
+	}
+	err := a.DB().DeleteScript(name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("script %q %w", name, ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	if err := m.loadScripts(a.DB()); err != nil {
+		log.Printf("[Scripts] %v", err)
+	}
+	return nil
+}
+
+// loadScripts replaces the loaded scripts with the enabled stored ones.
+// Scripts that fail to load are skipped and reported in the error.
+func (m *Manager) loadScripts(db store.Store) error {
+	scripts, err := db.ListScripts()
+	if err != nil {
+		return fmt.Errorf("list scripts: %w", err)
+	}
+	var sources []script.Source
+	for _, s := range scripts {
+		if s.Enabled {
+			sources = append(sources, script.Source{Name: s.Name, Code: s.Source})
+		}
+	}
+	return m.scripts.Load(sources)
+}
+
+func (m *Manager) scriptInfo(s store.Script) ScriptInfo {
+	info := ScriptInfo{Script: s}
+	info.Stats, info.Loaded = m.scripts.Stats(s.Name)
+	return info
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	"github.com/steipete/wacli/internal/app"
+	"github.com/steipete/wacli/internal/spam"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// spamTag is the contact tag applied to senders of messages above the spam threshold.
+const spamTag = "spam"
+
+// duplicateWindow bounds how far back identical inbound texts are counted.
+const duplicateWindow = 24 * time.Hour
+
+// scoreSpam computes and stores the spam score of an inbound message and applies
+// the configured actions. It reports whether the message is above the threshold.
+func (m *Manager) scoreSpam(a *app.App, pm wa.ParsedMessage) (float64, bool) {
+	if !m.config.SpamEnabled || pm.FromMe {
+		return 0, false
+	}
+
+	sender := pm.SenderJID
+	if sender == "" {
+		sender = pm.Chat.String()
+	}
+	known, err := a.DB().IsKnownSender(sender)
+	if err != nil {
+		known = true // fail open rather than flagging on DB errors
+	}
+	dups, _ := a.DB().CountDuplicateTextChats(pm.Text, pm.Chat.String(), time.Now().Add(-duplicateWindow))
+
+	res := spam.Score(spam.Signals{
+		Text:           pm.Text,
+		KnownSender:    known,
+		DuplicateChats: dups,
+	})
+	if err := a.DB().SetMessageSpamScore(pm.Chat.String(), pm.ID, res.Score); err != nil {
+		log.Printf("[Manager] Failed to store spam score of %s: %v", pm.ID, err)
+	}
+
+	if res.Score < m.config.SpamThreshold {
+		return res.Score, false
+	}
+
+	log.Printf("[Manager] Spam suspected: chat=%s from=%s id=%s score=%.2f reasons=%v", pm.Chat.String(), sender, pm.ID, res.Score, res.Reasons)
+	if m.config.HasSpamAction("tag") {
+		if err := a.DB().AddTag(sender, spamTag); err != nil {
+			log.Printf("[Manager] Failed to tag %s as spam: %v", sender, err)
+		}
+	}
+	if m.config.HasSpamAction("archive") {
+		if err := a.DB().SetChatArchived(pm.Chat.String(), true); err != nil {
+			log.Printf("[Manager] Failed to archive spam chat %s: %v", pm.Chat.String(), err)
+		}
+	}
+	return res.Score, true
+}
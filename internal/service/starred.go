@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// StarMessage stars or unstars a stored message. The change is pushed to the
+// phone as an app-state patch first and only recorded locally once accepted.
+func (m *Manager) StarMessage(ctx context.Context, chatJID, msgID string, starred bool) error {
+	a := m.App()
+	if a == nil {
+		return errNotInitialized
+	}
+	chat, err := NormalizeChatJID(chatJID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+	msg, err := a.DB().GetMessage(chat, msgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("message %s %w in %s", msgID, ErrNotFound, chat)
+	}
+	if err != nil {
+		return err
+	}
+
+	target, err := types.ParseJID(chat)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+	// Outside groups, and for our own messages, the patch carries no sender.
+	sender := target
+	if target.Server == types.GroupServer && !msg.FromMe {
+		if sender, err = types.ParseJID(msg.SenderJID); err != nil {
+			return fmt.Errorf("invalid sender JID %q: %w", msg.SenderJID, err)
+		}
+	}
+
+	if err := a.WA().SendAppState(ctx, appstate.BuildStar(target, sender, msgID, msg.FromMe, starred)); err != nil {
+		return fmt.Errorf("sync star: %w", err)
+	}
+	return a.DB().SetMessageStarred(chat, msgID, starred, time.Now().UTC())
+}
+
+// ListStarredMessages returns starred messages, newest first.
+func (m *Manager) ListStarredMessages(p store.ListStarredParams) ([]store.Message, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	if p.ChatJID != "" {
+		chat, err := NormalizeChatJID(p.ChatJID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidJID, err)
+		}
+		p.ChatJID = chat
+	}
+	return a.DB().ListStarredMessages(p)
+}
+
+// handleStar records a star or unstar made on the phone or another device.
+func (m *Manager) handleStar(evt *events.Star) {
+	a := m.App()
+	if a == nil || evt.Action == nil {
+		return
+	}
+	if err := a.DB().SetMessageStarred(evt.ChatJID.String(), evt.MessageID, evt.Action.GetStarred(), evt.Timestamp); err != nil {
+		log.Printf("[Manager] Failed to store star for %s/%s: %v", evt.ChatJID, evt.MessageID, err)
+	}
+}
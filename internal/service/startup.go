@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Startup steps, in the order they run.
+const (
+	StartupStepContacts = "contacts"
+	StartupStepGroups   = "groups"
+	StartupStepMedia    = "media"
+)
+
+// Startup step states.
+const (
+	StartupPending = "pending"
+	StartupRunning = "running"
+	StartupDone    = "done"
+	StartupSkipped = "skipped" // turned off in the config
+	StartupFailed  = "failed"
+)
+
+// startupMediaWindow is how far back the media step looks for media the
+// auto-downloader never fetched, e.g. because the service stopped with
+// downloads still queued.
+const startupMediaWindow = 7 * 24 * time.Hour
+
+// StartupStep reports one step of the startup pipeline.
+type StartupStep struct {
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	Count      int        `json:"count"` // contacts or groups stored, media files queued
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// StartupProgress reports the pipeline run once the sync worker is
+// connected: refresh contacts, refresh groups, then queue media downloads
+// missed while offline. Steps turned off in the config are skipped.
+type StartupProgress struct {
+	Ready      bool // every step has finished, failed or been skipped
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Steps      []StartupStep
+}
+
+// SyncReadyEvent is the payload of sync.ready, emitted when the startup
+// pipeline has finished.
+type SyncReadyEvent struct {
+	Steps     []StartupStep `json:"steps"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// startupState tracks the current run of the startup pipeline.
+type startupState struct {
+	mu       sync.Mutex
+	progress StartupProgress
+}
+
+func pendingStartupSteps() []StartupStep {
+	return []StartupStep{
+		{Name: StartupStepContacts, Status: StartupPending},
+		{Name: StartupStepGroups, Status: StartupPending},
+		{Name: StartupStepMedia, Status: StartupPending},
+	}
+}
+
+// reset marks every step pending; called when the sync worker starts.
+func (s *startupState) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progress = StartupProgress{Steps: pendingStartupSteps()}
+}
+
+// begin claims the run; it reports false if the pipeline already started
+// since the last reset.
+func (s *startupState) begin(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.progress.StartedAt.IsZero() || len(s.progress.Steps) == 0 {
+		return false
+	}
+	s.progress.StartedAt = now
+	return true
+}
+
+func (s *startupState) update(i int, fn func(*StartupStep)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.progress.Steps[i])
+}
+
+// finish marks the run ready and returns its steps.
+func (s *startupState) finish(now time.Time) []StartupStep {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progress.Ready = true
+	s.progress.FinishedAt = now
+	return append([]StartupStep(nil), s.progress.Steps...)
+}
+
+func (s *startupState) snapshot() StartupProgress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.progress
+	if len(p.Steps) == 0 {
+		p.Steps = pendingStartupSteps()
+	} else {
+		p.Steps = append([]StartupStep(nil), p.Steps...)
+	}
+	return p
+}
+
+// runStartup runs the startup pipeline once per sync worker start, on the
+// first connection. Failed steps are logged and reported but do not stop
+// the ones after them.
+func (m *Manager) runStartup(ctx context.Context) {
+	if !m.startup.begin(time.Now().UTC()) {
+		return
+	}
+	log.Println("[Startup] Running startup sync")
+
+	steps := []struct {
+		enabled bool
+		run     func(context.Context) (int, error)
+	}{
+		{m.config.RefreshContacts, m.RefreshContacts},
+		{m.config.RefreshGroups, m.RefreshGroups},
+		{m.config.DownloadMedia, m.queuePendingMedia},
+	}
+	for i, step := range steps {
+		if ctx.Err() != nil {
+			return
+		}
+		if !step.enabled {
+			m.startup.update(i, func(s *StartupStep) { s.Status = StartupSkipped })
+			continue
+		}
+		started := time.Now().UTC()
+		m.startup.update(i, func(s *StartupStep) {
+			s.Status, s.StartedAt = StartupRunning, &started
+		})
+		n, err := step.run(ctx)
+		finished := time.Now().UTC()
+		m.startup.update(i, func(s *StartupStep) {
+			s.Status, s.Count, s.FinishedAt = StartupDone, n, &finished
+			if err != nil {
+				s.Status, s.Error = StartupFailed, err.Error()
+				log.Printf("[Startup] Step %s failed: %v", s.Name, err)
+			}
+		})
+	}
+
+	now := time.Now().UTC()
+	done := m.startup.finish(now)
+	log.Println("[Startup] Startup sync complete")
+	m.emitEvent(EventSyncReady, &SyncReadyEvent{Steps: done, Timestamp: now})
+}
+
+// queuePendingMedia hands media received within startupMediaWindow but
+// never downloaded to the auto-downloader, applying the same filters as
+// live messages.
+func (m *Manager) queuePendingMedia(ctx context.Context) (int, error) {
+	a := m.App()
+	if a == nil || m.autoDownloads == nil {
+		return 0, errNotInitialized
+	}
+	pending, err := a.DB().ListPendingMedia(time.Now().Add(-startupMediaWindow), autoDownloadQueueSize)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, p := range pending {
+		if m.config.SpamEnabled && p.SpamScore >= m.config.SpamThreshold {
+			continue
+		}
+		if !m.config.AutoDownloads(p.MediaType, p.FileLength) {
+			continue
+		}
+		select {
+		case m.autoDownloads <- autoDownload{chatJID: p.ChatJID, msgID: p.MsgID}:
+			n++
+		case <-ctx.Done():
+			return n, ctx.Err()
+		}
+	}
+	return n, nil
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"log"
 	"sync"
+	"time"
 )
 
 // State represents the connection state of the WhatsApp service.
@@ -31,8 +32,10 @@ func (s State) IsReady() bool {
 type StateMachine struct {
 	mu        sync.RWMutex
 	state     State
+	since     time.Time // when state was entered
 	lastError error
 	qrCode    string
+	pairCode  string
 	listeners []func(old, new State)
 }
 
@@ -40,6 +43,7 @@ type StateMachine struct {
 func NewStateMachine() *StateMachine {
 	return &StateMachine{
 		state: StateUnauthenticated,
+		since: time.Now(),
 	}
 }
 
@@ -50,13 +54,29 @@ func (sm *StateMachine) State() State {
 	return sm.state
 }
 
+// Since returns when the current state was entered.
+func (sm *StateMachine) Since() time.Time {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.since
+}
+
+// enter switches to state, resetting since if it changes. Callers hold mu.
+func (sm *StateMachine) enter(state State) {
+	if sm.state != state {
+		sm.since = time.Now()
+	}
+	sm.state = state
+}
+
 // SetState transitions to a new state.
 func (sm *StateMachine) SetState(newState State) {
 	sm.mu.Lock()
 	oldState := sm.state
-	sm.state = newState
+	sm.enter(newState)
 	if newState != StatePairing {
 		sm.qrCode = ""
+		sm.pairCode = ""
 	}
 	if newState != StateError {
 		sm.lastError = nil
@@ -76,9 +96,10 @@ func (sm *StateMachine) SetState(newState State) {
 func (sm *StateMachine) SetError(err error) {
 	sm.mu.Lock()
 	oldState := sm.state
-	sm.state = StateError
+	sm.enter(StateError)
 	sm.lastError = err
 	sm.qrCode = ""
+	sm.pairCode = ""
 	listeners := sm.listeners
 	sm.mu.Unlock()
 
@@ -101,7 +122,7 @@ func (sm *StateMachine) SetQRCode(code string) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 	sm.qrCode = code
-	sm.state = StatePairing
+	sm.enter(StatePairing)
 	log.Printf("[State] QR code set (length: %d), state -> pairing", len(code))
 }
 
@@ -115,6 +136,25 @@ func (sm *StateMachine) QRCode() string {
 	return ""
 }
 
+// SetPairCode stores the code for linking by phone number.
+func (sm *StateMachine) SetPairCode(code string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.pairCode = code
+	sm.enter(StatePairing)
+	log.Printf("[State] Pairing code issued, state -> pairing")
+}
+
+// PairCode returns the current pairing code if in pairing state.
+func (sm *StateMachine) PairCode() string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	if sm.state == StatePairing {
+		return sm.pairCode
+	}
+	return ""
+}
+
 // ClearQRCode clears the stored QR code.
 func (sm *StateMachine) ClearQRCode() {
 	sm.mu.Lock()
@@ -134,9 +174,12 @@ func (sm *StateMachine) StatusInfo() StatusInfo {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
 	info := StatusInfo{
-		State:   sm.state,
-		Ready:   sm.state.IsReady(),
-		HasQR:   sm.qrCode != "",
+		State: sm.state,
+		Ready: sm.state.IsReady(),
+		HasQR: sm.qrCode != "",
+	}
+	if sm.state == StatePairing {
+		info.PairCode = sm.pairCode
 	}
 	if sm.lastError != nil {
 		info.Error = sm.lastError.Error()
@@ -146,8 +189,9 @@ func (sm *StateMachine) StatusInfo() StatusInfo {
 
 // StatusInfo holds status information for API responses.
 type StatusInfo struct {
-	State State  `json:"state"`
-	Ready bool   `json:"ready"`
-	HasQR bool   `json:"has_qr"`
-	Error string `json:"error,omitempty"`
+	State    State  `json:"state"`
+	Ready    bool   `json:"ready"`
+	HasQR    bool   `json:"has_qr"`
+	PairCode string `json:"pair_code,omitempty"`
+	Error    string `json:"error,omitempty"`
 }
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// SyncProgress reports what the sync worker has done since it last started.
+type SyncProgress struct {
+	Running        bool
+	State          string
+	StartedAt      time.Time // zero if the worker never ran
+	MessagesSynced int64     // live and history-sync messages stored
+	HistoryChunks  int64     // history-sync events processed
+	LastEventAt    time.Time // last WhatsApp event seen; zero if none yet
+	Startup        StartupProgress
+}
+
+// syncCounters are updated from the event handler without taking m.mu.
+type syncCounters struct {
+	startedAt     atomic.Int64 // unix nanoseconds
+	messages      atomic.Int64
+	historyChunks atomic.Int64
+	lastEventAt   atomic.Int64 // unix nanoseconds
+}
+
+func (c *syncCounters) reset(now time.Time) {
+	c.startedAt.Store(now.UnixNano())
+	c.messages.Store(0)
+	c.historyChunks.Store(0)
+	c.lastEventAt.Store(0)
+}
+
+func (c *syncCounters) touch() {
+	c.lastEventAt.Store(time.Now().UnixNano())
+}
+
+func unixNanoTime(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n).UTC()
+}
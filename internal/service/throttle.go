@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrSendThrottled is matched (via errors.Is) by sends refused by the send
+// throttle.
+var ErrSendThrottled = errors.New("send throttled")
+
+// SendThrottledError describes a send refused by the WASVC_SEND_* limits.
+type SendThrottledError struct {
+	ChatJID    string
+	RetryAfter time.Duration
+}
+
+func (e *SendThrottledError) Error() string {
+	return fmt.Sprintf("send to %s throttled, retry in %s", e.ChatJID, e.RetryAfter.Round(time.Millisecond))
+}
+
+func (e *SendThrottledError) Is(target error) bool { return target == ErrSendThrottled }
+
+// throttleSend takes a slot for a send to chatJID from the service-wide
+// send throttle, or returns a *SendThrottledError saying when to retry.
+func (m *Manager) throttleSend(chatJID string) error {
+	if wait := m.sendThrottle.Reserve(chatJID, time.Now()); wait > 0 {
+		log.Printf("[Send] Throttled send to %s, retry in %s", chatJID, wait.Round(time.Millisecond))
+		return &SendThrottledError{ChatJID: chatJID, RetryAfter: wait}
+	}
+	return nil
+}
+
+// waitThrottled sleeps until a throttled send may be retried. It returns
+// false if ctx ends first.
+func waitThrottled(ctx context.Context, err error) bool {
+	var te *SendThrottledError
+	if !errors.As(err, &te) {
+		return false
+	}
+	t := time.NewTimer(te.RetryAfter)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
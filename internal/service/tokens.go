@@ -0,0 +1,125 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// chatTokenPrefix marks chat-scoped tokens so they are recognizable in logs
+// and config files.
+const chatTokenPrefix = "wct_"
+
+// ErrInvalidChatToken is returned by ResolveChatToken for unknown or expired
+// tokens.
+var ErrInvalidChatToken = errors.New("invalid chat token")
+
+// NormalizeChatJID parses a phone number or JID into its canonical string
+// form, so chat-scoped access checks compare like with like. Input with a
+// slash is rejected; no JID has one, and it could come from a decoded path.
+func NormalizeChatJID(s string) (string, error) {
+	if strings.Contains(s, "/") {
+		return "", fmt.Errorf("%w %q: contains a slash", ErrInvalidJID, s)
+	}
+	jid, err := wa.ParseUserOrJID(strings.TrimSpace(s))
+	if err != nil {
+		return "", err
+	}
+	return jid.String(), nil
+}
+
+// MintChatToken creates a token that may only send to and read from chatJID.
+// The plaintext token is returned once; only its hash is stored. A zero ttl
+// means the token does not expire.
+func (m *Manager) MintChatToken(chatJID, label string, ttl time.Duration) (string, store.ChatToken, error) {
+	a := m.App()
+	if a == nil {
+		return "", store.ChatToken{}, errNotInitialized
+	}
+	jid, err := NormalizeChatJID(chatJID)
+	if err != nil {
+		return "", store.ChatToken{}, fmt.Errorf("%w: %w", ErrInvalidJID, err)
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", store.ChatToken{}, err
+	}
+	token := chatTokenPrefix + hex.EncodeToString(buf)
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().UTC().Add(ttl)
+	}
+	info, err := a.DB().CreateChatToken(hashToken(token), jid, label, expiresAt)
+	if err != nil {
+		return "", store.ChatToken{}, err
+	}
+	return token, info, nil
+}
+
+// ResolveChatToken returns the token record for a plaintext chat token.
+func (m *Manager) ResolveChatToken(token string) (store.ChatToken, error) {
+	if !strings.HasPrefix(token, chatTokenPrefix) {
+		return store.ChatToken{}, ErrInvalidChatToken
+	}
+	a := m.App()
+	if a == nil {
+		return store.ChatToken{}, errNotInitialized
+	}
+	info, err := a.DB().ChatTokenByHash(hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.ChatToken{}, ErrInvalidChatToken
+	}
+	if err != nil {
+		return store.ChatToken{}, err
+	}
+	if info.Expired(time.Now()) {
+		return store.ChatToken{}, ErrInvalidChatToken
+	}
+	return info, nil
+}
+
+// ListChatTokens returns chat-scoped tokens, optionally for a single chat.
+func (m *Manager) ListChatTokens(chatJID string) ([]store.ChatToken, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	if chatJID != "" {
+		jid, err := NormalizeChatJID(chatJID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidJID, err)
+		}
+		chatJID = jid
+	}
+	return a.DB().ListChatTokens(chatJID)
+}
+
+// RevokeChatToken deletes a chat-scoped token.
+func (m *Manager) RevokeChatToken(id int64) error {
+	a := m.App()
+	if a == nil {
+		return errNotInitialized
+	}
+	err := a.DB().DeleteChatToken(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("token %d %w", id, ErrNotFound)
+	}
+	return err
+}
+
+// hashToken returns the hex SHA-256 hash under which chat tokens and API
+// keys are stored.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
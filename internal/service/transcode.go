@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// transcodeVideo re-encodes an outbound video so it plays inline on every
+// phone. It returns the MP4 to send instead and a cleanup func that removes
+// the temporary files.
+func (m *Manager) transcodeVideo(ctx context.Context, file mediaFile) (mediaFile, func(), error) {
+	dir, err := os.MkdirTemp("", "wasvc-transcode-*")
+	if err != nil {
+		return mediaFile{}, nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+
+	src := file.path
+	if file.data != nil {
+		src = filepath.Join(dir, "source")
+		if err := os.WriteFile(src, file.data, 0o600); err != nil {
+			cleanup()
+			return mediaFile{}, nil, err
+		}
+	}
+	dst := filepath.Join(dir, "video.mp4")
+	err = wa.TranscodeVideo(ctx, src, dst, wa.TranscodeOptions{
+		MaxSide:   m.config.TranscodeMaxSide,
+		VideoKbps: m.config.TranscodeVideoKbps,
+		AudioKbps: m.config.TranscodeAudioKbps,
+	})
+	if err != nil {
+		cleanup()
+		return mediaFile{}, nil, err
+	}
+	return mediaFile{path: dst}, cleanup, nil
+}
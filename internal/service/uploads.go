@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// maxUploadSize is WhatsApp's limit for documents, the largest media.
+	maxUploadSize = 2 << 30
+	// uploadTTL is how long an upload session is kept after it was created.
+	uploadTTL = 24 * time.Hour
+)
+
+// UploadSession is a file being uploaded in chunks so it can be sent by ID.
+// Its bytes are kept in <store>/uploads/<id>.data and its metadata next to
+// them in <id>.json.
+type UploadSession struct {
+	ID        string    `json:"id"`
+	Filename  string    `json:"filename"`
+	MimeType  string    `json:"mime_type"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Received  int64     `json:"-"` // size of the data file
+}
+
+// Complete reports whether every byte has been received.
+func (u *UploadSession) Complete() bool { return u.Received == u.Size }
+
+func (m *Manager) uploadsDir() (string, error) {
+	a := m.App()
+	if a == nil {
+		return "", errNotInitialized
+	}
+	return filepath.Join(a.StoreDir(), "uploads"), nil
+}
+
+// CreateUpload starts an upload session for a file of size bytes.
+func (m *Manager) CreateUpload(filename, mimeType string, size int64) (*UploadSession, error) {
+	if size <= 0 || size > maxUploadSize {
+		return nil, fmt.Errorf("%w: must be between 1 byte and 2 GB", ErrInvalidUploadSize)
+	}
+	if err := m.config.checkSendSize(size); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(mimeType) != "" {
+		if err := m.config.checkSendType(mimeType); err != nil {
+			return nil, err
+		}
+	}
+	dir, err := m.uploadsDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	m.sweepUploads(dir)
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	u := &UploadSession{
+		ID:        hex.EncodeToString(buf),
+		Filename:  strings.TrimSpace(filename),
+		MimeType:  strings.TrimSpace(mimeType),
+		Size:      size,
+		CreatedAt: now,
+		ExpiresAt: now.Add(uploadTTL),
+	}
+	meta, err := json.Marshal(u)
+	if err != nil {
+		return nil, err
+	}
+	base := filepath.Join(dir, u.ID)
+	if err := os.WriteFile(base+".data", nil, 0600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(base+".json", meta, 0600); err != nil {
+		_ = os.Remove(base + ".data")
+		return nil, err
+	}
+	return u, nil
+}
+
+// GetUpload returns an upload session with the number of bytes received.
+func (m *Manager) GetUpload(id string) (*UploadSession, error) {
+	dir, err := m.uploadsDir()
+	if err != nil {
+		return nil, err
+	}
+	return readUpload(dir, id)
+}
+
+func readUpload(dir, id string) (*UploadSession, error) {
+	if _, err := hex.DecodeString(id); err != nil || len(id) != 32 {
+		return nil, fmt.Errorf("upload %w", ErrNotFound)
+	}
+	base := filepath.Join(dir, id)
+	meta, err := os.ReadFile(base + ".json")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("upload %w", ErrNotFound)
+	} else if err != nil {
+		return nil, err
+	}
+	var u UploadSession
+	if err := json.Unmarshal(meta, &u); err != nil {
+		return nil, fmt.Errorf("read upload %s: %w", id, err)
+	}
+	if time.Now().After(u.ExpiresAt) {
+		removeUpload(dir, id)
+		return nil, fmt.Errorf("upload %w", ErrNotFound)
+	}
+	st, err := os.Stat(base + ".data")
+	if err != nil {
+		return nil, fmt.Errorf("upload %w", ErrNotFound)
+	}
+	u.Received = st.Size()
+	return &u, nil
+}
+
+// WriteUploadChunk appends the byte range [start, start+length) of an upload
+// read from r. Chunks must arrive in order: start has to equal the bytes
+// received so far. Bytes that arrived before a broken chunk are kept, so the
+// client can resume from the session's received count.
+func (m *Manager) WriteUploadChunk(id string, start, length, total int64, r io.Reader) (*UploadSession, error) {
+	dir, err := m.uploadsDir()
+	if err != nil {
+		return nil, err
+	}
+	if !m.claimUpload(id) {
+		return nil, fmt.Errorf("%w: another chunk is being written", ErrUploadBusy)
+	}
+	defer m.releaseUpload(id)
+
+	u, err := readUpload(dir, id)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case total != u.Size:
+		return nil, fmt.Errorf("%w: total %d does not match upload size %d", ErrInvalidRange, total, u.Size)
+	case start != u.Received:
+		return nil, fmt.Errorf("%w: chunk starts at %d, %d bytes received", ErrOffsetMismatch, start, u.Received)
+	case length <= 0 || start+length > u.Size:
+		return nil, fmt.Errorf("%w: chunk exceeds upload size %d", ErrInvalidRange, u.Size)
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, id+".data"), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	n, err := io.Copy(f, io.LimitReader(r, length))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	u.Received += n
+	if err != nil {
+		return u, fmt.Errorf("%w: chunk cut short: %w", ErrUploadIncomplete, err)
+	}
+	if n != length {
+		return u, fmt.Errorf("%w: chunk has %d of %d bytes", ErrUploadIncomplete, n, length)
+	}
+	return u, nil
+}
+
+// DeleteUpload aborts an upload session.
+func (m *Manager) DeleteUpload(id string) error {
+	dir, err := m.uploadsDir()
+	if err != nil {
+		return err
+	}
+	if _, err := readUpload(dir, id); err != nil {
+		return err
+	}
+	removeUpload(dir, id)
+	return nil
+}
+
+// SendUpload sends a completed upload, streaming it from disk, and deletes
+// the session once sent. Empty filename and mimeType default to the ones
+// given when the session was created.
+func (m *Manager) SendUpload(ctx context.Context, to, id, filename, caption, mimeType string, opts SendFileOptions) (*SendFileResult, error) {
+	dir, err := m.uploadsDir()
+	if err != nil {
+		return nil, err
+	}
+	if !m.claimUpload(id) {
+		return nil, fmt.Errorf("%w: another chunk is being written", ErrUploadBusy)
+	}
+	defer m.releaseUpload(id)
+
+	u, err := readUpload(dir, id)
+	if err != nil {
+		return nil, err
+	}
+	if !u.Complete() {
+		return nil, fmt.Errorf("%w: %d of %d bytes received", ErrUploadIncomplete, u.Received, u.Size)
+	}
+	if filename == "" {
+		filename = u.Filename
+	}
+	if filename == "" {
+		filename = "file"
+	}
+	if mimeType == "" {
+		mimeType = u.MimeType
+	}
+	file := mediaFile{path: filepath.Join(dir, id+".data")}
+	res, err := m.sendMedia(ctx, to, file, filename, caption, mimeType, opts)
+	if err != nil {
+		m.recordFailedFile(file, filename, caption, mimeType, err)
+		return nil, err
+	}
+	removeUpload(dir, id)
+	return res, nil
+}
+
+// claimUpload marks a session as in use so chunks are not written
+// concurrently; it reports false if it already is.
+func (m *Manager) claimUpload(id string) bool {
+	m.uploadsMu.Lock()
+	defer m.uploadsMu.Unlock()
+	if m.uploadsBusy == nil {
+		m.uploadsBusy = map[string]bool{}
+	}
+	if m.uploadsBusy[id] {
+		return false
+	}
+	m.uploadsBusy[id] = true
+	return true
+}
+
+func (m *Manager) releaseUpload(id string) {
+	m.uploadsMu.Lock()
+	delete(m.uploadsBusy, id)
+	m.uploadsMu.Unlock()
+}
+
+// sweepUploads deletes expired sessions.
+func (m *Manager) sweepUploads(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if id, ok := strings.CutSuffix(e.Name(), ".json"); ok {
+			_, _ = readUpload(dir, id) // removes the session if expired
+		}
+	}
+}
+
+func removeUpload(dir, id string) {
+	_ = os.Remove(filepath.Join(dir, id+".data"))
+	_ = os.Remove(filepath.Join(dir, id+".json"))
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/steipete/wacli/internal/app"
+)
+
+// webhookMediaTimeout bounds fetching the media of one message for its
+// message.received event.
+const webhookMediaTimeout = 2 * time.Minute
+
+// MessageMedia is the media of a message as included in message.received
+// when WASVC_WEBHOOK_MEDIA is set: inline as base64, or as a signed URL.
+type MessageMedia struct {
+	MimeType     string     `json:"mime_type,omitempty"`
+	Filename     string     `json:"filename,omitempty"`
+	Size         int64      `json:"size,omitempty"`
+	Data         string     `json:"data,omitempty"` // base64
+	URL          string     `json:"url,omitempty"`
+	URLExpiresAt *time.Time `json:"url_expires_at,omitempty"`
+}
+
+// newSigningKey returns secret as an HMAC key, or a random key for this run
+// if it is empty.
+func newSigningKey(secret string) []byte {
+	if secret != "" {
+		return []byte(secret)
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func (m *Manager) mediaSignature(chatJID, msgID, expires string) string {
+	h := hmac.New(sha256.New, m.mediaURLKey)
+	h.Write([]byte(chatJID + "\n" + msgID + "\n" + expires))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SignMediaURL returns a URL under WASVC_PUBLIC_URL that streams the media
+// of a message without an API key until it expires.
+func (m *Manager) SignMediaURL(chatJID, msgID string) (string, time.Time) {
+	expiresAt := time.Now().UTC().Add(m.config.MediaURLTTL).Truncate(time.Second)
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	q := url.Values{"expires": {expires}, "sig": {m.mediaSignature(chatJID, msgID, expires)}}
+	u := m.config.PublicURL + "/media/" + url.PathEscape(chatJID) + "/" + url.PathEscape(msgID) + "/content?" + q.Encode()
+	return u, expiresAt
+}
+
+// VerifyMediaURL reports whether expires and sig are a valid, unexpired
+// signature for the media of a message.
+func (m *Manager) VerifyMediaURL(chatJID, msgID, expires, sig string) bool {
+	n, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > n {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(m.mediaSignature(chatJID, msgID, expires)))
+}
+
+// attachWebhookMedia sets msg.Media as configured by WASVC_WEBHOOK_MEDIA.
+// Media over the size cap is sent as a URL when WASVC_PUBLIC_URL is set,
+// and left out otherwise.
+func (m *Manager) attachWebhookMedia(msg *ReceivedMessage, size int64) {
+	media := &MessageMedia{Size: size}
+	if m.config.WebhookMedia == "base64" && (m.config.WebhookMediaMaxBytes == 0 || size <= m.config.WebhookMediaMaxBytes) {
+		if err := m.readWebhookMedia(msg.ChatJID, msg.MsgID, media); err != nil {
+			log.Printf("[Media] Failed to fetch %s/%s for webhook: %v", msg.ChatJID, msg.MsgID, err)
+		}
+	}
+	if media.Data == "" && m.config.PublicURL != "" {
+		u, expiresAt := m.SignMediaURL(msg.ChatJID, msg.MsgID)
+		media.URL, media.URLExpiresAt = u, &expiresAt
+	}
+	if media.Data == "" && media.URL == "" {
+		return
+	}
+	if media.MimeType == "" {
+		if a := m.App(); a != nil {
+			if info, err := a.DB().GetMediaDownloadInfo(msg.ChatJID, msg.MsgID); err == nil {
+				media.MimeType, media.Filename = info.MimeType, app.MediaFilename(info)
+			}
+		}
+	}
+	msg.Media = media
+}
+
+func (m *Manager) readWebhookMedia(chatJID, msgID string, media *MessageMedia) error {
+	ctx, cancel := context.WithTimeout(m.ctx, webhookMediaTimeout)
+	defer cancel()
+	content, err := m.OpenMedia(ctx, chatJID, msgID)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	var r io.Reader = content
+	if max := m.config.WebhookMediaMaxBytes; max > 0 {
+		r = io.LimitReader(content, max+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if max := m.config.WebhookMediaMaxBytes; max > 0 && int64(len(data)) > max {
+		return fmt.Errorf("media exceeds %d bytes", max)
+	}
+	media.MimeType, media.Filename = content.MimeType, content.Filename
+	media.Size = int64(len(data))
+	media.Data = base64.StdEncoding.EncodeToString(data)
+	return nil
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/webhook"
+)
+
+// ListWebhookDeadLetters returns webhook deliveries that exhausted their retries.
+func (m *Manager) ListWebhookDeadLetters(limit int) ([]store.WebhookDeadLetter, error) {
+	a := m.App()
+	if a == nil {
+		return nil, errNotInitialized
+	}
+	return a.DB().ListWebhookDeadLetters(limit)
+}
+
+// ReplayWebhookDeadLetter re-queues a dead-lettered delivery. The webhook
+// emitter picks it up from the persistent queue on its next refill.
+func (m *Manager) ReplayWebhookDeadLetter(id int64) (int64, error) {
+	a := m.App()
+	if a == nil {
+		return 0, errNotInitialized
+	}
+	queueID, err := a.DB().ReplayWebhookDeadLetter(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("dead letter %d %w", id, ErrNotFound)
+	}
+	return queueID, err
+}
+
+// WebhookInfo is a configured webhook endpoint and the chat filter in
+// effect for it. Secrets and headers are left out.
+type WebhookInfo struct {
+	URL           string
+	Events        []string
+	Chats         []string
+	ExcludeChats  []string
+	RuntimeFilter bool // the chat filter was set through the API rather than configured
+}
+
+// SetWebhookEmitter hands the manager the webhook emitter so its chat
+// filters can be managed at runtime.
+func (m *Manager) SetWebhookEmitter(e *webhook.Emitter) {
+	m.handlersMu.Lock()
+	m.webhooks = e
+	m.handlersMu.Unlock()
+}
+
+func (m *Manager) webhookEmitter() (*webhook.Emitter, error) {
+	m.handlersMu.RLock()
+	e := m.webhooks
+	m.handlersMu.RUnlock()
+	if e == nil {
+		return nil, fmt.Errorf("%w: no webhooks configured", ErrNotFound)
+	}
+	return e, nil
+}
+
+// ListWebhooks returns the configured webhook endpoints.
+func (m *Manager) ListWebhooks() []WebhookInfo {
+	e, err := m.webhookEmitter()
+	if err != nil {
+		return nil
+	}
+	eps := e.Endpoints()
+	out := make([]WebhookInfo, len(eps))
+	for i, ep := range eps {
+		f, runtime := e.ChatFilter(ep.URL)
+		out[i] = WebhookInfo{URL: ep.URL, Events: ep.Events, Chats: f.Chats, ExcludeChats: f.ExcludeChats, RuntimeFilter: runtime}
+	}
+	return out
+}
+
+// WebhookStats returns the webhook delivery metrics since the service started.
+func (m *Manager) WebhookStats() (webhook.Stats, error) {
+	e, err := m.webhookEmitter()
+	if err != nil {
+		return webhook.Stats{}, err
+	}
+	return e.Stats(), nil
+}
+
+// SetWebhookChatFilter replaces the chat filter of a webhook endpoint. Chat
+// entries may be JIDs, phone numbers or chat kinds. A nil filter goes back
+// to the configured one.
+func (m *Manager) SetWebhookChatFilter(url string, f *webhook.ChatFilter) error {
+	e, err := m.webhookEmitter()
+	if err != nil {
+		return err
+	}
+	if f == nil {
+		return e.ResetChatFilter(url)
+	}
+	norm := webhook.ChatFilter{
+		Chats:        normalizeChatFilter(f.Chats),
+		ExcludeChats: normalizeChatFilter(f.ExcludeChats),
+	}
+	return e.SetChatFilter(url, norm)
+}
+
+// normalizeChatFilter turns phone numbers and JIDs of a chat filter into
+// canonical JIDs, keeping chat kinds and entries it cannot parse as they are
+// for validation to report.
+func normalizeChatFilter(entries []string) []string {
+	var out []string
+	for _, c := range entries {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		if !webhook.IsChatKind(c) {
+			if jid, err := NormalizeChatJID(c); err == nil {
+				c = jid
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+// Package spam implements a lightweight heuristic spam scorer for inbound messages.
+package spam
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b[a-z0-9-]+\.(?:com|net|org|io|ly|me|xyz|top|click|link|info|biz)(?:/\S*)?\b`)
+
+// Signals are the facts about a message that feed the scorer.
+type Signals struct {
+	Text           string
+	KnownSender    bool // saved contact or someone we have written to
+	DuplicateChats int  // other chats that received the same text recently
+}
+
+// Result is the outcome of scoring a message.
+type Result struct {
+	Score   float64  // 0 (clean) to 1 (almost certainly spam)
+	Reasons []string // human-readable contributing signals
+}
+
+// Score computes a spam score from message signals.
+func Score(s Signals) Result {
+	var r Result
+
+	links := len(linkPattern.FindAllString(s.Text, -1))
+	if links > 0 {
+		r.Score += math.Min(0.4, 0.15*float64(links))
+		r.Reasons = append(r.Reasons, "links")
+		if words := len(strings.Fields(s.Text)); words > 0 && float64(links)/float64(words) >= 0.2 {
+			r.Score += 0.2
+			r.Reasons = append(r.Reasons, "link_density")
+		}
+	}
+
+	if !s.KnownSender {
+		r.Score += 0.3
+		r.Reasons = append(r.Reasons, "unknown_sender")
+	}
+
+	if s.DuplicateChats > 0 {
+		r.Score += math.Min(0.4, 0.2*float64(s.DuplicateChats))
+		r.Reasons = append(r.Reasons, "duplicate_content")
+	}
+
+	if r.Score > 1 {
+		r.Score = 1
+	}
+	r.Score = math.Round(r.Score*100) / 100
+	return r
+}
@@ -0,0 +1,35 @@
+package spam
+
+import "testing"
+
+func TestScoreCleanMessageFromKnownSender(t *testing.T) {
+	r := Score(Signals{Text: "see you at dinner tonight", KnownSender: true})
+	if r.Score != 0 || len(r.Reasons) != 0 {
+		t.Fatalf("expected clean score, got %+v", r)
+	}
+}
+
+func TestScoreLinkHeavyDuplicateFromStranger(t *testing.T) {
+	r := Score(Signals{
+		Text:           "WIN NOW https://x.example/promo bit.ly/abc",
+		KnownSender:    false,
+		DuplicateChats: 3,
+	})
+	if r.Score != 1 {
+		t.Fatalf("expected capped score 1, got %+v", r)
+	}
+	want := map[string]bool{"links": true, "link_density": true, "unknown_sender": true, "duplicate_content": true}
+	for _, reason := range r.Reasons {
+		delete(want, reason)
+	}
+	if len(want) != 0 {
+		t.Fatalf("missing reasons %v in %+v", want, r)
+	}
+}
+
+func TestScoreUnknownSenderOnly(t *testing.T) {
+	r := Score(Signals{Text: "hello, is this the bakery?"})
+	if r.Score != 0.3 {
+		t.Fatalf("expected 0.3 for unknown sender, got %+v", r)
+	}
+}
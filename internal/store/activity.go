@@ -0,0 +1,297 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MemberActivity summarizes how often a single sender posted in a chat.
+type MemberActivity struct {
+	SenderJID    string
+	SenderName   string
+	MessageCount int64
+	Hours        [24]int64 // message count per UTC hour of day
+	LastMessage  time.Time
+}
+
+// ChatActivity is the per-member breakdown of a chat over a time window.
+type ChatActivity struct {
+	ChatJID       string
+	Since         time.Time
+	Until         time.Time
+	TotalMessages int64
+	Hours         [24]int64
+	Members       []MemberActivity
+}
+
+// ChatActivity computes per-member message counts and hour-of-day
+// distribution for a chat from stored messages in [since, until).
+func (d *DB) ChatActivity(chatJID string, since, until time.Time) (ChatActivity, error) {
+	out := ChatActivity{ChatJID: chatJID, Since: since, Until: until}
+
+	hour := `CAST(strftime('%H', m.ts, 'unixepoch') AS INTEGER)`
+	if d.postgres {
+		hour = `CAST(EXTRACT(HOUR FROM to_timestamp(m.ts) AT TIME ZONE 'UTC') AS INTEGER)`
+	}
+	q := `
+		SELECT CASE WHEN m.from_me = 1 THEN 'me' ELSE COALESCE(m.sender_jid,'') END,
+		       COALESCE(MAX(m.sender_name),''),
+		       ` + hour + `,
+		       COUNT(1),
+		       MAX(m.ts)
+		FROM messages m
+		WHERE m.chat_jid = ?`
+	args := []interface{}{chatJID}
+	if !since.IsZero() {
+		q += " AND m.ts >= ?"
+		args = append(args, unix(since))
+	}
+	if !until.IsZero() {
+		q += " AND m.ts < ?"
+		args = append(args, unix(until))
+	}
+	q += " GROUP BY 1, 3"
+
+	rows, err := d.query(q, args...)
+	if err != nil {
+		return out, err
+	}
+	defer rows.Close()
+
+	members := map[string]*MemberActivity{}
+	for rows.Next() {
+		var sender, name string
+		var hour int
+		var count, lastTS int64
+		if err := rows.Scan(&sender, &name, &hour, &count, &lastTS); err != nil {
+			return out, err
+		}
+		ma := members[sender]
+		if ma == nil {
+			ma = &MemberActivity{SenderJID: sender}
+			members[sender] = ma
+		}
+		if strings.TrimSpace(name) != "" {
+			ma.SenderName = name
+		}
+		if hour >= 0 && hour < 24 {
+			ma.Hours[hour] += count
+			out.Hours[hour] += count
+		}
+		ma.MessageCount += count
+		if last := fromUnix(lastTS); last.After(ma.LastMessage) {
+			ma.LastMessage = last
+		}
+		out.TotalMessages += count
+	}
+	if err := rows.Err(); err != nil {
+		return out, err
+	}
+
+	for _, ma := range members {
+		out.Members = append(out.Members, *ma)
+	}
+	sort.Slice(out.Members, func(i, j int) bool {
+		if out.Members[i].MessageCount != out.Members[j].MessageCount {
+			return out.Members[i].MessageCount > out.Members[j].MessageCount
+		}
+		return out.Members[i].SenderJID < out.Members[j].SenderJID
+	})
+	return out, nil
+}
+
+// SenderCount is a sender's share of a chat's messages.
+type SenderCount struct {
+	SenderJID    string
+	SenderName   string
+	MessageCount int64
+}
+
+// ChatStats summarizes the stored traffic of one chat.
+type ChatStats struct {
+	ChatJID      string
+	ChatName     string
+	Kind         string
+	MessageCount int64
+	MediaCount   int64
+	FirstMessage time.Time
+	LastMessage  time.Time
+	TopSenders   []SenderCount
+}
+
+// ChatStatsParams selects the window and size of ChatStats.
+type ChatStatsParams struct {
+	Since      time.Time // zero means no lower bound
+	Until      time.Time // zero means no upper bound
+	Limit      int       // chats to return, busiest first
+	TopSenders int       // senders per chat; 0 skips the breakdown
+}
+
+// ChatStats returns per-chat message and media counts for messages in
+// [Since, Until), busiest chats first, with each chat's top senders.
+func (d *DB) ChatStats(p ChatStatsParams) ([]ChatStats, error) {
+	if p.Limit <= 0 {
+		p.Limit = 50
+	}
+	where := " WHERE 1=1"
+	var args []interface{}
+	if !p.Since.IsZero() {
+		where += " AND m.ts >= ?"
+		args = append(args, unix(p.Since))
+	}
+	if !p.Until.IsZero() {
+		where += " AND m.ts < ?"
+		args = append(args, unix(p.Until))
+	}
+
+	rows, err := d.query(`
+		SELECT m.chat_jid, COALESCE(c.name,''), COALESCE(c.kind,''),
+		       COUNT(1),
+		       SUM(CASE WHEN COALESCE(m.media_type,'') <> '' THEN 1 ELSE 0 END),
+		       MIN(m.ts), MAX(m.ts)
+		FROM messages m
+		LEFT JOIN chats c ON c.jid = m.chat_jid`+where+`
+		GROUP BY m.chat_jid, c.name, c.kind
+		ORDER BY 4 DESC, m.chat_jid
+		LIMIT ?`, append(args, p.Limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ChatStats
+	index := map[string]int{}
+	for rows.Next() {
+		var s ChatStats
+		var first, last int64
+		if err := rows.Scan(&s.ChatJID, &s.ChatName, &s.Kind, &s.MessageCount, &s.MediaCount, &first, &last); err != nil {
+			return nil, err
+		}
+		s.FirstMessage = fromUnix(first)
+		s.LastMessage = fromUnix(last)
+		index[s.ChatJID] = len(out)
+		out = append(out, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if p.TopSenders <= 0 || len(out) == 0 {
+		return out, nil
+	}
+
+	placeholders := make([]string, len(out))
+	senderArgs := append([]interface{}{}, args...)
+	for i, s := range out {
+		placeholders[i] = "?"
+		senderArgs = append(senderArgs, s.ChatJID)
+	}
+	srows, err := d.query(`
+		SELECT m.chat_jid,
+		       CASE WHEN m.from_me = 1 THEN 'me' ELSE COALESCE(m.sender_jid,'') END,
+		       COALESCE(MAX(m.sender_name),''),
+		       COUNT(1)
+		FROM messages m`+where+` AND m.chat_jid IN (`+strings.Join(placeholders, ",")+`)
+		GROUP BY 1, 2`, senderArgs...)
+	if err != nil {
+		return nil, err
+	}
+	defer srows.Close()
+	for srows.Next() {
+		var chat string
+		var sc SenderCount
+		if err := srows.Scan(&chat, &sc.SenderJID, &sc.SenderName, &sc.MessageCount); err != nil {
+			return nil, err
+		}
+		if i, ok := index[chat]; ok {
+			out[i].TopSenders = append(out[i].TopSenders, sc)
+		}
+	}
+	if err := srows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range out {
+		senders := out[i].TopSenders
+		sort.Slice(senders, func(a, b int) bool {
+			if senders[a].MessageCount != senders[b].MessageCount {
+				return senders[a].MessageCount > senders[b].MessageCount
+			}
+			return senders[a].SenderJID < senders[b].SenderJID
+		})
+		if len(senders) > p.TopSenders {
+			out[i].TopSenders = senders[:p.TopSenders]
+		}
+	}
+	return out, nil
+}
+
+// TimelineParams selects the range and granularity of MessageTimeline.
+type TimelineParams struct {
+	Since   time.Time     // inclusive; rounded down to a bucket boundary
+	Until   time.Time     // exclusive
+	Bucket  time.Duration // a whole number of seconds, e.g. time.Hour
+	ChatJID string        // empty counts every chat
+}
+
+// TimelineBucket counts the messages sent and received in one bucket.
+type TimelineBucket struct {
+	Start    time.Time
+	Sent     int64
+	Received int64
+}
+
+// MessageTimeline counts messages per bucket in [Since, Until), split into
+// sent and received. Buckets are aligned to the Unix epoch, so days and
+// hours start on UTC boundaries, and empty buckets are included.
+func (d *DB) MessageTimeline(p TimelineParams) ([]TimelineBucket, error) {
+	size := int64(p.Bucket / time.Second)
+	if size <= 0 {
+		return nil, fmt.Errorf("bucket must be at least a second")
+	}
+	start := unix(p.Since) / size * size
+	end := unix(p.Until)
+	if start >= end {
+		return nil, nil
+	}
+
+	q := `
+		SELECT (m.ts / ?) * ?, m.from_me, COUNT(1)
+		FROM messages m
+		WHERE m.ts >= ? AND m.ts < ?`
+	args := []interface{}{size, size, start, end}
+	if p.ChatJID != "" {
+		q += " AND m.chat_jid = ?"
+		args = append(args, p.ChatJID)
+	}
+	q += " GROUP BY 1, 2"
+
+	out := make([]TimelineBucket, 0, (end-start+size-1)/size)
+	for ts := start; ts < end; ts += size {
+		out = append(out, TimelineBucket{Start: fromUnix(ts)})
+	}
+
+	rows, err := d.query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var bucket, count int64
+		var fromMe int
+		if err := rows.Scan(&bucket, &fromMe, &count); err != nil {
+			return nil, err
+		}
+		i := (bucket - start) / size
+		if i < 0 || i >= int64(len(out)) {
+			continue
+		}
+		if fromMe == 1 {
+			out[i].Sent += count
+		} else {
+			out[i].Received += count
+		}
+	}
+	return out, rows.Err()
+}
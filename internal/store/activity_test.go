@@ -0,0 +1,181 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChatActivityCountsPerMemberAndHour(t *testing.T) {
+	db := openTestDB(t)
+
+	gid := "123@g.us"
+	if err := db.UpsertChat(gid, "group", "Group", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	base := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	msgs := []struct {
+		id     string
+		sender string
+		fromMe bool
+		ts     time.Time
+	}{
+		{"m1", "a@s.whatsapp.net", false, base},
+		{"m2", "a@s.whatsapp.net", false, base.Add(time.Minute)},
+		{"m3", "a@s.whatsapp.net", false, base.Add(5 * time.Hour)},
+		{"m4", "b@s.whatsapp.net", false, base.Add(5 * time.Hour)},
+		{"m5", "", true, base.Add(2 * time.Hour)},
+		{"old", "b@s.whatsapp.net", false, base.AddDate(0, 0, -60)},
+	}
+	for _, m := range msgs {
+		if err := db.UpsertMessage(UpsertMessageParams{
+			ChatJID:   gid,
+			MsgID:     m.id,
+			SenderJID: m.sender,
+			Timestamp: m.ts,
+			FromMe:    m.fromMe,
+			Text:      m.id,
+		}); err != nil {
+			t.Fatalf("UpsertMessage %s: %v", m.id, err)
+		}
+	}
+
+	act, err := db.ChatActivity(gid, base.AddDate(0, 0, -30), base.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("ChatActivity: %v", err)
+	}
+	if act.TotalMessages != 5 {
+		t.Fatalf("expected 5 messages in window, got %d", act.TotalMessages)
+	}
+	if len(act.Members) != 3 {
+		t.Fatalf("expected 3 members, got %+v", act.Members)
+	}
+	top := act.Members[0]
+	if top.SenderJID != "a@s.whatsapp.net" || top.MessageCount != 3 {
+		t.Fatalf("expected a@ to lead with 3, got %+v", top)
+	}
+	if top.Hours[9] != 2 || top.Hours[14] != 1 {
+		t.Fatalf("unexpected hour distribution for a@: %v", top.Hours)
+	}
+	if act.Hours[14] != 2 || act.Hours[11] != 1 {
+		t.Fatalf("unexpected chat hour distribution: %v", act.Hours)
+	}
+}
+
+func TestChatStatsRanksChatsAndSenders(t *testing.T) {
+	db := openTestDB(t)
+
+	base := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	if err := db.UpsertChat("1@g.us", "group", "Busy", base); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	if err := db.UpsertChat("2@s.whatsapp.net", "dm", "Quiet", base); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	msgs := []struct {
+		chat, id, sender, media string
+		ts                      time.Time
+	}{
+		{"1@g.us", "g1", "a@s.whatsapp.net", "", base},
+		{"1@g.us", "g2", "a@s.whatsapp.net", "image", base.Add(time.Hour)},
+		{"1@g.us", "g3", "b@s.whatsapp.net", "", base.Add(2 * time.Hour)},
+		{"1@g.us", "g4", "c@s.whatsapp.net", "video", base.Add(3 * time.Hour)},
+		{"2@s.whatsapp.net", "d1", "2@s.whatsapp.net", "", base.Add(30 * time.Minute)},
+		{"2@s.whatsapp.net", "old", "2@s.whatsapp.net", "", base.AddDate(0, 0, -60)},
+	}
+	for _, m := range msgs {
+		if err := db.UpsertMessage(UpsertMessageParams{
+			ChatJID:   m.chat,
+			MsgID:     m.id,
+			SenderJID: m.sender,
+			Timestamp: m.ts,
+			Text:      m.id,
+			MediaType: m.media,
+		}); err != nil {
+			t.Fatalf("UpsertMessage %s: %v", m.id, err)
+		}
+	}
+
+	stats, err := db.ChatStats(ChatStatsParams{Since: base.AddDate(0, 0, -30), Limit: 10, TopSenders: 2})
+	if err != nil {
+		t.Fatalf("ChatStats: %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 chats, got %+v", stats)
+	}
+	busy := stats[0]
+	if busy.ChatJID != "1@g.us" || busy.ChatName != "Busy" || busy.MessageCount != 4 || busy.MediaCount != 2 {
+		t.Fatalf("unexpected busiest chat: %+v", busy)
+	}
+	if !busy.FirstMessage.Equal(base) || !busy.LastMessage.Equal(base.Add(3*time.Hour)) {
+		t.Fatalf("unexpected first/last: %v %v", busy.FirstMessage, busy.LastMessage)
+	}
+	if len(busy.TopSenders) != 2 || busy.TopSenders[0].SenderJID != "a@s.whatsapp.net" || busy.TopSenders[0].MessageCount != 2 {
+		t.Fatalf("unexpected top senders: %+v", busy.TopSenders)
+	}
+	if stats[1].MessageCount != 1 {
+		t.Fatalf("expected the old DM message outside the window, got %+v", stats[1])
+	}
+
+	all, err := db.ChatStats(ChatStatsParams{Limit: 1})
+	if err != nil {
+		t.Fatalf("ChatStats: %v", err)
+	}
+	if len(all) != 1 || all[0].TopSenders != nil {
+		t.Fatalf("expected one chat without sender breakdown, got %+v", all)
+	}
+}
+
+func TestMessageTimelineBucketsSentAndReceived(t *testing.T) {
+	db := openTestDB(t)
+
+	base := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	msgs := []struct {
+		chat   string
+		id     string
+		fromMe bool
+		ts     time.Time
+	}{
+		{"a@s.whatsapp.net", "m1", false, base.Add(9 * time.Hour)},
+		{"a@s.whatsapp.net", "m2", true, base.Add(9*time.Hour + time.Minute)},
+		{"b@s.whatsapp.net", "m3", false, base.Add(10 * time.Hour)},
+		{"a@s.whatsapp.net", "m4", false, base.Add(50 * time.Hour)},
+		{"a@s.whatsapp.net", "before", false, base.Add(-time.Hour)},
+		{"a@s.whatsapp.net", "after", true, base.Add(72 * time.Hour)},
+	}
+	for _, jid := range []string{"a@s.whatsapp.net", "b@s.whatsapp.net"} {
+		if err := db.UpsertChat(jid, "dm", jid, time.Now()); err != nil {
+			t.Fatalf("UpsertChat: %v", err)
+		}
+	}
+	for _, m := range msgs {
+		if err := db.UpsertMessage(UpsertMessageParams{
+			ChatJID:   m.chat,
+			MsgID:     m.id,
+			SenderJID: m.chat,
+			Timestamp: m.ts,
+			FromMe:    m.fromMe,
+			Text:      m.id,
+		}); err != nil {
+			t.Fatalf("UpsertMessage %s: %v", m.id, err)
+		}
+	}
+
+	days, err := db.MessageTimeline(TimelineParams{Since: base.Add(3 * time.Hour), Until: base.Add(72 * time.Hour), Bucket: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("MessageTimeline: %v", err)
+	}
+	if len(days) != 3 || !days[0].Start.Equal(base) {
+		t.Fatalf("days = %+v", days)
+	}
+	if days[0].Sent != 1 || days[0].Received != 2 || days[1].Sent+days[1].Received != 0 || days[2].Received != 1 {
+		t.Fatalf("day counts = %+v", days)
+	}
+
+	hours, err := db.MessageTimeline(TimelineParams{Since: base.Add(9 * time.Hour), Until: base.Add(11 * time.Hour), Bucket: time.Hour, ChatJID: "a@s.whatsapp.net"})
+	if err != nil {
+		t.Fatalf("MessageTimeline hourly: %v", err)
+	}
+	if len(hours) != 2 || hours[0].Sent != 1 || hours[0].Received != 1 || hours[1].Received != 0 {
+		t.Fatalf("hour counts = %+v", hours)
+	}
+}
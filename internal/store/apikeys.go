@@ -0,0 +1,110 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// APIKey is a key accepted for full API access. Only the SHA-256 hash of
+// the key is stored.
+type APIKey struct {
+	ID         int64
+	Hint       string // first characters of the key, to tell keys apart; empty if unknown
+	Label      string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time // zero means no expiry
+	LastUsedAt time.Time // zero if never used
+	ReadOnly   bool      // may only read, not send or change anything
+}
+
+// Expired reports whether the key is past its expiry.
+func (k APIKey) Expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+const apiKeyColumns = `id, hint, label, created_at, expires_at, last_used_at, read_only`
+
+// EnsureAPIKey stores a key by hash unless it is already known. A known key
+// keeps its expiry, so a key rotated out stays invalid when it is still
+// configured on restart.
+func (d *DB) EnsureAPIKey(keyHash, hint, label string) error {
+	_, err := d.exec(`
+		INSERT INTO api_keys(key_hash, hint, label, created_at) VALUES(?, ?, ?, ?)
+		ON CONFLICT(key_hash) DO NOTHING
+	`, keyHash, hint, label, time.Now().UTC().Unix())
+	return err
+}
+
+// CreateAPIKey stores a new key by hash.
+func (d *DB) CreateAPIKey(keyHash, hint, label string, readOnly bool) (APIKey, error) {
+	now := time.Now().UTC()
+	id, err := d.insertID(d.sql, `
+		INSERT INTO api_keys(key_hash, hint, label, created_at, read_only) VALUES(?, ?, ?, ?, ?)
+	`, keyHash, hint, label, now.Unix(), boolToInt(readOnly))
+	if err != nil {
+		return APIKey{}, err
+	}
+	return APIKey{ID: id, Hint: hint, Label: label, CreatedAt: fromUnix(now.Unix()), ReadOnly: readOnly}, nil
+}
+
+// APIKeyByHash looks up a key by hash. It returns sql.ErrNoRows if the key
+// is unknown.
+func (d *DB) APIKeyByHash(keyHash string) (APIKey, error) {
+	return scanAPIKey(d.queryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = ?`, keyHash))
+}
+
+// ListAPIKeys returns all keys, oldest first, including expired ones.
+func (d *DB) ListAPIKeys() ([]APIKey, error) {
+	rows, err := d.query(`SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, k)
+	}
+	return out, rows.Err()
+}
+
+// ExpireAPIKeys makes every key other than exceptID with the given
+// read-only flag expire at the given time, unless it already expires
+// earlier. It returns the number of keys changed.
+func (d *DB) ExpireAPIKeys(exceptID int64, readOnly bool, at time.Time) (int, error) {
+	res, err := d.exec(`
+		UPDATE api_keys SET expires_at = ?
+		WHERE id != ? AND read_only = ? AND (expires_at = 0 OR expires_at > ?)
+	`, unix(at), exceptID, boolToInt(readOnly), unix(at))
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// TouchAPIKey records that a key was used at the given time.
+func (d *DB) TouchAPIKey(id int64, at time.Time) error {
+	_, err := d.exec(`UPDATE api_keys SET last_used_at = ? WHERE id = ?`, unix(at), id)
+	return err
+}
+
+func scanAPIKey(row rowScanner) (APIKey, error) {
+	var k APIKey
+	var hint, label sql.NullString
+	var created, expires, used, readOnly int64
+	if err := row.Scan(&k.ID, &hint, &label, &created, &expires, &used, &readOnly); err != nil {
+		return APIKey{}, err
+	}
+	k.Hint = hint.String
+	k.Label = label.String
+	k.CreatedAt = fromUnix(created)
+	k.ExpiresAt = fromUnix(expires)
+	k.LastUsedAt = fromUnix(used)
+	k.ReadOnly = readOnly != 0
+	return k, nil
+}
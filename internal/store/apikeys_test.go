@@ -0,0 +1,71 @@
+package store
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestAPIKeysRotation(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := db.EnsureAPIKey("hash-env", "", "env"); err != nil {
+		t.Fatalf("EnsureAPIKey: %v", err)
+	}
+	old, err := db.APIKeyByHash("hash-env")
+	if err != nil || old.Label != "env" || !old.ExpiresAt.IsZero() {
+		t.Fatalf("APIKeyByHash = %+v, %v", old, err)
+	}
+
+	created, err := db.CreateAPIKey("hash-new", "wak_1234", "rotated", false)
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	readOnly, err := db.CreateAPIKey("hash-ro", "wak_abcd", "analytics", true)
+	if err != nil || !readOnly.ReadOnly {
+		t.Fatalf("CreateAPIKey(read-only) = %+v, %v", readOnly, err)
+	}
+	grace := time.Now().UTC().Add(time.Hour)
+	// Rotating full-access keys leaves read-only keys alone
+	if n, err := db.ExpireAPIKeys(created.ID, false, grace); err != nil || n != 1 {
+		t.Fatalf("ExpireAPIKeys = %d, %v", n, err)
+	}
+	// A later grace period must not extend the first one
+	if n, err := db.ExpireAPIKeys(created.ID, false, grace.Add(time.Hour)); err != nil || n != 0 {
+		t.Fatalf("ExpireAPIKeys again = %d, %v", n, err)
+	}
+
+	// Seeding the rotated key again keeps its expiry
+	if err := db.EnsureAPIKey("hash-env", "", "env"); err != nil {
+		t.Fatalf("EnsureAPIKey again: %v", err)
+	}
+	old, err = db.APIKeyByHash("hash-env")
+	if err != nil || old.ExpiresAt.Unix() != grace.Unix() {
+		t.Fatalf("rotated key = %+v, %v", old, err)
+	}
+	if old.Expired(time.Now()) || !old.Expired(grace.Add(time.Second)) {
+		t.Fatalf("unexpected expiry for %+v", old)
+	}
+
+	used := time.Now().UTC().Add(-time.Minute)
+	if err := db.TouchAPIKey(created.ID, used); err != nil {
+		t.Fatalf("TouchAPIKey: %v", err)
+	}
+	keys, err := db.ListAPIKeys()
+	if err != nil || len(keys) != 3 {
+		t.Fatalf("ListAPIKeys = %+v, %v", keys, err)
+	}
+	if k := keys[1]; k.ID != created.ID || k.Hint != "wak_1234" || k.LastUsedAt.Unix() != used.Unix() || !k.ExpiresAt.IsZero() {
+		t.Fatalf("unexpected new key: %+v", k)
+	}
+	if !keys[0].LastUsedAt.IsZero() {
+		t.Fatalf("unused key has last_used_at: %+v", keys[0])
+	}
+	if k := keys[2]; !k.ReadOnly || !k.ExpiresAt.IsZero() || keys[0].ReadOnly {
+		t.Fatalf("unexpected read-only flags: %+v", keys)
+	}
+
+	if _, err := db.APIKeyByHash("nope"); err != sql.ErrNoRows {
+		t.Fatalf("expected sql.ErrNoRows for unknown hash, got %v", err)
+	}
+}
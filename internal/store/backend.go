@@ -0,0 +1,223 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// Store is the message index used by the service and CLI. It is implemented
+// by DB on top of SQLite (Open) or Postgres (OpenPostgres).
+type Store interface {
+	Close() error
+	Backend() string
+	HasFTS() bool
+
+	// Chats
+	UpsertChat(jid, kind, name string, lastTS time.Time) error
+	ListChats(query string, limit int) ([]Chat, error)
+	ListLabeledChats(labelID, query string, limit int) ([]Chat, error)
+	FilterChats(p ListChatsParams) ([]Chat, error)
+	GetChat(jid string) (Chat, error)
+	SetChatArchived(jid string, archived bool) error
+	SetChatPinned(jid string, pinned bool) error
+	SetChatMuted(jid string, muted bool, until time.Time) error
+	SetChatRead(jid string, read bool) error
+	SetChatUnreadCount(jid string, n int) error
+	IncrementChatUnread(jid string) error
+	ChatActivity(chatJID string, since, until time.Time) (ChatActivity, error)
+	ChatStats(p ChatStatsParams) ([]ChatStats, error)
+	MessageTimeline(p TimelineParams) ([]TimelineBucket, error)
+
+	// Messages
+	UpsertMessage(p UpsertMessageParams) error
+	UpsertMessages(chats []UpsertChatParams, msgs []UpsertMessageParams) (int, error)
+	ListMessages(p ListMessagesParams) ([]Message, error)
+	SearchMessages(p SearchMessagesParams) ([]Message, error)
+	GetMessage(chatJID, msgID string) (Message, error)
+	EditMessage(chatJID, msgID, text string, at time.Time) (bool, error)
+	RevokeMessage(chatJID, msgID string) (bool, error)
+	DeleteMessage(chatJID, msgID string) (bool, error)
+	MessageContext(chatJID, msgID string, before, after int) ([]Message, error)
+	ExportChat(chatJID string, fn func(ExportedMessage) error) error
+	GetOldestMessageInfo(chatJID string) (MessageInfo, error)
+	GetNewestMessageInfo(chatJID string) (MessageInfo, error)
+	GetMediaDownloadInfo(chatJID, msgID string) (MediaDownloadInfo, error)
+	MarkMediaDownloaded(chatJID, msgID, localPath string, downloadedAt time.Time) error
+	ListPendingMedia(since time.Time, limit int) ([]PendingMedia, error)
+	CountMessages() (int64, error)
+	CountChats() (int64, error)
+	CountContacts() (int64, error)
+	CountGroups() (int64, error)
+	SchemaInfo() (SchemaInfo, error)
+	IntegrityCheck() ([]string, error)
+	Ping(ctx context.Context) error
+
+	// Labels
+	UpsertLabel(l Label) error
+	DeleteLabel(id string) error
+	ListLabels() ([]Label, error)
+	FindLabel(idOrName string) (Label, error)
+	SetChatLabel(chatJID, labelID string, labeled bool) error
+
+	// Starring
+	SetMessageStarred(chatJID, msgID string, starred bool, at time.Time) error
+	ListStarredMessages(p ListStarredParams) ([]Message, error)
+
+	// Receipts
+	AddMessageReceipts(chatJID, recipientJID string, msgIDs []string, status string, at time.Time) error
+	MessageReceipts(chatJID, msgID string) ([]MessageReceipt, error)
+
+	// Outbox
+	EnqueueOutbox(chatJID, text string) (int64, error)
+	NextOutbox() (OutboxMessage, error)
+	GetOutbox(id int64) (OutboxMessage, error)
+	ListOutbox(status string, limit int) ([]OutboxMessage, error)
+	CountOutbox(status string) (int64, error)
+	UpdateOutbox(id int64, status, msgID, errText string) error
+	CancelOutbox(id int64) (bool, error)
+	FailInterruptedOutbox() (int64, error)
+
+	// Failed sends
+	AddFailedSend(f FailedSend) (int64, error)
+	GetFailedSend(id int64) (FailedSend, error)
+	ListFailedSends(chatJID string, limit int) ([]FailedSend, error)
+	FailedSendRetryFailed(id int64, errText string) error
+	DeleteFailedSend(id int64) (bool, error)
+
+	// Calls
+	InsertCall(c Call) (bool, error)
+	SetCallStatus(callID, status string) error
+	EndCall(callID string, at time.Time, reason string) error
+	ListCalls(p ListCallsParams) ([]Call, error)
+
+	// Spam scoring
+	SetMessageSpamScore(chatJID, msgID string, score float64) error
+	CountDuplicateTextChats(text, excludeChatJID string, since time.Time) (int, error)
+	IsKnownSender(jid string) (bool, error)
+
+	// Contacts
+	UpsertContact(jid, phone, pushName, fullName, firstName, businessName string) error
+	SearchContacts(query string, limit int) ([]Contact, error)
+	GetContact(jid string) (Contact, error)
+	SetAlias(jid, alias string) error
+	RemoveAlias(jid string) error
+	ListTags(jid string) ([]string, error)
+	AddTag(jid, tag string) error
+	RemoveTag(jid, tag string) error
+	ImportContacts(contacts []ContactImport) error
+	ListAllContacts() ([]Contact, error)
+
+	// Blocklist
+	SetBlocked(jid string, blocked bool) error
+	ReplaceBlocklist(jids []string) error
+	ListBlocked() ([]BlockedContact, error)
+
+	// LID mapping
+	PutLIDMappings(ms []LIDMapping) error
+	LookupLID(jid string) (LIDMapping, error)
+	CountLIDMappings() (int64, error)
+
+	// Groups
+	UpsertGroup(jid, name, ownerJID string, created time.Time) error
+	SetGroupDescription(jid, description string) error
+	ReplaceGroupParticipants(groupJID string, participants []GroupParticipant) error
+	ListGroups(query string, limit int) ([]Group, error)
+	GetGroup(jid string) (Group, error)
+
+	// Communities
+	SetGroupCommunity(jid string, isCommunity bool) error
+	LinkGroup(jid, communityJID string, isAnnouncementGroup bool) error
+	UnlinkGroupsExcept(communityJID string, keep []string) error
+	ListCommunities(query string, limit int) ([]Community, error)
+	ListCommunityGroups(communityJID string) ([]Group, error)
+
+	// Channels
+	UpsertChannel(c Channel) error
+	SetChannelFollowed(jid string, followed bool) error
+	SetChannelMuted(jid string, muted bool) error
+	UnfollowChannelsExcept(keep []string) error
+	ListChannels(query string, limit int) ([]Channel, error)
+	GetChannel(jid string) (Channel, error)
+	UpsertChannelPost(p ChannelPost) error
+	ListChannelPosts(p ListChannelPostsParams) ([]ChannelPost, error)
+
+	// Replication
+	EnableChangeLog() error
+	DisableChangeLog() error
+	MessageChangesAfter(seq int64, limit int) ([]ReplicaMessage, error)
+	PruneMessageChanges(seq int64) error
+	GetReplicationCursor(name string) (int64, error)
+	SetReplicationCursor(name string, seq int64) error
+
+	// Webhook spool
+	EnqueueWebhookEvent(endpointURL, eventType string, payload []byte) (int64, error)
+	PendingWebhookEvents(limit int) ([]WebhookQueueItem, error)
+	CountPendingWebhookEvents() (int64, error)
+	DeleteWebhookEvent(id int64) error
+	DeadLetterWebhookEvent(queueID int64, attempts int, lastError string) error
+	ListWebhookDeadLetters(limit int) ([]WebhookDeadLetter, error)
+	ReplayWebhookDeadLetter(id int64) (int64, error)
+	SetWebhookChatFilter(f WebhookChatFilter) error
+	DeleteWebhookChatFilter(endpointURL string) (bool, error)
+	ListWebhookChatFilters() ([]WebhookChatFilter, error)
+
+	// Chat-scoped tokens
+	CreateChatToken(tokenHash, chatJID, label string, expiresAt time.Time) (ChatToken, error)
+	ChatTokenByHash(tokenHash string) (ChatToken, error)
+	ListChatTokens(chatJID string) ([]ChatToken, error)
+	DeleteChatToken(id int64) error
+
+	// API keys
+	EnsureAPIKey(keyHash, hint, label string) error
+	CreateAPIKey(keyHash, hint, label string, readOnly bool) (APIKey, error)
+	APIKeyByHash(keyHash string) (APIKey, error)
+	ListAPIKeys() ([]APIKey, error)
+	ExpireAPIKeys(exceptID int64, readOnly bool, at time.Time) (int, error)
+	TouchAPIKey(id int64, at time.Time) error
+
+	// Scripts
+	PutScript(name, source string, enabled bool) error
+	GetScript(name string) (Script, error)
+	ListScripts() ([]Script, error)
+	DeleteScript(name string) error
+
+	// Auto-responder
+	SetChatResponder(chatJID string, enabled bool) error
+	DeleteChatResponder(chatJID string) error
+	GetChatResponder(chatJID string) (ChatResponder, error)
+	ListChatResponders() ([]ChatResponder, error)
+
+	// Rules
+	CreateRule(r Rule) (Rule, error)
+	UpdateRule(r Rule) (Rule, error)
+	GetRule(id int64) (Rule, error)
+	ListRules() ([]Rule, error)
+	DeleteRule(id int64) error
+
+	// Retention
+	SetChatRetention(chatJID string, maxAge time.Duration) error
+	DeleteChatRetention(chatJID string) error
+	ListChatRetention() ([]ChatRetention, error)
+	DBBytes() (int64, error)
+	PruneMessages(p RetentionPolicy, dryRun bool) (PruneResult, error)
+	DeleteChatMessages(chatJID string) (PruneResult, error)
+
+	// Backfill-all checkpoints
+	StartBackfillRun(run BackfillRun, chats []string) error
+	GetBackfillRun() (BackfillRun, error)
+	FinishBackfillRun(status string, at time.Time) error
+	UpdateBackfillChat(c BackfillChat) error
+	ResetRunningBackfillChats() error
+	ListBackfillChats(status string) ([]BackfillChat, error)
+	CountBackfillChats() (map[string]int, error)
+}
+
+var _ Store = (*DB)(nil)
+
+// Backend names the database behind the store: "sqlite" or "postgres".
+func (d *DB) Backend() string {
+	if d.postgres {
+		return "postgres"
+	}
+	return "sqlite"
+}
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"time"
+)
+
+// Backfill run and chat states.
+const (
+	BackfillRunning   = "running"
+	BackfillCompleted = "completed"
+	BackfillCancelled = "cancelled"
+
+	BackfillPending = "pending"
+	BackfillDone    = "done"
+	BackfillSkipped = "skipped" // no stored message to page back from
+	BackfillFailed  = "failed"
+)
+
+// BackfillRun holds the parameters and state of a backfill-all run, so an
+// interrupted run can be resumed after a restart.
+type BackfillRun struct {
+	Count          int // messages per on-demand request
+	Requests       int // requests per chat
+	WaitPerRequest time.Duration
+	Concurrency    int
+	Interval       time.Duration // minimum gap between starting two chats
+	Status         string
+	StartedAt      time.Time
+	FinishedAt     time.Time
+}
+
+// BackfillChat is the checkpoint of one chat in a backfill-all run.
+type BackfillChat struct {
+	ChatJID       string
+	Status        string
+	RequestsSent  int
+	MessagesAdded int64
+	Error         string
+	UpdatedAt     time.Time
+}
+
+// StartBackfillRun replaces any previous run with a new one covering chats,
+// all pending and processed in the given order.
+func (d *DB) StartBackfillRun(run BackfillRun, chats []string) error {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM backfill_runs`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM backfill_chats`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(d.rebind(`
+		INSERT INTO backfill_runs(id, count, requests, wait_seconds, concurrency, interval_seconds, status, started_at)
+		VALUES(1, ?, ?, ?, ?, ?, ?, ?)
+	`), run.Count, run.Requests, int64(run.WaitPerRequest/time.Second), run.Concurrency,
+		int64(run.Interval/time.Second), BackfillRunning, unix(run.StartedAt)); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(d.rebind(`INSERT INTO backfill_chats(chat_jid, position, status, updated_at) VALUES(?, ?, ?, ?)`))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for i, chat := range chats {
+		if _, err := stmt.Exec(chat, i, BackfillPending, unix(run.StartedAt)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetBackfillRun returns the current or last run, or sql.ErrNoRows.
+func (d *DB) GetBackfillRun() (BackfillRun, error) {
+	var r BackfillRun
+	var wait, interval, started, finished int64
+	err := d.queryRow(`
+		SELECT count, requests, wait_seconds, concurrency, interval_seconds, status, started_at, finished_at
+		FROM backfill_runs WHERE id = 1
+	`).Scan(&r.Count, &r.Requests, &wait, &r.Concurrency, &interval, &r.Status, &started, &finished)
+	if err != nil {
+		return BackfillRun{}, err
+	}
+	r.WaitPerRequest = time.Duration(wait) * time.Second
+	r.Interval = time.Duration(interval) * time.Second
+	r.StartedAt = fromUnix(started)
+	if finished > 0 {
+		r.FinishedAt = fromUnix(finished)
+	}
+	return r, nil
+}
+
+// FinishBackfillRun marks the run completed or cancelled.
+func (d *DB) FinishBackfillRun(status string, at time.Time) error {
+	_, err := d.exec(`UPDATE backfill_runs SET status = ?, finished_at = ? WHERE id = 1`, status, unix(at))
+	return err
+}
+
+// UpdateBackfillChat records the checkpoint of one chat.
+func (d *DB) UpdateBackfillChat(c BackfillChat) error {
+	if c.UpdatedAt.IsZero() {
+		c.UpdatedAt = time.Now().UTC()
+	}
+	_, err := d.exec(`
+		UPDATE backfill_chats SET status = ?, requests_sent = ?, messages_added = ?, error = ?, updated_at = ?
+		WHERE chat_jid = ?
+	`, c.Status, c.RequestsSent, c.MessagesAdded, c.Error, unix(c.UpdatedAt), c.ChatJID)
+	return err
+}
+
+// ResetRunningBackfillChats puts chats interrupted mid-backfill back to
+// pending, so a resumed run picks them up again.
+func (d *DB) ResetRunningBackfillChats() error {
+	_, err := d.exec(`UPDATE backfill_chats SET status = ? WHERE status = ?`, BackfillPending, BackfillRunning)
+	return err
+}
+
+// ListBackfillChats returns the chats of the run with the given status, or
+// all of them if status is empty, in processing order.
+func (d *DB) ListBackfillChats(status string) ([]BackfillChat, error) {
+	q := `SELECT chat_jid, status, requests_sent, messages_added, error, updated_at FROM backfill_chats`
+	var args []interface{}
+	if status != "" {
+		q += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	q += ` ORDER BY position`
+
+	rows, err := d.query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []BackfillChat
+	for rows.Next() {
+		var c BackfillChat
+		var updated int64
+		if err := rows.Scan(&c.ChatJID, &c.Status, &c.RequestsSent, &c.MessagesAdded, &c.Error, &updated); err != nil {
+			return nil, err
+		}
+		c.UpdatedAt = fromUnix(updated)
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// CountBackfillChats returns the number of chats of the run per status.
+func (d *DB) CountBackfillChats() (map[string]int, error) {
+	rows, err := d.query(`SELECT status, COUNT(1) FROM backfill_chats GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		out[status] = n
+	}
+	return out, rows.Err()
+}
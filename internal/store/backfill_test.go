@@ -0,0 +1,64 @@
+package store
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestBackfillRunCheckpoints(t *testing.T) {
+	db := openTestDB(t)
+
+	if _, err := db.GetBackfillRun(); err != sql.ErrNoRows {
+		t.Fatalf("expected no run, got %v", err)
+	}
+
+	started := time.Now().UTC().Truncate(time.Second)
+	run := BackfillRun{Count: 50, Requests: 3, WaitPerRequest: time.Minute, Concurrency: 2, Interval: 5 * time.Second, StartedAt: started}
+	chats := []string{"3@g.us", "1@s.whatsapp.net", "2@s.whatsapp.net"}
+	if err := db.StartBackfillRun(run, chats); err != nil {
+		t.Fatalf("StartBackfillRun: %v", err)
+	}
+	got, err := db.GetBackfillRun()
+	if err != nil || got.Status != BackfillRunning || got.WaitPerRequest != time.Minute || got.Interval != 5*time.Second ||
+		!got.StartedAt.Equal(started) || !got.FinishedAt.IsZero() {
+		t.Fatalf("GetBackfillRun = %+v, %v", got, err)
+	}
+
+	if err := db.UpdateBackfillChat(BackfillChat{ChatJID: chats[0], Status: BackfillDone, RequestsSent: 3, MessagesAdded: 120}); err != nil {
+		t.Fatalf("UpdateBackfillChat: %v", err)
+	}
+	if err := db.UpdateBackfillChat(BackfillChat{ChatJID: chats[1], Status: BackfillRunning}); err != nil {
+		t.Fatalf("UpdateBackfillChat: %v", err)
+	}
+	if err := db.ResetRunningBackfillChats(); err != nil {
+		t.Fatalf("ResetRunningBackfillChats: %v", err)
+	}
+	pending, err := db.ListBackfillChats(BackfillPending)
+	if err != nil || len(pending) != 2 || pending[0].ChatJID != chats[1] || pending[1].ChatJID != chats[2] {
+		t.Fatalf("ListBackfillChats(pending) = %+v, %v", pending, err)
+	}
+	counts, err := db.CountBackfillChats()
+	if err != nil || counts[BackfillPending] != 2 || counts[BackfillDone] != 1 {
+		t.Fatalf("CountBackfillChats = %v, %v", counts, err)
+	}
+	all, _ := db.ListBackfillChats("")
+	if len(all) != 3 || all[0].MessagesAdded != 120 || all[0].RequestsSent != 3 {
+		t.Fatalf("ListBackfillChats = %+v", all)
+	}
+
+	if err := db.FinishBackfillRun(BackfillCancelled, started.Add(time.Minute)); err != nil {
+		t.Fatalf("FinishBackfillRun: %v", err)
+	}
+	if got, _ := db.GetBackfillRun(); got.Status != BackfillCancelled || !got.FinishedAt.Equal(started.Add(time.Minute)) {
+		t.Fatalf("expected cancelled run, got %+v", got)
+	}
+
+	// A new run replaces the previous checkpoints.
+	if err := db.StartBackfillRun(run, chats[:1]); err != nil {
+		t.Fatalf("StartBackfillRun: %v", err)
+	}
+	if all, _ := db.ListBackfillChats(""); len(all) != 1 || all[0].Status != BackfillPending {
+		t.Fatalf("expected fresh checkpoints, got %+v", all)
+	}
+}
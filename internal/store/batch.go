@@ -0,0 +1,81 @@
+package store
+
+import "time"
+
+// UpsertChatParams is a chat row for UpsertMessages.
+type UpsertChatParams struct {
+	JID           string
+	Kind          string
+	Name          string
+	LastMessageTS time.Time
+}
+
+// UpsertMessages stores chats and messages with the same semantics as
+// UpsertChat and UpsertMessage, but in a single transaction with prepared
+// statements: history sync delivers thousands of messages at a time, and
+// committing each row separately is what makes the initial sync slow.
+//
+// If the batch fails, it is rolled back and stored row by row instead, so
+// one bad message does not cost the rest. It returns the number of
+// messages stored, and the first error seen.
+func (d *DB) UpsertMessages(chats []UpsertChatParams, msgs []UpsertMessageParams) (int, error) {
+	if err := d.upsertMessagesTx(chats, msgs); err == nil {
+		return len(msgs), nil
+	}
+
+	var firstErr error
+	for _, c := range chats {
+		if err := d.UpsertChat(c.JID, c.Kind, c.Name, c.LastMessageTS); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	stored := 0
+	for _, m := range msgs {
+		if err := d.UpsertMessage(m); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		stored++
+	}
+	return stored, firstErr
+}
+
+func (d *DB) upsertMessagesTx(chats []UpsertChatParams, msgs []UpsertMessageParams) (err error) {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if len(chats) > 0 {
+		stmt, err := tx.Prepare(d.rebind(upsertChatQuery))
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, c := range chats {
+			if _, err = stmt.Exec(chatArgs(c.JID, c.Kind, c.Name, c.LastMessageTS)...); err != nil {
+				return err
+			}
+		}
+	}
+	if len(msgs) > 0 {
+		stmt, err := tx.Prepare(d.rebind(upsertMessageQuery))
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, m := range msgs {
+			if _, err = stmt.Exec(messageArgs(m)...); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
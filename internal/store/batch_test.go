@@ -0,0 +1,68 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestUpsertMessages(t *testing.T) {
+	db := openTestDB(t)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	chats := []UpsertChatParams{{JID: "123@s.whatsapp.net", Kind: "dm", Name: "Alice", LastMessageTS: base.Add(time.Hour)}}
+	var msgs []UpsertMessageParams
+	for i := 0; i < 3; i++ {
+		msgs = append(msgs, UpsertMessageParams{
+			ChatJID:   "123@s.whatsapp.net",
+			MsgID:     fmt.Sprintf("m%d", i),
+			Timestamp: base.Add(time.Duration(i) * time.Minute),
+			Text:      fmt.Sprintf("hello %d", i),
+		})
+	}
+	n, err := db.UpsertMessages(chats, msgs)
+	if err != nil || n != 3 {
+		t.Fatalf("UpsertMessages = %d, %v", n, err)
+	}
+	c, err := db.GetChat("123@s.whatsapp.net")
+	if err != nil || c.Name != "Alice" || !c.LastMessageTS.Equal(base.Add(time.Hour)) {
+		t.Fatalf("GetChat = %+v, %v", c, err)
+	}
+	if got, _ := db.CountMessages(); got != 3 {
+		t.Fatalf("CountMessages = %d", got)
+	}
+
+	// Upserting again updates in place, like UpsertMessage.
+	msgs[0].Text = "edited"
+	if n, err := db.UpsertMessages(nil, msgs[:1]); err != nil || n != 1 {
+		t.Fatalf("UpsertMessages again = %d, %v", n, err)
+	}
+	m, err := db.GetMessage("123@s.whatsapp.net", "m0")
+	if err != nil || m.Text != "edited" {
+		t.Fatalf("GetMessage = %+v, %v", m, err)
+	}
+	if got, _ := db.CountMessages(); got != 3 {
+		t.Fatalf("CountMessages after update = %d", got)
+	}
+}
+
+func TestUpsertMessagesFallsBackRowByRow(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Now()
+
+	// The second message's chat does not exist, so its foreign key fails
+	// and the transaction with it; the others must still be stored.
+	chats := []UpsertChatParams{{JID: "123@s.whatsapp.net", Kind: "dm", LastMessageTS: now}}
+	msgs := []UpsertMessageParams{
+		{ChatJID: "123@s.whatsapp.net", MsgID: "a", Timestamp: now},
+		{ChatJID: "missing@s.whatsapp.net", MsgID: "b", Timestamp: now},
+		{ChatJID: "123@s.whatsapp.net", MsgID: "c", Timestamp: now},
+	}
+	n, err := db.UpsertMessages(chats, msgs)
+	if err == nil || n != 2 {
+		t.Fatalf("UpsertMessages = %d, %v; want 2 and an error", n, err)
+	}
+	if got, _ := db.CountMessages(); got != 2 {
+		t.Fatalf("CountMessages = %d", got)
+	}
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"strings"
+	"time"
+)
+
+// BlockedContact is an entry of the local blocklist mirror.
+type BlockedContact struct {
+	JID       string
+	Name      string // alias or best known contact name, if any
+	BlockedAt time.Time
+}
+
+// SetBlocked adds a JID to or removes it from the blocklist.
+func (d *DB) SetBlocked(jid string, blocked bool) error {
+	if !blocked {
+		_, err := d.exec(`DELETE FROM blocklist WHERE jid = ?`, jid)
+		return err
+	}
+	_, err := d.exec(`INSERT INTO blocklist(jid, blocked_at) VALUES (?, ?) ON CONFLICT(jid) DO NOTHING`,
+		jid, unix(time.Now().UTC()))
+	return err
+}
+
+// ReplaceBlocklist makes the blocklist exactly jids, keeping the blocked_at
+// time of entries that remain.
+func (d *DB) ReplaceBlocklist(jids []string) (err error) {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	now := unix(time.Now().UTC())
+	del := `DELETE FROM blocklist`
+	var args []interface{}
+	if len(jids) > 0 {
+		del += ` WHERE jid NOT IN (` + strings.TrimSuffix(strings.Repeat("?,", len(jids)), ",") + `)`
+		for _, jid := range jids {
+			args = append(args, jid)
+		}
+	}
+	if _, err = tx.Exec(d.rebind(del), args...); err != nil {
+		return err
+	}
+	for _, jid := range jids {
+		if _, err = tx.Exec(d.rebind(`INSERT INTO blocklist(jid, blocked_at) VALUES (?, ?) ON CONFLICT(jid) DO NOTHING`), jid, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListBlocked returns the blocklist, most recently blocked first.
+func (d *DB) ListBlocked() ([]BlockedContact, error) {
+	rows, err := d.query(`
+		SELECT b.jid,
+		       COALESCE(NULLIF(a.alias,''), NULLIF(c.full_name,''), NULLIF(c.push_name,''), NULLIF(c.business_name,''), NULLIF(c.first_name,''), ''),
+		       b.blocked_at
+		FROM blocklist b
+		LEFT JOIN contacts c ON c.jid = b.jid
+		LEFT JOIN contact_aliases a ON a.jid = b.jid
+		ORDER BY b.blocked_at DESC, b.jid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []BlockedContact
+	for rows.Next() {
+		var b BlockedContact
+		var blockedAt int64
+		if err := rows.Scan(&b.JID, &b.Name, &blockedAt); err != nil {
+			return nil, err
+		}
+		b.BlockedAt = fromUnix(blockedAt)
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
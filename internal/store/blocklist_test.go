@@ -0,0 +1,59 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBlocklist(t *testing.T) {
+	db := openTestDB(t)
+	alice, bob, carol := "111@s.whatsapp.net", "222@s.whatsapp.net", "333@s.whatsapp.net"
+
+	if err := db.UpsertContact(alice, "111", "Alice", "", "", ""); err != nil {
+		t.Fatalf("UpsertContact: %v", err)
+	}
+	if err := db.SetBlocked(alice, true); err != nil {
+		t.Fatalf("SetBlocked: %v", err)
+	}
+	if err := db.SetBlocked(bob, true); err != nil {
+		t.Fatalf("SetBlocked: %v", err)
+	}
+	bs, err := db.ListBlocked()
+	if err != nil || len(bs) != 2 {
+		t.Fatalf("ListBlocked = %+v, %v", bs, err)
+	}
+	var first time.Time
+	for _, b := range bs {
+		if b.JID == alice {
+			first = b.BlockedAt
+			if b.Name != "Alice" {
+				t.Fatalf("expected contact name, got %+v", b)
+			}
+		}
+	}
+
+	if err := db.ReplaceBlocklist([]string{alice, carol}); err != nil {
+		t.Fatalf("ReplaceBlocklist: %v", err)
+	}
+	bs, _ = db.ListBlocked()
+	got := map[string]time.Time{}
+	for _, b := range bs {
+		got[b.JID] = b.BlockedAt
+	}
+	if len(got) != 2 || !got[alice].Equal(first) {
+		t.Fatalf("expected alice kept with original time and carol added, got %+v", bs)
+	}
+	if _, ok := got[carol]; !ok {
+		t.Fatalf("expected carol blocked, got %+v", bs)
+	}
+
+	if err := db.SetBlocked(alice, false); err != nil {
+		t.Fatalf("SetBlocked: %v", err)
+	}
+	if err := db.ReplaceBlocklist(nil); err != nil {
+		t.Fatalf("ReplaceBlocklist: %v", err)
+	}
+	if n := countRows(t, db.sql, "SELECT COUNT(*) FROM blocklist"); n != 0 {
+		t.Fatalf("expected empty blocklist, got %d", n)
+	}
+}
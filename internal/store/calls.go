@@ -0,0 +1,112 @@
+package store
+
+import (
+	"strings"
+	"time"
+)
+
+// Call statuses. A call starts ringing and settles once answered on another
+// device, rejected, or ended by the caller (missed).
+const (
+	CallRinging  = "ringing"
+	CallAnswered = "answered"
+	CallRejected = "rejected"
+	CallMissed   = "missed"
+)
+
+// Call is an incoming call.
+type Call struct {
+	CallID    string
+	FromJID   string
+	GroupJID  string
+	Video     bool
+	Status    string
+	Timestamp time.Time
+	EndedAt   time.Time
+	EndReason string
+}
+
+type ListCallsParams struct {
+	FromJID string
+	Limit   int
+	Before  *time.Time
+}
+
+// InsertCall records a new call. It reports false if the call was already
+// recorded, as WhatsApp may announce a call more than once.
+func (d *DB) InsertCall(c Call) (bool, error) {
+	if c.Status == "" {
+		c.Status = CallRinging
+	}
+	res, err := d.exec(`
+		INSERT INTO calls(call_id, from_jid, group_jid, video, status, ts)
+		VALUES(?, ?, ?, ?, ?, ?)
+		ON CONFLICT(call_id) DO NOTHING
+	`, c.CallID, c.FromJID, c.GroupJID, boolToInt(c.Video), c.Status, unix(c.Timestamp))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// SetCallStatus settles a ringing call as answered or rejected. Calls that
+// already settled keep their status.
+func (d *DB) SetCallStatus(callID, status string) error {
+	_, err := d.exec(`UPDATE calls SET status = ? WHERE call_id = ? AND status = ?`, status, callID, CallRinging)
+	return err
+}
+
+// EndCall records when and why a call ended; a call still ringing at that
+// point was missed.
+func (d *DB) EndCall(callID string, at time.Time, reason string) error {
+	_, err := d.exec(`
+		UPDATE calls SET ended_at = ?, end_reason = ?,
+			status = CASE WHEN status = ? THEN ? ELSE status END
+		WHERE call_id = ?
+	`, unix(at), reason, CallRinging, CallMissed, callID)
+	return err
+}
+
+// ListCalls returns calls, newest first.
+func (d *DB) ListCalls(p ListCallsParams) ([]Call, error) {
+	if p.Limit <= 0 {
+		p.Limit = 50
+	}
+	query := `
+		SELECT call_id, from_jid, group_jid, video, status, ts, ended_at, end_reason
+		FROM calls
+		WHERE 1=1`
+	var args []interface{}
+	if strings.TrimSpace(p.FromJID) != "" {
+		query += " AND from_jid = ?"
+		args = append(args, p.FromJID)
+	}
+	if p.Before != nil {
+		query += " AND ts < ?"
+		args = append(args, unix(*p.Before))
+	}
+	query += " ORDER BY ts DESC LIMIT ?"
+	args = append(args, p.Limit)
+
+	rows, err := d.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Call
+	for rows.Next() {
+		var c Call
+		var video int
+		var ts, endedAt int64
+		if err := rows.Scan(&c.CallID, &c.FromJID, &c.GroupJID, &video, &c.Status, &ts, &endedAt, &c.EndReason); err != nil {
+			return nil, err
+		}
+		c.Video = video != 0
+		c.Timestamp = fromUnix(ts)
+		c.EndedAt = fromUnix(endedAt)
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
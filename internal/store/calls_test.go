@@ -0,0 +1,58 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalls(t *testing.T) {
+	db := openTestDB(t)
+	base := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	caller := "111@s.whatsapp.net"
+
+	for i, id := range []string{"c1", "c2", "c3"} {
+		isNew, err := db.InsertCall(Call{CallID: id, FromJID: caller, Video: id == "c2", Timestamp: base.Add(time.Duration(i) * time.Minute)})
+		if err != nil || !isNew {
+			t.Fatalf("InsertCall %s = %v, %v", id, isNew, err)
+		}
+	}
+	if isNew, err := db.InsertCall(Call{CallID: "c1", FromJID: caller, Timestamp: base}); err != nil || isNew {
+		t.Fatalf("InsertCall again = %v, %v; want false", isNew, err)
+	}
+
+	// c1 is rejected, c2 answered elsewhere, c3 rings out.
+	if err := db.SetCallStatus("c1", CallRejected); err != nil {
+		t.Fatalf("SetCallStatus: %v", err)
+	}
+	if err := db.SetCallStatus("c2", CallAnswered); err != nil {
+		t.Fatalf("SetCallStatus: %v", err)
+	}
+	for _, id := range []string{"c1", "c2", "c3"} {
+		if err := db.EndCall(id, base.Add(time.Hour), "timeout"); err != nil {
+			t.Fatalf("EndCall: %v", err)
+		}
+	}
+	// A late status does not override a settled call.
+	if err := db.SetCallStatus("c3", CallRejected); err != nil {
+		t.Fatalf("SetCallStatus: %v", err)
+	}
+
+	calls, err := db.ListCalls(ListCallsParams{FromJID: caller})
+	if err != nil || len(calls) != 3 {
+		t.Fatalf("ListCalls = %+v, %v", calls, err)
+	}
+	want := map[string]string{"c1": CallRejected, "c2": CallAnswered, "c3": CallMissed}
+	for _, c := range calls {
+		if c.Status != want[c.CallID] || !c.EndedAt.Equal(base.Add(time.Hour)) || c.EndReason != "timeout" {
+			t.Errorf("call %s = %+v", c.CallID, c)
+		}
+	}
+	if calls[0].CallID != "c3" || !calls[1].Video {
+		t.Fatalf("unexpected order or video flag: %+v", calls)
+	}
+
+	before := base.Add(time.Minute)
+	if older, err := db.ListCalls(ListCallsParams{Before: &before}); err != nil || len(older) != 1 || older[0].CallID != "c1" {
+		t.Fatalf("ListCalls before = %+v, %v", older, err)
+	}
+}
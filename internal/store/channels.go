@@ -0,0 +1,231 @@
+package store
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Channel is a WhatsApp Channel (newsletter).
+type Channel struct {
+	JID         string
+	Name        string
+	Description string
+	InviteCode  string
+	Subscribers int
+	Verified    bool
+	Role        string // owner, admin, subscriber or guest; empty if unknown
+	Muted       bool
+	Followed    bool
+	UpdatedAt   time.Time
+	LastPostAt  time.Time // zero if no post is stored
+}
+
+// ChannelPost is a post of a channel.
+type ChannelPost struct {
+	ChannelJID   string
+	MsgID        string
+	ServerID     int64 // per-channel sequence number, used for paging
+	Timestamp    time.Time
+	Text         string
+	MediaType    string
+	MediaCaption string
+	Views        int
+	Reactions    map[string]int
+}
+
+// UpsertChannel stores channel metadata. Empty text fields keep the stored
+// value.
+func (d *DB) UpsertChannel(c Channel) error {
+	if c.UpdatedAt.IsZero() {
+		c.UpdatedAt = time.Now().UTC()
+	}
+	_, err := d.exec(`
+		INSERT INTO channels(jid, name, description, invite_code, subscribers, verified, role, muted, followed, updated_at)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET
+			name=COALESCE(NULLIF(excluded.name,''), channels.name),
+			description=COALESCE(NULLIF(excluded.description,''), channels.description),
+			invite_code=COALESCE(NULLIF(excluded.invite_code,''), channels.invite_code),
+			subscribers=excluded.subscribers,
+			verified=excluded.verified,
+			role=COALESCE(NULLIF(excluded.role,''), channels.role),
+			muted=excluded.muted,
+			followed=excluded.followed,
+			updated_at=excluded.updated_at
+	`, c.JID, c.Name, c.Description, c.InviteCode, c.Subscribers, boolToInt(c.Verified), c.Role,
+		boolToInt(c.Muted), boolToInt(c.Followed), unix(c.UpdatedAt))
+	return err
+}
+
+// SetChannelFollowed records following or unfollowing a channel.
+func (d *DB) SetChannelFollowed(jid string, followed bool) error {
+	_, err := d.exec(`
+		INSERT INTO channels(jid, followed, updated_at) VALUES(?, ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET followed=excluded.followed, updated_at=excluded.updated_at
+	`, jid, boolToInt(followed), unix(time.Now().UTC()))
+	return err
+}
+
+// SetChannelMuted records muting or unmuting a channel.
+func (d *DB) SetChannelMuted(jid string, muted bool) error {
+	_, err := d.exec(`
+		INSERT INTO channels(jid, muted, updated_at) VALUES(?, ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET muted=excluded.muted, updated_at=excluded.updated_at
+	`, jid, boolToInt(muted), unix(time.Now().UTC()))
+	return err
+}
+
+// UnfollowChannelsExcept marks every followed channel not in keep as
+// unfollowed, reconciling the store with the list WhatsApp reports.
+func (d *DB) UnfollowChannelsExcept(keep []string) error {
+	q := `UPDATE channels SET followed = 0, updated_at = ? WHERE followed = 1`
+	args := []interface{}{unix(time.Now().UTC())}
+	if len(keep) > 0 {
+		q += ` AND jid NOT IN (` + strings.TrimSuffix(strings.Repeat("?,", len(keep)), ",") + `)`
+		for _, jid := range keep {
+			args = append(args, jid)
+		}
+	}
+	_, err := d.exec(q, args...)
+	return err
+}
+
+const channelColumns = `c.jid, c.name, c.description, c.invite_code, c.subscribers, c.verified, c.role,
+	c.muted, c.followed, c.updated_at, COALESCE((SELECT MAX(p.ts) FROM channel_posts p WHERE p.channel_jid = c.jid), 0) AS last_post_ts`
+
+// ListChannels returns channels matching query by name or JID, followed
+// channels first, then by latest post.
+func (d *DB) ListChannels(query string, limit int) ([]Channel, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	q := `SELECT ` + channelColumns + ` FROM channels c WHERE 1=1`
+	var args []interface{}
+	if strings.TrimSpace(query) != "" {
+		q += ` AND (LOWER(c.name) LIKE LOWER(?) OR LOWER(c.jid) LIKE LOWER(?))`
+		needle := "%" + query + "%"
+		args = append(args, needle, needle)
+	}
+	q += ` ORDER BY c.followed DESC, last_post_ts DESC, c.name LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := d.query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Channel
+	for rows.Next() {
+		c, err := scanChannel(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// GetChannel returns one channel, or sql.ErrNoRows.
+func (d *DB) GetChannel(jid string) (Channel, error) {
+	return scanChannel(d.queryRow(`SELECT `+channelColumns+` FROM channels c WHERE c.jid = ?`, jid))
+}
+
+func scanChannel(row rowScanner) (Channel, error) {
+	var c Channel
+	var verified, muted, followed int
+	var updated, lastPost int64
+	if err := row.Scan(&c.JID, &c.Name, &c.Description, &c.InviteCode, &c.Subscribers, &verified, &c.Role,
+		&muted, &followed, &updated, &lastPost); err != nil {
+		return Channel{}, err
+	}
+	c.Verified = verified != 0
+	c.Muted = muted != 0
+	c.Followed = followed != 0
+	c.UpdatedAt = fromUnix(updated)
+	if lastPost > 0 {
+		c.LastPostAt = fromUnix(lastPost)
+	}
+	return c, nil
+}
+
+// UpsertChannelPost stores a channel post, creating the channel if it is not
+// known yet. View and reaction counts only ever move forward, since live
+// posts arrive without them.
+func (d *DB) UpsertChannelPost(p ChannelPost) error {
+	reactions := ""
+	if len(p.Reactions) > 0 {
+		raw, err := json.Marshal(p.Reactions)
+		if err != nil {
+			return err
+		}
+		reactions = string(raw)
+	}
+	if _, err := d.exec(`
+		INSERT INTO channels(jid, updated_at) VALUES(?, ?)
+		ON CONFLICT(jid) DO NOTHING
+	`, p.ChannelJID, unix(time.Now().UTC())); err != nil {
+		return err
+	}
+	_, err := d.exec(`
+		INSERT INTO channel_posts(channel_jid, msg_id, server_id, ts, text, media_type, media_caption, views, reactions)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(channel_jid, msg_id) DO UPDATE SET
+			server_id=CASE WHEN excluded.server_id > 0 THEN excluded.server_id ELSE channel_posts.server_id END,
+			ts=excluded.ts,
+			text=excluded.text,
+			media_type=excluded.media_type,
+			media_caption=excluded.media_caption,
+			views=CASE WHEN excluded.views > channel_posts.views THEN excluded.views ELSE channel_posts.views END,
+			reactions=COALESCE(NULLIF(excluded.reactions,''), channel_posts.reactions)
+	`, p.ChannelJID, p.MsgID, p.ServerID, unix(p.Timestamp), p.Text, p.MediaType, p.MediaCaption, p.Views, reactions)
+	return err
+}
+
+// ListChannelPostsParams filters ListChannelPosts.
+type ListChannelPostsParams struct {
+	ChannelJID string
+	Limit      int
+	Before     *time.Time
+}
+
+// ListChannelPosts returns the posts of a channel, newest first.
+func (d *DB) ListChannelPosts(p ListChannelPostsParams) ([]ChannelPost, error) {
+	if p.Limit <= 0 {
+		p.Limit = 50
+	}
+	q := `
+		SELECT channel_jid, msg_id, server_id, ts, text, media_type, media_caption, views, reactions
+		FROM channel_posts WHERE channel_jid = ?`
+	args := []interface{}{p.ChannelJID}
+	if p.Before != nil {
+		q += ` AND ts < ?`
+		args = append(args, unix(*p.Before))
+	}
+	q += ` ORDER BY ts DESC, server_id DESC LIMIT ?`
+	args = append(args, p.Limit)
+
+	rows, err := d.query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ChannelPost
+	for rows.Next() {
+		var post ChannelPost
+		var ts int64
+		var reactions string
+		if err := rows.Scan(&post.ChannelJID, &post.MsgID, &post.ServerID, &ts, &post.Text, &post.MediaType,
+			&post.MediaCaption, &post.Views, &reactions); err != nil {
+			return nil, err
+		}
+		post.Timestamp = fromUnix(ts)
+		if reactions != "" {
+			_ = json.Unmarshal([]byte(reactions), &post.Reactions)
+		}
+		out = append(out, post)
+	}
+	return out, rows.Err()
+}
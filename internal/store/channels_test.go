@@ -0,0 +1,67 @@
+package store
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestChannels(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	news, other := "111@newsletter", "222@newsletter"
+
+	// Posts may arrive before the channel metadata.
+	if err := db.UpsertChannelPost(ChannelPost{ChannelJID: news, MsgID: "A", ServerID: 100, Timestamp: now, Text: "first",
+		Views: 10, Reactions: map[string]int{"👍": 3}}); err != nil {
+		t.Fatalf("UpsertChannelPost: %v", err)
+	}
+	if err := db.UpsertChannelPost(ChannelPost{ChannelJID: news, MsgID: "B", ServerID: 101, Timestamp: now.Add(time.Hour),
+		MediaType: "image", MediaCaption: "pic"}); err != nil {
+		t.Fatalf("UpsertChannelPost: %v", err)
+	}
+	// A live copy without counts must not reset them.
+	if err := db.UpsertChannelPost(ChannelPost{ChannelJID: news, MsgID: "A", Timestamp: now, Text: "first (edited)"}); err != nil {
+		t.Fatalf("UpsertChannelPost: %v", err)
+	}
+
+	if err := db.UpsertChannel(Channel{JID: news, Name: "News", Subscribers: 1000, Verified: true, Role: "subscriber", Followed: true}); err != nil {
+		t.Fatalf("UpsertChannel: %v", err)
+	}
+	if err := db.UpsertChannel(Channel{JID: other, Name: "Other", Followed: true}); err != nil {
+		t.Fatalf("UpsertChannel: %v", err)
+	}
+	c, err := db.GetChannel(news)
+	if err != nil || c.Name != "News" || !c.Verified || !c.Followed || !c.LastPostAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("GetChannel = %+v, %v", c, err)
+	}
+	if _, err := db.GetChannel("333@newsletter"); err != sql.ErrNoRows {
+		t.Fatalf("expected ErrNoRows, got %v", err)
+	}
+
+	if err := db.UnfollowChannelsExcept([]string{news}); err != nil {
+		t.Fatalf("UnfollowChannelsExcept: %v", err)
+	}
+	if err := db.SetChannelMuted(news, true); err != nil {
+		t.Fatalf("SetChannelMuted: %v", err)
+	}
+	channels, err := db.ListChannels("", 10)
+	if err != nil || len(channels) != 2 || channels[0].JID != news || !channels[0].Muted || channels[1].Followed {
+		t.Fatalf("ListChannels = %+v, %v", channels, err)
+	}
+	if channels, _ := db.ListChannels("oth", 10); len(channels) != 1 || channels[0].JID != other {
+		t.Fatalf("expected search to match Other, got %+v", channels)
+	}
+
+	posts, err := db.ListChannelPosts(ListChannelPostsParams{ChannelJID: news})
+	if err != nil || len(posts) != 2 || posts[0].MsgID != "B" || posts[0].MediaType != "image" {
+		t.Fatalf("ListChannelPosts = %+v, %v", posts, err)
+	}
+	if p := posts[1]; p.Text != "first (edited)" || p.ServerID != 100 || p.Views != 10 || p.Reactions["👍"] != 3 {
+		t.Fatalf("expected counts to survive the live copy, got %+v", p)
+	}
+	before := now.Add(time.Minute)
+	if posts, _ := db.ListChannelPosts(ListChannelPostsParams{ChannelJID: news, Before: &before}); len(posts) != 1 || posts[0].MsgID != "A" {
+		t.Fatalf("expected only the older post, got %+v", posts)
+	}
+}
@@ -0,0 +1,73 @@
+package store
+
+import "time"
+
+// The archived, pinned and muted flags mirror WhatsApp app state, which can
+// name chats that have no stored messages yet. Setting a flag therefore
+// creates the chat row if needed; UpsertChat fills in kind and name later.
+
+// SetChatArchived sets the archived flag of a chat. Archiving also unpins,
+// as it does on WhatsApp.
+func (d *DB) SetChatArchived(jid string, archived bool) error {
+	if err := d.setChatFlag(jid, "archived", int64(boolToInt(archived))); err != nil {
+		return err
+	}
+	if archived {
+		return d.setChatFlag(jid, "pinned", 0)
+	}
+	return nil
+}
+
+// SetChatPinned sets the pinned flag of a chat.
+func (d *DB) SetChatPinned(jid string, pinned bool) error {
+	return d.setChatFlag(jid, "pinned", int64(boolToInt(pinned)))
+}
+
+// SetChatMuted mutes a chat until the given time, or forever if until is
+// zero; muted=false unmutes it.
+func (d *DB) SetChatMuted(jid string, muted bool, until time.Time) error {
+	var v int64
+	if muted {
+		v = -1
+		if !until.IsZero() {
+			v = unix(until)
+		}
+	}
+	return d.setChatFlag(jid, "muted_until", v)
+}
+
+// SetChatRead marks a chat read, clearing its unread count, or unread. A
+// chat marked unread keeps its count, or gets a count of 1 if it had none,
+// as WhatsApp shows an unread badge for it.
+func (d *DB) SetChatRead(jid string, read bool) error {
+	if read {
+		return d.setChatFlag(jid, "unread_count", 0)
+	}
+	_, err := d.exec(`
+		INSERT INTO chats(jid, kind, unread_count) VALUES(?, 'unknown', 1)
+		ON CONFLICT(jid) DO UPDATE SET unread_count = CASE WHEN chats.unread_count > 0 THEN chats.unread_count ELSE 1 END`, jid)
+	return err
+}
+
+// SetChatUnreadCount sets the unread count of a chat, as reported by a
+// history sync.
+func (d *DB) SetChatUnreadCount(jid string, n int) error {
+	if n < 0 {
+		n = 0
+	}
+	return d.setChatFlag(jid, "unread_count", int64(n))
+}
+
+// IncrementChatUnread counts one more unread message in a chat.
+func (d *DB) IncrementChatUnread(jid string) error {
+	_, err := d.exec(`UPDATE chats SET unread_count = unread_count + 1 WHERE jid = ?`, jid)
+	return err
+}
+
+// setChatFlag sets one of the flag columns above; column is never user input.
+func (d *DB) setChatFlag(jid, column string, value int64) error {
+	_, err := d.exec(`
+		INSERT INTO chats(jid, kind, `+column+`) VALUES(?, 'unknown', ?)
+		ON CONFLICT(jid) DO UPDATE SET `+column+`=excluded.`+column, jid, value)
+	return err
+}
@@ -0,0 +1,167 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChatState(t *testing.T) {
+	db := openTestDB(t)
+
+	// Flags may be synced before the chat has any messages.
+	jid := "1@s.whatsapp.net"
+	if err := db.SetChatPinned(jid, true); err != nil {
+		t.Fatalf("SetChatPinned: %v", err)
+	}
+	if err := db.UpsertChat(jid, "dm", "Alice", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	c, err := db.GetChat(jid)
+	if err != nil || !c.Pinned || c.Kind != "dm" || c.Name != "Alice" {
+		t.Fatalf("GetChat = %+v, %v", c, err)
+	}
+
+	if err := db.SetChatArchived(jid, true); err != nil {
+		t.Fatalf("SetChatArchived: %v", err)
+	}
+	if c, _ := db.GetChat(jid); !c.Archived || c.Pinned {
+		t.Fatalf("expected archived and unpinned, got %+v", c)
+	}
+
+	if err := db.SetChatMuted(jid, true, time.Time{}); err != nil {
+		t.Fatalf("SetChatMuted: %v", err)
+	}
+	if c, _ := db.GetChat(jid); !c.Muted || !c.MutedUntil.IsZero() {
+		t.Fatalf("expected muted forever, got %+v", c)
+	}
+	until := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := db.SetChatMuted(jid, true, until); err != nil {
+		t.Fatalf("SetChatMuted: %v", err)
+	}
+	if c, _ := db.GetChat(jid); !c.Muted || !c.MutedUntil.Equal(until) {
+		t.Fatalf("expected muted until %v, got %+v", until, c)
+	}
+	if err := db.SetChatMuted(jid, true, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("SetChatMuted: %v", err)
+	}
+	if c, _ := db.GetChat(jid); c.Muted {
+		t.Fatalf("expired mute must read as unmuted, got %+v", c)
+	}
+	if err := db.SetChatMuted(jid, false, time.Time{}); err != nil {
+		t.Fatalf("SetChatMuted: %v", err)
+	}
+
+	chats, err := db.ListChats("", 10)
+	if err != nil || len(chats) != 1 || !chats[0].Archived || chats[0].Muted {
+		t.Fatalf("ListChats = %+v, %v", chats, err)
+	}
+}
+
+func TestChatUnreadAndLastMessage(t *testing.T) {
+	db := openTestDB(t)
+
+	jid := "1@s.whatsapp.net"
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := db.UpsertChat(jid, "dm", "Alice", base); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	for i, text := range []string{"first", "second"} {
+		if err := db.UpsertMessage(UpsertMessageParams{
+			ChatJID:    jid,
+			MsgID:      text,
+			SenderJID:  jid,
+			SenderName: "Alice",
+			Timestamp:  base.Add(time.Duration(i) * time.Minute),
+			Text:       text,
+		}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+		if err := db.IncrementChatUnread(jid); err != nil {
+			t.Fatalf("IncrementChatUnread: %v", err)
+		}
+	}
+
+	c, err := db.GetChat(jid)
+	if err != nil || c.UnreadCount != 2 || c.LastMessage != "second" || c.LastSender != "Alice" {
+		t.Fatalf("GetChat = %+v, %v", c, err)
+	}
+	if _, err := db.EditMessage(jid, "second", "edited", time.Now()); err != nil {
+		t.Fatalf("EditMessage: %v", err)
+	}
+	chats, err := db.ListChats("", 10)
+	if err != nil || len(chats) != 1 || chats[0].LastMessage != "edited" {
+		t.Fatalf("ListChats = %+v, %v", chats, err)
+	}
+
+	if err := db.SetChatRead(jid, true); err != nil {
+		t.Fatalf("SetChatRead: %v", err)
+	}
+	if c, _ := db.GetChat(jid); c.UnreadCount != 0 {
+		t.Fatalf("expected no unread messages, got %+v", c)
+	}
+	if err := db.SetChatRead(jid, false); err != nil {
+		t.Fatalf("SetChatRead: %v", err)
+	}
+	if c, _ := db.GetChat(jid); c.UnreadCount != 1 {
+		t.Fatalf("chat marked unread must count 1, got %+v", c)
+	}
+	if err := db.SetChatUnreadCount(jid, 5); err != nil {
+		t.Fatalf("SetChatUnreadCount: %v", err)
+	}
+	if err := db.SetChatRead(jid, false); err != nil {
+		t.Fatalf("SetChatRead: %v", err)
+	}
+	if c, _ := db.GetChat(jid); c.UnreadCount != 5 {
+		t.Fatalf("marking unread must keep the count, got %+v", c)
+	}
+}
+
+func TestFilterChats(t *testing.T) {
+	db := openTestDB(t)
+
+	now := time.Now()
+	for i, c := range []struct{ jid, kind, name string }{
+		{"1@s.whatsapp.net", "dm", "Zoe"},
+		{"2@lid", "unknown", "alice"},
+		{"3@g.us", "group", "Book club"},
+	} {
+		if err := db.UpsertChat(c.jid, c.kind, c.name, now.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("UpsertChat: %v", err)
+		}
+	}
+	if err := db.IncrementChatUnread("3@g.us"); err != nil {
+		t.Fatalf("IncrementChatUnread: %v", err)
+	}
+	if err := db.SetChatArchived("1@s.whatsapp.net", true); err != nil {
+		t.Fatalf("SetChatArchived: %v", err)
+	}
+
+	yes, no := true, false
+	for _, tc := range []struct {
+		name string
+		p    ListChatsParams
+		want []string
+	}{
+		{"all", ListChatsParams{}, []string{"3@g.us", "2@lid", "1@s.whatsapp.net"}},
+		{"dm", ListChatsParams{Kind: "dm"}, []string{"2@lid", "1@s.whatsapp.net"}},
+		{"group", ListChatsParams{Kind: "group"}, []string{"3@g.us"}},
+		{"unread", ListChatsParams{HasUnread: &yes}, []string{"3@g.us"}},
+		{"read", ListChatsParams{HasUnread: &no}, []string{"2@lid", "1@s.whatsapp.net"}},
+		{"archived", ListChatsParams{Archived: &yes}, []string{"1@s.whatsapp.net"}},
+		{"not archived by name", ListChatsParams{Archived: &no, Sort: ChatSortName}, []string{"2@lid", "3@g.us"}},
+		{"by name", ListChatsParams{Sort: ChatSortName}, []string{"2@lid", "3@g.us", "1@s.whatsapp.net"}},
+	} {
+		chats, err := db.FilterChats(tc.p)
+		if err != nil {
+			t.Fatalf("%s: FilterChats: %v", tc.name, err)
+		}
+		var got []string
+		for _, c := range chats {
+			got = append(got, c.JID)
+		}
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Fatalf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
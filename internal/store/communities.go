@@ -0,0 +1,106 @@
+package store
+
+import (
+	"strings"
+	"time"
+)
+
+// Community is a community parent group with a summary of its linked groups.
+type Community struct {
+	Group
+	GroupCount      int    // linked groups, including the announcement group
+	AnnouncementJID string // the announcement group, if known
+}
+
+// SetGroupCommunity marks whether a known group is a community parent.
+func (d *DB) SetGroupCommunity(jid string, isCommunity bool) error {
+	_, err := d.exec(`UPDATE groups SET is_community = ?, updated_at = ? WHERE jid = ?`,
+		boolToInt(isCommunity), unix(time.Now().UTC()), jid)
+	return err
+}
+
+// LinkGroup records the community a known group belongs to; an empty
+// communityJID unlinks it.
+func (d *DB) LinkGroup(jid, communityJID string, isAnnouncementGroup bool) error {
+	if communityJID == "" {
+		isAnnouncementGroup = false
+	}
+	_, err := d.exec(`UPDATE groups SET community_jid = ?, is_announcement_group = ?, updated_at = ? WHERE jid = ?`,
+		communityJID, boolToInt(isAnnouncementGroup), unix(time.Now().UTC()), jid)
+	return err
+}
+
+// UnlinkGroupsExcept unlinks every group of a community not in keep.
+func (d *DB) UnlinkGroupsExcept(communityJID string, keep []string) error {
+	q := `UPDATE groups SET community_jid = '', is_announcement_group = 0, updated_at = ? WHERE community_jid = ?`
+	args := []interface{}{unix(time.Now().UTC()), communityJID}
+	if len(keep) > 0 {
+		q += ` AND jid NOT IN (` + strings.TrimSuffix(strings.Repeat("?,", len(keep)), ",") + `)`
+		for _, jid := range keep {
+			args = append(args, jid)
+		}
+	}
+	_, err := d.exec(q, args...)
+	return err
+}
+
+// ListCommunities returns community parent groups matching query by name or
+// JID, by name.
+func (d *DB) ListCommunities(query string, limit int) ([]Community, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	q := `SELECT ` + groupColumns + `,
+		(SELECT COUNT(*) FROM groups s WHERE s.community_jid = groups.jid),
+		COALESCE((SELECT MIN(s.jid) FROM groups s WHERE s.community_jid = groups.jid AND s.is_announcement_group = 1), '')
+		FROM groups WHERE is_community = 1`
+	var args []interface{}
+	if strings.TrimSpace(query) != "" {
+		needle := "%" + query + "%"
+		q += ` AND (LOWER(name) LIKE LOWER(?) OR LOWER(jid) LIKE LOWER(?))`
+		args = append(args, needle, needle)
+	}
+	q += ` ORDER BY LOWER(COALESCE(name,'')), jid LIMIT ?`
+	args = append(args, limit)
+	rows, err := d.query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Community
+	for rows.Next() {
+		var c Community
+		var created, updated int64
+		var community, announcement int
+		if err := rows.Scan(&c.JID, &c.Name, &c.OwnerJID, &c.Description, &created, &updated,
+			&community, &c.CommunityJID, &announcement, &c.GroupCount, &c.AnnouncementJID); err != nil {
+			return nil, err
+		}
+		c.CreatedAt = fromUnix(created)
+		c.UpdatedAt = fromUnix(updated)
+		c.IsCommunity = community != 0
+		c.IsAnnouncementGroup = announcement != 0
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// ListCommunityGroups returns the groups linked to a community, the
+// announcement group first, then by name.
+func (d *DB) ListCommunityGroups(communityJID string) ([]Group, error) {
+	rows, err := d.query(`SELECT `+groupColumns+` FROM groups WHERE community_jid = ?
+		ORDER BY is_announcement_group DESC, LOWER(COALESCE(name,'')), jid`, communityJID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Group
+	for rows.Next() {
+		g, err := scanGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
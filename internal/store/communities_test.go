@@ -0,0 +1,58 @@
+package store
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestCommunities(t *testing.T) {
+	db := openTestDB(t)
+	created := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	parent, announce, sub, other := "100@g.us", "101@g.us", "102@g.us", "200@g.us"
+
+	for jid, name := range map[string]string{parent: "Neighbours", announce: "Neighbours", sub: "Events", other: "Family"} {
+		if err := db.UpsertGroup(jid, name, "", created); err != nil {
+			t.Fatalf("UpsertGroup: %v", err)
+		}
+	}
+	if err := db.SetGroupCommunity(parent, true); err != nil {
+		t.Fatalf("SetGroupCommunity: %v", err)
+	}
+	if err := db.LinkGroup(sub, parent, false); err != nil {
+		t.Fatalf("LinkGroup: %v", err)
+	}
+	if err := db.LinkGroup(announce, parent, true); err != nil {
+		t.Fatalf("LinkGroup: %v", err)
+	}
+
+	cs, err := db.ListCommunities("", 10)
+	if err != nil || len(cs) != 1 {
+		t.Fatalf("ListCommunities = %+v, %v", cs, err)
+	}
+	if c := cs[0]; c.JID != parent || !c.IsCommunity || c.GroupCount != 2 || c.AnnouncementJID != announce {
+		t.Fatalf("unexpected community %+v", c)
+	}
+
+	gs, err := db.ListCommunityGroups(parent)
+	if err != nil || len(gs) != 2 {
+		t.Fatalf("ListCommunityGroups = %+v, %v", gs, err)
+	}
+	if gs[0].JID != announce || !gs[0].IsAnnouncementGroup || gs[1].JID != sub || gs[1].CommunityJID != parent {
+		t.Fatalf("expected announcement group first, got %+v", gs)
+	}
+
+	if err := db.UnlinkGroupsExcept(parent, []string{announce}); err != nil {
+		t.Fatalf("UnlinkGroupsExcept: %v", err)
+	}
+	g, err := db.GetGroup(sub)
+	if err != nil || g.CommunityJID != "" || g.IsAnnouncementGroup {
+		t.Fatalf("expected %s unlinked, got %+v, %v", sub, g, err)
+	}
+	if g, _ := db.GetGroup(announce); g.CommunityJID != parent {
+		t.Fatalf("expected %s still linked, got %+v", announce, g)
+	}
+	if _, err := db.GetGroup("999@g.us"); err != sql.ErrNoRows {
+		t.Fatalf("expected ErrNoRows, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package store
+
+import (
+	"strings"
+	"time"
+)
+
+// ContactImport is a contact to merge into the local address book.
+type ContactImport struct {
+	JID   string
+	Phone string
+	Name  string   // stored as the full name; empty keeps the known one
+	Alias string   // empty keeps the current alias
+	Tags  []string // added to the current tags
+}
+
+// ImportContacts upserts contacts with their aliases and tags in one
+// transaction.
+func (d *DB) ImportContacts(contacts []ContactImport) (err error) {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	now := unix(time.Now().UTC())
+	for _, c := range contacts {
+		if _, err = tx.Exec(d.rebind(`
+			INSERT INTO contacts(jid, phone, full_name, updated_at)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT(jid) DO UPDATE SET
+				phone=COALESCE(NULLIF(excluded.phone,''), contacts.phone),
+				full_name=COALESCE(NULLIF(excluded.full_name,''), contacts.full_name),
+				updated_at=excluded.updated_at
+		`), c.JID, c.Phone, strings.TrimSpace(c.Name), now); err != nil {
+			return err
+		}
+		if alias := strings.TrimSpace(c.Alias); alias != "" {
+			if _, err = tx.Exec(d.rebind(`
+				INSERT INTO contact_aliases(jid, alias, notes, updated_at)
+				VALUES (?, ?, NULL, ?)
+				ON CONFLICT(jid) DO UPDATE SET alias=excluded.alias, updated_at=excluded.updated_at
+			`), c.JID, alias, now); err != nil {
+				return err
+			}
+		}
+		for _, tag := range c.Tags {
+			if tag = strings.TrimSpace(tag); tag == "" {
+				continue
+			}
+			if _, err = tx.Exec(d.rebind(`
+				INSERT INTO contact_tags(jid, tag, updated_at) VALUES(?, ?, ?)
+				ON CONFLICT(jid, tag) DO UPDATE SET updated_at=excluded.updated_at
+			`), c.JID, tag, now); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// ListAllContacts returns every contact with its alias and tags, by JID.
+func (d *DB) ListAllContacts() ([]Contact, error) {
+	tags, err := d.allTags()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := d.query(`
+		SELECT c.jid,
+		       COALESCE(c.phone,''),
+		       COALESCE(NULLIF(a.alias,''), ''),
+		       COALESCE(NULLIF(c.full_name,''), NULLIF(c.push_name,''), NULLIF(c.business_name,''), NULLIF(c.first_name,''), ''),
+		       c.updated_at
+		FROM contacts c
+		LEFT JOIN contact_aliases a ON a.jid = c.jid
+		ORDER BY c.jid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Contact
+	for rows.Next() {
+		var c Contact
+		var updated int64
+		if err := rows.Scan(&c.JID, &c.Phone, &c.Alias, &c.Name, &updated); err != nil {
+			return nil, err
+		}
+		c.UpdatedAt = fromUnix(updated)
+		c.Tags = tags[c.JID]
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// allTags returns the tags of every contact, keyed by JID.
+func (d *DB) allTags() (map[string][]string, error) {
+	rows, err := d.query(`SELECT jid, tag FROM contact_tags ORDER BY jid, tag`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string][]string)
+	for rows.Next() {
+		var jid, tag string
+		if err := rows.Scan(&jid, &tag); err != nil {
+			return nil, err
+		}
+		out[jid] = append(out[jid], tag)
+	}
+	return out, rows.Err()
+}
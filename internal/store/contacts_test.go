@@ -0,0 +1,42 @@
+package store
+
+import "testing"
+
+func TestImportAndListAllContacts(t *testing.T) {
+	db := openTestDB(t)
+	alice, bob := "111@s.whatsapp.net", "222@s.whatsapp.net"
+
+	if err := db.UpsertContact(alice, "111", "Ali", "Alice A", "", ""); err != nil {
+		t.Fatalf("UpsertContact: %v", err)
+	}
+	if err := db.AddTag(alice, "vip"); err != nil {
+		t.Fatalf("AddTag: %v", err)
+	}
+	err := db.ImportContacts([]ContactImport{
+		{JID: alice, Phone: "111", Alias: "Boss", Tags: []string{"customer", " "}},
+		{JID: bob, Phone: "222", Name: "Bob", Tags: []string{"lead"}},
+	})
+	if err != nil {
+		t.Fatalf("ImportContacts: %v", err)
+	}
+
+	cs, err := db.ListAllContacts()
+	if err != nil || len(cs) != 2 {
+		t.Fatalf("ListAllContacts = %+v, %v", cs, err)
+	}
+	a, b := cs[0], cs[1]
+	if a.JID != alice || a.Name != "Alice A" || a.Alias != "Boss" || len(a.Tags) != 2 || a.Tags[0] != "customer" || a.Tags[1] != "vip" {
+		t.Fatalf("unexpected alice: %+v", a)
+	}
+	if b.JID != bob || b.Name != "Bob" || b.Phone != "222" || b.Alias != "" || len(b.Tags) != 1 {
+		t.Fatalf("unexpected bob: %+v", b)
+	}
+
+	// Re-importing keeps the known name and does not duplicate tags.
+	if err := db.ImportContacts([]ContactImport{{JID: bob, Tags: []string{"lead"}}}); err != nil {
+		t.Fatalf("ImportContacts: %v", err)
+	}
+	if got, _ := db.GetContact(bob); got.Name != "Bob" || got.Phone != "222" || len(got.Tags) != 1 {
+		t.Fatalf("unexpected bob after re-import: %+v", got)
+	}
+}
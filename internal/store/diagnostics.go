@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// SchemaInfo describes the database for diagnostics. The schema has no
+// version number of its own; Migrations counts the column migrations that
+// are applied, out of KnownMigrations this build knows about.
+type SchemaInfo struct {
+	Backend         string   `json:"backend"`
+	EngineVersion   string   `json:"engine_version"`
+	Path            string   `json:"path,omitempty"`
+	SizeBytes       int64    `json:"size_bytes,omitempty"`
+	Tables          []string `json:"tables"`
+	Migrations      int      `json:"migrations"`
+	KnownMigrations int      `json:"known_migrations"`
+}
+
+// SchemaInfo reports the database engine, tables and applied migrations.
+func (d *DB) SchemaInfo() (SchemaInfo, error) {
+	info := SchemaInfo{Backend: d.Backend(), Path: d.path, KnownMigrations: len(columns)}
+	if d.path != "" {
+		if st, err := os.Stat(d.path); err == nil {
+			info.SizeBytes = st.Size()
+		}
+	}
+
+	versionQuery := `SELECT sqlite_version()`
+	tablesQuery := `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`
+	if d.postgres {
+		versionQuery = `SELECT version()`
+		tablesQuery = `SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() ORDER BY table_name`
+	}
+	if err := d.sql.QueryRow(versionQuery).Scan(&info.EngineVersion); err != nil {
+		return info, fmt.Errorf("engine version: %w", err)
+	}
+
+	rows, err := d.sql.Query(tablesQuery)
+	if err != nil {
+		return info, fmt.Errorf("list tables: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return info, err
+		}
+		info.Tables = append(info.Tables, name)
+	}
+	if err := rows.Err(); err != nil {
+		return info, err
+	}
+
+	for _, c := range columns {
+		var exists bool
+		if d.postgres {
+			var n int
+			err = d.queryRow(`SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?`, c.table, c.column).Scan(&n)
+			exists = n > 0
+		} else {
+			exists, err = d.hasColumn(c.table, c.column)
+		}
+		if err != nil {
+			return info, err
+		}
+		if exists {
+			info.Migrations++
+		}
+	}
+	return info, nil
+}
+
+// Ping checks that the database answers a query.
+func (d *DB) Ping(ctx context.Context) error {
+	var one int
+	return d.sql.QueryRowContext(ctx, `SELECT 1`).Scan(&one)
+}
+
+// IntegrityCheck runs SQLite's integrity check and returns the problems it
+// found, or none if the database is intact. Postgres has no equivalent, so
+// there it only checks that the database answers.
+func (d *DB) IntegrityCheck() ([]string, error) {
+	if d.postgres {
+		return nil, d.sql.Ping()
+	}
+	rows, err := d.sql.Query(`PRAGMA integrity_check`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	return problems, rows.Err()
+}
@@ -0,0 +1,38 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSchemaInfoAndIntegrityCheck(t *testing.T) {
+	db := openTestDB(t)
+
+	info, err := db.SchemaInfo()
+	if err != nil {
+		t.Fatalf("SchemaInfo: %v", err)
+	}
+	if info.Backend != "sqlite" || !strings.HasPrefix(info.EngineVersion, "3.") || info.SizeBytes == 0 {
+		t.Fatalf("info = %+v", info)
+	}
+	if info.Migrations != info.KnownMigrations || info.KnownMigrations == 0 {
+		t.Fatalf("migrations = %d of %d", info.Migrations, info.KnownMigrations)
+	}
+	if !strings.Contains(strings.Join(info.Tables, ","), "messages") {
+		t.Fatalf("tables = %v", info.Tables)
+	}
+
+	problems, err := db.IntegrityCheck()
+	if err != nil || len(problems) != 0 {
+		t.Fatalf("IntegrityCheck = %v, %v", problems, err)
+	}
+
+	if err := db.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	_ = db.Close()
+	if err := db.Ping(context.Background()); err == nil {
+		t.Fatalf("Ping after Close succeeded")
+	}
+}
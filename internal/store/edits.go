@@ -0,0 +1,61 @@
+package store
+
+import "time"
+
+// EditMessage records the sender's edit of a stored message; the original
+// text is kept. Edits older than the one already stored are ignored. It
+// reports false if the message is not stored.
+func (d *DB) EditMessage(chatJID, msgID, text string, at time.Time) (bool, error) {
+	res, err := d.exec(`
+		UPDATE messages SET edited_text = ?, edited_at = ?
+		WHERE chat_jid = ? AND msg_id = ? AND edited_at <= ?
+	`, text, unix(at), chatJID, msgID, unix(at))
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return n > 0, err
+	}
+	return d.messageExists(chatJID, msgID)
+}
+
+// RevokeMessage marks a stored message as deleted for everyone. It reports
+// false if the message is not stored.
+func (d *DB) RevokeMessage(chatJID, msgID string) (bool, error) {
+	res, err := d.exec(`UPDATE messages SET revoked = 1 WHERE chat_jid = ? AND msg_id = ?`, chatJID, msgID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteMessage removes a stored message with its star and receipts. It
+// reports false if the message is not stored.
+func (d *DB) DeleteMessage(chatJID, msgID string) (bool, error) {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(d.rebind(`DELETE FROM messages WHERE chat_jid = ? AND msg_id = ?`), chatJID, msgID)
+	if err != nil {
+		return false, err
+	}
+	for _, table := range []string{"starred_messages", "message_receipts"} {
+		if _, err := tx.Exec(d.rebind(`DELETE FROM `+table+` WHERE chat_jid = ? AND msg_id = ?`), chatJID, msgID); err != nil {
+			return false, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (d *DB) messageExists(chatJID, msgID string) (bool, error) {
+	var n int
+	err := d.queryRow(`SELECT COUNT(1) FROM messages WHERE chat_jid = ? AND msg_id = ?`, chatJID, msgID).Scan(&n)
+	return n > 0, err
+}
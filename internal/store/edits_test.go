@@ -0,0 +1,47 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEditAndRevokeMessage(t *testing.T) {
+	db := openTestDB(t)
+	chat := "123@s.whatsapp.net"
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	if err := db.UpsertChat(chat, "dm", "Alice", base); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	if err := db.UpsertMessage(UpsertMessageParams{ChatJID: chat, MsgID: "m1", Timestamp: base, Text: "helo"}); err != nil {
+		t.Fatalf("UpsertMessage: %v", err)
+	}
+
+	if ok, err := db.EditMessage(chat, "m1", "hello", base.Add(2*time.Minute)); err != nil || !ok {
+		t.Fatalf("EditMessage = %v, %v", ok, err)
+	}
+	// An older edit arriving late does not win.
+	if ok, err := db.EditMessage(chat, "m1", "hel", base.Add(time.Minute)); err != nil || !ok {
+		t.Fatalf("EditMessage (older) = %v, %v", ok, err)
+	}
+	m, err := db.GetMessage(chat, "m1")
+	if err != nil {
+		t.Fatalf("GetMessage: %v", err)
+	}
+	if m.Text != "helo" || m.EditedText != "hello" || !m.EditedAt.Equal(base.Add(2*time.Minute)) || m.Revoked {
+		t.Fatalf("after edit: %+v", m)
+	}
+
+	if ok, err := db.RevokeMessage(chat, "m1"); err != nil || !ok {
+		t.Fatalf("RevokeMessage = %v, %v", ok, err)
+	}
+	if m, _ := db.GetMessage(chat, "m1"); !m.Revoked {
+		t.Fatalf("not revoked: %+v", m)
+	}
+
+	if ok, err := db.EditMessage(chat, "missing", "x", base); err != nil || ok {
+		t.Fatalf("EditMessage(missing) = %v, %v", ok, err)
+	}
+	if ok, err := db.RevokeMessage(chat, "missing"); err != nil || ok {
+		t.Fatalf("RevokeMessage(missing) = %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,53 @@
+package store
+
+import "time"
+
+// ExportedMessage is a message as written to a chat export, with the media
+// details needed to include its attachment.
+type ExportedMessage struct {
+	MsgID      string
+	SenderJID  string
+	SenderName string
+	Timestamp  time.Time
+	FromMe     bool
+	Text       string
+	MediaType  string
+	Caption    string
+	Filename   string
+	MimeType   string
+	LocalPath  string // empty unless the media was downloaded
+}
+
+// ExportChat calls fn for every message of a chat, oldest first. Messages
+// are streamed, so chats of any size can be exported; an error from fn
+// stops the export and is returned.
+func (d *DB) ExportChat(chatJID string, fn func(ExportedMessage) error) error {
+	rows, err := d.query(`
+		SELECT msg_id, COALESCE(sender_jid,''), COALESCE(sender_name,''), ts, from_me, COALESCE(text,''),
+		       COALESCE(media_type,''), COALESCE(media_caption,''), COALESCE(filename,''), COALESCE(mime_type,''),
+		       COALESCE(local_path,'')
+		FROM messages
+		WHERE chat_jid = ?
+		ORDER BY ts, rowid
+	`, chatJID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m ExportedMessage
+		var ts int64
+		var fromMe int
+		if err := rows.Scan(&m.MsgID, &m.SenderJID, &m.SenderName, &ts, &fromMe, &m.Text,
+			&m.MediaType, &m.Caption, &m.Filename, &m.MimeType, &m.LocalPath); err != nil {
+			return err
+		}
+		m.Timestamp = fromUnix(ts)
+		m.FromMe = fromMe != 0
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExportChat(t *testing.T) {
+	db := openTestDB(t)
+
+	chat := "123@s.whatsapp.net"
+	other := "456@s.whatsapp.net"
+	base := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	for _, jid := range []string{chat, other} {
+		if err := db.UpsertChat(jid, "dm", "", base); err != nil {
+			t.Fatalf("UpsertChat: %v", err)
+		}
+	}
+	msgs := []UpsertMessageParams{
+		{ChatJID: chat, MsgID: "m2", SenderJID: chat, SenderName: "Alice", Timestamp: base.Add(time.Minute), MediaType: "image", MediaCaption: "look", MimeType: "image/jpeg"},
+		{ChatJID: chat, MsgID: "m1", Timestamp: base, FromMe: true, Text: "hi"},
+		{ChatJID: other, MsgID: "x", Timestamp: base, Text: "elsewhere"},
+	}
+	for _, p := range msgs {
+		if err := db.UpsertMessage(p); err != nil {
+			t.Fatalf("UpsertMessage %s: %v", p.MsgID, err)
+		}
+	}
+	if err := db.MarkMediaDownloaded(chat, "m2", "/media/m2.jpg", base); err != nil {
+		t.Fatalf("MarkMediaDownloaded: %v", err)
+	}
+
+	var got []ExportedMessage
+	if err := db.ExportChat(chat, func(m ExportedMessage) error {
+		got = append(got, m)
+		return nil
+	}); err != nil {
+		t.Fatalf("ExportChat: %v", err)
+	}
+	if len(got) != 2 || got[0].MsgID != "m1" || got[1].MsgID != "m2" {
+		t.Fatalf("expected m1, m2 oldest first, got %+v", got)
+	}
+	if !got[0].FromMe || got[0].Text != "hi" || !got[0].Timestamp.Equal(base) {
+		t.Fatalf("unexpected first message: %+v", got[0])
+	}
+	m := got[1]
+	if m.SenderName != "Alice" || m.MediaType != "image" || m.Caption != "look" || m.MimeType != "image/jpeg" || m.LocalPath != "/media/m2.jpg" {
+		t.Fatalf("unexpected media message: %+v", m)
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err := db.ExportChat(chat, func(ExportedMessage) error {
+		n++
+		return stop
+	})
+	if !errors.Is(err, stop) || n != 1 {
+		t.Fatalf("expected the callback error after one message, got %v after %d", err, n)
+	}
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"strings"
+	"time"
+)
+
+// Kinds of failed sends.
+const (
+	FailedSendText = "text"
+	FailedSendFile = "file"
+)
+
+// FailedSend is a message that could not be sent, with what is needed to
+// send it again.
+type FailedSend struct {
+	ID        int64
+	ChatJID   string
+	Kind      string
+	Text      string // message text, or the caption of a file
+	Filename  string
+	MimeType  string
+	Size      int64
+	Error     string // last send error
+	Attempts  int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+const failedSendColumns = `id, chat_jid, kind, text, filename, mime_type, size, error, attempts, created_at, updated_at`
+
+// AddFailedSend records a failed send and returns its id.
+func (d *DB) AddFailedSend(f FailedSend) (int64, error) {
+	now := unix(time.Now().UTC())
+	return d.insertID(d.sql, `
+		INSERT INTO failed_sends(chat_jid, kind, text, filename, mime_type, size, error, created_at, updated_at)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, f.ChatJID, f.Kind, f.Text, f.Filename, f.MimeType, f.Size, f.Error, now, now)
+}
+
+// GetFailedSend returns a failed send by id, or sql.ErrNoRows.
+func (d *DB) GetFailedSend(id int64) (FailedSend, error) {
+	return scanFailedSend(d.queryRow(`SELECT `+failedSendColumns+` FROM failed_sends WHERE id = ?`, id))
+}
+
+// ListFailedSends returns failed sends to a chat (any if empty), most
+// recent first.
+func (d *DB) ListFailedSends(chatJID string, limit int) ([]FailedSend, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	query := `SELECT ` + failedSendColumns + ` FROM failed_sends WHERE 1=1`
+	var args []interface{}
+	if strings.TrimSpace(chatJID) != "" {
+		query += " AND chat_jid = ?"
+		args = append(args, chatJID)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := d.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []FailedSend
+	for rows.Next() {
+		f, err := scanFailedSend(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+// FailedSendRetryFailed records another failed attempt at a failed send.
+func (d *DB) FailedSendRetryFailed(id int64, errText string) error {
+	_, err := d.exec(`UPDATE failed_sends SET error = ?, attempts = attempts + 1, updated_at = ? WHERE id = ?`,
+		errText, unix(time.Now().UTC()), id)
+	return err
+}
+
+// DeleteFailedSend removes a failed send once it was retried successfully or
+// given up on. It reports false if there was none.
+func (d *DB) DeleteFailedSend(id int64) (bool, error) {
+	res, err := d.exec(`DELETE FROM failed_sends WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func scanFailedSend(row rowScanner) (FailedSend, error) {
+	var f FailedSend
+	var created, updated int64
+	if err := row.Scan(&f.ID, &f.ChatJID, &f.Kind, &f.Text, &f.Filename, &f.MimeType, &f.Size, &f.Error, &f.Attempts, &created, &updated); err != nil {
+		return FailedSend{}, err
+	}
+	f.CreatedAt = fromUnix(created)
+	f.UpdatedAt = fromUnix(updated)
+	return f, nil
+}
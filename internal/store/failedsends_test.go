@@ -0,0 +1,49 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestFailedSends(t *testing.T) {
+	db := openTestDB(t)
+	chat := "123@s.whatsapp.net"
+
+	textID, err := db.AddFailedSend(FailedSend{ChatJID: chat, Kind: FailedSendText, Text: "hello", Error: "send failed: timeout"})
+	if err != nil {
+		t.Fatalf("AddFailedSend: %v", err)
+	}
+	fileID, err := db.AddFailedSend(FailedSend{ChatJID: "456@s.whatsapp.net", Kind: FailedSendFile, Text: "caption",
+		Filename: "a.jpg", MimeType: "image/jpeg", Size: 1234, Error: "upload failed: EOF"})
+	if err != nil {
+		t.Fatalf("AddFailedSend: %v", err)
+	}
+
+	all, err := db.ListFailedSends("", 0)
+	if err != nil || len(all) != 2 || all[0].ID != fileID || all[1].ID != textID {
+		t.Fatalf("ListFailedSends = %+v, %v", all, err)
+	}
+	mine, err := db.ListFailedSends(chat, 10)
+	if err != nil || len(mine) != 1 || mine[0].Text != "hello" || mine[0].Attempts != 1 {
+		t.Fatalf("ListFailedSends(chat) = %+v, %v", mine, err)
+	}
+
+	if err := db.FailedSendRetryFailed(fileID, "upload failed: reset"); err != nil {
+		t.Fatalf("FailedSendRetryFailed: %v", err)
+	}
+	f, err := db.GetFailedSend(fileID)
+	if err != nil || f.Attempts != 2 || f.Error != "upload failed: reset" || f.Size != 1234 || f.Kind != FailedSendFile {
+		t.Fatalf("GetFailedSend = %+v, %v", f, err)
+	}
+
+	if ok, err := db.DeleteFailedSend(fileID); err != nil || !ok {
+		t.Fatalf("DeleteFailedSend = %v, %v", ok, err)
+	}
+	if ok, err := db.DeleteFailedSend(fileID); err != nil || ok {
+		t.Fatalf("DeleteFailedSend again = %v, %v", ok, err)
+	}
+	if _, err := db.GetFailedSend(fileID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetFailedSend after delete: %v", err)
+	}
+}
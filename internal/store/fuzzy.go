@@ -0,0 +1,114 @@
+package store
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// minTrigramSimilarity is how similar (0..1) a query must be to a word of a
+// name to match it without being a substring, as pg_trgm's default.
+const minTrigramSimilarity = 0.3
+
+// transliterations covers letters that do not decompose into a base letter
+// and a combining mark.
+var transliterations = strings.NewReplacer(
+	"ß", "ss", "æ", "ae", "œ", "oe", "ø", "o", "ł", "l", "đ", "d", "ð", "d", "þ", "th", "ı", "i",
+)
+
+// foldText lowercases s, strips accents and transliterates, so "João" and
+// "joao" compare equal. Runs of spaces and punctuation become one space.
+func foldText(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, strings.ToLower(s))
+	if err != nil {
+		folded = strings.ToLower(s)
+	}
+	folded = transliterations.Replace(folded)
+	return strings.Join(strings.FieldsFunc(folded, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// phoneDigits returns the digits of s if it looks like (part of) a phone
+// number: only digits, spaces and + - ( ) . separators, and at least three
+// digits. Otherwise it returns "".
+func phoneDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case strings.ContainsRune(" +-().", r):
+		default:
+			return ""
+		}
+	}
+	if b.Len() < 3 {
+		return ""
+	}
+	return b.String()
+}
+
+// digitsOf returns only the digits of s.
+func digitsOf(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// trigrams returns the set of trigrams of a word, padded like pg_trgm.
+func trigrams(word string) map[string]bool {
+	r := []rune("  " + word + " ")
+	out := make(map[string]bool, len(r))
+	for i := 0; i+3 <= len(r); i++ {
+		out[string(r[i:i+3])] = true
+	}
+	return out
+}
+
+// trigramSimilarity returns the Jaccard similarity of the trigrams of a and b.
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	union := len(ta) + len(tb) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// matchScore rates how well a folded query matches a folded name: 1 for a
+// substring (a bit more at the start of a word), otherwise the best trigram
+// similarity of the query to a word of the name if it reaches the
+// threshold, else 0.
+func matchScore(query, name string) float64 {
+	if query == "" || name == "" {
+		return 0
+	}
+	if i := strings.Index(name, query); i >= 0 {
+		if i == 0 || name[i-1] == ' ' {
+			return 1.1
+		}
+		return 1
+	}
+	best := trigramSimilarity(query, name)
+	for _, word := range strings.Fields(name) {
+		best = max(best, trigramSimilarity(query, word))
+	}
+	if best < minTrigramSimilarity {
+		return 0
+	}
+	return best
+}
@@ -0,0 +1,78 @@
+package store
+
+import "testing"
+
+func TestFoldText(t *testing.T) {
+	for in, want := range map[string]string{
+		"João":                   "joao",
+		"  Müller-Lüdenscheidt ": "muller ludenscheidt",
+		"Straße":                 "strasse",
+		"Søren Łukasz":           "soren lukasz",
+		"ÇAĞLAR":                 "caglar",
+	} {
+		if got := foldText(in); got != want {
+			t.Errorf("foldText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPhoneDigits(t *testing.T) {
+	for in, want := range map[string]string{
+		"+49 151-234 567": "49151234567",
+		"(0151) 23.45":    "01512345",
+		"49":              "",
+		"joao 4915":       "",
+	} {
+		if got := phoneDigits(in); got != want {
+			t.Errorf("phoneDigits(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSearchContactsFuzzy(t *testing.T) {
+	db := openTestDB(t)
+	contacts := []struct{ jid, phone, name string }{
+		{"4915123456789@s.whatsapp.net", "+49 151 2345-6789", "João Silva"},
+		{"15550001111@s.whatsapp.net", "", "Jonathan Miller"},
+		{"15550002222@s.whatsapp.net", "", "Zoë Brooks"},
+	}
+	for _, c := range contacts {
+		if err := db.UpsertContact(c.jid, c.phone, "", c.name, "", ""); err != nil {
+			t.Fatalf("UpsertContact: %v", err)
+		}
+	}
+
+	search := func(q string) []string {
+		t.Helper()
+		found, err := db.SearchContacts(q, 10)
+		if err != nil {
+			t.Fatalf("SearchContacts(%q): %v", q, err)
+		}
+		var jids []string
+		for _, c := range found {
+			jids = append(jids, c.JID)
+		}
+		return jids
+	}
+
+	if got := search("joao"); len(got) != 1 || got[0] != contacts[0].jid {
+		t.Fatalf("joao = %v", got)
+	}
+	if got := search("zoe"); len(got) != 1 || got[0] != contacts[2].jid {
+		t.Fatalf("zoe = %v", got)
+	}
+	if got := search("+49 151 2345"); len(got) != 1 || got[0] != contacts[0].jid {
+		t.Fatalf("phone = %v", got)
+	}
+	// A typo still finds the name by trigram similarity.
+	if got := search("jonathon"); len(got) != 1 || got[0] != contacts[1].jid {
+		t.Fatalf("jonathon = %v", got)
+	}
+	// Substring matches rank above loose ones.
+	if got := search("jo"); len(got) != 2 || got[0] != contacts[0].jid {
+		t.Fatalf("jo = %v", got)
+	}
+	if got := search("xyzzy"); len(got) != 0 {
+		t.Fatalf("xyzzy = %v", got)
+	}
+}
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Label tags chats. Synced labels mirror WhatsApp Business labels; the others
+// only exist in this store.
+type Label struct {
+	ID        string
+	Name      string
+	Color     int32
+	Synced    bool
+	ChatCount int64
+	UpdatedAt time.Time
+}
+
+// UpsertLabel creates or updates a label by id.
+func (d *DB) UpsertLabel(l Label) error {
+	if l.UpdatedAt.IsZero() {
+		l.UpdatedAt = time.Now().UTC()
+	}
+	_, err := d.exec(`
+		INSERT INTO labels(id, name, color, synced, updated_at) VALUES(?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name=excluded.name,
+			color=excluded.color,
+			synced=excluded.synced,
+			updated_at=excluded.updated_at
+	`, l.ID, l.Name, l.Color, boolToInt(l.Synced), unix(l.UpdatedAt))
+	return err
+}
+
+// DeleteLabel removes a label and its chat assignments. It returns
+// sql.ErrNoRows if the label does not exist.
+func (d *DB) DeleteLabel(id string) error {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(d.rebind(`DELETE FROM labels WHERE id = ?`), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	if _, err := tx.Exec(d.rebind(`DELETE FROM chat_labels WHERE label_id = ?`), id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListLabels returns all labels by name, with the number of labeled chats.
+func (d *DB) ListLabels() ([]Label, error) {
+	rows, err := d.query(`
+		SELECT l.id, l.name, l.color, l.synced, l.updated_at,
+		       (SELECT COUNT(1) FROM chat_labels cl WHERE cl.label_id = l.id)
+		FROM labels l
+		ORDER BY LOWER(l.name), l.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Label
+	for rows.Next() {
+		l, err := scanLabel(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+// FindLabel looks a label up by id, or else by case-insensitive name. It
+// returns sql.ErrNoRows if neither matches.
+func (d *DB) FindLabel(idOrName string) (Label, error) {
+	return scanLabel(d.queryRow(`
+		SELECT l.id, l.name, l.color, l.synced, l.updated_at,
+		       (SELECT COUNT(1) FROM chat_labels cl WHERE cl.label_id = l.id)
+		FROM labels l
+		WHERE l.id = ? OR LOWER(l.name) = LOWER(?)
+		ORDER BY CASE WHEN l.id = ? THEN 0 ELSE 1 END, l.id
+		LIMIT 1
+	`, idOrName, idOrName, idOrName))
+}
+
+func scanLabel(row rowScanner) (Label, error) {
+	var l Label
+	var synced int
+	var updated int64
+	if err := row.Scan(&l.ID, &l.Name, &l.Color, &synced, &updated, &l.ChatCount); err != nil {
+		return Label{}, err
+	}
+	l.Synced = synced != 0
+	l.UpdatedAt = fromUnix(updated)
+	return l, nil
+}
+
+// SetChatLabel adds or removes a label from a chat.
+func (d *DB) SetChatLabel(chatJID, labelID string, labeled bool) error {
+	if !labeled {
+		_, err := d.exec(`DELETE FROM chat_labels WHERE chat_jid = ? AND label_id = ?`, chatJID, labelID)
+		return err
+	}
+	_, err := d.exec(`
+		INSERT INTO chat_labels(chat_jid, label_id) VALUES(?, ?)
+		ON CONFLICT(chat_jid, label_id) DO NOTHING
+	`, chatJID, labelID)
+	return err
+}
+
+// fillChatLabels sets Labels on each chat in one query.
+func (d *DB) fillChatLabels(chats []Chat) error {
+	if len(chats) == 0 {
+		return nil
+	}
+	index := make(map[string]int, len(chats))
+	placeholders := make([]string, len(chats))
+	args := make([]interface{}, len(chats))
+	for i, c := range chats {
+		index[c.JID] = i
+		placeholders[i] = "?"
+		args[i] = c.JID
+	}
+	rows, err := d.query(`
+		SELECT cl.chat_jid, l.name
+		FROM chat_labels cl
+		JOIN labels l ON l.id = cl.label_id
+		WHERE cl.chat_jid IN (`+strings.Join(placeholders, ",")+`)
+		ORDER BY LOWER(l.name)
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var chat, name string
+		if err := rows.Scan(&chat, &name); err != nil {
+			return err
+		}
+		if i, ok := index[chat]; ok {
+			chats[i].Labels = append(chats[i].Labels, name)
+		}
+	}
+	return rows.Err()
+}
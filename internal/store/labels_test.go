@@ -0,0 +1,68 @@
+package store
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestLabels(t *testing.T) {
+	db := openTestDB(t)
+
+	now := time.Now()
+	for _, jid := range []string{"1@s.whatsapp.net", "2@s.whatsapp.net"} {
+		if err := db.UpsertChat(jid, "dm", jid, now); err != nil {
+			t.Fatalf("UpsertChat: %v", err)
+		}
+	}
+	if err := db.UpsertLabel(Label{ID: "1", Name: "New customer", Color: 3, Synced: true}); err != nil {
+		t.Fatalf("UpsertLabel: %v", err)
+	}
+	if err := db.UpsertLabel(Label{ID: "local-1", Name: "VIP"}); err != nil {
+		t.Fatalf("UpsertLabel: %v", err)
+	}
+
+	// An association may be synced before the label itself.
+	if err := db.SetChatLabel("1@s.whatsapp.net", "1", true); err != nil {
+		t.Fatalf("SetChatLabel: %v", err)
+	}
+	if err := db.SetChatLabel("1@s.whatsapp.net", "1", true); err != nil {
+		t.Fatalf("SetChatLabel twice: %v", err)
+	}
+	if err := db.SetChatLabel("2@s.whatsapp.net", "local-1", true); err != nil {
+		t.Fatalf("SetChatLabel: %v", err)
+	}
+
+	l, err := db.FindLabel("vip")
+	if err != nil || l.ID != "local-1" || l.Synced || l.ChatCount != 1 {
+		t.Fatalf("FindLabel by name = %+v, %v", l, err)
+	}
+	if l, err := db.FindLabel("1"); err != nil || l.Name != "New customer" || !l.Synced || l.Color != 3 {
+		t.Fatalf("FindLabel by id = %+v, %v", l, err)
+	}
+
+	chats, err := db.ListLabeledChats("local-1", "", 10)
+	if err != nil || len(chats) != 1 || chats[0].JID != "2@s.whatsapp.net" {
+		t.Fatalf("ListLabeledChats = %+v, %v", chats, err)
+	}
+	all, err := db.ListChats("", 10)
+	if err != nil {
+		t.Fatalf("ListChats: %v", err)
+	}
+	for _, c := range all {
+		want := map[string]string{"1@s.whatsapp.net": "New customer", "2@s.whatsapp.net": "VIP"}[c.JID]
+		if len(c.Labels) != 1 || c.Labels[0] != want {
+			t.Fatalf("unexpected labels on %s: %v", c.JID, c.Labels)
+		}
+	}
+
+	if err := db.DeleteLabel("local-1"); err != nil {
+		t.Fatalf("DeleteLabel: %v", err)
+	}
+	if err := db.DeleteLabel("local-1"); err != sql.ErrNoRows {
+		t.Fatalf("expected ErrNoRows, got %v", err)
+	}
+	if chats, _ := db.ListLabeledChats("local-1", "", 10); len(chats) != 0 {
+		t.Fatalf("expected assignments removed with the label, got %+v", chats)
+	}
+}
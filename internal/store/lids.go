@@ -0,0 +1,67 @@
+package store
+
+import (
+	"strings"
+	"time"
+)
+
+// LIDMapping pairs a LID (anonymous JID, <digits>@lid) with the phone-number
+// JID of the same user.
+type LIDMapping struct {
+	LID string
+	PN  string
+}
+
+// IsLID reports whether jid is a LID.
+func IsLID(jid string) bool {
+	return strings.HasSuffix(jid, "@lid")
+}
+
+// PutLIDMappings stores mappings, replacing older ones of the same LID or
+// phone number. Incomplete pairs are skipped.
+func (d *DB) PutLIDMappings(ms []LIDMapping) (err error) {
+	if len(ms) == 0 {
+		return nil
+	}
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	now := unix(time.Now().UTC())
+	for _, lm := range ms {
+		if lm.LID == "" || lm.PN == "" {
+			continue
+		}
+		if _, err = tx.Exec(d.rebind(`DELETE FROM lid_map WHERE pn = ? AND lid <> ?`), lm.PN, lm.LID); err != nil {
+			return err
+		}
+		if _, err = tx.Exec(d.rebind(`
+			INSERT INTO lid_map(lid, pn, updated_at) VALUES (?, ?, ?)
+			ON CONFLICT(lid) DO UPDATE SET pn=excluded.pn, updated_at=excluded.updated_at
+		`), lm.LID, lm.PN, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// LookupLID returns the mapping jid belongs to, given either its LID or its
+// phone-number JID, or sql.ErrNoRows if it is unknown.
+func (d *DB) LookupLID(jid string) (LIDMapping, error) {
+	var lm LIDMapping
+	err := d.queryRow(`SELECT lid, pn FROM lid_map WHERE lid = ? OR pn = ? LIMIT 1`, jid, jid).Scan(&lm.LID, &lm.PN)
+	return lm, err
+}
+
+// CountLIDMappings returns the number of known mappings.
+func (d *DB) CountLIDMappings() (int64, error) {
+	var n int64
+	err := d.queryRow(`SELECT COUNT(1) FROM lid_map`).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLIDMappings(t *testing.T) {
+	db := openTestDB(t)
+	group := "123-456@g.us"
+	lid, pn := "987@lid", "111@s.whatsapp.net"
+	if err := db.UpsertChat(group, "group", "Team", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+
+	if err := db.UpsertMessage(UpsertMessageParams{ChatJID: group, MsgID: "m1", SenderJID: lid, Timestamp: time.Now(), Text: "hi"}); err != nil {
+		t.Fatalf("UpsertMessage: %v", err)
+	}
+	m, err := db.GetMessage(group, "m1")
+	if err != nil || m.SenderLID != lid || m.SenderPN != "" {
+		t.Fatalf("before mapping: %+v, %v", m, err)
+	}
+
+	if err := db.PutLIDMappings([]LIDMapping{{LID: lid, PN: pn}, {LID: "555@lid"}}); err != nil {
+		t.Fatalf("PutLIDMappings: %v", err)
+	}
+	m, _ = db.GetMessage(group, "m1")
+	if m.SenderJID != lid || m.SenderLID != lid || m.SenderPN != pn {
+		t.Fatalf("expected sender resolved, got %+v", m)
+	}
+	if got, err := db.LookupLID(pn); err != nil || got.LID != lid {
+		t.Fatalf("LookupLID(pn) = %+v, %v", got, err)
+	}
+	if _, err := db.LookupLID("555@lid"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected incomplete mapping skipped, got %v", err)
+	}
+
+	// A new LID for the same number replaces the old one.
+	if err := db.PutLIDMappings([]LIDMapping{{LID: "654@lid", PN: pn}}); err != nil {
+		t.Fatalf("PutLIDMappings: %v", err)
+	}
+	if n, _ := db.CountLIDMappings(); n != 1 {
+		t.Fatalf("expected 1 mapping, got %d", n)
+	}
+	if got, _ := db.LookupLID(pn); got.LID != "654@lid" {
+		t.Fatalf("expected LID replaced, got %+v", got)
+	}
+
+	if err := db.UpsertMessage(UpsertMessageParams{ChatJID: group, MsgID: "m2", SenderJID: pn, Timestamp: time.Now(), Text: "yo"}); err != nil {
+		t.Fatalf("UpsertMessage: %v", err)
+	}
+	m, _ = db.GetMessage(group, "m2")
+	if m.SenderPN != pn || m.SenderLID != "654@lid" {
+		t.Fatalf("expected LID of phone sender, got %+v", m)
+	}
+}
@@ -0,0 +1,138 @@
+package store
+
+import (
+	"strings"
+	"time"
+)
+
+// Outbox statuses. Messages wait as queued, are sending while handed to
+// WhatsApp, and end as sent, failed or cancelled.
+const (
+	OutboxQueued    = "queued"
+	OutboxSending   = "sending"
+	OutboxSent      = "sent"
+	OutboxFailed    = "failed"
+	OutboxCancelled = "cancelled"
+)
+
+// OutboxMessage is a text message waiting to be sent, or the record of one.
+type OutboxMessage struct {
+	ID        int64
+	ChatJID   string
+	Text      string
+	Status    string
+	MsgID     string // set once sent
+	Error     string // last send error
+	Attempts  int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+const outboxColumns = `id, chat_jid, text, status, msg_id, error, attempts, created_at, updated_at`
+
+// EnqueueOutbox queues a text message and returns its id.
+func (d *DB) EnqueueOutbox(chatJID, text string) (int64, error) {
+	now := unix(time.Now().UTC())
+	return d.insertID(d.sql, `
+		INSERT INTO outbox(chat_jid, text, status, created_at, updated_at) VALUES(?, ?, ?, ?, ?)
+	`, chatJID, text, OutboxQueued, now, now)
+}
+
+// NextOutbox returns the oldest queued message, or sql.ErrNoRows.
+func (d *DB) NextOutbox() (OutboxMessage, error) {
+	return scanOutbox(d.queryRow(`SELECT `+outboxColumns+` FROM outbox WHERE status = ? ORDER BY id LIMIT 1`, OutboxQueued))
+}
+
+// GetOutbox returns an outbox message by id, or sql.ErrNoRows.
+func (d *DB) GetOutbox(id int64) (OutboxMessage, error) {
+	return scanOutbox(d.queryRow(`SELECT `+outboxColumns+` FROM outbox WHERE id = ?`, id))
+}
+
+// ListOutbox returns outbox messages with the given status (any if empty),
+// oldest first.
+func (d *DB) ListOutbox(status string, limit int) ([]OutboxMessage, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	query := `SELECT ` + outboxColumns + ` FROM outbox WHERE 1=1`
+	var args []interface{}
+	if strings.TrimSpace(status) != "" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY id LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := d.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []OutboxMessage
+	for rows.Next() {
+		m, err := scanOutbox(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// CountOutbox returns the number of outbox messages with the given status.
+func (d *DB) CountOutbox(status string) (int64, error) {
+	var n int64
+	err := d.queryRow(`SELECT COUNT(1) FROM outbox WHERE status = ?`, status).Scan(&n)
+	return n, err
+}
+
+// UpdateOutbox moves a message to status, recording the WhatsApp message id
+// or the send error. Moving to sending counts an attempt.
+func (d *DB) UpdateOutbox(id int64, status, msgID, errText string) error {
+	attempt := 0
+	if status == OutboxSending {
+		attempt = 1
+	}
+	_, err := d.exec(`
+		UPDATE outbox SET status = ?, msg_id = ?, error = ?, updated_at = ?, attempts = attempts + ?
+		WHERE id = ?
+	`, status, msgID, errText, unix(time.Now().UTC()), attempt, id)
+	return err
+}
+
+// CancelOutbox cancels a queued message. It reports false if the message is
+// not queued (anymore).
+func (d *DB) CancelOutbox(id int64) (bool, error) {
+	res, err := d.exec(`UPDATE outbox SET status = ?, updated_at = ? WHERE id = ? AND status = ?`,
+		OutboxCancelled, unix(time.Now().UTC()), id, OutboxQueued)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// FailInterruptedOutbox marks messages left sending by a previous run as
+// failed: they may or may not have reached WhatsApp, and sending them again
+// could deliver them twice.
+func (d *DB) FailInterruptedOutbox() (int64, error) {
+	res, err := d.exec(`UPDATE outbox SET status = ?, error = ?, updated_at = ? WHERE status = ?`,
+		OutboxFailed, "interrupted by a restart while sending; it may or may not have been delivered",
+		unix(time.Now().UTC()), OutboxSending)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func scanOutbox(row rowScanner) (OutboxMessage, error) {
+	var m OutboxMessage
+	var created, updated int64
+	if err := row.Scan(&m.ID, &m.ChatJID, &m.Text, &m.Status, &m.MsgID, &m.Error, &m.Attempts, &created, &updated); err != nil {
+		return OutboxMessage{}, err
+	}
+	m.CreatedAt = fromUnix(created)
+	m.UpdatedAt = fromUnix(updated)
+	return m, nil
+}
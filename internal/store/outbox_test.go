@@ -0,0 +1,68 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestOutbox(t *testing.T) {
+	db := openTestDB(t)
+	chat := "123@s.whatsapp.net"
+
+	var ids []int64
+	for _, text := range []string{"one", "two", "three"} {
+		id, err := db.EnqueueOutbox(chat, text)
+		if err != nil {
+			t.Fatalf("EnqueueOutbox: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	next, err := db.NextOutbox()
+	if err != nil || next.ID != ids[0] || next.Text != "one" || next.Status != OutboxQueued {
+		t.Fatalf("NextOutbox = %+v, %v", next, err)
+	}
+	if err := db.UpdateOutbox(next.ID, OutboxSending, "", ""); err != nil {
+		t.Fatalf("UpdateOutbox: %v", err)
+	}
+	if err := db.UpdateOutbox(next.ID, OutboxSent, "MSG1", ""); err != nil {
+		t.Fatalf("UpdateOutbox: %v", err)
+	}
+	sent, err := db.GetOutbox(ids[0])
+	if err != nil || sent.Status != OutboxSent || sent.MsgID != "MSG1" || sent.Attempts != 1 {
+		t.Fatalf("GetOutbox = %+v, %v", sent, err)
+	}
+
+	if ok, err := db.CancelOutbox(ids[1]); err != nil || !ok {
+		t.Fatalf("CancelOutbox = %v, %v", ok, err)
+	}
+	if ok, err := db.CancelOutbox(ids[0]); err != nil || ok {
+		t.Fatalf("CancelOutbox of a sent message = %v, %v; want false", ok, err)
+	}
+	if next, err := db.NextOutbox(); err != nil || next.ID != ids[2] {
+		t.Fatalf("NextOutbox after cancel = %+v, %v", next, err)
+	}
+
+	// A message left sending by a crash is failed, not retried.
+	if err := db.UpdateOutbox(ids[2], OutboxSending, "", ""); err != nil {
+		t.Fatalf("UpdateOutbox: %v", err)
+	}
+	if n, err := db.FailInterruptedOutbox(); err != nil || n != 1 {
+		t.Fatalf("FailInterruptedOutbox = %d, %v", n, err)
+	}
+	if _, err := db.NextOutbox(); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("NextOutbox on empty queue: %v", err)
+	}
+	if n, _ := db.CountOutbox(OutboxFailed); n != 1 {
+		t.Fatalf("CountOutbox(failed) = %d", n)
+	}
+
+	all, err := db.ListOutbox("", 10)
+	if err != nil || len(all) != 3 || all[0].ID != ids[0] {
+		t.Fatalf("ListOutbox = %+v, %v", all, err)
+	}
+	if cancelled, _ := db.ListOutbox(OutboxCancelled, 10); len(cancelled) != 1 || cancelled[0].ID != ids[1] {
+		t.Fatalf("ListOutbox(cancelled) = %+v", cancelled)
+	}
+}
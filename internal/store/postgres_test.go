@@ -0,0 +1,163 @@
+package store
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRebindPostgres(t *testing.T) {
+	got := rebindPostgres(`SELECT * FROM messages WHERE chat_jid = ? AND text LIKE 'a?b' AND "q?" = ? LIMIT ?`)
+	want := `SELECT * FROM messages WHERE chat_jid = $1 AND text LIKE 'a?b' AND "q?" = $2 LIMIT $3`
+	if got != want {
+		t.Fatalf("rebindPostgres:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestPostgresTypes(t *testing.T) {
+	got := postgresTypes.Replace("id INTEGER PRIMARY KEY AUTOINCREMENT, ts INTEGER NOT NULL, thumb BLOB, score REAL")
+	want := "id BIGSERIAL PRIMARY KEY, ts BIGINT NOT NULL, thumb BYTEA, score DOUBLE PRECISION"
+	if got != want {
+		t.Fatalf("postgresTypes:\n got %s\nwant %s", got, want)
+	}
+}
+
+// postgresTestDSN, while set, makes openTestDB open a fresh Postgres schema
+// instead of a SQLite file. TestPostgresSuite sets it around each test.
+var postgresTestDSN string
+
+// postgresSuite lists the store tests that only go through the Store
+// interface, so they hold for both backends.
+var postgresSuite = map[string]func(*testing.T){
+	"ChatActivityCountsPerMemberAndHour":      TestChatActivityCountsPerMemberAndHour,
+	"ChatStatsRanksChatsAndSenders":           TestChatStatsRanksChatsAndSenders,
+	"MessageTimelineBucketsSentAndReceived":   TestMessageTimelineBucketsSentAndReceived,
+	"APIKeysRotation":                         TestAPIKeysRotation,
+	"BackfillRunCheckpoints":                  TestBackfillRunCheckpoints,
+	"UpsertMessages":                          TestUpsertMessages,
+	"UpsertMessagesFallsBackRowByRow":         TestUpsertMessagesFallsBackRowByRow,
+	"Calls":                                   TestCalls,
+	"Channels":                                TestChannels,
+	"ChatState":                               TestChatState,
+	"ChatUnreadAndLastMessage":                TestChatUnreadAndLastMessage,
+	"FilterChats":                             TestFilterChats,
+	"Communities":                             TestCommunities,
+	"ImportAndListAllContacts":                TestImportAndListAllContacts,
+	"EditAndRevokeMessage":                    TestEditAndRevokeMessage,
+	"ExportChat":                              TestExportChat,
+	"FailedSends":                             TestFailedSends,
+	"Labels":                                  TestLabels,
+	"LIDMappings":                             TestLIDMappings,
+	"Outbox":                                  TestOutbox,
+	"MessageReceipts":                         TestMessageReceipts,
+	"ChatResponderOverrides":                  TestChatResponderOverrides,
+	"RulesCRUD":                               TestRulesCRUD,
+	"ScriptsCRUD":                             TestScriptsCRUD,
+	"SpamSignalsAndScore":                     TestSpamSignalsAndScore,
+	"StarredMessages":                         TestStarredMessages,
+	"ChatTokensRoundTrip":                     TestChatTokensRoundTrip,
+	"WebhookChatFilters":                      TestWebhookChatFilters,
+	"WebhookQueueRoundTrip":                   TestWebhookQueueRoundTrip,
+	"WebhookDeadLetterAndReplay":              TestWebhookDeadLetterAndReplay,
+	"UpsertChatNameAndLastMessageTS":          TestUpsertChatNameAndLastMessageTS,
+	"MediaDownloadInfoAndMarkDownloaded":      TestMediaDownloadInfoAndMarkDownloaded,
+	"ListPendingMedia":                        TestListPendingMedia,
+	"MessageDurationKeptOnUpdate":             TestMessageDurationKeptOnUpdate,
+	"MessageReplyTo":                          TestMessageReplyTo,
+	"ContactsAliasTagsAndSearch":              TestContactsAliasTagsAndSearch,
+	"CountMessagesAndOldestMessageInfo":       TestCountMessagesAndOldestMessageInfo,
+	"MessageChangesAfterAndReplicationCursor": TestMessageChangesAfterAndReplicationCursor,
+	"CaseInsensitiveLookups":                  testCaseInsensitiveLookups,
+}
+
+// TestPostgresSuite runs postgresSuite against the Postgres server at
+// WACLI_TEST_POSTGRES_DSN, each test in a schema of its own.
+func TestPostgresSuite(t *testing.T) {
+	dsn := os.Getenv("WACLI_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("WACLI_TEST_POSTGRES_DSN not set")
+	}
+	for name, test := range postgresSuite {
+		t.Run(name, func(t *testing.T) {
+			postgresTestDSN = dsn
+			t.Cleanup(func() { postgresTestDSN = "" })
+			test(t)
+		})
+	}
+}
+
+func TestCaseInsensitiveLookups(t *testing.T) {
+	testCaseInsensitiveLookups(t)
+}
+
+// testCaseInsensitiveLookups checks that name and text filters ignore case,
+// which LIKE only does on SQLite.
+func testCaseInsensitiveLookups(t *testing.T) {
+	db := openTestDB(t)
+
+	chat := "123@s.whatsapp.net"
+	if err := db.UpsertChat(chat, "dm", "Alice Example", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	if err := db.UpsertMessage(UpsertMessageParams{ChatJID: chat, MsgID: "m1", Timestamp: time.Now(), Text: "Meeting Tomorrow"}); err != nil {
+		t.Fatalf("UpsertMessage: %v", err)
+	}
+	if chats, err := db.ListChats("ALICE", 10); err != nil || len(chats) != 1 {
+		t.Fatalf("ListChats(ALICE) = %+v, %v", chats, err)
+	}
+	if chats, err := db.FilterChats(ListChatsParams{Query: "alice", Limit: 10}); err != nil || len(chats) != 1 {
+		t.Fatalf("FilterChats(alice) = %+v, %v", chats, err)
+	}
+	if msgs, err := db.SearchMessages(SearchMessagesParams{Query: "meeting", Limit: 10}); err != nil || len(msgs) != 1 {
+		t.Fatalf("SearchMessages(meeting) = %+v, %v", msgs, err)
+	}
+	if err := db.UpsertLabel(Label{ID: "1", Name: "VIP"}); err != nil {
+		t.Fatalf("UpsertLabel: %v", err)
+	}
+	if l, err := db.FindLabel("vip"); err != nil || l.ID != "1" {
+		t.Fatalf("FindLabel(vip) = %+v, %v", l, err)
+	}
+}
+
+// openPostgresTestDB opens the store in a new schema of the database at dsn
+// and drops the schema when the test ends.
+func openPostgresTestDB(t *testing.T, dsn string) *DB {
+	t.Helper()
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open postgres: %v", err)
+	}
+	t.Cleanup(func() { _ = admin.Close() })
+
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	schema := "wacli_test_" + hex.EncodeToString(b)
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() { _, _ = admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`) })
+
+	db, err := OpenPostgres(withSearchPath(dsn, schema))
+	if err != nil {
+		t.Fatalf("OpenPostgres: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// withSearchPath adds a search_path run-time parameter to a libpq URL or
+// key=value connection string.
+func withSearchPath(dsn, schema string) string {
+	if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		q := u.Query()
+		q.Set("search_path", schema)
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	return strings.TrimSpace(dsn) + " search_path=" + schema
+}
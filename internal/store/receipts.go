@@ -0,0 +1,109 @@
+package store
+
+import "time"
+
+// Receipt statuses of our own messages, in order of progress.
+const (
+	ReceiptSent      = "sent"
+	ReceiptDelivered = "delivered"
+	ReceiptRead      = "read"
+	ReceiptPlayed    = "played" // voice notes and view-once media
+)
+
+// MessageReceipt is the delivery state of one of our messages for one
+// recipient (the chat itself for direct messages, a participant in groups).
+type MessageReceipt struct {
+	RecipientJID string
+	Status       string
+	DeliveredAt  time.Time
+	ReadAt       time.Time
+	PlayedAt     time.Time
+}
+
+func receiptStatus(deliveredAt, readAt, playedAt time.Time) string {
+	switch {
+	case !playedAt.IsZero():
+		return ReceiptPlayed
+	case !readAt.IsZero():
+		return ReceiptRead
+	case !deliveredAt.IsZero():
+		return ReceiptDelivered
+	default:
+		return ReceiptSent
+	}
+}
+
+// AddMessageReceipts records that recipientJID reached status (delivered,
+// read or played) for the given messages at at. A read receipt implies
+// delivery and a played receipt both, and the first time seen for each is
+// kept when receipts repeat.
+func (d *DB) AddMessageReceipts(chatJID, recipientJID string, msgIDs []string, status string, at time.Time) error {
+	var deliveredAt, readAt, playedAt int64
+	switch status {
+	case ReceiptDelivered:
+		deliveredAt = unix(at)
+	case ReceiptRead:
+		deliveredAt, readAt = unix(at), unix(at)
+	case ReceiptPlayed:
+		deliveredAt, readAt, playedAt = unix(at), unix(at), unix(at)
+	default:
+		return nil
+	}
+	if len(msgIDs) == 0 {
+		return nil
+	}
+
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(d.rebind(`
+		INSERT INTO message_receipts(chat_jid, msg_id, recipient_jid, delivered_at, read_at, played_at)
+		VALUES(?, ?, ?, ?, ?, ?)
+		ON CONFLICT(chat_jid, msg_id, recipient_jid) DO UPDATE SET
+			delivered_at=CASE WHEN message_receipts.delivered_at = 0 THEN excluded.delivered_at ELSE message_receipts.delivered_at END,
+			read_at=CASE WHEN message_receipts.read_at = 0 THEN excluded.read_at ELSE message_receipts.read_at END,
+			played_at=CASE WHEN message_receipts.played_at = 0 THEN excluded.played_at ELSE message_receipts.played_at END
+	`))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, id := range msgIDs {
+		if _, err := stmt.Exec(chatJID, id, recipientJID, deliveredAt, readAt, playedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// MessageReceipts returns the receipts of a message per recipient, in the
+// order they were delivered.
+func (d *DB) MessageReceipts(chatJID, msgID string) ([]MessageReceipt, error) {
+	rows, err := d.query(`
+		SELECT recipient_jid, delivered_at, read_at, played_at
+		FROM message_receipts
+		WHERE chat_jid = ? AND msg_id = ?
+		ORDER BY delivered_at, recipient_jid
+	`, chatJID, msgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []MessageReceipt
+	for rows.Next() {
+		var r MessageReceipt
+		var deliveredAt, readAt, playedAt int64
+		if err := rows.Scan(&r.RecipientJID, &deliveredAt, &readAt, &playedAt); err != nil {
+			return nil, err
+		}
+		r.DeliveredAt = fromUnix(deliveredAt)
+		r.ReadAt = fromUnix(readAt)
+		r.PlayedAt = fromUnix(playedAt)
+		r.Status = receiptStatus(r.DeliveredAt, r.ReadAt, r.PlayedAt)
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
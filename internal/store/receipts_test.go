@@ -0,0 +1,87 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMessageReceipts(t *testing.T) {
+	db := openTestDB(t)
+
+	group := "1203630@g.us"
+	base := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	if err := db.UpsertChat(group, "group", "Team", base); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	for _, m := range []UpsertMessageParams{
+		{ChatJID: group, MsgID: "out", Timestamp: base, FromMe: true, Text: "hi"},
+		{ChatJID: group, MsgID: "in", SenderJID: "111@s.whatsapp.net", Timestamp: base, Text: "hey"},
+	} {
+		if err := db.UpsertMessage(m); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+
+	msg, err := db.GetMessage(group, "out")
+	if err != nil || msg.Status != ReceiptSent || !msg.DeliveredAt.IsZero() {
+		t.Fatalf("GetMessage before receipts = %+v, %v", msg, err)
+	}
+
+	alice, bob := "111@s.whatsapp.net", "222@s.whatsapp.net"
+	steps := []struct {
+		who    string
+		status string
+		at     time.Time
+	}{
+		{alice, ReceiptDelivered, base.Add(time.Minute)},
+		{bob, ReceiptRead, base.Add(2 * time.Minute)}, // read without a delivery receipt first
+		{alice, ReceiptDelivered, base.Add(5 * time.Minute)},
+		{alice, ReceiptRead, base.Add(3 * time.Minute)},
+	}
+	for _, s := range steps {
+		if err := db.AddMessageReceipts(group, s.who, []string{"out"}, s.status, s.at); err != nil {
+			t.Fatalf("AddMessageReceipts: %v", err)
+		}
+	}
+
+	receipts, err := db.MessageReceipts(group, "out")
+	if err != nil || len(receipts) != 2 {
+		t.Fatalf("MessageReceipts = %+v, %v", receipts, err)
+	}
+	if r := receipts[0]; r.RecipientJID != alice || r.Status != ReceiptRead ||
+		!r.DeliveredAt.Equal(base.Add(time.Minute)) || !r.ReadAt.Equal(base.Add(3*time.Minute)) {
+		t.Fatalf("alice's receipt = %+v", r)
+	}
+	if r := receipts[1]; r.RecipientJID != bob || !r.DeliveredAt.Equal(base.Add(2*time.Minute)) {
+		t.Fatalf("bob's receipt = %+v", r)
+	}
+
+	// A played receipt implies the rest and keeps earlier times.
+	if err := db.AddMessageReceipts(group, bob, []string{"out"}, ReceiptPlayed, base.Add(4*time.Minute)); err != nil {
+		t.Fatalf("AddMessageReceipts played: %v", err)
+	}
+	receipts, err = db.MessageReceipts(group, "out")
+	if err != nil || len(receipts) != 2 {
+		t.Fatalf("MessageReceipts = %+v, %v", receipts, err)
+	}
+	if r := receipts[1]; r.Status != ReceiptPlayed || !r.ReadAt.Equal(base.Add(2*time.Minute)) || !r.PlayedAt.Equal(base.Add(4*time.Minute)) {
+		t.Fatalf("bob's played receipt = %+v", r)
+	}
+
+	msgs, err := db.ListMessages(ListMessagesParams{ChatJID: group, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListMessages: %v", err)
+	}
+	for _, m := range msgs {
+		switch m.MsgID {
+		case "out":
+			if m.Status != ReceiptPlayed || !m.DeliveredAt.Equal(base.Add(time.Minute)) || !m.ReadAt.Equal(base.Add(2*time.Minute)) || !m.PlayedAt.Equal(base.Add(4*time.Minute)) {
+				t.Fatalf("own message = %+v", m)
+			}
+		case "in":
+			if m.Status != "" {
+				t.Fatalf("incoming message has status %q", m.Status)
+			}
+		}
+	}
+}
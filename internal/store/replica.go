@@ -0,0 +1,284 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// changeLog records, per message, the latest change to the message or to its
+// star and receipts, in the order the changes were made. The triggers keep
+// one row per message and acknowledged changes are pruned, so the log only
+// holds what the standby has not received yet. It exists only while
+// replication is configured (see EnableChangeLog).
+const changeLog = `
+	CREATE TABLE IF NOT EXISTS message_changes (
+		seq INTEGER PRIMARY KEY AUTOINCREMENT,
+		chat_jid TEXT NOT NULL,
+		msg_id TEXT NOT NULL,
+		deleted INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_message_changes_msg ON message_changes(chat_jid, msg_id);
+`
+
+// changeLogTriggers fill message_changes on SQLite. Stars and receipts are
+// only logged while their message is stored, so removing them after the
+// message does not overwrite its deletion.
+var changeLogTriggers = []struct {
+	name, event, table, row string
+	deleted                 int
+}{
+	{"message_changes_ai", "INSERT", "messages", "new", 0},
+	{"message_changes_au", "UPDATE", "messages", "new", 0},
+	{"message_changes_ad", "DELETE", "messages", "old", 1},
+	{"starred_changes_ai", "INSERT", "starred_messages", "new", 0},
+	{"starred_changes_au", "UPDATE", "starred_messages", "new", 0},
+	{"starred_changes_ad", "DELETE", "starred_messages", "old", 0},
+	{"receipt_changes_ai", "INSERT", "message_receipts", "new", 0},
+	{"receipt_changes_au", "UPDATE", "message_receipts", "new", 0},
+}
+
+// sqliteChangeLogTriggers returns the statements (re)creating changeLogTriggers.
+func sqliteChangeLogTriggers() string {
+	var b strings.Builder
+	for _, t := range changeLogTriggers {
+		when := ""
+		if t.table != "messages" {
+			when = fmt.Sprintf("WHEN EXISTS (SELECT 1 FROM messages WHERE chat_jid = %[1]s.chat_jid AND msg_id = %[1]s.msg_id) ", t.row)
+		}
+		fmt.Fprintf(&b, `
+	DROP TRIGGER IF EXISTS %[1]s;
+	CREATE TRIGGER %[1]s AFTER %[2]s ON %[3]s %[4]sBEGIN
+		DELETE FROM message_changes WHERE chat_jid = %[5]s.chat_jid AND msg_id = %[5]s.msg_id;
+		INSERT INTO message_changes(chat_jid, msg_id, deleted) VALUES (%[5]s.chat_jid, %[5]s.msg_id, %[6]d);
+	END;
+`, t.name, t.event, t.table, when, t.row, t.deleted)
+	}
+	return b.String()
+}
+
+// postgresChangeLogTriggers are the Postgres counterpart of changeLogTriggers.
+const postgresChangeLogTriggers = `
+	CREATE OR REPLACE FUNCTION log_message_change() RETURNS trigger AS $$
+	DECLARE
+		r RECORD;
+	BEGIN
+		IF TG_OP = 'DELETE' THEN r := OLD; ELSE r := NEW; END IF;
+		IF TG_TABLE_NAME <> 'messages' AND NOT EXISTS (
+			SELECT 1 FROM messages WHERE chat_jid = r.chat_jid AND msg_id = r.msg_id) THEN
+			RETURN NULL;
+		END IF;
+		INSERT INTO message_changes(chat_jid, msg_id, deleted)
+		VALUES (r.chat_jid, r.msg_id, CASE WHEN TG_TABLE_NAME = 'messages' AND TG_OP = 'DELETE' THEN 1 ELSE 0 END)
+		ON CONFLICT (chat_jid, msg_id) DO UPDATE
+		SET seq = nextval(pg_get_serial_sequence('message_changes', 'seq')), deleted = excluded.deleted;
+		RETURN NULL;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	DROP TRIGGER IF EXISTS message_changes_log ON messages;
+	CREATE TRIGGER message_changes_log AFTER INSERT OR UPDATE OR DELETE ON messages
+		FOR EACH ROW EXECUTE FUNCTION log_message_change();
+	DROP TRIGGER IF EXISTS starred_changes_log ON starred_messages;
+	CREATE TRIGGER starred_changes_log AFTER INSERT OR UPDATE OR DELETE ON starred_messages
+		FOR EACH ROW EXECUTE FUNCTION log_message_change();
+	DROP TRIGGER IF EXISTS receipt_changes_log ON message_receipts;
+	CREATE TRIGGER receipt_changes_log AFTER INSERT OR UPDATE ON message_receipts
+		FOR EACH ROW EXECUTE FUNCTION log_message_change();
+`
+
+// postgresDropChangeLogTriggers removes postgresChangeLogTriggers.
+const postgresDropChangeLogTriggers = `
+	DROP TRIGGER IF EXISTS message_changes_log ON messages;
+	DROP TRIGGER IF EXISTS starred_changes_log ON starred_messages;
+	DROP TRIGGER IF EXISTS receipt_changes_log ON message_receipts;
+	DROP FUNCTION IF EXISTS log_message_change();
+`
+
+// EnableChangeLog creates the message change log and its triggers. A new log
+// is seeded with the stored messages, numbered by rowid, so replication
+// cursors saved before the log existed keep their place.
+func (d *DB) EnableChangeLog() error {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tableQuery := `SELECT COUNT(1) FROM sqlite_master WHERE type = 'table' AND name = 'message_changes'`
+	if d.postgres {
+		tableQuery = `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = 'message_changes'`
+	}
+	var exists int
+	if err := tx.QueryRow(tableQuery).Scan(&exists); err != nil {
+		return fmt.Errorf("inspect message_changes: %w", err)
+	}
+
+	create, triggers := changeLog, sqliteChangeLogTriggers()
+	if d.postgres {
+		create, triggers = postgresTypes.Replace(changeLog), postgresChangeLogTriggers
+	}
+	if _, err := tx.Exec(create); err != nil {
+		return fmt.Errorf("create message_changes: %w", err)
+	}
+	if exists == 0 {
+		if _, err := tx.Exec(`INSERT INTO message_changes(seq, chat_jid, msg_id, deleted) SELECT rowid, chat_jid, msg_id, 0 FROM messages`); err != nil {
+			return fmt.Errorf("seed message_changes: %w", err)
+		}
+		if d.postgres {
+			if _, err := tx.Exec(`SELECT setval(pg_get_serial_sequence('message_changes', 'seq'), MAX(seq)) FROM message_changes HAVING MAX(seq) IS NOT NULL`); err != nil {
+				return fmt.Errorf("seed message_changes: %w", err)
+			}
+		}
+	}
+	if _, err := tx.Exec(triggers); err != nil {
+		return fmt.Errorf("create message_changes triggers: %w", err)
+	}
+	return tx.Commit()
+}
+
+// DisableChangeLog drops the message change log and its triggers, so writes
+// stop paying for them while no standby is configured. The replication
+// cursors are reset with it: a standby configured later receives every
+// message from a freshly seeded log.
+func (d *DB) DisableChangeLog() error {
+	var drop strings.Builder
+	if d.postgres {
+		drop.WriteString(postgresDropChangeLogTriggers)
+	} else {
+		for _, t := range changeLogTriggers {
+			fmt.Fprintf(&drop, "DROP TRIGGER IF EXISTS %s;\n", t.name)
+		}
+	}
+	drop.WriteString("DROP TABLE IF EXISTS message_changes;\nDELETE FROM replication_cursors;\n")
+
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(drop.String()); err != nil {
+		return fmt.Errorf("drop message_changes: %w", err)
+	}
+	return tx.Commit()
+}
+
+// ReplicaMessage is a change to a stored message, used to stream history
+// and later changes to a standby instance in the order they were made. A
+// deleted message carries only its chat and message IDs.
+type ReplicaMessage struct {
+	Seq     int64
+	Deleted bool
+	UpsertMessageParams
+	EditedText string
+	EditedAt   time.Time
+	Revoked    bool
+	StarredAt  time.Time // zero when not starred
+	Receipts   []MessageReceipt
+}
+
+// MessageChangesAfter returns up to limit messages changed or deleted after
+// the given change sequence number, oldest change first, including the
+// media metadata needed to download on another host.
+func (d *DB) MessageChangesAfter(seq int64, limit int) ([]ReplicaMessage, error) {
+	if limit <= 0 {
+		limit = 500
+	}
+	rows, err := d.query(`
+		SELECT c.seq, c.chat_jid, c.msg_id, c.deleted, COALESCE(m.rowid,0),
+		       COALESCE(m.chat_name,''), COALESCE(m.sender_jid,''), COALESCE(m.sender_name,''),
+		       COALESCE(m.ts,0), COALESCE(m.from_me,0), COALESCE(m.text,''), COALESCE(m.media_type,''), COALESCE(m.media_caption,''),
+		       COALESCE(m.filename,''), COALESCE(m.mime_type,''), COALESCE(m.direct_path,''),
+		       m.media_key, m.file_sha256, m.file_enc_sha256, COALESCE(m.file_length,0), COALESCE(m.duration,0),
+		       COALESCE(m.reply_to_id,''), COALESCE(m.reply_to_sender,''),
+		       COALESCE(m.edited_text,''), COALESCE(m.edited_at,0), COALESCE(m.revoked,0), COALESCE(s.starred_at,0)
+		FROM message_changes c
+		LEFT JOIN messages m ON m.chat_jid = c.chat_jid AND m.msg_id = c.msg_id
+		LEFT JOIN starred_messages s ON s.chat_jid = c.chat_jid AND s.msg_id = c.msg_id
+		WHERE c.seq > ?
+		ORDER BY c.seq ASC
+		LIMIT ?
+	`, seq, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []ReplicaMessage
+	for rows.Next() {
+		var m ReplicaMessage
+		var rowID, ts, fileLen, duration, editedAt, starredAt int64
+		var deleted, fromMe, revoked int
+		if err := rows.Scan(&m.Seq, &m.ChatJID, &m.MsgID, &deleted, &rowID,
+			&m.ChatName, &m.SenderJID, &m.SenderName,
+			&ts, &fromMe, &m.Text, &m.MediaType, &m.MediaCaption,
+			&m.Filename, &m.MimeType, &m.DirectPath,
+			&m.MediaKey, &m.FileSHA256, &m.FileEncSHA256, &fileLen, &duration,
+			&m.ReplyToID, &m.ReplyToSender,
+			&m.EditedText, &editedAt, &revoked, &starredAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if deleted != 0 || rowID == 0 {
+			out = append(out, ReplicaMessage{Seq: m.Seq, Deleted: true,
+				UpsertMessageParams: UpsertMessageParams{ChatJID: m.ChatJID, MsgID: m.MsgID}})
+			continue
+		}
+		m.Timestamp = fromUnix(ts)
+		m.FromMe = fromMe != 0
+		if fileLen > 0 {
+			m.FileLength = uint64(fileLen)
+		}
+		m.Duration = uint32(duration)
+		if editedAt > 0 {
+			m.EditedAt = fromUnix(editedAt)
+		}
+		m.Revoked = revoked != 0
+		if starredAt > 0 {
+			m.StarredAt = fromUnix(starredAt)
+		}
+		out = append(out, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range out {
+		if out[i].Deleted || !out[i].FromMe {
+			continue
+		}
+		if out[i].Receipts, err = d.MessageReceipts(out[i].ChatJID, out[i].MsgID); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// PruneMessageChanges forgets the changes up to seq once a standby has
+// acknowledged them. A message changed again later is logged anew.
+func (d *DB) PruneMessageChanges(seq int64) error {
+	_, err := d.exec(`DELETE FROM message_changes WHERE seq <= ?`, seq)
+	return err
+}
+
+// GetReplicationCursor returns the last change sequence number acknowledged
+// by the named replica.
+func (d *DB) GetReplicationCursor(name string) (int64, error) {
+	var seq int64
+	err := d.queryRow(`SELECT last_rowid FROM replication_cursors WHERE name = ?`, strings.TrimSpace(name)).Scan(&seq)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return seq, err
+}
+
+// SetReplicationCursor records the last change sequence number acknowledged
+// by the named replica.
+func (d *DB) SetReplicationCursor(name string, seq int64) error {
+	_, err := d.exec(`
+		INSERT INTO replication_cursors(name, last_rowid, updated_at) VALUES(?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET last_rowid=excluded.last_rowid, updated_at=excluded.updated_at
+	`, strings.TrimSpace(name), seq, time.Now().UTC().Unix())
+	return err
+}
@@ -0,0 +1,204 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMessageChangesAfterAndReplicationCursor(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.EnableChangeLog(); err != nil {
+		t.Fatalf("EnableChangeLog: %v", err)
+	}
+
+	chat := "123@s.whatsapp.net"
+	if err := db.UpsertChat(chat, "dm", "Alice", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	base := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for i, id := range []string{"m1", "m2", "m3"} {
+		if err := db.UpsertMessage(UpsertMessageParams{
+			ChatJID:   chat,
+			MsgID:     id,
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+			Text:      id,
+			FromMe:    true,
+			MediaKey:  []byte{byte(i + 1)},
+		}); err != nil {
+			t.Fatalf("UpsertMessage %s: %v", id, err)
+		}
+	}
+
+	if cur, err := db.GetReplicationCursor("standby"); err != nil || cur != 0 {
+		t.Fatalf("expected empty cursor, got %d (err=%v)", cur, err)
+	}
+
+	first, err := db.MessageChangesAfter(0, 2)
+	if err != nil {
+		t.Fatalf("MessageChangesAfter: %v", err)
+	}
+	if len(first) != 2 || first[0].MsgID != "m1" || first[1].MsgID != "m2" {
+		t.Fatalf("unexpected first batch: %+v", first)
+	}
+	if len(first[0].MediaKey) != 1 || first[0].MediaKey[0] != 1 {
+		t.Fatalf("expected media key to round-trip, got %v", first[0].MediaKey)
+	}
+
+	if err := db.SetReplicationCursor("standby", first[1].Seq); err != nil {
+		t.Fatalf("SetReplicationCursor: %v", err)
+	}
+	cur, err := db.GetReplicationCursor("standby")
+	if err != nil {
+		t.Fatalf("GetReplicationCursor: %v", err)
+	}
+	rest, err := db.MessageChangesAfter(cur, 10)
+	if err != nil {
+		t.Fatalf("MessageChangesAfter: %v", err)
+	}
+	if len(rest) != 1 || rest[0].MsgID != "m3" {
+		t.Fatalf("expected only m3 after cursor, got %+v", rest)
+	}
+
+	// Edits, stars and receipts of acknowledged messages come around again;
+	// a deletion leaves a tombstone until it is acknowledged.
+	if _, err := db.EditMessage(chat, "m1", "m1 edited", base.Add(time.Minute)); err != nil {
+		t.Fatalf("EditMessage: %v", err)
+	}
+	if err := db.SetMessageStarred(chat, "m1", true, base.Add(time.Minute)); err != nil {
+		t.Fatalf("SetMessageStarred: %v", err)
+	}
+	if err := db.AddMessageReceipts(chat, chat, []string{"m1"}, ReceiptRead, base.Add(2*time.Minute)); err != nil {
+		t.Fatalf("AddMessageReceipts: %v", err)
+	}
+	if ok, err := db.DeleteMessage(chat, "m2"); err != nil || !ok {
+		t.Fatalf("DeleteMessage: %v, %v", ok, err)
+	}
+	changes, err := db.MessageChangesAfter(cur, 10)
+	if err != nil {
+		t.Fatalf("MessageChangesAfter: %v", err)
+	}
+	if len(changes) != 3 || changes[0].MsgID != "m3" || changes[1].MsgID != "m1" || changes[2].MsgID != "m2" {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+	m1 := changes[1]
+	if m1.Deleted || m1.EditedText != "m1 edited" || m1.StarredAt.IsZero() || len(m1.Receipts) != 1 || m1.Receipts[0].Status != ReceiptRead {
+		t.Fatalf("unexpected m1 change: %+v", m1)
+	}
+	if !changes[2].Deleted || changes[2].ChatJID != chat {
+		t.Fatalf("expected m2 tombstone, got %+v", changes[2])
+	}
+
+	if err := db.PruneMessageChanges(changes[1].Seq); err != nil {
+		t.Fatalf("PruneMessageChanges: %v", err)
+	}
+	rest, err = db.MessageChangesAfter(0, 10)
+	if err != nil {
+		t.Fatalf("MessageChangesAfter: %v", err)
+	}
+	if len(rest) != 1 || rest[0].MsgID != "m2" {
+		t.Fatalf("expected only the m2 tombstone after pruning up to m1, got %+v", rest)
+	}
+
+	// An acknowledged message changed again is logged anew.
+	if _, err := db.EditMessage(chat, "m3", "m3 edited", base.Add(3*time.Minute)); err != nil {
+		t.Fatalf("EditMessage: %v", err)
+	}
+	if err := db.PruneMessageChanges(changes[2].Seq); err != nil {
+		t.Fatalf("PruneMessageChanges: %v", err)
+	}
+	rest, err = db.MessageChangesAfter(0, 10)
+	if err != nil {
+		t.Fatalf("MessageChangesAfter: %v", err)
+	}
+	if len(rest) != 1 || rest[0].MsgID != "m3" || rest[0].EditedText != "m3 edited" {
+		t.Fatalf("expected the m3 edit after pruning, got %+v", rest)
+	}
+}
+
+func TestChangeLogSeededFromExistingMessages(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "wacli.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	chat := "123@s.whatsapp.net"
+	if err := db.UpsertChat(chat, "dm", "Alice", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	for _, id := range []string{"m1", "m2"} {
+		if err := db.UpsertMessage(UpsertMessageParams{ChatJID: chat, MsgID: id, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+	// Messages stored before the log existed, with a rowid cursor past m1.
+	if _, err := db.MessageChangesAfter(0, 10); err == nil {
+		t.Fatalf("expected no change log before EnableChangeLog")
+	}
+	var m1 int64
+	if err := db.sql.QueryRow(`SELECT rowid FROM messages WHERE msg_id = 'm1'`).Scan(&m1); err != nil {
+		t.Fatalf("rowid: %v", err)
+	}
+
+	if err := db.EnableChangeLog(); err != nil {
+		t.Fatalf("EnableChangeLog: %v", err)
+	}
+	changes, err := db.MessageChangesAfter(m1, 10)
+	if err != nil {
+		t.Fatalf("MessageChangesAfter: %v", err)
+	}
+	if len(changes) != 1 || changes[0].MsgID != "m2" {
+		t.Fatalf("expected m2 after the old rowid cursor, got %+v", changes)
+	}
+}
+
+func TestDisableChangeLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wacli.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	if err := db.EnableChangeLog(); err != nil {
+		t.Fatalf("EnableChangeLog: %v", err)
+	}
+	if err := db.SetReplicationCursor("standby", 7); err != nil {
+		t.Fatalf("SetReplicationCursor: %v", err)
+	}
+
+	if err := db.DisableChangeLog(); err != nil {
+		t.Fatalf("DisableChangeLog: %v", err)
+	}
+	if err := db.DisableChangeLog(); err != nil {
+		t.Fatalf("DisableChangeLog again: %v", err)
+	}
+	chat := "123@s.whatsapp.net"
+	if err := db.UpsertChat(chat, "dm", "Alice", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	if err := db.UpsertMessage(UpsertMessageParams{ChatJID: chat, MsgID: "m1", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("UpsertMessage without the log: %v", err)
+	}
+	if _, err := db.DeleteMessage(chat, "m1"); err != nil {
+		t.Fatalf("DeleteMessage without the log: %v", err)
+	}
+	var triggers int
+	if err := db.sql.QueryRow(`SELECT COUNT(1) FROM sqlite_master WHERE type IN ('table', 'trigger') AND name LIKE '%changes%'`).Scan(&triggers); err != nil || triggers != 0 {
+		t.Fatalf("%d change log objects left (err=%v)", triggers, err)
+	}
+	if cur, err := db.GetReplicationCursor("standby"); err != nil || cur != 0 {
+		t.Fatalf("cursor = %d (err=%v), want it reset", cur, err)
+	}
+
+	// Enabling again starts over from the stored messages.
+	if err := db.UpsertMessage(UpsertMessageParams{ChatJID: chat, MsgID: "m2", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("UpsertMessage: %v", err)
+	}
+	if err := db.EnableChangeLog(); err != nil {
+		t.Fatalf("EnableChangeLog: %v", err)
+	}
+	changes, err := db.MessageChangesAfter(0, 10)
+	if err != nil || len(changes) != 1 || changes[0].MsgID != "m2" {
+		t.Fatalf("changes = %+v (err=%v), want m2", changes, err)
+	}
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ChatResponder overrides whether the auto-responder replies in one chat.
+type ChatResponder struct {
+	ChatJID   string
+	Enabled   bool
+	UpdatedAt time.Time
+}
+
+// SetChatResponder sets the auto-responder override for a chat.
+func (d *DB) SetChatResponder(chatJID string, enabled bool) error {
+	_, err := d.exec(`
+		INSERT INTO chat_responder(chat_jid, enabled, updated_at) VALUES(?, ?, ?)
+		ON CONFLICT(chat_jid) DO UPDATE SET enabled=excluded.enabled, updated_at=excluded.updated_at
+	`, chatJID, boolToInt(enabled), time.Now().UTC().Unix())
+	return err
+}
+
+// DeleteChatResponder removes a chat's override. It returns sql.ErrNoRows if
+// the chat has none.
+func (d *DB) DeleteChatResponder(chatJID string) error {
+	res, err := d.exec(`DELETE FROM chat_responder WHERE chat_jid = ?`, chatJID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetChatResponder returns a chat's override, or sql.ErrNoRows.
+func (d *DB) GetChatResponder(chatJID string) (ChatResponder, error) {
+	return scanChatResponder(d.queryRow(`SELECT chat_jid, enabled, updated_at FROM chat_responder WHERE chat_jid = ?`, chatJID))
+}
+
+// ListChatResponders returns all per-chat overrides.
+func (d *DB) ListChatResponders() ([]ChatResponder, error) {
+	rows, err := d.query(`SELECT chat_jid, enabled, updated_at FROM chat_responder ORDER BY chat_jid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ChatResponder
+	for rows.Next() {
+		r, err := scanChatResponder(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func scanChatResponder(row rowScanner) (ChatResponder, error) {
+	var r ChatResponder
+	var enabled int
+	var updated int64
+	if err := row.Scan(&r.ChatJID, &enabled, &updated); err != nil {
+		return ChatResponder{}, err
+	}
+	r.Enabled = enabled != 0
+	r.UpdatedAt = fromUnix(updated)
+	return r, nil
+}
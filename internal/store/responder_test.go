@@ -0,0 +1,43 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestChatResponderOverrides(t *testing.T) {
+	db := openTestDB(t)
+
+	if _, err := db.GetChatResponder("a@s.whatsapp.net"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetChatResponder(unset) = %v, want sql.ErrNoRows", err)
+	}
+	if err := db.SetChatResponder("b@s.whatsapp.net", true); err != nil {
+		t.Fatalf("SetChatResponder: %v", err)
+	}
+	if err := db.SetChatResponder("a@s.whatsapp.net", true); err != nil {
+		t.Fatalf("SetChatResponder: %v", err)
+	}
+	if err := db.SetChatResponder("a@s.whatsapp.net", false); err != nil {
+		t.Fatalf("SetChatResponder (update): %v", err)
+	}
+
+	r, err := db.GetChatResponder("a@s.whatsapp.net")
+	if err != nil || r.Enabled || r.UpdatedAt.IsZero() {
+		t.Fatalf("GetChatResponder = %+v, %v", r, err)
+	}
+	list, err := db.ListChatResponders()
+	if err != nil {
+		t.Fatalf("ListChatResponders: %v", err)
+	}
+	if len(list) != 2 || list[0].ChatJID != "a@s.whatsapp.net" || list[0].Enabled || !list[1].Enabled {
+		t.Fatalf("unexpected overrides: %+v", list)
+	}
+
+	if err := db.DeleteChatResponder("a@s.whatsapp.net"); err != nil {
+		t.Fatalf("DeleteChatResponder: %v", err)
+	}
+	if err := db.DeleteChatResponder("a@s.whatsapp.net"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("DeleteChatResponder(missing) = %v, want sql.ErrNoRows", err)
+	}
+}
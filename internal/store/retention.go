@@ -0,0 +1,294 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// pruneBatch bounds how many messages are deleted per transaction, so the
+// janitor never blocks message ingestion for long.
+const pruneBatch = 500
+
+// expiredClause matches messages of m past their chat's max age, joined with
+// its chat_retention override r. It takes the global cutoff and now.
+const expiredClause = `((r.chat_jid IS NULL AND m.ts < ?) OR (COALESCE(r.max_age, 0) > 0 AND m.ts < ? - r.max_age))`
+
+// ChatRetention overrides the global retention policy for one chat.
+type ChatRetention struct {
+	ChatJID   string
+	MaxAge    time.Duration // zero means keep forever
+	UpdatedAt time.Time
+}
+
+// SetChatRetention sets the retention override for a chat.
+func (d *DB) SetChatRetention(chatJID string, maxAge time.Duration) error {
+	_, err := d.exec(`
+		INSERT INTO chat_retention(chat_jid, max_age, updated_at) VALUES(?, ?, ?)
+		ON CONFLICT(chat_jid) DO UPDATE SET max_age=excluded.max_age, updated_at=excluded.updated_at
+	`, chatJID, int64(maxAge/time.Second), time.Now().UTC().Unix())
+	return err
+}
+
+// DeleteChatRetention removes a chat's override. It returns sql.ErrNoRows if
+// the chat has none.
+func (d *DB) DeleteChatRetention(chatJID string) error {
+	res, err := d.exec(`DELETE FROM chat_retention WHERE chat_jid = ?`, chatJID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListChatRetention returns all per-chat overrides.
+func (d *DB) ListChatRetention() ([]ChatRetention, error) {
+	rows, err := d.query(`SELECT chat_jid, max_age, updated_at FROM chat_retention ORDER BY chat_jid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ChatRetention
+	for rows.Next() {
+		var r ChatRetention
+		var maxAge, updated int64
+		if err := rows.Scan(&r.ChatJID, &maxAge, &updated); err != nil {
+			return nil, err
+		}
+		r.MaxAge = time.Duration(maxAge) * time.Second
+		r.UpdatedAt = fromUnix(updated)
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// RetentionPolicy describes which messages PruneMessages removes.
+type RetentionPolicy struct {
+	MaxAge     time.Duration // messages older than this are removed; zero disables
+	MaxDBBytes int64         // oldest messages are removed until the database fits; zero disables
+	Now        time.Time
+}
+
+// PruneResult reports what PruneMessages removed (or would remove).
+type PruneResult struct {
+	Messages   int
+	ByAge      int
+	BySize     int
+	Chats      map[string]int // removed messages per chat
+	MediaPaths []string       // downloaded files no longer referenced by any message
+	DBBytes    int64          // database size in use before pruning
+}
+
+// DBBytes returns the bytes in use by the database, excluding free pages.
+// On Postgres, where freed space stays allocated to the table, it is the
+// size of the live message rows.
+func (d *DB) DBBytes() (int64, error) {
+	if d.postgres {
+		var n int64
+		err := d.sql.QueryRow(`SELECT COALESCE(SUM(pg_column_size(m.*)), 0) FROM messages m`).Scan(&n)
+		return n, err
+	}
+	var pageSize, pages, free int64
+	if err := d.sql.QueryRow(`PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return 0, err
+	}
+	if err := d.sql.QueryRow(`PRAGMA page_count`).Scan(&pages); err != nil {
+		return 0, err
+	}
+	if err := d.sql.QueryRow(`PRAGMA freelist_count`).Scan(&free); err != nil {
+		return 0, err
+	}
+	return (pages - free) * pageSize, nil
+}
+
+// PruneMessages deletes messages outside the retention policy. Per-chat
+// overrides replace MaxAge for their chat, and chats set to keep forever are
+// also exempt from the size limit. The size limit is enforced by removing
+// the oldest messages, estimating their share of the database from its
+// average bytes per message; freed pages are reused by SQLite but the file
+// does not shrink. With dryRun set nothing is deleted.
+func (d *DB) PruneMessages(p RetentionPolicy, dryRun bool) (PruneResult, error) {
+	res := PruneResult{Chats: map[string]int{}}
+	if p.Now.IsZero() {
+		p.Now = time.Now()
+	}
+	now := unix(p.Now)
+
+	ageCutoff := int64(0)
+	if p.MaxAge > 0 {
+		ageCutoff = now - int64(p.MaxAge/time.Second)
+	}
+	byAge, err := d.pruneCandidates(`
+		SELECT m.rowid, m.chat_jid, COALESCE(m.local_path,'')
+		FROM messages m LEFT JOIN chat_retention r ON r.chat_jid = m.chat_jid
+		WHERE `+expiredClause, ageCutoff, now)
+	if err != nil {
+		return res, fmt.Errorf("select expired messages: %w", err)
+	}
+	res.ByAge = len(byAge)
+
+	var bySize []pruneCandidate
+	if p.MaxDBBytes > 0 {
+		if res.DBBytes, err = d.DBBytes(); err != nil {
+			return res, fmt.Errorf("measure database: %w", err)
+		}
+		total, err := d.CountMessages()
+		if err != nil {
+			return res, err
+		}
+		if total > 0 {
+			perMessage := res.DBBytes / total
+			remaining := res.DBBytes - perMessage*int64(len(byAge))
+			if remaining > p.MaxDBBytes && perMessage > 0 {
+				need := (remaining - p.MaxDBBytes + perMessage - 1) / perMessage
+				bySize, err = d.pruneCandidates(`
+					SELECT m.rowid, m.chat_jid, COALESCE(m.local_path,'')
+					FROM messages m LEFT JOIN chat_retention r ON r.chat_jid = m.chat_jid
+					WHERE (r.chat_jid IS NULL OR r.max_age > 0) AND NOT `+expiredClause+`
+					ORDER BY m.ts ASC, m.rowid ASC
+					LIMIT ?
+				`, ageCutoff, now, need)
+				if err != nil {
+					return res, fmt.Errorf("select oldest messages: %w", err)
+				}
+			}
+		}
+	}
+	res.BySize = len(bySize)
+
+	all := append(byAge, bySize...)
+	res.Messages = len(all)
+	paths := map[string]int{} // local path -> removed messages referencing it
+	for _, c := range all {
+		res.Chats[c.chatJID]++
+		if c.localPath != "" {
+			paths[c.localPath]++
+		}
+	}
+
+	if res.MediaPaths, err = d.orphanedMedia(paths); err != nil {
+		return res, err
+	}
+
+	if dryRun {
+		return res, nil
+	}
+	for start := 0; start < len(all); start += pruneBatch {
+		end := start + pruneBatch
+		if end > len(all) {
+			end = len(all)
+		}
+		if err := d.deleteRowids(all[start:end]); err != nil {
+			return res, fmt.Errorf("delete messages: %w", err)
+		}
+	}
+	return res, nil
+}
+
+// DeleteChatMessages removes every stored message of a chat and its stars.
+// The chat itself is kept. MediaPaths lists the downloaded files no other
+// message points at, for the caller to remove.
+func (d *DB) DeleteChatMessages(chatJID string) (PruneResult, error) {
+	res := PruneResult{Chats: map[string]int{}}
+	rows, err := d.query(`SELECT COALESCE(local_path,'') FROM messages WHERE chat_jid = ?`, chatJID)
+	if err != nil {
+		return res, err
+	}
+	paths := map[string]int{}
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return res, err
+		}
+		res.Messages++
+		if path != "" {
+			paths[path]++
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return res, err
+	}
+	if res.MediaPaths, err = d.orphanedMedia(paths); err != nil {
+		return res, err
+	}
+
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(d.rebind(`DELETE FROM messages WHERE chat_jid = ?`), chatJID); err != nil {
+		return res, fmt.Errorf("delete messages: %w", err)
+	}
+	if _, err := tx.Exec(d.rebind(`DELETE FROM starred_messages WHERE chat_jid = ?`), chatJID); err != nil {
+		return res, fmt.Errorf("delete stars: %w", err)
+	}
+	if _, err := tx.Exec(d.rebind(`DELETE FROM message_receipts WHERE chat_jid = ?`), chatJID); err != nil {
+		return res, fmt.Errorf("delete receipts: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return res, err
+	}
+	if res.Messages > 0 {
+		res.Chats[chatJID] = res.Messages
+	}
+	return res, nil
+}
+
+// orphanedMedia returns the paths, sorted, whose every referencing message
+// is about to be removed; paths maps each to the number of such messages.
+func (d *DB) orphanedMedia(paths map[string]int) ([]string, error) {
+	var out []string
+	for path, removed := range paths {
+		var n int
+		if err := d.queryRow(`SELECT COUNT(1) FROM messages WHERE local_path = ?`, path).Scan(&n); err != nil {
+			return nil, err
+		}
+		if n <= removed {
+			out = append(out, path)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+type pruneCandidate struct {
+	rowid     int64
+	chatJID   string
+	localPath string
+}
+
+func (d *DB) pruneCandidates(query string, args ...interface{}) ([]pruneCandidate, error) {
+	rows, err := d.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []pruneCandidate
+	for rows.Next() {
+		var c pruneCandidate
+		if err := rows.Scan(&c.rowid, &c.chatJID, &c.localPath); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (d *DB) deleteRowids(batch []pruneCandidate) error {
+	args := make([]interface{}, len(batch))
+	for i, c := range batch {
+		args[i] = c.rowid
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(batch)), ",")
+	_, err := d.exec(`DELETE FROM messages WHERE rowid IN (`+placeholders+`)`, args...)
+	return err
+}
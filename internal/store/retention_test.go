@@ -0,0 +1,160 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPruneMessagesByAgeWithOverrides(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	chats := []string{"1@s.whatsapp.net", "2@s.whatsapp.net", "3@s.whatsapp.net"}
+	for _, chat := range chats {
+		if err := db.UpsertChat(chat, "dm", "", now); err != nil {
+			t.Fatalf("UpsertChat: %v", err)
+		}
+		for i, age := range []time.Duration{time.Hour, 10 * 24 * time.Hour, 100 * 24 * time.Hour} {
+			if err := db.UpsertMessage(UpsertMessageParams{
+				ChatJID:   chat,
+				MsgID:     fmt.Sprintf("m%d", i),
+				Timestamp: now.Add(-age),
+				Text:      "hello",
+			}); err != nil {
+				t.Fatalf("UpsertMessage: %v", err)
+			}
+		}
+	}
+	if err := db.MarkMediaDownloaded(chats[0], "m2", "/data/media/old.jpg", now); err != nil {
+		t.Fatalf("MarkMediaDownloaded: %v", err)
+	}
+	// Chat 2 keeps everything; chat 3 only keeps one week.
+	if err := db.SetChatRetention(chats[1], 0); err != nil {
+		t.Fatalf("SetChatRetention: %v", err)
+	}
+	if err := db.SetChatRetention(chats[2], 7*24*time.Hour); err != nil {
+		t.Fatalf("SetChatRetention: %v", err)
+	}
+
+	policy := RetentionPolicy{MaxAge: 30 * 24 * time.Hour, Now: now}
+	res, err := db.PruneMessages(policy, true)
+	if err != nil {
+		t.Fatalf("PruneMessages dry run: %v", err)
+	}
+	if res.Messages != 3 || res.Chats[chats[0]] != 1 || res.Chats[chats[1]] != 0 || res.Chats[chats[2]] != 2 {
+		t.Fatalf("unexpected dry run result: %+v", res)
+	}
+	if len(res.MediaPaths) != 1 || res.MediaPaths[0] != "/data/media/old.jpg" {
+		t.Fatalf("expected old media to be reported, got %v", res.MediaPaths)
+	}
+	if got := countRows(t, db.sql, `SELECT COUNT(1) FROM messages`); got != 9 {
+		t.Fatalf("dry run must not delete, have %d messages", got)
+	}
+
+	if _, err := db.PruneMessages(policy, false); err != nil {
+		t.Fatalf("PruneMessages: %v", err)
+	}
+	if got := countRows(t, db.sql, `SELECT COUNT(1) FROM messages`); got != 6 {
+		t.Fatalf("expected 6 messages left, got %d", got)
+	}
+	if got := countRows(t, db.sql, `SELECT COUNT(1) FROM messages WHERE chat_jid = ?`, chats[1]); got != 3 {
+		t.Fatalf("keep-forever chat lost messages, has %d", got)
+	}
+	if db.HasFTS() {
+		if got := countRows(t, db.sql, `SELECT COUNT(1) FROM messages_fts`); got != 6 {
+			t.Fatalf("expected FTS rows to follow deletes, got %d", got)
+		}
+	}
+}
+
+func TestPruneMessagesBySize(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	chat := "1@s.whatsapp.net"
+	if err := db.UpsertChat(chat, "dm", "", now); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	for i := 0; i < 200; i++ {
+		if err := db.UpsertMessage(UpsertMessageParams{
+			ChatJID:   chat,
+			MsgID:     fmt.Sprintf("m%03d", i),
+			Timestamp: now.Add(time.Duration(i) * time.Minute),
+			Text:      fmt.Sprintf("%0512d", i),
+		}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+
+	size, err := db.DBBytes()
+	if err != nil {
+		t.Fatalf("DBBytes: %v", err)
+	}
+	res, err := db.PruneMessages(RetentionPolicy{MaxDBBytes: size / 2, Now: now}, false)
+	if err != nil {
+		t.Fatalf("PruneMessages: %v", err)
+	}
+	if res.BySize < 90 || res.BySize > 110 {
+		t.Fatalf("expected about half the messages removed, got %d", res.BySize)
+	}
+	// The oldest go first.
+	if got := countRows(t, db.sql, `SELECT COUNT(1) FROM messages WHERE msg_id = 'm000'`); got != 0 {
+		t.Fatalf("expected oldest message to be removed")
+	}
+	if got := countRows(t, db.sql, `SELECT COUNT(1) FROM messages WHERE msg_id = 'm199'`); got != 1 {
+		t.Fatalf("expected newest message to be kept")
+	}
+}
+
+func TestDeleteChatMessages(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	chats := []string{"1@s.whatsapp.net", "2@s.whatsapp.net"}
+	for _, chat := range chats {
+		if err := db.UpsertChat(chat, "dm", "", now); err != nil {
+			t.Fatalf("UpsertChat: %v", err)
+		}
+		for i := 0; i < 3; i++ {
+			if err := db.UpsertMessage(UpsertMessageParams{
+				ChatJID:   chat,
+				MsgID:     fmt.Sprintf("m%d", i),
+				Timestamp: now.Add(time.Duration(i) * time.Minute),
+				Text:      "hello",
+			}); err != nil {
+				t.Fatalf("UpsertMessage: %v", err)
+			}
+		}
+	}
+	// A forwarded file shared by both chats must survive.
+	_ = db.MarkMediaDownloaded(chats[0], "m0", "/data/media/own.jpg", now)
+	_ = db.MarkMediaDownloaded(chats[0], "m1", "/data/media/shared.jpg", now)
+	_ = db.MarkMediaDownloaded(chats[1], "m1", "/data/media/shared.jpg", now)
+	if err := db.SetMessageStarred(chats[0], "m2", true, now); err != nil {
+		t.Fatalf("SetMessageStarred: %v", err)
+	}
+
+	newest, err := db.GetNewestMessageInfo(chats[0])
+	if err != nil || newest.MsgID != "m2" {
+		t.Fatalf("GetNewestMessageInfo = %+v, %v", newest, err)
+	}
+
+	res, err := db.DeleteChatMessages(chats[0])
+	if err != nil {
+		t.Fatalf("DeleteChatMessages: %v", err)
+	}
+	if res.Messages != 3 || len(res.MediaPaths) != 1 || res.MediaPaths[0] != "/data/media/own.jpg" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if got := countRows(t, db.sql, `SELECT COUNT(1) FROM messages WHERE chat_jid = ?`, chats[0]); got != 0 {
+		t.Fatalf("expected chat to be empty, has %d messages", got)
+	}
+	if got := countRows(t, db.sql, `SELECT COUNT(1) FROM messages`); got != 3 {
+		t.Fatalf("other chat lost messages, %d left", got)
+	}
+	if got := countRows(t, db.sql, `SELECT COUNT(1) FROM starred_messages`); got != 0 {
+		t.Fatalf("expected stars to be removed, have %d", got)
+	}
+	if _, err := db.GetChat(chats[0]); err != nil {
+		t.Fatalf("chat must be kept: %v", err)
+	}
+}
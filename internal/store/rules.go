@@ -0,0 +1,100 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Rule is a routing rule for inbound messages. Conditions and Actions are
+// JSON documents interpreted by the rules package.
+type Rule struct {
+	ID         int64
+	Name       string
+	Enabled    bool
+	Priority   int // lower runs first
+	Conditions string
+	Actions    string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+const ruleColumns = `id, name, enabled, priority, conditions, actions, created_at, updated_at`
+
+// CreateRule stores a new rule. ID and the timestamps of r are ignored.
+func (d *DB) CreateRule(r Rule) (Rule, error) {
+	now := time.Now().UTC().Unix()
+	id, err := d.insertID(d.sql, `
+		INSERT INTO rules(name, enabled, priority, conditions, actions, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, ?)
+	`, r.Name, boolToInt(r.Enabled), r.Priority, r.Conditions, r.Actions, now, now)
+	if err != nil {
+		return Rule{}, err
+	}
+	r.ID = id
+	r.CreatedAt = fromUnix(now)
+	r.UpdatedAt = r.CreatedAt
+	return r, nil
+}
+
+// UpdateRule replaces the rule with r.ID. It returns sql.ErrNoRows if there
+// is none.
+func (d *DB) UpdateRule(r Rule) (Rule, error) {
+	res, err := d.exec(`
+		UPDATE rules SET name = ?, enabled = ?, priority = ?, conditions = ?, actions = ?, updated_at = ? WHERE id = ?
+	`, r.Name, boolToInt(r.Enabled), r.Priority, r.Conditions, r.Actions, time.Now().UTC().Unix(), r.ID)
+	if err != nil {
+		return Rule{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return Rule{}, sql.ErrNoRows
+	}
+	return d.GetRule(r.ID)
+}
+
+// GetRule returns a rule by id, or sql.ErrNoRows.
+func (d *DB) GetRule(id int64) (Rule, error) {
+	return scanRule(d.queryRow(`SELECT `+ruleColumns+` FROM rules WHERE id = ?`, id))
+}
+
+// ListRules returns all rules in evaluation order: by priority, then id.
+func (d *DB) ListRules() ([]Rule, error) {
+	rows, err := d.query(`SELECT ` + ruleColumns + ` FROM rules ORDER BY priority, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Rule
+	for rows.Next() {
+		r, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// DeleteRule removes a rule. It returns sql.ErrNoRows if there is none.
+func (d *DB) DeleteRule(id int64) error {
+	res, err := d.exec(`DELETE FROM rules WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func scanRule(row rowScanner) (Rule, error) {
+	var r Rule
+	var enabled int
+	var created, updated int64
+	if err := row.Scan(&r.ID, &r.Name, &enabled, &r.Priority, &r.Conditions, &r.Actions, &created, &updated); err != nil {
+		return Rule{}, err
+	}
+	r.Enabled = enabled != 0
+	r.CreatedAt = fromUnix(created)
+	r.UpdatedAt = fromUnix(updated)
+	return r, nil
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestRulesCRUD(t *testing.T) {
+	db := openTestDB(t)
+
+	late, err := db.CreateRule(Rule{Name: "late", Enabled: true, Priority: 10, Conditions: `{}`, Actions: `[{"type":"ignore"}]`})
+	if err != nil {
+		t.Fatalf("CreateRule: %v", err)
+	}
+	early, err := db.CreateRule(Rule{Name: "early", Enabled: true, Priority: 1, Conditions: `{"text":"invoice"}`, Actions: `[{"type":"tag","tag":"billing"}]`})
+	if err != nil {
+		t.Fatalf("CreateRule: %v", err)
+	}
+	if late.ID == 0 || early.ID == late.ID || late.CreatedAt.IsZero() {
+		t.Fatalf("unexpected rules: %+v %+v", late, early)
+	}
+
+	list, err := db.ListRules()
+	if err != nil {
+		t.Fatalf("ListRules: %v", err)
+	}
+	if len(list) != 2 || list[0].Name != "early" || list[1].Name != "late" {
+		t.Fatalf("unexpected order: %+v", list)
+	}
+
+	late.Enabled = false
+	late.Priority = 0
+	updated, err := db.UpdateRule(late)
+	if err != nil {
+		t.Fatalf("UpdateRule: %v", err)
+	}
+	if updated.Enabled || updated.Priority != 0 || updated.Actions != `[{"type":"ignore"}]` || !updated.CreatedAt.Equal(late.CreatedAt) {
+		t.Fatalf("unexpected rule after update: %+v", updated)
+	}
+	if list, _ := db.ListRules(); list[0].ID != late.ID {
+		t.Fatalf("expected updated priority to reorder rules, got %+v", list)
+	}
+
+	if _, err := db.UpdateRule(Rule{ID: 999, Conditions: `{}`, Actions: `[]`}); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("UpdateRule(missing) = %v, want sql.ErrNoRows", err)
+	}
+	if err := db.DeleteRule(early.ID); err != nil {
+		t.Fatalf("DeleteRule: %v", err)
+	}
+	if _, err := db.GetRule(early.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetRule(deleted) = %v, want sql.ErrNoRows", err)
+	}
+	if err := db.DeleteRule(early.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("DeleteRule(deleted) = %v, want sql.ErrNoRows", err)
+	}
+}
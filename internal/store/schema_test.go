@@ -27,6 +27,7 @@ func TestOpenCreatesExpectedSchema(t *testing.T) {
 		"sender_name",
 		"local_path",
 		"downloaded_at",
+		"spam_score",
 	} {
 		if !cols[want] {
 			t.Fatalf("expected messages column %q to exist", want)
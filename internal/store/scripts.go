@@ -0,0 +1,74 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Script is a user script run by the embedded scripting engine.
+type Script struct {
+	Name      string
+	Source    string
+	Enabled   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// PutScript creates or replaces a script.
+func (d *DB) PutScript(name, source string, enabled bool) error {
+	now := time.Now().UTC().Unix()
+	_, err := d.exec(`
+		INSERT INTO scripts(name, source, enabled, created_at, updated_at) VALUES(?, ?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET source=excluded.source, enabled=excluded.enabled, updated_at=excluded.updated_at
+	`, name, source, boolToInt(enabled), now, now)
+	return err
+}
+
+// GetScript returns a script by name, or sql.ErrNoRows.
+func (d *DB) GetScript(name string) (Script, error) {
+	return scanScript(d.queryRow(`SELECT name, source, enabled, created_at, updated_at FROM scripts WHERE name = ?`, name))
+}
+
+// ListScripts returns all scripts ordered by name.
+func (d *DB) ListScripts() ([]Script, error) {
+	rows, err := d.query(`SELECT name, source, enabled, created_at, updated_at FROM scripts ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Script
+	for rows.Next() {
+		s, err := scanScript(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// DeleteScript removes a script. It returns sql.ErrNoRows if there is none.
+func (d *DB) DeleteScript(name string) error {
+	res, err := d.exec(`DELETE FROM scripts WHERE name = ?`, name)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func scanScript(row rowScanner) (Script, error) {
+	var s Script
+	var enabled int
+	var created, updated int64
+	if err := row.Scan(&s.Name, &s.Source, &enabled, &created, &updated); err != nil {
+		return Script{}, err
+	}
+	s.Enabled = enabled != 0
+	s.CreatedAt = fromUnix(created)
+	s.UpdatedAt = fromUnix(updated)
+	return s, nil
+}
@@ -0,0 +1,43 @@
+package store
+
+import "testing"
+
+func TestScriptsCRUD(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := db.PutScript("greet", "function onMessage(m) {}", true); err != nil {
+		t.Fatalf("PutScript: %v", err)
+	}
+	if err := db.PutScript("away", "function onMessage(m) {}", false); err != nil {
+		t.Fatalf("PutScript: %v", err)
+	}
+	if err := db.PutScript("greet", "function onMessage(m) { sendText(m.chat_jid, 'hi') }", true); err != nil {
+		t.Fatalf("PutScript (update): %v", err)
+	}
+
+	s, err := db.GetScript("greet")
+	if err != nil {
+		t.Fatalf("GetScript: %v", err)
+	}
+	if !s.Enabled || s.Source != "function onMessage(m) { sendText(m.chat_jid, 'hi') }" || s.CreatedAt.IsZero() {
+		t.Fatalf("unexpected script: %+v", s)
+	}
+
+	list, err := db.ListScripts()
+	if err != nil {
+		t.Fatalf("ListScripts: %v", err)
+	}
+	if len(list) != 2 || list[0].Name != "away" || list[0].Enabled || list[1].Name != "greet" {
+		t.Fatalf("unexpected list: %+v", list)
+	}
+
+	if err := db.DeleteScript("away"); err != nil {
+		t.Fatalf("DeleteScript: %v", err)
+	}
+	if err := db.DeleteScript("away"); !IsNotFound(err) {
+		t.Fatalf("DeleteScript twice: got %v, want not found", err)
+	}
+	if _, err := db.GetScript("away"); !IsNotFound(err) {
+		t.Fatalf("GetScript deleted: got %v, want not found", err)
+	}
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"html"
+	"strings"
+)
+
+// Result orders for SearchMessagesParams.Sort.
+const (
+	SearchSortRank = "rank" // best matches first
+	SearchSortTime = "time" // most recent first
+)
+
+// Snippet highlight styles for SearchMessagesParams.Highlight.
+const (
+	HighlightBrackets = "brackets" // [match]
+	HighlightMark     = "mark"     // HTML-escaped, with <mark>match</mark>
+)
+
+const (
+	defaultSnippetTokens = 12
+	maxSnippetTokens     = 64 // FTS5's limit
+)
+
+// Matches are marked with control characters in SQL and highlighted in Go,
+// so the rest of the snippet can be escaped for HTML.
+const (
+	snippetStart = "\x02"
+	snippetEnd   = "\x03"
+)
+
+var (
+	bracketHighlighter = strings.NewReplacer(snippetStart, "[", snippetEnd, "]")
+	markHighlighter    = strings.NewReplacer(snippetStart, "<mark>", snippetEnd, "</mark>")
+)
+
+func highlightSnippet(s, style string) string {
+	if style == HighlightMark {
+		return markHighlighter.Replace(html.EscapeString(s))
+	}
+	return bracketHighlighter.Replace(s)
+}
+
+// rankedRow scans a search result row, which has the rank after the snippet.
+type rankedRow struct {
+	rowScanner
+	rank *float64
+}
+
+func (r rankedRow) Scan(dest ...interface{}) error {
+	return r.rowScanner.Scan(append(dest, r.rank)...)
+}
+
+func (d *DB) scanSearchResults(query, highlight string, args ...interface{}) ([]Message, error) {
+	rows, err := d.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Message
+	for rows.Next() {
+		var rank float64
+		m, err := scanMessage(rankedRow{rows, &rank})
+		if err != nil {
+			return nil, err
+		}
+		m.Rank = rank
+		m.Snippet = highlightSnippet(m.Snippet, highlight)
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
@@ -3,6 +3,7 @@
 package store
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -41,3 +42,45 @@ func TestSearchMessagesUsesFTSWhenEnabled(t *testing.T) {
 		t.Fatalf("expected snippet for FTS search, got empty")
 	}
 }
+
+func TestSearchMessagesRankAndHighlight(t *testing.T) {
+	db := openTestDB(t)
+	chat := "123@s.whatsapp.net"
+	if err := db.UpsertChat(chat, "dm", "Alice", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, text := range []string{
+		"pizza pizza pizza <b>tonight</b>",
+		"maybe pizza later, or pasta, or something else entirely",
+	} {
+		if err := db.UpsertMessage(UpsertMessageParams{
+			ChatJID:   chat,
+			MsgID:     string(rune('a' + i)),
+			SenderJID: chat,
+			Timestamp: base.Add(time.Duration(i) * time.Hour),
+			Text:      text,
+		}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+
+	ms, err := db.SearchMessages(SearchMessagesParams{Query: "pizza", Highlight: HighlightMark})
+	if err != nil || len(ms) != 2 {
+		t.Fatalf("SearchMessages = %+v, %v", ms, err)
+	}
+	if ms[0].MsgID != "a" || ms[0].Rank <= ms[1].Rank || ms[1].Rank <= 0 {
+		t.Fatalf("not ranked by relevance: %s=%v, %s=%v", ms[0].MsgID, ms[0].Rank, ms[1].MsgID, ms[1].Rank)
+	}
+	if want := "<mark>pizza</mark> <mark>pizza</mark> <mark>pizza</mark> &lt;b&gt;tonight&lt;/b&gt;"; ms[0].Snippet != want {
+		t.Fatalf("snippet = %q, want %q", ms[0].Snippet, want)
+	}
+
+	ms, err = db.SearchMessages(SearchMessagesParams{Query: "pizza", Sort: SearchSortTime, SnippetTokens: 3})
+	if err != nil || len(ms) != 2 || ms[0].MsgID != "b" {
+		t.Fatalf("SearchMessages by time = %+v, %v", ms, err)
+	}
+	if !strings.Contains(ms[0].Snippet, "[pizza]") || len(strings.Fields(ms[0].Snippet)) > 4 {
+		t.Fatalf("snippet = %q", ms[0].Snippet)
+	}
+}
@@ -0,0 +1,28 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/steipete/wacli/internal/dbcrypt"
+)
+
+// SnapshotSQLite writes a transactionally consistent copy of the SQLite
+// database at src to dst using VACUUM INTO. It works while other connections
+// keep writing to src; dst must not exist yet.
+func SnapshotSQLite(src, dst string) error {
+	if _, err := os.Stat(src); err != nil {
+		return err
+	}
+	db, err := sql.Open(dbcrypt.Driver, fmt.Sprintf("file:%s?mode=ro&_busy_timeout=5000", src))
+	if err != nil {
+		return fmt.Errorf("open sqlite: %w", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`VACUUM INTO ?`, dst); err != nil {
+		return fmt.Errorf("vacuum into: %w", err)
+	}
+	return nil
+}
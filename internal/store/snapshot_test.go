@@ -0,0 +1,32 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshotSQLite(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.UpsertChat("123@s.whatsapp.net", "dm", "Alice", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "snapshot.db")
+	if err := SnapshotSQLite(db.path, dst); err != nil {
+		t.Fatalf("SnapshotSQLite: %v", err)
+	}
+
+	snap, err := Open(dst)
+	if err != nil {
+		t.Fatalf("Open snapshot: %v", err)
+	}
+	defer snap.Close()
+	if n := countRows(t, snap.sql, `SELECT COUNT(1) FROM chats WHERE jid = ?`, "123@s.whatsapp.net"); n != 1 {
+		t.Fatalf("expected chat in snapshot, got %d rows", n)
+	}
+
+	if err := SnapshotSQLite(db.path, dst); err == nil {
+		t.Fatalf("expected error when snapshot target exists")
+	}
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"strings"
+	"time"
+)
+
+// SetMessageSpamScore records the spam score computed for a message.
+func (d *DB) SetMessageSpamScore(chatJID, msgID string, score float64) error {
+	_, err := d.exec(`UPDATE messages SET spam_score = ? WHERE chat_jid = ? AND msg_id = ?`, score, chatJID, msgID)
+	return err
+}
+
+// CountDuplicateTextChats returns how many other chats received the same
+// inbound text since the given time.
+func (d *DB) CountDuplicateTextChats(text, excludeChatJID string, since time.Time) (int, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, nil
+	}
+	var n int
+	err := d.queryRow(`
+		SELECT COUNT(DISTINCT chat_jid)
+		FROM messages
+		WHERE ts >= ? AND from_me = 0 AND chat_jid != ? AND text = ?
+	`, unix(since), excludeChatJID, text).Scan(&n)
+	return n, err
+}
+
+// IsKnownSender reports whether jid is a saved contact (has a contact-book
+// name) or a chat we have written to ourselves.
+func (d *DB) IsKnownSender(jid string) (bool, error) {
+	var n int
+	err := d.queryRow(`
+		SELECT
+			(SELECT COUNT(1) FROM contacts WHERE jid = ? AND (COALESCE(full_name,'') != '' OR COALESCE(first_name,'') != '')) +
+			(SELECT COUNT(1) FROM contact_aliases WHERE jid = ?) +
+			(SELECT COUNT(1) FROM messages WHERE chat_jid = ? AND from_me = 1)
+	`, jid, jid, jid).Scan(&n)
+	return n > 0, err
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpamSignalsAndScore(t *testing.T) {
+	db := openTestDB(t)
+
+	now := time.Now().UTC()
+	for _, chat := range []string{"a@s.whatsapp.net", "b@s.whatsapp.net", "c@s.whatsapp.net"} {
+		if err := db.UpsertChat(chat, "dm", "", now); err != nil {
+			t.Fatalf("UpsertChat: %v", err)
+		}
+		if err := db.UpsertMessage(UpsertMessageParams{ChatJID: chat, MsgID: "x-" + chat, Timestamp: now, Text: "cheap pills http://x.example"}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+
+	n, err := db.CountDuplicateTextChats("cheap pills http://x.example", "a@s.whatsapp.net", now.Add(-time.Hour))
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 duplicate chats, got %d (err=%v)", n, err)
+	}
+
+	known, err := db.IsKnownSender("a@s.whatsapp.net")
+	if err != nil || known {
+		t.Fatalf("expected unknown sender, got %v (err=%v)", known, err)
+	}
+	if err := db.UpsertContact("a@s.whatsapp.net", "1", "", "Alice Doe", "", ""); err != nil {
+		t.Fatalf("UpsertContact: %v", err)
+	}
+	if known, _ := db.IsKnownSender("a@s.whatsapp.net"); !known {
+		t.Fatalf("expected saved contact to be known")
+	}
+
+	if err := db.SetMessageSpamScore("b@s.whatsapp.net", "x-b@s.whatsapp.net", 0.9); err != nil {
+		t.Fatalf("SetMessageSpamScore: %v", err)
+	}
+	m, err := db.GetMessage("b@s.whatsapp.net", "x-b@s.whatsapp.net")
+	if err != nil {
+		t.Fatalf("GetMessage: %v", err)
+	}
+	if m.SpamScore != 0.9 {
+		t.Fatalf("expected spam score 0.9, got %v", m.SpamScore)
+	}
+
+	if err := db.SetChatArchived("b@s.whatsapp.net", true); err != nil {
+		t.Fatalf("SetChatArchived: %v", err)
+	}
+	c, err := db.GetChat("b@s.whatsapp.net")
+	if err != nil || !c.Archived {
+		t.Fatalf("expected archived chat, got %+v (err=%v)", c, err)
+	}
+}
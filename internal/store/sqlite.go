@@ -0,0 +1,84 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SQLiteOptions tunes the SQLite connections of a store. Zero values take
+// the defaults of DefaultSQLiteOptions.
+type SQLiteOptions struct {
+	JournalMode string        // PRAGMA journal_mode: DELETE, TRUNCATE, PERSIST, MEMORY, WAL or OFF
+	Synchronous string        // PRAGMA synchronous: OFF, NORMAL, FULL or EXTRA
+	BusyTimeout time.Duration // how long to wait for a lock before failing with SQLITE_BUSY
+	CacheSize   int           // PRAGMA cache_size: pages if positive, KiB if negative
+	MmapSize    int64         // PRAGMA mmap_size in bytes; negative disables memory mapping
+}
+
+// DefaultSQLiteOptions returns the defaults: WAL so API reads do not wait
+// for history sync writes, a busy timeout long enough to ride out a large
+// sync batch, a 64 MiB page cache and 256 MiB of memory-mapped I/O.
+func DefaultSQLiteOptions() SQLiteOptions {
+	return SQLiteOptions{
+		JournalMode: "WAL",
+		Synchronous: "NORMAL",
+		BusyTimeout: 5 * time.Second,
+		CacheSize:   -64000,
+		MmapSize:    256 << 20,
+	}
+}
+
+// withDefaults fills in unset options.
+func (o SQLiteOptions) withDefaults() SQLiteOptions {
+	def := DefaultSQLiteOptions()
+	if o.JournalMode == "" {
+		o.JournalMode = def.JournalMode
+	}
+	if o.Synchronous == "" {
+		o.Synchronous = def.Synchronous
+	}
+	if o.BusyTimeout == 0 {
+		o.BusyTimeout = def.BusyTimeout
+	}
+	if o.CacheSize == 0 {
+		o.CacheSize = def.CacheSize
+	}
+	if o.MmapSize == 0 {
+		o.MmapSize = def.MmapSize
+	}
+	if o.MmapSize < 0 {
+		o.MmapSize = 0
+	}
+	return o
+}
+
+// Validate checks the option values; they end up in PRAGMA statements,
+// which cannot take bound parameters.
+func (o SQLiteOptions) Validate() error {
+	switch strings.ToUpper(o.JournalMode) {
+	case "", "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF":
+	default:
+		return fmt.Errorf("invalid journal mode: %s", o.JournalMode)
+	}
+	switch strings.ToUpper(o.Synchronous) {
+	case "", "OFF", "NORMAL", "FULL", "EXTRA":
+	default:
+		return fmt.Errorf("invalid synchronous mode: %s", o.Synchronous)
+	}
+	if o.BusyTimeout < 0 {
+		return fmt.Errorf("busy timeout must not be negative")
+	}
+	return nil
+}
+
+// pragmas returns the statements run on every new connection.
+func (o SQLiteOptions) pragmas() []string {
+	return []string{
+		"PRAGMA journal_mode=" + strings.ToUpper(o.JournalMode),
+		"PRAGMA synchronous=" + strings.ToUpper(o.Synchronous),
+		fmt.Sprintf("PRAGMA cache_size=%d", o.CacheSize),
+		fmt.Sprintf("PRAGMA mmap_size=%d", o.MmapSize),
+		"PRAGMA temp_store=MEMORY",
+	}
+}
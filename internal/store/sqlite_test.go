@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOpenWithOptionsAppliesPragmasPerConnection(t *testing.T) {
+	db, err := OpenWithOptions(filepath.Join(t.TempDir(), "wacli.db"), SQLiteOptions{
+		Synchronous: "full",
+		BusyTimeout: 1500 * time.Millisecond,
+		CacheSize:   -2000,
+	})
+	if err != nil {
+		t.Fatalf("OpenWithOptions: %v", err)
+	}
+	defer db.Close()
+
+	// Hold two connections at once so the pool cannot hand out the same one.
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		conn, err := db.sql.Conn(ctx)
+		if err != nil {
+			t.Fatalf("Conn: %v", err)
+		}
+		defer conn.Close()
+
+		var mode string
+		var sync, timeout, cache int
+		if err := conn.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&mode); err != nil || mode != "wal" {
+			t.Fatalf("conn %d: journal_mode = %q, %v", i, mode, err)
+		}
+		if err := conn.QueryRowContext(ctx, "PRAGMA synchronous").Scan(&sync); err != nil || sync != 2 {
+			t.Fatalf("conn %d: synchronous = %d, %v", i, sync, err)
+		}
+		if err := conn.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&timeout); err != nil || timeout != 1500 {
+			t.Fatalf("conn %d: busy_timeout = %d, %v", i, timeout, err)
+		}
+		if err := conn.QueryRowContext(ctx, "PRAGMA cache_size").Scan(&cache); err != nil || cache != -2000 {
+			t.Fatalf("conn %d: cache_size = %d, %v", i, cache, err)
+		}
+	}
+}
+
+func TestSQLiteOptionsValidate(t *testing.T) {
+	for _, o := range []SQLiteOptions{
+		{JournalMode: "wal; DROP TABLE chats"},
+		{Synchronous: "sometimes"},
+		{BusyTimeout: -time.Second},
+	} {
+		if err := o.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", o)
+		}
+	}
+	if err := DefaultSQLiteOptions().Validate(); err != nil {
+		t.Errorf("defaults: %v", err)
+	}
+}
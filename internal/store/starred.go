@@ -0,0 +1,52 @@
+package store
+
+import (
+	"strings"
+	"time"
+)
+
+// SetMessageStarred stars or unstars a message. Stars are kept apart from the
+// message rows so one synced from the phone before the message itself arrives
+// is not lost.
+func (d *DB) SetMessageStarred(chatJID, msgID string, starred bool, at time.Time) error {
+	if !starred {
+		_, err := d.exec(`DELETE FROM starred_messages WHERE chat_jid = ? AND msg_id = ?`, chatJID, msgID)
+		return err
+	}
+	_, err := d.exec(`
+		INSERT INTO starred_messages(chat_jid, msg_id, starred_at) VALUES(?, ?, ?)
+		ON CONFLICT(chat_jid, msg_id) DO UPDATE SET starred_at=excluded.starred_at
+	`, chatJID, msgID, unix(at))
+	return err
+}
+
+type ListStarredParams struct {
+	ChatJID string
+	Limit   int
+	Before  *time.Time
+}
+
+// ListStarredMessages returns starred messages, newest first.
+func (d *DB) ListStarredMessages(p ListStarredParams) ([]Message, error) {
+	if p.Limit <= 0 {
+		p.Limit = 50
+	}
+	query := `
+		SELECT ` + messageColumns + `, ''
+		FROM starred_messages s
+		JOIN messages m ON m.chat_jid = s.chat_jid AND m.msg_id = s.msg_id
+		LEFT JOIN chats c ON c.jid = m.chat_jid
+		WHERE 1=1`
+	var args []interface{}
+	if strings.TrimSpace(p.ChatJID) != "" {
+		query += " AND m.chat_jid = ?"
+		args = append(args, p.ChatJID)
+	}
+	if p.Before != nil {
+		query += " AND m.ts < ?"
+		args = append(args, unix(*p.Before))
+	}
+	query += " ORDER BY m.ts DESC LIMIT ?"
+	args = append(args, p.Limit)
+	return d.scanMessages(query, args...)
+}
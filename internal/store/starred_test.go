@@ -0,0 +1,56 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStarredMessages(t *testing.T) {
+	db := openTestDB(t)
+
+	chat := "123@s.whatsapp.net"
+	base := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	if err := db.UpsertChat(chat, "dm", "Alice", base); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+
+	// A star synced before its message must survive until the message arrives.
+	if err := db.SetMessageStarred(chat, "m2", true, base); err != nil {
+		t.Fatalf("SetMessageStarred: %v", err)
+	}
+	for i, id := range []string{"m1", "m2", "m3"} {
+		if err := db.UpsertMessage(UpsertMessageParams{
+			ChatJID:   chat,
+			MsgID:     id,
+			SenderJID: chat,
+			Timestamp: base.Add(time.Duration(i) * time.Minute),
+			Text:      id,
+		}); err != nil {
+			t.Fatalf("UpsertMessage %s: %v", id, err)
+		}
+	}
+	if err := db.SetMessageStarred(chat, "m3", true, base); err != nil {
+		t.Fatalf("SetMessageStarred: %v", err)
+	}
+
+	starred, err := db.ListStarredMessages(ListStarredParams{Limit: 10})
+	if err != nil {
+		t.Fatalf("ListStarredMessages: %v", err)
+	}
+	if len(starred) != 2 || starred[0].MsgID != "m3" || starred[1].MsgID != "m2" || !starred[0].Starred {
+		t.Fatalf("unexpected starred messages: %+v", starred)
+	}
+
+	if err := db.SetMessageStarred(chat, "m3", false, base); err != nil {
+		t.Fatalf("SetMessageStarred: %v", err)
+	}
+	msgs, err := db.ListMessages(ListMessagesParams{ChatJID: chat, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListMessages: %v", err)
+	}
+	for _, m := range msgs {
+		if m.Starred != (m.MsgID == "m2") {
+			t.Fatalf("unexpected starred flag on %s: %v", m.MsgID, m.Starred)
+		}
+	}
+}
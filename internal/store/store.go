@@ -6,30 +6,41 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/steipete/wacli/internal/dbcrypt"
 )
 
 type DB struct {
 	path       string
 	sql        *sql.DB
 	ftsEnabled bool
+	postgres   bool // queries are written for SQLite and rebound for Postgres
 }
 
+// Open opens the SQLite store at path with the default options.
 func Open(path string) (*DB, error) {
+	return OpenWithOptions(path, SQLiteOptions{})
+}
+
+// OpenWithOptions opens the SQLite store at path, applying opts to every
+// connection of the pool.
+func OpenWithOptions(path string, opts SQLiteOptions) (*DB, error) {
 	if strings.TrimSpace(path) == "" {
 		return nil, fmt.Errorf("db path is required")
 	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	opts = opts.withDefaults()
 	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
 		return nil, fmt.Errorf("create db directory: %w", err)
 	}
 
-	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000", path))
-	if err != nil {
-		return nil, fmt.Errorf("open sqlite: %w", err)
-	}
+	dsn := fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=%d", path, opts.BusyTimeout.Milliseconds())
+	db := dbcrypt.OpenDB(dsn, opts.pragmas()...)
 
 	s := &DB{path: path, sql: db}
 	if err := s.init(); err != nil {
@@ -46,100 +57,409 @@ func (d *DB) Close() error {
 	return d.sql.Close()
 }
 
-func (d *DB) init() error {
-	// Pragmas: keep consistent for writers/readers.
-	_, _ = d.sql.Exec("PRAGMA journal_mode=WAL;")
-	_, _ = d.sql.Exec("PRAGMA synchronous=NORMAL;")
-	_, _ = d.sql.Exec("PRAGMA temp_store=MEMORY;")
-	_, _ = d.sql.Exec("PRAGMA foreign_keys=ON;")
-
-	if err := d.ensureSchema(); err != nil {
-		return err
+// rebind rewrites ? placeholders to $1, $2, ... for Postgres.
+func (d *DB) rebind(query string) string {
+	if !d.postgres {
+		return query
 	}
-	return nil
+	return rebindPostgres(query)
 }
 
-func (d *DB) ensureSchema() error {
-	if _, err := d.sql.Exec(`
-		CREATE TABLE IF NOT EXISTS chats (
-			jid TEXT PRIMARY KEY,
-			kind TEXT NOT NULL, -- dm|group|broadcast|unknown
-			name TEXT,
-			last_message_ts INTEGER
-		);
+func (d *DB) exec(query string, args ...interface{}) (sql.Result, error) {
+	return d.sql.Exec(d.rebind(query), args...)
+}
 
-		CREATE TABLE IF NOT EXISTS contacts (
-			jid TEXT PRIMARY KEY,
-			phone TEXT,
-			push_name TEXT,
-			full_name TEXT,
-			first_name TEXT,
-			business_name TEXT,
-			updated_at INTEGER NOT NULL
-		);
+func (d *DB) query(query string, args ...interface{}) (*sql.Rows, error) {
+	return d.sql.Query(d.rebind(query), args...)
+}
 
-		CREATE TABLE IF NOT EXISTS groups (
-			jid TEXT PRIMARY KEY,
-			name TEXT,
-			owner_jid TEXT,
-			created_ts INTEGER,
-			updated_at INTEGER NOT NULL
-		);
+func (d *DB) queryRow(query string, args ...interface{}) *sql.Row {
+	return d.sql.QueryRow(d.rebind(query), args...)
+}
 
-		CREATE TABLE IF NOT EXISTS group_participants (
-			group_jid TEXT NOT NULL,
-			user_jid TEXT NOT NULL,
-			role TEXT,
-			updated_at INTEGER NOT NULL,
-			PRIMARY KEY (group_jid, user_jid),
-			FOREIGN KEY (group_jid) REFERENCES groups(jid) ON DELETE CASCADE
-		);
+// execer is satisfied by *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
 
-		CREATE TABLE IF NOT EXISTS contact_aliases (
-			jid TEXT PRIMARY KEY,
-			alias TEXT NOT NULL,
-			notes TEXT,
-			updated_at INTEGER NOT NULL
-		);
+// insertID runs an INSERT into a table with an "id" key and returns the new
+// id, or sql.ErrNoRows if nothing was inserted.
+func (d *DB) insertID(ex execer, query string, args ...interface{}) (int64, error) {
+	if d.postgres {
+		var id int64
+		err := ex.QueryRow(d.rebind(strings.TrimSpace(query))+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+	res, err := ex.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, sql.ErrNoRows
+	}
+	return res.LastInsertId()
+}
 
-		CREATE TABLE IF NOT EXISTS contact_tags (
-			jid TEXT NOT NULL,
-			tag TEXT NOT NULL,
-			updated_at INTEGER NOT NULL,
-			PRIMARY KEY (jid, tag)
-		);
+func (d *DB) init() error {
+	if d.postgres {
+		return d.ensurePostgresSchema()
+	}
 
-		CREATE TABLE IF NOT EXISTS messages (
-			rowid INTEGER PRIMARY KEY AUTOINCREMENT,
-			chat_jid TEXT NOT NULL,
-			chat_name TEXT,
-			msg_id TEXT NOT NULL,
-			sender_jid TEXT,
-			sender_name TEXT,
-			ts INTEGER NOT NULL,
-			from_me INTEGER NOT NULL,
-			text TEXT,
-			media_type TEXT,
-			media_caption TEXT,
-			filename TEXT,
-			mime_type TEXT,
-			direct_path TEXT,
-			media_key BLOB,
-			file_sha256 BLOB,
-			file_enc_sha256 BLOB,
-			file_length INTEGER,
-			local_path TEXT,
-			downloaded_at INTEGER,
-			UNIQUE(chat_jid, msg_id),
-			FOREIGN KEY (chat_jid) REFERENCES chats(jid) ON DELETE CASCADE
-		);
+	if err := d.ensureSchema(); err != nil {
+		return err
+	}
+	return nil
+}
 
-		CREATE INDEX IF NOT EXISTS idx_messages_chat_ts ON messages(chat_jid, ts);
-		CREATE INDEX IF NOT EXISTS idx_messages_ts ON messages(ts);
-	`); err != nil {
+// schema creates the tables shared by all backends, in SQLite syntax;
+// Postgres translates the column types (see postgresTypes).
+const schema = `
+	CREATE TABLE IF NOT EXISTS chats (
+		jid TEXT PRIMARY KEY,
+		kind TEXT NOT NULL, -- dm|group|broadcast|unknown
+		name TEXT,
+		last_message_ts INTEGER
+	);
+
+	CREATE TABLE IF NOT EXISTS contacts (
+		jid TEXT PRIMARY KEY,
+		phone TEXT,
+		push_name TEXT,
+		full_name TEXT,
+		first_name TEXT,
+		business_name TEXT,
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS groups (
+		jid TEXT PRIMARY KEY,
+		name TEXT,
+		owner_jid TEXT,
+		created_ts INTEGER,
+		updated_at INTEGER NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		is_community INTEGER NOT NULL DEFAULT 0,
+		community_jid TEXT NOT NULL DEFAULT '', -- parent community of a linked group
+		is_announcement_group INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE TABLE IF NOT EXISTS group_participants (
+		group_jid TEXT NOT NULL,
+		user_jid TEXT NOT NULL,
+		role TEXT,
+		updated_at INTEGER NOT NULL,
+		PRIMARY KEY (group_jid, user_jid),
+		FOREIGN KEY (group_jid) REFERENCES groups(jid) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS contact_aliases (
+		jid TEXT PRIMARY KEY,
+		alias TEXT NOT NULL,
+		notes TEXT,
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS contact_tags (
+		jid TEXT NOT NULL,
+		tag TEXT NOT NULL,
+		updated_at INTEGER NOT NULL,
+		PRIMARY KEY (jid, tag)
+	);
+
+	CREATE TABLE IF NOT EXISTS blocklist (
+		jid TEXT PRIMARY KEY,
+		blocked_at INTEGER NOT NULL -- when the block was first seen locally
+	);
+
+	-- LID (anonymous JID) to phone-number JID mapping, mirrored from
+	-- WhatsApp. Each LID maps to one phone number and vice versa.
+	CREATE TABLE IF NOT EXISTS lid_map (
+		lid TEXT PRIMARY KEY, -- <digits>@lid
+		pn TEXT NOT NULL, -- <digits>@s.whatsapp.net
+		updated_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_lid_map_pn ON lid_map(pn);
+
+	CREATE TABLE IF NOT EXISTS messages (
+		rowid INTEGER PRIMARY KEY AUTOINCREMENT,
+		chat_jid TEXT NOT NULL,
+		chat_name TEXT,
+		msg_id TEXT NOT NULL,
+		sender_jid TEXT,
+		sender_name TEXT,
+		ts INTEGER NOT NULL,
+		from_me INTEGER NOT NULL,
+		text TEXT,
+		media_type TEXT,
+		media_caption TEXT,
+		filename TEXT,
+		mime_type TEXT,
+		direct_path TEXT,
+		media_key BLOB,
+		file_sha256 BLOB,
+		file_enc_sha256 BLOB,
+		file_length INTEGER,
+		local_path TEXT,
+		downloaded_at INTEGER,
+		UNIQUE(chat_jid, msg_id),
+		FOREIGN KEY (chat_jid) REFERENCES chats(jid) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_messages_chat_ts ON messages(chat_jid, ts);
+	CREATE INDEX IF NOT EXISTS idx_messages_ts ON messages(ts);
+
+	CREATE TABLE IF NOT EXISTS replication_cursors (
+		name TEXT PRIMARY KEY,
+		last_rowid INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS webhook_queue (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		endpoint_url TEXT NOT NULL,
+		event_type TEXT NOT NULL,
+		payload BLOB NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS webhook_deadletter (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		endpoint_url TEXT NOT NULL,
+		event_type TEXT NOT NULL,
+		payload BLOB NOT NULL,
+		attempts INTEGER NOT NULL,
+		last_error TEXT,
+		created_at INTEGER NOT NULL,
+		failed_at INTEGER NOT NULL
+	);
+
+	-- Chat filters set at runtime per webhook endpoint, overriding the
+	-- configured ones. Lists are JSON arrays of JIDs and chat kinds.
+	CREATE TABLE IF NOT EXISTS webhook_chat_filters (
+		endpoint_url TEXT PRIMARY KEY,
+		chats TEXT NOT NULL DEFAULT '[]',
+		exclude_chats TEXT NOT NULL DEFAULT '[]',
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS chat_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		token_hash TEXT NOT NULL UNIQUE,
+		chat_jid TEXT NOT NULL,
+		label TEXT,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS idx_chat_tokens_chat ON chat_tokens(chat_jid);
+
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		key_hash TEXT NOT NULL UNIQUE,
+		hint TEXT,
+		label TEXT,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL DEFAULT 0,
+		last_used_at INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE TABLE IF NOT EXISTS chat_retention (
+		chat_jid TEXT PRIMARY KEY,
+		max_age INTEGER NOT NULL, -- seconds; 0 = keep forever
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS labels (
+		id TEXT PRIMARY KEY, -- WhatsApp Business label id, or local-N
+		name TEXT NOT NULL,
+		color INTEGER NOT NULL DEFAULT 0,
+		synced INTEGER NOT NULL DEFAULT 0, -- 1 = WhatsApp Business label
+		updated_at INTEGER NOT NULL
+	);
+
+	-- No foreign key: associations synced from the phone may precede the label.
+	CREATE TABLE IF NOT EXISTS chat_labels (
+		chat_jid TEXT NOT NULL,
+		label_id TEXT NOT NULL,
+		PRIMARY KEY (chat_jid, label_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_chat_labels_label ON chat_labels(label_id);
+
+	-- No foreign key: stars synced from the phone may precede the message.
+	CREATE TABLE IF NOT EXISTS starred_messages (
+		chat_jid TEXT NOT NULL,
+		msg_id TEXT NOT NULL,
+		starred_at INTEGER NOT NULL,
+		PRIMARY KEY (chat_jid, msg_id)
+	);
+
+	-- Delivery and read receipts for our own messages, one row per recipient.
+	-- No foreign key: a receipt may arrive before the sent message is stored.
+	CREATE TABLE IF NOT EXISTS message_receipts (
+		chat_jid TEXT NOT NULL,
+		msg_id TEXT NOT NULL,
+		recipient_jid TEXT NOT NULL,
+		delivered_at INTEGER NOT NULL DEFAULT 0,
+		read_at INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (chat_jid, msg_id, recipient_jid)
+	);
+
+	-- Outbound text messages kept while disconnected, sent in id order.
+	CREATE TABLE IF NOT EXISTS outbox (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		chat_jid TEXT NOT NULL,
+		text TEXT NOT NULL,
+		status TEXT NOT NULL, -- queued|sending|sent|failed|cancelled
+		msg_id TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT '',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_outbox_status ON outbox(status, id);
+
+	-- Sends that failed at WhatsApp, kept so they can be retried as is. Files
+	-- are kept next to the database under failed/<id>.
+	CREATE TABLE IF NOT EXISTS failed_sends (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		chat_jid TEXT NOT NULL,
+		kind TEXT NOT NULL, -- text|file
+		text TEXT NOT NULL DEFAULT '', -- message, or caption of a file
+		filename TEXT NOT NULL DEFAULT '',
+		mime_type TEXT NOT NULL DEFAULT '',
+		size INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 1,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	-- Incoming calls. The API cannot answer them, only record or reject them.
+	CREATE TABLE IF NOT EXISTS calls (
+		call_id TEXT PRIMARY KEY,
+		from_jid TEXT NOT NULL,
+		group_jid TEXT NOT NULL DEFAULT '',
+		video INTEGER NOT NULL DEFAULT 0,
+		status TEXT NOT NULL, -- ringing|answered|rejected|missed
+		ts INTEGER NOT NULL,
+		ended_at INTEGER NOT NULL DEFAULT 0,
+		end_reason TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX IF NOT EXISTS idx_calls_ts ON calls(ts);
+
+	-- WhatsApp Channels (newsletters). Their posts are kept apart from chat
+	-- messages since they have no sender and carry view and reaction counts.
+	CREATE TABLE IF NOT EXISTS channels (
+		jid TEXT PRIMARY KEY,
+		name TEXT NOT NULL DEFAULT '',
+		description TEXT NOT NULL DEFAULT '',
+		invite_code TEXT NOT NULL DEFAULT '',
+		subscribers INTEGER NOT NULL DEFAULT 0,
+		verified INTEGER NOT NULL DEFAULT 0,
+		role TEXT NOT NULL DEFAULT '', -- owner|admin|subscriber|guest
+		muted INTEGER NOT NULL DEFAULT 0,
+		followed INTEGER NOT NULL DEFAULT 0,
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS channel_posts (
+		channel_jid TEXT NOT NULL,
+		msg_id TEXT NOT NULL,
+		server_id INTEGER NOT NULL DEFAULT 0,
+		ts INTEGER NOT NULL,
+		text TEXT NOT NULL DEFAULT '',
+		media_type TEXT NOT NULL DEFAULT '',
+		media_caption TEXT NOT NULL DEFAULT '',
+		views INTEGER NOT NULL DEFAULT 0,
+		reactions TEXT NOT NULL DEFAULT '', -- JSON object of emoji to count
+		PRIMARY KEY (channel_jid, msg_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_channel_posts_ts ON channel_posts(channel_jid, ts);
+
+	-- The current or last backfill-all run; only id 1 is used.
+	CREATE TABLE IF NOT EXISTS backfill_runs (
+		id INTEGER PRIMARY KEY,
+		count INTEGER NOT NULL,
+		requests INTEGER NOT NULL,
+		wait_seconds INTEGER NOT NULL,
+		concurrency INTEGER NOT NULL,
+		interval_seconds INTEGER NOT NULL,
+		status TEXT NOT NULL, -- running|completed|cancelled
+		started_at INTEGER NOT NULL,
+		finished_at INTEGER NOT NULL DEFAULT 0
+	);
+
+	-- Per-chat checkpoints of the backfill-all run.
+	CREATE TABLE IF NOT EXISTS backfill_chats (
+		chat_jid TEXT PRIMARY KEY,
+		position INTEGER NOT NULL, -- processing order, most recent chat first
+		status TEXT NOT NULL, -- pending|running|done|skipped|failed
+		requests_sent INTEGER NOT NULL DEFAULT 0,
+		messages_added INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		updated_at INTEGER NOT NULL
+	);
+
+	-- Scripts for the embedded scripting engine (auto-replies and the like).
+	CREATE TABLE IF NOT EXISTS scripts (
+		name TEXT PRIMARY KEY,
+		source TEXT NOT NULL,
+		enabled INTEGER NOT NULL DEFAULT 1,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	-- Per-chat overrides of whether the auto-responder replies.
+	CREATE TABLE IF NOT EXISTS chat_responder (
+		chat_jid TEXT PRIMARY KEY,
+		enabled INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	-- Routing rules for inbound messages. Conditions and actions are JSON.
+	CREATE TABLE IF NOT EXISTS rules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		enabled INTEGER NOT NULL DEFAULT 1,
+		priority INTEGER NOT NULL DEFAULT 0,
+		conditions TEXT NOT NULL,
+		actions TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+`
+
+// columns were added after the initial schema; older databases are migrated
+// in place.
+var columns = []struct{ table, column, decl string }{
+	{"messages", "spam_score", "REAL"},
+	{"messages", "duration", "INTEGER NOT NULL DEFAULT 0"}, // seconds, audio and video
+	{"messages", "reply_to_id", "TEXT NOT NULL DEFAULT ''"},
+	{"messages", "reply_to_sender", "TEXT NOT NULL DEFAULT ''"},
+	{"messages", "edited_text", "TEXT NOT NULL DEFAULT ''"},
+	{"messages", "edited_at", "INTEGER NOT NULL DEFAULT 0"},
+	{"messages", "revoked", "INTEGER NOT NULL DEFAULT 0"},
+	{"chats", "archived", "INTEGER NOT NULL DEFAULT 0"},
+	{"chats", "pinned", "INTEGER NOT NULL DEFAULT 0"},
+	{"chats", "muted_until", "INTEGER NOT NULL DEFAULT 0"}, // unix seconds; -1 = forever
+	{"chats", "unread_count", "INTEGER NOT NULL DEFAULT 0"},
+	{"groups", "description", "TEXT NOT NULL DEFAULT ''"},
+	{"groups", "is_community", "INTEGER NOT NULL DEFAULT 0"},
+	{"groups", "community_jid", "TEXT NOT NULL DEFAULT ''"},
+	{"groups", "is_announcement_group", "INTEGER NOT NULL DEFAULT 0"},
+	{"message_receipts", "played_at", "INTEGER NOT NULL DEFAULT 0"},
+	{"api_keys", "read_only", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+func (d *DB) ensureSchema() error {
+	if _, err := d.sql.Exec(schema); err != nil {
 		return fmt.Errorf("create tables: %w", err)
 	}
 
+	if err := d.ensureColumns(); err != nil {
+		return err
+	}
+
 	if _, err := d.sql.Exec(`
 		CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(
 			text,
@@ -183,6 +503,57 @@ func (d *DB) ensureSchema() error {
 	return nil
 }
 
+func (d *DB) ensureColumns() error {
+	for _, c := range columns {
+		if err := d.ensureColumn(c.table, c.column, c.decl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureColumn adds a column to an existing table if it is missing.
+func (d *DB) ensureColumn(table, column, decl string) error {
+	if d.postgres {
+		if _, err := d.sql.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", table, column, postgresTypes.Replace(decl))); err != nil {
+			return fmt.Errorf("add %s.%s: %w", table, column, err)
+		}
+		return nil
+	}
+	exists, err := d.hasColumn(table, column)
+	if err != nil || exists {
+		return err
+	}
+	if _, err := d.sql.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, decl)); err != nil {
+		return fmt.Errorf("add %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// hasColumn reports whether a SQLite table has the named column.
+func (d *DB) hasColumn(table, column string) (bool, error) {
+	rows, err := d.sql.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		return false, fmt.Errorf("inspect %s: %w", table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			return false, fmt.Errorf("inspect %s: %w", table, err)
+		}
+		if strings.EqualFold(name, column) {
+			return true, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("inspect %s: %w", table, err)
+	}
+	return false, nil
+}
+
 // --- domain types + helpers
 
 type Chat struct {
@@ -190,14 +561,27 @@ type Chat struct {
 	Kind          string
 	Name          string
 	LastMessageTS time.Time
+	Archived      bool
+	Pinned        bool
+	Muted         bool
+	MutedUntil    time.Time // zero when muted forever
+	UnreadCount   int
+	LastMessage   string   // text or caption of the newest message; "" if revoked or media only
+	LastSender    string   // sender name of the newest message, or its JID
+	Labels        []string // label names; only filled by ListChats
 }
 
 type Group struct {
-	JID       string
-	Name      string
-	OwnerJID  string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	JID         string
+	Name        string
+	OwnerJID    string
+	Description string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+
+	IsCommunity         bool   // community parent group
+	CommunityJID        string // community the group is linked to, if any
+	IsAnnouncementGroup bool   // the community's announcement group
 }
 
 type GroupParticipant struct {
@@ -232,7 +616,27 @@ type Message struct {
 	FromMe    bool
 	Text      string
 	MediaType string
+	Duration  int // seconds, audio and video
+	SpamScore float64
+	Starred   bool
 	Snippet   string
+	Rank      float64 // search relevance, higher is better; 0 without full-text search
+	// The message this one replies to, if any.
+	ReplyToID     string
+	ReplyToSender string
+	// Latest edit by the sender; Text keeps the original.
+	EditedText string
+	EditedAt   time.Time
+	Revoked    bool // deleted for everyone by the sender
+	// Both identities of the sender where the LID mapping is known; one of
+	// them equals SenderJID.
+	SenderPN  string
+	SenderLID string
+	// Delivery state of our own messages: the first recipient's receipts.
+	Status      string // sent|delivered|read|played; empty for incoming messages
+	DeliveredAt time.Time
+	ReadAt      time.Time
+	PlayedAt    time.Time
 }
 
 type MessageInfo struct {
@@ -275,18 +679,24 @@ func boolToInt(b bool) int {
 }
 
 func (d *DB) UpsertChat(jid, kind, name string, lastTS time.Time) error {
+	_, err := d.exec(upsertChatQuery, chatArgs(jid, kind, name, lastTS)...)
+	return err
+}
+
+const upsertChatQuery = `
+	INSERT INTO chats(jid, kind, name, last_message_ts)
+	VALUES(?, ?, ?, ?)
+	ON CONFLICT(jid) DO UPDATE SET
+		kind=excluded.kind,
+		name=CASE WHEN excluded.name IS NOT NULL AND excluded.name != '' THEN excluded.name ELSE chats.name END,
+		last_message_ts=CASE WHEN excluded.last_message_ts > COALESCE(chats.last_message_ts, 0) THEN excluded.last_message_ts ELSE chats.last_message_ts END
+`
+
+func chatArgs(jid, kind, name string, lastTS time.Time) []interface{} {
 	if strings.TrimSpace(kind) == "" {
 		kind = "unknown"
 	}
-	_, err := d.sql.Exec(`
-		INSERT INTO chats(jid, kind, name, last_message_ts)
-		VALUES(?, ?, ?, ?)
-		ON CONFLICT(jid) DO UPDATE SET
-			kind=excluded.kind,
-			name=CASE WHEN excluded.name IS NOT NULL AND excluded.name != '' THEN excluded.name ELSE chats.name END,
-			last_message_ts=CASE WHEN excluded.last_message_ts > COALESCE(chats.last_message_ts, 0) THEN excluded.last_message_ts ELSE chats.last_message_ts END
-	`, jid, kind, name, unix(lastTS))
-	return err
+	return []interface{}{jid, kind, name, unix(lastTS)}
 }
 
 type UpsertMessageParams struct {
@@ -307,38 +717,53 @@ type UpsertMessageParams struct {
 	FileSHA256    []byte
 	FileEncSHA256 []byte
 	FileLength    uint64
+	Duration      uint32 // seconds, audio and video
+	ReplyToID     string // the message this one replies to
+	ReplyToSender string
 }
 
 func (d *DB) UpsertMessage(p UpsertMessageParams) error {
-	_, err := d.sql.Exec(`
-		INSERT INTO messages(
-			chat_jid, chat_name, msg_id, sender_jid, sender_name, ts, from_me, text,
-			media_type, media_caption, filename, mime_type, direct_path,
-			media_key, file_sha256, file_enc_sha256, file_length
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(chat_jid, msg_id) DO UPDATE SET
-			chat_name=COALESCE(NULLIF(excluded.chat_name,''), messages.chat_name),
-			sender_jid=excluded.sender_jid,
-			sender_name=COALESCE(NULLIF(excluded.sender_name,''), messages.sender_name),
-			ts=excluded.ts,
-			from_me=excluded.from_me,
-			text=excluded.text,
-			media_type=excluded.media_type,
-			media_caption=excluded.media_caption,
-			filename=COALESCE(NULLIF(excluded.filename,''), messages.filename),
-			mime_type=COALESCE(NULLIF(excluded.mime_type,''), messages.mime_type),
-			direct_path=COALESCE(NULLIF(excluded.direct_path,''), messages.direct_path),
-			media_key=CASE WHEN excluded.media_key IS NOT NULL AND length(excluded.media_key)>0 THEN excluded.media_key ELSE messages.media_key END,
-			file_sha256=CASE WHEN excluded.file_sha256 IS NOT NULL AND length(excluded.file_sha256)>0 THEN excluded.file_sha256 ELSE messages.file_sha256 END,
-			file_enc_sha256=CASE WHEN excluded.file_enc_sha256 IS NOT NULL AND length(excluded.file_enc_sha256)>0 THEN excluded.file_enc_sha256 ELSE messages.file_enc_sha256 END,
-			file_length=CASE WHEN excluded.file_length>0 THEN excluded.file_length ELSE messages.file_length END
-	`, p.ChatJID, nullIfEmpty(p.ChatName), p.MsgID, nullIfEmpty(p.SenderJID), nullIfEmpty(p.SenderName), unix(p.Timestamp), boolToInt(p.FromMe), nullIfEmpty(p.Text),
-		nullIfEmpty(p.MediaType), nullIfEmpty(p.MediaCaption), nullIfEmpty(p.Filename), nullIfEmpty(p.MimeType), nullIfEmpty(p.DirectPath),
-		p.MediaKey, p.FileSHA256, p.FileEncSHA256, int64(p.FileLength),
-	)
+	_, err := d.exec(upsertMessageQuery, messageArgs(p)...)
 	return err
 }
 
+const upsertMessageQuery = `
+	INSERT INTO messages(
+		chat_jid, chat_name, msg_id, sender_jid, sender_name, ts, from_me, text,
+		media_type, media_caption, filename, mime_type, direct_path,
+		media_key, file_sha256, file_enc_sha256, file_length, duration,
+		reply_to_id, reply_to_sender
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(chat_jid, msg_id) DO UPDATE SET
+		chat_name=COALESCE(NULLIF(excluded.chat_name,''), messages.chat_name),
+		sender_jid=excluded.sender_jid,
+		sender_name=COALESCE(NULLIF(excluded.sender_name,''), messages.sender_name),
+		ts=excluded.ts,
+		from_me=excluded.from_me,
+		text=excluded.text,
+		media_type=excluded.media_type,
+		media_caption=excluded.media_caption,
+		filename=COALESCE(NULLIF(excluded.filename,''), messages.filename),
+		mime_type=COALESCE(NULLIF(excluded.mime_type,''), messages.mime_type),
+		direct_path=COALESCE(NULLIF(excluded.direct_path,''), messages.direct_path),
+		media_key=CASE WHEN excluded.media_key IS NOT NULL AND length(excluded.media_key)>0 THEN excluded.media_key ELSE messages.media_key END,
+		file_sha256=CASE WHEN excluded.file_sha256 IS NOT NULL AND length(excluded.file_sha256)>0 THEN excluded.file_sha256 ELSE messages.file_sha256 END,
+		file_enc_sha256=CASE WHEN excluded.file_enc_sha256 IS NOT NULL AND length(excluded.file_enc_sha256)>0 THEN excluded.file_enc_sha256 ELSE messages.file_enc_sha256 END,
+		file_length=CASE WHEN excluded.file_length>0 THEN excluded.file_length ELSE messages.file_length END,
+		duration=CASE WHEN excluded.duration>0 THEN excluded.duration ELSE messages.duration END,
+		reply_to_id=COALESCE(NULLIF(excluded.reply_to_id,''), messages.reply_to_id),
+		reply_to_sender=COALESCE(NULLIF(excluded.reply_to_sender,''), messages.reply_to_sender)
+`
+
+func messageArgs(p UpsertMessageParams) []interface{} {
+	return []interface{}{
+		p.ChatJID, nullIfEmpty(p.ChatName), p.MsgID, nullIfEmpty(p.SenderJID), nullIfEmpty(p.SenderName), unix(p.Timestamp), boolToInt(p.FromMe), nullIfEmpty(p.Text),
+		nullIfEmpty(p.MediaType), nullIfEmpty(p.MediaCaption), nullIfEmpty(p.Filename), nullIfEmpty(p.MimeType), nullIfEmpty(p.DirectPath),
+		p.MediaKey, p.FileSHA256, p.FileEncSHA256, int64(p.FileLength), int64(p.Duration),
+		strings.TrimSpace(p.ReplyToID), strings.TrimSpace(p.ReplyToSender),
+	}
+}
+
 func nullIfEmpty(s string) interface{} {
 	s = strings.TrimSpace(s)
 	if s == "" {
@@ -359,7 +784,7 @@ func (d *DB) ListMessages(p ListMessagesParams) ([]Message, error) {
 		p.Limit = 50
 	}
 	query := `
-		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.media_type,'')
+		SELECT ` + messageColumns + `, ''
 		FROM messages m
 		LEFT JOIN chats c ON c.jid = m.chat_jid
 		WHERE 1=1`
@@ -378,26 +803,7 @@ func (d *DB) ListMessages(p ListMessagesParams) ([]Message, error) {
 	}
 	query += " ORDER BY m.ts DESC LIMIT ?"
 	args = append(args, p.Limit)
-
-	rows, err := d.sql.Query(query, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var out []Message
-	for rows.Next() {
-		var m Message
-		var ts int64
-		var fromMe int
-		if err := rows.Scan(&m.ChatJID, &m.ChatName, &m.MsgID, &m.SenderJID, &ts, &fromMe, &m.Text, &m.MediaType); err != nil {
-			return nil, err
-		}
-		m.Timestamp = fromUnix(ts)
-		m.FromMe = fromMe != 0
-		out = append(out, m)
-	}
-	return out, rows.Err()
+	return d.scanMessages(query, args...)
 }
 
 type SearchMessagesParams struct {
@@ -408,6 +814,12 @@ type SearchMessagesParams struct {
 	Before  *time.Time
 	After   *time.Time
 	Type    string
+	// Full-text search only: result order (SearchSortRank by default),
+	// snippet length in tokens (12 by default, at most 64) and how matches
+	// are highlighted in snippets (HighlightBrackets by default).
+	Sort          string
+	SnippetTokens int
+	Highlight     string
 }
 
 func (d *DB) SearchMessages(p SearchMessagesParams) ([]Message, error) {
@@ -417,8 +829,17 @@ func (d *DB) SearchMessages(p SearchMessagesParams) ([]Message, error) {
 	if p.Limit <= 0 {
 		p.Limit = 50
 	}
+	if p.SnippetTokens <= 0 {
+		p.SnippetTokens = defaultSnippetTokens
+	}
+	if p.SnippetTokens > maxSnippetTokens {
+		p.SnippetTokens = maxSnippetTokens
+	}
 
 	if d.ftsEnabled {
+		if d.postgres {
+			return d.searchPostgresFTS(p)
+		}
 		return d.searchFTS(p)
 	}
 	return d.searchLIKE(p)
@@ -426,7 +847,7 @@ func (d *DB) SearchMessages(p SearchMessagesParams) ([]Message, error) {
 
 func (d *DB) searchLIKE(p SearchMessagesParams) ([]Message, error) {
 	query := `
-		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.media_type,''), ''
+		SELECT ` + messageColumns + `, '', 0
 		FROM messages m
 		LEFT JOIN chats c ON c.jid = m.chat_jid
 		WHERE (LOWER(m.text) LIKE LOWER(?) OR LOWER(m.media_caption) LIKE LOWER(?) OR LOWER(m.filename) LIKE LOWER(?) OR LOWER(COALESCE(m.chat_name,'')) LIKE LOWER(?) OR LOWER(COALESCE(m.sender_name,'')) LIKE LOWER(?) OR LOWER(COALESCE(c.name,'')) LIKE LOWER(?))`
@@ -435,22 +856,27 @@ func (d *DB) searchLIKE(p SearchMessagesParams) ([]Message, error) {
 	query, args = applyMessageFilters(query, args, p)
 	query += " ORDER BY m.ts DESC LIMIT ?"
 	args = append(args, p.Limit)
-	return d.scanMessages(query, args...)
+	return d.scanSearchResults(query, p.Highlight, args...)
 }
 
 func (d *DB) searchFTS(p SearchMessagesParams) ([]Message, error) {
+	// bm25 is lower for better matches; Rank is reported higher-is-better.
 	query := `
-		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.media_type,''),
-		       snippet(messages_fts, 0, '[', ']', '…', 12)
+		SELECT ` + messageColumns + `,
+		       snippet(messages_fts, 0, ?, ?, '…', ?), -bm25(messages_fts)
 		FROM messages_fts
 		JOIN messages m ON messages_fts.rowid = m.rowid
 		LEFT JOIN chats c ON c.jid = m.chat_jid
 		WHERE messages_fts MATCH ?`
-	args := []interface{}{p.Query}
+	args := []interface{}{snippetStart, snippetEnd, p.SnippetTokens, p.Query}
 	query, args = applyMessageFilters(query, args, p)
-	query += " ORDER BY bm25(messages_fts) LIMIT ?"
+	if p.Sort == SearchSortTime {
+		query += " ORDER BY m.ts DESC LIMIT ?"
+	} else {
+		query += " ORDER BY bm25(messages_fts) LIMIT ?"
+	}
 	args = append(args, p.Limit)
-	return d.scanMessages(query, args...)
+	return d.scanSearchResults(query, p.Highlight, args...)
 }
 
 func applyMessageFilters(query string, args []interface{}, p SearchMessagesParams) (string, []interface{}) {
@@ -478,7 +904,7 @@ func applyMessageFilters(query string, args []interface{}, p SearchMessagesParam
 }
 
 func (d *DB) scanMessages(query string, args ...interface{}) ([]Message, error) {
-	rows, err := d.sql.Query(query, args...)
+	rows, err := d.query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -486,39 +912,70 @@ func (d *DB) scanMessages(query string, args ...interface{}) ([]Message, error)
 
 	var out []Message
 	for rows.Next() {
-		var m Message
-		var ts int64
-		var fromMe int
-		if err := rows.Scan(&m.ChatJID, &m.ChatName, &m.MsgID, &m.SenderJID, &ts, &fromMe, &m.Text, &m.MediaType, &m.Snippet); err != nil {
+		m, err := scanMessage(rows)
+		if err != nil {
 			return nil, err
 		}
-		m.Timestamp = fromUnix(ts)
-		m.FromMe = fromMe != 0
 		out = append(out, m)
 	}
 	return out, rows.Err()
 }
 
-func (d *DB) GetMessage(chatJID, msgID string) (Message, error) {
-	row := d.sql.QueryRow(`
-		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.media_type,'')
-		FROM messages m
-		LEFT JOIN chats c ON c.jid = m.chat_jid
-		WHERE m.chat_jid = ? AND m.msg_id = ?
-	`, chatJID, msgID)
+// messageColumns is the column list shared by Message queries (which append a
+// snippet column); keep in sync with scanMessage.
+const messageColumns = `m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.media_type,''),
+		       COALESCE(m.duration,0), COALESCE(m.spam_score,0), COALESCE(m.reply_to_id,''), COALESCE(m.reply_to_sender,''),
+		       COALESCE(m.edited_text,''), COALESCE(m.edited_at,0), COALESCE(m.revoked,0),
+		       COALESCE((SELECT l.pn FROM lid_map l WHERE l.lid = m.sender_jid), (SELECT l.lid FROM lid_map l WHERE l.pn = m.sender_jid), ''),
+		       EXISTS(SELECT 1 FROM starred_messages s WHERE s.chat_jid = m.chat_jid AND s.msg_id = m.msg_id),
+		       COALESCE((SELECT MIN(NULLIF(r.delivered_at,0)) FROM message_receipts r WHERE r.chat_jid = m.chat_jid AND r.msg_id = m.msg_id),0),
+		       COALESCE((SELECT MIN(NULLIF(r.read_at,0)) FROM message_receipts r WHERE r.chat_jid = m.chat_jid AND r.msg_id = m.msg_id),0),
+		       COALESCE((SELECT MIN(NULLIF(r.played_at,0)) FROM message_receipts r WHERE r.chat_jid = m.chat_jid AND r.msg_id = m.msg_id),0)`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanMessage(row rowScanner) (Message, error) {
 	var m Message
-	var ts int64
-	var fromMe int
-	if err := row.Scan(&m.ChatJID, &m.ChatName, &m.MsgID, &m.SenderJID, &ts, &fromMe, &m.Text, &m.MediaType); err != nil {
+	var ts, editedAt, deliveredAt, readAt, playedAt int64
+	var fromMe, revoked int
+	var senderAlt string
+	if err := row.Scan(&m.ChatJID, &m.ChatName, &m.MsgID, &m.SenderJID, &ts, &fromMe, &m.Text, &m.MediaType,
+		&m.Duration, &m.SpamScore, &m.ReplyToID, &m.ReplyToSender, &m.EditedText, &editedAt, &revoked, &senderAlt,
+		&m.Starred, &deliveredAt, &readAt, &playedAt,
+		&m.Snippet); err != nil {
 		return Message{}, err
 	}
 	m.Timestamp = fromUnix(ts)
 	m.FromMe = fromMe != 0
+	m.EditedAt = fromUnix(editedAt)
+	m.Revoked = revoked != 0
+	m.SenderPN, m.SenderLID = m.SenderJID, senderAlt
+	if IsLID(m.SenderJID) {
+		m.SenderPN, m.SenderLID = senderAlt, m.SenderJID
+	}
+	if m.FromMe {
+		m.DeliveredAt = fromUnix(deliveredAt)
+		m.ReadAt = fromUnix(readAt)
+		m.PlayedAt = fromUnix(playedAt)
+		m.Status = receiptStatus(m.DeliveredAt, m.ReadAt, m.PlayedAt)
+	}
 	return m, nil
 }
 
+func (d *DB) GetMessage(chatJID, msgID string) (Message, error) {
+	row := d.queryRow(`
+		SELECT `+messageColumns+`, ''
+		FROM messages m
+		LEFT JOIN chats c ON c.jid = m.chat_jid
+		WHERE m.chat_jid = ? AND m.msg_id = ?
+	`, chatJID, msgID)
+	return scanMessage(row)
+}
+
 func (d *DB) CountMessages() (int64, error) {
-	row := d.sql.QueryRow(`SELECT COUNT(1) FROM messages`)
+	row := d.queryRow(`SELECT COUNT(1) FROM messages`)
 	var n int64
 	if err := row.Scan(&n); err != nil {
 		return 0, err
@@ -527,7 +984,7 @@ func (d *DB) CountMessages() (int64, error) {
 }
 
 func (d *DB) CountChats() (int64, error) {
-	row := d.sql.QueryRow(`SELECT COUNT(1) FROM chats`)
+	row := d.queryRow(`SELECT COUNT(1) FROM chats`)
 	var n int64
 	if err := row.Scan(&n); err != nil {
 		return 0, err
@@ -536,7 +993,7 @@ func (d *DB) CountChats() (int64, error) {
 }
 
 func (d *DB) CountContacts() (int64, error) {
-	row := d.sql.QueryRow(`SELECT COUNT(1) FROM contacts`)
+	row := d.queryRow(`SELECT COUNT(1) FROM contacts`)
 	var n int64
 	if err := row.Scan(&n); err != nil {
 		return 0, err
@@ -545,7 +1002,7 @@ func (d *DB) CountContacts() (int64, error) {
 }
 
 func (d *DB) CountGroups() (int64, error) {
-	row := d.sql.QueryRow(`SELECT COUNT(1) FROM groups`)
+	row := d.queryRow(`SELECT COUNT(1) FROM groups`)
 	var n int64
 	if err := row.Scan(&n); err != nil {
 		return 0, err
@@ -554,15 +1011,24 @@ func (d *DB) CountGroups() (int64, error) {
 }
 
 func (d *DB) GetOldestMessageInfo(chatJID string) (MessageInfo, error) {
+	return d.edgeMessageInfo(chatJID, "ASC")
+}
+
+// GetNewestMessageInfo returns the latest stored message of a chat.
+func (d *DB) GetNewestMessageInfo(chatJID string) (MessageInfo, error) {
+	return d.edgeMessageInfo(chatJID, "DESC")
+}
+
+func (d *DB) edgeMessageInfo(chatJID, order string) (MessageInfo, error) {
 	chatJID = strings.TrimSpace(chatJID)
 	if chatJID == "" {
 		return MessageInfo{}, fmt.Errorf("chat JID is required")
 	}
-	row := d.sql.QueryRow(`
+	row := d.queryRow(`
 		SELECT m.chat_jid, m.msg_id, m.ts, m.from_me, COALESCE(m.sender_jid,''), COALESCE(m.sender_name,'')
 		FROM messages m
 		WHERE m.chat_jid = ?
-		ORDER BY m.ts ASC
+		ORDER BY m.ts `+order+`
 		LIMIT 1
 	`, chatJID)
 	var out MessageInfo
@@ -577,7 +1043,7 @@ func (d *DB) GetOldestMessageInfo(chatJID string) (MessageInfo, error) {
 }
 
 func (d *DB) GetMediaDownloadInfo(chatJID, msgID string) (MediaDownloadInfo, error) {
-	row := d.sql.QueryRow(`
+	row := d.queryRow(`
 		SELECT m.chat_jid,
 		       COALESCE(c.name,''),
 		       m.msg_id,
@@ -624,7 +1090,7 @@ func (d *DB) GetMediaDownloadInfo(chatJID, msgID string) (MediaDownloadInfo, err
 }
 
 func (d *DB) MarkMediaDownloaded(chatJID, msgID, localPath string, downloadedAt time.Time) error {
-	_, err := d.sql.Exec(`
+	_, err := d.exec(`
 		UPDATE messages
 		SET local_path = ?, downloaded_at = ?
 		WHERE chat_jid = ? AND msg_id = ?
@@ -632,6 +1098,46 @@ func (d *DB) MarkMediaDownloaded(chatJID, msgID, localPath string, downloadedAt
 	return err
 }
 
+// PendingMedia is received media that has not been downloaded.
+type PendingMedia struct {
+	ChatJID    string
+	MsgID      string
+	MediaType  string
+	FileLength uint64
+	SpamScore  float64
+}
+
+// ListPendingMedia returns media received since the given time that has no
+// local copy, oldest first. Deleted messages are left out.
+func (d *DB) ListPendingMedia(since time.Time, limit int) ([]PendingMedia, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := d.query(`
+		SELECT chat_jid, msg_id, media_type, COALESCE(file_length,0), COALESCE(spam_score,0)
+		FROM messages
+		WHERE from_me = 0 AND revoked = 0 AND ts >= ?
+		  AND COALESCE(media_type,'') != '' AND COALESCE(direct_path,'') != '' AND COALESCE(local_path,'') = ''
+		ORDER BY ts LIMIT ?`, unix(since), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []PendingMedia
+	for rows.Next() {
+		var p PendingMedia
+		var size int64
+		if err := rows.Scan(&p.ChatJID, &p.MsgID, &p.MediaType, &size, &p.SpamScore); err != nil {
+			return nil, err
+		}
+		if size > 0 {
+			p.FileLength = uint64(size)
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
 func (d *DB) MessageContext(chatJID, msgID string, before, after int) ([]Message, error) {
 	if before < 0 {
 		before = 0
@@ -644,8 +1150,8 @@ func (d *DB) MessageContext(chatJID, msgID string, before, after int) ([]Message
 		return nil, err
 	}
 
-	beforeRows, err := d.sql.Query(`
-		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.media_type,''), ''
+	beforeRows, err := d.query(`
+		SELECT `+messageColumns+`, ''
 		FROM messages m
 		LEFT JOIN chats c ON c.jid = m.chat_jid
 		WHERE m.chat_jid = ? AND m.ts < ?
@@ -659,22 +1165,18 @@ func (d *DB) MessageContext(chatJID, msgID string, before, after int) ([]Message
 
 	var prev []Message
 	for beforeRows.Next() {
-		var m Message
-		var ts int64
-		var fromMe int
-		if err := beforeRows.Scan(&m.ChatJID, &m.ChatName, &m.MsgID, &m.SenderJID, &ts, &fromMe, &m.Text, &m.MediaType, &m.Snippet); err != nil {
+		m, err := scanMessage(beforeRows)
+		if err != nil {
 			return nil, err
 		}
-		m.Timestamp = fromUnix(ts)
-		m.FromMe = fromMe != 0
 		prev = append(prev, m)
 	}
 	if err := beforeRows.Err(); err != nil {
 		return nil, err
 	}
 
-	afterRows, err := d.sql.Query(`
-		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.media_type,''), ''
+	afterRows, err := d.query(`
+		SELECT `+messageColumns+`, ''
 		FROM messages m
 		LEFT JOIN chats c ON c.jid = m.chat_jid
 		WHERE m.chat_jid = ? AND m.ts > ?
@@ -688,14 +1190,10 @@ func (d *DB) MessageContext(chatJID, msgID string, before, after int) ([]Message
 
 	var next []Message
 	for afterRows.Next() {
-		var m Message
-		var ts int64
-		var fromMe int
-		if err := afterRows.Scan(&m.ChatJID, &m.ChatName, &m.MsgID, &m.SenderJID, &ts, &fromMe, &m.Text, &m.MediaType, &m.Snippet); err != nil {
+		m, err := scanMessage(afterRows)
+		if err != nil {
 			return nil, err
 		}
-		m.Timestamp = fromUnix(ts)
-		m.FromMe = fromMe != 0
 		next = append(next, m)
 	}
 	if err := afterRows.Err(); err != nil {
@@ -715,48 +1213,130 @@ func (d *DB) MessageContext(chatJID, msgID string, before, after int) ([]Message
 }
 
 func (d *DB) ListChats(query string, limit int) ([]Chat, error) {
-	if limit <= 0 {
-		limit = 50
+	return d.FilterChats(ListChatsParams{Query: query, Limit: limit})
+}
+
+// ListLabeledChats is ListChats restricted to chats carrying labelID.
+func (d *DB) ListLabeledChats(labelID, query string, limit int) ([]Chat, error) {
+	return d.FilterChats(ListChatsParams{Query: query, LabelID: labelID, Limit: limit})
+}
+
+// Chat orders for ListChatsParams.Sort.
+const (
+	ChatSortLastMessage = "last_message" // most recent first
+	ChatSortName        = "name"         // by name, or JID for unnamed chats
+)
+
+// ListChatsParams selects the chats returned by FilterChats. Nil flags
+// match either way.
+type ListChatsParams struct {
+	Query     string // matches name or JID
+	LabelID   string
+	Kind      string // dm, group, broadcast or channel; "" for all
+	HasUnread *bool
+	Archived  *bool
+	Sort      string // ChatSortLastMessage by default
+	Limit     int
+}
+
+// FilterChats lists the chats matching p.
+func (d *DB) FilterChats(p ListChatsParams) ([]Chat, error) {
+	if p.Limit <= 0 {
+		p.Limit = 50
 	}
-	q := `SELECT jid, kind, COALESCE(name,''), COALESCE(last_message_ts,0) FROM chats WHERE 1=1`
+	q := `SELECT ` + chatColumns + ` FROM chats WHERE 1=1`
 	var args []interface{}
-	if strings.TrimSpace(query) != "" {
+	if strings.TrimSpace(p.Query) != "" {
 		q += ` AND (LOWER(name) LIKE LOWER(?) OR LOWER(jid) LIKE LOWER(?))`
-		needle := "%" + query + "%"
+		needle := "%" + p.Query + "%"
 		args = append(args, needle, needle)
 	}
-	q += ` ORDER BY last_message_ts DESC LIMIT ?`
-	args = append(args, limit)
+	if p.LabelID != "" {
+		q += ` AND jid IN (SELECT chat_jid FROM chat_labels WHERE label_id = ?)`
+		args = append(args, p.LabelID)
+	}
+	switch p.Kind {
+	case "":
+	case "dm":
+		// Chats with LID users are stored with an unknown kind.
+		q += ` AND (kind = 'dm' OR jid LIKE '%@lid')`
+	default:
+		q += ` AND kind = ?`
+		args = append(args, p.Kind)
+	}
+	if p.HasUnread != nil {
+		if *p.HasUnread {
+			q += ` AND unread_count > 0`
+		} else {
+			q += ` AND unread_count = 0`
+		}
+	}
+	if p.Archived != nil {
+		q += ` AND archived = ?`
+		args = append(args, boolToInt(*p.Archived))
+	}
+	if p.Sort == ChatSortName {
+		q += ` ORDER BY LOWER(COALESCE(NULLIF(name,''), jid)), jid LIMIT ?`
+	} else {
+		q += ` ORDER BY last_message_ts DESC LIMIT ?`
+	}
+	args = append(args, p.Limit)
 
-	rows, err := d.sql.Query(q, args...)
+	rows, err := d.query(q, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	var out []Chat
 	for rows.Next() {
-		var c Chat
-		var ts int64
-		if err := rows.Scan(&c.JID, &c.Kind, &c.Name, &ts); err != nil {
+		c, err := scanChat(rows)
+		if err != nil {
 			return nil, err
 		}
-		c.LastMessageTS = fromUnix(ts)
 		out = append(out, c)
 	}
-	return out, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, d.fillChatLabels(out)
 }
 
 func (d *DB) GetChat(jid string) (Chat, error) {
-	row := d.sql.QueryRow(`SELECT jid, kind, COALESCE(name,''), COALESCE(last_message_ts,0) FROM chats WHERE jid = ?`, jid)
+	return scanChat(d.queryRow(`SELECT `+chatColumns+` FROM chats WHERE jid = ?`, jid))
+}
+
+// chatColumns is the column list read by scanChat. The newest message is
+// looked up per chat so a chat list needs no message queries of its own.
+const chatColumns = `jid, kind, COALESCE(name,''), COALESCE(last_message_ts,0), archived, pinned, muted_until, unread_count,
+	COALESCE((SELECT CASE WHEN m.revoked != 0 THEN '' ELSE COALESCE(NULLIF(m.edited_text,''), NULLIF(m.text,''), NULLIF(m.media_caption,''), '') END
+		FROM messages m WHERE m.chat_jid = chats.jid ORDER BY m.ts DESC LIMIT 1), ''),
+	COALESCE((SELECT COALESCE(NULLIF(m.sender_name,''), m.sender_jid, '')
+		FROM messages m WHERE m.chat_jid = chats.jid ORDER BY m.ts DESC LIMIT 1), '')`
+
+func scanChat(row rowScanner) (Chat, error) {
 	var c Chat
-	var ts int64
-	if err := row.Scan(&c.JID, &c.Kind, &c.Name, &ts); err != nil {
+	var ts, mutedUntil int64
+	var archived, pinned int
+	if err := row.Scan(&c.JID, &c.Kind, &c.Name, &ts, &archived, &pinned, &mutedUntil, &c.UnreadCount, &c.LastMessage, &c.LastSender); err != nil {
 		return Chat{}, err
 	}
 	c.LastMessageTS = fromUnix(ts)
+	c.Archived = archived != 0
+	c.Pinned = pinned != 0
+	switch {
+	case mutedUntil < 0:
+		c.Muted = true
+	case mutedUntil > time.Now().Unix():
+		c.Muted = true
+		c.MutedUntil = fromUnix(mutedUntil)
+	}
 	return c, nil
 }
 
+// SearchContacts finds contacts by alias, name, phone number or JID. Names
+// match regardless of case and accents ("joao" finds "João"), and loosely
+// by trigram similarity to tolerate typos; phone numbers match on their
+// digits, ignoring spaces, dashes and a leading +. Best matches come first.
 func (d *DB) SearchContacts(query string, limit int) ([]Contact, error) {
 	if strings.TrimSpace(query) == "" {
 		return nil, fmt.Errorf("query is required")
@@ -764,38 +1344,82 @@ func (d *DB) SearchContacts(query string, limit int) ([]Contact, error) {
 	if limit <= 0 {
 		limit = 50
 	}
-	q := `
+	rows, err := d.query(`
 		SELECT c.jid,
 		       COALESCE(c.phone,''),
 		       COALESCE(NULLIF(a.alias,''), ''),
 		       COALESCE(NULLIF(c.full_name,''), NULLIF(c.push_name,''), NULLIF(c.business_name,''), NULLIF(c.first_name,''), ''),
-		       c.updated_at
+		       c.updated_at,
+		       COALESCE(c.full_name,''), COALESCE(c.push_name,''), COALESCE(c.business_name,''), COALESCE(c.first_name,'')
 		FROM contacts c
-		LEFT JOIN contact_aliases a ON a.jid = c.jid
-		WHERE LOWER(COALESCE(a.alias,'')) LIKE LOWER(?) OR LOWER(COALESCE(c.full_name,'')) LIKE LOWER(?) OR LOWER(COALESCE(c.push_name,'')) LIKE LOWER(?) OR LOWER(COALESCE(c.phone,'')) LIKE LOWER(?) OR LOWER(c.jid) LIKE LOWER(?)
-		ORDER BY COALESCE(NULLIF(a.alias,''), NULLIF(c.full_name,''), NULLIF(c.push_name,''), c.jid)
-		LIMIT ?`
-	needle := "%" + query + "%"
-	rows, err := d.sql.Query(q, needle, needle, needle, needle, needle, limit)
+		LEFT JOIN contact_aliases a ON a.jid = c.jid`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var out []Contact
+
+	folded := foldText(query)
+	digits := phoneDigits(query)
+	lowered := strings.ToLower(strings.TrimSpace(query))
+	type scored struct {
+		Contact
+		score float64
+		sort  string
+	}
+	var matches []scored
 	for rows.Next() {
 		var c Contact
 		var updated int64
-		if err := rows.Scan(&c.JID, &c.Phone, &c.Alias, &c.Name, &updated); err != nil {
+		var fullName, pushName, businessName, firstName string
+		if err := rows.Scan(&c.JID, &c.Phone, &c.Alias, &c.Name, &updated, &fullName, &pushName, &businessName, &firstName); err != nil {
 			return nil, err
 		}
 		c.UpdatedAt = fromUnix(updated)
-		out = append(out, c)
+
+		score := 0.0
+		for _, name := range []string{c.Alias, fullName, pushName, businessName, firstName} {
+			score = max(score, matchScore(folded, foldText(name)))
+		}
+		if digits != "" && (strings.Contains(digitsOf(c.Phone), digits) || strings.Contains(digitsOf(strings.SplitN(c.JID, "@", 2)[0]), digits)) {
+			score = max(score, 1)
+		}
+		if strings.Contains(strings.ToLower(c.JID), lowered) {
+			score = max(score, 1)
+		}
+		if score == 0 {
+			continue
+		}
+		sortName := c.Alias
+		if sortName == "" {
+			sortName = c.Name
+		}
+		if sortName == "" {
+			sortName = c.JID
+		}
+		matches = append(matches, scored{Contact: c, score: score, sort: foldText(sortName)})
 	}
-	return out, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].sort < matches[j].sort
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	out := make([]Contact, len(matches))
+	for i, m := range matches {
+		out[i] = m.Contact
+	}
+	return out, nil
 }
 
 func (d *DB) GetContact(jid string) (Contact, error) {
-	row := d.sql.QueryRow(`
+	row := d.queryRow(`
 		SELECT c.jid,
 		       COALESCE(c.phone,''),
 		       COALESCE(NULLIF(a.alias,''), ''),
@@ -817,7 +1441,7 @@ func (d *DB) GetContact(jid string) (Contact, error) {
 }
 
 func (d *DB) ListTags(jid string) ([]string, error) {
-	rows, err := d.sql.Query(`SELECT tag FROM contact_tags WHERE jid = ? ORDER BY tag`, jid)
+	rows, err := d.query(`SELECT tag FROM contact_tags WHERE jid = ? ORDER BY tag`, jid)
 	if err != nil {
 		return nil, err
 	}
@@ -835,7 +1459,7 @@ func (d *DB) ListTags(jid string) ([]string, error) {
 
 func (d *DB) UpsertContact(jid, phone, pushName, fullName, firstName, businessName string) error {
 	now := time.Now().UTC().Unix()
-	_, err := d.sql.Exec(`
+	_, err := d.exec(`
 		INSERT INTO contacts(jid, phone, push_name, full_name, first_name, business_name, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(jid) DO UPDATE SET
@@ -851,7 +1475,7 @@ func (d *DB) UpsertContact(jid, phone, pushName, fullName, firstName, businessNa
 
 func (d *DB) UpsertGroup(jid, name, ownerJID string, created time.Time) error {
 	now := time.Now().UTC().Unix()
-	_, err := d.sql.Exec(`
+	_, err := d.exec(`
 		INSERT INTO groups(jid, name, owner_jid, created_ts, updated_at)
 		VALUES (?, ?, ?, ?, ?)
 		ON CONFLICT(jid) DO UPDATE SET
@@ -863,6 +1487,13 @@ func (d *DB) UpsertGroup(jid, name, ownerJID string, created time.Time) error {
 	return err
 }
 
+// SetGroupDescription sets the description (topic) of a known group; an empty
+// description clears it. Unknown groups are ignored.
+func (d *DB) SetGroupDescription(jid, description string) error {
+	_, err := d.exec(`UPDATE groups SET description = ?, updated_at = ? WHERE jid = ?`, description, time.Now().UTC().Unix(), jid)
+	return err
+}
+
 func (d *DB) ReplaceGroupParticipants(groupJID string, participants []GroupParticipant) error {
 	tx, err := d.sql.Begin()
 	if err != nil {
@@ -874,10 +1505,10 @@ func (d *DB) ReplaceGroupParticipants(groupJID string, participants []GroupParti
 		}
 	}()
 
-	if _, err = tx.Exec(`DELETE FROM group_participants WHERE group_jid = ?`, groupJID); err != nil {
+	if _, err = tx.Exec(d.rebind(`DELETE FROM group_participants WHERE group_jid = ?`), groupJID); err != nil {
 		return err
 	}
-	stmt, err := tx.Prepare(`INSERT INTO group_participants(group_jid, user_jid, role, updated_at) VALUES(?, ?, ?, ?)`)
+	stmt, err := tx.Prepare(d.rebind(`INSERT INTO group_participants(group_jid, user_jid, role, updated_at) VALUES(?, ?, ?, ?)`))
 	if err != nil {
 		return err
 	}
@@ -899,7 +1530,7 @@ func (d *DB) ListGroups(query string, limit int) ([]Group, error) {
 	if limit <= 0 {
 		limit = 50
 	}
-	q := `SELECT jid, COALESCE(name,''), COALESCE(owner_jid,''), COALESCE(created_ts,0), updated_at FROM groups WHERE 1=1`
+	q := `SELECT ` + groupColumns + ` FROM groups WHERE 1=1`
 	var args []interface{}
 	if strings.TrimSpace(query) != "" {
 		needle := "%" + query + "%"
@@ -908,32 +1539,52 @@ func (d *DB) ListGroups(query string, limit int) ([]Group, error) {
 	}
 	q += ` ORDER BY COALESCE(created_ts,0) DESC LIMIT ?`
 	args = append(args, limit)
-	rows, err := d.sql.Query(q, args...)
+	rows, err := d.query(q, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	var out []Group
 	for rows.Next() {
-		var g Group
-		var created, updated int64
-		if err := rows.Scan(&g.JID, &g.Name, &g.OwnerJID, &created, &updated); err != nil {
+		g, err := scanGroup(rows)
+		if err != nil {
 			return nil, err
 		}
-		g.CreatedAt = fromUnix(created)
-		g.UpdatedAt = fromUnix(updated)
 		out = append(out, g)
 	}
 	return out, rows.Err()
 }
 
+const groupColumns = `jid, COALESCE(name,''), COALESCE(owner_jid,''), description, COALESCE(created_ts,0), updated_at,
+	is_community, community_jid, is_announcement_group`
+
+// GetGroup returns a stored group, or sql.ErrNoRows.
+func (d *DB) GetGroup(jid string) (Group, error) {
+	return scanGroup(d.queryRow(`SELECT `+groupColumns+` FROM groups WHERE jid = ?`, jid))
+}
+
+func scanGroup(row rowScanner) (Group, error) {
+	var g Group
+	var created, updated int64
+	var community, announcement int
+	if err := row.Scan(&g.JID, &g.Name, &g.OwnerJID, &g.Description, &created, &updated,
+		&community, &g.CommunityJID, &announcement); err != nil {
+		return Group{}, err
+	}
+	g.CreatedAt = fromUnix(created)
+	g.UpdatedAt = fromUnix(updated)
+	g.IsCommunity = community != 0
+	g.IsAnnouncementGroup = announcement != 0
+	return g, nil
+}
+
 func (d *DB) SetAlias(jid, alias string) error {
 	alias = strings.TrimSpace(alias)
 	if alias == "" {
 		return fmt.Errorf("alias is required")
 	}
 	now := time.Now().UTC().Unix()
-	_, err := d.sql.Exec(`
+	_, err := d.exec(`
 		INSERT INTO contact_aliases(jid, alias, notes, updated_at)
 		VALUES (?, ?, NULL, ?)
 		ON CONFLICT(jid) DO UPDATE SET alias=excluded.alias, updated_at=excluded.updated_at
@@ -942,7 +1593,7 @@ func (d *DB) SetAlias(jid, alias string) error {
 }
 
 func (d *DB) RemoveAlias(jid string) error {
-	_, err := d.sql.Exec(`DELETE FROM contact_aliases WHERE jid = ?`, jid)
+	_, err := d.exec(`DELETE FROM contact_aliases WHERE jid = ?`, jid)
 	return err
 }
 
@@ -952,7 +1603,7 @@ func (d *DB) AddTag(jid, tag string) error {
 		return fmt.Errorf("tag is required")
 	}
 	now := time.Now().UTC().Unix()
-	_, err := d.sql.Exec(`
+	_, err := d.exec(`
 		INSERT INTO contact_tags(jid, tag, updated_at) VALUES(?, ?, ?)
 		ON CONFLICT(jid, tag) DO UPDATE SET updated_at=excluded.updated_at
 	`, jid, tag, now)
@@ -960,7 +1611,7 @@ func (d *DB) AddTag(jid, tag string) error {
 }
 
 func (d *DB) RemoveTag(jid, tag string) error {
-	_, err := d.sql.Exec(`DELETE FROM contact_tags WHERE jid = ? AND tag = ?`, jid, tag)
+	_, err := d.exec(`DELETE FROM contact_tags WHERE jid = ? AND tag = ?`, jid, tag)
 	return err
 }
 
@@ -9,6 +9,9 @@ import (
 
 func openTestDB(t *testing.T) *DB {
 	t.Helper()
+	if postgresTestDSN != "" {
+		return openPostgresTestDB(t, postgresTestDSN)
+	}
 	dir := t.TempDir()
 	path := filepath.Join(dir, "wacli.db")
 	db, err := Open(path)
@@ -185,6 +188,110 @@ func TestMediaDownloadInfoAndMarkDownloaded(t *testing.T) {
 	}
 }
 
+func TestListPendingMedia(t *testing.T) {
+	db := openTestDB(t)
+
+	chat := "123@s.whatsapp.net"
+	if err := db.UpsertChat(chat, "dm", "Alice", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i, p := range []UpsertMessageParams{
+		{MsgID: "old", MediaType: "image", DirectPath: "/d/old"},
+		{MsgID: "img", MediaType: "image", DirectPath: "/d/img", FileLength: 10},
+		{MsgID: "mine", MediaType: "image", DirectPath: "/d/mine", FromMe: true},
+		{MsgID: "text", Text: "hi"},
+		{MsgID: "done", MediaType: "video", DirectPath: "/d/done"},
+		{MsgID: "doc", MediaType: "document", DirectPath: "/d/doc"},
+	} {
+		p.ChatJID, p.SenderJID = chat, chat
+		p.Timestamp = base.Add(time.Duration(i) * time.Hour)
+		if err := db.UpsertMessage(p); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+	if err := db.MarkMediaDownloaded(chat, "done", "/tmp/done", base); err != nil {
+		t.Fatalf("MarkMediaDownloaded: %v", err)
+	}
+
+	pending, err := db.ListPendingMedia(base.Add(time.Hour), 10)
+	if err != nil {
+		t.Fatalf("ListPendingMedia: %v", err)
+	}
+	if len(pending) != 2 || pending[0].MsgID != "img" || pending[0].FileLength != 10 || pending[1].MsgID != "doc" {
+		t.Fatalf("ListPendingMedia = %+v", pending)
+	}
+	if pending, _ := db.ListPendingMedia(base, 1); len(pending) != 1 || pending[0].MsgID != "old" {
+		t.Fatalf("ListPendingMedia with limit = %+v", pending)
+	}
+}
+
+func TestMessageDurationKeptOnUpdate(t *testing.T) {
+	db := openTestDB(t)
+
+	chat := "123@s.whatsapp.net"
+	if err := db.UpsertChat(chat, "dm", "Alice", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	p := UpsertMessageParams{
+		ChatJID:   chat,
+		MsgID:     "voice",
+		SenderJID: chat,
+		Timestamp: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Text:      "[Audio]",
+		MediaType: "audio",
+		Duration:  42,
+	}
+	if err := db.UpsertMessage(p); err != nil {
+		t.Fatalf("UpsertMessage: %v", err)
+	}
+	// A later upsert without metadata (e.g. an edit) keeps the duration.
+	p.Duration = 0
+	if err := db.UpsertMessage(p); err != nil {
+		t.Fatalf("UpsertMessage: %v", err)
+	}
+	m, err := db.GetMessage(chat, "voice")
+	if err != nil {
+		t.Fatalf("GetMessage: %v", err)
+	}
+	if m.Duration != 42 {
+		t.Fatalf("Duration = %d, want 42", m.Duration)
+	}
+}
+
+func TestMessageReplyTo(t *testing.T) {
+	db := openTestDB(t)
+
+	chat := "123@g.us"
+	if err := db.UpsertChat(chat, "group", "Team", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	p := UpsertMessageParams{
+		ChatJID:       chat,
+		MsgID:         "reply",
+		SenderJID:     "1@s.whatsapp.net",
+		Timestamp:     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Text:          "agreed",
+		ReplyToID:     "orig",
+		ReplyToSender: "2@s.whatsapp.net",
+	}
+	if err := db.UpsertMessage(p); err != nil {
+		t.Fatalf("UpsertMessage: %v", err)
+	}
+	// Re-delivery without context (e.g. from a history sync) keeps it.
+	p.ReplyToID, p.ReplyToSender = "", ""
+	if err := db.UpsertMessage(p); err != nil {
+		t.Fatalf("UpsertMessage: %v", err)
+	}
+	m, err := db.GetMessage(chat, "reply")
+	if err != nil {
+		t.Fatalf("GetMessage: %v", err)
+	}
+	if m.ReplyToID != "orig" || m.ReplyToSender != "2@s.whatsapp.net" {
+		t.Fatalf("reply to = %q/%q", m.ReplyToID, m.ReplyToSender)
+	}
+}
+
 func TestContactsAliasTagsAndSearch(t *testing.T) {
 	db := openTestDB(t)
 
@@ -298,6 +405,9 @@ func TestGroupsUpsertListAndParticipantsReplace(t *testing.T) {
 	if err := db.UpsertGroup(gid, "Group", "owner@s.whatsapp.net", created); err != nil {
 		t.Fatalf("UpsertGroup: %v", err)
 	}
+	if err := db.SetGroupDescription(gid, "Weekly sync"); err != nil {
+		t.Fatalf("SetGroupDescription: %v", err)
+	}
 	if err := db.ReplaceGroupParticipants(gid, []GroupParticipant{
 		{GroupJID: gid, UserJID: "a@s.whatsapp.net", Role: "admin"},
 		{GroupJID: gid, UserJID: "b@s.whatsapp.net", Role: ""},
@@ -309,7 +419,7 @@ func TestGroupsUpsertListAndParticipantsReplace(t *testing.T) {
 	if err != nil {
 		t.Fatalf("ListGroups: %v", err)
 	}
-	if len(gs) != 1 || gs[0].JID != gid {
+	if len(gs) != 1 || gs[0].JID != gid || gs[0].Description != "Weekly sync" {
 		t.Fatalf("expected group in list, got %+v", gs)
 	}
 
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ChatToken is an API token restricted to a single chat. Only the SHA-256
+// hash of the token is stored.
+type ChatToken struct {
+	ID        int64
+	ChatJID   string
+	Label     string
+	CreatedAt time.Time
+	ExpiresAt time.Time // zero means no expiry
+}
+
+// Expired reports whether the token is past its expiry.
+func (t ChatToken) Expired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && !now.Before(t.ExpiresAt)
+}
+
+// CreateChatToken stores a chat-scoped token by hash.
+func (d *DB) CreateChatToken(tokenHash, chatJID, label string, expiresAt time.Time) (ChatToken, error) {
+	now := time.Now().UTC()
+	id, err := d.insertID(d.sql, `
+		INSERT INTO chat_tokens(token_hash, chat_jid, label, created_at, expires_at) VALUES(?, ?, ?, ?, ?)
+	`, tokenHash, chatJID, label, now.Unix(), unix(expiresAt))
+	if err != nil {
+		return ChatToken{}, err
+	}
+	return ChatToken{
+		ID:        id,
+		ChatJID:   chatJID,
+		Label:     label,
+		CreatedAt: fromUnix(now.Unix()),
+		ExpiresAt: fromUnix(unix(expiresAt)),
+	}, nil
+}
+
+// ChatTokenByHash looks up a token by hash. It returns sql.ErrNoRows if the
+// token is unknown.
+func (d *DB) ChatTokenByHash(tokenHash string) (ChatToken, error) {
+	var t ChatToken
+	var created, expires int64
+	var label sql.NullString
+	err := d.queryRow(`
+		SELECT id, chat_jid, label, created_at, expires_at FROM chat_tokens WHERE token_hash = ?
+	`, tokenHash).Scan(&t.ID, &t.ChatJID, &label, &created, &expires)
+	if err != nil {
+		return ChatToken{}, err
+	}
+	t.Label = label.String
+	t.CreatedAt = fromUnix(created)
+	t.ExpiresAt = fromUnix(expires)
+	return t, nil
+}
+
+// ListChatTokens returns tokens for chatJID, or all tokens if chatJID is empty.
+func (d *DB) ListChatTokens(chatJID string) ([]ChatToken, error) {
+	query := `SELECT id, chat_jid, label, created_at, expires_at FROM chat_tokens`
+	var args []interface{}
+	if chatJID != "" {
+		query += ` WHERE chat_jid = ?`
+		args = append(args, chatJID)
+	}
+	query += ` ORDER BY id ASC`
+
+	rows, err := d.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ChatToken
+	for rows.Next() {
+		var t ChatToken
+		var created, expires int64
+		var label sql.NullString
+		if err := rows.Scan(&t.ID, &t.ChatJID, &label, &created, &expires); err != nil {
+			return nil, err
+		}
+		t.Label = label.String
+		t.CreatedAt = fromUnix(created)
+		t.ExpiresAt = fromUnix(expires)
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// DeleteChatToken revokes a token. It returns sql.ErrNoRows if the id is
+// unknown.
+func (d *DB) DeleteChatToken(id int64) error {
+	res, err := d.exec(`DELETE FROM chat_tokens WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestChatTokensRoundTrip(t *testing.T) {
+	db := openTestDB(t)
+
+	expires := time.Now().UTC().Add(time.Hour)
+	created, err := db.CreateChatToken("hash-a", "123@s.whatsapp.net", "customer a", expires)
+	if err != nil {
+		t.Fatalf("CreateChatToken: %v", err)
+	}
+	if _, err := db.CreateChatToken("hash-b", "456@s.whatsapp.net", "", time.Time{}); err != nil {
+		t.Fatalf("CreateChatToken: %v", err)
+	}
+	if _, err := db.CreateChatToken("hash-a", "789@s.whatsapp.net", "", time.Time{}); err == nil {
+		t.Fatalf("expected duplicate token hash to fail")
+	}
+
+	got, err := db.ChatTokenByHash("hash-a")
+	if err != nil {
+		t.Fatalf("ChatTokenByHash: %v", err)
+	}
+	if got.ID != created.ID || got.ChatJID != "123@s.whatsapp.net" || got.Label != "customer a" || got.ExpiresAt.Unix() != expires.Unix() {
+		t.Fatalf("unexpected token: %+v", got)
+	}
+	if got.Expired(time.Now()) || !got.Expired(expires.Add(time.Second)) {
+		t.Fatalf("unexpected expiry for %+v", got)
+	}
+	if _, err := db.ChatTokenByHash("nope"); err != sql.ErrNoRows {
+		t.Fatalf("expected sql.ErrNoRows for unknown hash, got %v", err)
+	}
+
+	all, err := db.ListChatTokens("")
+	if err != nil || len(all) != 2 {
+		t.Fatalf("ListChatTokens(all) = %+v, %v", all, err)
+	}
+	one, err := db.ListChatTokens("456@s.whatsapp.net")
+	if err != nil || len(one) != 1 || !one[0].ExpiresAt.IsZero() {
+		t.Fatalf("ListChatTokens(chat) = %+v, %v", one, err)
+	}
+
+	if err := db.DeleteChatToken(created.ID); err != nil {
+		t.Fatalf("DeleteChatToken: %v", err)
+	}
+	if err := db.DeleteChatToken(created.ID); err != sql.ErrNoRows {
+		t.Fatalf("expected sql.ErrNoRows deleting twice, got %v", err)
+	}
+	if _, err := db.ChatTokenByHash("hash-a"); err != sql.ErrNoRows {
+		t.Fatalf("expected revoked token to be gone, got %v", err)
+	}
+}
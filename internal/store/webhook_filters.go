@@ -0,0 +1,79 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookChatFilter is a chat filter set at runtime for a webhook endpoint.
+type WebhookChatFilter struct {
+	EndpointURL  string
+	Chats        []string
+	ExcludeChats []string
+	UpdatedAt    time.Time
+}
+
+// SetWebhookChatFilter stores the filter of an endpoint, replacing any
+// earlier one.
+func (d *DB) SetWebhookChatFilter(f WebhookChatFilter) error {
+	chats, err := json.Marshal(nonNil(f.Chats))
+	if err != nil {
+		return err
+	}
+	exclude, err := json.Marshal(nonNil(f.ExcludeChats))
+	if err != nil {
+		return err
+	}
+	_, err = d.exec(`
+		INSERT INTO webhook_chat_filters(endpoint_url, chats, exclude_chats, updated_at) VALUES(?, ?, ?, ?)
+		ON CONFLICT(endpoint_url) DO UPDATE SET
+			chats=excluded.chats, exclude_chats=excluded.exclude_chats, updated_at=excluded.updated_at
+	`, f.EndpointURL, string(chats), string(exclude), unix(time.Now().UTC()))
+	return err
+}
+
+// DeleteWebhookChatFilter removes the filter of an endpoint, reporting
+// whether there was one.
+func (d *DB) DeleteWebhookChatFilter(endpointURL string) (bool, error) {
+	res, err := d.exec(`DELETE FROM webhook_chat_filters WHERE endpoint_url = ?`, endpointURL)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListWebhookChatFilters returns every stored filter.
+func (d *DB) ListWebhookChatFilters() ([]WebhookChatFilter, error) {
+	rows, err := d.query(`SELECT endpoint_url, chats, exclude_chats, updated_at FROM webhook_chat_filters ORDER BY endpoint_url`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []WebhookChatFilter
+	for rows.Next() {
+		var f WebhookChatFilter
+		var chats, exclude string
+		var updated int64
+		if err := rows.Scan(&f.EndpointURL, &chats, &exclude, &updated); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(chats), &f.Chats); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(exclude), &f.ExcludeChats); err != nil {
+			return nil, err
+		}
+		f.UpdatedAt = fromUnix(updated)
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
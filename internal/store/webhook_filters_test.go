@@ -0,0 +1,29 @@
+package store
+
+import "testing"
+
+func TestWebhookChatFilters(t *testing.T) {
+	db := openTestDB(t)
+	url := "https://example.com/hook"
+
+	if err := db.SetWebhookChatFilter(WebhookChatFilter{EndpointURL: url, Chats: []string{"dm"}}); err != nil {
+		t.Fatalf("SetWebhookChatFilter: %v", err)
+	}
+	if err := db.SetWebhookChatFilter(WebhookChatFilter{EndpointURL: url, ExcludeChats: []string{"group", "123@s.whatsapp.net"}}); err != nil {
+		t.Fatalf("SetWebhookChatFilter: %v", err)
+	}
+	fs, err := db.ListWebhookChatFilters()
+	if err != nil || len(fs) != 1 {
+		t.Fatalf("ListWebhookChatFilters = %+v, %v", fs, err)
+	}
+	if f := fs[0]; len(f.Chats) != 0 || len(f.ExcludeChats) != 2 || f.UpdatedAt.IsZero() {
+		t.Fatalf("expected filter replaced, got %+v", f)
+	}
+
+	if ok, err := db.DeleteWebhookChatFilter(url); err != nil || !ok {
+		t.Fatalf("DeleteWebhookChatFilter = %v, %v", ok, err)
+	}
+	if ok, _ := db.DeleteWebhookChatFilter(url); ok {
+		t.Fatalf("expected nothing left to delete")
+	}
+}
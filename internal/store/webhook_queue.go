@@ -0,0 +1,155 @@
+package store
+
+import "time"
+
+// WebhookQueueItem is a webhook delivery that has not been acknowledged yet.
+type WebhookQueueItem struct {
+	ID          int64
+	EndpointURL string
+	EventType   string
+	Payload     []byte
+	CreatedAt   time.Time
+}
+
+// EnqueueWebhookEvent persists a pending webhook delivery and returns its id.
+func (d *DB) EnqueueWebhookEvent(endpointURL, eventType string, payload []byte) (int64, error) {
+	return d.insertID(d.sql, `
+		INSERT INTO webhook_queue(endpoint_url, event_type, payload, created_at) VALUES(?, ?, ?, ?)
+	`, endpointURL, eventType, payload, time.Now().UTC().Unix())
+}
+
+// PendingWebhookEvents returns up to limit queued deliveries, oldest first.
+func (d *DB) PendingWebhookEvents(limit int) ([]WebhookQueueItem, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := d.query(`
+		SELECT id, endpoint_url, event_type, payload, created_at
+		FROM webhook_queue
+		ORDER BY id ASC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []WebhookQueueItem
+	for rows.Next() {
+		var it WebhookQueueItem
+		var created int64
+		if err := rows.Scan(&it.ID, &it.EndpointURL, &it.EventType, &it.Payload, &created); err != nil {
+			return nil, err
+		}
+		it.CreatedAt = fromUnix(created)
+		out = append(out, it)
+	}
+	return out, rows.Err()
+}
+
+// CountPendingWebhookEvents returns the number of queued deliveries.
+func (d *DB) CountPendingWebhookEvents() (int64, error) {
+	var n int64
+	err := d.queryRow(`SELECT COUNT(1) FROM webhook_queue`).Scan(&n)
+	return n, err
+}
+
+// DeleteWebhookEvent removes a queued delivery once it is no longer pending.
+func (d *DB) DeleteWebhookEvent(id int64) error {
+	_, err := d.exec(`DELETE FROM webhook_queue WHERE id = ?`, id)
+	return err
+}
+
+// WebhookDeadLetter is a webhook delivery that exhausted its retries.
+type WebhookDeadLetter struct {
+	ID          int64
+	EndpointURL string
+	EventType   string
+	Payload     []byte
+	Attempts    int
+	LastError   string
+	CreatedAt   time.Time
+	FailedAt    time.Time
+}
+
+// DeadLetterWebhookEvent moves a queued delivery into the dead-letter table.
+func (d *DB) DeadLetterWebhookEvent(queueID int64, attempts int, lastError string) (err error) {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec(d.rebind(`
+		INSERT INTO webhook_deadletter(endpoint_url, event_type, payload, attempts, last_error, created_at, failed_at)
+		SELECT endpoint_url, event_type, payload, CAST(? AS INTEGER), CAST(? AS TEXT), created_at, CAST(? AS BIGINT)
+		FROM webhook_queue WHERE id = ?
+	`), attempts, lastError, time.Now().UTC().Unix(), queueID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(d.rebind(`DELETE FROM webhook_queue WHERE id = ?`), queueID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListWebhookDeadLetters returns up to limit dead-lettered deliveries, newest first.
+func (d *DB) ListWebhookDeadLetters(limit int) ([]WebhookDeadLetter, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := d.query(`
+		SELECT id, endpoint_url, event_type, payload, attempts, COALESCE(last_error,''), created_at, failed_at
+		FROM webhook_deadletter
+		ORDER BY id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []WebhookDeadLetter
+	for rows.Next() {
+		var dl WebhookDeadLetter
+		var created, failed int64
+		if err := rows.Scan(&dl.ID, &dl.EndpointURL, &dl.EventType, &dl.Payload, &dl.Attempts, &dl.LastError, &created, &failed); err != nil {
+			return nil, err
+		}
+		dl.CreatedAt = fromUnix(created)
+		dl.FailedAt = fromUnix(failed)
+		out = append(out, dl)
+	}
+	return out, rows.Err()
+}
+
+// ReplayWebhookDeadLetter moves a dead-lettered delivery back into the queue
+// and returns its new queue id. It returns sql.ErrNoRows if id is unknown.
+func (d *DB) ReplayWebhookDeadLetter(id int64) (queueID int64, err error) {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	queueID, err = d.insertID(tx, `
+		INSERT INTO webhook_queue(endpoint_url, event_type, payload, created_at)
+		SELECT endpoint_url, event_type, payload, created_at
+		FROM webhook_deadletter WHERE id = ?
+	`, id)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = tx.Exec(d.rebind(`DELETE FROM webhook_deadletter WHERE id = ?`), id); err != nil {
+		return 0, err
+	}
+	return queueID, tx.Commit()
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestWebhookQueueRoundTrip(t *testing.T) {
+	db := openTestDB(t)
+
+	first, err := db.EnqueueWebhookEvent("http://a.example/hook", "message.received", []byte(`{"n":1}`))
+	if err != nil {
+		t.Fatalf("EnqueueWebhookEvent: %v", err)
+	}
+	if _, err := db.EnqueueWebhookEvent("http://b.example/hook", "group.joined", []byte(`{"n":2}`)); err != nil {
+		t.Fatalf("EnqueueWebhookEvent: %v", err)
+	}
+
+	items, err := db.PendingWebhookEvents(10)
+	if err != nil {
+		t.Fatalf("PendingWebhookEvents: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 pending events, got %d", len(items))
+	}
+	if items[0].ID != first || items[0].EndpointURL != "http://a.example/hook" || string(items[0].Payload) != `{"n":1}` {
+		t.Fatalf("unexpected first item: %+v", items[0])
+	}
+	if items[1].EventType != "group.joined" {
+		t.Fatalf("unexpected second item: %+v", items[1])
+	}
+
+	if err := db.DeleteWebhookEvent(first); err != nil {
+		t.Fatalf("DeleteWebhookEvent: %v", err)
+	}
+	items, err = db.PendingWebhookEvents(10)
+	if err != nil {
+		t.Fatalf("PendingWebhookEvents: %v", err)
+	}
+	if len(items) != 1 || items[0].EndpointURL != "http://b.example/hook" {
+		t.Fatalf("expected only b.example pending, got %+v", items)
+	}
+}
+
+func TestWebhookDeadLetterAndReplay(t *testing.T) {
+	db := openTestDB(t)
+
+	id, err := db.EnqueueWebhookEvent("http://a.example/hook", "message.received", []byte(`{"n":1}`))
+	if err != nil {
+		t.Fatalf("EnqueueWebhookEvent: %v", err)
+	}
+	if err := db.DeadLetterWebhookEvent(id, 4, "unexpected status: 500"); err != nil {
+		t.Fatalf("DeadLetterWebhookEvent: %v", err)
+	}
+	if items, _ := db.PendingWebhookEvents(10); len(items) != 0 {
+		t.Fatalf("expected empty queue after dead-lettering, got %+v", items)
+	}
+
+	dls, err := db.ListWebhookDeadLetters(10)
+	if err != nil {
+		t.Fatalf("ListWebhookDeadLetters: %v", err)
+	}
+	if len(dls) != 1 || dls[0].Attempts != 4 || dls[0].LastError != "unexpected status: 500" || string(dls[0].Payload) != `{"n":1}` {
+		t.Fatalf("unexpected dead letters: %+v", dls)
+	}
+
+	if _, err := db.ReplayWebhookDeadLetter(dls[0].ID + 100); err != sql.ErrNoRows {
+		t.Fatalf("expected sql.ErrNoRows for unknown id, got %v", err)
+	}
+	queueID, err := db.ReplayWebhookDeadLetter(dls[0].ID)
+	if err != nil {
+		t.Fatalf("ReplayWebhookDeadLetter: %v", err)
+	}
+	items, err := db.PendingWebhookEvents(10)
+	if err != nil {
+		t.Fatalf("PendingWebhookEvents: %v", err)
+	}
+	if len(items) != 1 || items[0].ID != queueID || items[0].EventType != "message.received" {
+		t.Fatalf("expected replayed event in queue, got %+v", items)
+	}
+	if dls, _ := db.ListWebhookDeadLetters(10); len(dls) != 0 {
+		t.Fatalf("expected dead letter to be removed, got %+v", dls)
+	}
+}
@@ -0,0 +1,107 @@
+// Package throttle paces outbound sends: a token bucket shared by all
+// recipients and a minimum gap between sends to the same recipient.
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle allows up to PerMinute sends per minute on average, bursts of up
+// to Burst sends, and at most one send per Cooldown to the same recipient.
+// The zero value and a nil *Throttle allow everything.
+type Throttle struct {
+	perMinute int
+	burst     int
+	cooldown  time.Duration
+
+	mu     sync.Mutex
+	tokens float64
+	filled time.Time
+	last   map[string]time.Time
+}
+
+// New creates a throttle. A perMinute of zero or less disables the global
+// limit, a burst of zero or less defaults to perMinute, and a zero cooldown
+// disables the per-recipient gap.
+func New(perMinute, burst int, cooldown time.Duration) *Throttle {
+	if burst <= 0 {
+		burst = perMinute
+	}
+	return &Throttle{
+		perMinute: perMinute,
+		burst:     burst,
+		cooldown:  cooldown,
+		tokens:    float64(burst),
+		last:      make(map[string]time.Time),
+	}
+}
+
+// Enabled reports whether the throttle limits anything.
+func (t *Throttle) Enabled() bool {
+	return t != nil && (t.perMinute > 0 || t.cooldown > 0)
+}
+
+// Reserve records a send to key at now if it is allowed and returns zero.
+// Otherwise it records nothing and returns how long to wait before trying
+// again.
+func (t *Throttle) Reserve(key string, now time.Time) time.Duration {
+	if !t.Enabled() {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var wait time.Duration
+	if t.cooldown > 0 {
+		if last, ok := t.last[key]; ok {
+			wait = last.Add(t.cooldown).Sub(now)
+		}
+	}
+	if t.perMinute > 0 {
+		t.refill(now)
+		if t.tokens < 1 {
+			perToken := time.Minute / time.Duration(t.perMinute)
+			if w := time.Duration((1 - t.tokens) * float64(perToken)); w > wait {
+				wait = w
+			}
+		}
+	}
+	if wait > 0 {
+		return wait
+	}
+
+	if t.perMinute > 0 {
+		t.tokens--
+	}
+	if t.cooldown > 0 {
+		t.last[key] = now
+		t.prune(now)
+	}
+	return 0
+}
+
+func (t *Throttle) refill(now time.Time) {
+	if !t.filled.IsZero() && now.After(t.filled) {
+		t.tokens += now.Sub(t.filled).Minutes() * float64(t.perMinute)
+		if t.tokens > float64(t.burst) {
+			t.tokens = float64(t.burst)
+		}
+	}
+	if now.After(t.filled) {
+		t.filled = now
+	}
+}
+
+// prune forgets recipients whose cooldown has passed, keeping the map
+// bounded by the number of recipients within one cooldown.
+func (t *Throttle) prune(now time.Time) {
+	if len(t.last) < 1024 {
+		return
+	}
+	for k, last := range t.last {
+		if now.Sub(last) >= t.cooldown {
+			delete(t.last, k)
+		}
+	}
+}
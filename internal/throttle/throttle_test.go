@@ -0,0 +1,65 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottleGlobal(t *testing.T) {
+	th := New(60, 2, 0)
+	now := time.Unix(1000, 0)
+	for i, key := range []string{"a", "b"} {
+		if w := th.Reserve(key, now); w != 0 {
+			t.Fatalf("send %d within burst waited %s", i, w)
+		}
+	}
+	if w := th.Reserve("c", now); w != time.Second {
+		t.Fatalf("expected 1s wait after burst, got %s", w)
+	}
+	if w := th.Reserve("c", now.Add(500*time.Millisecond)); w != 500*time.Millisecond {
+		t.Fatalf("expected 500ms wait, got %s", w)
+	}
+	if w := th.Reserve("c", now.Add(time.Second)); w != 0 {
+		t.Fatalf("expected send after refill, got wait %s", w)
+	}
+	// Refill is capped at the burst.
+	later := now.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		if w := th.Reserve("d", later); w != 0 {
+			t.Fatalf("send %d after idle waited %s", i, w)
+		}
+	}
+	if w := th.Reserve("d", later); w == 0 {
+		t.Fatalf("expected burst to be capped")
+	}
+}
+
+func TestThrottleCooldown(t *testing.T) {
+	th := New(0, 0, 5*time.Second)
+	now := time.Unix(1000, 0)
+	if w := th.Reserve("a", now); w != 0 {
+		t.Fatalf("first send waited %s", w)
+	}
+	if w := th.Reserve("b", now); w != 0 {
+		t.Fatalf("other recipient waited %s", w)
+	}
+	if w := th.Reserve("a", now.Add(2*time.Second)); w != 3*time.Second {
+		t.Fatalf("expected 3s cooldown left, got %s", w)
+	}
+	if w := th.Reserve("a", now.Add(5*time.Second)); w != 0 {
+		t.Fatalf("send after cooldown waited %s", w)
+	}
+}
+
+func TestThrottleDisabled(t *testing.T) {
+	var th *Throttle
+	if th.Enabled() || th.Reserve("a", time.Now()) != 0 {
+		t.Fatalf("nil throttle should allow everything")
+	}
+	th = New(0, 0, 0)
+	for i := 0; i < 100; i++ {
+		if w := th.Reserve("a", time.Now()); w != 0 {
+			t.Fatalf("disabled throttle waited %s", w)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package wa
+
+import (
+	"context"
+
+	"go.mau.fi/whatsmeow/appstate"
+)
+
+// SendAppState pushes an app-state patch (star, archive, pin, mute, ...) so
+// the change shows up on the phone and other linked devices.
+func (c *Client) SendAppState(ctx context.Context, patch appstate.PatchInfo) error {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return ErrNotConnected
+	}
+	return cli.SendAppState(ctx, patch)
+}
@@ -0,0 +1,121 @@
+package wa
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os/exec"
+	"time"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// waveformBars is the number of bars the phone apps draw for a voice
+	// message; each is a byte from 0 to 100.
+	waveformBars = 64
+	// audioSampleRate is what the audio is decoded to for analysis; the
+	// waveform needs no more.
+	audioSampleRate = 8000
+
+	audioDecodeTimeout = 30 * time.Second
+)
+
+// AudioInfo is the playback metadata of an audio message.
+type AudioInfo struct {
+	Seconds  uint32
+	Waveform []byte
+}
+
+// AudioFileInfo decodes the audio file at path and measures its duration
+// and waveform. It needs ffmpeg on PATH.
+func AudioFileInfo(ctx context.Context, path string) (*AudioInfo, error) {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg not found: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, audioDecodeTimeout)
+	defer cancel()
+	var out, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpeg, "-hide_banner", "-loglevel", "error",
+		"-i", path, "-vn", "-ac", "1", "-ar", fmt.Sprint(audioSampleRate), "-f", "s16le", "pipe:1")
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	samples := make([]int16, out.Len()/2)
+	if err := binary.Read(&out, binary.LittleEndian, samples); err != nil {
+		return nil, err
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no audio stream")
+	}
+	return &AudioInfo{
+		Seconds:  uint32((len(samples) + audioSampleRate - 1) / audioSampleRate),
+		Waveform: waveform(samples),
+	}, nil
+}
+
+// waveform reduces samples to waveformBars loudness levels: the mean
+// amplitude of each slice, scaled so the loudest is 100.
+func waveform(samples []int16) []byte {
+	levels := make([]float64, waveformBars)
+	var peak float64
+	for i := range levels {
+		start, end := i*len(samples)/waveformBars, (i+1)*len(samples)/waveformBars
+		if end == start {
+			continue
+		}
+		var sum float64
+		for _, s := range samples[start:end] {
+			if s < 0 {
+				sum -= float64(s)
+			} else {
+				sum += float64(s)
+			}
+		}
+		levels[i] = sum / float64(end-start)
+		peak = max(peak, levels[i])
+	}
+	out := make([]byte, waveformBars)
+	if peak == 0 {
+		return out
+	}
+	for i, l := range levels {
+		out[i] = byte(l / peak * 100)
+	}
+	return out
+}
+
+// AttachAudioInfo sets the duration and waveform of an audio message from
+// its media data. Without them, recipients see 0:00 until they play it.
+// Other messages are left alone.
+func AttachAudioInfo(ctx context.Context, msg *waProto.Message, data []byte) error {
+	if msg.GetAudioMessage() == nil {
+		return nil
+	}
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return fmt.Errorf("ffmpeg not found: %w", err)
+	}
+	return withTempFile(data, func(path string) error {
+		return AttachFileAudioInfo(ctx, msg, path)
+	})
+}
+
+// AttachFileAudioInfo is AttachAudioInfo for audio on disk.
+func AttachFileAudioInfo(ctx context.Context, msg *waProto.Message, path string) error {
+	am := msg.GetAudioMessage()
+	if am == nil {
+		return nil
+	}
+	info, err := AudioFileInfo(ctx, path)
+	if err != nil {
+		return err
+	}
+	am.Seconds = proto.Uint32(info.Seconds)
+	am.Waveform = info.Waveform
+	return nil
+}
@@ -0,0 +1,39 @@
+package wa
+
+import (
+	"context"
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+)
+
+func TestWaveform(t *testing.T) {
+	samples := make([]int16, 6400)
+	for i := range samples {
+		// Silent first half, loud second half, alternating sign.
+		if i >= len(samples)/2 {
+			samples[i] = 20000
+			if i%2 == 0 {
+				samples[i] = -20000
+			}
+		}
+	}
+	w := waveform(samples)
+	if len(w) != waveformBars {
+		t.Fatalf("len = %d, want %d", len(w), waveformBars)
+	}
+	if w[0] != 0 || w[waveformBars-1] != 100 {
+		t.Fatalf("waveform = %v, want silence then 100", w)
+	}
+
+	if w := waveform(nil); len(w) != waveformBars || w[0] != 0 {
+		t.Fatalf("empty waveform = %v", w)
+	}
+}
+
+func TestAttachAudioInfoIgnoresOtherMessages(t *testing.T) {
+	msg := &waProto.Message{ImageMessage: &waProto.ImageMessage{}}
+	if err := AttachAudioInfo(context.Background(), msg, []byte("x")); err != nil {
+		t.Fatalf("AttachAudioInfo: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package wa
+
+import (
+	"context"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// GetBlocklist returns the JIDs blocked by this account.
+func (c *Client) GetBlocklist(ctx context.Context) ([]types.JID, error) {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return nil, ErrNotConnected
+	}
+	bl, err := cli.GetBlocklist(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return bl.JIDs, nil
+}
+
+// UpdateBlocklist blocks or unblocks jid and returns the resulting blocklist.
+func (c *Client) UpdateBlocklist(ctx context.Context, jid types.JID, block bool) ([]types.JID, error) {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return nil, ErrNotConnected
+	}
+	action := events.BlocklistChangeActionUnblock
+	if block {
+		action = events.BlocklistChangeActionBlock
+	}
+	bl, err := cli.UpdateBlocklist(ctx, jid, action)
+	if err != nil {
+		return nil, err
+	}
+	return bl.JIDs, nil
+}
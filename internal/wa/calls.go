@@ -0,0 +1,18 @@
+package wa
+
+import (
+	"context"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// RejectCall declines an incoming call.
+func (c *Client) RejectCall(ctx context.Context, from types.JID, callID string) error {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return ErrNotConnected
+	}
+	return cli.RejectCall(ctx, from, callID)
+}
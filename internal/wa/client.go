@@ -3,6 +3,7 @@ package wa
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -18,6 +19,9 @@ import (
 	"go.mau.fi/whatsmeow/types"
 	waLog "go.mau.fi/whatsmeow/util/log"
 	"google.golang.org/protobuf/proto"
+
+	"github.com/steipete/wacli/internal/dbcrypt"
+	"github.com/steipete/wacli/internal/phone"
 )
 
 // debugLog prints debug messages when WA_DEBUG environment variable is set to "true" or "1"
@@ -32,11 +36,16 @@ type Options struct {
 	DeviceName string // Name shown in WhatsApp linked devices (default: "WhatsApp-SVC")
 }
 
+// ErrNotConnected is returned by calls that need a live connection to
+// WhatsApp while there is none.
+var ErrNotConnected = errors.New("not connected")
+
 type Client struct {
 	opts Options
 
 	mu     sync.Mutex
 	client *whatsmeow.Client
+	db     *sql.DB // whatsmeow's own store
 }
 
 func New(opts Options) (*Client, error) {
@@ -67,10 +76,15 @@ func (c *Client) init() error {
 
 	ctx := context.Background()
 	dbLog := waLog.Stdout("Database", "ERROR", true)
-	container, err := sqlstore.New(ctx, "sqlite3", fmt.Sprintf("file:%s?_foreign_keys=on", c.opts.StorePath), dbLog)
+	db, err := sql.Open(dbcrypt.Driver, fmt.Sprintf("file:%s?_foreign_keys=on", c.opts.StorePath))
 	if err != nil {
 		return fmt.Errorf("open whatsmeow store: %w", err)
 	}
+	container := sqlstore.NewWithDB(db, "sqlite3", dbLog)
+	if err := container.Upgrade(ctx); err != nil {
+		_ = db.Close()
+		return fmt.Errorf("open whatsmeow store: %w", err)
+	}
 
 	deviceStore, err := container.GetFirstDevice(ctx)
 	if err != nil {
@@ -82,7 +96,9 @@ func (c *Client) init() error {
 	}
 
 	logger := waLog.Stdout("Client", "ERROR", true)
+	c.db = db
 	c.client = whatsmeow.NewClient(deviceStore, logger)
+	c.client.SetMediaHTTPClient(newMediaHTTPClient())
 	return nil
 }
 
@@ -109,6 +125,42 @@ func (c *Client) IsConnected() bool {
 type ConnectOptions struct {
 	AllowQR  bool
 	OnQRCode func(code string)
+
+	// PairPhone links by phone number instead of QR code: once the login
+	// socket is up, a pairing code for this number (international format,
+	// digits only) is requested and passed to OnPairCode, and QR codes are
+	// no longer shown.
+	PairPhone  string
+	OnPairCode func(code string)
+}
+
+// pairClientName is how the service shows up in the phone's linked devices
+// list when pairing by code. WhatsApp only accepts "Browser (OS)" names.
+const pairClientName = "Chrome (Linux)"
+
+// NormalizePairPhone strips formatting from a phone number for code pairing
+// and checks that what remains looks like an international number.
+func NormalizePairPhone(phone string) (string, error) {
+	phone = strings.TrimSpace(phone)
+	phone = strings.TrimPrefix(phone, "+")
+	var b strings.Builder
+	for _, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '(' || r == ')' || r == '.':
+		default:
+			return "", fmt.Errorf("phone number contains %q", r)
+		}
+	}
+	digits := b.String()
+	if len(digits) < 7 || len(digits) > 15 {
+		return "", fmt.Errorf("phone number must have 7 to 15 digits including the country code")
+	}
+	if digits[0] == '0' {
+		return "", fmt.Errorf("phone number must start with the country code, not 0")
+	}
+	return digits, nil
 }
 
 func (c *Client) Connect(ctx context.Context, opts ConnectOptions) error {
@@ -148,6 +200,7 @@ func (c *Client) Connect(ctx context.Context, opts ConnectOptions) error {
 	}
 
 	// Wait for QR flow to succeed or fail.
+	pairRequested := false
 	for {
 		select {
 		case <-ctx.Done():
@@ -163,7 +216,23 @@ func (c *Client) Connect(ctx context.Context, opts ConnectOptions) error {
 			}
 			switch evt.Event {
 			case "code":
-				if opts.OnQRCode != nil {
+				if opts.PairPhone != "" {
+					// The first code means the socket is ready; later
+					// ones are QR refreshes, irrelevant when pairing by code.
+					if pairRequested {
+						continue
+					}
+					pairRequested = true
+					code, err := cli.PairPhone(ctx, opts.PairPhone, true, whatsmeow.PairClientChrome, pairClientName)
+					if err != nil {
+						return fmt.Errorf("request pairing code: %w", err)
+					}
+					if opts.OnPairCode != nil {
+						opts.OnPairCode(code)
+					} else {
+						fmt.Printf("Enter this code on your phone (Linked Devices > Link with phone number): %s\n", code)
+					}
+				} else if opts.OnQRCode != nil {
 					opts.OnQRCode(evt.Code)
 				} else {
 					qrterminal.GenerateHalfBlock(evt.Code, qrterminal.M, os.Stdout)
@@ -174,6 +243,9 @@ func (c *Client) Connect(ctx context.Context, opts ConnectOptions) error {
 				// Continue waiting for the channel to close or for auth to complete.
 				// Don't return here - wait for the channel to close.
 			case "timeout":
+				if opts.PairPhone != "" {
+					return fmt.Errorf("pairing code expired")
+				}
 				return fmt.Errorf("QR code timed out")
 			case "error":
 				return fmt.Errorf("QR error")
@@ -207,7 +279,7 @@ func (c *Client) SendText(ctx context.Context, to types.JID, text string) (types
 	cli := c.client
 	c.mu.Unlock()
 	if cli == nil || !cli.IsConnected() {
-		return "", fmt.Errorf("not connected")
+		return "", ErrNotConnected
 	}
 	msg := &waProto.Message{Conversation: &text}
 	resp, err := cli.SendMessage(ctx, to, msg)
@@ -222,7 +294,7 @@ func (c *Client) SendProtoMessage(ctx context.Context, to types.JID, msg *waProt
 	cli := c.client
 	c.mu.Unlock()
 	if cli == nil || !cli.IsConnected() {
-		return "", fmt.Errorf("not connected")
+		return "", ErrNotConnected
 	}
 	resp, err := cli.SendMessage(ctx, to, msg)
 	if err != nil {
@@ -236,9 +308,36 @@ func (c *Client) Upload(ctx context.Context, data []byte, mediaType whatsmeow.Me
 	cli := c.client
 	c.mu.Unlock()
 	if cli == nil || !cli.IsConnected() {
-		return whatsmeow.UploadResponse{}, fmt.Errorf("not connected")
+		return whatsmeow.UploadResponse{}, ErrNotConnected
 	}
-	return cli.Upload(ctx, data, mediaType)
+	var resp whatsmeow.UploadResponse
+	err := withMediaRetry(ctx, func() error {
+		var err error
+		resp, err = cli.Upload(ctx, data, mediaType)
+		return err
+	})
+	return resp, err
+}
+
+// UploadFile encrypts and uploads a file without reading it into memory.
+func (c *Client) UploadFile(ctx context.Context, path string, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return whatsmeow.UploadResponse{}, ErrNotConnected
+	}
+	var resp whatsmeow.UploadResponse
+	err := withMediaRetry(ctx, func() error {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		resp, err = cli.UploadReader(ctx, f, nil, mediaType)
+		return err
+	})
+	return resp, err
 }
 
 func (c *Client) RequestHistorySyncOnDemand(ctx context.Context, lastKnown types.MessageInfo, count int) (types.MessageID, error) {
@@ -246,7 +345,7 @@ func (c *Client) RequestHistorySyncOnDemand(ctx context.Context, lastKnown types
 	cli := c.client
 	c.mu.Unlock()
 	if cli == nil || !cli.IsConnected() {
-		return "", fmt.Errorf("not connected")
+		return "", ErrNotConnected
 	}
 	if count <= 0 {
 		count = 50
@@ -271,6 +370,8 @@ func (c *Client) RequestHistorySyncOnDemand(ctx context.Context, lastKnown types
 	return resp.ID, nil
 }
 
+// ParseUserOrJID parses a full JID, or a phone number in international or
+// (with a default country code set) national format as a user JID.
 func ParseUserOrJID(s string) (types.JID, error) {
 	s = strings.TrimSpace(s)
 	if s == "" {
@@ -279,7 +380,47 @@ func ParseUserOrJID(s string) (types.JID, error) {
 	if strings.Contains(s, "@") {
 		return types.ParseJID(s)
 	}
-	return types.JID{User: s, Server: types.DefaultUserServer}, nil
+	user, err := phone.Normalize(s)
+	if err != nil {
+		return types.JID{}, err
+	}
+	return types.JID{User: user, Server: types.DefaultUserServer}, nil
+}
+
+// IsOnWhatsApp checks which phone numbers (in +<digits> form) are registered.
+func (c *Client) IsOnWhatsApp(ctx context.Context, phones []string) ([]types.IsOnWhatsAppResponse, error) {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return nil, ErrNotConnected
+	}
+	return cli.IsOnWhatsApp(ctx, phones)
+}
+
+// LIDMappings returns every LID to phone-number JID mapping whatsmeow has
+// learned, keyed by LID.
+func (c *Client) LIDMappings(ctx context.Context) (map[types.JID]types.JID, error) {
+	c.mu.Lock()
+	db := c.db
+	c.mu.Unlock()
+	if db == nil {
+		return nil, fmt.Errorf("store not open")
+	}
+	rows, err := db.QueryContext(ctx, `SELECT lid, pn FROM whatsmeow_lid_map`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[types.JID]types.JID)
+	for rows.Next() {
+		var lid, pn string
+		if err := rows.Scan(&lid, &pn); err != nil {
+			return nil, err
+		}
+		out[types.NewJID(lid, types.HiddenUserServer)] = types.NewJID(pn, types.DefaultUserServer)
+	}
+	return out, rows.Err()
 }
 
 func IsGroupJID(jid types.JID) bool {
@@ -358,7 +499,7 @@ func (c *Client) GetGroupInfo(ctx context.Context, jid types.JID) (*types.GroupI
 	cli := c.client
 	c.mu.Unlock()
 	if cli == nil || !cli.IsConnected() {
-		return nil, fmt.Errorf("not connected")
+		return nil, ErrNotConnected
 	}
 	return cli.GetGroupInfo(ctx, jid)
 }
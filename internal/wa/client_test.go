@@ -41,3 +41,20 @@ func TestBestContactName(t *testing.T) {
 		t.Fatalf("expected push name")
 	}
 }
+
+func TestNormalizePairPhone(t *testing.T) {
+	for in, want := range map[string]string{
+		"+1 (555) 010-9999": "15550109999",
+		"4915112345678":     "4915112345678",
+		" +44 7700.900123 ": "447700900123",
+	} {
+		if got, err := NormalizePairPhone(in); err != nil || got != want {
+			t.Fatalf("NormalizePairPhone(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "12345", "0151 12345678", "+1 555 abc", "1234567890123456"} {
+		if got, err := NormalizePairPhone(in); err == nil {
+			t.Fatalf("NormalizePairPhone(%q) = %q, want error", in, got)
+		}
+	}
+}
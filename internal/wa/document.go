@@ -0,0 +1,175 @@
+package wa
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// documentThumbnailMaxSide bounds the preview shown above a document,
+	// which WhatsApp displays larger than the blurred media placeholders.
+	documentThumbnailMaxSide = 480
+
+	pdfRenderTimeout = 15 * time.Second
+)
+
+// pdfPageObject matches the dictionary entry of a page object; /Pages
+// (the page tree) is excluded by the character after it.
+var pdfPageObject = regexp.MustCompile(`/Type\s*/Page[^s]`)
+
+// PDFPageCount counts the pages of a PDF by its page objects. PDFs that
+// keep their objects in compressed streams show none; for those it asks
+// pdfinfo if it is on PATH. It returns 0 if the count is unknown.
+func PDFPageCount(r io.Reader) int {
+	const overlap = 32
+	br := bufio.NewReaderSize(r, 64<<10)
+	buf := make([]byte, 0, 64<<10+overlap)
+	chunk := make([]byte, 64<<10)
+	n := 0
+	for {
+		k, err := br.Read(chunk)
+		kept := len(buf)
+		buf = append(buf, chunk[:k]...)
+		for _, m := range pdfPageObject.FindAllIndex(buf, -1) {
+			if m[1] > kept { // matches within the overlap were counted already
+				n++
+			}
+		}
+		if len(buf) > overlap {
+			buf = append(buf[:0], buf[len(buf)-overlap:]...)
+		}
+		if err != nil {
+			break
+		}
+	}
+	return n
+}
+
+// pdfInfoPages asks pdfinfo for the page count of the PDF at path.
+func pdfInfoPages(ctx context.Context, path string) int {
+	pdfinfo, err := exec.LookPath("pdfinfo")
+	if err != nil {
+		return 0
+	}
+	ctx, cancel := context.WithTimeout(ctx, pdfRenderTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, pdfinfo, path).Output()
+	if err != nil {
+		return 0
+	}
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		if v, ok := bytes.CutPrefix(line, []byte("Pages:")); ok {
+			n, _ := strconv.Atoi(string(bytes.TrimSpace(v)))
+			return n
+		}
+	}
+	return 0
+}
+
+// PDFFileThumbnail renders a JPEG preview of the first page of the PDF at
+// path. It needs pdftoppm (poppler-utils) on PATH.
+func PDFFileThumbnail(ctx context.Context, path string) (*Thumbnail, error) {
+	pdftoppm, err := exec.LookPath("pdftoppm")
+	if err != nil {
+		return nil, fmt.Errorf("pdftoppm not found: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, pdfRenderTimeout)
+	defer cancel()
+	var out, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, pdftoppm, "-f", "1", "-l", "1", "-singlefile",
+		"-scale-to", strconv.Itoa(documentThumbnailMaxSide), "-png", path)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("pdftoppm: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return renderThumbnail(out.Bytes(), documentThumbnailMaxSide)
+}
+
+// attachPDFPreview sets the page count and first-page preview of a PDF
+// document message. The page count is kept even if rendering fails.
+func attachPDFPreview(ctx context.Context, dm *waProto.DocumentMessage, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	pages := PDFPageCount(f)
+	f.Close()
+	if pages == 0 {
+		pages = pdfInfoPages(ctx, path)
+	}
+	if pages > 0 {
+		dm.PageCount = proto.Uint32(uint32(pages))
+	}
+
+	t, err := PDFFileThumbnail(ctx, path)
+	if err != nil {
+		return err
+	}
+	setDocumentThumbnail(dm, t)
+	return nil
+}
+
+func isPDF(dm *waProto.DocumentMessage) bool {
+	return dm != nil && dm.GetMimetype() == "application/pdf"
+}
+
+func setDocumentThumbnail(dm *waProto.DocumentMessage, t *Thumbnail) {
+	dm.JPEGThumbnail = t.JPEG
+	dm.ThumbnailWidth = proto.Uint32(uint32(t.ThumbWidth))
+	dm.ThumbnailHeight = proto.Uint32(uint32(t.ThumbHeight))
+}
+
+// CustomThumbnail renders a caller-supplied preview image (JPEG, PNG or
+// GIF) at the size WhatsApp shows for the given media type: large for
+// documents, small for images and videos.
+func CustomThumbnail(data []byte, mediaType string) (*Thumbnail, error) {
+	maxSide := thumbnailMaxSide
+	if mediaType == "document" {
+		maxSide = documentThumbnailMaxSide
+	}
+	return renderThumbnail(data, maxSide)
+}
+
+// SetThumbnail replaces the preview of an image, video or document message
+// with t, e.g. from CustomThumbnail. The media dimensions are kept.
+func SetThumbnail(msg *waProto.Message, t *Thumbnail) {
+	switch {
+	case msg.GetImageMessage() != nil:
+		msg.ImageMessage.JPEGThumbnail = t.JPEG
+	case msg.GetVideoMessage() != nil:
+		msg.VideoMessage.JPEGThumbnail = t.JPEG
+	case msg.GetDocumentMessage() != nil:
+		setDocumentThumbnail(msg.DocumentMessage, t)
+	}
+}
+
+// renderThumbnail decodes an image and encodes a JPEG of it scaled to
+// maxSide, keeping the dimensions of both.
+func renderThumbnail(data []byte, maxSide int) (*Thumbnail, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	b := img.Bounds()
+	small := scaleDown(img, maxSide)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, small, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, fmt.Errorf("encode thumbnail: %w", err)
+	}
+	sb := small.Bounds()
+	return &Thumbnail{JPEG: buf.Bytes(), Width: b.Dx(), Height: b.Dy(), ThumbWidth: sb.Dx(), ThumbHeight: sb.Dy()}, nil
+}
@@ -0,0 +1,69 @@
+package wa
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+func testPDF(pages int) []byte {
+	var b strings.Builder
+	b.WriteString("%PDF-1.4\n1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n")
+	b.WriteString("2 0 obj << /Type /Pages /Count " + strconv.Itoa(pages) + " >> endobj\n")
+	for i := 0; i < pages; i++ {
+		b.WriteString("3 0 obj << /Type /Page /Parent 2 0 R >> endobj\n")
+		b.WriteString(strings.Repeat("x", 40<<10)) // spread pages over read chunks
+	}
+	b.WriteString("%%EOF\n")
+	return []byte(b.String())
+}
+
+func TestPDFPageCount(t *testing.T) {
+	for _, pages := range []int{0, 1, 5} {
+		if got := PDFPageCount(bytes.NewReader(testPDF(pages))); got != pages {
+			t.Fatalf("PDFPageCount = %d, want %d", got, pages)
+		}
+	}
+	if got := PDFPageCount(strings.NewReader("<< /Type/Page>> << /Type\n/Page /Rotate 90 >>")); got != 2 {
+		t.Fatalf("PDFPageCount compact = %d, want 2", got)
+	}
+}
+
+func TestAttachThumbnailPDF(t *testing.T) {
+	msg := &waProto.Message{DocumentMessage: &waProto.DocumentMessage{Mimetype: proto.String("application/pdf")}}
+	// Rendering needs pdftoppm and a real PDF; the page count does not.
+	_ = AttachThumbnail(context.Background(), msg, testPDF(3))
+	if got := msg.GetDocumentMessage().GetPageCount(); got != 3 {
+		t.Fatalf("PageCount = %d, want 3", got)
+	}
+}
+
+func TestCustomThumbnail(t *testing.T) {
+	img := testPNG(t, 1000, 500)
+	doc, err := CustomThumbnail(img, "document")
+	if err != nil {
+		t.Fatalf("CustomThumbnail: %v", err)
+	}
+	if doc.ThumbWidth != documentThumbnailMaxSide || doc.ThumbHeight != documentThumbnailMaxSide/2 {
+		t.Fatalf("document thumbnail is %dx%d", doc.ThumbWidth, doc.ThumbHeight)
+	}
+	msg := &waProto.Message{DocumentMessage: &waProto.DocumentMessage{}}
+	SetThumbnail(msg, doc)
+	dm := msg.GetDocumentMessage()
+	if len(dm.GetJPEGThumbnail()) == 0 || dm.GetThumbnailWidth() != uint32(doc.ThumbWidth) {
+		t.Fatalf("document preview not set: %d bytes, width %d", len(dm.GetJPEGThumbnail()), dm.GetThumbnailWidth())
+	}
+
+	small, err := CustomThumbnail(img, "image")
+	if err != nil || small.ThumbWidth != thumbnailMaxSide {
+		t.Fatalf("image thumbnail = %+v, %v", small, err)
+	}
+	if _, err := CustomThumbnail([]byte("nope"), "document"); err == nil {
+		t.Fatalf("expected error for invalid image")
+	}
+}
@@ -13,7 +13,7 @@ func (c *Client) GetJoinedGroups(ctx context.Context) ([]*types.GroupInfo, error
 	cli := c.client
 	c.mu.Unlock()
 	if cli == nil || !cli.IsConnected() {
-		return nil, fmt.Errorf("not connected")
+		return nil, ErrNotConnected
 	}
 	return cli.GetJoinedGroups(ctx)
 }
@@ -23,11 +23,55 @@ func (c *Client) SetGroupName(ctx context.Context, jid types.JID, name string) e
 	cli := c.client
 	c.mu.Unlock()
 	if cli == nil || !cli.IsConnected() {
-		return fmt.Errorf("not connected")
+		return ErrNotConnected
 	}
 	return cli.SetGroupName(ctx, jid, name)
 }
 
+// GetSubGroups lists the groups linked to a community.
+func (c *Client) GetSubGroups(ctx context.Context, community types.JID) ([]*types.GroupLinkTarget, error) {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return nil, ErrNotConnected
+	}
+	return cli.GetSubGroups(ctx, community)
+}
+
+// SetGroupAnnounce sets whether only admins can send messages to a group.
+func (c *Client) SetGroupAnnounce(ctx context.Context, jid types.JID, announce bool) error {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return ErrNotConnected
+	}
+	return cli.SetGroupAnnounce(ctx, jid, announce)
+}
+
+// SetGroupLocked sets whether only admins can edit a group's info.
+func (c *Client) SetGroupLocked(ctx context.Context, jid types.JID, locked bool) error {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return ErrNotConnected
+	}
+	return cli.SetGroupLocked(ctx, jid, locked)
+}
+
+// SetGroupTopic replaces the description of a group; an empty topic removes it.
+func (c *Client) SetGroupTopic(ctx context.Context, jid types.JID, topic string) error {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return ErrNotConnected
+	}
+	return cli.SetGroupTopic(ctx, jid, "", "", topic)
+}
+
 type GroupParticipantAction string
 
 const (
@@ -42,7 +86,7 @@ func (c *Client) UpdateGroupParticipants(ctx context.Context, group types.JID, u
 	cli := c.client
 	c.mu.Unlock()
 	if cli == nil || !cli.IsConnected() {
-		return nil, fmt.Errorf("not connected")
+		return nil, ErrNotConnected
 	}
 
 	var a whatsmeow.ParticipantChange
@@ -67,17 +111,40 @@ func (c *Client) GetGroupInviteLink(ctx context.Context, group types.JID, reset
 	cli := c.client
 	c.mu.Unlock()
 	if cli == nil || !cli.IsConnected() {
-		return "", fmt.Errorf("not connected")
+		return "", ErrNotConnected
 	}
 	return cli.GetGroupInviteLink(ctx, group, reset)
 }
 
+// CreateGroup creates a group with the given participants; the own account
+// is added by the server. Names longer than 25 characters are rejected.
+func (c *Client) CreateGroup(ctx context.Context, name string, participants []types.JID) (*types.GroupInfo, error) {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return nil, ErrNotConnected
+	}
+	return cli.CreateGroup(ctx, whatsmeow.ReqCreateGroup{Name: name, Participants: participants})
+}
+
+// GetGroupInfoFromLink resolves an invite code or link without joining.
+func (c *Client) GetGroupInfoFromLink(ctx context.Context, code string) (*types.GroupInfo, error) {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return nil, ErrNotConnected
+	}
+	return cli.GetGroupInfoFromLink(ctx, code)
+}
+
 func (c *Client) JoinGroupWithLink(ctx context.Context, code string) (types.JID, error) {
 	c.mu.Lock()
 	cli := c.client
 	c.mu.Unlock()
 	if cli == nil || !cli.IsConnected() {
-		return types.JID{}, fmt.Errorf("not connected")
+		return types.JID{}, ErrNotConnected
 	}
 	return cli.JoinGroupWithLink(ctx, code)
 }
@@ -87,7 +154,7 @@ func (c *Client) LeaveGroup(ctx context.Context, group types.JID) error {
 	cli := c.client
 	c.mu.Unlock()
 	if cli == nil || !cli.IsConnected() {
-		return fmt.Errorf("not connected")
+		return ErrNotConnected
 	}
 	return cli.LeaveGroup(ctx, group)
 }
@@ -3,6 +3,7 @@ package wa
 import (
 	"context"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"path/filepath"
@@ -11,11 +12,25 @@ import (
 	"go.mau.fi/whatsmeow"
 )
 
+// tempDownloadPrefix names the scratch file a download decrypts into before
+// it is renamed to the target path.
+const tempDownloadPrefix = ".wacli-download-"
+
+// IsTempDownload reports whether a file name is a scratch file of a media download.
+func IsTempDownload(name string) bool {
+	return strings.HasPrefix(name, tempDownloadPrefix)
+}
+
+// IsPartialDownload reports whether a file name holds the bytes of a resumable download.
+func IsPartialDownload(name string) bool {
+	return strings.HasSuffix(name, partialSuffix)
+}
+
 func MediaTypeFromString(mediaType string) (whatsmeow.MediaType, error) {
 	switch strings.ToLower(strings.TrimSpace(mediaType)) {
 	case "image":
 		return whatsmeow.MediaImage, nil
-	case "video":
+	case "video", "gif":
 		return whatsmeow.MediaVideo, nil
 	case "audio":
 		return whatsmeow.MediaAudio, nil
@@ -33,7 +48,7 @@ func (c *Client) DownloadMediaToFile(ctx context.Context, directPath string, enc
 	cli := c.client
 	c.mu.Unlock()
 	if cli == nil || !cli.IsConnected() {
-		return 0, fmt.Errorf("not connected")
+		return 0, ErrNotConnected
 	}
 	if strings.TrimSpace(directPath) == "" {
 		return 0, fmt.Errorf("direct path is required")
@@ -47,7 +62,7 @@ func (c *Client) DownloadMediaToFile(ctx context.Context, directPath string, enc
 		return 0, fmt.Errorf("create output dir: %w", err)
 	}
 
-	tmpFile, err := os.CreateTemp(filepath.Dir(targetPath), ".wacli-download-*")
+	tmpFile, err := os.CreateTemp(filepath.Dir(targetPath), tempDownloadPrefix+"*")
 	if err != nil {
 		return 0, fmt.Errorf("create temp file: %w", err)
 	}
@@ -65,9 +80,32 @@ func (c *Client) DownloadMediaToFile(ctx context.Context, directPath string, enc
 		length = int(fileLength)
 	}
 
-	if err := cli.DownloadMediaWithPathToFile(ctx, directPath, encFileHash, fileHash, mediaKey, length, mt, mmsType, tmpFile); err != nil {
+	// Large media keep their encrypted bytes next to the target so an
+	// interrupted transfer continues where it stopped.
+	partPath := ""
+	if fileLength >= resumableMinSize {
+		partPath = PartialPath(targetPath)
+		ctx = withPartialFile(ctx, partPath)
+	}
+
+	err = withMediaRetry(ctx, func() error {
+		if err := tmpFile.Truncate(0); err != nil {
+			return fmt.Errorf("reset temp file: %w", err)
+		}
+		if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("reset temp file: %w", err)
+		}
+		return cli.DownloadMediaWithPathToFile(ctx, directPath, encFileHash, fileHash, mediaKey, length, mt, mmsType, tmpFile)
+	})
+	if err != nil {
+		if partPath != "" && isCorruptMedia(err) {
+			_ = os.Remove(partPath)
+		}
 		return 0, err
 	}
+	if partPath != "" {
+		_ = os.Remove(partPath)
+	}
 	if err := tmpFile.Sync(); err != nil {
 		return 0, fmt.Errorf("flush temp file: %w", err)
 	}
@@ -85,3 +123,61 @@ func (c *Client) DownloadMediaToFile(ctx context.Context, directPath string, enc
 	}
 	return info.Size(), nil
 }
+
+// TempMedia is decrypted media in a scratch file outside the store; Close
+// deletes it.
+type TempMedia struct {
+	*os.File
+}
+
+func (t *TempMedia) Close() error {
+	err := t.File.Close()
+	_ = os.Remove(t.Name())
+	return err
+}
+
+// DownloadMediaToTemp downloads and decrypts media into a scratch file in
+// the system temp dir, rewound for reading, without touching the media dir.
+func (c *Client) DownloadMediaToTemp(ctx context.Context, directPath string, encFileHash, fileHash, mediaKey []byte, fileLength uint64, mediaType, mmsType string) (*TempMedia, error) {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return nil, ErrNotConnected
+	}
+	if strings.TrimSpace(directPath) == "" {
+		return nil, fmt.Errorf("direct path is required")
+	}
+	mt, err := MediaTypeFromString(mediaType)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp("", tempDownloadPrefix+"*")
+	if err != nil {
+		return nil, fmt.Errorf("create temp file: %w", err)
+	}
+	tmp := &TempMedia{File: f}
+
+	length := -1
+	if fileLength > 0 && fileLength < math.MaxInt32 {
+		length = int(fileLength)
+	}
+	err = withMediaRetry(ctx, func() error {
+		if err := f.Truncate(0); err != nil {
+			return fmt.Errorf("reset temp file: %w", err)
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("reset temp file: %w", err)
+		}
+		return cli.DownloadMediaWithPathToFile(ctx, directPath, encFileHash, fileHash, mediaKey, length, mt, mmsType, f)
+	})
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = tmp.Close()
+		return nil, err
+	}
+	return tmp, nil
+}
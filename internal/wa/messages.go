@@ -19,17 +19,34 @@ type Media struct {
 	FileSHA256    []byte
 	FileEncSHA256 []byte
 	FileLength    uint64
+	Seconds       uint32 // audio and video duration
 }
 
 type ParsedMessage struct {
 	Chat      types.JID
 	ID        string
 	SenderJID string
+	// The sender's other identity: the phone-number JID when SenderJID is a
+	// LID, or the LID otherwise. Only set on live messages that carry it.
+	SenderAlt string
 	Timestamp time.Time
 	FromMe    bool
 	Text      string
 	Media     *Media
 	PushName  string
+	// The message this one replies to, if any.
+	ReplyToID     string
+	ReplyToSender string
+	// Set when this is an edit (with the new text in Text) or a revoke
+	// ("delete for everyone") of an earlier message in the chat.
+	EditOf   string
+	RevokeOf string
+}
+
+// IsChange reports whether pm edits or revokes an earlier message rather
+// than being a message of its own.
+func (pm ParsedMessage) IsChange() bool {
+	return pm.EditOf != "" || pm.RevokeOf != ""
 }
 
 func ParseLiveMessage(evt *events.Message) ParsedMessage {
@@ -43,6 +60,9 @@ func ParseLiveMessage(evt *events.Message) ParsedMessage {
 	if s := evt.Info.Sender.String(); s != "" {
 		msg.SenderJID = s
 	}
+	if !evt.Info.SenderAlt.IsEmpty() {
+		msg.SenderAlt = evt.Info.SenderAlt.ToNonAD().String()
+	}
 
 	extractWAProto(evt.Message, &msg)
 	return msg
@@ -78,6 +98,19 @@ func extractWAProto(m *waProto.Message, pm *ParsedMessage) {
 		return
 	}
 
+	if p := m.GetProtocolMessage(); p != nil {
+		switch p.GetType() {
+		case waProto.ProtocolMessage_REVOKE:
+			pm.RevokeOf = p.GetKey().GetID()
+		case waProto.ProtocolMessage_MESSAGE_EDIT:
+			pm.EditOf = p.GetKey().GetID()
+			var edited ParsedMessage
+			extractWAProto(p.GetEditedMessage(), &edited)
+			pm.Text = edited.Text
+		}
+		return
+	}
+
 	switch {
 	case m.GetConversation() != "":
 		pm.Text = m.GetConversation()
@@ -85,6 +118,11 @@ func extractWAProto(m *waProto.Message, pm *ParsedMessage) {
 		pm.Text = m.GetExtendedTextMessage().GetText()
 	}
 
+	if ci := contextInfo(m); ci.GetStanzaID() != "" {
+		pm.ReplyToID = ci.GetStanzaID()
+		pm.ReplyToSender = ci.GetParticipant()
+	}
+
 	if img := m.GetImageMessage(); img != nil {
 		if pm.Text == "" {
 			pm.Text = img.GetCaption()
@@ -106,8 +144,12 @@ func extractWAProto(m *waProto.Message, pm *ParsedMessage) {
 		if pm.Text == "" {
 			pm.Text = vid.GetCaption()
 		}
+		kind := "video"
+		if vid.GetGifPlayback() {
+			kind = "gif" // a video the sender's app loops silently
+		}
 		pm.Media = &Media{
-			Type:          "video",
+			Type:          kind,
 			Caption:       vid.GetCaption(),
 			MimeType:      vid.GetMimetype(),
 			DirectPath:    vid.GetDirectPath(),
@@ -115,6 +157,7 @@ func extractWAProto(m *waProto.Message, pm *ParsedMessage) {
 			FileSHA256:    clone(vid.GetFileSHA256()),
 			FileEncSHA256: clone(vid.GetFileEncSHA256()),
 			FileLength:    vid.GetFileLength(),
+			Seconds:       vid.GetSeconds(),
 		}
 		return
 	}
@@ -132,6 +175,7 @@ func extractWAProto(m *waProto.Message, pm *ParsedMessage) {
 			FileSHA256:    clone(aud.GetFileSHA256()),
 			FileEncSHA256: clone(aud.GetFileEncSHA256()),
 			FileLength:    aud.GetFileLength(),
+			Seconds:       aud.GetSeconds(),
 		}
 		return
 	}
@@ -155,6 +199,26 @@ func extractWAProto(m *waProto.Message, pm *ParsedMessage) {
 	}
 }
 
+// contextInfo returns the context (quoted message, mentions) of whichever
+// kind of message m is, or nil.
+func contextInfo(m *waProto.Message) *waProto.ContextInfo {
+	switch {
+	case m.GetExtendedTextMessage() != nil:
+		return m.GetExtendedTextMessage().GetContextInfo()
+	case m.GetImageMessage() != nil:
+		return m.GetImageMessage().GetContextInfo()
+	case m.GetVideoMessage() != nil:
+		return m.GetVideoMessage().GetContextInfo()
+	case m.GetAudioMessage() != nil:
+		return m.GetAudioMessage().GetContextInfo()
+	case m.GetDocumentMessage() != nil:
+		return m.GetDocumentMessage().GetContextInfo()
+	case m.GetStickerMessage() != nil:
+		return m.GetStickerMessage().GetContextInfo()
+	}
+	return nil
+}
+
 func clone(b []byte) []byte {
 	if len(b) == 0 {
 		return nil
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"go.mau.fi/whatsmeow"
 	waProto "go.mau.fi/whatsmeow/binary/proto"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
@@ -74,3 +75,93 @@ func TestParseLiveMessageImageClonesBytes(t *testing.T) {
 		t.Fatalf("expected MediaKey to be cloned")
 	}
 }
+
+func TestParseLiveMessageGIF(t *testing.T) {
+	chat, _ := types.ParseJID("123@s.whatsapp.net")
+	for _, tc := range []struct {
+		gif  bool
+		want string
+	}{{false, "video"}, {true, "gif"}} {
+		ev := &events.Message{
+			Info: types.MessageInfo{MessageSource: types.MessageSource{Chat: chat}, ID: "vid"},
+			Message: &waProto.Message{VideoMessage: &waProto.VideoMessage{
+				Mimetype:    proto.String("video/mp4"),
+				GifPlayback: proto.Bool(tc.gif),
+			}},
+		}
+		if pm := ParseLiveMessage(ev); pm.Media == nil || pm.Media.Type != tc.want {
+			t.Fatalf("gif=%v: media = %+v, want type %q", tc.gif, pm.Media, tc.want)
+		}
+	}
+	if mt, err := MediaTypeFromString("gif"); err != nil || mt != whatsmeow.MediaVideo {
+		t.Fatalf("MediaTypeFromString(gif) = %q, %v", mt, err)
+	}
+}
+
+func TestParseLiveMessageReply(t *testing.T) {
+	chat, _ := types.ParseJID("123@g.us")
+	ev := &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: chat},
+			ID:            "reply",
+		},
+		Message: &waProto.Message{ExtendedTextMessage: &waProto.ExtendedTextMessage{
+			Text: proto.String("yes"),
+			ContextInfo: &waProto.ContextInfo{
+				StanzaID:    proto.String("orig"),
+				Participant: proto.String("author@s.whatsapp.net"),
+			},
+		}},
+	}
+	pm := ParseLiveMessage(ev)
+	if pm.Text != "yes" || pm.ReplyToID != "orig" || pm.ReplyToSender != "author@s.whatsapp.net" {
+		t.Fatalf("unexpected parsed reply: %+v", pm)
+	}
+
+	ev.Message = &waProto.Message{Conversation: proto.String("plain")}
+	if pm := ParseLiveMessage(ev); pm.ReplyToID != "" || pm.ReplyToSender != "" {
+		t.Fatalf("unexpected reply on plain message: %+v", pm)
+	}
+}
+
+func TestParseLiveMessageEditAndRevoke(t *testing.T) {
+	chat, _ := types.ParseJID("123@s.whatsapp.net")
+	ev := &events.Message{
+		Info: types.MessageInfo{MessageSource: types.MessageSource{Chat: chat}, ID: "edit"},
+		Message: &waProto.Message{ProtocolMessage: &waProto.ProtocolMessage{
+			Type:          waProto.ProtocolMessage_MESSAGE_EDIT.Enum(),
+			Key:           &waProto.MessageKey{ID: proto.String("orig")},
+			EditedMessage: &waProto.Message{Conversation: proto.String("fixed typo")},
+		}},
+	}
+	pm := ParseLiveMessage(ev)
+	if pm.EditOf != "orig" || pm.Text != "fixed typo" || pm.RevokeOf != "" {
+		t.Fatalf("unexpected parsed edit: %+v", pm)
+	}
+
+	ev.Message = &waProto.Message{ProtocolMessage: &waProto.ProtocolMessage{
+		Type: waProto.ProtocolMessage_REVOKE.Enum(),
+		Key:  &waProto.MessageKey{ID: proto.String("orig")},
+	}}
+	pm = ParseLiveMessage(ev)
+	if pm.RevokeOf != "orig" || pm.EditOf != "" || pm.Text != "" {
+		t.Fatalf("unexpected parsed revoke: %+v", pm)
+	}
+}
+
+func TestParseNewsletterMessage(t *testing.T) {
+	channel, _ := types.ParseJID("120363000000000000@newsletter")
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	pm := ParseNewsletterMessage(channel, &types.NewsletterMessage{
+		MessageServerID: 42,
+		MessageID:       "postid",
+		Timestamp:       ts,
+		Message:         &waProto.Message{Conversation: proto.String("news")},
+	})
+	if pm.ID != "postid" || pm.Text != "news" || !pm.Timestamp.Equal(ts) {
+		t.Fatalf("unexpected parsed post: %+v", pm)
+	}
+	if pm.Chat != channel || pm.SenderJID != channel.String() {
+		t.Fatalf("expected the channel as chat and sender, got %+v", pm)
+	}
+}
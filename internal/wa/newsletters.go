@@ -0,0 +1,73 @@
+package wa
+
+import (
+	"context"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func (c *Client) GetSubscribedNewsletters(ctx context.Context) ([]*types.NewsletterMetadata, error) {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return nil, ErrNotConnected
+	}
+	return cli.GetSubscribedNewsletters(ctx)
+}
+
+func (c *Client) GetNewsletterInfo(ctx context.Context, jid types.JID) (*types.NewsletterMetadata, error) {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return nil, ErrNotConnected
+	}
+	return cli.GetNewsletterInfo(ctx, jid)
+}
+
+func (c *Client) FollowNewsletter(ctx context.Context, jid types.JID) error {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return ErrNotConnected
+	}
+	return cli.FollowNewsletter(ctx, jid)
+}
+
+func (c *Client) UnfollowNewsletter(ctx context.Context, jid types.JID) error {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return ErrNotConnected
+	}
+	return cli.UnfollowNewsletter(ctx, jid)
+}
+
+// GetNewsletterMessages fetches up to count posts of a channel older than
+// the given server ID, or the latest ones if before is 0.
+func (c *Client) GetNewsletterMessages(ctx context.Context, jid types.JID, count int, before types.MessageServerID) ([]*types.NewsletterMessage, error) {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return nil, ErrNotConnected
+	}
+	return cli.GetNewsletterMessages(ctx, jid, &whatsmeow.GetNewsletterMessagesParams{Count: count, Before: before})
+}
+
+// ParseNewsletterMessage parses a channel post fetched from the server.
+// Channel posts have no sender; the channel itself is the author.
+func ParseNewsletterMessage(channel types.JID, nm *types.NewsletterMessage) ParsedMessage {
+	pm := ParsedMessage{
+		Chat:      channel,
+		ID:        nm.MessageID,
+		SenderJID: channel.String(),
+		Timestamp: nm.Timestamp,
+	}
+	extractWAProto(nm.Message, &pm)
+	return pm
+}
@@ -0,0 +1,165 @@
+package wa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+)
+
+// optimizeQuality matches what the phone apps use for sent photos.
+const optimizeQuality = 80
+
+// ImageOptions sets when OptimizeImage re-encodes an image.
+type ImageOptions struct {
+	MaxSide  int   // longer side, in pixels; zero means no limit
+	MaxBytes int64 // zero means no limit
+}
+
+// OptimizeImage downscales and recompresses a JPEG or PNG image that is
+// larger than opts allows, like the phone apps do before sending. It returns
+// the new JPEG, or nil if the image is within limits, is a GIF, or would not
+// get smaller. The EXIF orientation is applied to the pixels; all other
+// metadata, including location, is dropped.
+func OptimizeImage(data []byte, opts ImageOptions) ([]byte, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	if format == "gif" {
+		return nil, nil
+	}
+	tooWide := opts.MaxSide > 0 && (cfg.Width > opts.MaxSide || cfg.Height > opts.MaxSide)
+	tooBig := opts.MaxBytes > 0 && int64(len(data)) > opts.MaxBytes
+	if !tooWide && !tooBig {
+		return nil, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	if opts.MaxSide > 0 {
+		img = scaleDown(img, opts.MaxSide)
+	}
+	if format == "jpeg" {
+		img = orient(img, exifOrientation(data))
+	}
+	if o, ok := img.(interface{ Opaque() bool }); !ok || !o.Opaque() {
+		img = flatten(img)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: optimizeQuality}); err != nil {
+		return nil, fmt.Errorf("encode image: %w", err)
+	}
+	if !tooWide && buf.Len() >= len(data) {
+		return nil, nil
+	}
+	return buf.Bytes(), nil
+}
+
+// flatten draws an image with transparency onto white, since JPEG has no
+// alpha channel.
+func flatten(img image.Image) image.Image {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	return dst
+}
+
+// orient applies an EXIF orientation (1-8) so the image displays upright
+// without it.
+func orient(img image.Image, o int) image.Image {
+	if o < 2 || o > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if o >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch o {
+			case 2: // mirrored
+				sx, sy = w-1-x, y
+			case 3: // upside down
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored upside down
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // needs a quarter turn clockwise
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // needs a quarter turn counter-clockwise
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// exifOrientation returns the orientation tag of a JPEG's EXIF block, or 1
+// if there is none.
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		if marker == 0xDA { // image data starts; no more metadata
+			break
+		}
+		n := int(binary.BigEndian.Uint16(data[i+2:]))
+		if n < 2 || i+2+n > len(data) {
+			break
+		}
+		seg := data[i+4 : i+2+n]
+		if marker == 0xE1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			return tiffOrientation(seg[6:])
+		}
+		i += 2 + n
+	}
+	return 1
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	off := int(order.Uint32(tiff[4:]))
+	if off < 8 || off+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[off:]))
+	for k := 0; k < count; k++ {
+		e := off + 2 + 12*k
+		if e+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[e:]) == 0x0112 {
+			return int(order.Uint16(tiff[e+8:]))
+		}
+	}
+	return 1
+}
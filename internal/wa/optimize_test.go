@@ -0,0 +1,88 @@
+package wa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+func TestOptimizeImageDownscales(t *testing.T) {
+	out, err := OptimizeImage(testPNG(t, 300, 100), ImageOptions{MaxSide: 150})
+	if err != nil {
+		t.Fatalf("OptimizeImage: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("expected an optimized image")
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("DecodeConfig: %v", err)
+	}
+	if format != "jpeg" || cfg.Width != 150 || cfg.Height != 50 {
+		t.Fatalf("got %s %dx%d, want jpeg 150x50", format, cfg.Width, cfg.Height)
+	}
+}
+
+func TestOptimizeImageWithinLimits(t *testing.T) {
+	data := testPNG(t, 100, 100)
+	out, err := OptimizeImage(data, ImageOptions{MaxSide: 150, MaxBytes: int64(len(data))})
+	if err != nil {
+		t.Fatalf("OptimizeImage: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("image within limits should be left alone")
+	}
+	if _, err := OptimizeImage([]byte("not an image"), ImageOptions{MaxSide: 10}); err == nil {
+		t.Fatalf("expected error for invalid image")
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// 2x1: red on the left, blue on the right.
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	img.Set(1, 0, color.RGBA{0, 0, 255, 255})
+
+	got := orient(img, 6)
+	if b := got.Bounds(); b.Dx() != 1 || b.Dy() != 2 {
+		t.Fatalf("bounds = %v, want 1x2", b)
+	}
+	// A quarter turn clockwise puts the left pixel on top.
+	if r, _, _, _ := got.At(0, 0).RGBA(); r == 0 {
+		t.Fatalf("top pixel should be red")
+	}
+	if got := orient(img, 1); got != img {
+		t.Fatalf("orientation 1 should return the image unchanged")
+	}
+}
+
+func TestExifOrientation(t *testing.T) {
+	var jpg bytes.Buffer
+	if err := jpeg.Encode(&jpg, image.NewRGBA(image.Rect(0, 0, 4, 4)), nil); err != nil {
+		t.Fatalf("jpeg.Encode: %v", err)
+	}
+	if got := exifOrientation(jpg.Bytes()); got != 1 {
+		t.Fatalf("orientation without EXIF = %d, want 1", got)
+	}
+
+	// Little-endian TIFF with one IFD entry: orientation (0x0112) = 6.
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 6)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	seg := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := binary.BigEndian.AppendUint16([]byte{0xFF, 0xE1}, uint16(len(seg)+2))
+	app1 = append(app1, seg...)
+	data := append([]byte{0xFF, 0xD8}, app1...)
+	data = append(data, jpg.Bytes()[2:]...)
+
+	if got := exifOrientation(data); got != 6 {
+		t.Fatalf("orientation = %d, want 6", got)
+	}
+}
@@ -0,0 +1,39 @@
+package wa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// SendPresence marks the linked device online (available) or offline
+// (unavailable). WhatsApp uses it to decide how receipts are sent and
+// whether the phone shows notifications.
+func (c *Client) SendPresence(ctx context.Context, state types.Presence) error {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return ErrNotConnected
+	}
+	err := cli.SendPresence(ctx, state)
+	if errors.Is(err, whatsmeow.ErrNoPushName) {
+		return fmt.Errorf("own push name not synced yet; retry once the initial sync has run")
+	}
+	return err
+}
+
+// SendChatPresence shows or clears "typing..." (composing) or, with media
+// audio, "recording audio..." in a chat.
+func (c *Client) SendChatPresence(ctx context.Context, jid types.JID, state types.ChatPresence, media types.ChatPresenceMedia) error {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return ErrNotConnected
+	}
+	return cli.SendChatPresence(ctx, jid, state, media)
+}
@@ -0,0 +1,123 @@
+package wa
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// maxProfilePictureBytes bounds profile picture downloads; full-size
+// pictures are well below 1 MB.
+const maxProfilePictureBytes = 10 << 20
+
+var profilePictureHTTP = &http.Client{Timeout: 30 * time.Second}
+
+// GetProfilePictureInfo returns where to download the profile picture of a
+// user, group or community. It returns nil without error if
+// params.ExistingID is still the current picture.
+func (c *Client) GetProfilePictureInfo(ctx context.Context, jid types.JID, params *whatsmeow.GetProfilePictureParams) (*types.ProfilePictureInfo, error) {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return nil, ErrNotConnected
+	}
+	return cli.GetProfilePictureInfo(ctx, jid, params)
+}
+
+// Self returns the linked account's JID and push name; the JID is empty
+// before pairing.
+func (c *Client) Self() (types.JID, string) {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || cli.Store == nil || cli.Store.ID == nil {
+		return types.EmptyJID, ""
+	}
+	return cli.Store.ID.ToNonAD(), cli.Store.PushName
+}
+
+// GetUserInfo returns the about text, picture ID and business name of users.
+func (c *Client) GetUserInfo(ctx context.Context, jids []types.JID) (map[types.JID]types.UserInfo, error) {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return nil, ErrNotConnected
+	}
+	return cli.GetUserInfo(ctx, jids)
+}
+
+// SetAbout sets the linked account's about text.
+func (c *Client) SetAbout(ctx context.Context, about string) error {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return ErrNotConnected
+	}
+	return cli.SetStatusMessage(ctx, about)
+}
+
+// SetProfilePhoto sets the linked account's profile picture from JPEG data
+// and returns the new picture ID.
+func (c *Client) SetProfilePhoto(ctx context.Context, jpeg []byte) (string, error) {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return "", ErrNotConnected
+	}
+	// An empty target addresses the account itself.
+	return cli.SetGroupPhoto(ctx, types.EmptyJID, jpeg)
+}
+
+// DownloadProfilePicture fetches a profile picture URL to targetPath. The
+// file is written under a temporary name and renamed into place, so readers
+// never see a partial picture.
+func DownloadProfilePicture(ctx context.Context, url, targetPath string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := profilePictureHTTP.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("download profile picture: HTTP %d", resp.StatusCode)
+	}
+
+	dir := filepath.Dir(targetPath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(dir, tempDownloadPrefix+"*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, io.LimitReader(resp.Body, maxProfilePictureBytes+1))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("download profile picture: %w", err)
+	}
+	if n > maxProfilePictureBytes {
+		return 0, fmt.Errorf("profile picture larger than %d bytes", maxProfilePictureBytes)
+	}
+	if err := os.Rename(tmp.Name(), targetPath); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
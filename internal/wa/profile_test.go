@@ -0,0 +1,39 @@
+package wa
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadProfilePicture(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pic.jpg" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("jpeg-bytes"))
+	}))
+	defer srv.Close()
+
+	dir := filepath.Join(t.TempDir(), "profile", "123@s.whatsapp.net")
+	target := filepath.Join(dir, "image-1.jpg")
+	n, err := DownloadProfilePicture(context.Background(), srv.URL+"/pic.jpg", target)
+	if err != nil || n != int64(len("jpeg-bytes")) {
+		t.Fatalf("DownloadProfilePicture = %d, %v", n, err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "jpeg-bytes" {
+		t.Fatalf("unexpected content %q", data)
+	}
+
+	if _, err := DownloadProfilePicture(context.Background(), srv.URL+"/missing.jpg", filepath.Join(dir, "image-2.jpg")); err == nil {
+		t.Fatalf("expected error for missing picture")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "image-1.jpg" {
+		t.Fatalf("expected only the downloaded picture, got %v", entries)
+	}
+}
@@ -0,0 +1,145 @@
+package wa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// ErrMediaRateLimited is matched (via errors.Is) by media transfers that kept
+// hitting 429/503 responses from the WhatsApp CDN after bounded retries.
+var ErrMediaRateLimited = errors.New("media CDN rate limited")
+
+const (
+	mediaMaxRetries    = 3
+	mediaBaseBackoff   = 2 * time.Second
+	mediaMaxRetryAfter = 60 * time.Second
+)
+
+// MediaRateLimitError describes a media transfer that gave up due to CDN throttling.
+type MediaRateLimitError struct {
+	StatusCode int
+	RetryAfter time.Duration // last server-suggested delay, 0 if unknown
+	Attempts   int
+	Err        error
+}
+
+func (e *MediaRateLimitError) Error() string {
+	return fmt.Sprintf("media CDN rate limited (status %d) after %d attempts: %v", e.StatusCode, e.Attempts, e.Err)
+}
+
+func (e *MediaRateLimitError) Unwrap() error { return e.Err }
+
+func (e *MediaRateLimitError) Is(target error) bool { return target == ErrMediaRateLimited }
+
+// MediaStats are process-wide counters for media CDN throttling.
+type MediaStats struct {
+	RateLimited int64 `json:"rate_limited"` // responses with 429/503
+	Retries     int64 `json:"retries"`      // retries performed after throttling
+	Failures    int64 `json:"failures"`     // transfers that gave up while throttled
+}
+
+var mediaStats struct {
+	rateLimited atomic.Int64
+	retries     atomic.Int64
+	failures    atomic.Int64
+}
+
+// MediaRateLimitStats returns a snapshot of the media throttling counters.
+func MediaRateLimitStats() MediaStats {
+	return MediaStats{
+		RateLimited: mediaStats.rateLimited.Load(),
+		Retries:     mediaStats.retries.Load(),
+		Failures:    mediaStats.failures.Load(),
+	}
+}
+
+// uploadStatusPrefix starts the error whatsmeow returns for an upload the
+// CDN answered with a non-200 status. Unlike downloads, which fail with a
+// whatsmeow.DownloadHTTPError, uploads have no typed error to match, so
+// uploadStatus parses the message; TestUploadStatusMessage pins it.
+const uploadStatusPrefix = "upload failed with status code "
+
+// uploadStatus returns the HTTP status of a failed whatsmeow upload.
+func uploadStatus(err error) (int, bool) {
+	msg := err.Error()
+	i := strings.Index(msg, uploadStatusPrefix)
+	if i < 0 {
+		return 0, false
+	}
+	digits := msg[i+len(uploadStatusPrefix):]
+	if end := strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }); end >= 0 {
+		digits = digits[:end]
+	}
+	status, err := strconv.Atoi(digits)
+	return status, err == nil
+}
+
+// mediaThrottle reports whether err is a CDN throttling response and the
+// Retry-After delay it carried, if any.
+func mediaThrottle(err error) (status int, retryAfter time.Duration, ok bool) {
+	var httpErr whatsmeow.DownloadHTTPError
+	if errors.As(err, &httpErr) && httpErr.Response != nil {
+		status = httpErr.StatusCode
+		retryAfter = parseRetryAfter(httpErr.Header.Get("Retry-After"))
+	} else {
+		status, _ = uploadStatus(err)
+	}
+	return status, retryAfter, status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// withMediaRetry runs fn, retrying on CDN throttling. Server-provided
+// Retry-After delays are honored unless they exceed mediaMaxRetryAfter, in
+// which case the transfer fails right away instead of blocking the caller.
+func withMediaRetry(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		status, retryAfter, throttled := mediaThrottle(err)
+		if !throttled {
+			return err
+		}
+		mediaStats.rateLimited.Add(1)
+
+		wait := retryAfter
+		if wait <= 0 {
+			wait = mediaBaseBackoff << (attempt - 1)
+		}
+		if attempt > mediaMaxRetries || wait > mediaMaxRetryAfter {
+			mediaStats.failures.Add(1)
+			return &MediaRateLimitError{StatusCode: status, RetryAfter: retryAfter, Attempts: attempt, Err: err}
+		}
+
+		debugLog("[wa] media CDN returned %d, retrying in %s (attempt %d)\n", status, wait, attempt)
+		mediaStats.retries.Add(1)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
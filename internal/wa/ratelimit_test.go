@@ -0,0 +1,103 @@
+package wa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+func throttled(status int, retryAfter string) error {
+	resp := &http.Response{StatusCode: status, Header: http.Header{}}
+	if retryAfter != "" {
+		resp.Header.Set("Retry-After", retryAfter)
+	}
+	return fmt.Errorf("failed to download media from last host: %w", whatsmeow.DownloadHTTPError{Response: resp})
+}
+
+func TestMediaThrottle(t *testing.T) {
+	if status, wait, ok := mediaThrottle(throttled(429, "7")); !ok || status != 429 || wait != 7*time.Second {
+		t.Fatalf("unexpected 429 classification: %d %s %v", status, wait, ok)
+	}
+	if status, _, ok := mediaThrottle(fmt.Errorf("failed to upload media: %w", errors.New("upload failed with status code 503"))); !ok || status != 503 {
+		t.Fatalf("unexpected upload classification: %d %v", status, ok)
+	}
+	if _, _, ok := mediaThrottle(throttled(404, "")); ok {
+		t.Fatalf("404 must not be treated as throttling")
+	}
+}
+
+func TestUploadStatus(t *testing.T) {
+	cases := []struct {
+		err    string
+		status int
+		ok     bool
+	}{
+		{"upload failed with status code 429", 429, true},
+		{"failed to upload media: upload failed with status code 503 (retry later)", 503, true},
+		{"upload failed with status code ", 0, false},
+		{"failed to parse upload response: EOF", 0, false},
+	}
+	for _, c := range cases {
+		if status, ok := uploadStatus(errors.New(c.err)); status != c.status || ok != c.ok {
+			t.Fatalf("uploadStatus(%q) = %d, %v; want %d, %v", c.err, status, ok, c.status, c.ok)
+		}
+	}
+}
+
+// TestUploadStatusMessage pins the whatsmeow upload error that uploadStatus
+// parses, so a dependency update that rewords it fails here rather than
+// silently disabling upload retries.
+func TestUploadStatusMessage(t *testing.T) {
+	file, _ := runtime.FuncForPC(reflect.ValueOf(whatsmeow.NewClient).Pointer()).FileLine(0)
+	src, err := os.ReadFile(filepath.Join(filepath.Dir(file), "upload.go"))
+	if err != nil {
+		t.Skipf("whatsmeow source not available: %v", err)
+	}
+	if want := `fmt.Errorf("` + uploadStatusPrefix + `%d", httpResp.StatusCode)`; !strings.Contains(string(src), want) {
+		t.Fatalf("whatsmeow upload.go no longer returns %s; update uploadStatus", want)
+	}
+}
+
+func TestWithMediaRetry(t *testing.T) {
+	calls := 0
+	err := withMediaRetry(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return throttled(503, "1")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("expected success on third call, got calls=%d err=%v", calls, err)
+	}
+
+	// A Retry-After beyond the cap fails immediately with a typed error.
+	calls = 0
+	err = withMediaRetry(context.Background(), func() error {
+		calls++
+		return throttled(429, "3600")
+	})
+	if calls != 1 || !errors.Is(err, ErrMediaRateLimited) {
+		t.Fatalf("expected immediate rate limit error, got calls=%d err=%v", calls, err)
+	}
+	var rl *MediaRateLimitError
+	if !errors.As(err, &rl) || rl.StatusCode != 429 || rl.RetryAfter != time.Hour {
+		t.Fatalf("unexpected error details: %+v", rl)
+	}
+
+	// Other errors are returned as-is.
+	plain := errors.New("boom")
+	if err := withMediaRetry(context.Background(), func() error { return plain }); err != plain {
+		t.Fatalf("expected passthrough error, got %v", err)
+	}
+}
@@ -0,0 +1,242 @@
+package wa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// resumableMinSize is the smallest media size for which the encrypted
+// download is kept on disk between attempts and resumed with Range requests.
+const resumableMinSize = 8 << 20
+
+// partialSuffix is appended to the target path of a resumable download.
+const partialSuffix = ".part"
+
+// DownloadProgress tracks a single media download. It is safe for concurrent use.
+type DownloadProgress struct {
+	received    atomic.Int64
+	total       atomic.Int64
+	resumedFrom atomic.Int64
+	updatedAt   atomic.Int64 // unix nanoseconds
+}
+
+// DownloadProgressSnapshot is a point-in-time view of a DownloadProgress.
+type DownloadProgressSnapshot struct {
+	Received    int64     // encrypted bytes available, including resumed ones
+	Total       int64     // expected encrypted size, 0 if unknown
+	ResumedFrom int64     // bytes reused from a previous attempt
+	UpdatedAt   time.Time // last time bytes were received
+}
+
+// Snapshot returns the current progress values.
+func (p *DownloadProgress) Snapshot() DownloadProgressSnapshot {
+	s := DownloadProgressSnapshot{
+		Received:    p.received.Load(),
+		Total:       p.total.Load(),
+		ResumedFrom: p.resumedFrom.Load(),
+	}
+	if ts := p.updatedAt.Load(); ts > 0 {
+		s.UpdatedAt = time.Unix(0, ts).UTC()
+	}
+	return s
+}
+
+func (p *DownloadProgress) add(n int64) {
+	p.received.Add(n)
+	p.updatedAt.Store(time.Now().UnixNano())
+}
+
+type progressKey struct{}
+
+// WithDownloadProgress returns a context that reports media download progress to p.
+func WithDownloadProgress(ctx context.Context, p *DownloadProgress) context.Context {
+	return context.WithValue(ctx, progressKey{}, p)
+}
+
+func progressFromContext(ctx context.Context) *DownloadProgress {
+	p, _ := ctx.Value(progressKey{}).(*DownloadProgress)
+	return p
+}
+
+type partialKey struct{}
+
+func withPartialFile(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, partialKey{}, path)
+}
+
+// PartialPath returns where the encrypted bytes of a resumable download to
+// targetPath are kept between attempts.
+func PartialPath(targetPath string) string {
+	return targetPath + partialSuffix
+}
+
+// isCorruptMedia reports whether err means the downloaded bytes themselves are
+// bad, in which case a partial download must not be resumed.
+func isCorruptMedia(err error) bool {
+	return errors.Is(err, whatsmeow.ErrInvalidMediaHMAC) ||
+		errors.Is(err, whatsmeow.ErrInvalidMediaEncSHA256) ||
+		errors.Is(err, whatsmeow.ErrInvalidMediaSHA256) ||
+		errors.Is(err, whatsmeow.ErrFileLengthMismatch) ||
+		errors.Is(err, whatsmeow.ErrTooShortFile)
+}
+
+// resumeTransport is the media HTTP transport. For downloads carrying a
+// partial file in their context it requests only the missing bytes and
+// replays the stored prefix, so whatsmeow still sees (and verifies) the
+// complete ciphertext.
+type resumeTransport struct {
+	base http.RoundTripper
+}
+
+func newMediaHTTPClient() *http.Client {
+	return &http.Client{Transport: &resumeTransport{base: http.DefaultTransport.(*http.Transport).Clone()}}
+}
+
+func (t *resumeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	progress := progressFromContext(ctx)
+	partPath, _ := ctx.Value(partialKey{}).(string)
+	if req.Method != http.MethodGet || (progress == nil && partPath == "") {
+		return t.base.RoundTrip(req)
+	}
+	if progress == nil {
+		progress = &DownloadProgress{}
+	}
+
+	var part *os.File
+	var offset int64
+	if partPath != "" {
+		f, err := os.OpenFile(partPath, os.O_RDWR|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("open partial download: %w", err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("stat partial download: %w", err)
+		}
+		part, offset = f, info.Size()
+	}
+
+	resp, err := t.do(req, offset)
+	if err == nil && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		// The stored prefix does not match the remote file; start over.
+		_ = resp.Body.Close()
+		offset = 0
+		resp, err = t.do(req, 0)
+	}
+	if err != nil || (resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent) {
+		if part != nil {
+			_ = part.Close()
+		}
+		return resp, err
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		// Full body: either no range was asked for or the server ignored it.
+		offset = 0
+	}
+	progress.resumedFrom.Store(offset)
+	progress.received.Store(offset)
+	if total := contentTotal(resp, offset); total > 0 {
+		progress.total.Store(total)
+	}
+
+	body := &progressReader{r: resp.Body, progress: progress, closers: []io.Closer{resp.Body}}
+	if part != nil {
+		if err := part.Truncate(offset); err != nil {
+			_ = part.Close()
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("truncate partial download: %w", err)
+		}
+		if _, err := part.Seek(offset, io.SeekStart); err != nil {
+			_ = part.Close()
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("seek partial download: %w", err)
+		}
+		body.r = io.MultiReader(io.NewSectionReader(part, 0, offset), io.TeeReader(resp.Body, part))
+		body.skip = offset
+		body.closers = append(body.closers, part)
+	}
+
+	resp.Body = body
+	if resp.StatusCode == http.StatusPartialContent {
+		resp.StatusCode = http.StatusOK
+		resp.Status = "200 OK"
+		if resp.ContentLength >= 0 {
+			resp.ContentLength += offset
+		}
+		resp.Header.Del("Content-Range")
+	}
+	return resp, nil
+}
+
+func (t *resumeTransport) do(req *http.Request, offset int64) (*http.Response, error) {
+	if offset <= 0 {
+		return t.base.RoundTrip(req)
+	}
+	r := req.Clone(req.Context())
+	r.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	return t.base.RoundTrip(r)
+}
+
+// contentTotal returns the full size of the remote file, if known.
+func contentTotal(resp *http.Response, offset int64) int64 {
+	if cr := resp.Header.Get("Content-Range"); cr != "" {
+		if i := strings.LastIndex(cr, "/"); i >= 0 {
+			if n, err := strconv.ParseInt(cr[i+1:], 10, 64); err == nil {
+				return n
+			}
+		}
+	}
+	if resp.ContentLength > 0 {
+		if resp.StatusCode == http.StatusPartialContent {
+			return offset + resp.ContentLength
+		}
+		return resp.ContentLength
+	}
+	return 0
+}
+
+// progressReader counts network bytes and closes the response body together
+// with the partial file.
+type progressReader struct {
+	r        io.Reader
+	progress *DownloadProgress
+	skip     int64 // replayed prefix bytes that are already counted
+	closers  []io.Closer
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	counted := int64(n)
+	if pr.skip > 0 {
+		used := min(pr.skip, counted)
+		pr.skip -= used
+		counted -= used
+	}
+	if counted > 0 {
+		pr.progress.add(counted)
+	}
+	return n, err
+}
+
+func (pr *progressReader) Close() error {
+	var first error
+	for _, c := range pr.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,92 @@
+package wa
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResumeTransportContinuesPartialDownload(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	var gotRange string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRange = r.Header.Get("Range")
+		http.ServeContent(w, r, "media", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	partPath := filepath.Join(t.TempDir(), "media.bin"+partialSuffix)
+	if err := os.WriteFile(partPath, content[:4000], 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	progress := &DownloadProgress{}
+	ctx := withPartialFile(WithDownloadProgress(context.Background(), progress), partPath)
+	client := &http.Client{Transport: &resumeTransport{base: http.DefaultTransport}}
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+
+	if gotRange != "bytes=4000-" {
+		t.Fatalf("expected range request, got %q", gotRange)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected rewritten 200, got %d", resp.StatusCode)
+	}
+	if !bytes.Equal(body, content) {
+		t.Fatalf("stitched body does not match content (%d bytes)", len(body))
+	}
+	stored, _ := os.ReadFile(partPath)
+	if !bytes.Equal(stored, content) {
+		t.Fatalf("partial file not completed (%d bytes)", len(stored))
+	}
+	snap := progress.Snapshot()
+	if snap.ResumedFrom != 4000 || snap.Received != int64(len(content)) || snap.Total != int64(len(content)) {
+		t.Fatalf("unexpected progress: %+v", snap)
+	}
+}
+
+func TestResumeTransportRestartsWhenRangeIgnored(t *testing.T) {
+	content := []byte(strings.Repeat("x", 2048))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer srv.Close()
+
+	partPath := filepath.Join(t.TempDir(), "media.bin"+partialSuffix)
+	if err := os.WriteFile(partPath, []byte("stale prefix"), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	ctx := withPartialFile(context.Background(), partPath)
+	client := &http.Client{Transport: &resumeTransport{base: http.DefaultTransport}}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if !bytes.Equal(body, content) {
+		t.Fatalf("expected full body when server ignores Range")
+	}
+	if stored, _ := os.ReadFile(partPath); !bytes.Equal(stored, content) {
+		t.Fatalf("expected partial file to be rewritten, got %d bytes", len(stored))
+	}
+}
@@ -0,0 +1,181 @@
+package wa
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register decoders for ImageThumbnail
+	_ "image/png"
+	"os"
+	"os/exec"
+	"time"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// thumbnailMaxSide bounds the inline preview; WhatsApp blurs it until the
+	// full media is downloaded, so it only needs to be small.
+	thumbnailMaxSide = 72
+	thumbnailQuality = 70
+
+	videoFrameTimeout = 15 * time.Second
+)
+
+// Thumbnail is the inline JPEG preview of an image, video or document, with
+// the dimensions of the full media and of the preview itself.
+type Thumbnail struct {
+	JPEG        []byte
+	Width       int
+	Height      int
+	ThumbWidth  int
+	ThumbHeight int
+}
+
+// ImageThumbnail renders a JPEG preview of a JPEG, PNG or GIF image.
+func ImageThumbnail(data []byte) (*Thumbnail, error) {
+	return renderThumbnail(data, thumbnailMaxSide)
+}
+
+// VideoThumbnail renders a JPEG preview of a video's first frame. It needs
+// ffmpeg on PATH.
+func VideoThumbnail(ctx context.Context, data []byte) (*Thumbnail, error) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return nil, fmt.Errorf("ffmpeg not found: %w", err)
+	}
+	var t *Thumbnail
+	err := withTempFile(data, func(path string) (err error) {
+		t, err = VideoFileThumbnail(ctx, path)
+		return err
+	})
+	return t, err
+}
+
+// withTempFile writes data to a temporary file for fn. MP4s often keep their
+// index at the end, so ffmpeg needs a seekable file rather than a pipe.
+func withTempFile(data []byte, fn func(path string) error) error {
+	f, err := os.CreateTemp("", "wacli-media-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return fn(f.Name())
+}
+
+// VideoFileThumbnail is VideoThumbnail for a video on disk.
+func VideoFileThumbnail(ctx context.Context, path string) (*Thumbnail, error) {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg not found: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, videoFrameTimeout)
+	defer cancel()
+	var out, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpeg, "-hide_banner", "-loglevel", "error",
+		"-i", path, "-frames:v", "1", "-f", "image2", "-c:v", "png", "pipe:1")
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return ImageThumbnail(out.Bytes())
+}
+
+// AttachThumbnail sets the inline preview and dimensions of an image or
+// video message from its media data. Without them, recipients see a blank
+// box until the media is downloaded. PDF documents get their page count and
+// a preview of the first page. Other messages are left alone.
+func AttachThumbnail(ctx context.Context, msg *waProto.Message, data []byte) error {
+	switch {
+	case msg.GetImageMessage() != nil:
+		t, err := ImageThumbnail(data)
+		if err != nil {
+			return err
+		}
+		im := msg.ImageMessage
+		im.JPEGThumbnail = t.JPEG
+		im.Width = proto.Uint32(uint32(t.Width))
+		im.Height = proto.Uint32(uint32(t.Height))
+	case msg.GetVideoMessage() != nil:
+		t, err := VideoThumbnail(ctx, data)
+		if err != nil {
+			return err
+		}
+		vm := msg.VideoMessage
+		vm.JPEGThumbnail = t.JPEG
+		vm.Width = proto.Uint32(uint32(t.Width))
+		vm.Height = proto.Uint32(uint32(t.Height))
+	case isPDF(msg.GetDocumentMessage()):
+		return withTempFile(data, func(path string) error {
+			return attachPDFPreview(ctx, msg.DocumentMessage, path)
+		})
+	}
+	return nil
+}
+
+// AttachFileThumbnail is AttachThumbnail for media on disk; only images are
+// read into memory.
+func AttachFileThumbnail(ctx context.Context, msg *waProto.Message, path string) error {
+	switch {
+	case msg.GetImageMessage() != nil:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return AttachThumbnail(ctx, msg, data)
+	case msg.GetVideoMessage() != nil:
+		t, err := VideoFileThumbnail(ctx, path)
+		if err != nil {
+			return err
+		}
+		vm := msg.VideoMessage
+		vm.JPEGThumbnail = t.JPEG
+		vm.Width = proto.Uint32(uint32(t.Width))
+		vm.Height = proto.Uint32(uint32(t.Height))
+	case isPDF(msg.GetDocumentMessage()):
+		return attachPDFPreview(ctx, msg.DocumentMessage, path)
+	}
+	return nil
+}
+
+// scaleDown shrinks img so its longer side is at most maxSide, averaging
+// the source pixels behind each target pixel.
+func scaleDown(img image.Image, maxSide int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		return img
+	}
+	tw, th := maxSide, h*maxSide/w
+	if h > w {
+		tw, th = w*maxSide/h, maxSide
+	}
+	tw, th = max(tw, 1), max(th, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := b.Min.Y+y*h/th, b.Min.Y+(y+1)*h/th
+		for x := 0; x < tw; x++ {
+			x0, x1 := b.Min.X+x*w/tw, b.Min.X+(x+1)*w/tw
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < max(y1, y0+1); sy++ {
+				for sx := x0; sx < max(x1, x0+1); sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, bl, a, n = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			dst.Set(x, y, color.RGBA64{uint16(r / n), uint16(g / n), uint16(bl / n), uint16(a / n)})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,74 @@
+package wa
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestImageThumbnail(t *testing.T) {
+	th, err := ImageThumbnail(testPNG(t, 400, 200))
+	if err != nil {
+		t.Fatalf("ImageThumbnail: %v", err)
+	}
+	if th.Width != 400 || th.Height != 200 {
+		t.Fatalf("expected full dimensions 400x200, got %dx%d", th.Width, th.Height)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(th.JPEG))
+	if err != nil {
+		t.Fatalf("thumbnail is not a JPEG: %v", err)
+	}
+	if cfg.Width != thumbnailMaxSide || cfg.Height != thumbnailMaxSide/2 {
+		t.Fatalf("expected %dx%d thumbnail, got %dx%d", thumbnailMaxSide, thumbnailMaxSide/2, cfg.Width, cfg.Height)
+	}
+
+	// Small images keep their size.
+	th, err = ImageThumbnail(testPNG(t, 10, 30))
+	if err != nil {
+		t.Fatalf("ImageThumbnail: %v", err)
+	}
+	if cfg, _ := jpeg.DecodeConfig(bytes.NewReader(th.JPEG)); cfg.Width != 10 || cfg.Height != 30 {
+		t.Fatalf("expected 10x30 thumbnail, got %dx%d", cfg.Width, cfg.Height)
+	}
+
+	if _, err := ImageThumbnail([]byte("not an image")); err == nil {
+		t.Fatalf("expected error for invalid image")
+	}
+}
+
+func TestAttachThumbnail(t *testing.T) {
+	msg := &waProto.Message{ImageMessage: &waProto.ImageMessage{}}
+	if err := AttachThumbnail(context.Background(), msg, testPNG(t, 100, 150)); err != nil {
+		t.Fatalf("AttachThumbnail: %v", err)
+	}
+	im := msg.GetImageMessage()
+	if len(im.GetJPEGThumbnail()) == 0 || im.GetWidth() != 100 || im.GetHeight() != 150 {
+		t.Fatalf("expected thumbnail and dimensions, got %d bytes %dx%d", len(im.GetJPEGThumbnail()), im.GetWidth(), im.GetHeight())
+	}
+
+	doc := &waProto.Message{DocumentMessage: &waProto.DocumentMessage{}}
+	if err := AttachThumbnail(context.Background(), doc, []byte("x")); err != nil {
+		t.Fatalf("documents should be left alone: %v", err)
+	}
+}
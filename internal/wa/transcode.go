@@ -0,0 +1,68 @@
+package wa
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+const videoTranscodeTimeout = 10 * time.Minute
+
+// TranscodeOptions caps the output of TranscodeVideo.
+type TranscodeOptions struct {
+	MaxSide   int // shorter side of the frame, in pixels; zero keeps the source size
+	VideoKbps int // zero lets the encoder pick the bitrate
+	AudioKbps int
+}
+
+// TranscodeVideo re-encodes the video at src to an H.264/AAC MP4 at dst,
+// the only format every WhatsApp client plays inline. It needs ffmpeg on
+// PATH.
+func TranscodeVideo(ctx context.Context, src, dst string, opts TranscodeOptions) error {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return fmt.Errorf("ffmpeg not found: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, videoTranscodeTimeout)
+	defer cancel()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpeg, transcodeArgs(src, dst, opts)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
+
+func transcodeArgs(src, dst string, opts TranscodeOptions) []string {
+	args := []string{"-hide_banner", "-loglevel", "error", "-y",
+		"-i", src,
+		"-map", "0:v:0", "-map", "0:a:0?",
+		"-c:v", "libx264", "-profile:v", "main", "-preset", "veryfast", "-pix_fmt", "yuv420p",
+	}
+	// yuv420p needs even dimensions, whether or not the frame is scaled.
+	scale := "scale=trunc(iw/2)*2:trunc(ih/2)*2"
+	if opts.MaxSide > 0 {
+		// Bound the shorter side so portrait videos keep the same detail as
+		// landscape ones; never upscale.
+		n := strconv.Itoa(opts.MaxSide)
+		scale = "scale=w='if(gt(iw,ih),-2,trunc(min(iw," + n + ")/2)*2)'" +
+			":h='if(gt(iw,ih),trunc(min(ih," + n + ")/2)*2,-2)'"
+	}
+	args = append(args, "-vf", scale)
+	if opts.VideoKbps > 0 {
+		kbps := strconv.Itoa(opts.VideoKbps) + "k"
+		args = append(args, "-b:v", kbps, "-maxrate", kbps,
+			"-bufsize", strconv.Itoa(opts.VideoKbps*2)+"k")
+	}
+	args = append(args, "-c:a", "aac", "-ac", "2")
+	if opts.AudioKbps > 0 {
+		args = append(args, "-b:a", strconv.Itoa(opts.AudioKbps)+"k")
+	}
+	// Moving the index to the front lets recipients start playback before
+	// the whole file has downloaded.
+	return append(args, "-movflags", "+faststart", "-f", "mp4", dst)
+}
@@ -0,0 +1,32 @@
+package wa
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTranscodeArgs(t *testing.T) {
+	args := strings.Join(transcodeArgs("in.mov", "out.mp4", TranscodeOptions{MaxSide: 720, VideoKbps: 1500, AudioKbps: 128}), " ")
+	for _, want := range []string{
+		"-i in.mov",
+		"-c:v libx264",
+		"-pix_fmt yuv420p",
+		"min(iw,720)",
+		"-b:v 1500k -maxrate 1500k -bufsize 3000k",
+		"-c:a aac",
+		"-b:a 128k",
+		"-movflags +faststart",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("args missing %q: %s", want, args)
+		}
+	}
+	if !strings.HasSuffix(args, " out.mp4") {
+		t.Errorf("output should be last: %s", args)
+	}
+
+	args = strings.Join(transcodeArgs("in.mov", "out.mp4", TranscodeOptions{}), " ")
+	if strings.Contains(args, "-b:v") || strings.Contains(args, "min(") {
+		t.Errorf("uncapped transcode should not limit size or bitrate: %s", args)
+	}
+}
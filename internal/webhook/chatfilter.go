@@ -0,0 +1,108 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidChatFilter is returned for a chat filter entry that is neither a
+// JID nor a chat kind.
+var ErrInvalidChatFilter = errors.New("invalid chat filter")
+
+// Chat kinds a ChatFilter entry may name instead of a JID.
+var chatKinds = map[string]bool{"dm": true, "group": true, "broadcast": true, "channel": true}
+
+// ChatFilter limits an endpoint to events of some chats. Entries are chat
+// JIDs or chat kinds: dm, group, broadcast or channel. Events not tied to a
+// chat (connection state, sync progress, ...) are never filtered out.
+type ChatFilter struct {
+	Chats        []string `json:"chats,omitempty"`         // only these; empty means all
+	ExcludeChats []string `json:"exclude_chats,omitempty"` // never these, even if in Chats
+}
+
+// IsChatKind reports whether s names a chat kind rather than a chat.
+func IsChatKind(s string) bool {
+	return chatKinds[s]
+}
+
+// IsEmpty reports whether the filter lets every chat through.
+func (f ChatFilter) IsEmpty() bool {
+	return len(f.Chats) == 0 && len(f.ExcludeChats) == 0
+}
+
+// Validate checks that every entry is a chat kind or a JID.
+func (f ChatFilter) Validate() error {
+	for _, list := range [][]string{f.Chats, f.ExcludeChats} {
+		for _, e := range list {
+			e = strings.TrimSpace(e)
+			if !chatKinds[e] && !strings.Contains(e, "@") {
+				return fmt.Errorf("%w: entry %q: want a JID or one of dm, group, broadcast, channel", ErrInvalidChatFilter, e)
+			}
+		}
+	}
+	return nil
+}
+
+// Allows reports whether events of chatJID pass the filter. An empty
+// chatJID always passes.
+func (f ChatFilter) Allows(chatJID string) bool {
+	if chatJID == "" {
+		return true
+	}
+	kind := ChatKind(chatJID)
+	match := func(list []string) bool {
+		for _, e := range list {
+			e = strings.TrimSpace(e)
+			if e == chatJID || e == kind {
+				return true
+			}
+		}
+		return false
+	}
+	if match(f.ExcludeChats) {
+		return false
+	}
+	return len(f.Chats) == 0 || match(f.Chats)
+}
+
+// ChatKind classifies a chat JID as dm, group, broadcast or channel, or
+// returns "" for anything else.
+func ChatKind(chatJID string) string {
+	_, server, _ := strings.Cut(chatJID, "@")
+	switch server {
+	case "s.whatsapp.net", "lid":
+		return "dm"
+	case "g.us":
+		return "group"
+	case "broadcast":
+		return "broadcast"
+	case "newsletter":
+		return "channel"
+	}
+	return ""
+}
+
+// EventChat returns the chat an event payload is about: its chat_jid, or
+// group_jid or channel_jid for events of those. It returns "" for events
+// not tied to a chat.
+func EventChat(payload []byte) string {
+	var ev struct {
+		Data struct {
+			ChatJID    string `json:"chat_jid"`
+			GroupJID   string `json:"group_jid"`
+			ChannelJID string `json:"channel_jid"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		return ""
+	}
+	switch {
+	case ev.Data.ChatJID != "":
+		return ev.Data.ChatJID
+	case ev.Data.GroupJID != "":
+		return ev.Data.GroupJID
+	}
+	return ev.Data.ChannelJID
+}
@@ -5,15 +5,39 @@ import (
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/tls"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// Headers carrying the delivery time and its signature. The signature header
+// name can be changed with Config.SignatureHeader.
+const (
+	TimestampHeader        = "X-Webhook-Timestamp"
+	DefaultSignatureHeader = "X-Webhook-Signature"
 )
 
+// ErrUnknownEndpoint is returned for a URL that is not a configured endpoint.
+var ErrUnknownEndpoint = errors.New("webhook endpoint not configured")
+
+// refillInterval is how often persisted events that did not fit into the
+// in-memory queue are picked up again.
+const refillInterval = 5 * time.Second
+
+// retryBackoff is the delay before the first retry; it doubles with every
+// further attempt. Tests shorten it.
+var retryBackoff = time.Second
+
 // Event represents a webhook event payload.
 type Event struct {
 	Type      string      `json:"type"`
@@ -21,12 +45,65 @@ type Event struct {
 	Data      interface{} `json:"data"`
 }
 
+// Endpoint is a single webhook target with its own secret, event filter and
+// chat filter.
+type Endpoint struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret,omitempty"`
+	// SecondarySecret also signs deliveries while secrets are rotated, so
+	// receivers holding either secret accept them.
+	SecondarySecret string            `json:"secondary_secret,omitempty"`
+	Events          []string          `json:"events,omitempty"`  // empty means all events; "group.*" matches a prefix
+	Headers         map[string]string `json:"headers,omitempty"` // static headers sent with every delivery, e.g. Authorization
+	ChatFilter
+}
+
+// Matches reports whether the endpoint subscribes to the given event type.
+func (ep Endpoint) Matches(eventType string) bool {
+	return MatchEvent(ep.Events, eventType)
+}
+
+// MatchEvent reports whether eventType matches any of the filter patterns.
+// An empty filter matches all events; "group.*" matches a prefix.
+func MatchEvent(patterns []string, eventType string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		switch {
+		case pattern == "*" || pattern == eventType:
+			return true
+		case strings.HasSuffix(pattern, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(pattern, "*")):
+			return true
+		}
+	}
+	return false
+}
+
 // Config holds webhook configuration.
 type Config struct {
+	// URL and Secret configure a single endpoint receiving all events.
+	// They are kept for backwards compatibility and are merged into Endpoints.
 	URL        string
 	Secret     string
+	Endpoints  []Endpoint
 	MaxRetries int
 	Timeout    time.Duration
+	// DB, when set, persists queued events so undelivered ones survive
+	// restarts and queue overflow, and keeps events that exhaust their
+	// retries in the dead-letter table.
+	DB store.Store
+	// TLS, when set, is used for all deliveries (client certificate for
+	// mutual TLS and/or custom root CAs). See LoadTLSConfig.
+	TLS *tls.Config
+	// SignatureHeader names the header carrying the HMAC signature;
+	// DefaultSignatureHeader if empty.
+	SignatureHeader string
+	// SignTimestamp signs the timestamp and payload rather than the payload
+	// alone, so receivers can reject replays. Off by default because it
+	// breaks receivers that check a plain HMAC of the body.
+	SignTimestamp bool
 }
 
 // Emitter handles webhook delivery with retry logic.
@@ -35,14 +112,27 @@ type Emitter struct {
 	client     *http.Client
 	queue      chan *queuedEvent
 	wg         sync.WaitGroup
+	refillWG   sync.WaitGroup
 	ctx        context.Context
 	cancel     context.CancelFunc
 	maxWorkers int
+
+	inflightMu sync.Mutex
+	inflight   map[int64]bool // persisted ids currently queued or being delivered
+
+	filtersMu sync.RWMutex
+	filters   map[string]ChatFilter // chat filters set at runtime, by endpoint URL
+
+	metricsMu sync.Mutex
+	metrics   map[string]*EndpointStats // delivery counters, by endpoint URL
 }
 
 type queuedEvent struct {
-	event   *Event
-	retries int
+	id        int64 // webhook_queue row id; 0 when not persisted
+	endpoint  Endpoint
+	eventType string
+	payload   []byte
+	retries   int
 }
 
 // NewEmitter creates a new webhook emitter.
@@ -53,18 +143,41 @@ func NewEmitter(cfg Config) *Emitter {
 	if cfg.Timeout <= 0 {
 		cfg.Timeout = 10 * time.Second
 	}
+	if cfg.SignatureHeader == "" {
+		cfg.SignatureHeader = DefaultSignatureHeader
+	}
+	if cfg.URL != "" {
+		cfg.Endpoints = append([]Endpoint{{URL: cfg.URL, Secret: cfg.Secret}}, cfg.Endpoints...)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
+	client := &http.Client{Timeout: cfg.Timeout}
+	if cfg.TLS != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = cfg.TLS
+		client.Transport = transport
+	}
+
 	e := &Emitter{
-		config: cfg,
-		client: &http.Client{
-			Timeout: cfg.Timeout,
-		},
+		config:     cfg,
+		client:     client,
 		queue:      make(chan *queuedEvent, 1000),
 		ctx:        ctx,
 		cancel:     cancel,
 		maxWorkers: 4,
+		inflight:   make(map[int64]bool),
+		filters:    make(map[string]ChatFilter),
+		metrics:    make(map[string]*EndpointStats),
+	}
+	if cfg.DB != nil {
+		fs, err := cfg.DB.ListWebhookChatFilters()
+		if err != nil {
+			log.Printf("[Webhook] Failed to load chat filters: %v", err)
+		}
+		for _, f := range fs {
+			e.filters[f.EndpointURL] = ChatFilter{Chats: f.Chats, ExcludeChats: f.ExcludeChats}
+		}
 	}
 
 	return e
@@ -77,19 +190,50 @@ func (e *Emitter) Start() {
 		go e.worker()
 	}
 	log.Printf("[Webhook] Started %d workers", e.maxWorkers)
+
+	if e.config.DB != nil {
+		e.refillWG.Add(1)
+		go e.refillLoop()
+	}
 }
 
-// Stop gracefully shuts down the emitter.
+// Stop gracefully shuts down the emitter. Persisted events that were not
+// delivered yet stay in the store and are retried on the next start.
 func (e *Emitter) Stop() {
 	e.cancel()
+	e.refillWG.Wait()
 	close(e.queue)
 	e.wg.Wait()
 	log.Println("[Webhook] Stopped")
 }
 
-// Emit queues an event for delivery.
+// Emit queues an event for delivery to every endpoint subscribed to its type
+// whose chat filter lets the event's chat through.
 func (e *Emitter) Emit(eventType string, data interface{}) {
-	if e.config.URL == "" {
+	e.emit(eventType, data, func(ep Endpoint, chat string) bool {
+		if !ep.Matches(eventType) {
+			return false
+		}
+		f, _ := e.ChatFilter(ep.URL)
+		return f.Allows(chat)
+	})
+}
+
+// EmitTo queues an event for delivery to the configured endpoints with the
+// given URLs only, whatever their event filter. Unknown URLs are ignored.
+func (e *Emitter) EmitTo(urls []string, eventType string, data interface{}) {
+	e.emit(eventType, data, func(ep Endpoint, _ string) bool {
+		for _, u := range urls {
+			if u == ep.URL {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (e *Emitter) emit(eventType string, data interface{}, want func(ep Endpoint, chat string) bool) {
+	if len(e.config.Endpoints) == 0 {
 		return
 	}
 
@@ -98,12 +242,155 @@ func (e *Emitter) Emit(eventType string, data interface{}) {
 		Timestamp: time.Now().UTC(),
 		Data:      data,
 	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("[Webhook] Failed to marshal event: %v", err)
+		return
+	}
+
+	chat := EventChat(payload)
+	for _, ep := range e.config.Endpoints {
+		if !want(ep, chat) {
+			continue
+		}
+		qe := &queuedEvent{endpoint: ep, eventType: eventType, payload: payload}
+		if e.config.DB != nil {
+			id, err := e.config.DB.EnqueueWebhookEvent(ep.URL, eventType, payload)
+			if err != nil {
+				log.Printf("[Webhook] Failed to persist event for %s: %v", ep.URL, err)
+			} else {
+				qe.id = id
+			}
+		}
+		if !e.enqueue(qe) {
+			if qe.id == 0 {
+				log.Printf("[Webhook] Queue full, dropping event for %s", ep.URL)
+				e.record(ep.URL, func(s *EndpointStats) { s.Dropped++ })
+				continue
+			}
+			log.Printf("[Webhook] Queue full, deferring persisted event for %s", ep.URL)
+		}
+		e.record(ep.URL, func(s *EndpointStats) { s.Enqueued++ })
+	}
+}
 
+// enqueue hands an event to the workers without blocking. Persisted events
+// are tracked as in flight so the refill loop does not queue them twice.
+func (e *Emitter) enqueue(qe *queuedEvent) bool {
+	if qe.id != 0 {
+		e.inflightMu.Lock()
+		defer e.inflightMu.Unlock()
+		if e.inflight[qe.id] {
+			return true
+		}
+	}
 	select {
-	case e.queue <- &queuedEvent{event: event, retries: 0}:
+	case e.queue <- qe:
+		if qe.id != 0 {
+			e.inflight[qe.id] = true
+		}
+		return true
 	default:
-		log.Println("[Webhook] Queue full, dropping event")
+		return false
+	}
+}
+
+// finish removes a persisted event from the store once it is delivered.
+func (e *Emitter) finish(qe *queuedEvent) {
+	if qe.id == 0 {
+		return
 	}
+	if err := e.config.DB.DeleteWebhookEvent(qe.id); err != nil {
+		log.Printf("[Webhook] Failed to remove queued event %d: %v", qe.id, err)
+	}
+	e.release(qe)
+}
+
+// release clears the in-flight mark so the refill loop may queue the event again.
+func (e *Emitter) release(qe *queuedEvent) {
+	if qe.id == 0 {
+		return
+	}
+	e.inflightMu.Lock()
+	delete(e.inflight, qe.id)
+	e.inflightMu.Unlock()
+}
+
+// refillLoop queues persisted events left over from a previous run or
+// deferred because the in-memory queue was full.
+func (e *Emitter) refillLoop() {
+	defer e.refillWG.Done()
+
+	ticker := time.NewTicker(refillInterval)
+	defer ticker.Stop()
+
+	for {
+		e.refill()
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refill loads pending events from the store until the queue is full.
+func (e *Emitter) refill() {
+	free := cap(e.queue) - len(e.queue)
+	if free <= 0 {
+		return
+	}
+	e.inflightMu.Lock()
+	limit := free + len(e.inflight)
+	e.inflightMu.Unlock()
+
+	items, err := e.config.DB.PendingWebhookEvents(limit)
+	if err != nil {
+		log.Printf("[Webhook] Failed to load queued events: %v", err)
+		return
+	}
+
+	restored := 0
+	for _, it := range items {
+		if e.ctx.Err() != nil {
+			return
+		}
+		ep, ok := e.endpoint(it.EndpointURL)
+		if !ok {
+			// Keep the event for replay once the endpoint is configured
+			// again, but out of the queue so it doesn't block others.
+			log.Printf("[Webhook] Dead-lettering queued event %d for unconfigured endpoint %s", it.ID, it.EndpointURL)
+			if err := e.config.DB.DeadLetterWebhookEvent(it.ID, 0, "endpoint not configured"); err != nil {
+				log.Printf("[Webhook] Failed to dead-letter event %d: %v", it.ID, err)
+				continue
+			}
+			e.record(it.EndpointURL, func(s *EndpointStats) { s.DeadLettered++ })
+			continue
+		}
+		e.inflightMu.Lock()
+		queued := e.inflight[it.ID]
+		e.inflightMu.Unlock()
+		if queued {
+			continue
+		}
+		if !e.enqueue(&queuedEvent{id: it.ID, endpoint: ep, eventType: it.EventType, payload: it.Payload}) {
+			break
+		}
+		restored++
+	}
+	if restored > 0 {
+		log.Printf("[Webhook] Queued %d persisted events", restored)
+	}
+}
+
+// endpoint looks up a configured endpoint by URL.
+func (e *Emitter) endpoint(url string) (Endpoint, bool) {
+	for _, ep := range e.config.Endpoints {
+		if ep.URL == url {
+			return ep, true
+		}
+	}
+	return Endpoint{}, false
 }
 
 // worker processes events from the queue.
@@ -125,54 +412,90 @@ func (e *Emitter) worker() {
 
 // deliver attempts to send the webhook with retries.
 func (e *Emitter) deliver(qe *queuedEvent) {
-	payload, err := json.Marshal(qe.event)
-	if err != nil {
-		log.Printf("[Webhook] Failed to marshal event: %v", err)
-		return
-	}
-
+	var lastErr error
 	for attempt := 0; attempt <= qe.retries+e.config.MaxRetries; attempt++ {
 		if attempt > 0 {
 			// Exponential backoff: 1s, 2s, 4s, ...
-			backoff := time.Duration(1<<(attempt-1)) * time.Second
+			backoff := time.Duration(1<<(attempt-1)) * retryBackoff
 			if backoff > 30*time.Second {
 				backoff = 30 * time.Second
 			}
 			select {
 			case <-e.ctx.Done():
+				// Shutting down: keep the persisted event for the next start.
+				e.release(qe)
 				return
 			case <-time.After(backoff):
 			}
 		}
 
-		err := e.send(payload)
+		start := time.Now()
+		err := e.send(qe.endpoint, qe.payload)
+		if err != nil && e.ctx.Err() != nil {
+			// Shutting down mid-request: not a failure of the endpoint.
+			e.release(qe)
+			return
+		}
+		e.observe(qe.endpoint.URL, attempt, time.Since(start), err)
 		if err == nil {
 			if attempt > 0 {
-				log.Printf("[Webhook] Event %s delivered after %d retries", qe.event.Type, attempt)
+				log.Printf("[Webhook] Event %s delivered to %s after %d retries", qe.eventType, qe.endpoint.URL, attempt)
 			}
+			e.finish(qe)
 			return
 		}
 
-		log.Printf("[Webhook] Delivery attempt %d failed: %v", attempt+1, err)
+		lastErr = err
+		log.Printf("[Webhook] Delivery attempt %d to %s failed: %v", attempt+1, qe.endpoint.URL, err)
 	}
 
-	log.Printf("[Webhook] Event %s dropped after %d attempts", qe.event.Type, e.config.MaxRetries+1)
+	attempts := qe.retries + e.config.MaxRetries + 1
+	if qe.id == 0 {
+		log.Printf("[Webhook] Event %s for %s dropped after %d attempts", qe.eventType, qe.endpoint.URL, attempts)
+		e.record(qe.endpoint.URL, func(s *EndpointStats) { s.Dropped++ })
+		return
+	}
+	log.Printf("[Webhook] Event %s for %s dead-lettered after %d attempts", qe.eventType, qe.endpoint.URL, attempts)
+	if err := e.config.DB.DeadLetterWebhookEvent(qe.id, attempts, lastErr.Error()); err != nil {
+		log.Printf("[Webhook] Failed to dead-letter event %d: %v", qe.id, err)
+		e.record(qe.endpoint.URL, func(s *EndpointStats) { s.Dropped++ })
+		e.finish(qe)
+		return
+	}
+	e.record(qe.endpoint.URL, func(s *EndpointStats) { s.DeadLettered++ })
+	e.release(qe)
 }
 
 // send performs the actual HTTP request.
-func (e *Emitter) send(payload []byte) error {
-	req, err := http.NewRequestWithContext(e.ctx, http.MethodPost, e.config.URL, bytes.NewReader(payload))
+func (e *Emitter) send(ep Endpoint, payload []byte) error {
+	req, err := http.NewRequestWithContext(e.ctx, http.MethodPost, ep.URL, bytes.NewReader(payload))
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
 
+	for k, v := range ep.Headers {
+		req.Header.Set(k, v)
+	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "wasvc-webhook/1.0")
 
-	// Add HMAC signature if secret is configured
-	if e.config.Secret != "" {
-		signature := computeHMAC(payload, e.config.Secret)
-		req.Header.Set("X-Webhook-Signature", signature)
+	// Sign the delivery time along with the payload so receivers can reject
+	// replayed deliveries.
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(TimestampHeader, ts)
+	if ep.Secret != "" {
+		// During a rotation the header lists one signature per secret.
+		var signatures []string
+		for _, secret := range []string{ep.Secret, ep.SecondarySecret} {
+			switch {
+			case secret == "":
+			case e.config.SignTimestamp:
+				signatures = append(signatures, SignTimestamped(ts, payload, secret))
+			default:
+				signatures = append(signatures, Sign(payload, secret))
+			}
+		}
+		req.Header.Set(e.config.SignatureHeader, strings.Join(signatures, ","))
 	}
 
 	resp, err := e.client.Do(req)
@@ -188,14 +511,75 @@ func (e *Emitter) send(payload []byte) error {
 	return nil
 }
 
-// computeHMAC generates an HMAC-SHA256 signature.
-func computeHMAC(payload []byte, secret string) string {
+// Sign generates an HMAC-SHA256 signature of payload.
+func Sign(payload []byte, secret string) string {
 	h := hmac.New(sha256.New, []byte(secret))
 	h.Write(payload)
 	return "sha256=" + hex.EncodeToString(h.Sum(nil))
 }
 
-// IsConfigured returns true if a webhook URL is set.
+// SignTimestamped generates the signature sent with Config.SignTimestamp:
+// an HMAC-SHA256 of the timestamp header value, a dot and the payload.
+func SignTimestamped(timestamp string, payload []byte, secret string) string {
+	return Sign(append([]byte(timestamp+"."), payload...), secret)
+}
+
+// IsConfigured returns true if at least one webhook endpoint is set.
 func (e *Emitter) IsConfigured() bool {
-	return e.config.URL != ""
+	return len(e.config.Endpoints) > 0
+}
+
+// ChatFilter returns the chat filter in effect for an endpoint, and whether
+// it was set at runtime rather than configured.
+func (e *Emitter) ChatFilter(url string) (ChatFilter, bool) {
+	e.filtersMu.RLock()
+	f, ok := e.filters[url]
+	e.filtersMu.RUnlock()
+	if ok {
+		return f, true
+	}
+	ep, _ := e.endpoint(url)
+	return ep.ChatFilter, false
+}
+
+// SetChatFilter replaces the chat filter of an endpoint until it is reset,
+// persisting it when the emitter has a store.
+func (e *Emitter) SetChatFilter(url string, f ChatFilter) error {
+	if _, ok := e.endpoint(url); !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownEndpoint, url)
+	}
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	if e.config.DB != nil {
+		if err := e.config.DB.SetWebhookChatFilter(store.WebhookChatFilter{EndpointURL: url, Chats: f.Chats, ExcludeChats: f.ExcludeChats}); err != nil {
+			return err
+		}
+	}
+	e.filtersMu.Lock()
+	e.filters[url] = f
+	e.filtersMu.Unlock()
+	return nil
+}
+
+// ResetChatFilter drops the runtime chat filter of an endpoint, going back
+// to the configured one.
+func (e *Emitter) ResetChatFilter(url string) error {
+	if _, ok := e.endpoint(url); !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownEndpoint, url)
+	}
+	if e.config.DB != nil {
+		if _, err := e.config.DB.DeleteWebhookChatFilter(url); err != nil {
+			return err
+		}
+	}
+	e.filtersMu.Lock()
+	delete(e.filters, url)
+	e.filtersMu.Unlock()
+	return nil
+}
+
+// Endpoints returns the configured webhook endpoints.
+func (e *Emitter) Endpoints() []Endpoint {
+	return e.config.Endpoints
 }
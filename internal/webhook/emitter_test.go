@@ -0,0 +1,257 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+func openTestStore(t *testing.T) *store.DB {
+	t.Helper()
+	db, err := store.Open(filepath.Join(t.TempDir(), "wacli.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// receiver is a webhook endpoint recording the deliveries it accepts.
+type receiver struct {
+	*httptest.Server
+	mu     sync.Mutex
+	status int // response status; 200 if zero
+	got    []*http.Request
+	bodies [][]byte
+}
+
+func newReceiver(t *testing.T) *receiver {
+	rc := &receiver{}
+	rc.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rc.mu.Lock()
+		defer rc.mu.Unlock()
+		rc.got = append(rc.got, r)
+		rc.bodies = append(rc.bodies, body)
+		if rc.status != 0 {
+			w.WriteHeader(rc.status)
+		}
+	}))
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+func (rc *receiver) count() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.got)
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func pending(t *testing.T, db *store.DB) []store.WebhookQueueItem {
+	t.Helper()
+	items, err := db.PendingWebhookEvents(100)
+	if err != nil {
+		t.Fatalf("PendingWebhookEvents: %v", err)
+	}
+	return items
+}
+
+func TestEmitterRedeliversPersistedEventsAfterRestart(t *testing.T) {
+	db := openTestStore(t)
+	rc := newReceiver(t)
+
+	// The first run queues the event but stops before delivering it.
+	first := NewEmitter(Config{URL: rc.URL, DB: db})
+	first.Emit("message.received", map[string]string{"chat_jid": "123@s.whatsapp.net"})
+	first.Stop()
+	if n := len(pending(t, db)); n != 1 || rc.count() != 0 {
+		t.Fatalf("after stop: %d pending, %d delivered; want 1, 0", n, rc.count())
+	}
+
+	second := NewEmitter(Config{URL: rc.URL, DB: db})
+	second.Start()
+	defer second.Stop()
+	waitFor(t, "redelivery", func() bool { return rc.count() == 1 })
+	waitFor(t, "queue row removal", func() bool { return len(pending(t, db)) == 0 })
+
+	var ev Event
+	if err := json.Unmarshal(rc.bodies[0], &ev); err != nil || ev.Type != "message.received" {
+		t.Fatalf("redelivered %s (%v)", rc.bodies[0], err)
+	}
+}
+
+func TestEmitterDoesNotQueueInflightEventTwice(t *testing.T) {
+	db := openTestStore(t)
+	rc := newReceiver(t)
+
+	e := NewEmitter(Config{URL: rc.URL, DB: db})
+	e.Emit("message.received", map[string]string{"chat_jid": "123@s.whatsapp.net"})
+	items := pending(t, db)
+	if len(items) != 1 || len(e.queue) != 1 {
+		t.Fatalf("after emit: %d pending, %d queued; want 1, 1", len(items), len(e.queue))
+	}
+
+	// The refill loop sees the persisted row but it is already in flight.
+	e.refill()
+	e.refill()
+	if !e.enqueue(&queuedEvent{id: items[0].ID, endpoint: Endpoint{URL: rc.URL}, payload: items[0].Payload}) || len(e.queue) != 1 {
+		t.Fatalf("in-flight event queued again: %d queued", len(e.queue))
+	}
+
+	e.Start()
+	defer e.Stop()
+	waitFor(t, "delivery", func() bool { return len(pending(t, db)) == 0 })
+	e.refill()
+	time.Sleep(100 * time.Millisecond)
+	if n := rc.count(); n != 1 {
+		t.Fatalf("delivered %d times, want once", n)
+	}
+}
+
+func TestEmitterDeadLettersAfterExhaustedRetries(t *testing.T) {
+	defer func(d time.Duration) { retryBackoff = d }(retryBackoff)
+	retryBackoff = time.Millisecond
+
+	db := openTestStore(t)
+	rc := newReceiver(t)
+	rc.status = http.StatusInternalServerError
+
+	e := NewEmitter(Config{URL: rc.URL, DB: db, MaxRetries: 2})
+	e.Start()
+	defer e.Stop()
+	e.Emit("message.received", map[string]string{"chat_jid": "123@s.whatsapp.net"})
+
+	var dead []store.WebhookDeadLetter
+	waitFor(t, "dead letter", func() bool {
+		var err error
+		dead, err = db.ListWebhookDeadLetters(10)
+		if err != nil {
+			t.Fatalf("ListWebhookDeadLetters: %v", err)
+		}
+		return len(dead) == 1
+	})
+	if dead[0].Attempts != 3 || dead[0].EndpointURL != rc.URL || !strings.Contains(dead[0].LastError, "500") {
+		t.Fatalf("dead letter = %+v; want 3 attempts at %s with the 500 status", dead[0], rc.URL)
+	}
+	if n := rc.count(); n != 3 {
+		t.Fatalf("attempted %d deliveries, want 3", n)
+	}
+	if n := len(pending(t, db)); n != 0 {
+		t.Fatalf("%d events still pending after dead-lettering", n)
+	}
+	if s := e.Stats().Endpoints[0]; s.DeadLettered != 1 {
+		t.Fatalf("stats = %+v, want one dead letter", s)
+	}
+}
+
+func TestEmitterRefillDeadLettersUnconfiguredEndpoint(t *testing.T) {
+	db := openTestStore(t)
+	rc := newReceiver(t)
+
+	// Queued while "http://gone.example/hook" was still configured.
+	if _, err := db.EnqueueWebhookEvent("http://gone.example/hook", "message.received", []byte(`{}`)); err != nil {
+		t.Fatalf("EnqueueWebhookEvent: %v", err)
+	}
+
+	e := NewEmitter(Config{URL: rc.URL, DB: db})
+	e.refill()
+	if len(e.queue) != 0 {
+		t.Fatalf("queued %d events for an unconfigured endpoint", len(e.queue))
+	}
+	if n := len(pending(t, db)); n != 0 {
+		t.Fatalf("%d events still pending", n)
+	}
+	dead, err := db.ListWebhookDeadLetters(10)
+	if err != nil {
+		t.Fatalf("ListWebhookDeadLetters: %v", err)
+	}
+	if len(dead) != 1 || dead[0].EndpointURL != "http://gone.example/hook" || dead[0].LastError != "endpoint not configured" {
+		t.Fatalf("dead letters = %+v", dead)
+	}
+}
+
+// hmacHex is the HMAC-SHA256 of msg under secret, as sent in signatures.
+func hmacHex(secret, msg string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(msg))
+	return "sha256=" + hex.EncodeToString(h.Sum(nil))
+}
+
+// deliverOne emits an event through an emitter for cfg with the single
+// endpoint ep and returns the request and body the receiver got.
+func deliverOne(t *testing.T, cfg Config, ep Endpoint) (*http.Request, []byte) {
+	t.Helper()
+	rc := newReceiver(t)
+	ep.URL = rc.URL
+	cfg.Endpoints = []Endpoint{ep}
+	e := NewEmitter(cfg)
+	e.Start()
+	defer e.Stop()
+	e.Emit("message.received", map[string]string{"chat_jid": "123@s.whatsapp.net"})
+	waitFor(t, "delivery", func() bool { return rc.count() == 1 })
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.got[0], rc.bodies[0]
+}
+
+func TestEmitterSignsTimestamp(t *testing.T) {
+	r, body := deliverOne(t, Config{SignTimestamp: true, SignatureHeader: "X-Hub-Signature-256"}, Endpoint{Secret: "s3cret"})
+
+	ts := r.Header.Get(TimestampHeader)
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || time.Since(time.Unix(sec, 0)).Abs() > time.Minute {
+		t.Fatalf("%s = %q, want the current unix time", TimestampHeader, ts)
+	}
+	if got := r.Header.Get(DefaultSignatureHeader); got != "" {
+		t.Fatalf("default signature header set to %q despite SignatureHeader", got)
+	}
+	if got, want := r.Header.Get("X-Hub-Signature-256"), hmacHex("s3cret", ts+"."+string(body)); got != want {
+		t.Fatalf("signature = %q, want %q over timestamp.body", got, want)
+	}
+}
+
+func TestEmitterSignsWithBothSecretsDuringRotation(t *testing.T) {
+	r, body := deliverOne(t, Config{}, Endpoint{Secret: "new", SecondarySecret: "old"})
+	sigs := strings.Split(r.Header.Get(DefaultSignatureHeader), ",")
+	if len(sigs) != 2 {
+		t.Fatalf("signature header %q, want two comma-separated signatures", r.Header.Get(DefaultSignatureHeader))
+	}
+	for i, secret := range []string{"new", "old"} {
+		if want := hmacHex(secret, string(body)); sigs[i] != want {
+			t.Fatalf("signature %d = %q, want %q for secret %q", i, sigs[i], want, secret)
+		}
+	}
+
+	r, body = deliverOne(t, Config{}, Endpoint{Secret: "new"})
+	if got, want := r.Header.Get(DefaultSignatureHeader), hmacHex("new", string(body)); got != want {
+		t.Fatalf("single secret: signature header %q, want %q", got, want)
+	}
+
+	r, _ = deliverOne(t, Config{}, Endpoint{})
+	if got := r.Header.Get(DefaultSignatureHeader); got != "" {
+		t.Fatalf("no secret: signature header %q, want none", got)
+	}
+}
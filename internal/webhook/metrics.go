@@ -0,0 +1,134 @@
+package webhook
+
+import (
+	"math"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds of the delivery latency histogram.
+// Slower requests land in a final overflow bucket.
+var LatencyBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+}
+
+// Stats is a snapshot of the emitter's delivery metrics since it started.
+type Stats struct {
+	QueueDepth int // deliveries waiting in memory for a worker
+	Endpoints  []EndpointStats
+}
+
+// EndpointStats are the delivery counters of one webhook endpoint.
+type EndpointStats struct {
+	URL          string
+	Enqueued     int64 // events accepted for delivery
+	Delivered    int64 // events the endpoint acknowledged with a 2xx
+	Retried      int64 // delivery attempts after the first
+	Failed       int64 // attempts that errored or got a non-2xx status
+	Dropped      int64 // events lost to a full queue or exhausted retries without a store
+	DeadLettered int64 // events moved to the dead-letter table
+	Latency      Histogram
+}
+
+// Histogram counts delivery request durations by LatencyBuckets.
+type Histogram struct {
+	Counts []int64 // per bucket, not cumulative; the last entry is the overflow bucket
+	Count  int64
+	Sum    time.Duration
+}
+
+func newHistogram() Histogram {
+	return Histogram{Counts: make([]int64, len(LatencyBuckets)+1)}
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	i := 0
+	for i < len(LatencyBuckets) && d > LatencyBuckets[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Count++
+	h.Sum += d
+}
+
+func (h Histogram) clone() Histogram {
+	h.Counts = append([]int64(nil), h.Counts...)
+	return h
+}
+
+// Mean returns the average request duration.
+func (h Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile estimates the q-quantile (0 < q <= 1) as the upper bound of the
+// bucket it falls in. Durations in the overflow bucket report the largest
+// bound.
+func (h Histogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(q * float64(h.Count)))
+	var seen int64
+	for i, n := range h.Counts {
+		seen += n
+		if seen >= rank && i < len(LatencyBuckets) {
+			return LatencyBuckets[i]
+		}
+	}
+	return LatencyBuckets[len(LatencyBuckets)-1]
+}
+
+// record updates the counters of an endpoint.
+func (e *Emitter) record(url string, update func(*EndpointStats)) {
+	e.metricsMu.Lock()
+	defer e.metricsMu.Unlock()
+	s, ok := e.metrics[url]
+	if !ok {
+		s = &EndpointStats{URL: url, Latency: newHistogram()}
+		e.metrics[url] = s
+	}
+	update(s)
+}
+
+// observe records the outcome and duration of one delivery attempt.
+func (e *Emitter) observe(url string, attempt int, took time.Duration, err error) {
+	e.record(url, func(s *EndpointStats) {
+		if attempt > 0 {
+			s.Retried++
+		}
+		if err == nil {
+			s.Delivered++
+		} else {
+			s.Failed++
+		}
+		s.Latency.observe(took)
+	})
+}
+
+// Stats returns the delivery metrics of every configured endpoint, in
+// configuration order.
+func (e *Emitter) Stats() Stats {
+	st := Stats{QueueDepth: len(e.queue), Endpoints: make([]EndpointStats, len(e.config.Endpoints))}
+	e.metricsMu.Lock()
+	defer e.metricsMu.Unlock()
+	for i, ep := range e.config.Endpoints {
+		if s, ok := e.metrics[ep.URL]; ok {
+			st.Endpoints[i] = *s
+			st.Endpoints[i].Latency = s.Latency.clone()
+		} else {
+			st.Endpoints[i] = EndpointStats{URL: ep.URL, Latency: newHistogram()}
+		}
+	}
+	return st
+}
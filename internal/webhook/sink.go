@@ -0,0 +1,159 @@
+package webhook
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// sinkQueueSize bounds the events a Sink holds before dropping new ones.
+const sinkQueueSize = 1000
+
+// sinkDrainTimeout bounds how long Stop keeps publishing queued events.
+var sinkDrainTimeout = 5 * time.Second
+
+// SinkConfig configures a Sink.
+type SinkConfig struct {
+	Name    string   // log prefix, e.g. "NATS"
+	Events  []string // event filter; empty means all, "group.*" matches a prefix
+	Workers int      // events published concurrently; 1 if zero
+
+	// Filter, when set, decides which events are queued instead of Events.
+	Filter func(eventType string) bool
+
+	// Publish delivers one event; a returned error is logged. ctx is
+	// cancelled when the sink stops.
+	Publish func(ctx context.Context, event *Event) error
+	// PublishBatch, used instead of Publish when set, delivers up to
+	// MaxBatch events that were queued together.
+	PublishBatch func(ctx context.Context, events []*Event) error
+	MaxBatch     int
+}
+
+// Sink feeds events to a publisher other than the HTTP webhooks, such as a
+// message broker or a local command. It filters events by type, queues them
+// without blocking the caller, dropping them when the queue is full, and
+// publishes them from background workers.
+type Sink struct {
+	config SinkConfig
+	queue  chan *Event
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.RWMutex
+	stopped bool // queue is closed
+}
+
+// NewSink creates a sink; Start launches its workers.
+func NewSink(cfg SinkConfig) *Sink {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.MaxBatch <= 0 {
+		cfg.MaxBatch = 1
+	}
+	if cfg.Filter == nil {
+		events := cfg.Events
+		cfg.Filter = func(eventType string) bool { return MatchEvent(events, eventType) }
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Sink{
+		config: cfg,
+		queue:  make(chan *Event, sinkQueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start launches the workers.
+func (s *Sink) Start() {
+	for i := 0; i < s.config.Workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+}
+
+// Stop publishes the events still queued, giving up on them after
+// sinkDrainTimeout, and waits for the workers to exit. Events emitted
+// afterwards are dropped.
+func (s *Sink) Stop() {
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(sinkDrainTimeout):
+		log.Printf("[%s] Gave up publishing %d queued events", s.config.Name, len(s.queue))
+		s.cancel()
+		<-done
+	}
+	s.cancel()
+}
+
+// Emit queues an event if it matches the filter.
+func (s *Sink) Emit(eventType string, data interface{}) {
+	if !s.config.Filter(eventType) {
+		return
+	}
+	event := &Event{
+		Type:      eventType,
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.stopped {
+		return
+	}
+	select {
+	case s.queue <- event:
+	default:
+		log.Printf("[%s] Queue full, dropping %s event", s.config.Name, eventType)
+	}
+}
+
+func (s *Sink) worker() {
+	defer s.wg.Done()
+
+	for event := range s.queue {
+		if s.ctx.Err() != nil {
+			continue // gave up draining
+		}
+		if s.config.PublishBatch == nil {
+			if err := s.config.Publish(s.ctx, event); err != nil {
+				log.Printf("[%s] Failed to publish %s: %v", s.config.Name, event.Type, err)
+			}
+			continue
+		}
+
+		batch := []*Event{event}
+	fill:
+		for len(batch) < s.config.MaxBatch {
+			select {
+			case next, ok := <-s.queue:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, next)
+			default:
+				break fill
+			}
+		}
+		if err := s.config.PublishBatch(s.ctx, batch); err != nil {
+			log.Printf("[%s] Failed to publish %d events: %v", s.config.Name, len(batch), err)
+		}
+	}
+}
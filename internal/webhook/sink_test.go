@@ -0,0 +1,70 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSinkDrainsQueuedEventsOnStop(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]string
+	s := NewSink(SinkConfig{
+		Name:     "Test",
+		Events:   []string{"message.*"},
+		MaxBatch: 2,
+		PublishBatch: func(_ context.Context, events []*Event) error {
+			mu.Lock()
+			defer mu.Unlock()
+			var types []string
+			for _, ev := range events {
+				types = append(types, ev.Type)
+			}
+			batches = append(batches, types)
+			return nil
+		},
+	})
+
+	// Queued before the workers run, so they are batched together.
+	for _, eventType := range []string{"message.received", "group.joined", "message.sent", "message.revoked"} {
+		s.Emit(eventType, nil)
+	}
+	s.Start()
+	s.Stop()
+	s.Emit("message.received", nil)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 || batches[1][0] != "message.revoked" {
+		t.Fatalf("batches = %v, want [[message.received message.sent] [message.revoked]]", batches)
+	}
+}
+
+func TestSinkStopGivesUpDraining(t *testing.T) {
+	defer func(d time.Duration) { sinkDrainTimeout = d }(sinkDrainTimeout)
+	sinkDrainTimeout = 50 * time.Millisecond
+
+	var published int
+	s := NewSink(SinkConfig{
+		Name: "Test",
+		Publish: func(ctx context.Context, _ *Event) error {
+			published++
+			<-ctx.Done() // a broker that never answers
+			return ctx.Err()
+		},
+	})
+	for i := 0; i < 3; i++ {
+		s.Emit("message.received", nil)
+	}
+	s.Start()
+
+	start := time.Now()
+	s.Stop()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Stop took %s", elapsed)
+	}
+	if published != 1 {
+		t.Fatalf("published %d events, want the remaining ones dropped after the first", published)
+	}
+}